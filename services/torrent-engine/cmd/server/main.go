@@ -19,6 +19,7 @@ import (
 	"torrentstream/internal/domain"
 	"torrentstream/internal/metrics"
 	mongorepo "torrentstream/internal/repository/mongo"
+	"torrentstream/internal/services/events"
 	"torrentstream/internal/services/session/player"
 	sessionmongo "torrentstream/internal/services/session/repository/mongo"
 	"torrentstream/internal/services/torrent/engine/anacrolix"
@@ -32,6 +33,7 @@ import (
 )
 
 func main() {
+	startedAt := time.Now().UTC()
 	cfg := app.LoadConfig()
 	logger := newLogger(cfg.LogLevel, cfg.LogFormat)
 	slog.SetDefault(logger)
@@ -79,11 +81,71 @@ func main() {
 	hlsSettingsRepo := mongorepo.NewHLSSettingsRepository(mongoClient, cfg.MongoDatabase)
 	storageSettingsRepo := mongorepo.NewStorageSettingsRepository(mongoClient, cfg.MongoDatabase)
 	playerSettingsRepo := sessionmongo.NewPlayerSettingsRepository(mongoClient, cfg.MongoDatabase)
+	retentionSettingsRepo := mongorepo.NewRetentionSettingsRepository(mongoClient, cfg.MongoDatabase)
 
 	if err := repo.EnsureIndexes(ctx); err != nil {
 		logger.Warn("mongo ensure indexes failed", slog.String("error", err.Error()))
 	}
 
+	statsHistory := &usecase.StatsHistory{Logger: logger}
+	if cfg.StatsHistoryDays > 0 {
+		statsRepo := mongorepo.NewStatsRepository(mongoClient, cfg.MongoDatabase, time.Duration(cfg.StatsHistoryDays)*24*time.Hour)
+		if err := statsRepo.EnsureIndexes(ctx); err != nil {
+			logger.Warn("stats ensure indexes failed", slog.String("error", err.Error()))
+		}
+		statsHistory.Store = statsRepo
+	}
+
+	webhookRepo := mongorepo.NewWebhookRepository(mongoClient, cfg.MongoDatabase)
+	if err := webhookRepo.EnsureIndexes(ctx); err != nil {
+		logger.Warn("webhook ensure indexes failed", slog.String("error", err.Error()))
+	}
+
+	eventLogRepo := mongorepo.NewEventLogRepository(mongoClient, cfg.MongoDatabase)
+	if err := eventLogRepo.EnsureIndexes(ctx); err != nil {
+		logger.Warn("event log ensure indexes failed", slog.String("error", err.Error()))
+	}
+	eventLog := &usecase.EventLog{Store: eventLogRepo, Logger: logger}
+
+	if err := watchHistoryRepo.EnsureIndexes(ctx); err != nil {
+		logger.Warn("watch history ensure indexes failed", slog.String("error", err.Error()))
+	}
+	if migrated, err := watchHistoryRepo.MigrateToDefaultProfile(ctx); err != nil {
+		logger.Warn("watch history profile migration failed", slog.String("error", err.Error()))
+	} else if migrated > 0 {
+		logger.Info("moved watch history to the default profile", slog.Int64("positions", migrated))
+	}
+	profileRepo := sessionmongo.NewProfileRepository(mongoClient, cfg.MongoDatabase)
+	if err := profileRepo.EnsureIndexes(ctx); err != nil {
+		logger.Warn("profile ensure indexes failed", slog.String("error", err.Error()))
+	}
+	profilesUC := &usecase.Profiles{Store: profileRepo, History: watchHistoryRepo}
+
+	var authUC *usecase.Auth
+	if cfg.AuthEnabled {
+		userRepo := mongorepo.NewUserRepository(mongoClient, cfg.MongoDatabase)
+		if err := userRepo.EnsureIndexes(ctx); err != nil {
+			logger.Warn("user ensure indexes failed", slog.String("error", err.Error()))
+		}
+		authUC = &usecase.Auth{
+			Store:      userRepo,
+			SessionTTL: time.Duration(cfg.AuthSessionHours) * time.Hour,
+			Logger:     logger,
+		}
+		password, created, err := authUC.Bootstrap(ctx, cfg.AuthAdminUser, cfg.AuthAdminPassword)
+		if err != nil {
+			logger.Error("auth bootstrap failed", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		if created && cfg.AuthAdminPassword == "" {
+			logger.Warn("created first admin with a generated password; change it after logging in",
+				slog.String("username", cfg.AuthAdminUser),
+				slog.String("password", password))
+		} else if created {
+			logger.Info("created first admin", slog.String("username", cfg.AuthAdminUser))
+		}
+	}
+
 	if enc, ok, err := encodingSettingsRepo.GetEncodingSettings(ctx); err != nil {
 		logger.Warn("encoding settings load failed", slog.String("error", err.Error()))
 	} else if ok {
@@ -123,6 +185,19 @@ func main() {
 		}
 	}
 
+	retentionPolicy := domain.RetentionPolicy{
+		WatchedDays:   cfg.RetentionWatchedDays,
+		UntouchedDays: cfg.RetentionUntouchedDays,
+		MaxTotalBytes: cfg.RetentionMaxBytes,
+		KeepTags:      cfg.RetentionKeepTags,
+		DryRun:        cfg.RetentionDryRun,
+	}
+	if policy, ok, err := retentionSettingsRepo.GetRetentionPolicy(ctx); err != nil {
+		logger.Warn("retention settings load failed", slog.String("error", err.Error()))
+	} else if ok {
+		retentionPolicy = policy
+	}
+
 	currentTorrentID := domain.TorrentID("")
 	if id, ok, err := playerSettingsRepo.GetCurrentTorrentID(ctx); err != nil {
 		logger.Warn("player settings load failed", slog.String("error", err.Error()))
@@ -135,10 +210,20 @@ func main() {
 	} else if ok {
 		prioritizeActiveFileOnly = enabled
 	}
+	// The player state stored before profiles existed becomes the default
+	// profile's.
+	if err := profilesUC.EnsureDefault(ctx, currentTorrentID, prioritizeActiveFileOnly); err != nil {
+		logger.Warn("default profile setup failed", slog.String("error", err.Error()))
+	}
+
+	// Engine state changes are pushed to subscribers (sync, metrics, WS).
+	eventBus := events.NewBus(logger)
 
 	engine, err := anacrolix.New(anacrolix.Config{
-		DataDir:     cfg.TorrentDataDir,
-		MaxSessions: cfg.MaxSessions,
+		DataDir:          cfg.TorrentDataDir,
+		MaxSessions:      cfg.MaxSessions,
+		BanBadPeersAfter: cfg.BanBadPeersAfter,
+		Events:           eventBus,
 	})
 	if err != nil {
 		logger.Error("torrent engine init failed", slog.String("error", err.Error()))
@@ -152,32 +237,186 @@ func main() {
 	}()
 
 	// Start background state sync.
-	syncUC := usecase.SyncState{Engine: engine, Repo: repo, Logger: logger}
+	transferStats := &usecase.TransferStats{
+		Engine:    engine,
+		Repo:      repo,
+		Store:     mongorepo.NewTransferCountersRepository(mongoClient, cfg.MongoDatabase),
+		StartedAt: startedAt,
+		Logger:    logger,
+	}
+	// State changes are stored on events; the periodic sync catches up on
+	// download progress.
+	syncUC := usecase.SyncState{Engine: engine, Repo: repo, Logger: logger, Interval: 30 * time.Second, Stats: transferStats}
 	go syncUC.Run(rootCtx)
 
+	// Storage roots: the data directory plus TORRENT_STORAGE_ROOTS.
+	placement := &usecase.StoragePlacement{
+		Roots:      cfg.StorageRoots,
+		Policy:     cfg.StoragePlacement,
+		Categories: cfg.StorageCategories,
+		Repo:       repo,
+	}
+
 	// Start disk pressure monitor.
-	if cfg.MinDiskSpaceBytes > 0 {
+	pressureStatus := &usecase.DiskPressureStatus{}
+	if cfg.MinDiskSpaceBytes > 0 || rootsHaveMinFree(cfg.StorageRoots) {
 		diskUC := usecase.DiskPressure{
 			Engine:       engine,
 			Logger:       logger,
 			DataDir:      cfg.TorrentDataDir,
 			MinFreeBytes: cfg.MinDiskSpaceBytes,
 			ResumeBytes:  cfg.MinDiskSpaceBytes * 2,
+			Placement:    placement,
+			Status:       pressureStatus,
+			Events:       eventBus,
 		}
 		go diskUC.Run(rootCtx)
 	}
 
-	createUC := usecase.CreateTorrent{Engine: engine, Repo: repo, Now: time.Now}
-	startUC := usecase.StartTorrent{Engine: engine, Repo: repo, Now: time.Now}
-	stopUC := usecase.StopTorrent{Engine: engine, Repo: repo, Now: time.Now}
-	deleteUC := usecase.DeleteTorrent{Engine: engine, Repo: repo, DataDir: cfg.TorrentDataDir}
-	streamUC := &usecase.StreamTorrent{Engine: engine, Repo: repo, ReadaheadBytes: 2 << 20}
+	storageSettings := app.NewStorageSettingsManager(
+		cfg.TorrentDataDir,
+		app.StorageSettings{
+			MaxSessions:       cfg.MaxSessions,
+			MinDiskSpaceBytes: cfg.MinDiskSpaceBytes,
+		},
+		engine,
+		storageSettingsRepo,
+		func(ctx context.Context) (int64, error) {
+			records, err := repo.List(ctx, domain.TorrentFilter{})
+			if err != nil {
+				return 0, err
+			}
+			// Trashed torrents keep their data until they are purged.
+			trashed, err := repo.List(ctx, domain.TorrentFilter{Trashed: true})
+			if err != nil {
+				return 0, err
+			}
+			records = append(records, trashed...)
+			var total int64
+			for _, record := range records {
+				if len(record.Files) == 0 {
+					if record.DoneBytes > 0 {
+						total += record.DoneBytes
+					}
+					continue
+				}
+				for _, file := range record.Files {
+					if file.BytesCompleted > 0 {
+						total += file.BytesCompleted
+					}
+				}
+			}
+			return total, nil
+		},
+	)
+
+	// Pre-flight disk space check for new and resumed downloads.
+	diskSpace := &usecase.DiskSpace{
+		Engine:       engine,
+		DataDir:      cfg.TorrentDataDir,
+		MinFreeBytes: storageSettings.MinDiskSpaceBytes,
+		Placement:    placement,
+	}
+	storageSettings.SetDiskSpaceFunc(func(ctx context.Context) (int64, int64, error) {
+		usage, err := diskSpace.Usage(ctx)
+		return usage.FreeBytes, usage.ReservedBytes, err
+	})
+	if len(cfg.StorageRoots) > 1 {
+		storageSettings.SetStorageRootsFunc(func(ctx context.Context) ([]app.StorageRootUsage, error) {
+			return storageRootsUsage(ctx, placement, diskSpace, pressureStatus)
+		})
+	}
+
+	createUC := usecase.CreateTorrent{Engine: engine, Repo: repo, Now: time.Now, Space: diskSpace, Placement: placement, Metainfo: engine, Events: eventBus}
+	startUC := usecase.StartTorrent{Engine: engine, Repo: repo, Now: time.Now, Space: diskSpace, Events: eventBus}
+	stopUC := usecase.StopTorrent{Engine: engine, Repo: repo, Now: time.Now, Events: eventBus}
+	deleteUC := usecase.DeleteTorrent{
+		Engine:    engine,
+		Repo:      repo,
+		DataDir:   cfg.TorrentDataDir,
+		Placement: placement,
+		Trash:     cfg.TrashRetentionHours > 0,
+		Now:       time.Now,
+		Stats:     statsHistory,
+		Events:    eventBus,
+	}
+	restoreUC := usecase.RestoreTorrent{Engine: engine, Repo: repo, Now: time.Now}
+	webSeedsUC := usecase.WebSeeds{Engine: engine, Repo: repo, Now: time.Now}
+	integrityUC := usecase.Integrity{Engine: engine, Repo: repo, Now: time.Now}
+	streamUC := &usecase.StreamTorrent{
+		Engine:         engine,
+		Repo:           repo,
+		ReadaheadBytes: 2 << 20,
+		Placement:      placement,
+		DataDir:        cfg.TorrentDataDir,
+	}
 	stateUC := usecase.GetTorrentState{Engine: engine}
 	listStateUC := usecase.ListActiveTorrentStates{Engine: engine}
 	mediaProbe := ffprobe.New(cfg.FFProbePath)
 	playerSettings := player.NewPlayerSettingsManager(engine, playerSettingsRepo, currentTorrentID, prioritizeActiveFileOnly)
 	streamUC.PlayerSettings = playerSettings
 
+	// Start retention cleanup.
+	retentionSettings := app.NewRetentionSettingsManager(retentionPolicy, retentionSettingsRepo)
+	// Retention runs to free space, so its deletes skip the trash.
+	retentionDelete := withDeleteReason(deleteUC, domain.ReasonRetention)
+	retentionDelete.Trash = false
+	retentionUC := usecase.Retention{
+		Repo:         repo,
+		WatchHistory: watchHistoryRepo,
+		Engine:       engine,
+		Delete:       retentionDelete,
+		Policy:       retentionSettings.Get,
+		Logger:       logger,
+	}
+	go retentionUC.Run(rootCtx)
+
+	// Purge torrents whose trash retention has elapsed.
+	if cfg.TrashRetentionHours > 0 {
+		trashPurge := usecase.TrashPurge{
+			Repo:      repo,
+			Delete:    withDeleteReason(deleteUC, domain.ReasonTrashExpired),
+			Retention: time.Duration(cfg.TrashRetentionHours) * time.Hour,
+			Logger:    logger,
+		}
+		go trashPurge.Run(rootCtx)
+	}
+
+	var webhooksUC *usecase.Webhooks
+	if cfg.AuthEnabled {
+		webhooksUC = &usecase.Webhooks{
+			Store:  webhookRepo,
+			Repo:   repo,
+			Client: &http.Client{Timeout: 30 * time.Second},
+			Logger: logger,
+		}
+	} else {
+		// Webhooks make the server send requests to any URL, so only admins
+		// may set them.
+		logger.Warn("webhooks disabled: they require TORRENT_AUTH_ENABLED=true")
+	}
+
+	var postProcessUC *usecase.PostProcess
+	if cfg.PostProcessEnabled && !cfg.AuthEnabled {
+		// Commands run programs on the host, so only admins may set them.
+		logger.Warn("post-processing disabled: it requires TORRENT_AUTH_ENABLED=true")
+	}
+	if cfg.PostProcessEnabled && cfg.AuthEnabled {
+		postProcessRepo := mongorepo.NewPostProcessRepository(mongoClient, cfg.MongoDatabase)
+		if err := postProcessRepo.EnsureIndexes(ctx); err != nil {
+			logger.Warn("post-process ensure indexes failed", slog.String("error", err.Error()))
+		}
+		postProcessUC = &usecase.PostProcess{
+			Store:         postProcessRepo,
+			Repo:          repo,
+			Placement:     placement,
+			DataDir:       cfg.TorrentDataDir,
+			Logger:        logger,
+			Timeout:       time.Duration(cfg.PostProcessTimeoutSeconds) * time.Second,
+			MaxConcurrent: cfg.PostProcessMaxConcurrent,
+		}
+	}
+
 	hlsCfg := apihttp.HLSConfig{
 		FFMPEGPath:      cfg.FFMPEGPath,
 		FFProbePath:     cfg.FFProbePath,
@@ -199,50 +438,52 @@ func main() {
 		apihttp.WithStartTorrent(startUC),
 		apihttp.WithStopTorrent(stopUC),
 		apihttp.WithDeleteTorrent(deleteUC),
+		apihttp.WithRestoreTorrent(restoreUC),
+		apihttp.WithWebSeeds(webSeedsUC),
+		apihttp.WithIntegrity(integrityUC),
+		apihttp.WithStatsHistory(statsHistory),
+		apihttp.WithTransferStats(transferStats),
 		apihttp.WithStreamTorrent(streamUC),
+		apihttp.WithArchiveFiles(streamUC),
 		apihttp.WithGetTorrentState(stateUC),
 		apihttp.WithListTorrentStates(listStateUC),
 		apihttp.WithHLS(hlsCfg),
 		apihttp.WithMediaProbe(mediaProbe, cfg.TorrentDataDir),
+		apihttp.WithStorageRoots(placement.Paths()...),
 		apihttp.WithWatchHistory(watchHistoryRepo),
+		apihttp.WithProfiles(profilesUC),
 		apihttp.WithEngine(engine),
 		apihttp.WithPlayerSettings(playerSettings),
-		apihttp.WithStorageSettings(app.NewStorageSettingsManager(
-			cfg.TorrentDataDir,
-			app.StorageSettings{
-				MaxSessions:       cfg.MaxSessions,
-				MinDiskSpaceBytes: cfg.MinDiskSpaceBytes,
-			},
-			engine,
-			storageSettingsRepo,
-			func(ctx context.Context) (int64, error) {
-				records, err := repo.List(ctx, domain.TorrentFilter{})
-				if err != nil {
-					return 0, err
-				}
-				var total int64
-				for _, record := range records {
-					if len(record.Files) == 0 {
-						if record.DoneBytes > 0 {
-							total += record.DoneBytes
-						}
-						continue
-					}
-					for _, file := range record.Files {
-						if file.BytesCompleted > 0 {
-							total += file.BytesCompleted
-						}
-					}
-				}
-				return total, nil
-			},
-		)),
+		apihttp.WithStorageSettings(storageSettings),
+		apihttp.WithRetentionSettings(retentionSettings),
+		apihttp.WithRetention(retentionUC),
+		apihttp.WithEvents(eventBus),
+		apihttp.WithEventLog(eventLog),
 		apihttp.WithAllowedOrigins(cfg.CORSAllowedOrigins),
+		apihttp.WithTrustedProxies(cfg.TrustedProxies),
 	}
 	if cfg.OpenAPIPath != "" {
 		options = append(options, apihttp.WithOpenAPIPath(cfg.OpenAPIPath))
 	}
 
+	if webhooksUC != nil {
+		options = append(options, apihttp.WithWebhooks(webhooksUC))
+	}
+	if postProcessUC != nil {
+		options = append(options, apihttp.WithPostProcess(postProcessUC))
+	}
+	if authUC != nil {
+		options = append(options, apihttp.WithAuth(authUC))
+	}
+	if cfg.QBittorrentAPIEnabled {
+		options = append(options, apihttp.WithQBittorrent(apihttp.QBittorrentConfig{
+			Categories:    repo,
+			CategoryRoots: cfg.StorageCategories,
+		}))
+	}
+	if cfg.TransmissionRPCEnabled {
+		options = append(options, apihttp.WithTransmission())
+	}
 	handler := apihttp.NewServer(createUC, options...)
 
 	// Wire encoding settings manager after server creation (needs HLS engine).
@@ -257,8 +498,55 @@ func main() {
 		handler.SetHLSSettings(hlsMgr)
 	}
 
+	// Flag dead torrents.
+	if cfg.StallTimeoutMinutes > 0 {
+		stallDetector := &usecase.StallDetector{
+			Engine:        engine,
+			Trackers:      engine,
+			Repo:          repo,
+			Stalls:        repo,
+			Stop:          withStopReason(stopUC, domain.ReasonStalled),
+			Notifier:      handler,
+			After:         time.Duration(cfg.StallTimeoutMinutes) * time.Minute,
+			Action:        cfg.StallAction,
+			RetryTrackers: cfg.StallRetryTrackers,
+			Logger:        logger,
+		}
+		go stallDetector.Run(rootCtx)
+	}
+
+	// The record is stored before clients are told, so the torrent list
+	// broadcast after an event already carries the new status.
+	eventBus.Subscribe(rootCtx, "state", func(ctx context.Context, event domain.Event) {
+		syncUC.HandleEvent(ctx, event)
+		handler.HandleEvent(ctx, event)
+	})
+	eventBus.Subscribe(rootCtx, "event-log", eventLog.HandleEvent)
+	spaceCheck := usecase.MetadataSpaceCheck{Engine: engine, Repo: repo, Space: diskSpace, Logger: logger, Events: eventBus}
+	eventBus.Subscribe(rootCtx, "space", spaceCheck.HandleEvent)
+	if webhooksUC != nil {
+		eventBus.Subscribe(rootCtx, "webhooks", webhooksUC.HandleEvent)
+	}
+	if postProcessUC != nil {
+		eventBus.Subscribe(rootCtx, "post-process", postProcessUC.HandleEvent)
+	}
+	if cfg.UnpackEnabled {
+		unpackUC := &usecase.Unpack{
+			Repo:      repo,
+			Extracted: repo,
+			Placement: placement,
+			DataDir:   cfg.TorrentDataDir,
+			Space:     diskSpace,
+			Logger:    logger,
+		}
+		eventBus.Subscribe(rootCtx, "unpack", unpackUC.HandleEvent)
+	}
+	eventBus.Subscribe(rootCtx, "metrics", func(ctx context.Context, event domain.Event) {
+		metrics.TorrentEventsTotal.WithLabelValues(string(event.Type)).Inc()
+	})
+
 	// Periodically update Prometheus gauges from engine state.
-	go updateEngineMetrics(rootCtx, engine, handler.HLSCacheTotalSize, handler)
+	go updateEngineMetrics(rootCtx, engine, handler.HLSCacheTotalSize, handler, statsHistory)
 
 	srv := &http.Server{
 		Addr:              cfg.HTTPAddr,
@@ -303,9 +591,9 @@ func main() {
 	logger.Info("server stopped")
 }
 
-func updateEngineMetrics(ctx context.Context, engine *anacrolix.Engine, cacheSize func() int64, handler *apihttp.Server) {
+func updateEngineMetrics(ctx context.Context, engine *anacrolix.Engine, cacheSize func() int64, handler *apihttp.Server, stats *usecase.StatsHistory) {
 	stateTicker := time.NewTicker(5 * time.Second)
-	torrentTicker := time.NewTicker(15 * time.Second)
+	torrentTicker := time.NewTicker(30 * time.Second) // status changes are pushed on events
 	healthTicker := time.NewTicker(30 * time.Second)
 	defer stateTicker.Stop()
 	defer torrentTicker.Stop()
@@ -341,6 +629,7 @@ func updateEngineMetrics(ctx context.Context, engine *anacrolix.Engine, cacheSiz
 				metrics.HLSCacheSizeBytes.Set(float64(cacheSize()))
 			}
 			handler.BroadcastStates(states)
+			stats.Record(ctx, states)
 		case <-torrentTicker.C:
 			handler.BroadcastTorrents()
 		case <-healthTicker.C:
@@ -418,14 +707,66 @@ func restoreTorrents(ctx context.Context, engine *anacrolix.Engine, repo *mongor
 	wg.Wait()
 }
 
+// withDeleteReason and withStopReason copy a use case so that the torrents
+// it removes or stops are logged with the automatic cause.
+func withDeleteReason(uc usecase.DeleteTorrent, reason string) usecase.DeleteTorrent {
+	uc.Reason = reason
+	return uc
+}
+
+func withStopReason(uc usecase.StopTorrent, reason string) usecase.StopTorrent {
+	uc.Reason = reason
+	return uc
+}
+
+func rootsHaveMinFree(roots []domain.StorageRoot) bool {
+	for _, root := range roots {
+		if root.MinFreeBytes > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// storageRootsUsage merges placement usage with reservations and the latest
+// disk pressure state for the storage settings endpoint.
+func storageRootsUsage(ctx context.Context, placement *usecase.StoragePlacement, space *usecase.DiskSpace, pressure *usecase.DiskPressureStatus) ([]app.StorageRootUsage, error) {
+	usage, err := placement.Usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paused := make(map[string]bool)
+	for _, root := range pressure.Roots() {
+		paused[root.Path] = root.Paused
+	}
+	out := make([]app.StorageRootUsage, 0, len(usage))
+	for _, u := range usage {
+		root := app.StorageRootUsage{
+			Path:           u.Path,
+			QuotaBytes:     u.QuotaBytes,
+			MinFreeBytes:   u.MinFreeBytes,
+			FreeBytes:      u.FreeBytes,
+			UsedBytes:      u.UsedBytes,
+			AvailableBytes: u.AvailableBytes,
+			Torrents:       u.Torrents,
+			Paused:         paused[u.Path],
+		}
+		if spaceUsage, err := space.UsageIn(ctx, u.Path); err == nil {
+			root.ReservedBytes = spaceUsage.ReservedBytes
+		}
+		out = append(out, root)
+	}
+	return out, nil
+}
+
 func newLogger(levelRaw, formatRaw string) *slog.Logger {
 	level := parseLogLevel(levelRaw)
 	options := &slog.HandlerOptions{Level: level}
 	format := strings.ToLower(strings.TrimSpace(formatRaw))
 	if format == "json" {
-		return slog.New(slog.NewJSONHandler(os.Stdout, options))
+		return slog.New(telemetry.NewRedactingHandler(slog.NewJSONHandler(os.Stdout, options)))
 	}
-	return slog.New(slog.NewTextHandler(os.Stdout, options))
+	return slog.New(telemetry.NewRedactingHandler(slog.NewTextHandler(os.Stdout, options)))
 }
 
 func parseLogLevel(raw string) slog.Level {
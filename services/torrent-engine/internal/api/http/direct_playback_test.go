@@ -350,6 +350,43 @@ func TestDirectPlaybackRepoFallback(t *testing.T) {
 	}
 }
 
+func TestDirectPlaybackServesExtractedFile(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("extracted-content")
+	if err := os.WriteFile(filepath.Join(dir, "movie.mp4"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The live session only knows the archive; the record knows what was
+	// extracted from it.
+	state := &fakeGetTorrentState{
+		result: domain.SessionState{
+			Files: []domain.FileRef{{Path: "movie.rar", Length: 100, BytesCompleted: 100}},
+		},
+	}
+	repo := &fakeRepo{
+		get: domain.TorrentRecord{
+			Files: []domain.FileRef{{Index: 0, Path: "movie.rar", Length: 100, BytesCompleted: 100}},
+			Extracted: []domain.FileRef{
+				{Index: 1, Path: "movie.mp4", Length: int64(len(content)), BytesCompleted: int64(len(content))},
+			},
+		},
+	}
+	server := NewServer(&fakeCreateTorrent{},
+		WithMediaProbe(&fakeMediaProbe{}, dir),
+		WithGetTorrentState(state),
+		WithRepository(repo),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/torrents/t1/direct/1", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != string(content) {
+		t.Fatalf("status = %d, body %q", w.Code, w.Body.String())
+	}
+}
+
 func TestDirectPlaybackFileIndexOutOfRange(t *testing.T) {
 	dir := t.TempDir()
 	state := &fakeGetTorrentState{
@@ -523,10 +560,10 @@ func TestResolveFileRefIndexOutOfRange(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// resolveDataFilePath tests
+// joinDataFilePath tests
 // ---------------------------------------------------------------------------
 
-func TestResolveDataFilePath(t *testing.T) {
+func TestJoinDataFilePath(t *testing.T) {
 	dir := t.TempDir()
 	tests := []struct {
 		name     string
@@ -541,7 +578,7 @@ func TestResolveDataFilePath(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := resolveDataFilePath(tc.base, tc.filePath)
+			got, err := joinDataFilePath(tc.base, tc.filePath)
 			if tc.wantErr {
 				if err == nil {
 					t.Fatalf("expected error for %q / %q", tc.base, tc.filePath)
@@ -558,6 +595,34 @@ func TestResolveDataFilePath(t *testing.T) {
 	}
 }
 
+func TestTorrentDataDirUsesRecordRoot(t *testing.T) {
+	primary := t.TempDir()
+	extra := t.TempDir()
+	// The same relative path on another root belongs to another torrent.
+	for _, root := range []string{primary, extra} {
+		if err := os.MkdirAll(filepath.Join(root, "Show"), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "Show", "e01.mkv"), []byte(root), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	repo := &fakeRepo{get: domain.TorrentRecord{ID: "t1", Source: domain.TorrentSource{DataDir: extra}}}
+	server := NewServer(&fakeCreateTorrent{},
+		WithMediaProbe(&fakeMediaProbe{}, primary),
+		WithRepository(repo),
+	)
+
+	if got := server.torrentDataDir(context.Background(), "t1"); got != extra {
+		t.Fatalf("data dir = %q, want %q", got, extra)
+	}
+
+	repo.getErr = domain.ErrNotFound
+	if got := server.torrentDataDir(context.Background(), "t2"); got != primary {
+		t.Fatalf("data dir of unknown torrent = %q, want %q", got, primary)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // StreamJobManager remux tests
 // ---------------------------------------------------------------------------
@@ -0,0 +1,27 @@
+package apihttp
+
+import (
+	"net/http"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+// handleArchiveFiles serves GET /torrents/{id}/archive-files: the files
+// stored uncompressed in the torrent's RAR sets. Their indices stream through
+// /torrents/{id}/stream and /torrents/{id}/hls like torrent files.
+func (s *Server) handleArchiveFiles(w http.ResponseWriter, r *http.Request, id string) {
+	if s.archiveFiles == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "archive streaming not configured")
+		return
+	}
+	files, err := s.archiveFiles.ArchivedFiles(r.Context(), domain.TorrentID(id))
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	if files == nil {
+		files = []usecase.ArchivedFile{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": files, "count": len(files)})
+}
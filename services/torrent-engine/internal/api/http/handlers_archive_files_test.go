@@ -0,0 +1,70 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+type fakeArchiveFiles struct {
+	files []usecase.ArchivedFile
+	err   error
+	id    domain.TorrentID
+}
+
+func (f *fakeArchiveFiles) ArchivedFiles(ctx context.Context, id domain.TorrentID) ([]usecase.ArchivedFile, error) {
+	f.id = id
+	return f.files, f.err
+}
+
+func TestArchiveFilesList(t *testing.T) {
+	uc := &fakeArchiveFiles{files: []usecase.ArchivedFile{{
+		FileRef: domain.FileRef{Index: 3, Path: "Set/movie.mkv", Length: 1000, BytesCompleted: 500, Progress: 0.5},
+		Archive: "Set/set.part1.rar",
+	}}}
+	s := NewServer(nil, WithArchiveFiles(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/archive-files", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got struct {
+		Items []struct {
+			Index    int     `json:"index"`
+			Path     string  `json:"path"`
+			Archive  string  `json:"archive"`
+			Length   int64   `json:"length"`
+			Progress float64 `json:"progress"`
+		} `json:"items"`
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if uc.id != "t1" || got.Count != 1 || got.Items[0].Index != 3 || got.Items[0].Archive != "Set/set.part1.rar" || got.Items[0].Progress != 0.5 {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+
+	uc.err = domain.ErrNotFound
+	rec = doSettingsRequest(s, http.MethodGet, "/torrents/t1/archive-files", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	rec = doSettingsRequest(s, http.MethodPost, "/torrents/t1/archive-files", nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestArchiveFilesNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/archive-files", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+}
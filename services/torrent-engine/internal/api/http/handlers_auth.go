@@ -0,0 +1,349 @@
+package apihttp
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+// Login, API key and user management handlers.
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	User      domain.User `json:"user"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	Password        string `json:"password"`
+}
+
+type apiKeyRequest struct {
+	Name string      `json:"name"`
+	Role domain.Role `json:"role"`
+}
+
+// apiKeyCreatedResponse carries the key itself, which is only shown once.
+type apiKeyCreatedResponse struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+type userRequest struct {
+	Username *string      `json:"username"`
+	Password *string      `json:"password"`
+	Role     *domain.Role `json:"role"`
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.auth == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "authentication not configured")
+		return
+	}
+	var body loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return
+	}
+	keys := s.loginKeys(r, body.Username)
+	if wait := s.logins.wait(keys); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "rate_limited", "too many failed logins, try again later")
+		return
+	}
+	result, err := s.auth.Login(r.Context(), body.Username, body.Password)
+	if errors.Is(err, domain.ErrInvalidCredentials) {
+		s.logins.fail(keys)
+	}
+	if err != nil {
+		writeAuthError(w, err, "user not found")
+		return
+	}
+	s.logins.succeed(keys)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    result.Token,
+		Path:     "/",
+		Expires:  result.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeJSON(w, http.StatusOK, loginResponse{User: result.User, ExpiresAt: result.ExpiresAt})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.auth == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "authentication not configured")
+		return
+	}
+	if err := s.auth.Logout(r.Context(), sessionToken(r)); err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAuthMe returns the caller; PATCH changes the caller's password,
+// which ends all of the caller's sessions.
+func (s *Server) handleAuthMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPatch {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := s.requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, principal)
+		return
+	}
+
+	var body changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return
+	}
+	if err := s.auth.ChangePassword(r.Context(), principal.User.ID, body.CurrentPassword, body.Password); err != nil {
+		writeAuthError(w, err, "user not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := s.requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		keys, err := s.auth.ListAPIKeys(r.Context(), principal.User.ID)
+		if err != nil {
+			writeAuthError(w, err, "api key not found")
+			return
+		}
+		if keys == nil {
+			keys = []domain.APIKey{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": keys, "count": len(keys)})
+		return
+	}
+
+	var body apiKeyRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return
+	}
+	// A key never exceeds the rights it was created with.
+	role := body.Role
+	if role == "" {
+		role = principal.Role
+	}
+	if !principal.Role.Allows(role) {
+		writeError(w, http.StatusForbidden, "forbidden", "role exceeds the caller's role")
+		return
+	}
+	key, secret, err := s.auth.CreateAPIKey(r.Context(), principal.User.ID, body.Name, role)
+	if err != nil {
+		writeAuthError(w, err, "user not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, apiKeyCreatedResponse{APIKey: key, Key: secret})
+}
+
+func (s *Server) handleAPIKeyByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/auth/keys/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := s.requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	if err := s.auth.DeleteAPIKey(r.Context(), principal.User.ID, id); err != nil {
+		writeAuthError(w, err, "api key not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.auth == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "authentication not configured")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		users, err := s.auth.ListUsers(r.Context())
+		if err != nil {
+			writeAuthError(w, err, "user not found")
+			return
+		}
+		if users == nil {
+			users = []domain.User{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": users, "count": len(users)})
+		return
+	}
+
+	body, ok := decodeUserRequest(w, r)
+	if !ok {
+		return
+	}
+	input := usecase.UserInput{Role: domain.RoleUser}
+	if body.Username != nil {
+		input.Username = *body.Username
+	}
+	if body.Password != nil {
+		input.Password = *body.Password
+	}
+	if body.Role != nil {
+		input.Role = *body.Role
+	}
+	user, err := s.auth.CreateUser(r.Context(), input)
+	if err != nil {
+		writeAuthError(w, err, "user not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (s *Server) handleUserByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/users/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+	if s.auth == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "authentication not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		user, err := s.auth.GetUser(r.Context(), id)
+		if err != nil {
+			writeAuthError(w, err, "user not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, user)
+	case http.MethodPatch:
+		body, ok := decodeUserRequest(w, r)
+		if !ok {
+			return
+		}
+		if body.Username != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "username cannot be changed")
+			return
+		}
+		user, err := s.auth.UpdateUser(r.Context(), id, usecase.UserUpdate{Password: body.Password, Role: body.Role})
+		if err != nil {
+			writeAuthError(w, err, "user not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, user)
+	case http.MethodDelete:
+		if err := s.auth.DeleteUser(r.Context(), id); err != nil {
+			writeAuthError(w, err, "user not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// requirePrincipal returns the caller set by authMiddleware. It answers 501
+// when authentication is off.
+func (s *Server) requirePrincipal(w http.ResponseWriter, r *http.Request) (domain.Principal, bool) {
+	if s.auth == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "authentication not configured")
+		return domain.Principal{}, false
+	}
+	principal, ok := principalFrom(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "authentication required")
+		return domain.Principal{}, false
+	}
+	return principal, true
+}
+
+func decodeUserRequest(w http.ResponseWriter, r *http.Request) (userRequest, bool) {
+	var body userRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return body, false
+	}
+	return body, true
+}
+
+// isHTTPS reports whether the client reached the server over TLS, directly
+// or through a proxy.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func writeAuthError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", notFound)
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		writeError(w, http.StatusUnauthorized, "invalid_credentials", "invalid username or password")
+	case errors.Is(err, domain.ErrInvalidUser):
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, domain.ErrAlreadyExists):
+		writeError(w, http.StatusConflict, "already_exists", "username already taken")
+	case errors.Is(err, domain.ErrLastAdmin):
+		writeError(w, http.StatusConflict, "last_admin", err.Error())
+	default:
+		writeDomainError(w, err)
+	}
+}
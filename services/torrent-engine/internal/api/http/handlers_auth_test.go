@@ -0,0 +1,477 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+// fakeAuthUseCase authenticates session tokens and API keys that map
+// directly to principals.
+type fakeAuthUseCase struct {
+	sessions map[string]domain.Principal
+	keys     map[string]domain.Principal
+	users    map[string]domain.User
+	created  []domain.APIKey
+}
+
+func newFakeAuthUseCase() *fakeAuthUseCase {
+	f := &fakeAuthUseCase{
+		sessions: make(map[string]domain.Principal),
+		keys:     make(map[string]domain.Principal),
+		users:    make(map[string]domain.User),
+	}
+	for _, role := range []domain.Role{domain.RoleAdmin, domain.RoleUser, domain.RoleViewer} {
+		user := domain.User{ID: "id-" + string(role), Username: string(role), Role: role}
+		f.users[user.ID] = user
+		f.sessions["session-"+string(role)] = domain.Principal{User: user, Role: role}
+		f.keys["tx_"+string(role)] = domain.Principal{User: user, Role: role, APIKeyID: "key-" + string(role)}
+	}
+	return f
+}
+
+func (f *fakeAuthUseCase) Authenticate(_ context.Context, token string) (domain.Principal, error) {
+	p, ok := f.sessions[token]
+	if !ok {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	return p, nil
+}
+
+func (f *fakeAuthUseCase) AuthenticateKey(_ context.Context, key string) (domain.Principal, error) {
+	p, ok := f.keys[key]
+	if !ok {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	return p, nil
+}
+
+func (f *fakeAuthUseCase) Login(_ context.Context, username, password string) (usecase.LoginResult, error) {
+	if password != "password123" {
+		return usecase.LoginResult{}, domain.ErrInvalidCredentials
+	}
+	p, ok := f.sessions["session-"+username]
+	if !ok {
+		return usecase.LoginResult{}, domain.ErrInvalidCredentials
+	}
+	return usecase.LoginResult{Token: "session-" + username, User: p.User, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func (f *fakeAuthUseCase) Logout(_ context.Context, token string) error {
+	delete(f.sessions, token)
+	return nil
+}
+
+func (f *fakeAuthUseCase) ChangePassword(_ context.Context, id, current, password string) error {
+	if current != "password123" {
+		return domain.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (f *fakeAuthUseCase) ListUsers(_ context.Context) ([]domain.User, error) {
+	var out []domain.User
+	for _, u := range f.users {
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+func (f *fakeAuthUseCase) GetUser(_ context.Context, id string) (domain.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrNotFound
+	}
+	return u, nil
+}
+
+func (f *fakeAuthUseCase) CreateUser(_ context.Context, input usecase.UserInput) (domain.User, error) {
+	for _, u := range f.users {
+		if u.Username == input.Username {
+			return domain.User{}, domain.ErrAlreadyExists
+		}
+	}
+	if len(input.Password) < domain.MinPasswordLength {
+		return domain.User{}, fmt.Errorf("%w: password too short", domain.ErrInvalidUser)
+	}
+	user := domain.User{ID: "id-" + input.Username, Username: input.Username, Role: input.Role}
+	f.users[user.ID] = user
+	return user, nil
+}
+
+func (f *fakeAuthUseCase) UpdateUser(_ context.Context, id string, update usecase.UserUpdate) (domain.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrNotFound
+	}
+	if update.Role != nil {
+		if u.Role == domain.RoleAdmin && *update.Role != domain.RoleAdmin {
+			return domain.User{}, domain.ErrLastAdmin
+		}
+		u.Role = *update.Role
+	}
+	f.users[id] = u
+	return u, nil
+}
+
+func (f *fakeAuthUseCase) DeleteUser(_ context.Context, id string) error {
+	if _, ok := f.users[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.users, id)
+	return nil
+}
+
+func (f *fakeAuthUseCase) ListAPIKeys(_ context.Context, userID string) ([]domain.APIKey, error) {
+	var out []domain.APIKey
+	for _, k := range f.created {
+		if k.UserID == userID {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAuthUseCase) CreateAPIKey(_ context.Context, userID, name string, role domain.Role) (domain.APIKey, string, error) {
+	key := domain.APIKey{ID: fmt.Sprintf("k%d", len(f.created)+1), UserID: userID, Name: name, Prefix: "tx_new", Role: role}
+	f.created = append(f.created, key)
+	return key, "tx_newsecret", nil
+}
+
+func (f *fakeAuthUseCase) DeleteAPIKey(_ context.Context, userID, id string) error {
+	for i, k := range f.created {
+		if k.ID == id && k.UserID == userID {
+			f.created = append(f.created[:i], f.created[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func doAuthRequest(s *Server, method, path, session string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"a","password":"b"}`)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("login: expected 501, got %d", rec.Code)
+	}
+	rec = doAuthRequest(s, http.MethodGet, "/users", "", "")
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("users: expected 501, got %d", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareEnforcesRoles(t *testing.T) {
+	s := NewServer(&fakeCreateTorrent{}, WithAuth(newFakeAuthUseCase()), WithRepository(&fakeRepo{}))
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		session string
+		want    int
+	}{
+		{"anonymous list", http.MethodGet, "/torrents", "", http.StatusUnauthorized},
+		{"unknown session", http.MethodGet, "/torrents", "bogus", http.StatusUnauthorized},
+		{"viewer list", http.MethodGet, "/torrents", "session-viewer", http.StatusOK},
+		{"viewer delete", http.MethodDelete, "/torrents/t1", "session-viewer", http.StatusForbidden},
+		{"viewer start", http.MethodPost, "/torrents/t1/start", "session-viewer", http.StatusForbidden},
+		{"viewer seek", http.MethodPost, "/torrents/t1/hls/0/seek", "session-viewer", 0},
+		{"viewer watch position", http.MethodPut, "/watch-history/t1/0", "session-viewer", http.StatusNotImplemented},
+		{"viewer settings", http.MethodGet, "/settings/hls", "session-viewer", http.StatusForbidden},
+		{"viewer player settings", http.MethodGet, "/settings/player", "session-viewer", http.StatusNotImplemented},
+		{"viewer update player settings", http.MethodPut, "/settings/player", "session-viewer", http.StatusNotImplemented},
+		{"viewer switch profile", http.MethodPost, "/profiles/p1/switch", "session-viewer", http.StatusNotImplemented},
+		{"viewer create profile", http.MethodPost, "/profiles", "session-viewer", http.StatusForbidden},
+		{"user create profile", http.MethodPost, "/profiles", "session-user", http.StatusNotImplemented},
+		{"user settings", http.MethodGet, "/settings/hls", "session-user", http.StatusForbidden},
+		{"user users", http.MethodGet, "/users", "session-user", http.StatusForbidden},
+		{"admin settings", http.MethodGet, "/settings/webhooks", "session-admin", http.StatusNotImplemented},
+		{"admin users", http.MethodGet, "/users", "session-admin", http.StatusOK},
+		{"anonymous ws", http.MethodGet, "/ws", "", http.StatusUnauthorized},
+		{"anonymous history", http.MethodGet, "/watch-history", "", http.StatusUnauthorized},
+		{"anonymous qbittorrent login", http.MethodPost, "/api/v2/auth/login", "", http.StatusNotImplemented},
+		{"anonymous qbittorrent info", http.MethodGet, "/api/v2/torrents/info", "", http.StatusForbidden},
+		{"viewer qbittorrent info", http.MethodPost, "/api/v2/torrents/info", "session-viewer", http.StatusNotImplemented},
+		{"viewer qbittorrent add", http.MethodPost, "/api/v2/torrents/add", "session-viewer", http.StatusForbidden},
+		{"user qbittorrent add", http.MethodPost, "/api/v2/torrents/add", "session-user", http.StatusNotImplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doAuthRequest(s, tt.method, tt.path, tt.session, "")
+			// 0: the request passes authentication, whatever the handler answers.
+			if tt.want == 0 {
+				if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+					t.Fatalf("%s %s: got %d, want it allowed", tt.method, tt.path, rec.Code)
+				}
+				return
+			}
+			if rec.Code != tt.want {
+				t.Fatalf("%s %s: got %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareAcceptsAPIKeys(t *testing.T) {
+	s := NewServer(&fakeCreateTorrent{}, WithAuth(newFakeAuthUseCase()), WithRepository(&fakeRepo{}))
+
+	for name, set := range map[string]func(*http.Request){
+		"header": func(r *http.Request) { r.Header.Set(apiKeyHeader, "tx_viewer") },
+		"bearer": func(r *http.Request) { r.Header.Set("Authorization", "Bearer tx_viewer") },
+		"query":  func(r *http.Request) { r.URL.RawQuery = "apikey=tx_viewer" },
+		"basic":  func(r *http.Request) { r.SetBasicAuth("anyone", "tx_viewer") },
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/torrents", nil)
+		set(req)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: got %d, want 200", name, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/torrents/t1", nil)
+	req.Header.Set(apiKeyHeader, "tx_viewer")
+	// A valid cookie does not widen a key's rights.
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: "session-admin"})
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer key delete: got %d, want 403", rec.Code)
+	}
+}
+
+func TestAuthPublicPaths(t *testing.T) {
+	for _, path := range []string{"/auth/login", "/auth/logout", "/metrics", "/internal/health/player", "/swagger", "/swagger/openapi.json"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if _, ok := requiredRole(req); ok {
+			t.Errorf("%s should not require authentication", path)
+		}
+	}
+	req := httptest.NewRequest(http.MethodOptions, "/torrents", nil)
+	if _, ok := requiredRole(req); ok {
+		t.Error("preflight should not require authentication")
+	}
+}
+
+func TestLoginSetsSessionCookie(t *testing.T) {
+	auth := newFakeAuthUseCase()
+	s := NewServer(nil, WithAuth(auth))
+
+	rec := doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"viewer","password":"nope"}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad password: expected 401, got %d", rec.Code)
+	}
+
+	rec = doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"viewer","password":"password123"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sessionCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "session-viewer" || !cookie.HttpOnly {
+		t.Fatalf("session cookie = %+v", cookie)
+	}
+
+	rec = doAuthRequest(s, http.MethodGet, "/auth/me", cookie.Value, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("me: expected 200, got %d", rec.Code)
+	}
+	var me domain.Principal
+	if err := json.Unmarshal(rec.Body.Bytes(), &me); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if me.User.Username != "viewer" || me.Role != domain.RoleViewer {
+		t.Fatalf("me = %+v", me)
+	}
+
+	rec = doAuthRequest(s, http.MethodPost, "/auth/logout", cookie.Value, "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("logout: expected 204, got %d", rec.Code)
+	}
+	if rec = doAuthRequest(s, http.MethodGet, "/auth/me", cookie.Value, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("me after logout: expected 401, got %d", rec.Code)
+	}
+}
+
+func TestLoginBacksOffAfterFailures(t *testing.T) {
+	s := NewServer(nil, WithAuth(newFakeAuthUseCase()))
+	now := time.Unix(1000, 0)
+	s.logins.now = func() time.Time { return now }
+
+	for i := 0; i <= loginFreeFailures; i++ {
+		rec := doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"viewer","password":"nope"}`)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: expected 401, got %d", i, rec.Code)
+		}
+	}
+
+	// Even the right password waits, and so does another user from the
+	// same address.
+	rec := doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"viewer","password":"password123"}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("locked: expected 429 with Retry-After 1, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec = doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"admin","password":"password123"}`); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("same address: expected 429, got %d", rec.Code)
+	}
+
+	now = now.Add(2 * time.Second)
+	if rec = doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"viewer","password":"password123"}`); rec.Code != http.StatusOK {
+		t.Fatalf("after backoff: expected 200, got %d", rec.Code)
+	}
+
+	// The next failure from the address doubles the wait.
+	doAuthRequest(s, http.MethodPost, "/auth/login", "", `{"username":"viewer","password":"nope"}`)
+	if wait := s.logins.wait(s.loginKeys(httptest.NewRequest(http.MethodPost, "/auth/login", nil), "viewer")); wait != 2*time.Second {
+		t.Fatalf("wait = %v, want 2s", wait)
+	}
+}
+
+func TestLoginCapsUsernameBackoff(t *testing.T) {
+	s := NewServer(nil, WithAuth(newFakeAuthUseCase()))
+	now := time.Unix(1000, 0)
+	s.logins.now = func() time.Time { return now }
+	login := func(addr, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"admin","password":"`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "192.0.2.1")
+		req.RemoteAddr = addr + ":1234"
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Guesses from many addresses run up the username's backoff.
+	for i := 0; i < 20; i++ {
+		now = now.Add(loginUserMaxDelay)
+		if rec := login(fmt.Sprintf("203.0.113.%d", i), "nope"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: expected 401, got %d", i, rec.Code)
+		}
+	}
+
+	// The admin waits at most loginUserMaxDelay, and X-Forwarded-For from
+	// an untrusted client does not move it onto a throttled address.
+	rec := login("198.51.100.1", "password123")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("locked: expected 429 with Retry-After 60, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	now = now.Add(loginUserMaxDelay)
+	if rec = login("198.51.100.1", "password123"); rec.Code != http.StatusOK {
+		t.Fatalf("after cap: expected 200, got %d", rec.Code)
+	}
+}
+
+func TestAPIKeyHandlers(t *testing.T) {
+	auth := newFakeAuthUseCase()
+	s := NewServer(nil, WithAuth(auth))
+
+	rec := doAuthRequest(s, http.MethodPost, "/auth/keys", "session-user", `{"name":"kodi","role":"admin"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("key above role: expected 403, got %d", rec.Code)
+	}
+
+	rec = doAuthRequest(s, http.MethodPost, "/auth/keys", "session-user", `{"name":"kodi"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created apiKeyCreatedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.Key != "tx_newsecret" || created.Role != domain.RoleUser {
+		t.Fatalf("created = %+v", created)
+	}
+
+	rec = doAuthRequest(s, http.MethodGet, "/auth/keys", "session-user", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"count":1`) {
+		t.Fatalf("list: %d %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "tx_newsecret") {
+		t.Fatal("list exposes the key")
+	}
+
+	if rec = doAuthRequest(s, http.MethodDelete, "/auth/keys/"+created.ID, "session-viewer", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("delete foreign key: expected 404, got %d", rec.Code)
+	}
+	if rec = doAuthRequest(s, http.MethodDelete, "/auth/keys/"+created.ID, "session-user", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rec.Code)
+	}
+}
+
+func TestUserHandlers(t *testing.T) {
+	auth := newFakeAuthUseCase()
+	s := NewServer(nil, WithAuth(auth))
+
+	rec := doAuthRequest(s, http.MethodPost, "/users", "session-admin", `{"username":"friend","password":"password123","role":"viewer"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "password") {
+		t.Fatalf("response exposes the password: %s", rec.Body.String())
+	}
+
+	rec = doAuthRequest(s, http.MethodPost, "/users", "session-admin", `{"username":"friend","password":"password123"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate: expected 409, got %d", rec.Code)
+	}
+	rec = doAuthRequest(s, http.MethodPost, "/users", "session-admin", `{"username":"pal","password":"short"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("short password: expected 400, got %d", rec.Code)
+	}
+
+	rec = doAuthRequest(s, http.MethodPatch, "/users/id-friend", "session-admin", `{"role":"user"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"role":"user"`) {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = doAuthRequest(s, http.MethodPatch, "/users/id-admin", "session-admin", `{"role":"viewer"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("demote last admin: expected 409, got %d", rec.Code)
+	}
+	rec = doAuthRequest(s, http.MethodPatch, "/users/id-friend", "session-admin", `{"username":"renamed"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("rename: expected 400, got %d", rec.Code)
+	}
+
+	if rec = doAuthRequest(s, http.MethodDelete, "/users/id-friend", "session-admin", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rec.Code)
+	}
+	if rec = doAuthRequest(s, http.MethodGet, "/users/id-friend", "session-admin", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("get deleted: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	if got := redactQuery("fileIndex=1"); got != "fileIndex=1" {
+		t.Fatalf("redactQuery without key = %q", got)
+	}
+	got := redactQuery("apikey=tx_secret&fileIndex=1")
+	if strings.Contains(got, "tx_secret") || !strings.Contains(got, "fileIndex=1") {
+		t.Fatalf("redactQuery = %q", got)
+	}
+}
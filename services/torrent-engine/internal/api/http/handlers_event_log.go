@@ -0,0 +1,69 @@
+package apihttp
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"torrentstream/internal/domain"
+)
+
+// handleEventLog serves GET /events/log: the stored event timeline of all
+// torrents, newest first.
+func (s *Server) handleEventLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeEventLog(w, r, domain.TorrentID(strings.TrimSpace(r.URL.Query().Get("torrentId"))))
+}
+
+// handleTorrentEvents serves GET /torrents/{id}/events. Events stay
+// available after the torrent is deleted.
+func (s *Server) handleTorrentEvents(w http.ResponseWriter, r *http.Request, id string) {
+	s.writeEventLog(w, r, domain.TorrentID(id))
+}
+
+func (s *Server) writeEventLog(w http.ResponseWriter, r *http.Request, id domain.TorrentID) {
+	if s.eventLog == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "event log not configured")
+		return
+	}
+	query := r.URL.Query()
+	filter := domain.EventFilter{TorrentID: id}
+	for _, t := range parseCommaSeparated(query.Get("types")) {
+		if !slices.Contains(domain.EventTypes, domain.EventType(t)) {
+			writeError(w, http.StatusBadRequest, "invalid_request", "unknown event type: "+t)
+			return
+		}
+		filter.Types = append(filter.Types, domain.EventType(t))
+	}
+	var ok bool
+	if filter.Since, ok = parseStatsTime(query.Get("from")); !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid from")
+		return
+	}
+	if filter.Until, ok = parseStatsTime(query.Get("to")); !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid to")
+		return
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 1000 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "limit must be between 1 and 1000")
+			return
+		}
+		filter.Limit = n
+	}
+
+	events, err := s.eventLog.List(r.Context(), filter)
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": events, "count": len(events)})
+}
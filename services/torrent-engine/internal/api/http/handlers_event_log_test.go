@@ -0,0 +1,110 @@
+package apihttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeEventLog struct {
+	events []domain.Event
+	filter domain.EventFilter
+	err    error
+}
+
+func (f *fakeEventLog) List(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	f.filter = filter
+	return f.events, f.err
+}
+
+func TestTorrentEventsEndpoint(t *testing.T) {
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	uc := &fakeEventLog{events: []domain.Event{
+		{Type: domain.EventTorrentStopped, TorrentID: "t1", Time: at, Reason: domain.ReasonDiskPressure},
+	}}
+	s := NewServer(nil, WithEventLog(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/events?types=torrent_stopped,error&from=2026-06-01T00:00:00Z&limit=20", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got struct {
+		Items []domain.Event `json:"items"`
+		Count int            `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Count != 1 || got.Items[0].Reason != domain.ReasonDiskPressure {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+	f := uc.filter
+	if f.TorrentID != "t1" || len(f.Types) != 2 || f.Types[1] != domain.EventError || !f.Since.Equal(at.Add(-12*time.Hour)) || !f.Until.IsZero() || f.Limit != 20 {
+		t.Fatalf("filter = %+v", f)
+	}
+
+	rec = doSettingsRequest(s, http.MethodPost, "/torrents/t1/events", nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestEventLogEndpoint(t *testing.T) {
+	uc := &fakeEventLog{}
+	s := NewServer(nil, WithEventLog(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/events/log?torrentId=t2&to=1780000000", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if uc.filter.TorrentID != "t2" || uc.filter.Until.Unix() != 1780000000 {
+		t.Fatalf("filter = %+v", uc.filter)
+	}
+	var got struct {
+		Items []domain.Event `json:"items"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || got.Items == nil {
+		t.Fatalf("items = %+v, %v", got.Items, err)
+	}
+
+	for _, query := range []string{"types=bogus", "from=yesterday", "limit=0", "limit=5000"} {
+		rec = doSettingsRequest(s, http.MethodGet, "/events/log?"+query, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestEventLogNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	for _, target := range []string{"/events/log", "/torrents/t1/events"} {
+		rec := doSettingsRequest(s, http.MethodGet, target, nil)
+		if rec.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: expected 501, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestUpdateTagsPublishesEvent(t *testing.T) {
+	repo := &fakeRepo{get: domain.TorrentRecord{ID: "t1", Tags: []string{"movie"}}}
+	events := &recordingEvents{}
+	server := NewServer(&fakeCreateTorrent{}, WithRepository(repo), WithEvents(events))
+
+	req := httptest.NewRequest(http.MethodPut, "/torrents/t1/tags", bytes.NewBufferString(`{"tags":["movie"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if len(events.events) != 1 || events.events[0].Type != domain.EventTagsChanged || events.events[0].TorrentID != "t1" || len(events.events[0].Tags) != 1 {
+		t.Fatalf("events = %+v", events.events)
+	}
+}
@@ -41,11 +41,17 @@ func (s *Server) handleWatchHistory(w http.ResponseWriter, r *http.Request) {
 
 	status := strings.TrimSpace(r.URL.Query().Get("status"))
 
+	profile, err := s.currentProfile(r)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+
 	var positions []domain.WatchPosition
 	if status == "incomplete" {
-		positions, err = s.watchHistory.ListIncomplete(r.Context(), limit)
+		positions, err = s.watchHistory.ListIncomplete(r.Context(), profile.ID, limit)
 	} else {
-		positions, err = s.watchHistory.ListRecent(r.Context(), limit)
+		positions, err = s.watchHistory.ListRecent(r.Context(), profile.ID, limit)
 	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list watch history")
@@ -75,9 +81,19 @@ func (s *Server) handleWatchHistoryByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	profile, err := s.currentProfile(r)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
-		pos, err := s.watchHistory.Get(r.Context(), torrentID, fileIndex)
+		pos, err := s.watchHistory.Get(r.Context(), profile.ID, torrentID, fileIndex)
 		if err != nil {
 			if errors.Is(err, domain.ErrNotFound) {
 				writeError(w, http.StatusNotFound, "not_found", "no watch position found")
@@ -101,6 +117,7 @@ func (s *Server) handleWatchHistoryByID(w http.ResponseWriter, r *http.Request)
 		}
 
 		wp := domain.WatchPosition{
+			ProfileID:   profile.ID,
 			TorrentID:   torrentID,
 			FileIndex:   fileIndex,
 			Position:    body.Position,
@@ -16,7 +16,7 @@ import (
 // ---- fake watch history store ----
 
 type fakeWatchHistoryStore struct {
-	positions map[string]domain.WatchPosition // keyed by "torrentId:fileIndex"
+	positions map[string]domain.WatchPosition // keyed by "torrentId:fileIndex", prefixed with "profileId/" outside the default profile
 	listErr   error
 	getErr    error
 	upsertErr error
@@ -28,24 +28,34 @@ func newFakeWatchHistoryStore() *fakeWatchHistoryStore {
 	}
 }
 
-func (f *fakeWatchHistoryStore) posKey(id domain.TorrentID, idx int) string {
-	return fmt.Sprintf("%s:%d", string(id), idx)
+func (f *fakeWatchHistoryStore) posKey(profileID string, id domain.TorrentID, idx int) string {
+	if profileID == "" || profileID == domain.DefaultProfileID {
+		return fmt.Sprintf("%s:%d", string(id), idx)
+	}
+	return fmt.Sprintf("%s/%s:%d", profileID, string(id), idx)
+}
+
+func inProfile(p domain.WatchPosition, profileID string) bool {
+	if p.ProfileID == "" {
+		return profileID == domain.DefaultProfileID
+	}
+	return p.ProfileID == profileID
 }
 
 func (f *fakeWatchHistoryStore) Upsert(_ context.Context, wp domain.WatchPosition) error {
 	if f.upsertErr != nil {
 		return f.upsertErr
 	}
-	k := f.posKey(wp.TorrentID, wp.FileIndex)
+	k := f.posKey(wp.ProfileID, wp.TorrentID, wp.FileIndex)
 	f.positions[k] = wp
 	return nil
 }
 
-func (f *fakeWatchHistoryStore) Get(_ context.Context, id domain.TorrentID, idx int) (domain.WatchPosition, error) {
+func (f *fakeWatchHistoryStore) Get(_ context.Context, profileID string, id domain.TorrentID, idx int) (domain.WatchPosition, error) {
 	if f.getErr != nil {
 		return domain.WatchPosition{}, f.getErr
 	}
-	k := f.posKey(id, idx)
+	k := f.posKey(profileID, id, idx)
 	pos, ok := f.positions[k]
 	if !ok {
 		return domain.WatchPosition{}, domain.ErrNotFound
@@ -53,13 +63,15 @@ func (f *fakeWatchHistoryStore) Get(_ context.Context, id domain.TorrentID, idx
 	return pos, nil
 }
 
-func (f *fakeWatchHistoryStore) ListRecent(_ context.Context, limit int) ([]domain.WatchPosition, error) {
+func (f *fakeWatchHistoryStore) ListRecent(_ context.Context, profileID string, limit int) ([]domain.WatchPosition, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
 	result := make([]domain.WatchPosition, 0, len(f.positions))
 	for _, p := range f.positions {
-		result = append(result, p)
+		if inProfile(p, profileID) {
+			result = append(result, p)
+		}
 	}
 	if limit > 0 && limit < len(result) {
 		result = result[:limit]
@@ -67,13 +79,13 @@ func (f *fakeWatchHistoryStore) ListRecent(_ context.Context, limit int) ([]doma
 	return result, nil
 }
 
-func (f *fakeWatchHistoryStore) ListIncomplete(_ context.Context, limit int) ([]domain.WatchPosition, error) {
+func (f *fakeWatchHistoryStore) ListIncomplete(_ context.Context, profileID string, limit int) ([]domain.WatchPosition, error) {
 	if f.listErr != nil {
 		return nil, f.listErr
 	}
 	result := make([]domain.WatchPosition, 0)
 	for _, p := range f.positions {
-		if p.Duration <= 0 || p.Position < 10 {
+		if !inProfile(p, profileID) || p.Duration <= 0 || p.Position < 10 {
 			continue
 		}
 		if p.Position >= p.Duration-15 {
@@ -0,0 +1,22 @@
+package apihttp
+
+import (
+	"net/http"
+
+	"torrentstream/internal/domain"
+)
+
+// handleIntegrity serves GET /torrents/{id}/integrity: hash failures, peers
+// that sent bad data and the piece state of each file.
+func (s *Server) handleIntegrity(w http.ResponseWriter, r *http.Request, id string) {
+	if s.integrity == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "integrity report not configured")
+		return
+	}
+	report, err := s.integrity.Report(r.Context(), domain.TorrentID(id))
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,64 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeIntegrity struct {
+	report domain.IntegrityReport
+	err    error
+	id     domain.TorrentID
+}
+
+func (f *fakeIntegrity) Report(ctx context.Context, id domain.TorrentID) (domain.IntegrityReport, error) {
+	f.id = id
+	return f.report, f.err
+}
+
+func TestIntegrityReport(t *testing.T) {
+	uc := &fakeIntegrity{report: domain.IntegrityReport{
+		TorrentID:    "t1",
+		SessionOpen:  true,
+		HashFailures: 2,
+		FailedPieces: []domain.PieceFailure{{Index: 12, Failures: 2}},
+		BadPeers:     []domain.BadPeer{{IP: "192.0.2.7", BadPieces: 2, Banned: true}},
+		Files:        []domain.FileIntegrity{{Index: 0, Path: "movie.mkv", Status: domain.FileCorrupted, Pieces: 40, CorruptedPieces: 1, FailedPieces: []int{12}}},
+	}}
+	s := NewServer(nil, WithIntegrity(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/integrity", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got domain.IntegrityReport
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if uc.id != "t1" || got.HashFailures != 2 || got.Files[0].Status != domain.FileCorrupted || !got.BadPeers[0].Banned {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+
+	uc.err = domain.ErrNotFound
+	rec = doSettingsRequest(s, http.MethodGet, "/torrents/t1/integrity", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	rec = doSettingsRequest(s, http.MethodPost, "/torrents/t1/integrity", nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestIntegrityNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/integrity", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+}
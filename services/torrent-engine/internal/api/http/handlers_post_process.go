@@ -0,0 +1,173 @@
+package apihttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"torrentstream/internal/domain"
+)
+
+// Post-processing command and run log handlers.
+
+type postProcessRequest struct {
+	Name           *string           `json:"name"`
+	Trigger        *domain.EventType `json:"trigger"`
+	Program        *string           `json:"program"`
+	Args           *[]string         `json:"args"`
+	TimeoutSeconds *int              `json:"timeoutSeconds"`
+	Enabled        *bool             `json:"enabled"`
+}
+
+func (s *Server) handlePostProcessCommands(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.postProcess == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "post-processing not enabled")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		cmds, err := s.postProcess.List(r.Context())
+		if err != nil {
+			writePostProcessError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": cmds, "count": len(cmds)})
+		return
+	}
+
+	body, ok := decodePostProcessRequest(w, r)
+	if !ok {
+		return
+	}
+	// New commands are enabled unless stated otherwise.
+	cmd := applyPostProcessRequest(domain.PostProcessCommand{Enabled: true}, body)
+	created, err := s.postProcess.Create(r.Context(), cmd)
+	if err != nil {
+		writePostProcessError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handlePostProcessCommandByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/settings/post-process/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+	if s.postProcess == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "post-processing not enabled")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cmd, err := s.postProcess.Get(r.Context(), id)
+		if err != nil {
+			writePostProcessError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, cmd)
+	case http.MethodPut, http.MethodPatch:
+		existing, err := s.postProcess.Get(r.Context(), id)
+		if err != nil {
+			writePostProcessError(w, err)
+			return
+		}
+		body, ok := decodePostProcessRequest(w, r)
+		if !ok {
+			return
+		}
+		updated, err := s.postProcess.Update(r.Context(), applyPostProcessRequest(existing, body))
+		if err != nil {
+			writePostProcessError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, updated)
+	case http.MethodDelete:
+		if err := s.postProcess.Delete(r.Context(), id); err != nil {
+			writePostProcessError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePostProcessRuns lists the command runs of a torrent, newest first.
+func (s *Server) handlePostProcessRuns(w http.ResponseWriter, r *http.Request, id string) {
+	if s.postProcess == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "post-processing not enabled")
+		return
+	}
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+	runs, err := s.postProcess.Runs(r.Context(), domain.TorrentID(id), limit)
+	if err != nil {
+		writePostProcessError(w, err)
+		return
+	}
+	if runs == nil {
+		runs = []domain.PostProcessRun{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": runs, "count": len(runs)})
+}
+
+func decodePostProcessRequest(w http.ResponseWriter, r *http.Request) (postProcessRequest, bool) {
+	var body postProcessRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return body, false
+	}
+	return body, true
+}
+
+func applyPostProcessRequest(cmd domain.PostProcessCommand, body postProcessRequest) domain.PostProcessCommand {
+	if body.Name != nil {
+		cmd.Name = *body.Name
+	}
+	if body.Trigger != nil {
+		cmd.Trigger = *body.Trigger
+	}
+	if body.Program != nil {
+		cmd.Program = *body.Program
+	}
+	if body.Args != nil {
+		cmd.Args = *body.Args
+	}
+	if body.TimeoutSeconds != nil {
+		cmd.TimeoutSeconds = *body.TimeoutSeconds
+	}
+	if body.Enabled != nil {
+		cmd.Enabled = *body.Enabled
+	}
+	return cmd
+}
+
+func writePostProcessError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "not_found", "command not found")
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidPostProcess) {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	writeDomainError(w, err)
+}
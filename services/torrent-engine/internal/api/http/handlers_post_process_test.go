@@ -0,0 +1,157 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakePostProcessUseCase struct {
+	cmds   map[string]domain.PostProcessCommand
+	runs   []domain.PostProcessRun
+	nextID int
+}
+
+func newFakePostProcessUseCase() *fakePostProcessUseCase {
+	return &fakePostProcessUseCase{cmds: make(map[string]domain.PostProcessCommand)}
+}
+
+func (f *fakePostProcessUseCase) List(_ context.Context) ([]domain.PostProcessCommand, error) {
+	var out []domain.PostProcessCommand
+	for _, c := range f.cmds {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (f *fakePostProcessUseCase) Get(_ context.Context, id string) (domain.PostProcessCommand, error) {
+	c, ok := f.cmds[id]
+	if !ok {
+		return domain.PostProcessCommand{}, domain.ErrNotFound
+	}
+	return c, nil
+}
+
+func (f *fakePostProcessUseCase) Create(_ context.Context, cmd domain.PostProcessCommand) (domain.PostProcessCommand, error) {
+	if cmd.Program == "" {
+		return domain.PostProcessCommand{}, fmt.Errorf("%w: program is required", domain.ErrInvalidPostProcess)
+	}
+	f.nextID++
+	cmd.ID = fmt.Sprintf("c%d", f.nextID)
+	f.cmds[cmd.ID] = cmd
+	return cmd, nil
+}
+
+func (f *fakePostProcessUseCase) Update(_ context.Context, cmd domain.PostProcessCommand) (domain.PostProcessCommand, error) {
+	f.cmds[cmd.ID] = cmd
+	return cmd, nil
+}
+
+func (f *fakePostProcessUseCase) Delete(_ context.Context, id string) error {
+	if _, ok := f.cmds[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.cmds, id)
+	return nil
+}
+
+func (f *fakePostProcessUseCase) Runs(_ context.Context, id domain.TorrentID, limit int) ([]domain.PostProcessRun, error) {
+	var out []domain.PostProcessRun
+	for _, run := range f.runs {
+		if run.TorrentID == id {
+			out = append(out, run)
+		}
+	}
+	return out, nil
+}
+
+func TestPostProcessNotEnabled(t *testing.T) {
+	s := NewServer(nil)
+	for _, path := range []string{"/settings/post-process", "/settings/post-process/c1", "/torrents/t1/post-process"} {
+		rec := doSettingsRequest(s, http.MethodGet, path, nil)
+		if rec.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: expected 501, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestPostProcessNeedsAuth(t *testing.T) {
+	s := NewServer(nil, WithPostProcess(newFakePostProcessUseCase()))
+	rec := doSettingsRequest(s, http.MethodPost, "/settings/post-process", []byte(`{"trigger":"completed","program":"/bin/sh"}`))
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("without auth: expected 501, got %d", rec.Code)
+	}
+
+	s = NewServer(nil, WithAuth(newFakeAuthUseCase()), WithPostProcess(newFakePostProcessUseCase()))
+	rec = doAuthRequest(s, http.MethodPost, "/settings/post-process", "session-user", `{"trigger":"completed","program":"/bin/sh"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("user role: expected 403, got %d", rec.Code)
+	}
+}
+
+func TestPostProcessCommandCRUD(t *testing.T) {
+	uc := newFakePostProcessUseCase()
+	s := NewServer(nil, WithAuth(newFakeAuthUseCase()), WithPostProcess(uc))
+
+	rec := doAuthRequest(s, http.MethodPost, "/settings/post-process", "session-admin",
+		`{"trigger":"completed","program":"/usr/bin/scan","args":["{{.SavePath}}"],"timeoutSeconds":30}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.PostProcessCommand
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID != "c1" || !created.Enabled || created.TimeoutSeconds != 30 {
+		t.Fatalf("created = %+v", created)
+	}
+
+	rec = doAuthRequest(s, http.MethodPatch, "/settings/post-process/c1", "session-admin", `{"enabled":false}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if cmd := uc.cmds["c1"]; cmd.Enabled || cmd.Program != "/usr/bin/scan" {
+		t.Fatalf("patched = %+v", cmd)
+	}
+
+	rec = doAuthRequest(s, http.MethodPost, "/settings/post-process", "session-admin", `{"trigger":"completed"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid create: expected 400, got %d", rec.Code)
+	}
+	rec = doAuthRequest(s, http.MethodPatch, "/settings/post-process/missing", "session-admin", `{}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("patch missing: expected 404, got %d", rec.Code)
+	}
+
+	rec = doAuthRequest(s, http.MethodDelete, "/settings/post-process/c1", "session-admin", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rec.Code)
+	}
+}
+
+func TestPostProcessRunsByTorrent(t *testing.T) {
+	uc := newFakePostProcessUseCase()
+	uc.runs = []domain.PostProcessRun{
+		{ID: "r1", TorrentID: "t1", CommandID: "c1", Output: "done"},
+		{ID: "r2", TorrentID: "t2", CommandID: "c1"},
+	}
+	s := NewServer(nil, WithAuth(newFakeAuthUseCase()), WithPostProcess(uc))
+
+	rec := doAuthRequest(s, http.MethodGet, "/torrents/t1/post-process", "session-viewer", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"r1"`) || strings.Contains(rec.Body.String(), `"r2"`) {
+		t.Fatalf("unexpected runs: %s", rec.Body.String())
+	}
+
+	rec = doAuthRequest(s, http.MethodGet, "/torrents/t1/post-process?limit=abc", "session-viewer", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad limit: expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,224 @@
+package apihttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+// Profile handlers. A request acts for the profile named by the
+// X-Profile-Id header or the profile cookie set by the switch endpoint;
+// without either it uses the caller's own profile, or the default profile
+// when authentication is off.
+
+const (
+	profileHeader = "X-Profile-Id"
+	profileCookie = "torrx_profile"
+)
+
+type profileRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.profiles == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "profiles not configured")
+		return
+	}
+	caller := callerFrom(r)
+
+	if r.Method == http.MethodGet {
+		profiles, err := s.profiles.List(r.Context(), caller)
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		if profiles == nil {
+			profiles = []domain.Profile{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": profiles, "count": len(profiles)})
+		return
+	}
+
+	body, ok := decodeProfileRequest(w, r)
+	if !ok {
+		return
+	}
+	// Profiles created by an admin are shared; everyone else gets a
+	// profile of their own.
+	if caller != nil && caller.Role == domain.RoleAdmin {
+		caller = nil
+	}
+	profile, err := s.profiles.Create(r.Context(), body.Name, caller)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, profile)
+}
+
+func (s *Server) handleCurrentProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.profiles == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "profiles not configured")
+		return
+	}
+	profile, err := s.currentProfile(r)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (s *Server) handleProfileByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/profiles/"), "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "switch") {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+	if s.profiles == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "profiles not configured")
+		return
+	}
+	id := parts[0]
+	caller := callerFrom(r)
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleSwitchProfile(w, r, id, caller)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		profile, err := s.profiles.Get(r.Context(), id, caller)
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, profile)
+	case http.MethodPatch:
+		body, ok := decodeProfileRequest(w, r)
+		if !ok {
+			return
+		}
+		profile, err := s.profiles.Rename(r.Context(), id, body.Name, caller)
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, profile)
+	case http.MethodDelete:
+		if err := s.profiles.Delete(r.Context(), id, caller); err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSwitchProfile remembers the profile in a cookie so that later
+// requests from the same client act for it.
+func (s *Server) handleSwitchProfile(w http.ResponseWriter, r *http.Request, id string, caller *domain.Principal) {
+	profile, err := s.profiles.Get(r.Context(), id, caller)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     profileCookie,
+		Value:    profile.ID,
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// currentProfile returns the profile r acts for. Without profiles all
+// history and player state belongs to the default profile.
+func (s *Server) currentProfile(r *http.Request) (domain.Profile, error) {
+	if s.profiles == nil {
+		return domain.Profile{ID: domain.DefaultProfileID}, nil
+	}
+	requested := strings.TrimSpace(r.Header.Get(profileHeader))
+	if requested == "" {
+		if cookie, err := r.Cookie(profileCookie); err == nil {
+			requested = cookie.Value
+		}
+	}
+	return s.profiles.Resolve(r.Context(), requested, callerFrom(r))
+}
+
+// recordPlayerState stores update on the current profile. A profile that
+// focuses a torrent also brings its own file priority preference along.
+func (s *Server) recordPlayerState(r *http.Request, update usecase.PlayerStateUpdate) error {
+	if s.profiles == nil {
+		return nil
+	}
+	profile, err := s.currentProfile(r)
+	if err != nil {
+		return err
+	}
+	profile, err = s.profiles.SetPlayerState(r.Context(), profile.ID, update)
+	if err != nil {
+		return err
+	}
+	if update.CurrentTorrentID != nil && update.PrioritizeActiveFileOnly == nil &&
+		profile.PrioritizeActiveFileOnly != nil && s.player != nil &&
+		s.player.PrioritizeActiveFileOnly() != *profile.PrioritizeActiveFileOnly {
+		return s.player.SetPrioritizeActiveFileOnly(*profile.PrioritizeActiveFileOnly)
+	}
+	return nil
+}
+
+// callerFrom returns the authenticated caller, or nil when authentication
+// is off.
+func callerFrom(r *http.Request) *domain.Principal {
+	principal, ok := principalFrom(r.Context())
+	if !ok {
+		return nil
+	}
+	return &principal
+}
+
+func decodeProfileRequest(w http.ResponseWriter, r *http.Request) (profileRequest, bool) {
+	var body profileRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return body, false
+	}
+	return body, true
+}
+
+func writeProfileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "profile not found")
+	case errors.Is(err, domain.ErrInvalidProfile):
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		writeDomainError(w, err)
+	}
+}
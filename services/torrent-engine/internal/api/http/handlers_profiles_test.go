@@ -0,0 +1,246 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+type fakeProfileStore struct {
+	profiles map[string]domain.Profile
+}
+
+func (f *fakeProfileStore) ListProfiles(_ context.Context) ([]domain.Profile, error) {
+	out := make([]domain.Profile, 0, len(f.profiles))
+	for _, p := range f.profiles {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (f *fakeProfileStore) GetProfile(_ context.Context, id string) (domain.Profile, error) {
+	p, ok := f.profiles[id]
+	if !ok {
+		return domain.Profile{}, domain.ErrNotFound
+	}
+	return p, nil
+}
+
+func (f *fakeProfileStore) GetProfileByOwner(_ context.Context, ownerID string) (domain.Profile, error) {
+	for _, p := range f.profiles {
+		if p.OwnerID == ownerID {
+			return p, nil
+		}
+	}
+	return domain.Profile{}, domain.ErrNotFound
+}
+
+func (f *fakeProfileStore) SaveProfile(_ context.Context, profile domain.Profile) error {
+	f.profiles[profile.ID] = profile
+	return nil
+}
+
+func (f *fakeProfileStore) DeleteProfile(_ context.Context, id string) error {
+	if _, ok := f.profiles[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.profiles, id)
+	return nil
+}
+
+// newProfilesServer returns a server with a default profile and a "kids"
+// profile.
+func newProfilesServer(t *testing.T, opts ...ServerOption) (*Server, *fakeProfileStore) {
+	t.Helper()
+	store := &fakeProfileStore{profiles: make(map[string]domain.Profile)}
+	uc := &usecase.Profiles{Store: store}
+	if err := uc.EnsureDefault(context.Background(), "", true); err != nil {
+		t.Fatalf("EnsureDefault: %v", err)
+	}
+	store.profiles["kids"] = domain.Profile{ID: "kids", Name: "Kids"}
+	return NewServer(nil, append(opts, WithProfiles(uc))...), store
+}
+
+func doProfileRequest(s *Server, method, path, profileID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if profileID != "" {
+		req.Header.Set(profileHeader, profileID)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProfilesNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	for _, path := range []string{"/profiles", "/profiles/current", "/profiles/kids"} {
+		rec := doProfileRequest(s, http.MethodGet, path, "", "")
+		if rec.Code != http.StatusNotImplemented {
+			t.Fatalf("GET %s: expected 501, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestProfilesCreateRenameDelete(t *testing.T) {
+	s, store := newProfilesServer(t)
+
+	rec := doProfileRequest(s, http.MethodPost, "/profiles", "", `{"name":" Guest "}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Profile
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID == "" || created.Name != "Guest" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	rec = doProfileRequest(s, http.MethodPost, "/profiles", "", `{"name":""}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty name: expected 400, got %d", rec.Code)
+	}
+
+	rec = doProfileRequest(s, http.MethodGet, "/profiles", "", "")
+	var list struct {
+		Items []domain.Profile `json:"items"`
+		Count int              `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || list.Count != 3 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	rec = doProfileRequest(s, http.MethodPatch, "/profiles/"+created.ID, "", `{"name":"Visitor"}`)
+	if rec.Code != http.StatusOK || store.profiles[created.ID].Name != "Visitor" {
+		t.Fatalf("rename: got %d, profile %+v", rec.Code, store.profiles[created.ID])
+	}
+
+	rec = doProfileRequest(s, http.MethodDelete, "/profiles/"+domain.DefaultProfileID, "", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("delete default: expected 400, got %d", rec.Code)
+	}
+	rec = doProfileRequest(s, http.MethodDelete, "/profiles/"+created.ID, "", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rec.Code)
+	}
+	rec = doProfileRequest(s, http.MethodGet, "/profiles/"+created.ID, "", "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get deleted: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestProfilesSwitchSetsCookie(t *testing.T) {
+	s, _ := newProfilesServer(t)
+
+	rec := doProfileRequest(s, http.MethodPost, "/profiles/missing/switch", "", "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("switch to unknown: expected 404, got %d", rec.Code)
+	}
+
+	rec = doProfileRequest(s, http.MethodPost, "/profiles/kids/switch", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("switch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == profileCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "kids" {
+		t.Fatalf("profile cookie = %+v", cookie)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/profiles/current", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	var current domain.Profile
+	if err := json.Unmarshal(rec.Body.Bytes(), &current); err != nil || current.ID != "kids" {
+		t.Fatalf("current = %+v, %v", current, err)
+	}
+}
+
+func TestWatchHistoryIsPerProfile(t *testing.T) {
+	history := newFakeWatchHistoryStore()
+	s, _ := newProfilesServer(t, WithWatchHistory(history))
+
+	rec := doProfileRequest(s, http.MethodPut, "/watch-history/abc/0", "kids", `{"position":42,"duration":100}`)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("put: expected 204, got %d", rec.Code)
+	}
+	if pos, ok := history.positions["kids/abc:0"]; !ok || pos.ProfileID != "kids" {
+		t.Fatalf("positions = %+v", history.positions)
+	}
+
+	rec = doProfileRequest(s, http.MethodGet, "/watch-history/abc/0", "", "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("default profile get: expected 404, got %d", rec.Code)
+	}
+	rec = doProfileRequest(s, http.MethodGet, "/watch-history/abc/0", "kids", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("kids get: expected 200, got %d", rec.Code)
+	}
+
+	var positions []domain.WatchPosition
+	rec = doProfileRequest(s, http.MethodGet, "/watch-history?status=incomplete", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &positions); err != nil || len(positions) != 0 {
+		t.Fatalf("default continue watching = %+v, %v", positions, err)
+	}
+	rec = doProfileRequest(s, http.MethodGet, "/watch-history?status=incomplete", "kids", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &positions); err != nil || len(positions) != 1 {
+		t.Fatalf("kids continue watching = %+v, %v", positions, err)
+	}
+}
+
+func TestPlayerSettingsArePerProfile(t *testing.T) {
+	player := &fakePlayerSettings{prioritizeActiveFileOnly: true}
+	s, store := newProfilesServer(t, WithPlayerSettings(player))
+
+	rec := doProfileRequest(s, http.MethodPost, "/torrents/t1/focus", "kids", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("focus: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if store.profiles["kids"].CurrentTorrentID != "t1" {
+		t.Fatalf("kids profile = %+v", store.profiles["kids"])
+	}
+
+	rec = doProfileRequest(s, http.MethodPatch, "/settings/player", "kids", `{"prioritizeActiveFileOnly":false}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp playerSettingsResponse
+	rec = doProfileRequest(s, http.MethodGet, "/settings/player", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.ProfileID != domain.DefaultProfileID || resp.CurrentTorrentID != "" || !resp.PrioritizeActiveFileOnly {
+		t.Fatalf("default settings = %+v", resp)
+	}
+
+	// Focusing from the default profile brings its preference back.
+	rec = doProfileRequest(s, http.MethodPost, "/torrents/t2/focus", "", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("focus: expected 204, got %d", rec.Code)
+	}
+	if player.current != "t2" || !player.prioritizeActiveFileOnly {
+		t.Fatalf("player = %+v", player)
+	}
+	rec = doProfileRequest(s, http.MethodGet, "/settings/player", "kids", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.ProfileID != "kids" || resp.CurrentTorrentID != "t1" || resp.PrioritizeActiveFileOnly {
+		t.Fatalf("kids settings = %+v", resp)
+	}
+}
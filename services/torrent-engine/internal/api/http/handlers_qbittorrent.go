@@ -0,0 +1,953 @@
+package apihttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+	domainports "torrentstream/internal/domain/ports"
+	"torrentstream/internal/usecase"
+)
+
+// qBittorrent Web API handlers. They serve the part of /api/v2 that Sonarr,
+// Radarr and "add to client" browser extensions use, mapped onto the
+// torrent use cases. qBittorrent identifies torrents by info hash, which is
+// the torrent ID here.
+
+const (
+	qbitAPIPrefix = "/api/v2/"
+	// qbitSessionCookie is the cookie qBittorrent clients send back after
+	// logging in. It carries a regular session token.
+	qbitSessionCookie = "SID"
+	// qbitAppVersion and qbitWebAPIVersion match qBittorrent 4.6, whose
+	// clients pause and resume torrents; the 5.x stop and start endpoints
+	// are served as well.
+	qbitAppVersion    = "v4.6.7"
+	qbitWebAPIVersion = "2.9.3"
+	// qbitMaxETA is what qBittorrent reports when the ETA is unknown.
+	qbitMaxETA = 8640000
+)
+
+// qbitReadEndpoints do not change anything and are open to viewers,
+// whatever the method. qbitWriteEndpoints only accept POST, as in
+// qBittorrent 4.4 and later.
+var (
+	qbitReadEndpoints = map[string]bool{
+		"app/version":         true,
+		"app/webapiVersion":   true,
+		"app/preferences":     true,
+		"app/defaultSavePath": true,
+		"torrents/info":       true,
+		"torrents/properties": true,
+		"torrents/files":      true,
+		"torrents/categories": true,
+	}
+	qbitWriteEndpoints = map[string]bool{
+		"auth/login":              true,
+		"auth/logout":             true,
+		"torrents/add":            true,
+		"torrents/delete":         true,
+		"torrents/pause":          true,
+		"torrents/stop":           true,
+		"torrents/resume":         true,
+		"torrents/start":          true,
+		"torrents/createCategory": true,
+		"torrents/setCategory":    true,
+	}
+)
+
+// QBittorrentConfig enables the qBittorrent Web API.
+type QBittorrentConfig struct {
+	// Categories moves torrents between categories.
+	Categories domainports.CategoryRepository
+	// CategoryRoots maps the configured categories to their storage root,
+	// reported as the category's save path.
+	CategoryRoots map[string]string
+}
+
+type qbitAPI struct {
+	categories domainports.CategoryRepository
+	roots      map[string]string
+	// client fetches .torrent links.
+	client *http.Client
+
+	mu sync.Mutex
+	// created holds categories created over the API that no torrent uses
+	// yet; qBittorrent clients create their category before adding.
+	created map[string]bool
+}
+
+func WithQBittorrent(cfg QBittorrentConfig) ServerOption {
+	return func(s *Server) {
+		s.qbit = &qbitAPI{
+			categories: cfg.Categories,
+			roots:      cfg.CategoryRoots,
+			client:     &http.Client{Timeout: qbitFetchTimeout},
+			created:    make(map[string]bool),
+		}
+	}
+}
+
+type qbitTorrentInfo struct {
+	Hash             string  `json:"hash"`
+	InfohashV1       string  `json:"infohash_v1"`
+	InfohashV2       string  `json:"infohash_v2"`
+	Name             string  `json:"name"`
+	MagnetURI        string  `json:"magnet_uri"`
+	Size             int64   `json:"size"`
+	TotalSize        int64   `json:"total_size"`
+	Progress         float64 `json:"progress"`
+	Completed        int64   `json:"completed"`
+	AmountLeft       int64   `json:"amount_left"`
+	Downloaded       int64   `json:"downloaded"`
+	Uploaded         int64   `json:"uploaded"`
+	Ratio            float64 `json:"ratio"`
+	DLSpeed          int64   `json:"dlspeed"`
+	UPSpeed          int64   `json:"upspeed"`
+	ETA              int64   `json:"eta"`
+	State            string  `json:"state"`
+	Category         string  `json:"category"`
+	Tags             string  `json:"tags"`
+	SavePath         string  `json:"save_path"`
+	ContentPath      string  `json:"content_path"`
+	AddedOn          int64   `json:"added_on"`
+	CompletionOn     int64   `json:"completion_on"`
+	LastActivity     int64   `json:"last_activity"`
+	RatioLimit       float64 `json:"ratio_limit"`
+	SeedingTimeLimit int64   `json:"seeding_time_limit"`
+	MaxRatio         float64 `json:"max_ratio"`
+	MaxSeedingTime   int64   `json:"max_seeding_time"`
+	SeedingTime      int64   `json:"seeding_time"`
+	Priority         int     `json:"priority"`
+	AutoTMM          bool    `json:"auto_tmm"`
+	IsPrivate        bool    `json:"is_private"`
+}
+
+type qbitProperties struct {
+	Hash             string  `json:"hash"`
+	InfohashV1       string  `json:"infohash_v1"`
+	InfohashV2       string  `json:"infohash_v2"`
+	Name             string  `json:"name"`
+	SavePath         string  `json:"save_path"`
+	CreationDate     int64   `json:"creation_date"`
+	AdditionDate     int64   `json:"addition_date"`
+	CompletionDate   int64   `json:"completion_date"`
+	TotalSize        int64   `json:"total_size"`
+	TotalDownloaded  int64   `json:"total_downloaded"`
+	TotalUploaded    int64   `json:"total_uploaded"`
+	ShareRatio       float64 `json:"share_ratio"`
+	DLSpeed          int64   `json:"dl_speed"`
+	UPSpeed          int64   `json:"up_speed"`
+	ETA              int64   `json:"eta"`
+	NbConnections    int     `json:"nb_connections"`
+	Peers            int     `json:"peers"`
+	PiecesNum        int     `json:"pieces_num"`
+	DLLimit          int64   `json:"dl_limit"`
+	UPLimit          int64   `json:"up_limit"`
+	IsPrivate        bool    `json:"is_private"`
+	SeedingTime      int64   `json:"seeding_time"`
+	TimeElapsed      int64   `json:"time_elapsed"`
+	SeedingTimeLimit int64   `json:"seeding_time_limit"`
+}
+
+type qbitFile struct {
+	Index      int     `json:"index"`
+	Name       string  `json:"name"`
+	Size       int64   `json:"size"`
+	Progress   float64 `json:"progress"`
+	Priority   int     `json:"priority"`
+	IsSeed     bool    `json:"is_seed"`
+	PieceRange [2]int  `json:"piece_range"`
+}
+
+type qbitCategory struct {
+	Name     string `json:"name"`
+	SavePath string `json:"savePath"`
+}
+
+// qbitPreferences reports no seeding limits and no queueing, so clients
+// neither wait for limits nor try to reorder the queue.
+type qbitPreferences struct {
+	SavePath              string  `json:"save_path"`
+	TempPathEnabled       bool    `json:"temp_path_enabled"`
+	MaxRatioEnabled       bool    `json:"max_ratio_enabled"`
+	MaxRatio              float64 `json:"max_ratio"`
+	MaxSeedingTimeEnabled bool    `json:"max_seeding_time_enabled"`
+	MaxSeedingTime        int64   `json:"max_seeding_time"`
+	MaxRatioAct           int     `json:"max_ratio_act"`
+	QueueingEnabled       bool    `json:"queueing_enabled"`
+	DHT                   bool    `json:"dht"`
+	AutoTMMEnabled        bool    `json:"auto_tmm_enabled"`
+	WebUIUsername         string  `json:"web_ui_username"`
+}
+
+const (
+	// qbitMaxUploadMemory bounds the part of a torrents/add upload kept in
+	// memory.
+	qbitMaxUploadMemory = 5 << 20
+	// qbitMaxTorrentBytes bounds a .torrent fetched from a torrents/add
+	// link.
+	qbitMaxTorrentBytes = 10 << 20
+	qbitFetchTimeout    = 30 * time.Second
+)
+
+func (s *Server) handleQBittorrent(w http.ResponseWriter, r *http.Request) {
+	if s.qbit == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "qBittorrent API not configured")
+		return
+	}
+	endpoint := strings.TrimPrefix(r.URL.Path, qbitAPIPrefix)
+	switch {
+	case qbitReadEndpoints[endpoint]:
+	case qbitWriteEndpoints[endpoint]:
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+	default:
+		writeQBitText(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if err := parseQBitForm(r); err != nil {
+		writeQBitText(w, http.StatusBadRequest, "Bad Request")
+		return
+	}
+
+	switch endpoint {
+	case "auth/login":
+		s.handleQBitLogin(w, r)
+	case "auth/logout":
+		s.handleQBitLogout(w, r)
+	case "app/version":
+		writeQBitText(w, http.StatusOK, qbitAppVersion)
+	case "app/webapiVersion":
+		writeQBitText(w, http.StatusOK, qbitWebAPIVersion)
+	case "app/defaultSavePath":
+		writeQBitText(w, http.StatusOK, s.mediaDataDir)
+	case "app/preferences":
+		s.handleQBitPreferences(w, r)
+	case "torrents/info":
+		s.handleQBitInfo(w, r)
+	case "torrents/properties":
+		s.handleQBitProperties(w, r)
+	case "torrents/files":
+		s.handleQBitFiles(w, r)
+	case "torrents/add":
+		s.handleQBitAdd(w, r)
+	case "torrents/delete":
+		s.handleQBitDelete(w, r)
+	case "torrents/pause", "torrents/stop":
+		if s.stopTorrent == nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "stop torrent use case not configured")
+			return
+		}
+		s.handleQBitControl(w, r, s.stopTorrent.Execute)
+	case "torrents/resume", "torrents/start":
+		if s.startTorrent == nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "start torrent use case not configured")
+			return
+		}
+		s.handleQBitControl(w, r, s.startTorrent.Execute)
+	case "torrents/categories":
+		s.handleQBitCategories(w, r)
+	case "torrents/createCategory":
+		s.handleQBitCreateCategory(w, r)
+	case "torrents/setCategory":
+		s.handleQBitSetCategory(w, r)
+	}
+}
+
+// handleQBitLogin answers "Fails." with status 200 for wrong credentials,
+// as qBittorrent does. Without authentication every login succeeds.
+func (s *Server) handleQBitLogin(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		writeQBitText(w, http.StatusOK, "Ok.")
+		return
+	}
+	keys := s.loginKeys(r, r.FormValue("username"))
+	if s.logins.wait(keys) > 0 {
+		// qBittorrent answers a banned client this way.
+		writeQBitText(w, http.StatusForbidden, "Your IP address has been banned after too many failed authentication attempts.")
+		return
+	}
+	result, err := s.auth.Login(r.Context(), r.FormValue("username"), r.FormValue("password"))
+	if errors.Is(err, domain.ErrInvalidCredentials) {
+		s.logins.fail(keys)
+		writeQBitText(w, http.StatusOK, "Fails.")
+		return
+	}
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	s.logins.succeed(keys)
+	http.SetCookie(w, &http.Cookie{
+		Name:     qbitSessionCookie,
+		Value:    result.Token,
+		Path:     "/",
+		Expires:  result.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeQBitText(w, http.StatusOK, "Ok.")
+}
+
+func (s *Server) handleQBitLogout(w http.ResponseWriter, r *http.Request) {
+	if s.auth != nil {
+		if cookie, err := r.Cookie(qbitSessionCookie); err == nil && cookie.Value != "" {
+			if err := s.auth.Logout(r.Context(), cookie.Value); err != nil {
+				writeDomainError(w, err)
+				return
+			}
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     qbitSessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleQBitPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs := qbitPreferences{
+		SavePath:       s.mediaDataDir,
+		MaxRatio:       -1,
+		MaxSeedingTime: -1,
+		DHT:            true,
+	}
+	if principal, ok := principalFrom(r.Context()); ok {
+		prefs.WebUIUsername = principal.User.Username
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+func (s *Server) handleQBitInfo(w http.ResponseWriter, r *http.Request) {
+	if s.repo == nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "repository not configured")
+		return
+	}
+	filter := domain.TorrentFilter{}
+	if _, ok := r.Form["category"]; ok {
+		category := r.FormValue("category")
+		filter.Category = &category
+	}
+	if tag := strings.TrimSpace(r.FormValue("tag")); tag != "" {
+		filter.Tags = []string{tag}
+	}
+	records, err := s.repo.List(r.Context(), filter)
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	hashes := qbitHashSet(r.FormValue("hashes"))
+	states := s.activeStates(r.Context())
+	stateFilter := r.FormValue("filter")
+	items := make([]qbitTorrentInfo, 0, len(records))
+	for _, record := range records {
+		if hashes != nil && !hashes[string(record.ID)] && !hashes[string(record.InfoHash)] && !hashes[string(record.InfoHashV2)] {
+			continue
+		}
+		state, live := states[record.ID]
+		info := s.qbitTorrentInfo(record, state, live)
+		if qbitMatchesFilter(info, stateFilter) {
+			items = append(items, info)
+		}
+	}
+	if field := r.FormValue("sort"); field != "" {
+		sortQBitTorrents(items, field, qbitBool(r.FormValue("reverse")))
+	}
+	writeJSON(w, http.StatusOK, qbitPage(items, r.FormValue("offset"), r.FormValue("limit")))
+}
+
+func (s *Server) handleQBitProperties(w http.ResponseWriter, r *http.Request) {
+	record, ok := s.qbitTorrentByHash(w, r)
+	if !ok {
+		return
+	}
+	state, live := s.activeStates(r.Context())[record.ID]
+	info := s.qbitTorrentInfo(record, state, live)
+	writeJSON(w, http.StatusOK, qbitProperties{
+		Hash:             info.Hash,
+		InfohashV1:       info.InfohashV1,
+		InfohashV2:       info.InfohashV2,
+		Name:             info.Name,
+		SavePath:         info.SavePath,
+		CreationDate:     -1,
+		AdditionDate:     info.AddedOn,
+		CompletionDate:   -1,
+		TotalSize:        info.Size,
+		TotalDownloaded:  info.Downloaded,
+		TotalUploaded:    info.Uploaded,
+		ShareRatio:       info.Ratio,
+		DLSpeed:          info.DLSpeed,
+		UPSpeed:          info.UPSpeed,
+		ETA:              info.ETA,
+		NbConnections:    state.Peers,
+		Peers:            state.Peers,
+		PiecesNum:        state.NumPieces,
+		DLLimit:          -1,
+		UPLimit:          -1,
+		IsPrivate:        info.IsPrivate,
+		TimeElapsed:      int64(time.Since(record.CreatedAt).Seconds()),
+		SeedingTimeLimit: -2,
+	})
+}
+
+func (s *Server) handleQBitFiles(w http.ResponseWriter, r *http.Request) {
+	record, ok := s.qbitTorrentByHash(w, r)
+	if !ok {
+		return
+	}
+	files := make([]qbitFile, 0, len(record.Files))
+	for _, f := range record.Files {
+		progress := f.Progress
+		if f.Length > 0 {
+			progress = progressRatio(f.BytesCompleted, f.Length)
+		}
+		priority := 1
+		if f.Priority == "none" {
+			priority = 0
+		}
+		last := f.PieceEnd - 1
+		if last < f.PieceStart {
+			last = f.PieceStart
+		}
+		files = append(files, qbitFile{
+			Index:      f.Index,
+			Name:       f.Path,
+			Size:       f.Length,
+			Progress:   progress,
+			Priority:   priority,
+			IsSeed:     progress >= 1,
+			PieceRange: [2]int{f.PieceStart, last},
+		})
+	}
+	writeJSON(w, http.StatusOK, files)
+}
+
+// handleQBitAdd adds magnet links and http(s) .torrent links from "urls"
+// and uploaded "torrents" files. Links that cannot be fetched are skipped;
+// "Fails." is answered when nothing was added.
+func (s *Server) handleQBitAdd(w http.ResponseWriter, r *http.Request) {
+	dataDir := s.requestedDataDir(r.FormValue("savepath"))
+	var sources []domain.TorrentSource
+	for _, link := range strings.Split(r.FormValue("urls"), "\n") {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(link), "magnet:") {
+			sources = append(sources, domain.TorrentSource{Magnet: link, DataDir: dataDir})
+			continue
+		}
+		path, err := s.fetchQBitTorrent(r.Context(), link)
+		if err != nil {
+			s.logger.Warn("qbittorrent add skipped link", slog.String("error", domain.RedactText(err.Error())))
+			continue
+		}
+		sources = append(sources, domain.TorrentSource{Torrent: path, DataDir: dataDir})
+	}
+	if r.MultipartForm != nil {
+		for _, header := range r.MultipartForm.File["torrents"] {
+			file, err := header.Open()
+			if err != nil {
+				writeQBitText(w, http.StatusBadRequest, "Fails.")
+				return
+			}
+			path, err := saveUploadedFile(file, header.Filename, s.mediaDataDir)
+			file.Close()
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", "failed to store torrent")
+				return
+			}
+			sources = append(sources, domain.TorrentSource{Torrent: path, DataDir: dataDir})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	category := strings.TrimSpace(r.FormValue("category"))
+	tags := parseCommaSeparated(r.FormValue("tags"))
+	paused := qbitBool(r.FormValue("paused")) || qbitBool(r.FormValue("stopped"))
+	added := 0
+	for _, source := range sources {
+		record, err := s.createTorrent.Execute(ctx, usecase.CreateTorrentInput{
+			Source:   source,
+			Name:     strings.TrimSpace(r.FormValue("rename")),
+			Category: category,
+		})
+		if err != nil {
+			s.logger.Warn("qbittorrent add failed", slog.String("error", err.Error()))
+			continue
+		}
+		added++
+		s.qbitAfterAdd(ctx, record, category, tags, paused)
+	}
+	if added == 0 {
+		writeQBitText(w, http.StatusOK, "Fails.")
+		return
+	}
+	writeQBitText(w, http.StatusOK, "Ok.")
+}
+
+// fetchQBitTorrent downloads the .torrent file behind an http(s) link and
+// stores it like an upload.
+func (s *Server) fetchQBitTorrent(ctx context.Context, link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("unsupported link")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.qbit.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch torrent: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, qbitMaxTorrentBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > qbitMaxTorrentBytes {
+		return "", errors.New("fetch torrent: file too large")
+	}
+	return saveUploadedFile(bytes.NewReader(data), "qbittorrent.torrent", s.mediaDataDir)
+}
+
+// qbitAfterAdd applies the add options the create use case does not take.
+// A torrent that was already present keeps its state but joins the
+// requested category.
+func (s *Server) qbitAfterAdd(ctx context.Context, record domain.TorrentRecord, category string, tags []string, paused bool) {
+	if category != "" && record.Category != category && s.qbit.categories != nil {
+		if err := s.qbit.categories.SetCategory(ctx, record.ID, category); err != nil {
+			s.logger.Warn("qbittorrent set category failed", slog.String("id", string(record.ID)), slog.String("error", err.Error()))
+		}
+	}
+	if len(tags) > 0 && s.repo != nil {
+		if err := s.repo.UpdateTags(ctx, record.ID, mergeTags(record.Tags, tags)); err != nil {
+			s.logger.Warn("qbittorrent set tags failed", slog.String("id", string(record.ID)), slog.String("error", err.Error()))
+		}
+	}
+	if paused && s.stopTorrent != nil {
+		if _, err := s.stopTorrent.Execute(ctx, record.ID); err != nil {
+			s.logger.Warn("qbittorrent pause after add failed", slog.String("id", string(record.ID)), slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (s *Server) handleQBitDelete(w http.ResponseWriter, r *http.Request) {
+	if s.deleteTorrent == nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "delete torrent use case not configured")
+		return
+	}
+	records, err := s.qbitTorrents(r.Context(), r.FormValue("hashes"))
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+	deleteFiles := qbitBool(r.FormValue("deleteFiles"))
+	for _, record := range records {
+		if err := s.deleteTorrent.Execute(r.Context(), record.ID, deleteFiles); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			writeDomainError(w, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleQBitControl pauses or resumes torrents. Like qBittorrent it
+// answers 200 even for torrents that were already in that state.
+func (s *Server) handleQBitControl(w http.ResponseWriter, r *http.Request, control func(context.Context, domain.TorrentID) (domain.TorrentRecord, error)) {
+	records, err := s.qbitTorrents(r.Context(), r.FormValue("hashes"))
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+	for _, record := range records {
+		if _, err := control(r.Context(), record.ID); err != nil {
+			s.logger.Debug("qbittorrent control skipped torrent", slog.String("id", string(record.ID)), slog.String("error", err.Error()))
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleQBitCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.qbitCategories(r.Context())
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, categories)
+}
+
+func (s *Server) handleQBitCreateCategory(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue("category"))
+	if name == "" {
+		writeQBitText(w, http.StatusBadRequest, "Category name cannot be empty")
+		return
+	}
+	if !validQBitCategory(name) {
+		writeQBitText(w, http.StatusConflict, "Incorrect category name")
+		return
+	}
+	categories, err := s.qbitCategories(r.Context())
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+	if _, ok := categories[name]; ok {
+		writeQBitText(w, http.StatusConflict, "Unable to create category")
+		return
+	}
+	s.qbit.mu.Lock()
+	s.qbit.created[name] = true
+	s.qbit.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleQBitSetCategory moves torrents to an existing category, or out of
+// their category when it is empty.
+func (s *Server) handleQBitSetCategory(w http.ResponseWriter, r *http.Request) {
+	if s.qbit.categories == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "categories not configured")
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("category"))
+	if name != "" {
+		categories, err := s.qbitCategories(r.Context())
+		if err != nil {
+			writeRepoError(w, err)
+			return
+		}
+		if _, ok := categories[name]; !ok {
+			writeQBitText(w, http.StatusConflict, "Incorrect category name")
+			return
+		}
+	}
+	records, err := s.qbitTorrents(r.Context(), r.FormValue("hashes"))
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+	for _, record := range records {
+		if err := s.qbit.categories.SetCategory(r.Context(), record.ID, name); err != nil {
+			writeRepoError(w, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// qbitCategories lists the configured categories, those created over the
+// API and those in use by torrents.
+func (s *Server) qbitCategories(ctx context.Context) (map[string]qbitCategory, error) {
+	out := make(map[string]qbitCategory)
+	for name, root := range s.qbit.roots {
+		out[name] = qbitCategory{Name: name, SavePath: root}
+	}
+	s.qbit.mu.Lock()
+	for name := range s.qbit.created {
+		if _, ok := out[name]; !ok {
+			out[name] = qbitCategory{Name: name}
+		}
+	}
+	s.qbit.mu.Unlock()
+	if s.repo == nil {
+		return out, nil
+	}
+	records, err := s.repo.List(ctx, domain.TorrentFilter{})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if _, ok := out[record.Category]; record.Category != "" && !ok {
+			out[record.Category] = qbitCategory{Name: record.Category}
+		}
+	}
+	return out, nil
+}
+
+// qbitTorrentByHash resolves the "hash" parameter, answering 404 for
+// unknown torrents.
+func (s *Server) qbitTorrentByHash(w http.ResponseWriter, r *http.Request) (domain.TorrentRecord, bool) {
+	if s.repo == nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "repository not configured")
+		return domain.TorrentRecord{}, false
+	}
+	record, err := s.torrentByHash(r.Context(), r.FormValue("hash"))
+	if errors.Is(err, domain.ErrNotFound) {
+		writeQBitText(w, http.StatusNotFound, "Not Found")
+		return domain.TorrentRecord{}, false
+	}
+	if err != nil {
+		writeRepoError(w, err)
+		return domain.TorrentRecord{}, false
+	}
+	return record, true
+}
+
+// qbitTorrents resolves a "|"-separated list of hashes, or "all". Unknown
+// hashes are skipped.
+func (s *Server) qbitTorrents(ctx context.Context, hashes string) ([]domain.TorrentRecord, error) {
+	if s.repo == nil {
+		return nil, errors.New("repository not configured")
+	}
+	if strings.TrimSpace(hashes) == "all" {
+		return s.repo.List(ctx, domain.TorrentFilter{})
+	}
+	var out []domain.TorrentRecord
+	for _, hash := range strings.Split(hashes, "|") {
+		record, err := s.torrentByHash(ctx, hash)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
+
+func (s *Server) qbitTorrentInfo(record domain.TorrentRecord, state domain.SessionState, live bool) qbitTorrentInfo {
+	size := selectedSize(record)
+	done := record.DoneBytes
+	if done > size {
+		done = size
+	}
+	complete := size > 0 && done >= size
+	savePath := s.torrentSavePath(record)
+	info := qbitTorrentInfo{
+		Hash:             string(record.ID),
+		InfohashV1:       string(record.InfoHash),
+		InfohashV2:       string(record.InfoHashV2),
+		Name:             record.Name,
+		MagnetURI:        hashMagnet(record),
+		Size:             size,
+		TotalSize:        record.TotalBytes,
+		Progress:         progressRatio(done, size),
+		Completed:        done,
+		AmountLeft:       size - done,
+		Downloaded:       state.Downloaded,
+		Uploaded:         state.Uploaded,
+		DLSpeed:          state.DownloadSpeed,
+		UPSpeed:          state.UploadSpeed,
+		ETA:              qbitMaxETA,
+		State:            qbitTorrentState(record, state, live, complete),
+		Category:         record.Category,
+		Tags:             strings.Join(record.Tags, ", "),
+		SavePath:         savePath,
+		ContentPath:      qbitContentPath(savePath, record),
+		AddedOn:          record.CreatedAt.Unix(),
+		CompletionOn:     -1,
+		LastActivity:     record.UpdatedAt.Unix(),
+		RatioLimit:       -2,
+		SeedingTimeLimit: -2,
+		MaxRatio:         -1,
+		MaxSeedingTime:   -1,
+		IsPrivate:        record.Private,
+	}
+	switch {
+	case complete:
+		info.ETA = 0
+	case state.DownloadSpeed > 0:
+		info.ETA = (size - done) / state.DownloadSpeed
+	}
+	if done > 0 {
+		info.Ratio = float64(state.Uploaded) / float64(done)
+	}
+	return info
+}
+
+// qbitTorrentState maps a torrent onto the qBittorrent state names that
+// clients use to tell queued, running, finished and failed torrents apart.
+func qbitTorrentState(record domain.TorrentRecord, state domain.SessionState, live, complete bool) string {
+	switch {
+	case record.Status == domain.TorrentError:
+		return "error"
+	case record.Status == domain.TorrentPending:
+		return "metaDL"
+	case !live || record.Status == domain.TorrentStopped:
+		if complete {
+			return "pausedUP"
+		}
+		return "pausedDL"
+	case complete:
+		if state.UploadSpeed > 0 {
+			return "uploading"
+		}
+		return "stalledUP"
+	case record.StalledSince != nil || state.DownloadSpeed == 0:
+		return "stalledDL"
+	default:
+		return "downloading"
+	}
+}
+
+// qbitMatchesFilter applies the "filter" parameter of torrents/info.
+// Unknown filters match everything.
+func qbitMatchesFilter(info qbitTorrentInfo, filter string) bool {
+	switch filter {
+	case "downloading":
+		return strings.HasSuffix(info.State, "DL") || info.State == "downloading"
+	case "seeding":
+		return info.State == "uploading" || info.State == "stalledUP"
+	case "completed":
+		return info.Progress >= 1
+	case "paused", "stopped":
+		return strings.HasPrefix(info.State, "paused")
+	case "resumed", "running":
+		return !strings.HasPrefix(info.State, "paused")
+	case "active":
+		return info.DLSpeed > 0 || info.UPSpeed > 0
+	case "inactive":
+		return info.DLSpeed == 0 && info.UPSpeed == 0
+	case "stalled":
+		return strings.HasPrefix(info.State, "stalled")
+	case "stalled_uploading":
+		return info.State == "stalledUP"
+	case "stalled_downloading":
+		return info.State == "stalledDL"
+	case "errored":
+		return info.State == "error"
+	default:
+		return true
+	}
+}
+
+func sortQBitTorrents(items []qbitTorrentInfo, field string, reverse bool) {
+	less := func(a, b qbitTorrentInfo) bool {
+		switch field {
+		case "name":
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case "size":
+			return a.Size < b.Size
+		case "total_size":
+			return a.TotalSize < b.TotalSize
+		case "progress":
+			return a.Progress < b.Progress
+		case "dlspeed":
+			return a.DLSpeed < b.DLSpeed
+		case "upspeed":
+			return a.UPSpeed < b.UPSpeed
+		case "eta":
+			return a.ETA < b.ETA
+		case "ratio":
+			return a.Ratio < b.Ratio
+		case "state":
+			return a.State < b.State
+		case "category":
+			return a.Category < b.Category
+		case "last_activity":
+			return a.LastActivity < b.LastActivity
+		default:
+			return a.AddedOn < b.AddedOn
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if reverse {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+}
+
+// qbitPage applies "offset" and "limit"; a negative offset counts from the
+// end.
+func qbitPage(items []qbitTorrentInfo, offsetValue, limitValue string) []qbitTorrentInfo {
+	offset, _ := strconv.Atoi(offsetValue)
+	if offset < 0 {
+		offset += len(items)
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if limit, err := strconv.Atoi(limitValue); err == nil && limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// qbitHashSet parses the "hashes" filter of torrents/info; nil means no
+// filter.
+func qbitHashSet(value string) map[string]bool {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "all" {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, hash := range strings.Split(value, "|") {
+		if hash = strings.ToLower(strings.TrimSpace(hash)); hash != "" {
+			set[hash] = true
+		}
+	}
+	return set
+}
+
+// qbitContentPath is the file of a single-file torrent or the top
+// directory of a multi-file torrent.
+func qbitContentPath(savePath string, record domain.TorrentRecord) string {
+	if len(record.Files) == 0 {
+		return filepath.Join(savePath, record.Name)
+	}
+	first := record.Files[0].Path
+	if len(record.Files) == 1 {
+		return filepath.Join(savePath, filepath.FromSlash(first))
+	}
+	top, _, _ := strings.Cut(first, "/")
+	return filepath.Join(savePath, top)
+}
+
+// validQBitCategory follows qBittorrent's rules for category names, which
+// may be "/"-separated subcategories.
+func validQBitCategory(name string) bool {
+	return !strings.Contains(name, "\\") && !strings.Contains(name, "//") &&
+		!strings.HasPrefix(name, "/") && !strings.HasSuffix(name, "/")
+}
+
+func parseQBitForm(r *http.Request) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return r.ParseMultipartForm(qbitMaxUploadMemory)
+	}
+	return r.ParseForm()
+}
+
+func qbitBool(value string) bool {
+	return strings.EqualFold(strings.TrimSpace(value), "true")
+}
+
+func writeQBitText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, text)
+}
@@ -0,0 +1,451 @@
+package apihttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+const (
+	qbitHashA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	qbitHashB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+// qbitRepo is a fakeRepo that looks torrents up and filters them by
+// category and info hash.
+type qbitRepo struct {
+	fakeRepo
+	records    []domain.TorrentRecord
+	categories map[domain.TorrentID]string
+}
+
+func (f *qbitRepo) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	for _, record := range f.records {
+		if record.ID == id && !record.InTrash() {
+			return f.withCategory(record), nil
+		}
+	}
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
+
+func (f *qbitRepo) List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error) {
+	f.lastFilter = filter
+	var out []domain.TorrentRecord
+	for _, record := range f.records {
+		record = f.withCategory(record)
+		if filter.Category != nil && record.Category != *filter.Category {
+			continue
+		}
+		if filter.InfoHash != "" && record.InfoHash != filter.InfoHash && record.InfoHashV2 != filter.InfoHash {
+			continue
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
+
+func (f *qbitRepo) SetCategory(ctx context.Context, id domain.TorrentID, category string) error {
+	if f.categories == nil {
+		f.categories = make(map[domain.TorrentID]string)
+	}
+	f.categories[id] = category
+	return nil
+}
+
+func (f *qbitRepo) withCategory(record domain.TorrentRecord) domain.TorrentRecord {
+	if category, ok := f.categories[record.ID]; ok {
+		record.Category = category
+	}
+	return record
+}
+
+func newQBitRepo() *qbitRepo {
+	added := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &qbitRepo{records: []domain.TorrentRecord{
+		{
+			ID:         qbitHashA,
+			InfoHash:   qbitHashA,
+			Name:       "Show.S01",
+			Status:     domain.TorrentActive,
+			Category:   "tv-sonarr",
+			TotalBytes: 300,
+			DoneBytes:  100,
+			Files: []domain.FileRef{
+				{Index: 0, Path: "Show.S01/e01.mkv", Length: 200, BytesCompleted: 100, PieceStart: 0, PieceEnd: 2},
+				{Index: 1, Path: "Show.S01/sample.mkv", Length: 100, Priority: "none", PieceStart: 2, PieceEnd: 3},
+			},
+			Source:    domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:" + qbitHashA + "&tr=https://tracker/announce?passkey=secret"},
+			CreatedAt: added,
+			UpdatedAt: added,
+		},
+		{
+			ID:         qbitHashB,
+			InfoHash:   qbitHashB,
+			Name:       "Movie.mkv",
+			Status:     domain.TorrentCompleted,
+			TotalBytes: 500,
+			DoneBytes:  500,
+			Files:      []domain.FileRef{{Index: 0, Path: "Movie.mkv", Length: 500, BytesCompleted: 500}},
+			Source:     domain.TorrentSource{DataDir: "/media/movies"},
+			CreatedAt:  added.Add(time.Hour),
+			UpdatedAt:  added.Add(time.Hour),
+		},
+	}}
+}
+
+func newQBitServer(repo *qbitRepo, opts ...ServerOption) *Server {
+	opts = append([]ServerOption{
+		WithRepository(repo),
+		WithListTorrentStates(&fakeListTorrentStates{result: []domain.SessionState{
+			{ID: qbitHashA, Status: domain.TorrentActive, DownloadSpeed: 50, Peers: 4, NumPieces: 3},
+		}}),
+		WithQBittorrent(QBittorrentConfig{Categories: repo, CategoryRoots: map[string]string{"tv-sonarr": "/media/tv"}}),
+	}, opts...)
+	return NewServer(&fakeCreateTorrent{}, opts...)
+}
+
+func doQBitRequest(s *Server, endpoint string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, qbitAPIPrefix+endpoint, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeQBitInfo(t *testing.T, rec *httptest.ResponseRecorder) []qbitTorrentInfo {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var items []qbitTorrentInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return items
+}
+
+func TestQBitNotConfigured(t *testing.T) {
+	s := NewServer(&fakeCreateTorrent{})
+	rec := doQBitRequest(s, "app/version", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("status = %d, want 501", rec.Code)
+	}
+}
+
+func TestQBitVersionAndEndpoints(t *testing.T) {
+	s := newQBitServer(newQBitRepo())
+
+	req := httptest.NewRequest(http.MethodGet, qbitAPIPrefix+"app/version", nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != qbitAppVersion {
+		t.Fatalf("version: %d %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, qbitAPIPrefix+"torrents/delete?hashes=all", nil)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET delete: got %d, want 405", rec.Code)
+	}
+
+	rec = doQBitRequest(s, "sync/maindata", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown endpoint: got %d, want 404", rec.Code)
+	}
+}
+
+func TestQBitLogin(t *testing.T) {
+	s := newQBitServer(newQBitRepo())
+	rec := doQBitRequest(s, "auth/login", url.Values{"username": {"admin"}, "password": {"x"}})
+	if rec.Body.String() != "Ok." {
+		t.Fatalf("login without auth: %q", rec.Body.String())
+	}
+
+	s = newQBitServer(newQBitRepo(), WithAuth(newFakeAuthUseCase()))
+	rec = doQBitRequest(s, "auth/login", url.Values{"username": {"user"}, "password": {"wrong"}})
+	if rec.Code != http.StatusOK || rec.Body.String() != "Fails." {
+		t.Fatalf("wrong password: %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = doQBitRequest(s, "auth/login", url.Values{"username": {"user"}, "password": {"password123"}})
+	if rec.Body.String() != "Ok." {
+		t.Fatalf("login: %q", rec.Body.String())
+	}
+	var sid *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == qbitSessionCookie {
+			sid = cookie
+		}
+	}
+	if sid == nil || sid.Value != "session-user" {
+		t.Fatalf("SID cookie = %+v", sid)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, qbitAPIPrefix+"torrents/info", nil)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Body.String() != "Forbidden" {
+		t.Fatalf("anonymous info: %d %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, qbitAPIPrefix+"torrents/info", nil)
+	req.AddCookie(sid)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("info with SID: got %d", rec.Code)
+	}
+}
+
+func TestQBitInfo(t *testing.T) {
+	s := newQBitServer(newQBitRepo())
+
+	items := decodeQBitInfo(t, doQBitRequest(s, "torrents/info", nil))
+	if len(items) != 2 {
+		t.Fatalf("items = %d, want 2", len(items))
+	}
+	show, movie := items[0], items[1]
+	if show.Hash != qbitHashA || show.State != "downloading" || show.Category != "tv-sonarr" {
+		t.Fatalf("show = %+v", show)
+	}
+	if show.Size != 200 || show.TotalSize != 300 || show.Progress != 0.5 || show.AmountLeft != 100 || show.ETA != 2 {
+		t.Fatalf("show sizes = %+v", show)
+	}
+	if show.ContentPath != "Show.S01" {
+		t.Fatalf("show content path = %q", show.ContentPath)
+	}
+	if strings.Contains(show.MagnetURI, "passkey") {
+		t.Fatalf("magnet leaks tracker credentials: %q", show.MagnetURI)
+	}
+	if movie.State != "pausedUP" || movie.Progress != 1 || movie.ETA != 0 {
+		t.Fatalf("movie = %+v", movie)
+	}
+	if movie.SavePath != "/media/movies" || movie.ContentPath != "/media/movies/Movie.mkv" {
+		t.Fatalf("movie paths = %q %q", movie.SavePath, movie.ContentPath)
+	}
+
+	items = decodeQBitInfo(t, doQBitRequest(s, "torrents/info", url.Values{"category": {"tv-sonarr"}}))
+	if len(items) != 1 || items[0].Hash != qbitHashA {
+		t.Fatalf("category filter = %+v", items)
+	}
+
+	items = decodeQBitInfo(t, doQBitRequest(s, "torrents/info", url.Values{"filter": {"completed"}}))
+	if len(items) != 1 || items[0].Hash != qbitHashB {
+		t.Fatalf("completed filter = %+v", items)
+	}
+
+	items = decodeQBitInfo(t, doQBitRequest(s, "torrents/info", url.Values{"hashes": {strings.ToUpper(qbitHashB)}}))
+	if len(items) != 1 || items[0].Hash != qbitHashB {
+		t.Fatalf("hashes filter = %+v", items)
+	}
+
+	items = decodeQBitInfo(t, doQBitRequest(s, "torrents/info", url.Values{"sort": {"added_on"}, "reverse": {"true"}, "limit": {"1"}}))
+	if len(items) != 1 || items[0].Hash != qbitHashB {
+		t.Fatalf("sorted page = %+v", items)
+	}
+}
+
+func TestQBitPropertiesAndFiles(t *testing.T) {
+	s := newQBitServer(newQBitRepo())
+
+	rec := doQBitRequest(s, "torrents/properties", url.Values{"hash": {qbitHashA}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("properties: %d", rec.Code)
+	}
+	var props qbitProperties
+	if err := json.Unmarshal(rec.Body.Bytes(), &props); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if props.Hash != qbitHashA || props.Peers != 4 || props.PiecesNum != 3 {
+		t.Fatalf("properties = %+v", props)
+	}
+
+	rec = doQBitRequest(s, "torrents/properties", url.Values{"hash": {"cccc"}})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown hash: got %d, want 404", rec.Code)
+	}
+
+	rec = doQBitRequest(s, "torrents/files", url.Values{"hash": {qbitHashA}})
+	var files []qbitFile
+	if err := json.Unmarshal(rec.Body.Bytes(), &files); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("files = %+v", files)
+	}
+	if files[0].Progress != 0.5 || files[0].Priority != 1 || files[0].PieceRange != [2]int{0, 1} {
+		t.Fatalf("file 0 = %+v", files[0])
+	}
+	if files[1].Priority != 0 {
+		t.Fatalf("file 1 priority = %d, want 0", files[1].Priority)
+	}
+}
+
+func TestQBitAddMagnet(t *testing.T) {
+	repo := newQBitRepo()
+	create := &fakeCreateTorrent{result: domain.TorrentRecord{ID: "c1", Name: "New"}}
+	stop := &fakeStopTorrent{}
+	s := NewServer(create,
+		WithRepository(repo),
+		WithStopTorrent(stop),
+		WithStorageRoots("/media/tv"),
+		WithQBittorrent(QBittorrentConfig{Categories: repo}),
+	)
+
+	rec := doQBitRequest(s, "torrents/add", url.Values{
+		"urls":     {"magnet:?xt=urn:btih:c1\nftp://indexer/file.torrent"},
+		"category": {"tv-sonarr"},
+		"savepath": {"/media/tv/"},
+		"tags":     {"sonarr"},
+		"paused":   {"true"},
+	})
+	if rec.Code != http.StatusOK || rec.Body.String() != "Ok." {
+		t.Fatalf("add: %d %q", rec.Code, rec.Body.String())
+	}
+	if create.called != 1 {
+		t.Fatalf("create called %d times, want 1", create.called)
+	}
+	if create.input.Source.Magnet != "magnet:?xt=urn:btih:c1" || create.input.Category != "tv-sonarr" || create.input.Source.DataDir != "/media/tv" {
+		t.Fatalf("input = %+v", create.input)
+	}
+	if stop.id != "c1" {
+		t.Fatalf("stopped %q, want c1", stop.id)
+	}
+	if repo.lastTagsID != "c1" || len(repo.lastTags) != 1 || repo.lastTags[0] != "sonarr" {
+		t.Fatalf("tags = %q %v", repo.lastTagsID, repo.lastTags)
+	}
+
+	create.called = 0
+	rec = doQBitRequest(s, "torrents/add", url.Values{"urls": {"ftp://indexer/file.torrent"}, "savepath": {"/etc"}})
+	if rec.Body.String() != "Fails." || create.called != 0 {
+		t.Fatalf("add unsupported link: %q, create called %d", rec.Body.String(), create.called)
+	}
+}
+
+func TestQBitAddTorrentURL(t *testing.T) {
+	const metainfo = "d4:infod4:name4:showee"
+	indexer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/show.torrent" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(metainfo))
+	}))
+	defer indexer.Close()
+
+	create := &fakeCreateTorrent{result: domain.TorrentRecord{ID: "c1"}}
+	s := NewServer(create, WithMediaProbe(nil, t.TempDir()), WithQBittorrent(QBittorrentConfig{}))
+
+	rec := doQBitRequest(s, "torrents/add", url.Values{"urls": {indexer.URL + "/show.torrent"}})
+	if rec.Body.String() != "Ok." || create.called != 1 {
+		t.Fatalf("add: %q, create called %d", rec.Body.String(), create.called)
+	}
+	data, err := os.ReadFile(create.input.Source.Torrent)
+	if err != nil || string(data) != metainfo {
+		t.Fatalf("stored torrent = %q, %v", data, err)
+	}
+
+	create.called = 0
+	rec = doQBitRequest(s, "torrents/add", url.Values{"urls": {indexer.URL + "/missing.torrent"}})
+	if rec.Body.String() != "Fails." || create.called != 0 {
+		t.Fatalf("add missing: %q, create called %d", rec.Body.String(), create.called)
+	}
+}
+
+func TestQBitAddTorrentFile(t *testing.T) {
+	create := &fakeCreateTorrent{result: domain.TorrentRecord{ID: "c1"}}
+	s := NewServer(create, WithMediaProbe(nil, t.TempDir()), WithQBittorrent(QBittorrentConfig{}))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("torrents", "show.torrent")
+	part.Write([]byte("d4:infod4:name4:showee"))
+	mw.WriteField("rename", "Renamed")
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, qbitAPIPrefix+"torrents/add", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Body.String() != "Ok." {
+		t.Fatalf("add: %d %q", rec.Code, rec.Body.String())
+	}
+	if create.input.Source.Torrent == "" || create.input.Name != "Renamed" {
+		t.Fatalf("input = %+v", create.input)
+	}
+}
+
+func TestQBitDeleteAndControl(t *testing.T) {
+	del := &fakeDeleteTorrent{}
+	start := &fakeStartTorrent{}
+	stop := &fakeStopTorrent{}
+	s := newQBitServer(newQBitRepo(), WithDeleteTorrent(del), WithStartTorrent(start), WithStopTorrent(stop))
+
+	rec := doQBitRequest(s, "torrents/delete", url.Values{"hashes": {qbitHashA + "|cccc"}, "deleteFiles": {"true"}})
+	if rec.Code != http.StatusOK || del.called != 1 || del.id != qbitHashA || !del.deleteFiles {
+		t.Fatalf("delete: %d %+v", rec.Code, del)
+	}
+
+	rec = doQBitRequest(s, "torrents/pause", url.Values{"hashes": {"all"}})
+	if rec.Code != http.StatusOK || len(stop.ids) != 2 {
+		t.Fatalf("pause: %d %v", rec.Code, stop.ids)
+	}
+
+	start.err = domain.ErrNotFound
+	rec = doQBitRequest(s, "torrents/start", url.Values{"hashes": {qbitHashB}})
+	if rec.Code != http.StatusOK || len(start.ids) != 1 || start.ids[0] != qbitHashB {
+		t.Fatalf("start: %d %v", rec.Code, start.ids)
+	}
+}
+
+func TestQBitCategories(t *testing.T) {
+	repo := newQBitRepo()
+	s := newQBitServer(repo)
+
+	rec := doQBitRequest(s, "torrents/createCategory", url.Values{"category": {"radarr"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create: %d", rec.Code)
+	}
+	rec = doQBitRequest(s, "torrents/createCategory", url.Values{"category": {"radarr"}})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("create existing: got %d, want 409", rec.Code)
+	}
+	rec = doQBitRequest(s, "torrents/createCategory", url.Values{"category": {""}})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("create empty: got %d, want 400", rec.Code)
+	}
+
+	rec = doQBitRequest(s, "torrents/categories", nil)
+	var categories map[string]qbitCategory
+	if err := json.Unmarshal(rec.Body.Bytes(), &categories); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(categories) != 2 || categories["tv-sonarr"].SavePath != "/media/tv" || categories["radarr"].Name != "radarr" {
+		t.Fatalf("categories = %+v", categories)
+	}
+
+	rec = doQBitRequest(s, "torrents/setCategory", url.Values{"hashes": {qbitHashB}, "category": {"unknown"}})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("set unknown: got %d, want 409", rec.Code)
+	}
+	rec = doQBitRequest(s, "torrents/setCategory", url.Values{"hashes": {qbitHashB}, "category": {"radarr"}})
+	if rec.Code != http.StatusOK || repo.categories[qbitHashB] != "radarr" {
+		t.Fatalf("set: %d %v", rec.Code, repo.categories)
+	}
+	rec = doQBitRequest(s, "torrents/setCategory", url.Values{"hashes": {qbitHashA}, "category": {""}})
+	if rec.Code != http.StatusOK || repo.categories[qbitHashA] != "" {
+		t.Fatalf("clear: %d %v", rec.Code, repo.categories)
+	}
+}
@@ -0,0 +1,135 @@
+package apihttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Retention settings and cleanup handlers.
+
+type updateRetentionSettingsRequest struct {
+	WatchedDays   *int      `json:"watchedDays"`
+	UntouchedDays *int      `json:"untouchedDays"`
+	MaxTotalBytes *int64    `json:"maxTotalBytes"`
+	KeepTags      *[]string `json:"keepTags"`
+	KeepTagged    *bool     `json:"keepTagged"`
+	KeepFiles     *bool     `json:"keepFiles"`
+	DryRun        *bool     `json:"dryRun"`
+}
+
+func (s *Server) handleRetentionSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetRetentionSettings(w, r)
+	case http.MethodPatch, http.MethodPut:
+		s.handleUpdateRetentionSettings(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleGetRetentionSettings(w http.ResponseWriter, _ *http.Request) {
+	if s.retentionSettings == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "retention settings not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.retentionSettings.Get())
+}
+
+func (s *Server) handleUpdateRetentionSettings(w http.ResponseWriter, r *http.Request) {
+	if s.retentionSettings == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "retention settings not configured")
+		return
+	}
+
+	var body updateRetentionSettingsRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return
+	}
+
+	next := s.retentionSettings.Get()
+	if body.WatchedDays != nil {
+		if *body.WatchedDays < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "watchedDays must be >= 0")
+			return
+		}
+		next.WatchedDays = *body.WatchedDays
+	}
+	if body.UntouchedDays != nil {
+		if *body.UntouchedDays < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "untouchedDays must be >= 0")
+			return
+		}
+		next.UntouchedDays = *body.UntouchedDays
+	}
+	if body.MaxTotalBytes != nil {
+		if *body.MaxTotalBytes < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "maxTotalBytes must be >= 0")
+			return
+		}
+		next.MaxTotalBytes = *body.MaxTotalBytes
+	}
+	if body.KeepTags != nil {
+		tags := make([]string, 0, len(*body.KeepTags))
+		for _, tag := range *body.KeepTags {
+			if t := strings.TrimSpace(tag); t != "" {
+				tags = append(tags, t)
+			}
+		}
+		next.KeepTags = tags
+	}
+	if body.KeepTagged != nil {
+		next.KeepTagged = *body.KeepTagged
+	}
+	if body.KeepFiles != nil {
+		next.KeepFiles = *body.KeepFiles
+	}
+	if body.DryRun != nil {
+		next.DryRun = *body.DryRun
+	}
+
+	if err := s.retentionSettings.Update(next); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to update retention settings")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.retentionSettings.Get())
+}
+
+func (s *Server) handleRetentionPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.retention == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "retention not configured")
+		return
+	}
+	report, err := s.retention.Preview(r.Context())
+	if err != nil {
+		writeUseCaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleRetentionRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.retention == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "retention not configured")
+		return
+	}
+	report, err := s.retention.Apply(r.Context())
+	if err != nil {
+		writeUseCaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
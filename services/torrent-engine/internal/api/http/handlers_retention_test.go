@@ -0,0 +1,173 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+type fakeRetentionSettingsCtrl struct {
+	policy    domain.RetentionPolicy
+	updateErr error
+}
+
+func (f *fakeRetentionSettingsCtrl) Get() domain.RetentionPolicy { return f.policy }
+func (f *fakeRetentionSettingsCtrl) Update(p domain.RetentionPolicy) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.policy = p
+	return nil
+}
+
+type fakeRetentionUseCase struct {
+	report       usecase.RetentionReport
+	err          error
+	previewCalls int
+	applyCalls   int
+}
+
+func (f *fakeRetentionUseCase) Preview(_ context.Context) (usecase.RetentionReport, error) {
+	f.previewCalls++
+	report := f.report
+	report.DryRun = true
+	return report, f.err
+}
+
+func (f *fakeRetentionUseCase) Apply(_ context.Context) (usecase.RetentionReport, error) {
+	f.applyCalls++
+	return f.report, f.err
+}
+
+func TestGetRetentionSettings(t *testing.T) {
+	ctrl := &fakeRetentionSettingsCtrl{policy: domain.RetentionPolicy{WatchedDays: 7, KeepTags: []string{"pinned"}}}
+	s := NewServer(nil, WithRetentionSettings(ctrl))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/settings/retention", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got domain.RetentionPolicy
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.WatchedDays != 7 || len(got.KeepTags) != 1 {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+}
+
+func TestRetentionSettings_NotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodGet, "/settings/retention", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+}
+
+func TestUpdateRetentionSettings_PartialUpdate(t *testing.T) {
+	ctrl := &fakeRetentionSettingsCtrl{policy: domain.RetentionPolicy{WatchedDays: 7, KeepTags: []string{"pinned"}}}
+	s := NewServer(nil, WithRetentionSettings(ctrl))
+
+	body := []byte(`{"maxTotalBytes":1073741824,"keepTags":[" keep ",""],"dryRun":true}`)
+	rec := doSettingsRequest(s, http.MethodPatch, "/settings/retention", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	p := ctrl.policy
+	if p.WatchedDays != 7 || p.MaxTotalBytes != 1073741824 || !p.DryRun {
+		t.Fatalf("unexpected policy: %+v", p)
+	}
+	if len(p.KeepTags) != 1 || p.KeepTags[0] != "keep" {
+		t.Fatalf("keepTags = %v, want [keep]", p.KeepTags)
+	}
+}
+
+func TestUpdateRetentionSettings_InvalidValues(t *testing.T) {
+	ctrl := &fakeRetentionSettingsCtrl{}
+	s := NewServer(nil, WithRetentionSettings(ctrl))
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "negative watchedDays", body: `{"watchedDays":-1}`},
+		{name: "negative untouchedDays", body: `{"untouchedDays":-1}`},
+		{name: "negative maxTotalBytes", body: `{"maxTotalBytes":-1}`},
+		{name: "unknown field", body: `{"foo":1}`},
+		{name: "bad json", body: `{bad`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := doSettingsRequest(s, http.MethodPatch, "/settings/retention", []byte(tc.body))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateRetentionSettings_StoreError(t *testing.T) {
+	ctrl := &fakeRetentionSettingsCtrl{updateErr: errors.New("mongo down")}
+	s := NewServer(nil, WithRetentionSettings(ctrl))
+
+	rec := doSettingsRequest(s, http.MethodPut, "/settings/retention", []byte(`{"untouchedDays":30}`))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+}
+
+func TestRetentionPreviewAndRun(t *testing.T) {
+	uc := &fakeRetentionUseCase{report: usecase.RetentionReport{
+		ReclaimableBytes: 100,
+		Candidates: []usecase.RetentionCandidate{
+			{ID: "t1", Name: "Movie", Reason: usecase.RetentionWatched, Bytes: 100},
+		},
+	}}
+	s := NewServer(nil, WithRetention(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/retention/preview", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preview: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report usecase.RetentionReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !report.DryRun || len(report.Candidates) != 1 || report.Candidates[0].ID != "t1" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	rec = doSettingsRequest(s, http.MethodPost, "/retention/run", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("run: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if uc.previewCalls != 1 || uc.applyCalls != 1 {
+		t.Fatalf("calls: preview=%d apply=%d", uc.previewCalls, uc.applyCalls)
+	}
+
+	rec = doSettingsRequest(s, http.MethodGet, "/retention/run", nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestRetentionRun_Errors(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodPost, "/retention/run", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+
+	uc := &fakeRetentionUseCase{err: usecase.ErrRepository}
+	s = NewServer(nil, WithRetention(uc))
+	rec = doSettingsRequest(s, http.MethodGet, "/retention/preview", nil)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+}
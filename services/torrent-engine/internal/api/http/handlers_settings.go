@@ -9,9 +9,11 @@ import (
 
 	"torrentstream/internal/app"
 	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
 )
 
 type playerSettingsResponse struct {
+	ProfileID                string           `json:"profileId,omitempty"`
 	CurrentTorrentID         domain.TorrentID `json:"currentTorrentId,omitempty"`
 	PrioritizeActiveFileOnly bool             `json:"prioritizeActiveFileOnly"`
 }
@@ -118,15 +120,40 @@ func (s *Server) handlePlayerHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, s.BuildPlayerHealth(r.Context()))
 }
 
-func (s *Server) handleGetPlayerSettings(w http.ResponseWriter, _ *http.Request) {
+func (s *Server) handleGetPlayerSettings(w http.ResponseWriter, r *http.Request) {
 	if s.player == nil {
 		writeError(w, http.StatusNotImplemented, "not_configured", "player settings are not configured")
 		return
 	}
-	writeJSON(w, http.StatusOK, playerSettingsResponse{
+	resp, err := s.playerSettings(r)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// playerSettings returns the player settings of the current profile. The
+// engine follows whichever profile focused a torrent last, so a profile
+// without its own preference sees the engine's.
+func (s *Server) playerSettings(r *http.Request) (playerSettingsResponse, error) {
+	resp := playerSettingsResponse{
 		CurrentTorrentID:         s.player.CurrentTorrentID(),
 		PrioritizeActiveFileOnly: s.player.PrioritizeActiveFileOnly(),
-	})
+	}
+	if s.profiles == nil {
+		return resp, nil
+	}
+	profile, err := s.currentProfile(r)
+	if err != nil {
+		return playerSettingsResponse{}, err
+	}
+	resp.ProfileID = profile.ID
+	resp.CurrentTorrentID = profile.CurrentTorrentID
+	if profile.PrioritizeActiveFileOnly != nil {
+		resp.PrioritizeActiveFileOnly = *profile.PrioritizeActiveFileOnly
+	}
+	return resp, nil
 }
 
 func (s *Server) handleUpdatePlayerSettings(w http.ResponseWriter, r *http.Request) {
@@ -147,12 +174,14 @@ func (s *Server) handleUpdatePlayerSettings(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	update := usecase.PlayerStateUpdate{PrioritizeActiveFileOnly: body.PrioritizeActiveFileOnly}
 	if body.CurrentTorrentID != nil {
 		id := domain.TorrentID(strings.TrimSpace(*body.CurrentTorrentID))
 		if err := s.setCurrentTorrentID(r.Context(), id); err != nil {
 			writeDomainError(w, err)
 			return
 		}
+		update.CurrentTorrentID = &id
 	}
 
 	if body.PrioritizeActiveFileOnly != nil {
@@ -162,10 +191,16 @@ func (s *Server) handleUpdatePlayerSettings(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	writeJSON(w, http.StatusOK, playerSettingsResponse{
-		CurrentTorrentID:         s.player.CurrentTorrentID(),
-		PrioritizeActiveFileOnly: s.player.PrioritizeActiveFileOnly(),
-	})
+	if err := s.recordPlayerState(r, update); err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	resp, err := s.playerSettings(r)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
 	s.BroadcastPlayerSettings()
 }
 
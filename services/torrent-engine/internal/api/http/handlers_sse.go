@@ -0,0 +1,106 @@
+package apihttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+// sseKeepAlive is the interval of comment lines that keep idle streams open
+// through proxies.
+const sseKeepAlive = 30 * time.Second
+
+// handleEventStream serves GET /events: the WebSocket messages as
+// server-sent events, for clients that cannot use /ws. The stream is a
+// subscription (see wsSubscribeRequest) built from the topics and torrents
+// query parameters; Last-Event-ID resumes after a sequence number.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.wsHub == nil {
+		writeError(w, http.StatusServiceUnavailable, "not_configured", "event stream not available")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "internal_error", "streaming unsupported")
+		return
+	}
+
+	query := r.URL.Query()
+	req := wsSubscribeRequest{Action: "subscribe", Topics: parseCommaSeparated(query.Get("topics"))}
+	for _, id := range parseCommaSeparated(query.Get("torrents")) {
+		req.Torrents = append(req.Torrents, domain.TorrentID(id))
+	}
+	lastID := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if lastID == "" {
+		lastID = strings.TrimSpace(query.Get("lastEventId"))
+	}
+	if lastID != "" {
+		since, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid Last-Event-ID")
+			return
+		}
+		req.Since = since
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	client := &wsClient{hub: s.wsHub, send: make(chan []byte, 256)}
+	if !s.wsHub.attachClient(client, req) {
+		return
+	}
+	defer s.wsHub.detachClient(client)
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return // Client disconnected
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case msg, ok := <-client.send:
+			if !ok {
+				return // Hub closed or the client fell behind
+			}
+			if err := writeSSEMessage(w, msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSEMessage writes a hub message as an event named after its type,
+// with its sequence number as the event ID.
+func writeSSEMessage(w http.ResponseWriter, msg []byte) error {
+	var head struct {
+		Type string `json:"type"`
+		Seq  uint64 `json:"seq"`
+	}
+	_ = json.Unmarshal(msg, &head)
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", head.Seq, head.Type, msg); err != nil {
+		return err // Client disconnected
+	}
+	return nil
+}
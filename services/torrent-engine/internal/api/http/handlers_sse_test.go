@@ -0,0 +1,120 @@
+package apihttp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// openEventStream connects to /events and returns its events on a channel.
+func openEventStream(t *testing.T, srv *httptest.Server, query, lastID string) <-chan sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events"+query, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get /events: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("status=%d content-type=%q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	out := make(chan sseEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		var ev sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if ev.event != "" {
+					out <- ev
+				}
+				ev = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				ev.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				ev.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	return out
+}
+
+func nextSSEEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no event received")
+	}
+	return sseEvent{}
+}
+
+func TestEventStreamFiltersAndResumes(t *testing.T) {
+	s := makeWSServer()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	defer s.Close()
+
+	events := openEventStream(t, srv, "?topics=states&torrents=a", "")
+	s.BroadcastStates([]domain.SessionState{{ID: "a", Progress: 0.1}, {ID: "b", Progress: 0.1}})
+	s.wsHub.Broadcast("health", map[string]string{"status": "ok"})
+
+	ev := nextSSEEvent(t, events)
+	if ev.event != "states" || ev.id != "1" {
+		t.Fatalf("event = %+v", ev)
+	}
+	var msg wsDeltaMessage
+	if err := json.Unmarshal([]byte(ev.data), &msg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(msg.Changed) != 1 || string(msg.Changed[0]["id"]) != `"a"` {
+		t.Fatalf("message = %+v", msg)
+	}
+
+	s.BroadcastStates([]domain.SessionState{{ID: "a", Progress: 0.4}})
+	if ev := nextSSEEvent(t, events); ev.id != "3" {
+		t.Fatalf("event = %+v", ev)
+	}
+
+	resumed := openEventStream(t, srv, "?topics=states,health", "1")
+	for _, want := range []string{"health", "states"} {
+		if ev := nextSSEEvent(t, resumed); ev.event != want {
+			t.Fatalf("replayed %q, want %q", ev.event, want)
+		}
+	}
+}
+
+func TestEventStreamRejectsInvalidLastEventID(t *testing.T) {
+	s := makeWSServer()
+	defer s.Close()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
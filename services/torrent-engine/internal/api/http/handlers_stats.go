@@ -0,0 +1,134 @@
+package apihttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type torrentStatsResponse struct {
+	TorrentID domain.TorrentID      `json:"torrentId"`
+	From      time.Time             `json:"from"`
+	To        time.Time             `json:"to"`
+	Step      int64                 `json:"step"` // seconds; 0 returns raw samples
+	Totals    domain.TransferTotals `json:"totals"`
+	Items     []domain.StatsSample  `json:"items"`
+	Count     int                   `json:"count"`
+}
+
+// handleTorrentStats serves GET /torrents/{id}/stats?from=&to=&step=. from
+// and to accept RFC 3339 or unix seconds and default to the last hour; step
+// accepts a duration ("5m") or seconds.
+func (s *Server) handleTorrentStats(w http.ResponseWriter, r *http.Request, id string) {
+	if s.statsHistory == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "stats history not configured")
+		return
+	}
+	torrentID := domain.TorrentID(id)
+	if s.repo != nil {
+		if _, err := s.repo.Get(r.Context(), torrentID); err != nil {
+			writeRepoError(w, err)
+			return
+		}
+	}
+
+	query := r.URL.Query()
+	to, ok := parseStatsTime(query.Get("to"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid to")
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	from, ok := parseStatsTime(query.Get("from"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid from")
+		return
+	}
+	if from.IsZero() {
+		from = to.Add(-time.Hour)
+	}
+	step, ok := parseStatsStep(query.Get("step"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid step")
+		return
+	}
+
+	items, err := s.statsHistory.Query(r.Context(), torrentID, from, to, step)
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	totals, err := s.statsHistory.Totals(r.Context(), torrentID)
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, torrentStatsResponse{
+		TorrentID: torrentID,
+		From:      from,
+		To:        to,
+		Step:      int64(step / time.Second),
+		Totals:    totals,
+		Items:     items,
+		Count:     len(items),
+	})
+}
+
+// handleGlobalStats serves GET /stats: session and all-time transfer
+// counters, torrents per status, DHT nodes and uptime.
+func (s *Server) handleGlobalStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.transferStats == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "transfer stats not configured")
+		return
+	}
+	stats, err := s.transferStats.Get(r.Context())
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// parseStatsTime parses RFC 3339 or unix seconds. An empty value is the
+// zero time.
+func parseStatsTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, true
+	}
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil && sec >= 0 {
+		return time.Unix(sec, 0).UTC(), true
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+// parseStatsStep parses a duration or whole seconds. An empty value means
+// no down-sampling.
+func parseStatsStep(raw string) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, true
+	}
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	step, err := time.ParseDuration(raw)
+	if err != nil || step < 0 {
+		return 0, false
+	}
+	return step, true
+}
@@ -0,0 +1,139 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+type fakeStatsHistory struct {
+	samples  []domain.StatsSample
+	totals   domain.TransferTotals
+	err      error
+	id       domain.TorrentID
+	from, to time.Time
+	step     time.Duration
+}
+
+func (f *fakeStatsHistory) Query(ctx context.Context, id domain.TorrentID, from, to time.Time, step time.Duration) ([]domain.StatsSample, error) {
+	f.id, f.from, f.to, f.step = id, from, to, step
+	return f.samples, f.err
+}
+
+func (f *fakeStatsHistory) Totals(ctx context.Context, id domain.TorrentID) (domain.TransferTotals, error) {
+	return f.totals, nil
+}
+
+func TestTorrentStats(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	uc := &fakeStatsHistory{
+		samples: []domain.StatsSample{{Time: at, DownloadSpeed: 2048, Peers: 7, Progress: 0.5, Downloaded: 1 << 20}},
+		totals:  domain.TransferTotals{TorrentID: "t1", Downloaded: 1 << 20, Uploaded: 512},
+	}
+	s := NewServer(nil, WithStatsHistory(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/stats?from=1767322800&to=2026-01-02T04:00:00Z&step=5m", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got torrentStatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if uc.id != "t1" || uc.step != 5*time.Minute {
+		t.Fatalf("query id=%q step=%v", uc.id, uc.step)
+	}
+	if !uc.from.Equal(time.Unix(1767322800, 0)) || !uc.to.Equal(time.Date(2026, 1, 2, 4, 0, 0, 0, time.UTC)) {
+		t.Fatalf("query range %v..%v", uc.from, uc.to)
+	}
+	if got.Count != 1 || got.Step != 300 || got.Items[0].Peers != 7 || got.Totals.Uploaded != 512 {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+}
+
+func TestTorrentStatsDefaultsToLastHour(t *testing.T) {
+	uc := &fakeStatsHistory{}
+	s := NewServer(nil, WithStatsHistory(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/stats", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if uc.to.Sub(uc.from) != time.Hour || uc.step != 0 {
+		t.Fatalf("query range %v..%v step %v", uc.from, uc.to, uc.step)
+	}
+}
+
+func TestTorrentStatsInvalidParams(t *testing.T) {
+	uc := &fakeStatsHistory{}
+	s := NewServer(nil, WithStatsHistory(uc))
+
+	for _, query := range []string{"from=yesterday", "to=-", "step=fast", "step=-5s"} {
+		rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/stats?"+query, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+
+	uc.err = usecase.ErrInvalidStatsRange
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/stats?from=200&to=100", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid range, got %d", rec.Code)
+	}
+}
+
+func TestTorrentStatsNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/stats", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+}
+
+type fakeTransferStats struct {
+	stats domain.GlobalStats
+	err   error
+}
+
+func (f *fakeTransferStats) Get(ctx context.Context) (domain.GlobalStats, error) {
+	return f.stats, f.err
+}
+
+func TestGlobalStats(t *testing.T) {
+	uc := &fakeTransferStats{stats: domain.GlobalStats{
+		AllTime:       domain.TransferCounters{Downloaded: 1000, Uploaded: 1500, WastedBytes: 16384},
+		Ratio:         1.5,
+		Torrents:      map[domain.TorrentStatus]int{domain.TorrentActive: 2},
+		TotalTorrents: 2,
+		DHTNodes:      80,
+	}}
+	s := NewServer(nil, WithTransferStats(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/stats", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got domain.GlobalStats
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.AllTime.WastedBytes != 16384 || got.Ratio != 1.5 || got.Torrents[domain.TorrentActive] != 2 || got.DHTNodes != 80 {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+
+	uc.err = usecase.ErrRepository
+	rec = doSettingsRequest(s, http.MethodGet, "/stats", nil)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+
+	rec = doSettingsRequest(s, http.MethodPost, "/stats", nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
@@ -52,7 +52,7 @@ func (s *Server) handleStreamTorrent(w http.ResponseWriter, r *http.Request, id
 	if s.mediaDataDir != "" {
 		if file, ok := s.resolveFileRef(r.Context(), domain.TorrentID(id), fileIndex); ok {
 			if file.Length > 0 && file.BytesCompleted >= file.Length {
-				filePath, pathErr := resolveDataFilePath(s.mediaDataDir, file.Path)
+				filePath, pathErr := joinDataFilePath(s.torrentDataDir(r.Context(), domain.TorrentID(id)), file.Path)
 				if pathErr == nil {
 					if info, statErr := os.Stat(filePath); statErr == nil && !info.IsDir() {
 						setDLNAHeaders(w.Header())
@@ -377,7 +377,7 @@ func (s *Server) handleSubtitleTrackVTT(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	filePath, pathErr := resolveDataFilePath(s.mediaDataDir, file.Path)
+	filePath, pathErr := joinDataFilePath(s.torrentDataDir(r.Context(), domain.TorrentID(id)), file.Path)
 	if pathErr != nil {
 		http.NotFound(w, r)
 		return
@@ -575,7 +575,7 @@ func (s *Server) handleMediaInfo(w http.ResponseWriter, r *http.Request, id stri
 		// SubtitlesReady is dynamic (depends on file existence on disk), so
 		// recompute it even on cache hit.
 		if fileIndex < len(record.Files) && record.Files[fileIndex].Path != "" && s.mediaDataDir != "" {
-			if resolved, resolveErr := resolveDataFilePath(s.mediaDataDir, record.Files[fileIndex].Path); resolveErr == nil {
+			if resolved, resolveErr := joinDataFilePath(s.torrentSavePath(record), record.Files[fileIndex].Path); resolveErr == nil {
 				if info, statErr := os.Stat(resolved); statErr == nil && !info.IsDir() {
 					cached.SubtitlesReady = true
 				}
@@ -612,7 +612,7 @@ func (s *Server) handleMediaInfo(w http.ResponseWriter, r *http.Request, id stri
 	bestInfo := domain.MediaInfo{Tracks: []domain.MediaTrack{}}
 
 	if filePathRel != "" {
-		filePath, pathErr := resolveDataFilePath(s.mediaDataDir, filePathRel)
+		filePath, pathErr := joinDataFilePath(s.torrentSavePath(record), filePathRel)
 		if pathErr == nil {
 			probeCtx, probeCancel := context.WithTimeout(r.Context(), mediaProbeTimeout)
 			info, probeErr := s.mediaProbe.Probe(probeCtx, filePath)
@@ -667,7 +667,7 @@ func (s *Server) handleMediaInfo(w http.ResponseWriter, r *http.Request, id stri
 
 	// Subtitles require the file to exist on disk for ffmpeg extraction.
 	if filePathRel != "" && s.mediaDataDir != "" {
-		if resolved, err := resolveDataFilePath(s.mediaDataDir, filePathRel); err == nil {
+		if resolved, err := joinDataFilePath(s.torrentSavePath(record), filePathRel); err == nil {
 			if info, statErr := os.Stat(resolved); statErr == nil && !info.IsDir() {
 				bestInfo.SubtitlesReady = true
 			}
@@ -799,7 +799,8 @@ func storeRewrittenPlaylistStream(job *StreamJob, playlistPath string, audioTrac
 // resolveFileRef returns the FileRef for the given fileIndex from the live
 // engine session (most accurate BytesCompleted) or, if the torrent is not
 // active, from the persisted repository record. This allows the fast path
-// and direct-playback handler to work for stopped/completed torrents.
+// and direct-playback handler to work for stopped/completed torrents and
+// for files extracted from the torrent's archives.
 func (s *Server) resolveFileRef(ctx context.Context, id domain.TorrentID, fileIndex int) (domain.FileRef, bool) {
 	if s.getState != nil {
 		if state, err := s.getState.Execute(ctx, id); err == nil {
@@ -813,6 +814,9 @@ func (s *Server) resolveFileRef(ctx context.Context, id domain.TorrentID, fileIn
 			if fileIndex < len(record.Files) {
 				return record.Files[fileIndex], true
 			}
+			if file, ok := record.ExtractedFile(fileIndex); ok {
+				return file, true
+			}
 		}
 	}
 	return domain.FileRef{}, false
@@ -840,7 +844,7 @@ func (s *Server) handleDirectPlayback(w http.ResponseWriter, r *http.Request, id
 		return
 	}
 
-	filePath, pathErr := resolveDataFilePath(s.mediaDataDir, file.Path)
+	filePath, pathErr := joinDataFilePath(s.torrentDataDir(r.Context(), domain.TorrentID(id)), file.Path)
 	if pathErr != nil {
 		http.NotFound(w, r)
 		return
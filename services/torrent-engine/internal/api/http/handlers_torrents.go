@@ -49,8 +49,10 @@ func (s *Server) handleCreateTorrent(w http.ResponseWriter, r *http.Request) {
 }
 
 type createTorrentJSON struct {
-	Magnet string `json:"magnet"`
-	Name   string `json:"name,omitempty"`
+	Magnet   string   `json:"magnet"`
+	Name     string   `json:"name,omitempty"`
+	Category string   `json:"category,omitempty"`
+	WebSeeds []string `json:"webSeeds,omitempty"`
 }
 
 func (s *Server) handleCreateTorrentJSON(w http.ResponseWriter, r *http.Request) {
@@ -63,8 +65,9 @@ func (s *Server) handleCreateTorrentJSON(w http.ResponseWriter, r *http.Request)
 	}
 
 	input := usecase.CreateTorrentInput{
-		Source: domain.TorrentSource{Magnet: strings.TrimSpace(body.Magnet)},
-		Name:   strings.TrimSpace(body.Name),
+		Source:   domain.TorrentSource{Magnet: strings.TrimSpace(body.Magnet), WebSeeds: body.WebSeeds},
+		Name:     strings.TrimSpace(body.Name),
+		Category: strings.TrimSpace(body.Category),
 	}
 
 	// Cap the handler execution time so we never block indefinitely.
@@ -102,8 +105,9 @@ func (s *Server) handleCreateTorrentMultipart(w http.ResponseWriter, r *http.Req
 
 	name := strings.TrimSpace(r.FormValue("name"))
 	input := usecase.CreateTorrentInput{
-		Source: domain.TorrentSource{Torrent: path},
-		Name:   name,
+		Source:   domain.TorrentSource{Torrent: path, WebSeeds: r.MultipartForm.Value["webSeeds"]},
+		Name:     name,
+		Category: strings.TrimSpace(r.FormValue("category")),
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
@@ -119,15 +123,17 @@ func (s *Server) handleCreateTorrentMultipart(w http.ResponseWriter, r *http.Req
 }
 
 type torrentSummary struct {
-	ID         domain.TorrentID     `json:"id"`
-	Name       string               `json:"name"`
-	Status     domain.TorrentStatus `json:"status"`
-	Progress   float64              `json:"progress"`
-	DoneBytes  int64                `json:"doneBytes"`
-	TotalBytes int64                `json:"totalBytes"`
-	CreatedAt  time.Time            `json:"createdAt"`
-	UpdatedAt  time.Time            `json:"updatedAt"`
-	Tags       []string             `json:"tags,omitempty"`
+	ID            domain.TorrentID     `json:"id"`
+	Name          string               `json:"name"`
+	Status        domain.TorrentStatus `json:"status"`
+	Progress      float64              `json:"progress"`
+	DoneBytes     int64                `json:"doneBytes"`
+	TotalBytes    int64                `json:"totalBytes"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt"`
+	Tags          []string             `json:"tags,omitempty"`
+	StalledSince  *time.Time           `json:"stalledSince,omitempty"`
+	StalledReason domain.StallReason   `json:"stalledReason,omitempty"`
 }
 
 type torrentListSummary struct {
@@ -227,15 +233,17 @@ func (s *Server) handleListTorrents(w http.ResponseWriter, r *http.Request) {
 	summaries := make([]torrentSummary, 0, len(records))
 	for _, record := range records {
 		summaries = append(summaries, torrentSummary{
-			ID:         record.ID,
-			Name:       record.Name,
-			Status:     record.Status,
-			Progress:   progressRatio(record.DoneBytes, record.TotalBytes),
-			DoneBytes:  record.DoneBytes,
-			TotalBytes: record.TotalBytes,
-			CreatedAt:  record.CreatedAt,
-			UpdatedAt:  record.UpdatedAt,
-			Tags:       record.Tags,
+			ID:            record.ID,
+			Name:          record.Name,
+			Status:        record.Status,
+			Progress:      progressRatio(record.DoneBytes, record.TotalBytes),
+			DoneBytes:     record.DoneBytes,
+			TotalBytes:    record.TotalBytes,
+			CreatedAt:     record.CreatedAt,
+			UpdatedAt:     record.UpdatedAt,
+			Tags:          record.Tags,
+			StalledSince:  record.StalledSince,
+			StalledReason: record.StalledReason,
 		})
 	}
 
@@ -381,6 +389,44 @@ func (s *Server) handleTorrentByID(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			s.handleUpdateTags(w, r, id)
+		case "webseeds":
+			s.handleWebSeeds(w, r, id)
+		case "magnet":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			s.handleExportMagnet(w, r, id)
+		case "stats":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			s.handleTorrentStats(w, r, id)
+		case "integrity":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			s.handleIntegrity(w, r, id)
+		case "post-process":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			s.handlePostProcessRuns(w, r, id)
+		case "events":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			s.handleTorrentEvents(w, r, id)
+		case "archive-files":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			s.handleArchiveFiles(w, r, id)
 		default:
 			http.NotFound(w, r)
 		}
@@ -405,6 +451,35 @@ func (s *Server) handleGetTorrent(w http.ResponseWriter, r *http.Request, id str
 	writeJSON(w, http.StatusOK, buildTorrentRecordView(record))
 }
 
+type magnetResponse struct {
+	Magnet string `json:"magnet"`
+}
+
+// handleExportMagnet returns a magnet link for the torrent. Tracker and web
+// seed credentials are redacted unless ?credentials=true is given, which a
+// viewer may not ask for since the passkeys belong to the owner.
+func (s *Server) handleExportMagnet(w http.ResponseWriter, r *http.Request, id string) {
+	if s.repo == nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "repository not configured")
+		return
+	}
+	withCredentials := r.URL.Query().Get("credentials") == "true"
+	if withCredentials && s.auth != nil {
+		if principal, ok := principalFrom(r.Context()); !ok || !principal.Role.Allows(domain.RoleUser) {
+			writeError(w, http.StatusForbidden, "forbidden", "credentials require role "+string(domain.RoleUser))
+			return
+		}
+	}
+
+	record, err := s.repo.Get(r.Context(), domain.TorrentID(id))
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, magnetResponse{Magnet: record.Magnet(withCredentials)})
+}
+
 func (s *Server) handleStartTorrent(w http.ResponseWriter, r *http.Request, id string) {
 	if s.startTorrent == nil {
 		writeError(w, http.StatusInternalServerError, "internal_error", "start torrent use case not configured")
@@ -503,6 +578,9 @@ func (s *Server) handleUpdateTags(w http.ResponseWriter, r *http.Request, id str
 		writeRepoError(w, err)
 		return
 	}
+	if s.events != nil {
+		s.events.Publish(domain.Event{Type: domain.EventTagsChanged, TorrentID: record.ID, Tags: record.Tags})
+	}
 	writeJSON(w, http.StatusOK, record)
 }
 
@@ -655,10 +733,15 @@ func (s *Server) handleListTorrentStates(w http.ResponseWriter, r *http.Request)
 
 func (s *Server) handleFocus(w http.ResponseWriter, r *http.Request, id string) {
 	if s.player != nil {
-		if err := s.setCurrentTorrentID(r.Context(), domain.TorrentID(id)); err != nil {
+		torrentID := domain.TorrentID(id)
+		if err := s.setCurrentTorrentID(r.Context(), torrentID); err != nil {
 			writeDomainError(w, err)
 			return
 		}
+		if err := s.recordPlayerState(r, usecase.PlayerStateUpdate{CurrentTorrentID: &torrentID}); err != nil {
+			writeProfileError(w, err)
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
@@ -679,6 +762,11 @@ func (s *Server) handleUnfocus(w http.ResponseWriter, r *http.Request) {
 			writeDomainError(w, err)
 			return
 		}
+		var none domain.TorrentID
+		if err := s.recordPlayerState(r, usecase.PlayerStateUpdate{CurrentTorrentID: &none}); err != nil {
+			writeProfileError(w, err)
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
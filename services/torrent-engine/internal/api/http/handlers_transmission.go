@@ -0,0 +1,776 @@
+package apihttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"torrentstream/internal/app"
+	"torrentstream/internal/domain"
+	"torrentstream/internal/usecase"
+)
+
+// Transmission RPC handler. It serves the session and torrent methods that
+// Transmission remotes, Flexget and scripts use, mapped onto the torrent use
+// cases. As in Transmission, torrents get numeric ids that last until the
+// engine restarts; hashString is the torrent ID.
+
+const (
+	transmissionRPCPath       = "/transmission/rpc"
+	transmissionSessionHeader = "X-Transmission-Session-Id"
+	// transmissionVersion and the RPC versions match Transmission 4.0.
+	transmissionVersion           = "4.0.6 (torrx)"
+	transmissionRPCVersion        = 17
+	transmissionRPCVersionMinimum = 14
+	transmissionRPCSemver         = "5.3.0"
+	// transmissionRecentlyActive is how far back "recently-active" looks
+	// for torrents without a live session.
+	transmissionRecentlyActive  = time.Minute
+	transmissionMaxRequestBytes = 16 << 20
+)
+
+// Transmission torrent status, error and ETA values.
+const (
+	transmissionStatusStopped  = 0
+	transmissionStatusDownload = 4
+	transmissionStatusSeed     = 6
+
+	transmissionErrorLocal = 3
+
+	transmissionETANotAvailable = -1
+	transmissionETAUnknown      = -2
+	// transmissionRatioUnlimited is the seedRatioMode and seedIdleMode of
+	// torrents without seeding limits.
+	transmissionRatioUnlimited = 2
+)
+
+type transmissionRPC struct {
+	sessionID string
+
+	mu       sync.Mutex
+	ids      map[domain.TorrentID]int
+	torrents map[int]domain.TorrentID
+	lastID   int
+}
+
+// WithTransmission enables the Transmission RPC.
+func WithTransmission() ServerOption {
+	return func(s *Server) {
+		var b [24]byte
+		_, _ = rand.Read(b[:])
+		s.transmission = &transmissionRPC{
+			sessionID: hex.EncodeToString(b[:]),
+			ids:       make(map[domain.TorrentID]int),
+			torrents:  make(map[int]domain.TorrentID),
+		}
+	}
+}
+
+// id returns the number of a torrent, handing out the next one on first
+// sight.
+func (t *transmissionRPC) id(torrentID domain.TorrentID) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if id, ok := t.ids[torrentID]; ok {
+		return id
+	}
+	t.lastID++
+	t.ids[torrentID] = t.lastID
+	t.torrents[t.lastID] = torrentID
+	return t.lastID
+}
+
+func (t *transmissionRPC) torrentID(id int) (domain.TorrentID, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	torrentID, ok := t.torrents[id]
+	return torrentID, ok
+}
+
+// removed returns, once, the ids of torrents that are gone.
+func (t *transmissionRPC) removed(present map[domain.TorrentID]bool) []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := []int{}
+	for torrentID, id := range t.ids {
+		if !present[torrentID] {
+			out = append(out, id)
+			delete(t.ids, torrentID)
+			delete(t.torrents, id)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+type transmissionRequest struct {
+	Method    string          `json:"method"`
+	Arguments json.RawMessage `json:"arguments"`
+	Tag       json.RawMessage `json:"tag,omitempty"`
+}
+
+type transmissionResponse struct {
+	Result    string          `json:"result"`
+	Arguments any             `json:"arguments"`
+	Tag       json.RawMessage `json:"tag,omitempty"`
+}
+
+type transmissionTorrentArgs struct {
+	IDs             json.RawMessage `json:"ids"`
+	Fields          []string        `json:"fields"`
+	Format          string          `json:"format"`
+	DeleteLocalData bool            `json:"delete-local-data"`
+}
+
+type transmissionAddArgs struct {
+	Filename    string   `json:"filename"`
+	Metainfo    string   `json:"metainfo"`
+	DownloadDir string   `json:"download-dir"`
+	Paused      bool     `json:"paused"`
+	Labels      []string `json:"labels"`
+}
+
+type transmissionSessionArgs struct {
+	Fields               []string `json:"fields"`
+	DownloadQueueEnabled *bool    `json:"download-queue-enabled"`
+	DownloadQueueSize    *int     `json:"download-queue-size"`
+}
+
+type transmissionStats struct {
+	UploadedBytes   int64 `json:"uploadedBytes"`
+	DownloadedBytes int64 `json:"downloadedBytes"`
+	FilesAdded      int   `json:"filesAdded"`
+	SessionCount    int   `json:"sessionCount"`
+	SecondsActive   int64 `json:"secondsActive"`
+}
+
+type transmissionFile struct {
+	Name           string `json:"name"`
+	Length         int64  `json:"length"`
+	BytesCompleted int64  `json:"bytesCompleted"`
+}
+
+type transmissionFileStat struct {
+	BytesCompleted int64 `json:"bytesCompleted"`
+	Wanted         bool  `json:"wanted"`
+	Priority       int   `json:"priority"`
+}
+
+// handleTransmission answers every request without the current session id
+// with 409 and the id, which clients then send back. This keeps web pages
+// from posting to the RPC through the user's browser.
+func (s *Server) handleTransmission(w http.ResponseWriter, r *http.Request) {
+	if s.transmission == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "Transmission RPC not configured")
+		return
+	}
+	if r.Header.Get(transmissionSessionHeader) != s.transmission.sessionID {
+		w.Header().Set(transmissionSessionHeader, s.transmission.sessionID)
+		http.Error(w, "invalid session id", http.StatusConflict)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req transmissionRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, transmissionMaxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return
+	}
+	if required := transmissionRole(req.Method); !callerAllows(r, s.auth, required) {
+		writeError(w, http.StatusForbidden, "forbidden", "requires role "+string(required))
+		return
+	}
+
+	ctx := r.Context()
+	var (
+		args any
+		err  error
+	)
+	switch req.Method {
+	case "session-get":
+		args, err = s.transmissionSessionGet(req.Arguments)
+	case "session-set":
+		err = s.transmissionSessionSet(req.Arguments)
+	case "session-stats":
+		args, err = s.transmissionSessionStats(ctx)
+	case "torrent-get":
+		args, err = s.transmissionTorrentGet(ctx, req.Arguments)
+	case "torrent-add":
+		args, err = s.transmissionTorrentAdd(ctx, req.Arguments)
+	case "torrent-start", "torrent-start-now":
+		if s.startTorrent == nil {
+			err = errors.New("start torrent use case not configured")
+			break
+		}
+		err = s.transmissionControl(ctx, req.Arguments, s.startTorrent.Execute)
+	case "torrent-stop":
+		if s.stopTorrent == nil {
+			err = errors.New("stop torrent use case not configured")
+			break
+		}
+		err = s.transmissionControl(ctx, req.Arguments, s.stopTorrent.Execute)
+	case "torrent-remove":
+		err = s.transmissionRemove(ctx, req.Arguments)
+	default:
+		err = errors.New("method name not recognized")
+	}
+
+	// Transmission reports failures in "result" with status 200.
+	resp := transmissionResponse{Result: "success", Arguments: args, Tag: req.Tag}
+	if err != nil {
+		resp.Result = err.Error()
+		resp.Arguments = nil
+	}
+	if resp.Arguments == nil {
+		resp.Arguments = struct{}{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// transmissionRole is the role a method needs. authMiddleware lets viewers
+// reach the RPC, as the method is only known from the body.
+func transmissionRole(method string) domain.Role {
+	switch method {
+	case "session-get", "session-stats", "torrent-get":
+		return domain.RoleViewer
+	case "session-set":
+		return domain.RoleAdmin
+	default:
+		return domain.RoleUser
+	}
+}
+
+// callerAllows reports whether the caller set by authMiddleware has the
+// role. Everything is allowed when authentication is off.
+func callerAllows(r *http.Request, auth AuthUseCase, role domain.Role) bool {
+	if auth == nil {
+		return true
+	}
+	principal, ok := principalFrom(r.Context())
+	return ok && principal.Role.Allows(role)
+}
+
+func decodeTransmissionArgs(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errors.New("invalid arguments")
+	}
+	return nil
+}
+
+func (s *Server) transmissionSessionGet(raw json.RawMessage) (map[string]any, error) {
+	var args transmissionSessionArgs
+	if err := decodeTransmissionArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	wanted := func(string) bool { return true }
+	if len(args.Fields) > 0 {
+		set := make(map[string]bool, len(args.Fields))
+		for _, field := range args.Fields {
+			set[field] = true
+		}
+		wanted = func(field string) bool { return set[field] }
+	}
+
+	session := map[string]any{
+		"version":                    transmissionVersion,
+		"rpc-version":                transmissionRPCVersion,
+		"rpc-version-minimum":        transmissionRPCVersionMinimum,
+		"rpc-version-semver":         transmissionRPCSemver,
+		"session-id":                 s.transmission.sessionID,
+		"download-dir":               s.mediaDataDir,
+		"incomplete-dir-enabled":     false,
+		"start-added-torrents":       true,
+		"speed-limit-down-enabled":   false,
+		"speed-limit-up-enabled":     false,
+		"alt-speed-enabled":          false,
+		"seedRatioLimited":           false,
+		"idle-seeding-limit-enabled": false,
+		"seed-queue-enabled":         false,
+		"dht-enabled":                true,
+		"pex-enabled":                true,
+	}
+	// Reading the storage settings scans the data directory, so only when
+	// asked for.
+	if s.storage != nil && (wanted("download-queue-enabled") || wanted("download-queue-size") || wanted("download-dir-free-space")) {
+		view := s.storage.Get()
+		session["download-queue-enabled"] = view.MaxSessions > 0
+		session["download-queue-size"] = view.MaxSessions
+		session["download-dir-free-space"] = view.Usage.FreeBytes
+	}
+	for field := range session {
+		if !wanted(field) {
+			delete(session, field)
+		}
+	}
+	return session, nil
+}
+
+// transmissionSessionSet applies the download queue, which maps onto the
+// maximum number of active sessions. Other settings have no counterpart
+// and are ignored.
+func (s *Server) transmissionSessionSet(raw json.RawMessage) error {
+	var args transmissionSessionArgs
+	if err := decodeTransmissionArgs(raw, &args); err != nil {
+		return err
+	}
+	if args.DownloadQueueEnabled == nil && args.DownloadQueueSize == nil {
+		return nil
+	}
+	if s.storage == nil {
+		return errors.New("storage settings not configured")
+	}
+	current := s.storage.Get()
+	next := app.StorageSettings{MaxSessions: current.MaxSessions, MinDiskSpaceBytes: current.MinDiskSpaceBytes}
+	if args.DownloadQueueSize != nil {
+		if *args.DownloadQueueSize < 0 {
+			return errors.New("download-queue-size must be >= 0")
+		}
+		next.MaxSessions = *args.DownloadQueueSize
+	}
+	if args.DownloadQueueEnabled != nil && !*args.DownloadQueueEnabled {
+		next.MaxSessions = 0
+	}
+	return s.storage.Update(next)
+}
+
+func (s *Server) transmissionSessionStats(ctx context.Context) (map[string]any, error) {
+	if s.transferStats == nil {
+		return nil, errors.New("statistics not configured")
+	}
+	stats, err := s.transferStats.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var active int
+	var downloadSpeed, uploadSpeed int64
+	for _, state := range s.activeStates(ctx) {
+		if state.Status != domain.TorrentStopped {
+			active++
+		}
+		downloadSpeed += state.DownloadSpeed
+		uploadSpeed += state.UploadSpeed
+	}
+	paused := stats.TotalTorrents - active
+	if paused < 0 {
+		paused = 0
+	}
+	return map[string]any{
+		"activeTorrentCount": active,
+		"pausedTorrentCount": paused,
+		"torrentCount":       stats.TotalTorrents,
+		"downloadSpeed":      downloadSpeed,
+		"uploadSpeed":        uploadSpeed,
+		"current-stats": transmissionStats{
+			DownloadedBytes: stats.Session.Downloaded,
+			UploadedBytes:   stats.Session.Uploaded,
+			SessionCount:    1,
+			SecondsActive:   stats.UptimeSeconds,
+		},
+		"cumulative-stats": transmissionStats{
+			DownloadedBytes: stats.AllTime.Downloaded,
+			UploadedBytes:   stats.AllTime.Uploaded,
+			SessionCount:    1,
+			SecondsActive:   stats.UptimeSeconds,
+		},
+	}, nil
+}
+
+func (s *Server) transmissionTorrentGet(ctx context.Context, raw json.RawMessage) (map[string]any, error) {
+	var args transmissionTorrentArgs
+	if err := decodeTransmissionArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if len(args.Fields) == 0 {
+		return nil, errors.New("no fields specified")
+	}
+	records, recent, err := s.transmissionTorrents(ctx, args.IDs)
+	if err != nil {
+		return nil, err
+	}
+	states := s.activeStates(ctx)
+
+	out := map[string]any{}
+	if recent {
+		present := make(map[domain.TorrentID]bool, len(records))
+		for _, record := range records {
+			present[record.ID] = true
+		}
+		out["removed"] = s.transmission.removed(present)
+		cutoff := time.Now().Add(-transmissionRecentlyActive)
+		active := records[:0]
+		for _, record := range records {
+			if _, live := states[record.ID]; live || record.UpdatedAt.After(cutoff) {
+				active = append(active, record)
+			}
+		}
+		records = active
+	}
+
+	// Numbers follow the order torrents were added in.
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+	views := make([]transmissionTorrent, 0, len(records))
+	for _, record := range records {
+		state, live := states[record.ID]
+		views = append(views, s.transmissionTorrent(record, state, live))
+	}
+	sort.SliceStable(views, func(i, j int) bool { return views[i].id < views[j].id })
+
+	if args.Format == "table" {
+		rows := make([][]any, 0, len(views)+1)
+		header := make([]any, 0, len(args.Fields))
+		for _, field := range args.Fields {
+			header = append(header, field)
+		}
+		rows = append(rows, header)
+		for _, view := range views {
+			row := make([]any, 0, len(args.Fields))
+			for _, field := range args.Fields {
+				value, _ := view.field(field)
+				row = append(row, value)
+			}
+			rows = append(rows, row)
+		}
+		out["torrents"] = rows
+		return out, nil
+	}
+
+	torrents := make([]map[string]any, 0, len(views))
+	for _, view := range views {
+		torrent := make(map[string]any, len(args.Fields))
+		for _, field := range args.Fields {
+			if value, ok := view.field(field); ok {
+				torrent[field] = value
+			}
+		}
+		torrents = append(torrents, torrent)
+	}
+	out["torrents"] = torrents
+	return out, nil
+}
+
+// transmissionTorrentAdd adds a magnet link given as "filename" or a
+// base64 .torrent given as "metainfo". URLs and server paths are not
+// fetched or read.
+func (s *Server) transmissionTorrentAdd(ctx context.Context, raw json.RawMessage) (map[string]any, error) {
+	var args transmissionAddArgs
+	if err := decodeTransmissionArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	source := domain.TorrentSource{DataDir: s.requestedDataDir(args.DownloadDir)}
+	filename := strings.TrimSpace(args.Filename)
+	switch {
+	case args.Metainfo != "":
+		data, err := base64.StdEncoding.DecodeString(args.Metainfo)
+		if err != nil {
+			return nil, errors.New("invalid or corrupt torrent file")
+		}
+		path, err := saveUploadedFile(bytes.NewReader(data), "transmission.torrent", s.mediaDataDir)
+		if err != nil {
+			return nil, errors.New("failed to store torrent")
+		}
+		source.Torrent = path
+	case strings.HasPrefix(strings.ToLower(filename), "magnet:"):
+		source.Magnet = filename
+	case filename != "":
+		return nil, errors.New("only magnet links and metainfo are supported")
+	default:
+		return nil, errors.New("no filename or metainfo specified")
+	}
+
+	started := time.Now()
+	record, err := s.createTorrent.Execute(ctx, usecase.CreateTorrentInput{Source: source, Paused: args.Paused})
+	if err != nil {
+		return nil, err
+	}
+	if len(args.Labels) > 0 && s.repo != nil {
+		if err := s.repo.UpdateTags(ctx, record.ID, mergeTags(record.Tags, args.Labels)); err != nil {
+			s.logger.Warn("transmission set labels failed", slog.String("id", string(record.ID)), slog.String("error", err.Error()))
+		}
+	}
+
+	// The create use case returns the existing record for known torrents.
+	key := "torrent-added"
+	if record.CreatedAt.Before(started) {
+		key = "torrent-duplicate"
+	}
+	return map[string]any{key: map[string]any{
+		"id":         s.transmission.id(record.ID),
+		"name":       record.Name,
+		"hashString": string(record.ID),
+	}}, nil
+}
+
+// transmissionControl starts or stops torrents. Like Transmission it
+// succeeds for torrents that were already in that state.
+func (s *Server) transmissionControl(ctx context.Context, raw json.RawMessage, control func(context.Context, domain.TorrentID) (domain.TorrentRecord, error)) error {
+	var args transmissionTorrentArgs
+	if err := decodeTransmissionArgs(raw, &args); err != nil {
+		return err
+	}
+	records, _, err := s.transmissionTorrents(ctx, args.IDs)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if _, err := control(ctx, record.ID); err != nil {
+			s.logger.Debug("transmission control skipped torrent", slog.String("id", string(record.ID)), slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+// transmissionRemove moves torrents to the trash, with their data when
+// "delete-local-data" is set.
+func (s *Server) transmissionRemove(ctx context.Context, raw json.RawMessage) error {
+	if s.deleteTorrent == nil {
+		return errors.New("delete torrent use case not configured")
+	}
+	var args transmissionTorrentArgs
+	if err := decodeTransmissionArgs(raw, &args); err != nil {
+		return err
+	}
+	records, _, err := s.transmissionTorrents(ctx, args.IDs)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := s.deleteTorrent.Execute(ctx, record.ID, args.DeleteLocalData); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// transmissionTorrents resolves "ids": a number, a hash, a list of either,
+// or "recently-active". Without ids every torrent is meant. Unknown ids
+// are skipped.
+func (s *Server) transmissionTorrents(ctx context.Context, raw json.RawMessage) ([]domain.TorrentRecord, bool, error) {
+	if s.repo == nil {
+		return nil, false, errors.New("repository not configured")
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		records, err := s.repo.List(ctx, domain.TorrentFilter{})
+		return records, false, err
+	}
+	if string(raw) == `"recently-active"` {
+		records, err := s.repo.List(ctx, domain.TorrentFilter{})
+		return records, true, err
+	}
+
+	items := []json.RawMessage{raw}
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, false, errors.New("invalid ids")
+		}
+	}
+	var out []domain.TorrentRecord
+	for _, item := range items {
+		var (
+			record domain.TorrentRecord
+			err    error
+			number int
+			hash   string
+		)
+		switch {
+		case json.Unmarshal(item, &number) == nil:
+			torrentID, ok := s.transmission.torrentID(number)
+			if !ok {
+				continue
+			}
+			record, err = s.repo.Get(ctx, torrentID)
+		case json.Unmarshal(item, &hash) == nil:
+			record, err = s.torrentByHash(ctx, hash)
+		default:
+			return nil, false, errors.New("invalid ids")
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, record)
+	}
+	return out, false, nil
+}
+
+// transmissionTorrent is a torrent with the numbers torrent-get reports.
+type transmissionTorrent struct {
+	id       int
+	record   domain.TorrentRecord
+	state    domain.SessionState
+	live     bool
+	size     int64
+	done     int64
+	savePath string
+}
+
+func (s *Server) transmissionTorrent(record domain.TorrentRecord, state domain.SessionState, live bool) transmissionTorrent {
+	size := selectedSize(record)
+	done := record.DoneBytes
+	if done > size {
+		done = size
+	}
+	return transmissionTorrent{
+		id:       s.transmission.id(record.ID),
+		record:   record,
+		state:    state,
+		live:     live,
+		size:     size,
+		done:     done,
+		savePath: s.torrentSavePath(record),
+	}
+}
+
+func (t transmissionTorrent) complete() bool {
+	return t.size > 0 && t.done >= t.size
+}
+
+func (t transmissionTorrent) status() int {
+	switch {
+	case !t.live || t.record.Status == domain.TorrentStopped || t.record.Status == domain.TorrentError:
+		return transmissionStatusStopped
+	case t.complete():
+		return transmissionStatusSeed
+	default:
+		return transmissionStatusDownload
+	}
+}
+
+func (t transmissionTorrent) eta() int64 {
+	switch {
+	case t.complete() || t.status() != transmissionStatusDownload:
+		return transmissionETANotAvailable
+	case t.state.DownloadSpeed <= 0:
+		return transmissionETAUnknown
+	default:
+		return (t.size - t.done) / t.state.DownloadSpeed
+	}
+}
+
+// field returns a torrent-get field; unknown fields are left out, as
+// Transmission does.
+func (t transmissionTorrent) field(name string) (any, bool) {
+	record := t.record
+	switch name {
+	case "id":
+		return t.id, true
+	case "hashString":
+		return string(record.ID), true
+	case "name":
+		return record.Name, true
+	case "status":
+		return t.status(), true
+	case "error":
+		if record.Status == domain.TorrentError {
+			return transmissionErrorLocal, true
+		}
+		return 0, true
+	case "errorString":
+		if record.Status == domain.TorrentError {
+			return "torrent failed", true
+		}
+		return "", true
+	case "totalSize":
+		return record.TotalBytes, true
+	case "sizeWhenDone":
+		return t.size, true
+	case "leftUntilDone":
+		return t.size - t.done, true
+	case "haveValid":
+		return t.done, true
+	case "haveUnchecked":
+		return 0, true
+	case "percentDone":
+		return progressRatio(t.done, t.size), true
+	case "percentComplete":
+		return progressRatio(record.DoneBytes, record.TotalBytes), true
+	case "metadataPercentComplete":
+		if record.Status == domain.TorrentPending {
+			return 0, true
+		}
+		return 1, true
+	case "rateDownload":
+		return t.state.DownloadSpeed, true
+	case "rateUpload":
+		return t.state.UploadSpeed, true
+	case "eta":
+		return t.eta(), true
+	case "downloadDir":
+		return t.savePath, true
+	case "addedDate":
+		return record.CreatedAt.Unix(), true
+	case "activityDate":
+		return record.UpdatedAt.Unix(), true
+	case "downloadedEver":
+		return t.state.Downloaded, true
+	case "uploadedEver":
+		return t.state.Uploaded, true
+	case "uploadRatio":
+		if t.done <= 0 {
+			return -1, true
+		}
+		return float64(t.state.Uploaded) / float64(t.done), true
+	case "peersConnected":
+		return t.state.Peers, true
+	case "pieceCount":
+		return t.state.NumPieces, true
+	case "isFinished":
+		// Torrents finish in Transmission when a seeding limit is reached,
+		// and there are none here.
+		return false, true
+	case "isStalled":
+		return record.StalledSince != nil, true
+	case "isPrivate":
+		return record.Private, true
+	case "labels":
+		return append([]string{}, record.Tags...), true
+	case "magnetLink":
+		return hashMagnet(record), true
+	case "seedRatioMode", "seedIdleMode":
+		return transmissionRatioUnlimited, true
+	case "files":
+		files := make([]transmissionFile, 0, len(record.Files))
+		for _, f := range record.Files {
+			files = append(files, transmissionFile{Name: f.Path, Length: f.Length, BytesCompleted: f.BytesCompleted})
+		}
+		return files, true
+	case "fileStats":
+		stats := make([]transmissionFileStat, 0, len(record.Files))
+		for _, f := range record.Files {
+			stats = append(stats, transmissionFileStat{BytesCompleted: f.BytesCompleted, Wanted: f.Priority != "none"})
+		}
+		return stats, true
+	case "wanted":
+		wanted := make([]int, 0, len(record.Files))
+		for _, f := range record.Files {
+			if f.Priority == "none" {
+				wanted = append(wanted, 0)
+			} else {
+				wanted = append(wanted, 1)
+			}
+		}
+		return wanted, true
+	case "priorities":
+		return make([]int, len(record.Files)), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,285 @@
+package apihttp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"torrentstream/internal/app"
+	"torrentstream/internal/domain"
+)
+
+type transmissionReply struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+	Tag       int             `json:"tag"`
+}
+
+func newTransmissionServer(repo *qbitRepo, opts ...ServerOption) *Server {
+	opts = append([]ServerOption{
+		WithRepository(repo),
+		WithListTorrentStates(&fakeListTorrentStates{result: []domain.SessionState{
+			{ID: qbitHashA, Status: domain.TorrentActive, DownloadSpeed: 50, Peers: 4},
+		}}),
+		WithTransmission(),
+	}, opts...)
+	return NewServer(&fakeCreateTorrent{}, opts...)
+}
+
+func doTransmission(t *testing.T, s *Server, body string) transmissionReply {
+	t.Helper()
+	rec := doTransmissionRequest(s, body, s.transmission.sessionID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var reply transmissionReply
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return reply
+}
+
+func doTransmissionRequest(s *Server, body, sessionID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, transmissionRPCPath, strings.NewReader(body))
+	if sessionID != "" {
+		req.Header.Set(transmissionSessionHeader, sessionID)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTransmissionSessionHandshake(t *testing.T) {
+	s := newTransmissionServer(newQBitRepo())
+
+	rec := doTransmissionRequest(s, `{"method":"session-get"}`, "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("without session id: got %d, want 409", rec.Code)
+	}
+	id := rec.Header().Get(transmissionSessionHeader)
+	if id == "" {
+		t.Fatalf("409 without %s header", transmissionSessionHeader)
+	}
+
+	rec = doTransmissionRequest(s, `{"method":"session-get","tag":7}`, id)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("with session id: got %d", rec.Code)
+	}
+	var reply transmissionReply
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var session map[string]any
+	if err := json.Unmarshal(reply.Arguments, &session); err != nil {
+		t.Fatalf("decode arguments: %v", err)
+	}
+	if reply.Result != "success" || reply.Tag != 7 || session["rpc-version"] != float64(transmissionRPCVersion) || session["session-id"] != id {
+		t.Fatalf("reply = %+v %v", reply, session)
+	}
+}
+
+func TestTransmissionUnknownMethod(t *testing.T) {
+	s := newTransmissionServer(newQBitRepo())
+	reply := doTransmission(t, s, `{"method":"blocklist-update"}`)
+	if reply.Result != "method name not recognized" {
+		t.Fatalf("result = %q", reply.Result)
+	}
+}
+
+func TestTransmissionTorrentGet(t *testing.T) {
+	s := newTransmissionServer(newQBitRepo())
+
+	reply := doTransmission(t, s, `{"method":"torrent-get","arguments":{"fields":["id","hashString","status","percentDone","sizeWhenDone","eta","downloadDir","wanted","bogus"]}}`)
+	var args struct {
+		Torrents []map[string]any `json:"torrents"`
+	}
+	if err := json.Unmarshal(reply.Arguments, &args); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(args.Torrents) != 2 {
+		t.Fatalf("torrents = %+v", args.Torrents)
+	}
+	show, movie := args.Torrents[0], args.Torrents[1]
+	if show["id"] != float64(1) || show["hashString"] != qbitHashA || show["status"] != float64(transmissionStatusDownload) {
+		t.Fatalf("show = %v", show)
+	}
+	if show["percentDone"] != 0.5 || show["sizeWhenDone"] != float64(200) || show["eta"] != float64(2) {
+		t.Fatalf("show progress = %v", show)
+	}
+	if _, ok := show["bogus"]; ok {
+		t.Fatalf("unknown field reported: %v", show)
+	}
+	if wanted, _ := show["wanted"].([]any); len(wanted) != 2 || wanted[1] != float64(0) {
+		t.Fatalf("wanted = %v", show["wanted"])
+	}
+	if movie["id"] != float64(2) || movie["status"] != float64(transmissionStatusStopped) || movie["downloadDir"] != "/media/movies" {
+		t.Fatalf("movie = %v", movie)
+	}
+
+	reply = doTransmission(t, s, `{"method":"torrent-get","arguments":{"ids":[2,"`+qbitHashA+`"],"fields":["id","name"],"format":"table"}}`)
+	var table struct {
+		Torrents [][]any `json:"torrents"`
+	}
+	if err := json.Unmarshal(reply.Arguments, &table); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(table.Torrents) != 3 || table.Torrents[0][0] != "id" || table.Torrents[1][0] != float64(1) || table.Torrents[2][1] != "Movie.mkv" {
+		t.Fatalf("table = %v", table.Torrents)
+	}
+
+	reply = doTransmission(t, s, `{"method":"torrent-get","arguments":{}}`)
+	if reply.Result != "no fields specified" {
+		t.Fatalf("result = %q", reply.Result)
+	}
+}
+
+func TestTransmissionRecentlyActive(t *testing.T) {
+	repo := newQBitRepo()
+	s := newTransmissionServer(repo)
+	doTransmission(t, s, `{"method":"torrent-get","arguments":{"fields":["id"]}}`)
+
+	repo.records = repo.records[:1]
+	reply := doTransmission(t, s, `{"method":"torrent-get","arguments":{"ids":"recently-active","fields":["id"]}}`)
+	var args struct {
+		Torrents []map[string]any `json:"torrents"`
+		Removed  []int            `json:"removed"`
+	}
+	if err := json.Unmarshal(reply.Arguments, &args); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(args.Torrents) != 1 || args.Torrents[0]["id"] != float64(1) {
+		t.Fatalf("torrents = %v", args.Torrents)
+	}
+	if len(args.Removed) != 1 || args.Removed[0] != 2 {
+		t.Fatalf("removed = %v", args.Removed)
+	}
+}
+
+func TestTransmissionTorrentAdd(t *testing.T) {
+	repo := newQBitRepo()
+	create := &fakeCreateTorrent{result: domain.TorrentRecord{ID: "c1", Name: "New", CreatedAt: time.Now().Add(time.Second)}}
+	stop := &fakeStopTorrent{}
+	s := NewServer(create,
+		WithRepository(repo),
+		WithStopTorrent(stop),
+		WithStorageRoots("/media/tv"),
+		WithTransmission(),
+	)
+
+	reply := doTransmission(t, s, `{"method":"torrent-add","arguments":{"filename":"magnet:?xt=urn:btih:c1","download-dir":"/media/tv","paused":true,"labels":["flexget"]}}`)
+	if reply.Result != "success" || !strings.Contains(string(reply.Arguments), `"torrent-added":{"hashString":"c1","id":1,"name":"New"}`) {
+		t.Fatalf("reply = %s %s", reply.Result, reply.Arguments)
+	}
+	if create.input.Source.Magnet != "magnet:?xt=urn:btih:c1" || create.input.Source.DataDir != "/media/tv" || !create.input.Paused {
+		t.Fatalf("input = %+v", create.input)
+	}
+	// A paused torrent is created stopped, not started and then stopped.
+	if stop.called != 0 || len(repo.lastTags) != 1 || repo.lastTags[0] != "flexget" {
+		t.Fatalf("stop called %d, tags %v", stop.called, repo.lastTags)
+	}
+
+	create.result.CreatedAt = time.Now().Add(-time.Hour)
+	reply = doTransmission(t, s, `{"method":"torrent-add","arguments":{"filename":"magnet:?xt=urn:btih:c1"}}`)
+	if !strings.Contains(string(reply.Arguments), `"torrent-duplicate"`) {
+		t.Fatalf("duplicate reply = %s", reply.Arguments)
+	}
+
+	create.called = 0
+	reply = doTransmission(t, s, `{"method":"torrent-add","arguments":{"filename":"/etc/passwd"}}`)
+	if reply.Result == "success" || create.called != 0 {
+		t.Fatalf("path add: %q, create called %d", reply.Result, create.called)
+	}
+}
+
+func TestTransmissionTorrentAddMetainfo(t *testing.T) {
+	create := &fakeCreateTorrent{result: domain.TorrentRecord{ID: "c1", CreatedAt: time.Now().Add(time.Second)}}
+	s := NewServer(create, WithMediaProbe(nil, t.TempDir()), WithTransmission())
+
+	metainfo := base64.StdEncoding.EncodeToString([]byte("d4:infod4:name4:showee"))
+	reply := doTransmission(t, s, `{"method":"torrent-add","arguments":{"metainfo":"`+metainfo+`"}}`)
+	if reply.Result != "success" || create.input.Source.Torrent == "" {
+		t.Fatalf("reply %q, input %+v", reply.Result, create.input)
+	}
+}
+
+func TestTransmissionControlAndRemove(t *testing.T) {
+	del := &fakeDeleteTorrent{}
+	start := &fakeStartTorrent{}
+	stop := &fakeStopTorrent{}
+	s := newTransmissionServer(newQBitRepo(), WithDeleteTorrent(del), WithStartTorrent(start), WithStopTorrent(stop))
+	doTransmission(t, s, `{"method":"torrent-get","arguments":{"fields":["id"]}}`)
+
+	if reply := doTransmission(t, s, `{"method":"torrent-stop"}`); reply.Result != "success" || len(stop.ids) != 2 {
+		t.Fatalf("stop all: %q %v", reply.Result, stop.ids)
+	}
+	if reply := doTransmission(t, s, `{"method":"torrent-start","arguments":{"ids":2}}`); reply.Result != "success" || len(start.ids) != 1 || start.ids[0] != qbitHashB {
+		t.Fatalf("start: %q %v", reply.Result, start.ids)
+	}
+	reply := doTransmission(t, s, `{"method":"torrent-remove","arguments":{"ids":[1,99],"delete-local-data":true}}`)
+	if reply.Result != "success" || del.called != 1 || del.id != qbitHashA || !del.deleteFiles {
+		t.Fatalf("remove: %q %+v", reply.Result, del)
+	}
+}
+
+func TestTransmissionSessionSetAndStats(t *testing.T) {
+	storage := &fakeStorageSettingsCtrl{settings: app.StorageSettingsView{MaxSessions: 3, MinDiskSpaceBytes: 10}}
+	stats := &fakeTransferStats{stats: domain.GlobalStats{TotalTorrents: 2, AllTime: domain.TransferCounters{Downloaded: 100}}}
+	s := newTransmissionServer(newQBitRepo(), WithStorageSettings(storage), WithTransferStats(stats))
+
+	reply := doTransmission(t, s, `{"method":"session-set","arguments":{"download-queue-size":5,"speed-limit-down":100}}`)
+	if reply.Result != "success" || storage.settings.MaxSessions != 5 || storage.settings.MinDiskSpaceBytes != 10 {
+		t.Fatalf("set: %q %+v", reply.Result, storage.settings)
+	}
+	doTransmission(t, s, `{"method":"session-set","arguments":{"download-queue-enabled":false}}`)
+	if storage.settings.MaxSessions != 0 {
+		t.Fatalf("disable queue: %+v", storage.settings)
+	}
+
+	reply = doTransmission(t, s, `{"method":"session-stats"}`)
+	var args struct {
+		Active     int               `json:"activeTorrentCount"`
+		Paused     int               `json:"pausedTorrentCount"`
+		Speed      int64             `json:"downloadSpeed"`
+		Cumulative transmissionStats `json:"cumulative-stats"`
+	}
+	if err := json.Unmarshal(reply.Arguments, &args); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if args.Active != 1 || args.Paused != 1 || args.Speed != 50 || args.Cumulative.DownloadedBytes != 100 {
+		t.Fatalf("stats = %+v", args)
+	}
+}
+
+func TestTransmissionRoles(t *testing.T) {
+	s := newTransmissionServer(newQBitRepo(), WithAuth(newFakeAuthUseCase()))
+
+	req := httptest.NewRequest(http.MethodPost, transmissionRPCPath, strings.NewReader(`{"method":"session-get"}`))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("anonymous: %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+
+	for _, tt := range []struct {
+		key, method string
+		want        int
+	}{
+		{"tx_viewer", "torrent-get", http.StatusOK},
+		{"tx_viewer", "torrent-stop", http.StatusForbidden},
+		{"tx_user", "torrent-stop", http.StatusOK},
+		{"tx_user", "session-set", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodPost, transmissionRPCPath, strings.NewReader(`{"method":"`+tt.method+`","arguments":{"fields":["id"]}}`))
+		req.SetBasicAuth("anyone", tt.key)
+		req.Header.Set(transmissionSessionHeader, s.transmission.sessionID)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Fatalf("%s %s: got %d, want %d: %s", tt.key, tt.method, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package apihttp
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"torrentstream/internal/domain"
+)
+
+// Trash bin handlers. Deleted torrents stay in the trash until they are
+// restored, purged explicitly or expire.
+
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.repo == nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "repository not configured")
+		return
+	}
+
+	records, err := s.repo.List(r.Context(), domain.TorrentFilter{
+		Trashed:   true,
+		SortBy:    "updatedAt",
+		SortOrder: domain.SortDesc,
+	})
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	items := make([]torrentRecordView, 0, len(records))
+	for _, record := range records {
+		items = append(items, buildTorrentRecordView(record))
+	}
+	writeJSON(w, http.StatusOK, torrentListFull{Items: items, Count: len(items)})
+}
+
+func (s *Server) handleTrashByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/trash/")
+	id, action, _ := strings.Cut(rest, "/")
+	if id == "" {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+
+	switch {
+	case action == "restore" && r.Method == http.MethodPost:
+		s.handleRestoreTorrent(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		s.handlePurgeTorrent(w, r, id)
+	case action == "" || action == "restore":
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+	}
+}
+
+func (s *Server) handleRestoreTorrent(w http.ResponseWriter, r *http.Request, id string) {
+	if s.restoreTorrent == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "trash not configured")
+		return
+	}
+
+	record, err := s.restoreTorrent.Execute(r.Context(), domain.TorrentID(id))
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// handlePurgeTorrent removes a trashed torrent for good. Deleting a record
+// that is in the trash bypasses it, so the delete use case does the work.
+func (s *Server) handlePurgeTorrent(w http.ResponseWriter, r *http.Request, id string) {
+	if s.repo == nil || s.deleteTorrent == nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "delete torrent use case not configured")
+		return
+	}
+
+	record, err := s.repo.GetTrashed(r.Context(), domain.TorrentID(id))
+	if errors.Is(err, domain.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "not_found", "torrent not in trash")
+		return
+	}
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	if err := s.deleteTorrent.Execute(r.Context(), record.ID, false); err != nil {
+		writeDomainError(w, err)
+		return
+	}
+
+	if s.hls != nil {
+		s.hls.PurgeTorrent(record.ID)
+	}
+	s.invalidateMediaProbeCache(record.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
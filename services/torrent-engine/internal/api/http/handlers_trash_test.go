@@ -0,0 +1,114 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeRestoreTorrent struct {
+	called int
+	id     domain.TorrentID
+	result domain.TorrentRecord
+	err    error
+}
+
+func (f *fakeRestoreTorrent) Execute(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	f.called++
+	f.id = id
+	return f.result, f.err
+}
+
+func TestListTrash(t *testing.T) {
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeRepo{list: []domain.TorrentRecord{{ID: "t1", Name: "Sintel", DeletedAt: &deletedAt}}}
+	s := NewServer(nil, WithRepository(repo))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/trash", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !repo.lastFilter.Trashed {
+		t.Fatalf("expected trashed filter, got %+v", repo.lastFilter)
+	}
+	var got struct {
+		Items []domain.TorrentRecord `json:"items"`
+		Count int                    `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Count != 1 || got.Items[0].DeletedAt == nil || !got.Items[0].DeletedAt.Equal(deletedAt) {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+}
+
+func TestRestoreTorrentFromTrash(t *testing.T) {
+	uc := &fakeRestoreTorrent{result: domain.TorrentRecord{ID: "t1", Status: domain.TorrentActive}}
+	s := NewServer(nil, WithRestoreTorrent(uc))
+
+	rec := doSettingsRequest(s, http.MethodPost, "/trash/t1/restore", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if uc.called != 1 || uc.id != "t1" {
+		t.Fatalf("restore not called with t1: %+v", uc)
+	}
+
+	uc.err = domain.ErrNotFound
+	rec = doSettingsRequest(s, http.MethodPost, "/trash/t1/restore", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestRestoreTorrent_NotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodPost, "/trash/t1/restore", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+}
+
+func TestPurgeTrashedTorrent(t *testing.T) {
+	deletedAt := time.Now()
+	repo := &fakeRepo{get: domain.TorrentRecord{ID: "t1", DeletedAt: &deletedAt}}
+	deleter := &fakeDeleteTorrent{}
+	s := NewServer(nil, WithRepository(repo), WithDeleteTorrent(deleter))
+
+	rec := doSettingsRequest(s, http.MethodDelete, "/trash/t1", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if deleter.called != 1 || deleter.id != "t1" {
+		t.Fatalf("delete not called: %+v", deleter)
+	}
+}
+
+func TestPurgeTorrentNotInTrash(t *testing.T) {
+	repo := &fakeRepo{get: domain.TorrentRecord{ID: "t1"}}
+	deleter := &fakeDeleteTorrent{}
+	s := NewServer(nil, WithRepository(repo), WithDeleteTorrent(deleter))
+
+	rec := doSettingsRequest(s, http.MethodDelete, "/trash/t1", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	if deleter.called != 0 {
+		t.Fatalf("live torrent must not be deleted through /trash")
+	}
+}
+
+func TestTrashMethodNotAllowed(t *testing.T) {
+	s := NewServer(nil, WithRepository(&fakeRepo{}))
+	if rec := doSettingsRequest(s, http.MethodPost, "/trash", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST /trash: expected 405, got %d", rec.Code)
+	}
+	if rec := doSettingsRequest(s, http.MethodGet, "/trash/t1/restore", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET restore: expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,191 @@
+package apihttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"torrentstream/internal/domain"
+)
+
+// Webhook configuration and delivery log handlers.
+
+type webhookRequest struct {
+	Name    *string             `json:"name"`
+	URL     *string             `json:"url"`
+	Events  *[]domain.EventType `json:"events"`
+	Secret  *string             `json:"secret"`
+	Enabled *bool               `json:"enabled"`
+}
+
+// webhookResponse never includes the secret, only whether one is set.
+type webhookResponse struct {
+	domain.Webhook
+	HasSecret bool `json:"hasSecret"`
+}
+
+func toWebhookResponse(hook domain.Webhook) webhookResponse {
+	return webhookResponse{Webhook: hook, HasSecret: hook.Secret != ""}
+}
+
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.webhooks == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "webhooks not configured")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		hooks, err := s.webhooks.List(r.Context())
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+		items := make([]webhookResponse, 0, len(hooks))
+		for _, hook := range hooks {
+			items = append(items, toWebhookResponse(hook))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": items, "count": len(items)})
+		return
+	}
+
+	body, ok := decodeWebhookRequest(w, r)
+	if !ok {
+		return
+	}
+	// New webhooks are enabled unless stated otherwise.
+	hook := applyWebhookRequest(domain.Webhook{Enabled: true}, body)
+	created, err := s.webhooks.Create(r.Context(), hook)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toWebhookResponse(created))
+}
+
+func (s *Server) handleWebhookByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/settings/webhooks/")
+	id, action, _ := strings.Cut(rest, "/")
+	if id == "" {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+	if action != "" && action != "deliveries" {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+	if s.webhooks == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "webhooks not configured")
+		return
+	}
+
+	switch {
+	case action == "deliveries" && r.Method == http.MethodGet:
+		s.handleWebhookDeliveries(w, r, id)
+	case action == "" && r.Method == http.MethodGet:
+		hook, err := s.webhooks.Get(r.Context(), id)
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toWebhookResponse(hook))
+	case action == "" && (r.Method == http.MethodPut || r.Method == http.MethodPatch):
+		s.handleUpdateWebhook(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		if err := s.webhooks.Delete(r.Context(), id); err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	existing, err := s.webhooks.Get(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	body, ok := decodeWebhookRequest(w, r)
+	if !ok {
+		return
+	}
+	updated, err := s.webhooks.Update(r.Context(), applyWebhookRequest(existing, body))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toWebhookResponse(updated))
+}
+
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+	deliveries, err := s.webhooks.Deliveries(r.Context(), id, limit)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": deliveries, "count": len(deliveries)})
+}
+
+func decodeWebhookRequest(w http.ResponseWriter, r *http.Request) (webhookRequest, bool) {
+	var body webhookRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+		return body, false
+	}
+	return body, true
+}
+
+// applyWebhookRequest sets the fields present in body. An empty secret
+// removes the signature.
+func applyWebhookRequest(hook domain.Webhook, body webhookRequest) domain.Webhook {
+	if body.Name != nil {
+		hook.Name = *body.Name
+	}
+	if body.URL != nil {
+		hook.URL = *body.URL
+	}
+	if body.Events != nil {
+		hook.Events = *body.Events
+	}
+	if body.Secret != nil {
+		hook.Secret = *body.Secret
+	}
+	if body.Enabled != nil {
+		hook.Enabled = *body.Enabled
+	}
+	return hook
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "not_found", "webhook not found")
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidWebhook) {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	writeDomainError(w, err)
+}
@@ -0,0 +1,148 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeWebhooksUseCase struct {
+	hooks      map[string]domain.Webhook
+	deliveries []domain.WebhookDelivery
+	nextID     int
+}
+
+func newFakeWebhooksUseCase() *fakeWebhooksUseCase {
+	return &fakeWebhooksUseCase{hooks: make(map[string]domain.Webhook)}
+}
+
+func (f *fakeWebhooksUseCase) List(_ context.Context) ([]domain.Webhook, error) {
+	var out []domain.Webhook
+	for _, h := range f.hooks {
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+func (f *fakeWebhooksUseCase) Get(_ context.Context, id string) (domain.Webhook, error) {
+	h, ok := f.hooks[id]
+	if !ok {
+		return domain.Webhook{}, domain.ErrNotFound
+	}
+	return h, nil
+}
+
+func (f *fakeWebhooksUseCase) Create(_ context.Context, hook domain.Webhook) (domain.Webhook, error) {
+	if !strings.HasPrefix(hook.URL, "http") {
+		return domain.Webhook{}, fmt.Errorf("%w: bad url", domain.ErrInvalidWebhook)
+	}
+	f.nextID++
+	hook.ID = fmt.Sprintf("w%d", f.nextID)
+	f.hooks[hook.ID] = hook
+	return hook, nil
+}
+
+func (f *fakeWebhooksUseCase) Update(_ context.Context, hook domain.Webhook) (domain.Webhook, error) {
+	if _, ok := f.hooks[hook.ID]; !ok {
+		return domain.Webhook{}, domain.ErrNotFound
+	}
+	f.hooks[hook.ID] = hook
+	return hook, nil
+}
+
+func (f *fakeWebhooksUseCase) Delete(_ context.Context, id string) error {
+	if _, ok := f.hooks[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.hooks, id)
+	return nil
+}
+
+func (f *fakeWebhooksUseCase) Deliveries(_ context.Context, id string, limit int) ([]domain.WebhookDelivery, error) {
+	if _, ok := f.hooks[id]; !ok {
+		return nil, domain.ErrNotFound
+	}
+	return f.deliveries, nil
+}
+
+func TestWebhooksNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodGet, "/settings/webhooks", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+}
+
+func TestWebhooksCRUD(t *testing.T) {
+	uc := newFakeWebhooksUseCase()
+	s := NewServer(nil, WithWebhooks(uc))
+
+	rec := doSettingsRequest(s, http.MethodPost, "/settings/webhooks",
+		[]byte(`{"url":"https://example.com/hook","events":["completed"],"secret":"s3cret"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "s3cret") {
+		t.Fatalf("secret leaked: %s", rec.Body.String())
+	}
+	var created webhookResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID != "w1" || !created.Enabled || !created.HasSecret {
+		t.Fatalf("created = %+v", created)
+	}
+
+	rec = doSettingsRequest(s, http.MethodPatch, "/settings/webhooks/w1", []byte(`{"enabled":false}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if hook := uc.hooks["w1"]; hook.Enabled || hook.Secret != "s3cret" || hook.URL != "https://example.com/hook" {
+		t.Fatalf("patched hook = %+v", hook)
+	}
+
+	rec = doSettingsRequest(s, http.MethodGet, "/settings/webhooks/w1/deliveries?limit=10", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"items":[]`) {
+		t.Fatalf("deliveries: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doSettingsRequest(s, http.MethodDelete, "/settings/webhooks/w1", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rec.Code)
+	}
+	rec = doSettingsRequest(s, http.MethodGet, "/settings/webhooks/w1", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get deleted: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestWebhooksRejectInvalidRequests(t *testing.T) {
+	s := NewServer(nil, WithWebhooks(newFakeWebhooksUseCase()))
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPost, "/settings/webhooks", `{"url":"ftp://x"}`, http.StatusBadRequest},
+		{http.MethodPost, "/settings/webhooks", `{"bogus":1}`, http.StatusBadRequest},
+		{http.MethodGet, "/settings/webhooks/missing/deliveries?limit=0", "", http.StatusBadRequest},
+		{http.MethodPut, "/settings/webhooks/missing", `{}`, http.StatusNotFound},
+		{http.MethodGet, "/settings/webhooks/w1/other", "", http.StatusNotFound},
+		{http.MethodPost, "/settings/webhooks/w1", `{}`, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		var body []byte
+		if tt.body != "" {
+			body = []byte(tt.body)
+		}
+		rec := doSettingsRequest(s, tt.method, tt.path, body)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d: %s", tt.method, tt.path, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package apihttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"torrentstream/internal/domain"
+)
+
+type webSeedList struct {
+	Items []domain.WebSeed `json:"items"`
+	Count int              `json:"count"`
+}
+
+type addWebSeedsRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// handleWebSeeds serves /torrents/{id}/webseeds: GET lists web seeds with
+// their statistics, POST adds URLs and DELETE ?url= removes one.
+func (s *Server) handleWebSeeds(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPost, http.MethodDelete:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.webSeeds == nil {
+		writeError(w, http.StatusNotImplemented, "not_configured", "web seeds not configured")
+		return
+	}
+
+	torrentID := domain.TorrentID(id)
+	switch r.Method {
+	case http.MethodGet:
+		seeds, err := s.webSeeds.List(r.Context(), torrentID)
+		if err != nil {
+			writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, webSeedList{Items: seeds, Count: len(seeds)})
+	case http.MethodPost:
+		var body addWebSeedsRequest
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid json")
+			return
+		}
+		seeds, err := s.webSeeds.Add(r.Context(), torrentID, body.URLs)
+		if err != nil {
+			writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, webSeedList{Items: seeds, Count: len(seeds)})
+	case http.MethodDelete:
+		url := strings.TrimSpace(r.URL.Query().Get("url"))
+		if url == "" {
+			writeError(w, http.StatusBadRequest, "invalid_request", "url is required")
+			return
+		}
+		if err := s.webSeeds.Remove(r.Context(), torrentID, url); err != nil {
+			writeDomainError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
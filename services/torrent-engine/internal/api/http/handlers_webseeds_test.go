@@ -0,0 +1,122 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeWebSeeds struct {
+	seeds   []domain.WebSeed
+	err     error
+	id      domain.TorrentID
+	added   []string
+	removed string
+}
+
+func (f *fakeWebSeeds) List(ctx context.Context, id domain.TorrentID) ([]domain.WebSeed, error) {
+	f.id = id
+	return f.seeds, f.err
+}
+
+func (f *fakeWebSeeds) Add(ctx context.Context, id domain.TorrentID, urls []string) ([]domain.WebSeed, error) {
+	f.id = id
+	f.added = urls
+	return f.seeds, f.err
+}
+
+func (f *fakeWebSeeds) Remove(ctx context.Context, id domain.TorrentID, url string) error {
+	f.id = id
+	f.removed = url
+	return f.err
+}
+
+func TestListWebSeeds(t *testing.T) {
+	uc := &fakeWebSeeds{seeds: []domain.WebSeed{{URL: "http://mirror/", BytesDownloaded: 1024, Errors: 1, LastError: "HTTP 503"}}}
+	s := NewServer(nil, WithWebSeeds(uc))
+
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/webseeds", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got webSeedList
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if uc.id != "t1" || got.Count != 1 || got.Items[0].BytesDownloaded != 1024 || got.Items[0].LastError != "HTTP 503" {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+}
+
+func TestAddWebSeeds(t *testing.T) {
+	uc := &fakeWebSeeds{}
+	s := NewServer(nil, WithWebSeeds(uc))
+
+	rec := doSettingsRequest(s, http.MethodPost, "/torrents/t1/webseeds", []byte(`{"urls":["http://mirror/data/"]}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(uc.added) != 1 || uc.added[0] != "http://mirror/data/" {
+		t.Fatalf("added = %v", uc.added)
+	}
+
+	uc.err = domain.ErrInvalidWebSeed
+	rec = doSettingsRequest(s, http.MethodPost, "/torrents/t1/webseeds", []byte(`{"urls":["nope"]}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestRemoveWebSeed(t *testing.T) {
+	uc := &fakeWebSeeds{}
+	s := NewServer(nil, WithWebSeeds(uc))
+
+	rec := doSettingsRequest(s, http.MethodDelete, "/torrents/t1/webseeds?url=http%3A%2F%2Fmirror%2F", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if uc.removed != "http://mirror/" {
+		t.Fatalf("removed = %q", uc.removed)
+	}
+
+	rec = doSettingsRequest(s, http.MethodDelete, "/torrents/t1/webseeds", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without url, got %d", rec.Code)
+	}
+
+	uc.err = domain.ErrNotFound
+	rec = doSettingsRequest(s, http.MethodDelete, "/torrents/t1/webseeds?url=http%3A%2F%2Fother%2F", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestWebSeedsNotConfigured(t *testing.T) {
+	s := NewServer(nil)
+	rec := doSettingsRequest(s, http.MethodGet, "/torrents/t1/webseeds", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", rec.Code)
+	}
+}
+
+func TestCreateTorrentJSONWithWebSeeds(t *testing.T) {
+	uc := &fakeCreateTorrent{result: domain.TorrentRecord{ID: "t1"}}
+	s := NewServer(uc)
+
+	req := httptest.NewRequest(http.MethodPost, "/torrents", strings.NewReader(`{"magnet":"magnet:?xt=urn:btih:abc","webSeeds":["http://mirror/data/"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := uc.input.Source.WebSeeds; len(got) != 1 || got[0] != "http://mirror/data/" {
+		t.Fatalf("webSeeds = %v", got)
+	}
+}
@@ -1276,6 +1276,45 @@ func TestStreamJobManagerEnsureJobNilStream(t *testing.T) {
 	}
 }
 
+type recordingEvents struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (r *recordingEvents) Publish(event domain.Event) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func TestStreamJobManagerEnsureJobPublishesPlaybackStarted(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mgr := newStreamJobManager(&fakeStreamTorrent{}, nil, HLSConfig{BaseDir: t.TempDir()}, logger)
+	events := &recordingEvents{}
+	mgr.events = events
+
+	// A finished transcode from a previous run is reused without FFmpeg.
+	dir := mgr.buildJobDir(hlsKey{id: "t1", fileIndex: 2, audioTrack: 0, subtitleTrack: -1})
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.m3u8"), []byte("#EXTM3U\n#EXT-X-ENDLIST\n"), 0o644); err != nil {
+		t.Fatalf("write playlist: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := mgr.EnsureJob("t1", 2, 0, -1); err != nil {
+			t.Fatalf("EnsureJob: %v", err)
+		}
+	}
+	if len(events.events) != 1 {
+		t.Fatalf("events = %+v", events.events)
+	}
+	if ev := events.events[0]; ev.Type != domain.EventPlaybackStarted || ev.TorrentID != "t1" || ev.FileIndex == nil || *ev.FileIndex != 2 {
+		t.Fatalf("event = %+v", ev)
+	}
+}
+
 func TestStreamJobManagerCountRunningJobs(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 	mgr := newStreamJobManager(nil, nil, HLSConfig{}, logger)
@@ -0,0 +1,120 @@
+package apihttp
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+const (
+	// loginFreeFailures is how many failed logins pass before the backoff
+	// starts.
+	loginFreeFailures = 5
+	loginBaseDelay    = time.Second
+	loginMaxDelay     = 15 * time.Minute
+	// loginUserMaxDelay caps the backoff of a username, which anyone can
+	// run up, so nobody can lock a user out for long.
+	loginUserMaxDelay = time.Minute
+	// loginForgetAfter drops the failures of a client or username that
+	// has not failed for this long.
+	loginForgetAfter = time.Hour
+	loginMaxEntries  = 10000
+)
+
+// loginThrottle slows down password guessing. Failed logins are counted
+// per client address and per username; past loginFreeFailures each
+// failure doubles the wait before the next attempt, up to loginMaxDelay
+// for an address and loginUserMaxDelay for a username.
+type loginThrottle struct {
+	mu       sync.Mutex
+	failures map[string]loginFailures
+	now      func() time.Time
+}
+
+type loginFailures struct {
+	count int
+	last  time.Time
+	until time.Time
+}
+
+func newLoginThrottle() *loginThrottle {
+	return &loginThrottle{failures: make(map[string]loginFailures), now: time.Now}
+}
+
+// loginKeys returns the throttle keys of a login attempt: the client
+// address and the username.
+func (s *Server) loginKeys(r *http.Request, username string) []string {
+	name, err := domain.NormalizeUsername(username)
+	if err != nil {
+		name = strings.ToLower(strings.TrimSpace(username))
+	}
+	return []string{"ip:" + clientIP(r, s.trustedProxies), "user:" + name}
+}
+
+// wait returns how long the caller has to wait before the next attempt,
+// or zero when it may try now.
+func (t *loginThrottle) wait(keys []string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	var wait time.Duration
+	for _, key := range keys {
+		if d := t.failures[key].until.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+func (t *loginThrottle) fail(keys []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if len(t.failures) >= loginMaxEntries {
+		t.forget(now)
+	}
+	for _, key := range keys {
+		f := t.failures[key]
+		if now.Sub(f.last) > loginForgetAfter {
+			f = loginFailures{}
+		}
+		f.count++
+		f.last = now
+		if over := f.count - loginFreeFailures; over > 0 {
+			maxDelay := loginMaxDelay
+			if strings.HasPrefix(key, "user:") {
+				maxDelay = loginUserMaxDelay
+			}
+			delay := maxDelay
+			if over <= 20 {
+				delay = min(loginBaseDelay<<(over-1), maxDelay)
+			}
+			f.until = now.Add(delay)
+		}
+		t.failures[key] = f
+	}
+}
+
+// succeed clears the failures of the username. The client address keeps
+// its count, so logging into one's own account does not reset guessing
+// at others.
+func (t *loginThrottle) succeed(keys []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range keys {
+		if strings.HasPrefix(key, "user:") {
+			delete(t.failures, key)
+		}
+	}
+}
+
+func (t *loginThrottle) forget(now time.Time) {
+	for key, f := range t.failures {
+		if now.Sub(f.last) > loginForgetAfter {
+			delete(t.failures, key)
+		}
+	}
+}
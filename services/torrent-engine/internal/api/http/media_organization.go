@@ -12,6 +12,12 @@ import (
 
 type torrentRecordView struct {
 	domain.TorrentRecord
+	// DataDir is the storage root holding the torrent data; empty for the
+	// default data directory.
+	DataDir           string             `json:"dataDir,omitempty"`
+	Trackers          []string           `json:"trackers,omitempty"`      // magnet tr or create request
+	Peers             []string           `json:"peers,omitempty"`         // magnet x.pe or create request
+	SelectedFiles     []int              `json:"selectedFiles,omitempty"` // BEP 53 select-only indices
 	MediaOrganization *mediaOrganization `json:"mediaOrganization,omitempty"`
 }
 
@@ -57,16 +63,27 @@ var (
 	spacePattern     = regexp.MustCompile(`\s+`)
 )
 
-var mediaVideoExtensions = map[string]struct{}{
-	".mp4": {}, ".m4v": {}, ".mov": {}, ".mkv": {}, ".avi": {},
-	".wmv": {}, ".flv": {}, ".webm": {}, ".ts": {}, ".m2ts": {},
-}
-
 func buildTorrentRecordView(record domain.TorrentRecord) torrentRecordView {
 	return torrentRecordView{
 		TorrentRecord:     record,
-		MediaOrganization: buildMediaOrganization(record.Files),
+		DataDir:           record.Source.DataDir,
+		Trackers:          redactURLs(record.Source.Trackers),
+		Peers:             record.Source.Peers,
+		SelectedFiles:     record.Source.SelectedFiles,
+		MediaOrganization: buildMediaOrganization(record.AllFiles()),
+	}
+}
+
+// redactURLs returns urls with tracker credentials replaced.
+func redactURLs(urls []string) []string {
+	if len(urls) == 0 {
+		return nil
 	}
+	out := make([]string, len(urls))
+	for i, u := range urls {
+		out[i] = domain.RedactURL(u)
+	}
+	return out
 }
 
 func buildMediaOrganization(files []domain.FileRef) *mediaOrganization {
@@ -213,7 +230,7 @@ func parseMediaFile(file domain.FileRef) mediaParsedFile {
 		}
 	}
 
-	if _, ok := mediaVideoExtensions[ext]; !ok {
+	if _, ok := domain.VideoExtensions[ext]; !ok {
 		return parsed
 	}
 
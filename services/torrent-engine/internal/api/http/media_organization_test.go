@@ -69,3 +69,25 @@ func TestBuildTorrentRecordViewIncludesOrganization(t *testing.T) {
 		t.Fatalf("contentType = %q, want movie", view.MediaOrganization.ContentType)
 	}
 }
+
+func TestBuildTorrentRecordViewIncludesExtractedFiles(t *testing.T) {
+	record := domain.TorrentRecord{
+		ID:        "t1",
+		Files:     []domain.FileRef{{Index: 0, Path: "Movie.2024/movie.rar", Length: 100}},
+		Extracted: []domain.FileRef{{Index: 1, Path: "Movie.2024/Movie.2024.1080p.mkv", Length: 90}},
+	}
+
+	view := buildTorrentRecordView(record)
+	if view.MediaOrganization == nil || len(view.MediaOrganization.Groups) == 0 {
+		t.Fatalf("mediaOrganization = %+v", view.MediaOrganization)
+	}
+	found := false
+	for _, group := range view.MediaOrganization.Groups {
+		for _, item := range group.Items {
+			found = found || item.FileIndex == 1
+		}
+	}
+	if !found {
+		t.Fatalf("extracted file missing from %+v", view.MediaOrganization.Groups)
+	}
+}
@@ -2,16 +2,21 @@ package apihttp
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/time/rate"
+	"torrentstream/internal/domain"
 	"torrentstream/internal/metrics"
 )
 
@@ -41,6 +46,14 @@ func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return nil, nil, fmt.Errorf("underlying ResponseWriter does not implement http.Hijacker")
 }
 
+// Flush implements http.Flusher so that server-sent events reach the client
+// as they are written.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // corsMiddleware handles Cross-Origin Resource Sharing headers.
 // When allowedOrigins is empty, any origin is permitted (development mode).
 // When populated, only listed origins are reflected; unmatched origins get
@@ -64,8 +77,12 @@ func corsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Vary", "Origin")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Range")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Range, Authorization, X-Api-Key, X-Profile-Id")
 			w.Header().Set("Access-Control-Expose-Headers", "Content-Range, Accept-Ranges, Content-Length, transferMode.dlna.org, contentFeatures.dlna.org")
+			// Session cookies are only sent by origins listed explicitly.
+			if allowed[origin] {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
 		}
 
 		if r.Method == http.MethodOptions {
@@ -77,7 +94,7 @@ func corsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
 	})
 }
 
-func loggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+func loggingMiddleware(logger *slog.Logger, trusted []netip.Prefix, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
@@ -92,10 +109,10 @@ func loggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
 			slog.Int("status", rw.status),
 			slog.Int("bytes", rw.size),
 			slog.Int64("durationMs", duration.Milliseconds()),
-			slog.String("clientIP", clientIP(r)),
+			slog.String("clientIP", clientIP(r, trusted)),
 		}
 		if rawQuery := strings.TrimSpace(r.URL.RawQuery); rawQuery != "" {
-			attrs = append(attrs, slog.String("query", truncate(rawQuery, 180)))
+			attrs = append(attrs, slog.String("query", truncate(redactQuery(rawQuery), 180)))
 		}
 		if userAgent := strings.TrimSpace(r.UserAgent()); userAgent != "" {
 			attrs = append(attrs, slog.String("userAgent", truncate(userAgent, 120)))
@@ -104,7 +121,20 @@ func loggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
 	})
 }
 
-func recoveryMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+// redactQuery hides API keys passed in the query string.
+func redactQuery(rawQuery string) string {
+	if !strings.Contains(rawQuery, apiKeyParam+"=") {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return domain.Redacted
+	}
+	values.Set(apiKeyParam, domain.Redacted)
+	return values.Encode()
+}
+
+func recoveryMiddleware(logger *slog.Logger, trusted []netip.Prefix, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
@@ -112,7 +142,7 @@ func recoveryMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
 					slog.Any("error", err),
 					slog.String("method", r.Method),
 					slog.String("path", r.URL.Path),
-					slog.String("clientIP", clientIP(r)),
+					slog.String("clientIP", clientIP(r, trusted)),
 					slog.String("stack", string(debug.Stack())),
 				)
 				writeError(w, http.StatusInternalServerError, "internal_error", "internal server error")
@@ -154,12 +184,30 @@ func normalizeRoute(path string) string {
 		return "/torrents/:id"
 	case strings.HasPrefix(path, "/settings/"):
 		return "/settings"
+	case strings.HasPrefix(path, "/retention/"):
+		return "/retention"
+	case path == "/events" || path == "/ws":
+		return path
+	case path == "/trash":
+		return "/trash"
+	case strings.HasPrefix(path, "/trash/"):
+		return "/trash/:id"
 	case path == "/watch-history":
 		return "/watch-history"
 	case strings.HasPrefix(path, "/watch-history/"):
 		return "/watch-history/:id"
+	case strings.HasPrefix(path, "/auth/"):
+		return "/auth"
+	case path == "/users" || strings.HasPrefix(path, "/users/"):
+		return "/users"
+	case path == "/profiles" || strings.HasPrefix(path, "/profiles/"):
+		return "/profiles"
 	case strings.HasPrefix(path, "/swagger"):
 		return "/swagger"
+	case strings.HasPrefix(path, qbitAPIPrefix):
+		return "/api/v2"
+	case path == transmissionRPCPath:
+		return path
 	default:
 		return "/other"
 	}
@@ -191,21 +239,48 @@ func isNoisyPath(path string) bool {
 	return false
 }
 
-func clientIP(r *http.Request) string {
+// clientIP returns the address of the client. X-Forwarded-For and
+// X-Real-IP are only believed when the request comes from a trusted proxy;
+// X-Forwarded-For is then read from the right, skipping trusted proxies, so
+// a client cannot pick its address by sending the header itself.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote := strings.TrimSpace(r.RemoteAddr)
+	if host, _, err := net.SplitHostPort(remote); err == nil && host != "" {
+		remote = host
+	}
+	if !isTrustedProxy(remote, trusted) {
+		return remote
+	}
 	if xff := strings.TrimSpace(r.Header.Get("X-Forwarded-For")); xff != "" {
-		parts := strings.Split(xff, ",")
-		if len(parts) > 0 && strings.TrimSpace(parts[0]) != "" {
-			return strings.TrimSpace(parts[0])
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			if i == 0 || !isTrustedProxy(hop, trusted) {
+				return hop
+			}
 		}
 	}
 	if xrip := strings.TrimSpace(r.Header.Get("X-Real-IP")); xrip != "" {
 		return xrip
 	}
-	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
-	if err == nil && host != "" {
-		return host
+	return remote
+}
+
+func isTrustedProxy(addr string, trusted []netip.Prefix) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
 	}
-	return strings.TrimSpace(r.RemoteAddr)
+	return false
 }
 
 func truncate(value string, limit int) string {
@@ -236,3 +311,160 @@ func rateLimitMiddleware(rps float64, burst int, next http.Handler) http.Handler
 		next.ServeHTTP(w, r)
 	})
 }
+
+const (
+	sessionCookie = "torrx_session"
+	apiKeyHeader  = "X-Api-Key"
+	// apiKeyParam carries the API key for clients that cannot set headers,
+	// such as media players opening a stream URL.
+	apiKeyParam = "apikey"
+)
+
+type principalKey struct{}
+
+// principalFrom returns the caller authenticated by authMiddleware.
+func principalFrom(ctx context.Context) (domain.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return p, ok
+}
+
+// authMiddleware authenticates requests with an API key or a session
+// cookie and rejects callers whose role does not allow the request. With a
+// nil auth every request passes.
+func authMiddleware(auth AuthUseCase, next http.Handler) http.Handler {
+	if auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		required, ok := requiredRole(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// qBittorrent clients expect a plain 403 and log in again.
+		qbit := strings.HasPrefix(r.URL.Path, qbitAPIPrefix)
+		var (
+			principal domain.Principal
+			err       error
+		)
+		if key := requestAPIKey(r); key != "" {
+			principal, err = auth.AuthenticateKey(r.Context(), key)
+		} else {
+			principal, err = auth.Authenticate(r.Context(), sessionToken(r))
+		}
+		if err != nil {
+			if errors.Is(err, domain.ErrUnauthorized) {
+				if qbit {
+					writeQBitText(w, http.StatusForbidden, "Forbidden")
+					return
+				}
+				if r.URL.Path == transmissionRPCPath {
+					// Transmission clients ask for credentials on a Basic
+					// challenge.
+					w.Header().Set("WWW-Authenticate", `Basic realm="Transmission"`)
+				}
+				writeError(w, http.StatusUnauthorized, "unauthorized", "authentication required")
+				return
+			}
+			writeDomainError(w, err)
+			return
+		}
+		if !principal.Role.Allows(required) {
+			if qbit {
+				writeQBitText(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+			writeError(w, http.StatusForbidden, "forbidden", "requires role "+string(required))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// requiredRole returns the role a request needs, or false for requests
+// that need no authentication. Viewers may read and play: besides GET they
+// may seek, focus a torrent, switch profile, save their watch position and
+// change their player settings. Settings, users and retention need an
+// admin; every other change needs a user. The qBittorrent API has its own
+// read endpoints, which it also serves over POST. The Transmission RPC
+// checks the role of each method itself.
+func requiredRole(r *http.Request) (domain.Role, bool) {
+	path := r.URL.Path
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+	switch {
+	case r.Method == http.MethodOptions,
+		path == "/auth/login", path == "/auth/logout",
+		path == qbitAPIPrefix+"auth/login", path == qbitAPIPrefix+"auth/logout",
+		path == "/internal/health/player", path == "/metrics",
+		strings.HasPrefix(path, "/swagger"):
+		return "", false
+	case path == "/settings/player", path == transmissionRPCPath:
+		return domain.RoleViewer, true
+	case strings.HasPrefix(path, qbitAPIPrefix):
+		if qbitReadEndpoints[strings.TrimPrefix(path, qbitAPIPrefix)] {
+			return domain.RoleViewer, true
+		}
+		return domain.RoleUser, true
+	case strings.HasPrefix(path, "/settings/"), strings.HasPrefix(path, "/retention/"),
+		path == "/users", strings.HasPrefix(path, "/users/"):
+		return domain.RoleAdmin, true
+	case read, strings.HasPrefix(path, "/auth/"), isPlaybackWrite(r):
+		return domain.RoleViewer, true
+	default:
+		return domain.RoleUser, true
+	}
+}
+
+// isPlaybackWrite reports whether r is a write the player makes while
+// watching.
+func isPlaybackWrite(r *http.Request) bool {
+	path := r.URL.Path
+	if strings.HasPrefix(path, "/watch-history/") {
+		return r.Method == http.MethodPut
+	}
+	if strings.HasPrefix(path, "/profiles/") && strings.HasSuffix(path, "/switch") {
+		return r.Method == http.MethodPost
+	}
+	if r.Method != http.MethodPost || !strings.HasPrefix(path, "/torrents/") {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/torrents/"), "/")
+	switch {
+	case len(parts) == 1:
+		return parts[0] == "unfocus"
+	case parts[1] == "focus":
+		return len(parts) == 2
+	case parts[1] == "hls":
+		return parts[len(parts)-1] == "seek"
+	}
+	return false
+}
+
+// requestAPIKey returns the API key from the X-Api-Key header, a bearer
+// token, the password of Basic credentials or the apikey query parameter.
+func requestAPIKey(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(apiKeyHeader)); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	// Clients that only know Basic authentication, such as Transmission
+	// remotes, send the key as the password with any username.
+	if _, password, ok := r.BasicAuth(); ok {
+		return strings.TrimSpace(password)
+	}
+	return strings.TrimSpace(r.URL.Query().Get(apiKeyParam))
+}
+
+// sessionToken returns the session cookie, or the one set by the
+// qBittorrent login.
+func sessionToken(r *http.Request) string {
+	for _, name := range []string{sessionCookie, qbitSessionCookie} {
+		if cookie, err := r.Cookie(name); err == nil && cookie.Value != "" {
+			return cookie.Value
+		}
+	}
+	return ""
+}
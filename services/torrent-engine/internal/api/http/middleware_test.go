@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"strings"
 	"testing"
 )
@@ -231,7 +232,7 @@ func TestRateLimitMiddleware_SkipsMetricsEndpoint(t *testing.T) {
 
 func TestRecoveryMiddleware_CatchesPanic(t *testing.T) {
 	logger := slog.Default()
-	handler := recoveryMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := recoveryMiddleware(logger, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		panic("test panic")
 	}))
 
@@ -248,7 +249,7 @@ func TestRecoveryMiddleware_CatchesPanic(t *testing.T) {
 
 func TestRecoveryMiddleware_CatchesNilPanic(t *testing.T) {
 	logger := slog.Default()
-	handler := recoveryMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := recoveryMiddleware(logger, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		panic(nil)
 	}))
 
@@ -269,7 +270,7 @@ func TestRecoveryMiddleware_CatchesNilPanic(t *testing.T) {
 
 func TestRecoveryMiddleware_CatchesErrorPanic(t *testing.T) {
 	logger := slog.Default()
-	handler := recoveryMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := recoveryMiddleware(logger, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		panic(fmt.Errorf("something went wrong"))
 	}))
 
@@ -285,7 +286,7 @@ func TestRecoveryMiddleware_CatchesErrorPanic(t *testing.T) {
 
 func TestRecoveryMiddleware_NoPanicPassesThrough(t *testing.T) {
 	logger := slog.Default()
-	handler := recoveryMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := recoveryMiddleware(logger, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
 	}))
 
@@ -303,7 +304,7 @@ func TestRecoveryMiddleware_NoPanicPassesThrough(t *testing.T) {
 
 func TestLoggingMiddleware_SetsStatusAndSize(t *testing.T) {
 	logger := slog.Default()
-	handler := loggingMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := loggingMiddleware(logger, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("hello"))
 	}))
@@ -329,7 +330,7 @@ func TestLoggingMiddleware_DefaultStatusIs200(t *testing.T) {
 		w.Write([]byte("ok"))
 	})
 	// Wrap in a test middleware to capture the responseWriter status.
-	handler := loggingMiddleware(logger, inner)
+	handler := loggingMiddleware(logger, nil, inner)
 
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 	rec := httptest.NewRecorder()
@@ -408,39 +409,73 @@ func TestResponseWriter_HijackUnsupported(t *testing.T) {
 // ---------- clientIP tests ----------
 
 func TestClientIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
 	tests := []struct {
 		name       string
 		xff        string
 		xRealIP    string
 		remoteAddr string
+		trusted    []netip.Prefix
 		want       string
 	}{
 		{
-			name:       "X-Forwarded-For single",
+			name:       "X-Forwarded-For ignored without trusted proxies",
 			xff:        "1.2.3.4",
 			remoteAddr: "5.6.7.8:9999",
-			want:       "1.2.3.4",
+			want:       "5.6.7.8",
 		},
 		{
-			name:       "X-Forwarded-For multiple takes first",
-			xff:        "1.2.3.4, 10.0.0.1, 172.16.0.1",
+			name:       "X-Forwarded-For ignored from untrusted remote",
+			xff:        "1.2.3.4",
+			xRealIP:    "1.2.3.4",
 			remoteAddr: "5.6.7.8:9999",
+			trusted:    proxies,
+			want:       "5.6.7.8",
+		},
+		{
+			name:       "X-Forwarded-For single from trusted proxy",
+			xff:        "1.2.3.4",
+			remoteAddr: "10.0.0.2:9999",
+			trusted:    proxies,
 			want:       "1.2.3.4",
 		},
 		{
-			name:       "X-Forwarded-For with spaces",
-			xff:        "  1.2.3.4 , 10.0.0.1",
-			remoteAddr: "5.6.7.8:9999",
+			name:       "X-Forwarded-For skips trusted hops from the right",
+			xff:        "9.9.9.9, 1.2.3.4 , 10.0.0.1",
+			remoteAddr: "10.0.0.2:9999",
+			trusted:    proxies,
 			want:       "1.2.3.4",
 		},
 		{
-			name:       "X-Real-IP fallback",
-			xRealIP:    "10.0.0.1",
-			remoteAddr: "5.6.7.8:9999",
-			want:       "10.0.0.1",
+			name:       "X-Forwarded-For of only trusted hops takes first",
+			xff:        "10.0.0.3, 10.0.0.1",
+			remoteAddr: "[::1]:9999",
+			trusted:    proxies,
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			xRealIP:    "1.2.3.4",
+			remoteAddr: "10.0.0.2:9999",
+			trusted:    proxies,
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "XFF whitespace only falls through to X-Real-IP",
+			xff:        "   ",
+			xRealIP:    "1.2.3.4",
+			remoteAddr: "10.0.0.2:9999",
+			trusted:    proxies,
+			want:       "1.2.3.4",
 		},
 		{
-			name:       "RemoteAddr fallback with port",
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.2:9999",
+			trusted:    proxies,
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "RemoteAddr with port",
 			remoteAddr: "192.168.1.1:12345",
 			want:       "192.168.1.1",
 		},
@@ -449,20 +484,6 @@ func TestClientIP(t *testing.T) {
 			remoteAddr: "192.168.1.1",
 			want:       "192.168.1.1",
 		},
-		{
-			name:       "XFF empty string falls through",
-			xff:        "",
-			xRealIP:    "10.0.0.1",
-			remoteAddr: "5.6.7.8:9999",
-			want:       "10.0.0.1",
-		},
-		{
-			name:       "XFF whitespace only falls through",
-			xff:        "   ",
-			xRealIP:    "",
-			remoteAddr: "5.6.7.8:9999",
-			want:       "5.6.7.8",
-		},
 	}
 
 	for _, tc := range tests {
@@ -476,7 +497,7 @@ func TestClientIP(t *testing.T) {
 			}
 			req.RemoteAddr = tc.remoteAddr
 
-			got := clientIP(req)
+			got := clientIP(req, tc.trusted)
 			if got != tc.want {
 				t.Errorf("clientIP() = %q, want %q", got, tc.want)
 			}
@@ -590,6 +611,10 @@ func TestNormalizeRoute(t *testing.T) {
 		{"/torrents/abc123/hls/0/master.m3u8", "/hls/playlist"},
 		{"/settings/encoding", "/settings"},
 		{"/settings/storage", "/settings"},
+		{"/settings/retention", "/settings"},
+		{"/retention/preview", "/retention"},
+		{"/trash", "/trash"},
+		{"/trash/abc/restore", "/trash/:id"},
 		{"/watch-history", "/watch-history"},
 		{"/watch-history/abc123", "/watch-history/:id"},
 		{"/torrents/abc/hls/0/index.m3u8", "/hls/playlist"},
@@ -661,11 +686,11 @@ func TestMiddlewareChain_RecoveryOutermost(t *testing.T) {
 		panic("test chain panic")
 	})
 
-	chain := recoveryMiddleware(logger,
+	chain := recoveryMiddleware(logger, nil,
 		rateLimitMiddleware(100, 200,
 			metricsMiddleware(
 				corsMiddleware(nil,
-					loggingMiddleware(logger, inner)))))
+					loggingMiddleware(logger, nil, inner)))))
 
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 	req.Header.Set("Origin", "http://example.com")
@@ -685,7 +710,7 @@ func TestMiddlewareChain_CorsAndRateLimitTogether(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	chain := recoveryMiddleware(logger,
+	chain := recoveryMiddleware(logger, nil,
 		rateLimitMiddleware(0.001, 1,
 			corsMiddleware([]string{"http://allowed.com"}, inner)))
 
@@ -5,6 +5,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -36,11 +38,58 @@ type DeleteTorrentUseCase interface {
 	Execute(ctx context.Context, id domain.TorrentID, deleteFiles bool) error
 }
 
+type RestoreTorrentUseCase interface {
+	Execute(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error)
+}
+
+type WebSeedsUseCase interface {
+	List(ctx context.Context, id domain.TorrentID) ([]domain.WebSeed, error)
+	Add(ctx context.Context, id domain.TorrentID, urls []string) ([]domain.WebSeed, error)
+	Remove(ctx context.Context, id domain.TorrentID, url string) error
+}
+
+type StatsHistoryUseCase interface {
+	Query(ctx context.Context, id domain.TorrentID, from, to time.Time, step time.Duration) ([]domain.StatsSample, error)
+	Totals(ctx context.Context, id domain.TorrentID) (domain.TransferTotals, error)
+}
+
+type IntegrityUseCase interface {
+	Report(ctx context.Context, id domain.TorrentID) (domain.IntegrityReport, error)
+}
+
+type TransferStatsUseCase interface {
+	Get(ctx context.Context) (domain.GlobalStats, error)
+}
+
 type StreamTorrentUseCase interface {
 	Execute(ctx context.Context, id domain.TorrentID, fileIndex int) (usecase.StreamResult, error)
 	ExecuteRaw(ctx context.Context, id domain.TorrentID, fileIndex int) (usecase.StreamResult, error)
 }
 
+type ArchiveFilesUseCase interface {
+	ArchivedFiles(ctx context.Context, id domain.TorrentID) ([]usecase.ArchivedFile, error)
+}
+
+type EventLogUseCase interface {
+	List(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error)
+}
+
+type AuthUseCase interface {
+	Authenticate(ctx context.Context, token string) (domain.Principal, error)
+	AuthenticateKey(ctx context.Context, key string) (domain.Principal, error)
+	Login(ctx context.Context, username, password string) (usecase.LoginResult, error)
+	Logout(ctx context.Context, token string) error
+	ChangePassword(ctx context.Context, id, current, password string) error
+	ListUsers(ctx context.Context) ([]domain.User, error)
+	GetUser(ctx context.Context, id string) (domain.User, error)
+	CreateUser(ctx context.Context, input usecase.UserInput) (domain.User, error)
+	UpdateUser(ctx context.Context, id string, update usecase.UserUpdate) (domain.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error)
+	CreateAPIKey(ctx context.Context, userID, name string, role domain.Role) (domain.APIKey, string, error)
+	DeleteAPIKey(ctx context.Context, userID, id string) error
+}
+
 type GetTorrentStateUseCase interface {
 	Execute(ctx context.Context, id domain.TorrentID) (domain.SessionState, error)
 }
@@ -51,9 +100,19 @@ type ListTorrentStatesUseCase interface {
 
 type WatchHistoryStore interface {
 	Upsert(ctx context.Context, wp domain.WatchPosition) error
-	Get(ctx context.Context, torrentID domain.TorrentID, fileIndex int) (domain.WatchPosition, error)
-	ListRecent(ctx context.Context, limit int) ([]domain.WatchPosition, error)
-	ListIncomplete(ctx context.Context, limit int) ([]domain.WatchPosition, error)
+	Get(ctx context.Context, profileID string, torrentID domain.TorrentID, fileIndex int) (domain.WatchPosition, error)
+	ListRecent(ctx context.Context, profileID string, limit int) ([]domain.WatchPosition, error)
+	ListIncomplete(ctx context.Context, profileID string, limit int) ([]domain.WatchPosition, error)
+}
+
+type ProfilesUseCase interface {
+	List(ctx context.Context, caller *domain.Principal) ([]domain.Profile, error)
+	Get(ctx context.Context, id string, caller *domain.Principal) (domain.Profile, error)
+	Create(ctx context.Context, name string, caller *domain.Principal) (domain.Profile, error)
+	Rename(ctx context.Context, id, name string, caller *domain.Principal) (domain.Profile, error)
+	Delete(ctx context.Context, id string, caller *domain.Principal) error
+	Resolve(ctx context.Context, requested string, caller *domain.Principal) (domain.Profile, error)
+	SetPlayerState(ctx context.Context, id string, update usecase.PlayerStateUpdate) (domain.Profile, error)
 }
 
 type EncodingSettingsController interface {
@@ -78,6 +137,34 @@ type StorageSettingsController interface {
 	Update(settings app.StorageSettings) error
 }
 
+type RetentionSettingsController interface {
+	Get() domain.RetentionPolicy
+	Update(policy domain.RetentionPolicy) error
+}
+
+type RetentionUseCase interface {
+	Preview(ctx context.Context) (usecase.RetentionReport, error)
+	Apply(ctx context.Context) (usecase.RetentionReport, error)
+}
+
+type WebhooksUseCase interface {
+	List(ctx context.Context) ([]domain.Webhook, error)
+	Get(ctx context.Context, id string) (domain.Webhook, error)
+	Create(ctx context.Context, hook domain.Webhook) (domain.Webhook, error)
+	Update(ctx context.Context, hook domain.Webhook) (domain.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	Deliveries(ctx context.Context, id string, limit int) ([]domain.WebhookDelivery, error)
+}
+
+type PostProcessUseCase interface {
+	List(ctx context.Context) ([]domain.PostProcessCommand, error)
+	Get(ctx context.Context, id string) (domain.PostProcessCommand, error)
+	Create(ctx context.Context, cmd domain.PostProcessCommand) (domain.PostProcessCommand, error)
+	Update(ctx context.Context, cmd domain.PostProcessCommand) (domain.PostProcessCommand, error)
+	Delete(ctx context.Context, id string) error
+	Runs(ctx context.Context, id domain.TorrentID, limit int) ([]domain.PostProcessRun, error)
+}
+
 type MediaProbe interface {
 	Probe(ctx context.Context, filePath string) (domain.MediaInfo, error)
 	ProbeReader(ctx context.Context, reader io.Reader) (domain.MediaInfo, error)
@@ -98,33 +185,52 @@ type mediaProbeCacheKey struct {
 const mediaProbeCacheTTL = 5 * time.Minute
 
 type Server struct {
-	createTorrent   CreateTorrentUseCase
-	startTorrent    StartTorrentUseCase
-	stopTorrent     StopTorrentUseCase
-	deleteTorrent   DeleteTorrentUseCase
-	streamTorrent   StreamTorrentUseCase
-	getState        GetTorrentStateUseCase
-	listStates      ListTorrentStatesUseCase
-	repo            domainports.TorrentRepository
-	openAPIPath     string
-	hls             *StreamJobManager
-	hlsCfg          *HLSConfig
-	mediaProbe      MediaProbe
-	mediaDataDir    string
-	watchHistory    WatchHistoryStore
-	encoding        EncodingSettingsController
-	hlsSettingsCtrl HLSSettingsController
-	player          PlayerSettingsController
-	storage         StorageSettingsController
-	engine          domainports.Engine
-	allowedOrigins  []string
-	logger          *slog.Logger
-	handler         http.Handler
-	wsHub           *wsHub
-	cleanupCancel   context.CancelFunc
-	cleanupDone     chan struct{}
-	mediaCacheMu    sync.RWMutex
-	mediaProbeCache map[mediaProbeCacheKey]mediaProbeCacheEntry
+	createTorrent     CreateTorrentUseCase
+	startTorrent      StartTorrentUseCase
+	stopTorrent       StopTorrentUseCase
+	deleteTorrent     DeleteTorrentUseCase
+	restoreTorrent    RestoreTorrentUseCase
+	webSeeds          WebSeedsUseCase
+	statsHistory      StatsHistoryUseCase
+	transferStats     TransferStatsUseCase
+	integrity         IntegrityUseCase
+	streamTorrent     StreamTorrentUseCase
+	archiveFiles      ArchiveFilesUseCase
+	getState          GetTorrentStateUseCase
+	listStates        ListTorrentStatesUseCase
+	repo              domainports.TorrentRepository
+	openAPIPath       string
+	hls               *StreamJobManager
+	hlsCfg            *HLSConfig
+	mediaProbe        MediaProbe
+	mediaDataDir      string
+	storageRoots      []string
+	watchHistory      WatchHistoryStore
+	encoding          EncodingSettingsController
+	hlsSettingsCtrl   HLSSettingsController
+	player            PlayerSettingsController
+	storage           StorageSettingsController
+	retentionSettings RetentionSettingsController
+	retention         RetentionUseCase
+	webhooks          WebhooksUseCase
+	postProcess       PostProcessUseCase
+	eventLog          EventLogUseCase
+	auth              AuthUseCase
+	logins            *loginThrottle
+	profiles          ProfilesUseCase
+	qbit              *qbitAPI
+	transmission      *transmissionRPC
+	engine            domainports.Engine
+	allowedOrigins    []string
+	trustedProxies    []netip.Prefix
+	logger            *slog.Logger
+	handler           http.Handler
+	wsHub             *wsHub
+	events            domainports.EventPublisher
+	cleanupCancel     context.CancelFunc
+	cleanupDone       chan struct{}
+	mediaCacheMu      sync.RWMutex
+	mediaProbeCache   map[mediaProbeCacheKey]mediaProbeCacheEntry
 }
 
 type ServerOption func(*Server)
@@ -159,12 +265,62 @@ func WithDeleteTorrent(uc DeleteTorrentUseCase) ServerOption {
 	}
 }
 
+func WithRestoreTorrent(uc RestoreTorrentUseCase) ServerOption {
+	return func(s *Server) {
+		s.restoreTorrent = uc
+	}
+}
+
+func WithWebSeeds(uc WebSeedsUseCase) ServerOption {
+	return func(s *Server) {
+		s.webSeeds = uc
+	}
+}
+
+func WithStatsHistory(uc StatsHistoryUseCase) ServerOption {
+	return func(s *Server) {
+		s.statsHistory = uc
+	}
+}
+
+func WithTransferStats(uc TransferStatsUseCase) ServerOption {
+	return func(s *Server) {
+		s.transferStats = uc
+	}
+}
+
+func WithIntegrity(uc IntegrityUseCase) ServerOption {
+	return func(s *Server) {
+		s.integrity = uc
+	}
+}
+
 func WithStreamTorrent(uc StreamTorrentUseCase) ServerOption {
 	return func(s *Server) {
 		s.streamTorrent = uc
 	}
 }
 
+func WithArchiveFiles(uc ArchiveFilesUseCase) ServerOption {
+	return func(s *Server) {
+		s.archiveFiles = uc
+	}
+}
+
+// WithAuth requires every request except login, health, metrics and the API
+// docs to be authenticated with a session or an API key.
+func WithAuth(uc AuthUseCase) ServerOption {
+	return func(s *Server) {
+		s.auth = uc
+	}
+}
+
+func WithProfiles(uc ProfilesUseCase) ServerOption {
+	return func(s *Server) {
+		s.profiles = uc
+	}
+}
+
 func WithHLS(cfg HLSConfig) ServerOption {
 	return func(s *Server) {
 		s.hlsCfg = &cfg
@@ -184,6 +340,13 @@ func WithMediaProbe(probe MediaProbe, dataDir string) ServerOption {
 	}
 }
 
+// WithStorageRoots sets the storage roots clients may ask for as a save path.
+func WithStorageRoots(roots ...string) ServerOption {
+	return func(s *Server) {
+		s.storageRoots = cleanStorageRoots(roots)
+	}
+}
+
 func WithGetTorrentState(uc GetTorrentStateUseCase) ServerOption {
 	return func(s *Server) {
 		s.getState = uc
@@ -220,6 +383,47 @@ func WithStorageSettings(ctrl StorageSettingsController) ServerOption {
 	}
 }
 
+func WithRetentionSettings(ctrl RetentionSettingsController) ServerOption {
+	return func(s *Server) {
+		s.retentionSettings = ctrl
+	}
+}
+
+func WithRetention(uc RetentionUseCase) ServerOption {
+	return func(s *Server) {
+		s.retention = uc
+	}
+}
+
+func WithWebhooks(uc WebhooksUseCase) ServerOption {
+	return func(s *Server) {
+		s.webhooks = uc
+	}
+}
+
+// WithPostProcess serves the post-processing commands and runs. They are
+// only served together with WithAuth, where /settings/* needs an admin,
+// since the commands run programs on the host.
+func WithPostProcess(uc PostProcessUseCase) ServerOption {
+	return func(s *Server) {
+		s.postProcess = uc
+	}
+}
+
+func WithEventLog(uc EventLogUseCase) ServerOption {
+	return func(s *Server) {
+		s.eventLog = uc
+	}
+}
+
+// WithEvents publishes playback_started when a stream starts and
+// tags_changed when tags are replaced.
+func WithEvents(events domainports.EventPublisher) ServerOption {
+	return func(s *Server) {
+		s.events = events
+	}
+}
+
 func WithEngine(engine domainports.Engine) ServerOption {
 	return func(s *Server) {
 		s.engine = engine
@@ -234,6 +438,15 @@ func WithAllowedOrigins(origins []string) ServerOption {
 	}
 }
 
+// WithTrustedProxies lists the reverse proxies whose X-Forwarded-For and
+// X-Real-IP headers are believed. Without any, the client address is the
+// remote address of the connection.
+func WithTrustedProxies(prefixes []netip.Prefix) ServerOption {
+	return func(s *Server) {
+		s.trustedProxies = prefixes
+	}
+}
+
 // EncodingSettingsEngine returns the internal HLS manager as an
 // app.EncodingSettingsEngine. Returns nil if HLS is not configured.
 func (s *Server) EncodingSettingsEngine() app.EncodingSettingsEngine {
@@ -278,7 +491,9 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "websocket not available", http.StatusServiceUnavailable)
 		return
 	}
-	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	upgrader := wsUpgrader
+	upgrader.CheckOrigin = s.checkWSOrigin
+	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		s.logger.Error("ws upgrade failed", slog.String("error", err.Error()))
 		return
@@ -293,6 +508,32 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 	go client.readPump()
 }
 
+// checkWSOrigin refuses WebSocket upgrades started by other sites when
+// authentication is on, since the browser sends the session cookie along.
+// The origin must match the host the request was sent to, directly or
+// through a proxy, or be one of the allowed CORS origins. Requests without
+// an Origin do not come from a browser page and pass.
+func (s *Server) checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if s.auth == nil || origin == "" {
+		return true
+	}
+	for _, allowed := range s.allowedOrigins {
+		if strings.TrimRight(strings.TrimSpace(allowed), "/") == origin {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	forwarded := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Host"), ",")[0])
+	return forwarded != "" && strings.EqualFold(u.Host, forwarded)
+}
+
 // BroadcastStates sends states to all WebSocket clients.
 func (s *Server) BroadcastStates(states []domain.SessionState) {
 	if s.wsHub != nil {
@@ -316,15 +557,17 @@ func (s *Server) BroadcastTorrents() {
 	summaries := make([]torrentSummary, 0, len(records))
 	for _, record := range records {
 		summaries = append(summaries, torrentSummary{
-			ID:         record.ID,
-			Name:       record.Name,
-			Status:     record.Status,
-			Progress:   progressRatio(record.DoneBytes, record.TotalBytes),
-			DoneBytes:  record.DoneBytes,
-			TotalBytes: record.TotalBytes,
-			CreatedAt:  record.CreatedAt,
-			UpdatedAt:  record.UpdatedAt,
-			Tags:       record.Tags,
+			ID:            record.ID,
+			Name:          record.Name,
+			Status:        record.Status,
+			Progress:      progressRatio(record.DoneBytes, record.TotalBytes),
+			DoneBytes:     record.DoneBytes,
+			TotalBytes:    record.TotalBytes,
+			CreatedAt:     record.CreatedAt,
+			UpdatedAt:     record.UpdatedAt,
+			Tags:          record.Tags,
+			StalledSince:  record.StalledSince,
+			StalledReason: record.StalledReason,
 		})
 	}
 	s.wsHub.Broadcast("torrents", summaries)
@@ -342,6 +585,25 @@ func (s *Server) BroadcastPlayerSettings() {
 	})
 }
 
+// NotifyStall pushes a stall event to all connected WebSocket clients.
+func (s *Server) NotifyStall(event domain.StallEvent) {
+	if s.wsHub != nil {
+		s.wsHub.Broadcast("stalled", event)
+	}
+}
+
+// HandleEvent pushes an engine event to all connected WebSocket clients and,
+// when the event changes a torrent's status, the refreshed torrent list.
+func (s *Server) HandleEvent(ctx context.Context, event domain.Event) {
+	if s.wsHub == nil {
+		return
+	}
+	s.wsHub.Broadcast("event", event)
+	if event.Type != domain.EventFileCompleted {
+		s.BroadcastTorrents()
+	}
+}
+
 // BroadcastHealth broadcasts the current player health status to all
 // connected WebSocket clients.
 func (s *Server) BroadcastHealth(ctx context.Context) {
@@ -362,6 +624,7 @@ func NewServer(create CreateTorrentUseCase, opts ...ServerOption) *Server {
 		createTorrent:   create,
 		openAPIPath:     defaultOpenAPIPath(),
 		mediaProbeCache: make(map[mediaProbeCacheKey]mediaProbeCacheEntry),
+		logins:          newLoginThrottle(),
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -370,6 +633,10 @@ func NewServer(create CreateTorrentUseCase, opts ...ServerOption) *Server {
 	if s.logger == nil {
 		s.logger = slog.Default()
 	}
+	if s.postProcess != nil && s.auth == nil {
+		s.logger.Warn("post-processing endpoints disabled: authentication is off")
+		s.postProcess = nil
+	}
 
 	if s.hls == nil && s.streamTorrent != nil {
 		cfg := HLSConfig{}
@@ -378,6 +645,10 @@ func NewServer(create CreateTorrentUseCase, opts ...ServerOption) *Server {
 		}
 		s.hls = newStreamJobManager(s.streamTorrent, s.engine, cfg, s.logger)
 	}
+	if s.hls != nil {
+		s.hls.events = s.events
+		s.hls.torrentDir = s.torrentDataDir
+	}
 	s.startOrphanCleanupLoop()
 
 	s.wsHub = newWSHub(s.logger)
@@ -390,6 +661,16 @@ func NewServer(create CreateTorrentUseCase, opts ...ServerOption) *Server {
 	mux.HandleFunc("/settings/hls", s.handleHLSSettings)
 	mux.HandleFunc("/settings/player", s.handlePlayerSettings)
 	mux.HandleFunc("/settings/storage", s.handleStorageSettings)
+	mux.HandleFunc("/settings/retention", s.handleRetentionSettings)
+	mux.HandleFunc("/settings/webhooks", s.handleWebhooks)
+	mux.HandleFunc("/settings/webhooks/", s.handleWebhookByID)
+	mux.HandleFunc("/settings/post-process", s.handlePostProcessCommands)
+	mux.HandleFunc("/settings/post-process/", s.handlePostProcessCommandByID)
+	mux.HandleFunc("/retention/preview", s.handleRetentionPreview)
+	mux.HandleFunc("/retention/run", s.handleRetentionRun)
+	mux.HandleFunc("/stats", s.handleGlobalStats)
+	mux.HandleFunc("/trash", s.handleTrash)
+	mux.HandleFunc("/trash/", s.handleTrashByID)
 	mux.HandleFunc("/watch-history", s.handleWatchHistory)
 	mux.HandleFunc("/watch-history/", s.handleWatchHistoryByID)
 	mux.HandleFunc("/internal/health/player", s.handlePlayerHealth)
@@ -399,14 +680,28 @@ func NewServer(create CreateTorrentUseCase, opts ...ServerOption) *Server {
 	mux.HandleFunc("/swagger/openapi.json", s.handleOpenAPI)
 	mux.HandleFunc("/swagger/openapi", s.handleOpenAPI)
 	mux.HandleFunc("/ws", s.handleWS)
-
-	traced := otelhttp.NewHandler(loggingMiddleware(s.logger, mux), "torrent-engine",
+	mux.HandleFunc("/events", s.handleEventStream)
+	mux.HandleFunc("/events/log", s.handleEventLog)
+	mux.HandleFunc("/auth/login", s.handleLogin)
+	mux.HandleFunc("/auth/logout", s.handleLogout)
+	mux.HandleFunc("/auth/me", s.handleAuthMe)
+	mux.HandleFunc("/auth/keys", s.handleAPIKeys)
+	mux.HandleFunc("/auth/keys/", s.handleAPIKeyByID)
+	mux.HandleFunc("/users", s.handleUsers)
+	mux.HandleFunc("/users/", s.handleUserByID)
+	mux.HandleFunc("/profiles", s.handleProfiles)
+	mux.HandleFunc("/profiles/current", s.handleCurrentProfile)
+	mux.HandleFunc("/profiles/", s.handleProfileByID)
+	mux.HandleFunc("/api/v2/", s.handleQBittorrent)
+	mux.HandleFunc(transmissionRPCPath, s.handleTransmission)
+
+	traced := otelhttp.NewHandler(loggingMiddleware(s.logger, s.trustedProxies, authMiddleware(s.auth, mux)), "torrent-engine",
 		otelhttp.WithFilter(func(r *http.Request) bool {
 			p := r.URL.Path
 			return p != "/metrics" && p != "/internal/health/player" && !strings.HasPrefix(p, "/swagger")
 		}),
 	)
-	s.handler = recoveryMiddleware(s.logger, rateLimitMiddleware(100, 200, metricsMiddleware(corsMiddleware(s.allowedOrigins, traced))))
+	s.handler = recoveryMiddleware(s.logger, s.trustedProxies, rateLimitMiddleware(100, 200, metricsMiddleware(corsMiddleware(s.allowedOrigins, traced))))
 	return s
 }
 
@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -204,11 +206,22 @@ func (f *fakeRepo) UpdateProgress(ctx context.Context, id domain.TorrentID, upda
 }
 
 func (f *fakeRepo) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return f.lookup(id, false)
+}
+
+func (f *fakeRepo) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return f.lookup(id, true)
+}
+
+func (f *fakeRepo) lookup(id domain.TorrentID, trashed bool) (domain.TorrentRecord, error) {
 	f.getCalled++
 	f.lastID = id
 	if f.getErr != nil {
 		return domain.TorrentRecord{}, f.getErr
 	}
+	if f.get.InTrash() != trashed {
+		return domain.TorrentRecord{}, domain.ErrNotFound
+	}
 	return f.get, nil
 }
 
@@ -1460,6 +1473,40 @@ func TestCreateTorrentEngineError(t *testing.T) {
 	}
 }
 
+func TestCreateTorrentInsufficientSpace(t *testing.T) {
+	uc := &fakeCreateTorrent{err: fmt.Errorf("%w: need 10 bytes, 5 available", usecase.ErrInsufficientSpace)}
+	server := NewServer(uc)
+
+	req := httptest.NewRequest(http.MethodPost, "/torrents", bytes.NewReader([]byte(`{"magnet":"m"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInsufficientStorage {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var resp errorEnvelope
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Error.Code != "insufficient_space" {
+		t.Fatalf("code = %s", resp.Error.Code)
+	}
+}
+
+func TestStartTorrentInsufficientSpace(t *testing.T) {
+	start := &fakeStartTorrent{err: usecase.ErrInsufficientSpace}
+	server := NewServer(&fakeCreateTorrent{}, WithStartTorrent(start))
+
+	req := httptest.NewRequest(http.MethodPost, "/torrents/t1/start", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInsufficientStorage {
+		t.Fatalf("status = %d", w.Code)
+	}
+}
+
 func TestCreateTorrentBadJSON(t *testing.T) {
 	server := NewServer(&fakeCreateTorrent{})
 
@@ -2256,3 +2303,67 @@ func TestFallbackContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestExportMagnetRedactsCredentials(t *testing.T) {
+	repo := &fakeRepo{get: domain.TorrentRecord{
+		ID:       "t1",
+		InfoHash: "abc",
+		Name:     "Show",
+		Private:  true,
+		Source:   domain.TorrentSource{Trackers: []string{"http://t.example/announce?passkey=s3cret"}},
+	}}
+	server := NewServer(&fakeCreateTorrent{}, WithRepository(repo))
+
+	for _, tc := range []struct {
+		query  string
+		secret bool
+	}{{"", false}, {"?credentials=true", true}} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/torrents/t1/magnet"+tc.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d", tc.query, w.Code)
+		}
+		var resp magnetResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if strings.Contains(resp.Magnet, "s3cret") != tc.secret || !strings.HasPrefix(resp.Magnet, "magnet:?xt=urn:btih:abc") {
+			t.Fatalf("%q: magnet = %q", tc.query, resp.Magnet)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/torrents/t1", nil))
+	body := w.Body.String()
+	if strings.Contains(body, "s3cret") || !strings.Contains(body, `"private":true`) {
+		t.Fatalf("record view = %s", body)
+	}
+}
+
+func TestExportMagnetCredentialsNeedUserRole(t *testing.T) {
+	repo := &fakeRepo{get: domain.TorrentRecord{
+		ID:       "t1",
+		InfoHash: "abc",
+		Source:   domain.TorrentSource{Trackers: []string{"http://t.example/announce?passkey=s3cret"}},
+	}}
+	server := NewServer(&fakeCreateTorrent{}, WithRepository(repo), WithAuth(newFakeAuthUseCase()))
+
+	for _, tc := range []struct {
+		session string
+		query   string
+		want    int
+	}{
+		{"session-viewer", "", http.StatusOK},
+		{"session-viewer", "?credentials=true", http.StatusForbidden},
+		{"session-user", "?credentials=true", http.StatusOK},
+		{"session-admin", "?credentials=true", http.StatusOK},
+	} {
+		rec := doAuthRequest(server, http.MethodGet, "/torrents/t1/magnet"+tc.query, tc.session, "")
+		if rec.Code != tc.want {
+			t.Fatalf("%s %q: status = %d, want %d", tc.session, tc.query, rec.Code, tc.want)
+		}
+		if rec.Code == http.StatusForbidden && strings.Contains(rec.Body.String(), "s3cret") {
+			t.Fatalf("%s: forbidden response leaks the passkey", tc.session)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package apihttp
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -32,6 +35,14 @@ func writeUseCaseError(w http.ResponseWriter, err error) {
 		writeError(w, http.StatusBadRequest, "invalid_request", "invalid fileIndex")
 		return
 	}
+	if errors.Is(err, domain.ErrInvalidWebSeed) {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid web seed url")
+		return
+	}
+	if errors.Is(err, usecase.ErrInsufficientSpace) {
+		writeError(w, http.StatusInsufficientStorage, "insufficient_space", err.Error())
+		return
+	}
 	if errors.Is(err, usecase.ErrRepository) {
 		writeError(w, http.StatusInternalServerError, "repository_error", err.Error())
 		return
@@ -62,6 +73,18 @@ func writeDomainError(w http.ResponseWriter, err error) {
 		writeError(w, http.StatusBadRequest, "invalid_request", "invalid fileIndex")
 		return
 	}
+	if errors.Is(err, domain.ErrInvalidWebSeed) {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid web seed url")
+		return
+	}
+	if errors.Is(err, usecase.ErrInvalidStatsRange) {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid stats range")
+		return
+	}
+	if errors.Is(err, usecase.ErrInsufficientSpace) {
+		writeError(w, http.StatusInsufficientStorage, "insufficient_space", err.Error())
+		return
+	}
 	if errors.Is(err, usecase.ErrRepository) {
 		writeError(w, http.StatusInternalServerError, "repository_error", err.Error())
 		return
@@ -115,7 +138,24 @@ func saveUploadedFile(src io.Reader, filename, dataDir string) (string, error) {
 	return out.Name(), nil
 }
 
-func resolveDataFilePath(dataDir, filePath string) (string, error) {
+func cleanStorageRoots(roots []string) []string {
+	out := make([]string, 0, len(roots))
+	for _, root := range roots {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		out = append(out, filepath.Clean(root))
+	}
+	return out
+}
+
+// joinDataFilePath joins filePath onto dataDir, the save path of its
+// torrent, and rejects paths that escape it.
+func joinDataFilePath(dataDir, filePath string) (string, error) {
 	base := strings.TrimSpace(dataDir)
 	if base == "" {
 		return "", errors.New("data dir is required")
@@ -227,6 +267,11 @@ func applyLimitOffset(records []domain.TorrentRecord, limit, offset int) []domai
 	return records
 }
 
+// mergeTags adds extra to tags, dropping duplicates regardless of case.
+func mergeTags(tags, extra []string) []string {
+	return parseCommaSeparated(strings.Join(append(append([]string(nil), tags...), extra...), ","))
+}
+
 func progressRatio(done, total int64) float64 {
 	if total <= 0 {
 		return 0
@@ -393,3 +438,111 @@ func (s *Server) handleSwagger(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = io.WriteString(w, swaggerHTML)
 }
+
+// torrentByHash looks a torrent up by its ID or by its v1 or v2 info hash.
+// Torrents in the trash are not found.
+func (s *Server) torrentByHash(ctx context.Context, hash string) (domain.TorrentRecord, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if hash == "" {
+		return domain.TorrentRecord{}, domain.ErrNotFound
+	}
+	record, err := s.repo.Get(ctx, domain.TorrentID(hash))
+	if err == nil {
+		return record, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return domain.TorrentRecord{}, err
+	}
+	records, err := s.repo.List(ctx, domain.TorrentFilter{InfoHash: domain.InfoHash(hash), Limit: 1})
+	if err != nil {
+		return domain.TorrentRecord{}, err
+	}
+	if len(records) == 0 {
+		return domain.TorrentRecord{}, domain.ErrNotFound
+	}
+	return records[0], nil
+}
+
+// activeStates returns the live state of the torrents with an open
+// session. Failures only cost the live numbers.
+func (s *Server) activeStates(ctx context.Context) map[domain.TorrentID]domain.SessionState {
+	states := make(map[domain.TorrentID]domain.SessionState)
+	if s.listStates == nil {
+		return states
+	}
+	list, err := s.listStates.Execute(ctx)
+	if err != nil {
+		s.logger.Warn("list torrent states failed", slog.String("error", err.Error()))
+		return states
+	}
+	for _, state := range list {
+		states[state.ID] = state
+	}
+	return states
+}
+
+// selectedSize is the size of the files selected for download.
+func selectedSize(record domain.TorrentRecord) int64 {
+	if len(record.Files) == 0 {
+		return record.TotalBytes
+	}
+	var size int64
+	for _, f := range record.Files {
+		if f.Priority != "none" {
+			size += f.Length
+		}
+	}
+	return size
+}
+
+// torrentSavePath is the directory holding the torrent's data.
+func (s *Server) torrentSavePath(record domain.TorrentRecord) string {
+	if record.Source.DataDir != "" {
+		return record.Source.DataDir
+	}
+	return s.mediaDataDir
+}
+
+// torrentDataDir is torrentSavePath for a torrent looked up by ID; unknown
+// torrents use the media data directory.
+func (s *Server) torrentDataDir(ctx context.Context, id domain.TorrentID) string {
+	if s.repo != nil {
+		if record, err := s.repo.Get(ctx, id); err == nil {
+			return s.torrentSavePath(record)
+		}
+	}
+	return s.mediaDataDir
+}
+
+// hashMagnet builds a magnet link from the info hash and name only; the
+// stored source may carry private tracker credentials.
+func hashMagnet(record domain.TorrentRecord) string {
+	hash := string(record.InfoHash)
+	if hash == "" {
+		hash = string(record.ID)
+	}
+	link := "magnet:?xt=urn:btih:" + hash
+	if record.Name != "" {
+		link += "&dn=" + url.QueryEscape(record.Name)
+	}
+	return link
+}
+
+// requestedDataDir accepts a save path asked for by a client only when it is a storage
+// root; other paths are left to the placement policy.
+func (s *Server) requestedDataDir(savePath string) string {
+	savePath = strings.TrimSpace(savePath)
+	if savePath == "" {
+		return ""
+	}
+	clean := filepath.Clean(savePath)
+	for _, root := range s.storageRoots {
+		if filepath.Clean(root) == clean {
+			return root
+		}
+	}
+	if s.mediaDataDir != "" && filepath.Clean(s.mediaDataDir) == clean {
+		return s.mediaDataDir
+	}
+	return ""
+}
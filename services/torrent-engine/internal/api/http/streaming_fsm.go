@@ -329,7 +329,7 @@ func (j *StreamJob) doLoading() error {
 			// Estimate byte offset for the seek target.
 			filePath := dataSourceFilePath(ds)
 			if filePath == "" && j.mgr.dataDir != "" {
-				if p, err := resolveDataFilePath(j.mgr.dataDir, result.File.Path); err == nil {
+				if p, err := j.mgr.dataFilePath(j.key.id, result.File.Path); err == nil {
 					filePath = p
 				}
 			}
@@ -568,7 +568,7 @@ func (j *StreamJob) doPlaying() {
 			if j.streamResult != nil && j.streamResult.File.Length > 0 {
 				filePath := ""
 				if j.mgr.dataDir != "" {
-					if p, err := resolveDataFilePath(j.mgr.dataDir, j.streamResult.File.Path); err == nil {
+					if p, err := j.mgr.dataFilePath(j.key.id, j.streamResult.File.Path); err == nil {
 						filePath = p
 					}
 				}
@@ -596,7 +596,7 @@ func (j *StreamJob) doPlaying() {
 				bytePos := int64(0)
 				filePath := ""
 				if j.mgr.dataDir != "" {
-					if p, err := resolveDataFilePath(j.mgr.dataDir, j.streamResult.File.Path); err == nil {
+					if p, err := j.mgr.dataFilePath(j.key.id, j.streamResult.File.Path); err == nil {
 						filePath = p
 					}
 				}
@@ -655,7 +655,7 @@ func (j *StreamJob) doBuffering() error {
 		bytePos := int64(0)
 		filePath := ""
 		if j.mgr.dataDir != "" {
-			if p, err := resolveDataFilePath(j.mgr.dataDir, j.streamResult.File.Path); err == nil {
+			if p, err := j.mgr.dataFilePath(j.key.id, j.streamResult.File.Path); err == nil {
 				filePath = p
 			}
 		}
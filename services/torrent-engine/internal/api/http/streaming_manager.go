@@ -35,6 +35,8 @@ type StreamJobManager struct {
 	ffprobePath string
 	baseDir     string
 	dataDir     string
+	// torrentDir returns the save path of a torrent; nil means dataDir.
+	torrentDir func(ctx context.Context, id domain.TorrentID) string
 
 	mu              sync.RWMutex
 	preset          string
@@ -64,6 +66,8 @@ type StreamJobManager struct {
 	windowAfter   int64 // priority window ahead of playback
 
 	logger *slog.Logger
+	// events receives playback_started when a job is created; nil disables it.
+	events ports.EventPublisher
 
 	// Health stats.
 	totalJobStarts        uint64
@@ -290,6 +294,7 @@ func (m *StreamJobManager) EnsureJob(id domain.TorrentID, fileIndex, audioTrack,
 	if cachedJob != nil {
 		m.jobs[key] = cachedJob
 		m.mu.Unlock()
+		m.publishPlaybackStarted(key)
 		if cachedIsMultiVariant {
 			m.logger.Info("stream reusing cached multi-variant transcode", slog.String("dir", dir))
 		} else {
@@ -308,9 +313,20 @@ func (m *StreamJobManager) EnsureJob(id domain.TorrentID, fileIndex, audioTrack,
 	m.mu.Unlock()
 
 	m.prepareJobDirAsync(key, job, dir)
+	m.publishPlaybackStarted(key)
 	return job, nil
 }
 
+// publishPlaybackStarted reports a new stream; seeks replace the job of a
+// running stream and are not reported.
+func (m *StreamJobManager) publishPlaybackStarted(key hlsKey) {
+	if m.events == nil {
+		return
+	}
+	fileIndex := key.fileIndex
+	m.events.Publish(domain.Event{Type: domain.EventPlaybackStarted, TorrentID: key.id, FileIndex: &fileIndex})
+}
+
 // SeekJob handles a seek request for the given key.
 // Returns the job, the chosen seek mode, and any error.
 func (m *StreamJobManager) SeekJob(id domain.TorrentID, fileIndex, audioTrack, subtitleTrack int, seekSeconds float64, forceHard bool) (*StreamJob, SeekMode, error) {
@@ -502,6 +518,19 @@ func (m *StreamJobManager) chooseSeekMode(key hlsKey, job *StreamJob, targetSec
 
 // ---- Data source creation ---------------------------------------------------
 
+// dataFilePath resolves a file of torrent id under its save path.
+func (m *StreamJobManager) dataFilePath(id domain.TorrentID, filePath string) (string, error) {
+	dir := m.dataDir
+	if m.torrentDir != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if d := m.torrentDir(ctx, id); d != "" {
+			dir = d
+		}
+	}
+	return joinDataFilePath(dir, filePath)
+}
+
 // newStreamDataSource determines the best data source for a StreamJob.
 // Returns the data source and subtitle path.
 func (m *StreamJobManager) newStreamDataSource(result usecase.StreamResult, job *StreamJob) (MediaDataSource, string) {
@@ -511,7 +540,7 @@ func (m *StreamJobManager) newStreamDataSource(result usecase.StreamResult, job
 	subtitleSourcePath := ""
 
 	if m.dataDir != "" {
-		candidatePath, pathErr := resolveDataFilePath(m.dataDir, result.File.Path)
+		candidatePath, pathErr := m.dataFilePath(job.key.id, result.File.Path)
 		if pathErr == nil {
 			if info, statErr := os.Stat(candidatePath); statErr == nil && !info.IsDir() {
 				subtitleSourcePath = candidatePath
@@ -553,7 +582,7 @@ func (m *StreamJobManager) newStreamDataSource(result usecase.StreamResult, job
 	// read all preceding bytes, which stalls on undownloaded pieces.
 	var source io.ReadCloser = result.Reader
 	if job.seekSeconds > 0 && result.File.Length > 0 {
-		if seeked := m.seekPipeReader(result.Reader, result.File, job.seekSeconds, subtitleSourcePath); seeked != nil {
+		if seeked := m.seekPipeReader(job.key.id, result.Reader, result.File, job.seekSeconds, subtitleSourcePath); seeked != nil {
 			source = seeked
 		}
 	}
@@ -572,9 +601,9 @@ const (
 // the estimated byte offset for the target time. Returns a headerPrefixReader
 // that feeds [header | data-from-offset] to FFmpeg, or nil if seeking is not
 // possible (missing duration, offset too small, seek error).
-func (m *StreamJobManager) seekPipeReader(reader ports.StreamReader, file domain.FileRef, seekSec float64, filePath string) io.ReadCloser {
+func (m *StreamJobManager) seekPipeReader(id domain.TorrentID, reader ports.StreamReader, file domain.FileRef, seekSec float64, filePath string) io.ReadCloser {
 	if filePath == "" && m.dataDir != "" {
-		if p, err := resolveDataFilePath(m.dataDir, file.Path); err == nil {
+		if p, err := m.dataFilePath(id, file.Path); err == nil {
 			filePath = p
 		}
 	}
@@ -1079,7 +1108,7 @@ func (m *StreamJobManager) preSeekPriorityBoost(key hlsKey, seekSeconds float64)
 		return
 	}
 
-	candidatePath, pathErr := resolveDataFilePath(m.dataDir, file.Path)
+	candidatePath, pathErr := m.dataFilePath(key.id, file.Path)
 	if pathErr != nil {
 		return
 	}
@@ -0,0 +1,323 @@
+package apihttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"torrentstream/internal/domain"
+)
+
+// wsHistorySize is the number of delta messages kept for clients that
+// resume after a reconnect.
+const wsHistorySize = 512
+
+// wsTopics maps message types to the topics clients subscribe to.
+var wsTopics = map[string]string{
+	"states":          "states",
+	"torrents":        "torrents",
+	"stalled":         "torrents",
+	"event":           "torrents",
+	"health":          "health",
+	"player_settings": "player",
+}
+
+// wsKeyedTypes are lists diffed per item by their "id" field.
+var wsKeyedTypes = map[string]bool{"states": true, "torrents": true}
+
+// wsSnapshotTypes keep their last value so subscribers get a snapshot; the
+// others are one-off notifications.
+var wsSnapshotTypes = []string{"states", "torrents", "health", "player_settings"}
+
+// wsSubscribeRequest is sent by clients to switch to filtered deltas:
+//
+//	{"action":"subscribe","topics":["states"],"torrents":["<id>"],"since":42}
+//
+// Empty topics or torrents mean all. since resumes after the last sequence
+// number the client saw; 0 requests a snapshot.
+type wsSubscribeRequest struct {
+	Action   string             `json:"action"`
+	Topics   []string           `json:"topics,omitempty"`
+	Torrents []domain.TorrentID `json:"torrents,omitempty"`
+	Since    uint64             `json:"since,omitempty"`
+}
+
+type wsSubscription struct {
+	client *wsClient
+	req    wsSubscribeRequest
+}
+
+// wsFilter selects the messages a subscribed client receives.
+type wsFilter struct {
+	topics   map[string]bool
+	torrents map[string]bool
+}
+
+func newWSFilter(req wsSubscribeRequest) *wsFilter {
+	f := &wsFilter{}
+	if len(req.Topics) > 0 {
+		f.topics = make(map[string]bool, len(req.Topics))
+		for _, t := range req.Topics {
+			f.topics[t] = true
+		}
+	}
+	if len(req.Torrents) > 0 {
+		f.torrents = make(map[string]bool, len(req.Torrents))
+		for _, id := range req.Torrents {
+			f.torrents[string(id)] = true
+		}
+	}
+	return f
+}
+
+func (f *wsFilter) wantsType(msgType string) bool {
+	return len(f.topics) == 0 || f.topics[wsTopics[msgType]]
+}
+
+func (f *wsFilter) wantsTorrent(id string) bool {
+	return len(f.torrents) == 0 || id == "" || f.torrents[id]
+}
+
+// wsDeltaMessage is sent to subscribed clients. Lists carry the changed
+// fields of each item (always with its id) in changed and the ids of items
+// gone from the list in removed; objects carry their changed fields in data
+// and dropped field names in removed. full marks a snapshot that replaces
+// the client's copy.
+type wsDeltaMessage struct {
+	Type    string                       `json:"type"`
+	Seq     uint64                       `json:"seq"`
+	Full    bool                         `json:"full,omitempty"`
+	Data    interface{}                  `json:"data,omitempty"`
+	Changed []map[string]json.RawMessage `json:"changed,omitempty"`
+	Removed []string                     `json:"removed,omitempty"`
+}
+
+// wsUpdate is a typed message decoded once by the broadcaster, so the hub
+// only compares raw field values.
+type wsUpdate struct {
+	msgType string
+	legacy  []byte                     // full message for clients without a subscription
+	raw     json.RawMessage            // data as sent
+	items   []wsItem                   // keyed lists
+	fields  map[string]json.RawMessage // objects
+}
+
+type wsItem struct {
+	id     string
+	raw    json.RawMessage
+	fields map[string]json.RawMessage
+}
+
+func newWSUpdate(msgType string, raw json.RawMessage, legacy []byte) wsUpdate {
+	u := wsUpdate{msgType: msgType, legacy: legacy, raw: raw}
+	if wsKeyedTypes[msgType] {
+		var list []json.RawMessage
+		if json.Unmarshal(raw, &list) == nil {
+			u.items = make([]wsItem, 0, len(list))
+			for _, item := range list {
+				var fields map[string]json.RawMessage
+				if json.Unmarshal(item, &fields) != nil {
+					continue
+				}
+				var id string
+				_ = json.Unmarshal(fields["id"], &id)
+				u.items = append(u.items, wsItem{id: id, raw: item, fields: fields})
+			}
+		}
+		return u
+	}
+	_ = json.Unmarshal(raw, &u.fields)
+	return u
+}
+
+// wsDeltaState holds the last value of every snapshot type and the recent
+// deltas. It is owned by the hub goroutine.
+type wsDeltaState struct {
+	seq     uint64
+	lists   map[string][]wsItem
+	objects map[string]json.RawMessage
+	fields  map[string]map[string]json.RawMessage
+	history []wsDeltaMessage
+}
+
+func newWSDeltaState() *wsDeltaState {
+	return &wsDeltaState{
+		lists:   make(map[string][]wsItem),
+		objects: make(map[string]json.RawMessage),
+		fields:  make(map[string]map[string]json.RawMessage),
+	}
+}
+
+// apply records u and returns the delta for subscribers; ok is false when
+// nothing changed.
+func (s *wsDeltaState) apply(u wsUpdate) (wsDeltaMessage, bool) {
+	msg := wsDeltaMessage{Type: u.msgType}
+	switch {
+	case wsKeyedTypes[u.msgType]:
+		prev := make(map[string]wsItem, len(s.lists[u.msgType]))
+		for _, item := range s.lists[u.msgType] {
+			prev[item.id] = item
+		}
+		for _, item := range u.items {
+			old, ok := prev[item.id]
+			delete(prev, item.id)
+			if changed := diffFields(old.fields, item.fields, ok); changed != nil {
+				msg.Changed = append(msg.Changed, changed)
+			}
+		}
+		for _, item := range s.lists[u.msgType] {
+			if _, gone := prev[item.id]; gone {
+				msg.Removed = append(msg.Removed, item.id)
+			}
+		}
+		s.lists[u.msgType] = u.items
+		if len(msg.Changed) == 0 && len(msg.Removed) == 0 {
+			return msg, false
+		}
+	case u.fields != nil && slices.Contains(wsSnapshotTypes, u.msgType):
+		old := s.fields[u.msgType]
+		changed := diffFields(old, u.fields, old != nil)
+		for name := range old {
+			if _, ok := u.fields[name]; !ok {
+				msg.Removed = append(msg.Removed, name)
+			}
+		}
+		slices.Sort(msg.Removed)
+		s.fields[u.msgType] = u.fields
+		s.objects[u.msgType] = u.raw
+		if changed == nil && len(msg.Removed) == 0 {
+			return msg, false
+		}
+		msg.Data = changed
+	default:
+		// Notifications are sent as they are.
+		msg.Data = u.raw
+	}
+
+	s.seq++
+	msg.Seq = s.seq
+	s.history = append(s.history, msg)
+	if len(s.history) > wsHistorySize {
+		s.history = slices.Delete(s.history, 0, len(s.history)-wsHistorySize)
+	}
+	return msg, true
+}
+
+// diffFields returns the fields of next that differ from prev, with the id.
+// A new item (known=false) is returned whole; nil means no change.
+func diffFields(prev, next map[string]json.RawMessage, known bool) map[string]json.RawMessage {
+	if !known {
+		return next
+	}
+	var changed map[string]json.RawMessage
+	for name, value := range next {
+		if old, ok := prev[name]; ok && bytes.Equal(old, value) {
+			continue
+		}
+		if changed == nil {
+			changed = make(map[string]json.RawMessage)
+		}
+		changed[name] = value
+	}
+	if changed != nil {
+		if id, ok := next["id"]; ok {
+			changed["id"] = id
+		}
+	}
+	return changed
+}
+
+// since returns the deltas after seq, or ok=false when they are no longer
+// all kept (or seq is from before a restart) and a snapshot is needed.
+func (s *wsDeltaState) since(seq uint64) ([]wsDeltaMessage, bool) {
+	if seq > s.seq {
+		return nil, false
+	}
+	if seq == s.seq {
+		return nil, true
+	}
+	if len(s.history) == 0 || s.history[0].Seq > seq+1 {
+		return nil, false
+	}
+	i, _ := slices.BinarySearchFunc(s.history, seq+1, func(m wsDeltaMessage, target uint64) int {
+		switch {
+		case m.Seq < target:
+			return -1
+		case m.Seq > target:
+			return 1
+		}
+		return 0
+	})
+	return s.history[i:], true
+}
+
+// snapshots returns the current value of every snapshot type.
+func (s *wsDeltaState) snapshots() []wsDeltaMessage {
+	var out []wsDeltaMessage
+	for _, msgType := range wsSnapshotTypes {
+		if wsKeyedTypes[msgType] {
+			items, ok := s.lists[msgType]
+			if !ok {
+				continue
+			}
+			list := make([]json.RawMessage, 0, len(items))
+			for _, item := range items {
+				list = append(list, item.raw)
+			}
+			out = append(out, wsDeltaMessage{Type: msgType, Seq: s.seq, Full: true, Data: list})
+			continue
+		}
+		if raw, ok := s.objects[msgType]; ok {
+			out = append(out, wsDeltaMessage{Type: msgType, Seq: s.seq, Full: true, Data: raw})
+		}
+	}
+	return out
+}
+
+// filterFor narrows msg to what f selects; ok is false when nothing is left.
+func (f *wsFilter) filterFor(msg wsDeltaMessage) (wsDeltaMessage, bool) {
+	if !f.wantsType(msg.Type) {
+		return msg, false
+	}
+	if len(f.torrents) == 0 {
+		return msg, true
+	}
+	if wsKeyedTypes[msg.Type] {
+		out := wsDeltaMessage{Type: msg.Type, Seq: msg.Seq, Full: msg.Full}
+		if msg.Full {
+			var list []json.RawMessage
+			for _, raw := range msg.Data.([]json.RawMessage) {
+				var item struct {
+					ID string `json:"id"`
+				}
+				if json.Unmarshal(raw, &item) == nil && f.wantsTorrent(item.ID) {
+					list = append(list, raw)
+				}
+			}
+			out.Data = list
+			return out, true
+		}
+		for _, changed := range msg.Changed {
+			var id string
+			_ = json.Unmarshal(changed["id"], &id)
+			if f.wantsTorrent(id) {
+				out.Changed = append(out.Changed, changed)
+			}
+		}
+		for _, id := range msg.Removed {
+			if f.wantsTorrent(id) {
+				out.Removed = append(out.Removed, id)
+			}
+		}
+		return out, len(out.Changed) > 0 || len(out.Removed) > 0
+	}
+	if raw, ok := msg.Data.(json.RawMessage); ok && !msg.Full {
+		var target struct {
+			TorrentID string `json:"torrentId"`
+		}
+		if json.Unmarshal(raw, &target) == nil && !f.wantsTorrent(target.TorrentID) {
+			return msg, false
+		}
+	}
+	return msg, true
+}
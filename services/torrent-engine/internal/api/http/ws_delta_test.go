@@ -0,0 +1,168 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"torrentstream/internal/domain"
+)
+
+func wsTestUpdate(t *testing.T, msgType string, data interface{}) wsUpdate {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return newWSUpdate(msgType, raw, nil)
+}
+
+func TestWSDeltaStateDiffsListItems(t *testing.T) {
+	s := newWSDeltaState()
+	states := []domain.SessionState{
+		{ID: "a", Status: domain.TorrentActive, Progress: 0.1},
+		{ID: "b", Status: domain.TorrentActive, Progress: 0.5},
+	}
+	first, ok := s.apply(wsTestUpdate(t, "states", states))
+	if !ok || first.Seq != 1 || len(first.Changed) != 2 {
+		t.Fatalf("first delta = %+v", first)
+	}
+
+	if _, ok := s.apply(wsTestUpdate(t, "states", states)); ok {
+		t.Fatalf("unchanged list produced a delta")
+	}
+
+	next, ok := s.apply(wsTestUpdate(t, "states", []domain.SessionState{
+		{ID: "a", Status: domain.TorrentActive, Progress: 0.2},
+	}))
+	if !ok || next.Seq != 2 {
+		t.Fatalf("delta = %+v", next)
+	}
+	if len(next.Changed) != 1 || len(next.Changed[0]) != 2 || string(next.Changed[0]["id"]) != `"a"` || string(next.Changed[0]["progress"]) != "0.2" {
+		t.Fatalf("changed = %v", next.Changed)
+	}
+	if len(next.Removed) != 1 || next.Removed[0] != "b" {
+		t.Fatalf("removed = %v", next.Removed)
+	}
+}
+
+func TestWSDeltaStateObjectsAndResume(t *testing.T) {
+	s := newWSDeltaState()
+	s.apply(wsTestUpdate(t, "health", map[string]interface{}{"status": "ok", "activeSessions": 1}))
+	delta, ok := s.apply(wsTestUpdate(t, "health", map[string]interface{}{"status": "ok", "activeSessions": 2}))
+	changed, _ := delta.Data.(map[string]json.RawMessage)
+	if !ok || len(changed) != 1 || string(changed["activeSessions"]) != "2" {
+		t.Fatalf("health delta = %+v", delta)
+	}
+	s.apply(wsTestUpdate(t, "event", domain.Event{Type: domain.EventCompleted, TorrentID: "a"}))
+
+	missed, ok := s.since(1)
+	if !ok || len(missed) != 2 || missed[0].Seq != 2 || missed[1].Type != "event" {
+		t.Fatalf("since(1) = %+v, %v", missed, ok)
+	}
+	if _, ok := s.since(99); ok {
+		t.Fatalf("sequence from before a restart should need a snapshot")
+	}
+
+	for i := 0; i < wsHistorySize+5; i++ {
+		s.apply(wsTestUpdate(t, "event", domain.Event{Type: domain.EventModeChanged, TorrentID: "a"}))
+	}
+	if _, ok := s.since(1); ok {
+		t.Fatalf("pruned history should need a snapshot")
+	}
+	snaps := s.snapshots()
+	if len(snaps) != 1 || snaps[0].Type != "health" || !snaps[0].Full || snaps[0].Seq != s.seq {
+		t.Fatalf("snapshots = %+v", snaps)
+	}
+}
+
+func TestWSFilterByTopicAndTorrent(t *testing.T) {
+	f := newWSFilter(wsSubscribeRequest{Topics: []string{"states", "torrents"}, Torrents: []domain.TorrentID{"a"}})
+	s := newWSDeltaState()
+	delta, _ := s.apply(wsTestUpdate(t, "states", []domain.SessionState{{ID: "a"}, {ID: "b"}}))
+
+	got, ok := f.filterFor(delta)
+	if !ok || len(got.Changed) != 1 || string(got.Changed[0]["id"]) != `"a"` {
+		t.Fatalf("filtered = %+v", got)
+	}
+	health, _ := s.apply(wsTestUpdate(t, "health", map[string]string{"status": "ok"}))
+	if _, ok := f.filterFor(health); ok {
+		t.Fatalf("health passed a states/torrents subscription")
+	}
+	other, _ := s.apply(wsTestUpdate(t, "event", domain.Event{Type: domain.EventCompleted, TorrentID: "b"}))
+	if _, ok := f.filterFor(other); ok {
+		t.Fatalf("event of another torrent passed the filter")
+	}
+	snap, ok := f.filterFor(s.snapshots()[0])
+	if list, _ := snap.Data.([]json.RawMessage); !ok || len(list) != 1 {
+		t.Fatalf("filtered snapshot = %+v", snap)
+	}
+}
+
+func readWSDelta(t *testing.T, conn *websocket.Conn) wsDeltaMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read ws message: %v", err)
+	}
+	var msg wsDeltaMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("unmarshal: %v (raw: %s)", err, data)
+	}
+	return msg
+}
+
+func TestWSSubscribeReceivesDeltasAndResumes(t *testing.T) {
+	s := makeWSServer()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	defer s.Close()
+
+	legacy := dialWS(t, srv)
+	defer legacy.Close()
+	conn := dialWS(t, srv)
+	time.Sleep(50 * time.Millisecond)
+	s.BroadcastStates([]domain.SessionState{{ID: "a", Progress: 0.1}, {ID: "b", Progress: 0.1}})
+	readWSMessage(t, legacy, 2*time.Second)
+	readWSMessage(t, conn, 2*time.Second)
+
+	if err := conn.WriteJSON(wsSubscribeRequest{Action: "subscribe", Topics: []string{"states"}, Torrents: []domain.TorrentID{"a"}}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	snap := readWSDelta(t, conn)
+	if snap.Type != "states" || !snap.Full || snap.Seq != 1 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+
+	s.BroadcastStates([]domain.SessionState{{ID: "a", Progress: 0.5}, {ID: "b", Progress: 0.9}})
+	delta := readWSDelta(t, conn)
+	if delta.Seq != 2 || len(delta.Changed) != 1 || string(delta.Changed[0]["progress"]) != "0.5" {
+		t.Fatalf("delta = %+v", delta)
+	}
+	if full := readWSMessage(t, legacy, 2*time.Second); full.Type != "states" {
+		t.Fatalf("legacy client got %q", full.Type)
+	}
+	conn.Close()
+
+	// Changes while disconnected are replayed after the last seen sequence.
+	s.BroadcastStates([]domain.SessionState{{ID: "a", Progress: 0.7}})
+	readWSMessage(t, legacy, 2*time.Second)
+	resumed := dialWS(t, srv)
+	defer resumed.Close()
+	if err := resumed.WriteJSON(wsSubscribeRequest{Action: "subscribe", Torrents: []domain.TorrentID{"a"}, Since: delta.Seq}); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	missed := readWSDelta(t, resumed)
+	if missed.Full || missed.Seq != 3 || len(missed.Changed) != 1 || string(missed.Changed[0]["progress"]) != "0.7" {
+		t.Fatalf("replayed = %+v", missed)
+	}
+	s.HandleEvent(context.Background(), domain.Event{Type: domain.EventCompleted, TorrentID: "a"})
+	if ev := readWSDelta(t, resumed); ev.Type != "event" || ev.Seq != 4 {
+		t.Fatalf("event = %+v", ev)
+	}
+}
@@ -3,7 +3,6 @@ package apihttp
 import (
 	"encoding/json"
 	"log/slog"
-	"net/http"
 	"sync/atomic"
 	"time"
 
@@ -18,28 +17,39 @@ type wsMessage struct {
 
 type wsClient struct {
 	hub  *wsHub
-	conn *websocket.Conn
+	conn *websocket.Conn // nil for SSE streams
 	send chan []byte
+	// filter is set once the client subscribes; until then it receives
+	// every message in full. Owned by the hub goroutine.
+	filter *wsFilter
 }
 
 type wsHub struct {
 	clients    map[*wsClient]bool
 	broadcast  chan []byte
+	updates    chan wsUpdate
+	subscribe  chan wsSubscription
+	attach     chan wsSubscription
 	register   chan *wsClient
 	unregister chan *wsClient
 	done       chan struct{}
 	logger     *slog.Logger
 	clientN    atomic.Int64
+	delta      *wsDeltaState
 }
 
 func newWSHub(logger *slog.Logger) *wsHub {
 	return &wsHub{
 		clients:    make(map[*wsClient]bool),
 		broadcast:  make(chan []byte, 64),
+		updates:    make(chan wsUpdate, 64),
+		subscribe:  make(chan wsSubscription),
+		attach:     make(chan wsSubscription),
 		register:   make(chan *wsClient),
 		unregister: make(chan *wsClient),
 		done:       make(chan struct{}),
 		logger:     logger,
+		delta:      newWSDeltaState(),
 	}
 }
 
@@ -48,11 +58,13 @@ func (h *wsHub) run() {
 		select {
 		case <-h.done:
 			for client := range h.clients {
-				_ = client.conn.WriteControl(
-					websocket.CloseMessage,
-					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
-					time.Now().Add(2*time.Second),
-				)
+				if client.conn != nil {
+					_ = client.conn.WriteControl(
+						websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+						time.Now().Add(2*time.Second),
+					)
+				}
 				close(client.send)
 				delete(h.clients, client)
 			}
@@ -72,15 +84,118 @@ func (h *wsHub) run() {
 			}
 		case msg := <-h.broadcast:
 			for client := range h.clients {
-				select {
-				case client.send <- msg:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-					h.clientN.Store(int64(len(h.clients)))
-				}
+				h.sendTo(client, msg)
+			}
+		case u := <-h.updates:
+			h.dispatch(u)
+		case sub := <-h.subscribe:
+			h.subscribeClient(sub)
+		case sub := <-h.attach:
+			// Registered and subscribed at once, so the client never sees
+			// a full message.
+			h.clients[sub.client] = true
+			h.clientN.Store(int64(len(h.clients)))
+			h.subscribeClient(sub)
+		}
+	}
+}
+
+// sendTo queues msg for client, dropping a client that cannot keep up.
+func (h *wsHub) sendTo(client *wsClient, msg []byte) {
+	select {
+	case client.send <- msg:
+	default:
+		close(client.send)
+		delete(h.clients, client)
+		h.clientN.Store(int64(len(h.clients)))
+	}
+}
+
+// dispatch sends u in full to clients without a subscription and its delta
+// to subscribed clients.
+func (h *wsHub) dispatch(u wsUpdate) {
+	delta, changed := h.delta.apply(u)
+	var shared []byte
+	for client := range h.clients {
+		if client.filter == nil {
+			h.sendTo(client, u.legacy)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if len(client.filter.torrents) == 0 {
+			if !client.filter.wantsType(delta.Type) {
+				continue
+			}
+			if shared == nil {
+				shared = h.marshal(delta)
+			}
+			if shared != nil {
+				h.sendTo(client, shared)
 			}
+			continue
 		}
+		h.sendDelta(client, delta)
+	}
+}
+
+// subscribeClient applies a subscription and brings the client up to date:
+// the deltas it missed when it resumes, a snapshot otherwise.
+func (h *wsHub) subscribeClient(sub wsSubscription) {
+	client := sub.client
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	client.filter = newWSFilter(sub.req)
+	if sub.req.Since > 0 {
+		if missed, ok := h.delta.since(sub.req.Since); ok {
+			for _, msg := range missed {
+				h.sendDelta(client, msg)
+			}
+			return
+		}
+	}
+	for _, msg := range h.delta.snapshots() {
+		h.sendDelta(client, msg)
+	}
+}
+
+func (h *wsHub) sendDelta(client *wsClient, msg wsDeltaMessage) {
+	msg, ok := client.filter.filterFor(msg)
+	if !ok {
+		return
+	}
+	if payload := h.marshal(msg); payload != nil {
+		h.sendTo(client, payload)
+	}
+}
+
+func (h *wsHub) marshal(msg wsDeltaMessage) []byte {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Error("ws marshal failed", slog.String("error", err.Error()))
+		return nil
+	}
+	return redactPayload(payload)
+}
+
+// attachClient registers a client that is subscribed from the start. It
+// returns false once the hub is closed.
+func (h *wsHub) attachClient(client *wsClient, req wsSubscribeRequest) bool {
+	select {
+	case h.attach <- wsSubscription{client: client, req: req}:
+		return true
+	case <-h.done:
+		return false
+	}
+}
+
+// detachClient unregisters a client unless the hub is already closed.
+func (h *wsHub) detachClient(client *wsClient) {
+	select {
+	case h.unregister <- client:
+	case <-h.done:
 	}
 }
 
@@ -95,43 +210,42 @@ func (h *wsHub) clientCount() int {
 
 // BroadcastStates sends the full state list to all connected clients.
 func (h *wsHub) BroadcastStates(states []domain.SessionState) {
+	h.Broadcast("states", states)
+}
+
+// Broadcast sends a typed JSON message to all connected WebSocket clients,
+// as a delta to subscribed ones.
+func (h *wsHub) Broadcast(msgType string, data interface{}) {
 	if h.clientN.Load() == 0 {
 		return
 	}
-	msg := wsMessage{Type: "states", Data: states}
-	data, err := json.Marshal(msg)
+	raw, err := json.Marshal(data)
 	if err != nil {
 		h.logger.Error("ws marshal failed", slog.String("error", err.Error()))
 		return
 	}
-	select {
-	case h.broadcast <- data:
-	default:
-		// Broadcast channel full, skip this update.
-	}
-}
-
-// Broadcast sends a typed JSON message to all connected WebSocket clients.
-func (h *wsHub) Broadcast(msgType string, data interface{}) {
-	if h.clientN.Load() == 0 {
-		return
-	}
-	msg := wsMessage{Type: msgType, Data: data}
-	payload, err := json.Marshal(msg)
+	payload, err := json.Marshal(wsMessage{Type: msgType, Data: json.RawMessage(raw)})
 	if err != nil {
 		h.logger.Error("ws marshal failed", slog.String("error", err.Error()))
 		return
 	}
 	select {
-	case h.broadcast <- payload:
+	case h.updates <- newWSUpdate(msgType, raw, redactPayload(payload)):
 	default:
+		// Update channel full, skip this update.
 	}
 }
 
+// redactPayload strips tracker credentials from URLs in an outgoing
+// message, since every connected client receives it.
+func redactPayload(data []byte) []byte {
+	return []byte(domain.RedactText(string(data)))
+}
+
+// wsUpgrader is copied per server, which sets CheckOrigin.
 var wsUpgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin:     func(r *http.Request) bool { return true },
 }
 
 func (c *wsClient) writePump() {
@@ -165,16 +279,25 @@ func (c *wsClient) readPump() {
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
-	c.conn.SetReadLimit(512)
+	c.conn.SetReadLimit(64 << 10)
 	_ = c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	c.conn.SetPongHandler(func(string) error {
 		_ = c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
 			break
 		}
+		var req wsSubscribeRequest
+		if json.Unmarshal(data, &req) != nil || req.Action != "subscribe" {
+			continue
+		}
+		select {
+		case c.hub.subscribe <- wsSubscription{client: c, req: req}:
+		case <-c.hub.done:
+			return
+		}
 	}
 }
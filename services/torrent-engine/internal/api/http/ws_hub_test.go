@@ -413,6 +413,38 @@ func TestHandleWS_UpgradeSucceeds(t *testing.T) {
 	}
 }
 
+func TestHandleWS_ChecksOriginWithAuth(t *testing.T) {
+	s := NewServer(nil, WithAuth(newFakeAuthUseCase()), WithAllowedOrigins([]string{"https://app.example"}))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	dial := func(origin string) (*websocket.Conn, *http.Response, error) {
+		header := http.Header{}
+		header.Set("Cookie", sessionCookie+"=session-viewer")
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		return websocket.DefaultDialer.Dial(url, header)
+	}
+
+	for _, origin := range []string{"", srv.URL, "https://app.example"} {
+		conn, _, err := dial(origin)
+		if err != nil {
+			t.Fatalf("origin %q: dial: %v", origin, err)
+		}
+		conn.Close()
+	}
+
+	_, resp, err := dial("https://evil.example")
+	if err == nil {
+		t.Fatal("cross-site origin: expected the upgrade to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("cross-site origin: response = %+v, want 403", resp)
+	}
+}
+
 func TestHandleWS_MultipleConcurrentClients(t *testing.T) {
 	s := makeWSServer()
 	srv := httptest.NewServer(s)
@@ -852,7 +884,15 @@ func (f *fakeWSRepo) UpdateProgress(_ context.Context, _ domain.TorrentID, _ dom
 }
 func (f *fakeWSRepo) Get(_ context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
 	for _, r := range f.records {
-		if r.ID == id {
+		if r.ID == id && !r.InTrash() {
+			return r, nil
+		}
+	}
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
+func (f *fakeWSRepo) GetTrashed(_ context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	for _, r := range f.records {
+		if r.ID == id && r.InTrash() {
 			return r, nil
 		}
 	}
@@ -895,3 +935,38 @@ func (f *fakeWSPlayerCtrl) SetPrioritizeActiveFileOnly(enabled bool) error {
 	f.prioritizeActiveFileOnly = enabled
 	return nil
 }
+
+func TestRedactPayload(t *testing.T) {
+	payload, _ := json.Marshal(wsMessage{Type: "health", Data: map[string]string{
+		"lastError": "announce http://t.example/announce?passkey=s3cret&x=1 failed",
+	}})
+	got := string(redactPayload(payload))
+	if strings.Contains(got, "s3cret") {
+		t.Fatalf("passkey leaked: %s", got)
+	}
+	var msg wsMessage
+	if err := json.Unmarshal([]byte(got), &msg); err != nil {
+		t.Fatalf("redacted payload is not valid JSON: %v", err)
+	}
+}
+
+func TestServerHandleEventBroadcasts(t *testing.T) {
+	s := makeWSServer()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	defer s.Close()
+
+	conn := dialWS(t, srv)
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	s.HandleEvent(context.Background(), domain.Event{Type: domain.EventCompleted, TorrentID: "t1"})
+	msg := readWSMessage(t, conn, 2*time.Second)
+	if msg.Type != "event" {
+		t.Fatalf("ws type=%q, want event", msg.Type)
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if !ok || data["type"] != "completed" || data["torrentId"] != "t1" {
+		t.Fatalf("ws data = %#v", msg.Data)
+	}
+}
@@ -1,9 +1,13 @@
 package app
 
 import (
+	"net/netip"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+
+	"torrentstream/internal/domain"
 )
 
 type Config struct {
@@ -29,9 +33,75 @@ type Config struct {
 	HLSWindowBeforeMB  int
 	HLSWindowAfterMB   int
 	CORSAllowedOrigins []string // empty = allow all (dev mode)
+	// Reverse proxies whose X-Forwarded-For is believed; empty = none.
+	TrustedProxies []netip.Prefix
+
+	// Initial retention policy; overridden by settings stored in Mongo.
+	RetentionWatchedDays   int
+	RetentionUntouchedDays int
+	RetentionMaxBytes      int64
+	RetentionKeepTags      []string
+	RetentionDryRun        bool
+
+	// Hours a deleted torrent stays in the trash; 0 deletes immediately.
+	TrashRetentionHours int
+
+	// Days per-torrent statistics samples are kept in Mongo; 0 keeps the
+	// history in memory only.
+	StatsHistoryDays int
+
+	// Minutes without progress before a torrent is flagged as stalled; 0
+	// disables detection.
+	StallTimeoutMinutes int
+	StallAction         domain.StallAction
+	StallRetryTrackers  []string
+
+	// Bad pieces after which a peer is banned; 0 leaves banning to the
+	// torrent client.
+	BanBadPeersAfter int
+
+	// Post-processing commands are configured over the API; they only run
+	// when enabled here.
+	PostProcessEnabled        bool
+	PostProcessMaxConcurrent  int
+	PostProcessTimeoutSeconds int
+
+	// UnpackEnabled extracts RAR, ZIP and 7z archives of completed torrents.
+	UnpackEnabled bool
+
+	// AuthEnabled requires a login or an API key for the API. The first
+	// admin is created from AuthAdminUser and AuthAdminPassword when there
+	// are no users; an empty password is generated and logged once.
+	AuthEnabled       bool
+	AuthAdminUser     string
+	AuthAdminPassword string
+	AuthSessionHours  int
+
+	// QBittorrentAPIEnabled serves the qBittorrent Web API under /api/v2
+	// for Sonarr, Radarr and browser extensions.
+	QBittorrentAPIEnabled bool
+	// TransmissionRPCEnabled serves the Transmission RPC at
+	// /transmission/rpc for Transmission remotes and scripts.
+	TransmissionRPCEnabled bool
+
+	// Storage roots new torrents are spread over; TorrentDataDir is always
+	// the first one.
+	StorageRoots      []domain.StorageRoot
+	StoragePlacement  domain.PlacementPolicy
+	StorageCategories map[string]string
 }
 
 func LoadConfig() Config {
+	dataDir := getEnv("TORRENT_DATA_DIR", "data")
+	stallAction := domain.StallAction(strings.ToLower(getEnv("TORRENT_STALL_ACTION", string(domain.StallActionNone))))
+	if !stallAction.Valid() {
+		stallAction = domain.StallActionNone
+	}
+	placement := domain.PlacementPolicy(strings.ToLower(getEnv("TORRENT_STORAGE_PLACEMENT", string(domain.PlacementMostFree))))
+	if !placement.Valid() {
+		placement = domain.PlacementMostFree
+	}
+
 	return Config{
 		HTTPAddr:          getEnv("HTTP_ADDR", ":8080"),
 		MongoURI:          getEnv("MONGO_URI", "mongodb://localhost:27017"),
@@ -39,7 +109,7 @@ func LoadConfig() Config {
 		MongoCollection:   getEnv("MONGO_COLLECTION", "torrents"),
 		LogLevel:          strings.ToLower(getEnv("LOG_LEVEL", "info")),
 		LogFormat:         strings.ToLower(getEnv("LOG_FORMAT", "text")),
-		TorrentDataDir:    getEnv("TORRENT_DATA_DIR", "data"),
+		TorrentDataDir:    dataDir,
 		OpenAPIPath:       getEnv("OPENAPI_PATH", ""),
 		MaxSessions:        int(getEnvInt64("TORRENT_MAX_SESSIONS", 0)),
 		MinDiskSpaceBytes:  getEnvInt64("TORRENT_MIN_DISK_SPACE_BYTES", 0),
@@ -55,7 +125,113 @@ func LoadConfig() Config {
 		HLSWindowBeforeMB:  int(getEnvInt64("HLS_WINDOW_BEFORE_MB", 8)),
 		HLSWindowAfterMB:   int(getEnvInt64("HLS_WINDOW_AFTER_MB", 32)),
 		CORSAllowedOrigins: parseCSV(getEnv("CORS_ALLOWED_ORIGINS", "")),
+		TrustedProxies:     parseTrustedProxies(getEnv("TRUSTED_PROXIES", "")),
+
+		RetentionWatchedDays:   int(getEnvInt64("TORRENT_RETENTION_WATCHED_DAYS", 0)),
+		RetentionUntouchedDays: int(getEnvInt64("TORRENT_RETENTION_UNTOUCHED_DAYS", 0)),
+		RetentionMaxBytes:      getEnvInt64("TORRENT_RETENTION_MAX_BYTES", 0),
+		RetentionKeepTags:      parseCSV(getEnv("TORRENT_RETENTION_KEEP_TAGS", "pinned")),
+		RetentionDryRun:        getEnvBool("TORRENT_RETENTION_DRY_RUN", false),
+
+		TrashRetentionHours: int(getEnvInt64("TORRENT_TRASH_RETENTION_HOURS", 72)),
+
+		StatsHistoryDays: int(getEnvInt64("TORRENT_STATS_HISTORY_DAYS", 30)),
+
+		StallTimeoutMinutes: int(getEnvInt64("TORRENT_STALL_TIMEOUT_MINUTES", 30)),
+		StallAction:         stallAction,
+		StallRetryTrackers:  parseCSV(getEnv("TORRENT_STALL_RETRY_TRACKERS", "")),
+
+		BanBadPeersAfter: int(getEnvInt64("TORRENT_BAN_BAD_PEERS_AFTER", 0)),
+
+		PostProcessEnabled:        getEnvBool("TORRENT_POST_PROCESS_ENABLED", false),
+		PostProcessMaxConcurrent:  int(getEnvInt64("TORRENT_POST_PROCESS_MAX_CONCURRENT", 2)),
+		PostProcessTimeoutSeconds: int(getEnvInt64("TORRENT_POST_PROCESS_TIMEOUT_SECONDS", 600)),
+
+		UnpackEnabled: getEnvBool("TORRENT_UNPACK_ENABLED", true),
+
+		AuthEnabled:       getEnvBool("TORRENT_AUTH_ENABLED", false),
+		AuthAdminUser:     getEnv("TORRENT_AUTH_ADMIN_USER", "admin"),
+		AuthAdminPassword: getEnv("TORRENT_AUTH_ADMIN_PASSWORD", ""),
+		AuthSessionHours:  int(getEnvInt64("TORRENT_AUTH_SESSION_HOURS", 720)),
+
+		QBittorrentAPIEnabled:  getEnvBool("TORRENT_QBITTORRENT_API_ENABLED", true),
+		TransmissionRPCEnabled: getEnvBool("TORRENT_TRANSMISSION_RPC_ENABLED", true),
+
+		StorageRoots:      parseStorageRoots(dataDir, getEnv("TORRENT_STORAGE_ROOTS", "")),
+		StoragePlacement:  placement,
+		StorageCategories: parseCategoryRoots(getEnv("TORRENT_STORAGE_CATEGORIES", "")),
+	}
+}
+
+// parseStorageRoots parses comma-separated "path[|quotaBytes[|minFreeBytes]]"
+// entries. dataDir is always the first root; an entry for it only sets its
+// limits.
+func parseStorageRoots(dataDir, spec string) []domain.StorageRoot {
+	roots := []domain.StorageRoot{{Path: dataDir}}
+	for _, entry := range parseCSV(spec) {
+		parts := strings.Split(entry, "|")
+		root := domain.StorageRoot{Path: strings.TrimSpace(parts[0])}
+		if root.Path == "" {
+			continue
+		}
+		if len(parts) > 1 {
+			root.QuotaBytes = parseNonNegative(parts[1])
+		}
+		if len(parts) > 2 {
+			root.MinFreeBytes = parseNonNegative(parts[2])
+		}
+
+		duplicate := false
+		for i := range roots {
+			if filepath.Clean(roots[i].Path) == filepath.Clean(root.Path) {
+				roots[i].QuotaBytes = root.QuotaBytes
+				roots[i].MinFreeBytes = root.MinFreeBytes
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// parseTrustedProxies parses comma-separated CIDRs or single addresses;
+// invalid entries are skipped.
+func parseTrustedProxies(spec string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, entry := range parseCSV(spec) {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			out = append(out, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			addr = addr.Unmap()
+			out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return out
+}
+
+// parseCategoryRoots parses comma-separated "category=path" entries.
+func parseCategoryRoots(spec string) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range parseCSV(spec) {
+		name, path, ok := strings.Cut(entry, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !ok || name == "" || path == "" {
+			continue
+		}
+		out[name] = path
 	}
+	return out
+}
+
+func parseNonNegative(s string) int64 {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
 }
 
 func parseCSV(s string) []string {
@@ -93,3 +269,15 @@ func getEnvInt64(key string, fallback int64) int64 {
 	}
 	return parsed
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
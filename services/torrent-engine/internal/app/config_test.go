@@ -1,8 +1,11 @@
 package app
 
 import (
+	"net/netip"
 	"os"
 	"testing"
+
+	"torrentstream/internal/domain"
 )
 
 func setEnvs(t *testing.T, envs map[string]string) {
@@ -23,6 +26,15 @@ func TestLoadConfigDefaults(t *testing.T) {
 		"HLS_SEGMENT_DURATION", "HLS_RAMBUF_SIZE_MB", "HLS_PREBUFFER_MB",
 		"HLS_WINDOW_BEFORE_MB", "HLS_WINDOW_AFTER_MB",
 		"CORS_ALLOWED_ORIGINS",
+		"TORRENT_RETENTION_WATCHED_DAYS", "TORRENT_RETENTION_UNTOUCHED_DAYS",
+		"TORRENT_RETENTION_MAX_BYTES", "TORRENT_RETENTION_KEEP_TAGS",
+		"TORRENT_RETENTION_DRY_RUN", "TORRENT_TRASH_RETENTION_HOURS",
+		"TORRENT_STORAGE_ROOTS", "TORRENT_STORAGE_PLACEMENT", "TORRENT_STORAGE_CATEGORIES",
+		"TORRENT_POST_PROCESS_ENABLED", "TORRENT_POST_PROCESS_MAX_CONCURRENT", "TORRENT_POST_PROCESS_TIMEOUT_SECONDS",
+		"TORRENT_UNPACK_ENABLED",
+		"TORRENT_AUTH_ENABLED", "TORRENT_AUTH_ADMIN_USER", "TORRENT_AUTH_ADMIN_PASSWORD", "TORRENT_AUTH_SESSION_HOURS",
+		"TORRENT_QBITTORRENT_API_ENABLED",
+		"TORRENT_TRANSMISSION_RPC_ENABLED",
 	}
 	for _, k := range envVars {
 		t.Setenv(k, "")
@@ -57,6 +69,26 @@ func TestLoadConfigDefaults(t *testing.T) {
 		{"HLSPrebufferMB", cfg.HLSPrebufferMB, 4},
 		{"HLSWindowBeforeMB", cfg.HLSWindowBeforeMB, 8},
 		{"HLSWindowAfterMB", cfg.HLSWindowAfterMB, 32},
+		{"RetentionWatchedDays", cfg.RetentionWatchedDays, 0},
+		{"RetentionUntouchedDays", cfg.RetentionUntouchedDays, 0},
+		{"RetentionMaxBytes", cfg.RetentionMaxBytes, int64(0)},
+		{"RetentionDryRun", cfg.RetentionDryRun, false},
+		{"TrashRetentionHours", cfg.TrashRetentionHours, 72},
+		{"StatsHistoryDays", cfg.StatsHistoryDays, 30},
+		{"StallTimeoutMinutes", cfg.StallTimeoutMinutes, 30},
+		{"StallAction", cfg.StallAction, domain.StallActionNone},
+		{"BanBadPeersAfter", cfg.BanBadPeersAfter, 0},
+		{"PostProcessEnabled", cfg.PostProcessEnabled, false},
+		{"PostProcessMaxConcurrent", cfg.PostProcessMaxConcurrent, 2},
+		{"PostProcessTimeoutSeconds", cfg.PostProcessTimeoutSeconds, 600},
+		{"UnpackEnabled", cfg.UnpackEnabled, true},
+		{"AuthEnabled", cfg.AuthEnabled, false},
+		{"AuthAdminUser", cfg.AuthAdminUser, "admin"},
+		{"AuthAdminPassword", cfg.AuthAdminPassword, ""},
+		{"AuthSessionHours", cfg.AuthSessionHours, 720},
+		{"QBittorrentAPIEnabled", cfg.QBittorrentAPIEnabled, true},
+		{"TransmissionRPCEnabled", cfg.TransmissionRPCEnabled, true},
+		{"StoragePlacement", cfg.StoragePlacement, domain.PlacementMostFree},
 	}
 
 	for _, tt := range tests {
@@ -70,6 +102,12 @@ func TestLoadConfigDefaults(t *testing.T) {
 	if len(cfg.CORSAllowedOrigins) != 0 {
 		t.Errorf("CORSAllowedOrigins: got %v, want nil/empty", cfg.CORSAllowedOrigins)
 	}
+	if len(cfg.RetentionKeepTags) != 1 || cfg.RetentionKeepTags[0] != "pinned" {
+		t.Errorf("RetentionKeepTags: got %v, want [pinned]", cfg.RetentionKeepTags)
+	}
+	if len(cfg.StorageRoots) != 1 || cfg.StorageRoots[0].Path != "data" {
+		t.Errorf("StorageRoots: got %v, want [data]", cfg.StorageRoots)
+	}
 }
 
 func TestLoadConfigFromEnv(t *testing.T) {
@@ -174,6 +212,30 @@ func TestGetEnvInt64InvalidFallsBack(t *testing.T) {
 	}
 }
 
+func TestGetEnvBool(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVal   string
+		fallback bool
+		want     bool
+	}{
+		{"empty string", "", true, true},
+		{"true", "true", false, true},
+		{"one", "1", false, true},
+		{"false", "false", true, false},
+		{"invalid", "maybe", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_BOOL_VAR", tt.envVal)
+			if got := getEnvBool("TEST_BOOL_VAR", tt.fallback); got != tt.want {
+				t.Errorf("getEnvBool(%q, %v) = %v, want %v", tt.envVal, tt.fallback, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseCSV(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -210,6 +272,78 @@ func TestParseCSV(t *testing.T) {
 	}
 }
 
+func TestParseStorageRoots(t *testing.T) {
+	roots := parseStorageRoots("/data", "/mnt/a|1000|10, /data/|0|50,/mnt/b,|1")
+	want := []domain.StorageRoot{
+		{Path: "/data", MinFreeBytes: 50},
+		{Path: "/mnt/a", QuotaBytes: 1000, MinFreeBytes: 10},
+		{Path: "/mnt/b"},
+	}
+	if len(roots) != len(want) {
+		t.Fatalf("roots = %+v, want %+v", roots, want)
+	}
+	for i := range want {
+		if roots[i] != want[i] {
+			t.Errorf("roots[%d] = %+v, want %+v", i, roots[i], want[i])
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	got := parseTrustedProxies("10.1.2.3/8, 192.168.1.5, ::1, bogus, 300.0.0.1/8")
+	want := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.5/32"),
+		netip.MustParsePrefix("::1/128"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("proxies = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("proxies[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadConfigStoragePlacement(t *testing.T) {
+	setEnvs(t, map[string]string{
+		"TORRENT_STORAGE_PLACEMENT":  "Round-Robin",
+		"TORRENT_STORAGE_CATEGORIES": "movies=/mnt/a, series = /mnt/b,broken",
+	})
+	cfg := LoadConfig()
+	if cfg.StoragePlacement != domain.PlacementRoundRobin {
+		t.Errorf("StoragePlacement = %q", cfg.StoragePlacement)
+	}
+	if len(cfg.StorageCategories) != 2 || cfg.StorageCategories["series"] != "/mnt/b" {
+		t.Errorf("StorageCategories = %v", cfg.StorageCategories)
+	}
+
+	t.Setenv("TORRENT_STORAGE_PLACEMENT", "random")
+	if cfg := LoadConfig(); cfg.StoragePlacement != domain.PlacementMostFree {
+		t.Errorf("invalid placement should fall back to most-free, got %q", cfg.StoragePlacement)
+	}
+}
+
+func TestLoadConfigStallDetection(t *testing.T) {
+	setEnvs(t, map[string]string{
+		"TORRENT_STALL_ACTION":         "Retry",
+		"TORRENT_STALL_RETRY_TRACKERS": "udp://a.example:1337/announce, http://b.example/announce",
+	})
+	cfg := LoadConfig()
+	if cfg.StallAction != domain.StallActionRetry {
+		t.Errorf("StallAction = %q", cfg.StallAction)
+	}
+	if len(cfg.StallRetryTrackers) != 2 || cfg.StallRetryTrackers[1] != "http://b.example/announce" {
+		t.Errorf("StallRetryTrackers = %v", cfg.StallRetryTrackers)
+	}
+
+	t.Setenv("TORRENT_STALL_ACTION", "delete")
+	if cfg := LoadConfig(); cfg.StallAction != domain.StallActionNone {
+		t.Errorf("invalid action should fall back to none, got %q", cfg.StallAction)
+	}
+}
+
 func TestGetEnvFallback(t *testing.T) {
 	t.Setenv("TEST_EXISTING", "hello")
 
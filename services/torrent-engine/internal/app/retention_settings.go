@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type RetentionSettingsStore interface {
+	GetRetentionPolicy(ctx context.Context) (domain.RetentionPolicy, bool, error)
+	SetRetentionPolicy(ctx context.Context, policy domain.RetentionPolicy) error
+}
+
+type RetentionSettingsManager struct {
+	mu      sync.RWMutex
+	policy  domain.RetentionPolicy
+	store   RetentionSettingsStore
+	timeout time.Duration
+}
+
+func NewRetentionSettingsManager(initial domain.RetentionPolicy, store RetentionSettingsStore) *RetentionSettingsManager {
+	return &RetentionSettingsManager{
+		policy:  clonePolicy(initial),
+		store:   store,
+		timeout: 5 * time.Second,
+	}
+}
+
+func (m *RetentionSettingsManager) Get() domain.RetentionPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return clonePolicy(m.policy)
+}
+
+func (m *RetentionSettingsManager) Update(next domain.RetentionPolicy) error {
+	next = clonePolicy(next)
+
+	m.mu.Lock()
+	prev := m.policy
+	m.policy = next
+	m.mu.Unlock()
+
+	if m.store == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	if err := m.store.SetRetentionPolicy(ctx, next); err != nil {
+		m.mu.Lock()
+		m.policy = prev
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func clonePolicy(p domain.RetentionPolicy) domain.RetentionPolicy {
+	if p.KeepTags != nil {
+		p.KeepTags = append([]string(nil), p.KeepTags...)
+	}
+	return p
+}
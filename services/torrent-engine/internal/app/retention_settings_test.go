@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeRetentionStore struct {
+	policy   domain.RetentionPolicy
+	setErr   error
+	setCalls int
+}
+
+func (f *fakeRetentionStore) GetRetentionPolicy(_ context.Context) (domain.RetentionPolicy, bool, error) {
+	return f.policy, true, nil
+}
+
+func (f *fakeRetentionStore) SetRetentionPolicy(_ context.Context, p domain.RetentionPolicy) error {
+	f.setCalls++
+	if f.setErr != nil {
+		return f.setErr
+	}
+	f.policy = p
+	return nil
+}
+
+func TestRetentionSettingsManager_UpdatePersists(t *testing.T) {
+	store := &fakeRetentionStore{}
+	mgr := NewRetentionSettingsManager(domain.RetentionPolicy{KeepTags: []string{"pinned"}}, store)
+
+	next := domain.RetentionPolicy{WatchedDays: 14, MaxTotalBytes: 1 << 30, KeepTags: []string{"keep"}}
+	if err := mgr.Update(next); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if store.setCalls != 1 || store.policy.WatchedDays != 14 {
+		t.Fatalf("store = %+v (calls %d)", store.policy, store.setCalls)
+	}
+	got := mgr.Get()
+	if got.WatchedDays != 14 || got.MaxTotalBytes != 1<<30 || len(got.KeepTags) != 1 || got.KeepTags[0] != "keep" {
+		t.Fatalf("Get = %+v", got)
+	}
+
+	got.KeepTags[0] = "mutated"
+	if mgr.Get().KeepTags[0] != "keep" {
+		t.Fatalf("Get must return a copy of KeepTags")
+	}
+}
+
+func TestRetentionSettingsManager_UpdateRollsBackOnStoreError(t *testing.T) {
+	store := &fakeRetentionStore{setErr: errors.New("mongo down")}
+	mgr := NewRetentionSettingsManager(domain.RetentionPolicy{UntouchedDays: 30}, store)
+
+	if err := mgr.Update(domain.RetentionPolicy{UntouchedDays: 1}); err == nil {
+		t.Fatalf("expected error")
+	}
+	if got := mgr.Get(); got.UntouchedDays != 30 {
+		t.Fatalf("expected rollback to 30, got %d", got.UntouchedDays)
+	}
+}
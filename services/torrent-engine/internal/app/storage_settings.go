@@ -20,7 +20,26 @@ type StorageUsage struct {
 	DataDirLogicalBytes          int64     `json:"dataDirLogicalBytes"`
 	DataDirAllocatedBytes        int64     `json:"dataDirAllocatedBytes"`
 	TorrentClientDownloadedBytes int64     `json:"torrentClientDownloadedBytes"`
+	FreeBytes                    int64     `json:"freeBytes"`
+	ReservedBytes                int64     `json:"reservedBytes"`
+	AvailableBytes               int64     `json:"availableBytes"`
 	ScannedAt                    time.Time `json:"scannedAt"`
+	// Roots is reported when torrents are spread over several storage roots.
+	Roots []StorageRootUsage `json:"roots,omitempty"`
+}
+
+// StorageRootUsage reports space on one storage root.
+type StorageRootUsage struct {
+	Path           string `json:"path"`
+	QuotaBytes     int64  `json:"quotaBytes"`
+	MinFreeBytes   int64  `json:"minFreeBytes"`
+	FreeBytes      int64  `json:"freeBytes"`
+	UsedBytes      int64  `json:"usedBytes"`
+	ReservedBytes  int64  `json:"reservedBytes"`
+	AvailableBytes int64  `json:"availableBytes"`
+	Torrents       int    `json:"torrents"`
+	// Paused is set while disk pressure keeps downloads on the root stopped.
+	Paused bool `json:"paused"`
 }
 
 type StorageSettingsView struct {
@@ -39,11 +58,20 @@ type StorageSettingsStore interface {
 	SetStorageSettings(ctx context.Context, settings StorageSettings) error
 }
 
+// DiskSpaceFunc reports free bytes on the data directory and the bytes still
+// to be written by active downloads.
+type DiskSpaceFunc func(ctx context.Context) (freeBytes, reservedBytes int64, err error)
+
+// StorageRootsFunc reports per-root usage of the configured storage roots.
+type StorageRootsFunc func(ctx context.Context) ([]StorageRootUsage, error)
+
 type StorageSettingsManager struct {
 	mu                sync.RWMutex
 	runtime           StorageSettingsRuntime
 	store             StorageSettingsStore
 	downloadedBytesFn func(ctx context.Context) (int64, error)
+	diskSpaceFn       DiskSpaceFunc
+	rootsFn           StorageRootsFunc
 	dataDir           string
 	maxSessions       int
 	minDiskSpaceBytes int64
@@ -68,11 +96,36 @@ func NewStorageSettingsManager(
 	}
 }
 
+// SetDiskSpaceFunc sets the source of free and reserved bytes reported in
+// StorageUsage.
+func (m *StorageSettingsManager) SetDiskSpaceFunc(fn DiskSpaceFunc) {
+	m.mu.Lock()
+	m.diskSpaceFn = fn
+	m.mu.Unlock()
+}
+
+// SetStorageRootsFunc sets the source of per-root usage reported in
+// StorageUsage.Roots.
+func (m *StorageSettingsManager) SetStorageRootsFunc(fn StorageRootsFunc) {
+	m.mu.Lock()
+	m.rootsFn = fn
+	m.mu.Unlock()
+}
+
+// MinDiskSpaceBytes returns the configured disk-pressure threshold.
+func (m *StorageSettingsManager) MinDiskSpaceBytes() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.minDiskSpaceBytes
+}
+
 func (m *StorageSettingsManager) Get() StorageSettingsView {
 	m.mu.RLock()
 	currentMax := m.maxSessions
 	currentMinFree := m.minDiskSpaceBytes
 	dataDir := m.dataDir
+	diskSpaceFn := m.diskSpaceFn
+	rootsFn := m.rootsFn
 	m.mu.RUnlock()
 
 	if m.runtime != nil {
@@ -87,6 +140,22 @@ func (m *StorageSettingsManager) Get() StorageSettingsView {
 			usage.TorrentClientDownloadedBytes = total
 		}
 	}
+	if diskSpaceFn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+		if free, reserved, err := diskSpaceFn(ctx); err == nil {
+			usage.FreeBytes = free
+			usage.ReservedBytes = reserved
+			usage.AvailableBytes = max(free-reserved-currentMinFree, 0)
+		}
+	}
+	if rootsFn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+		if roots, err := rootsFn(ctx); err == nil {
+			usage.Roots = roots
+		}
+	}
 
 	return StorageSettingsView{
 		MaxSessions:       currentMax,
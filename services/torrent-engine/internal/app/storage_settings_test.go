@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStorageSettingsManager_GetReportsDiskSpace(t *testing.T) {
+	mgr := NewStorageSettingsManager(t.TempDir(), StorageSettings{MinDiskSpaceBytes: 100}, nil, nil, nil)
+	mgr.SetDiskSpaceFunc(func(context.Context) (int64, int64, error) {
+		return 1000, 300, nil
+	})
+
+	usage := mgr.Get().Usage
+	if usage.FreeBytes != 1000 || usage.ReservedBytes != 300 || usage.AvailableBytes != 600 {
+		t.Fatalf("usage = %+v", usage)
+	}
+}
+
+func TestStorageSettingsManager_GetIgnoresDiskSpaceError(t *testing.T) {
+	mgr := NewStorageSettingsManager(t.TempDir(), StorageSettings{}, nil, nil, nil)
+	mgr.SetDiskSpaceFunc(func(context.Context) (int64, int64, error) {
+		return 0, 0, errors.New("unsupported")
+	})
+
+	usage := mgr.Get().Usage
+	if usage.FreeBytes != 0 || usage.ReservedBytes != 0 || usage.AvailableBytes != 0 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if !usage.DataDirExists {
+		t.Fatalf("expected data dir to exist")
+	}
+}
+
+func TestStorageSettingsManager_GetReportsRoots(t *testing.T) {
+	mgr := NewStorageSettingsManager(t.TempDir(), StorageSettings{}, nil, nil, nil)
+	if roots := mgr.Get().Usage.Roots; roots != nil {
+		t.Fatalf("roots = %+v, want none without a roots func", roots)
+	}
+
+	mgr.SetStorageRootsFunc(func(context.Context) ([]StorageRootUsage, error) {
+		return []StorageRootUsage{
+			{Path: "/a", FreeBytes: 1000},
+			{Path: "/b", FreeBytes: 50, Paused: true},
+		}, nil
+	})
+	roots := mgr.Get().Usage.Roots
+	if len(roots) != 2 || roots[1].Path != "/b" || !roots[1].Paused {
+		t.Fatalf("roots = %+v", roots)
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import "time"
+
+// EventType identifies an engine event.
+type EventType string
+
+const (
+	// EventTorrentAdded: a new torrent was added and stored.
+	EventTorrentAdded EventType = "torrent_added"
+	// EventMetadataReady: the metadata of a magnet arrived, or a torrent
+	// file was opened; the file list is known.
+	EventMetadataReady EventType = "metadata_ready"
+	// EventModeChanged: the session moved between modes (From, To).
+	EventModeChanged EventType = "mode_changed"
+	// EventCompleted: every selected file was downloaded.
+	EventCompleted EventType = "completed"
+	// EventError: the session failed, e.g. metadata never arrived.
+	EventError EventType = "error"
+	// EventFileCompleted: one file (FileIndex) finished downloading.
+	EventFileCompleted EventType = "file_completed"
+	// EventDiskPressure: free space on a storage root (Path) dropped below
+	// its threshold and downloads were stopped.
+	EventDiskPressure EventType = "disk_pressure"
+	// EventPlaybackStarted: a stream of file FileIndex was started.
+	EventPlaybackStarted EventType = "playback_started"
+	// EventTorrentStarted: the torrent was started (Reason).
+	EventTorrentStarted EventType = "torrent_started"
+	// EventTorrentStopped: the torrent was stopped (Reason).
+	EventTorrentStopped EventType = "torrent_stopped"
+	// EventTagsChanged: the tags of the torrent were replaced (Tags).
+	EventTagsChanged EventType = "tags_changed"
+	// EventTorrentTrashed: the torrent was moved to the trash (Reason).
+	EventTorrentTrashed EventType = "torrent_trashed"
+	// EventTorrentDeleted: the torrent was removed for good (Reason).
+	EventTorrentDeleted EventType = "torrent_deleted"
+)
+
+// Reasons of start, stop and delete events.
+const (
+	// ReasonUser: requested through the API.
+	ReasonUser = "user"
+	// ReasonDiskPressure: free space ran low, or recovered.
+	ReasonDiskPressure = "disk_pressure"
+	// ReasonIdle: the session was not read for the idle timeout.
+	ReasonIdle = "idle"
+	// ReasonStalled: the torrent showed no activity (stall action stop).
+	ReasonStalled = "stalled"
+	// ReasonRetention: the retention policy matched the torrent.
+	ReasonRetention = "retention"
+	// ReasonTrashExpired: the torrent stayed in the trash past its retention.
+	ReasonTrashExpired = "trash_expired"
+)
+
+// EventTypes lists every event type.
+var EventTypes = []EventType{
+	EventTorrentAdded, EventMetadataReady, EventModeChanged, EventCompleted,
+	EventError, EventFileCompleted, EventDiskPressure, EventPlaybackStarted,
+	EventTorrentStarted, EventTorrentStopped, EventTagsChanged, EventTorrentTrashed,
+	EventTorrentDeleted,
+}
+
+// Event is a state change of a torrent, or of the engine (TorrentID empty),
+// published on the event bus.
+type Event struct {
+	Type      EventType   `json:"type"`
+	TorrentID TorrentID   `json:"torrentId"`
+	Time      time.Time   `json:"time"`
+	From      SessionMode `json:"from,omitempty"`
+	To        SessionMode `json:"to,omitempty"`
+	FileIndex *int        `json:"fileIndex,omitempty"`
+	Path      string      `json:"path,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	FreeBytes int64       `json:"freeBytes,omitempty"`
+	Reason    string      `json:"reason,omitempty"` // who or what started, stopped or deleted the torrent
+	Source    string      `json:"source,omitempty"` // "magnet" or "torrent" on torrent_added
+	Tags      []string    `json:"tags,omitempty"`
+}
+
+// EventFilter selects entries of the event log. Zero fields match all.
+type EventFilter struct {
+	TorrentID TorrentID
+	Types     []EventType
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
@@ -9,4 +9,12 @@ type FileRef struct {
 	Priority       string  `json:"priority,omitempty"`
 	PieceStart     int     `json:"pieceStart,omitempty"` // inclusive
 	PieceEnd       int     `json:"pieceEnd,omitempty"`   // exclusive
+	// PiecesRoot is the hex merkle root of the file in v2 torrents.
+	PiecesRoot string `json:"piecesRoot,omitempty"`
+}
+
+// VideoExtensions are the lowercase file extensions treated as video.
+var VideoExtensions = map[string]struct{}{
+	".mp4": {}, ".m4v": {}, ".mov": {}, ".mkv": {}, ".avi": {},
+	".wmv": {}, ".flv": {}, ".webm": {}, ".ts": {}, ".m2ts": {},
 }
@@ -15,4 +15,11 @@ type TorrentFilter struct {
 	SortOrder SortOrder      `json:"sortOrder,omitempty"`
 	Limit     int            `json:"limit,omitempty"`
 	Offset    int            `json:"offset,omitempty"`
+	// Trashed lists only soft-deleted torrents; otherwise they are excluded.
+	Trashed bool `json:"trashed,omitempty"`
+	// InfoHash matches torrents whose v1 or v2 info hash equals it.
+	InfoHash InfoHash `json:"infoHash,omitempty"`
+	// Category matches torrents in that category; an empty category
+	// matches uncategorized torrents.
+	Category *string `json:"category,omitempty"`
 }
@@ -0,0 +1,69 @@
+package domain
+
+import "time"
+
+// FileIntegrityStatus summarizes the verified state of a file's pieces.
+type FileIntegrityStatus string
+
+const (
+	// FileIntact: every piece passed the hash check.
+	FileIntact FileIntegrityStatus = "complete"
+	// FilePartial: some pieces are still missing.
+	FilePartial FileIntegrityStatus = "partial"
+	// FileMissing: no piece has been downloaded yet.
+	FileMissing FileIntegrityStatus = "missing"
+	// FileCorrupted: pieces failed the hash check and were not downloaded
+	// again yet.
+	FileCorrupted FileIntegrityStatus = "corrupted"
+)
+
+// PieceFailure records the hash failures of one piece.
+type PieceFailure struct {
+	Index        int       `json:"index"`
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"lastFailedAt"`
+	// Recovered is true once the piece was downloaded again and passed.
+	Recovered bool `json:"recovered"`
+}
+
+// BadPeer is a peer that contributed data to pieces that failed the hash
+// check.
+type BadPeer struct {
+	IP         string    `json:"ip"`
+	Client     string    `json:"client,omitempty"`
+	BadPieces  int       `json:"badPieces"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Banned     bool      `json:"banned"`
+}
+
+// FileIntegrity is the piece-level state of one file.
+type FileIntegrity struct {
+	Index           int                 `json:"index"`
+	Path            string              `json:"path"`
+	Length          int64               `json:"length"`
+	Status          FileIntegrityStatus `json:"status"`
+	Pieces          int                 `json:"pieces"`
+	CompletePieces  int                 `json:"completePieces"`
+	PartialPieces   int                 `json:"partialPieces"`
+	CorruptedPieces int                 `json:"corruptedPieces"`
+	// FailedPieces lists every piece of the file that failed the hash check,
+	// including recovered ones.
+	FailedPieces []int `json:"failedPieces,omitempty"`
+}
+
+// IntegrityReport describes hash failures of a torrent, the peers that sent
+// bad data and the verified state of each file. Piece counts are only known
+// while the session is open; otherwise files are summarized from the stored
+// byte counts.
+type IntegrityReport struct {
+	TorrentID    TorrentID       `json:"torrentId"`
+	SessionOpen  bool            `json:"sessionOpen"`
+	PieceLength  int64           `json:"pieceLength,omitempty"`
+	NumPieces    int             `json:"numPieces,omitempty"`
+	HashFailures int64           `json:"hashFailures"`
+	WastedBytes  int64           `json:"wastedBytes"`
+	FailedPieces []PieceFailure  `json:"failedPieces"`
+	BadPeers     []BadPeer       `json:"badPeers"`
+	Files        []FileIntegrity `json:"files"`
+	GeneratedAt  time.Time       `json:"generatedAt"`
+}
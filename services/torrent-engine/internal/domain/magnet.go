@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Magnet builds a magnet link for the record with its info hashes, name,
+// trackers, web seeds, direct peers and file selection. Tracker and web
+// seed credentials are redacted unless withCredentials is set.
+func (r TorrentRecord) Magnet(withCredentials bool) string {
+	var b strings.Builder
+	b.WriteString("magnet:?xt=urn:btih:")
+	b.WriteString(strings.ToLower(string(r.InfoHash)))
+	if r.InfoHashV2 != "" {
+		b.WriteString("&xt=urn:btmh:1220")
+		b.WriteString(strings.ToLower(string(r.InfoHashV2)))
+	}
+	if r.Name != "" {
+		b.WriteString("&dn=")
+		b.WriteString(url.QueryEscape(r.Name))
+	}
+	writeURLs := func(key string, urls []string) {
+		for _, u := range urls {
+			if !withCredentials {
+				u = RedactURL(u)
+			}
+			b.WriteString("&" + key + "=")
+			b.WriteString(url.QueryEscape(u))
+		}
+	}
+	writeURLs("tr", r.Source.Trackers)
+	writeURLs("ws", r.Source.WebSeeds)
+	for _, peer := range r.Source.Peers {
+		b.WriteString("&x.pe=")
+		b.WriteString(url.QueryEscape(peer))
+	}
+	if len(r.Source.SelectedFiles) > 0 {
+		b.WriteString("&so=")
+		b.WriteString(formatSelectOnly(r.Source.SelectedFiles))
+	}
+	return b.String()
+}
+
+// formatSelectOnly writes sorted file indices in BEP 53 form, collapsing
+// runs into ranges: [0 2 3 4] becomes "0,2-4".
+func formatSelectOnly(indices []int) string {
+	var parts []string
+	for i := 0; i < len(indices); {
+		j := i
+		for j+1 < len(indices) && indices[j+1] == indices[j]+1 {
+			j++
+		}
+		part := strconv.Itoa(indices[i])
+		if j > i {
+			part += "-" + strconv.Itoa(indices[j])
+		}
+		parts = append(parts, part)
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
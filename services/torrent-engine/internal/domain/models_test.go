@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -47,6 +48,11 @@ func TestPriorityConstants(t *testing.T) {
 func TestTorrentSourceJSONTags(t *testing.T) {
 	expectJSONTag(t, TorrentSource{}, "Magnet", "magnet,omitempty")
 	expectJSONTag(t, TorrentSource{}, "Torrent", "torrent,omitempty")
+	expectJSONTag(t, TorrentSource{}, "DataDir", "dataDir,omitempty")
+	expectJSONTag(t, TorrentSource{}, "WebSeeds", "webSeeds,omitempty")
+	expectJSONTag(t, TorrentSource{}, "Trackers", "trackers,omitempty")
+	expectJSONTag(t, TorrentSource{}, "Peers", "peers,omitempty")
+	expectJSONTag(t, TorrentSource{}, "SelectedFiles", "selectedFiles,omitempty")
 }
 
 func TestFileRefJSONTags(t *testing.T) {
@@ -55,6 +61,7 @@ func TestFileRefJSONTags(t *testing.T) {
 	expectJSONTag(t, FileRef{}, "Length", "length")
 	expectJSONTag(t, FileRef{}, "PieceStart", "pieceStart,omitempty")
 	expectJSONTag(t, FileRef{}, "PieceEnd", "pieceEnd,omitempty")
+	expectJSONTag(t, FileRef{}, "PiecesRoot", "piecesRoot,omitempty")
 }
 
 func TestRangeJSONTags(t *testing.T) {
@@ -67,6 +74,7 @@ func TestTorrentRecordJSONTags(t *testing.T) {
 	expectJSONTag(t, TorrentRecord{}, "Name", "name")
 	expectJSONTag(t, TorrentRecord{}, "Status", "status")
 	expectJSONTag(t, TorrentRecord{}, "InfoHash", "infoHash")
+	expectJSONTag(t, TorrentRecord{}, "InfoHashV2", "infoHashV2,omitempty")
 	expectJSONTag(t, TorrentRecord{}, "Source", "-")
 	expectJSONTag(t, TorrentRecord{}, "Files", "files")
 	expectJSONTag(t, TorrentRecord{}, "TotalBytes", "totalBytes")
@@ -74,6 +82,8 @@ func TestTorrentRecordJSONTags(t *testing.T) {
 	expectJSONTag(t, TorrentRecord{}, "CreatedAt", "createdAt")
 	expectJSONTag(t, TorrentRecord{}, "UpdatedAt", "updatedAt")
 	expectJSONTag(t, TorrentRecord{}, "Tags", "tags")
+	expectJSONTag(t, TorrentRecord{}, "DeletedAt", "deletedAt,omitempty")
+	expectJSONTag(t, TorrentRecord{}, "DeleteFiles", "deleteFiles,omitempty")
 }
 
 func TestTorrentFilterJSONTags(t *testing.T) {
@@ -84,11 +94,15 @@ func TestTorrentFilterJSONTags(t *testing.T) {
 	expectJSONTag(t, TorrentFilter{}, "SortOrder", "sortOrder,omitempty")
 	expectJSONTag(t, TorrentFilter{}, "Limit", "limit,omitempty")
 	expectJSONTag(t, TorrentFilter{}, "Offset", "offset,omitempty")
+	expectJSONTag(t, TorrentFilter{}, "Trashed", "trashed,omitempty")
+	expectJSONTag(t, TorrentFilter{}, "InfoHash", "infoHash,omitempty")
 }
 
 func TestSessionStateJSONTags(t *testing.T) {
 	expectJSONTag(t, SessionState{}, "ID", "id")
 	expectJSONTag(t, SessionState{}, "Status", "status")
+	expectJSONTag(t, SessionState{}, "InfoHash", "infoHash,omitempty")
+	expectJSONTag(t, SessionState{}, "InfoHashV2", "infoHashV2,omitempty")
 	expectJSONTag(t, SessionState{}, "Mode", "mode,omitempty")
 	expectJSONTag(t, SessionState{}, "TransferPhase", "transferPhase,omitempty")
 	expectJSONTag(t, SessionState{}, "Progress", "progress")
@@ -269,3 +283,27 @@ func expectJSONTag(t *testing.T, v interface{}, fieldName, want string) {
 		t.Fatalf("%s json tag = %q, want %q", fieldName, got, want)
 	}
 }
+
+func TestPlacementPolicyValid(t *testing.T) {
+	for _, p := range []PlacementPolicy{PlacementMostFree, PlacementRoundRobin, PlacementCategory} {
+		if !p.Valid() {
+			t.Fatalf("%q should be valid", p)
+		}
+	}
+	if PlacementPolicy("random").Valid() {
+		t.Fatalf("unknown policy must be invalid")
+	}
+}
+
+func TestNormalizeWebSeedURL(t *testing.T) {
+	for _, raw := range []string{"http://mirror.local/data/", " https://mirror.local/file.iso ", "ftp://user:pw@mirror.local/pub/"} {
+		if _, err := NormalizeWebSeedURL(raw); err != nil {
+			t.Fatalf("NormalizeWebSeedURL(%q): %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"", "mirror.local/data", "udp://mirror.local:80", "http://"} {
+		if _, err := NormalizeWebSeedURL(raw); !errors.Is(err, ErrInvalidWebSeed) {
+			t.Fatalf("NormalizeWebSeedURL(%q) err = %v, want ErrInvalidWebSeed", raw, err)
+		}
+	}
+}
@@ -24,3 +24,32 @@ type Engine interface {
 	// Pass 0 to remove the limit (unlimited).
 	SetDownloadRateLimit(ctx context.Context, id domain.TorrentID, bytesPerSec int64) error
 }
+
+// TrackerEngine adds trackers to open sessions.
+type TrackerEngine interface {
+	// AddTrackers adds urls as an extra tier. Private torrents are left
+	// unchanged (BEP 27).
+	AddTrackers(ctx context.Context, id domain.TorrentID, urls []string) error
+}
+
+// IntegrityEngine reports piece hash failures of open sessions.
+type IntegrityEngine interface {
+	// Integrity returns the hash failures, bad peers and per-file piece
+	// state of the session.
+	Integrity(ctx context.Context, id domain.TorrentID) (domain.IntegrityReport, error)
+}
+
+// MetainfoEngine reads .torrent files without adding them.
+type MetainfoEngine interface {
+	// TorrentFiles lists the files of the .torrent file at path.
+	TorrentFiles(path string) ([]domain.FileRef, error)
+}
+
+// WebSeedEngine manages BEP 19 web seeds of open sessions.
+type WebSeedEngine interface {
+	AddWebSeeds(ctx context.Context, id domain.TorrentID, urls []string) error
+	// RemoveWebSeed stops using url for the session.
+	RemoveWebSeed(ctx context.Context, id domain.TorrentID, url string) error
+	// WebSeeds lists the session's web seeds with transfer statistics.
+	WebSeeds(ctx context.Context, id domain.TorrentID) ([]domain.WebSeed, error)
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "torrentstream/internal/domain"
+
+// EventPublisher delivers torrent events to subscribers. Publish must not
+// block, since it is called with engine locks held.
+type EventPublisher interface {
+	Publish(event domain.Event)
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"torrentstream/internal/domain"
 )
@@ -10,9 +11,33 @@ type TorrentRepository interface {
 	Create(ctx context.Context, t domain.TorrentRecord) error
 	Update(ctx context.Context, t domain.TorrentRecord) error
 	UpdateProgress(ctx context.Context, id domain.TorrentID, update domain.ProgressUpdate) error
+	// Get returns a torrent outside the trash; a trashed torrent is
+	// domain.ErrNotFound, as it is for the user.
 	Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error)
+	// GetTrashed returns a torrent in the trash, for restoring and purging.
+	GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error)
 	List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error)
 	GetMany(ctx context.Context, ids []domain.TorrentID) ([]domain.TorrentRecord, error)
 	Delete(ctx context.Context, id domain.TorrentID) error
 	UpdateTags(ctx context.Context, id domain.TorrentID, tags []string) error
 }
+
+// StallRepository stores dead torrent detection results. They are written
+// apart from full record updates so they never race with progress syncs.
+type StallRepository interface {
+	SetStalled(ctx context.Context, id domain.TorrentID, since time.Time, reason domain.StallReason) error
+	ClearStalled(ctx context.Context, id domain.TorrentID) error
+}
+
+// CategoryRepository moves torrents between categories, apart from full
+// record updates like StallRepository.
+type CategoryRepository interface {
+	SetCategory(ctx context.Context, id domain.TorrentID, category string) error
+}
+
+// ExtractedRepository stores the files unpacked from a torrent's archives,
+// apart from full record updates like StallRepository.
+type ExtractedRepository interface {
+	// SetExtracted stores files; unpacked marks that no archive is left.
+	SetExtracted(ctx context.Context, id domain.TorrentID, files []domain.FileRef, unpacked bool) error
+}
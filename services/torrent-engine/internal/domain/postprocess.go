@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var ErrInvalidPostProcess = errors.New("invalid post-processing command")
+
+// PostProcessTriggers are the events a post-processing command can run on.
+var PostProcessTriggers = []EventType{EventTorrentAdded, EventMetadataReady, EventCompleted}
+
+// PostProcessCommand is an external program run for a torrent when Trigger
+// fires. Args are text/template strings rendered with the torrent's
+// details; the program is executed directly, not through a shell.
+type PostProcessCommand struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Trigger EventType `json:"trigger"`
+	Program string    `json:"program"`
+	Args    []string  `json:"args,omitempty"`
+	// TimeoutSeconds bounds a run; 0 uses the engine default.
+	TimeoutSeconds int       `json:"timeoutSeconds,omitempty"`
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// ValidPostProcessTrigger reports whether commands can run on t.
+func ValidPostProcessTrigger(t EventType) bool {
+	return slices.Contains(PostProcessTriggers, t)
+}
+
+// PostProcessRun is the outcome of running a command for a torrent.
+type PostProcessRun struct {
+	ID          string    `json:"id"`
+	TorrentID   TorrentID `json:"torrentId"`
+	CommandID   string    `json:"commandId"`
+	CommandName string    `json:"commandName,omitempty"`
+	Trigger     EventType `json:"trigger"`
+	Program     string    `json:"program"`
+	Args        []string  `json:"args,omitempty"`
+	Success     bool      `json:"success"`
+	ExitCode    int       `json:"exitCode"`
+	// Output is the combined stdout and stderr, cut at the engine limit.
+	Output    string    `json:"output,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  int64     `json:"durationMs"`
+}
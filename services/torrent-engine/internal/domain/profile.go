@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// DefaultProfileID is the profile used when none is selected. Watch history
+// recorded before profiles existed belongs to it.
+const DefaultProfileID = "default"
+
+// Profile separates the watch history and player state of the people
+// sharing an instance. A profile with an OwnerID is only usable by that user
+// (and admins); other profiles are shared.
+type Profile struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	OwnerID string `json:"ownerId,omitempty"`
+	// CurrentTorrentID is the torrent the profile last focused.
+	CurrentTorrentID TorrentID `json:"currentTorrentId,omitempty"`
+	// PrioritizeActiveFileOnly is the profile's player preference; nil
+	// follows the global setting.
+	PrioritizeActiveFileOnly *bool     `json:"prioritizeActiveFileOnly,omitempty"`
+	CreatedAt                time.Time `json:"createdAt"`
+	UpdatedAt                time.Time `json:"updatedAt"`
+}
+
+// UsableBy reports whether the caller may use the profile. Without
+// authentication (nil caller) every profile is usable.
+func (p Profile) UsableBy(caller *Principal) bool {
+	return p.OwnerID == "" || caller == nil || caller.User.ID == p.OwnerID || caller.Role == RoleAdmin
+}
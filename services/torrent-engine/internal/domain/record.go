@@ -10,6 +10,8 @@ type TorrentRecord struct {
 	Name       string        `json:"name"`
 	Status     TorrentStatus `json:"status"`
 	InfoHash   InfoHash      `json:"infoHash"`
+	InfoHashV2 InfoHash      `json:"infoHashV2,omitempty"` // v2 and hybrid torrents only
+	Private    bool          `json:"private,omitempty"`    // BEP 27 private flag from the metainfo
 	Source     TorrentSource `json:"-"`
 	Files      []FileRef     `json:"files"`
 	TotalBytes int64         `json:"totalBytes"`
@@ -17,6 +19,48 @@ type TorrentRecord struct {
 	CreatedAt  time.Time     `json:"createdAt"`
 	UpdatedAt  time.Time     `json:"updatedAt"`
 	Tags       []string      `json:"tags"`
+	// Category groups torrents for download clients such as Sonarr and
+	// picks the storage root under the category placement policy.
+	Category string `json:"category,omitempty"`
+	// DeletedAt is set while the torrent sits in the trash.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// DeleteFiles records whether purging a trashed torrent removes its data.
+	DeleteFiles bool `json:"deleteFiles,omitempty"`
+	// StalledSince is set while the torrent is flagged as dead, with the
+	// reason in StalledReason.
+	StalledSince  *time.Time  `json:"stalledSince,omitempty"`
+	StalledReason StallReason `json:"stalledReason,omitempty"`
+	// Extracted lists the files unpacked from the torrent's archives. Their
+	// indices continue after the last torrent file.
+	Extracted []FileRef `json:"extracted,omitempty"`
+	// Unpacked is set once every archive was extracted. Until then a new
+	// attempt keeps the files extracted so far and retries the rest.
+	Unpacked bool `json:"unpacked,omitempty"`
+}
+
+// InTrash reports whether the torrent has been soft-deleted.
+func (r TorrentRecord) InTrash() bool {
+	return r.DeletedAt != nil
+}
+
+// AllFiles returns the torrent files followed by the extracted files.
+func (r TorrentRecord) AllFiles() []FileRef {
+	if len(r.Extracted) == 0 {
+		return r.Files
+	}
+	files := make([]FileRef, 0, len(r.Files)+len(r.Extracted))
+	files = append(files, r.Files...)
+	return append(files, r.Extracted...)
+}
+
+// ExtractedFile returns the extracted file with the given index.
+func (r TorrentRecord) ExtractedFile(index int) (FileRef, bool) {
+	for _, f := range r.Extracted {
+		if f.Index == index {
+			return f, true
+		}
+	}
+	return FileRef{}, false
 }
 
 // ProgressUpdate holds fields for an atomic progress update via $max.
@@ -26,6 +70,11 @@ type ProgressUpdate struct {
 	Status     TorrentStatus
 	Files      []FileRef
 	Name       string
+	// InfoHash and InfoHashV2 are set once metadata reveals the hashes.
+	InfoHash   InfoHash
+	InfoHashV2 InfoHash
+	// Private is set once metadata shows the BEP 27 private flag.
+	Private bool
 }
 
 // Validate checks domain invariants for TorrentRecord.
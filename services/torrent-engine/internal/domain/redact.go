@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces tracker credentials in URLs shown outside the engine.
+const Redacted = "REDACTED"
+
+// secretQueryKeys are announce URL query parameters that carry per-user
+// tracker credentials.
+var secretQueryKeys = map[string]struct{}{
+	"passkey":      {},
+	"authkey":      {},
+	"torrent_pass": {},
+	"pk":           {},
+	"key":          {},
+	"auth":         {},
+	"token":        {},
+	"secure":       {},
+	"uid":          {},
+	"apikey":       {},
+	"api_key":      {},
+}
+
+var (
+	// passkeySegment matches path segments that look like a passkey, such
+	// as the 32 hex characters in "/a1b2.../announce".
+	passkeySegment = regexp.MustCompile(`^[A-Za-z0-9_-]{16,}$`)
+	textURL        = regexp.MustCompile(`(?i)\b(?:https?|udp|wss?|ftp)://[^\s"'<>]+`)
+	textMagnet     = regexp.MustCompile(`(?i)\bmagnet:\?[^\s"'<>]+`)
+)
+
+// RedactURL replaces the credentials in a tracker or web seed URL: user
+// info, known passkey query parameters and passkey-like path segments.
+// Values that are not URLs are returned unchanged.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	changed := false
+	if u.User != nil {
+		u.User = url.User(Redacted)
+		changed = true
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, seg := range segments {
+		if passkeySegment.MatchString(seg) && strings.ContainsAny(seg, "0123456789") {
+			segments[i] = Redacted
+			changed = true
+		}
+	}
+	if changed {
+		u.Path = strings.Join(segments, "/")
+		u.RawPath = ""
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		queryChanged := false
+		for key := range query {
+			if _, ok := secretQueryKeys[strings.ToLower(key)]; ok {
+				query.Set(key, Redacted)
+				queryChanged = true
+			}
+		}
+		if queryChanged {
+			u.RawQuery = query.Encode()
+			changed = true
+		}
+	}
+
+	if !changed {
+		return raw
+	}
+	return u.String()
+}
+
+// RedactMagnet redacts the tracker and web seed URLs of a magnet link,
+// keeping every other parameter and the parameter order.
+func RedactMagnet(magnet string) string {
+	prefix, query, ok := strings.Cut(magnet, "?")
+	if !ok {
+		return magnet
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || !isMagnetURLKey(key) {
+			continue
+		}
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+		if redacted := RedactURL(decoded); redacted != decoded {
+			pairs[i] = key + "=" + url.QueryEscape(redacted)
+		}
+	}
+	return prefix + "?" + strings.Join(pairs, "&")
+}
+
+// RedactText redacts every URL and magnet link found in free text such as
+// a log message or an error.
+func RedactText(text string) string {
+	if !strings.Contains(text, "://") && !strings.Contains(text, "magnet:") {
+		return text
+	}
+	text = textMagnet.ReplaceAllStringFunc(text, RedactMagnet)
+	return textURL.ReplaceAllStringFunc(text, RedactURL)
+}
+
+func isMagnetURLKey(key string) bool {
+	switch key {
+	case "tr", "ws", "as", "xs":
+		return true
+	}
+	return strings.HasPrefix(key, "tr.")
+}
@@ -0,0 +1,28 @@
+package domain
+
+// RetentionPolicy describes which completed torrents may be removed
+// automatically to free disk space. A rule with a zero value is disabled.
+type RetentionPolicy struct {
+	// WatchedDays removes torrents whose media files were all watched to the
+	// end at least this many days ago.
+	WatchedDays int `json:"watchedDays"`
+	// UntouchedDays removes torrents with no record update or playback
+	// activity for this many days.
+	UntouchedDays int `json:"untouchedDays"`
+	// MaxTotalBytes removes the least recently used torrents until the total
+	// downloaded size fits under this quota.
+	MaxTotalBytes int64 `json:"maxTotalBytes"`
+	// KeepTags protects torrents carrying any of these tags (case-insensitive).
+	KeepTags []string `json:"keepTags"`
+	// KeepTagged protects every torrent that has at least one tag.
+	KeepTagged bool `json:"keepTagged"`
+	// KeepFiles removes only the torrent records and leaves data on disk.
+	KeepFiles bool `json:"keepFiles"`
+	// DryRun makes the periodic job report candidates without deleting them.
+	DryRun bool `json:"dryRun"`
+}
+
+// Active reports whether at least one retention rule is configured.
+func (p RetentionPolicy) Active() bool {
+	return p.WatchedDays > 0 || p.UntouchedDays > 0 || p.MaxTotalBytes > 0
+}
@@ -3,4 +3,18 @@ package domain
 type TorrentSource struct {
 	Magnet  string `json:"magnet,omitempty"`
 	Torrent string `json:"torrent,omitempty"`
+	// DataDir is the storage root the torrent data is written to. Empty means
+	// the engine's default data directory.
+	DataDir string `json:"dataDir,omitempty"`
+	// WebSeeds are BEP 19 HTTP/FTP web seed URLs added by the user, on top of
+	// those listed in the magnet or metainfo.
+	WebSeeds []string `json:"webSeeds,omitempty"`
+	// Trackers are announce URLs added on top of those in the magnet or
+	// metainfo, e.g. the magnet's tr= parameters.
+	Trackers []string `json:"trackers,omitempty"`
+	// Peers are host:port addresses connected to directly (x.pe=).
+	Peers []string `json:"peers,omitempty"`
+	// SelectedFiles limits the download to these file indices (BEP 53
+	// so=). Empty downloads every file.
+	SelectedFiles []int `json:"selectedFiles,omitempty"`
 }
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// StallReason explains why a torrent is considered dead.
+type StallReason string
+
+const (
+	StallNoMetadata StallReason = "no_metadata" // magnet metadata never arrived
+	StallNoPeers    StallReason = "no_peers"    // no peers or seeders connected
+	StallNoProgress StallReason = "no_progress" // nothing downloaded
+)
+
+// StallAction is taken when a torrent is detected as stalled.
+type StallAction string
+
+const (
+	StallActionNone StallAction = "none"
+	StallActionStop StallAction = "stop"
+	// StallActionRetry adds extra trackers once and flags the torrent only
+	// if it stays stalled.
+	StallActionRetry StallAction = "retry"
+)
+
+func (a StallAction) Valid() bool {
+	switch a {
+	case StallActionNone, StallActionStop, StallActionRetry:
+		return true
+	}
+	return false
+}
+
+// StallEvent is pushed when a torrent is flagged as stalled or recovers.
+type StallEvent struct {
+	TorrentID    TorrentID   `json:"torrentId"`
+	Name         string      `json:"name"`
+	Stalled      bool        `json:"stalled"`
+	Reason       StallReason `json:"reason,omitempty"`
+	StalledSince *time.Time  `json:"stalledSince,omitempty"`
+	Action       StallAction `json:"action,omitempty"` // action taken on the torrent
+}
@@ -5,6 +5,9 @@ import "time"
 type SessionState struct {
 	ID                   TorrentID     `json:"id"`
 	Status               TorrentStatus `json:"status"`
+	InfoHash             InfoHash      `json:"infoHash,omitempty"`
+	InfoHashV2           InfoHash      `json:"infoHashV2,omitempty"`
+	Private              bool          `json:"private,omitempty"`
 	Mode                 SessionMode   `json:"mode,omitempty"`
 	TransferPhase        TransferPhase `json:"transferPhase,omitempty"`
 	Progress             float64       `json:"progress"`
@@ -12,6 +15,8 @@ type SessionState struct {
 	Peers                int           `json:"peers"`
 	DownloadSpeed        int64         `json:"downloadSpeed"`
 	UploadSpeed          int64         `json:"uploadSpeed"`
+	Downloaded           int64         `json:"downloaded,omitempty"` // payload bytes received since the session was opened
+	Uploaded             int64         `json:"uploaded,omitempty"`   // payload bytes sent since the session was opened
 	Files                []FileRef     `json:"files,omitempty"`
 	NumPieces            int           `json:"numPieces,omitempty"`
 	PieceBitfield        string        `json:"pieceBitfield,omitempty"`
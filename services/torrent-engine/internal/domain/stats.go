@@ -0,0 +1,73 @@
+package domain
+
+import "time"
+
+// StatsSample is one point of a torrent's statistics history. Speeds and
+// peers are averaged over the sample's interval; progress and the transfer
+// totals are the values at its end.
+type StatsSample struct {
+	Time          time.Time `json:"time"`
+	DownloadSpeed int64     `json:"downloadSpeed"`
+	UploadSpeed   int64     `json:"uploadSpeed"`
+	Peers         int       `json:"peers"`
+	Progress      float64   `json:"progress"`
+	Downloaded    int64     `json:"downloaded"` // all-time payload bytes received
+	Uploaded      int64     `json:"uploaded"`   // all-time payload bytes sent
+}
+
+// TransferTotals are the all-time payload bytes a torrent has received and
+// sent, across restarts.
+type TransferTotals struct {
+	TorrentID  TorrentID `json:"torrentId"`
+	Downloaded int64     `json:"downloaded"`
+	Uploaded   int64     `json:"uploaded"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// EngineStats are client-wide transfer counters since the engine started.
+type EngineStats struct {
+	Downloaded   int64 // payload bytes received
+	Uploaded     int64 // payload bytes sent
+	HashFailures int64 // pieces that failed the hash check
+	WastedBytes  int64 // bytes downloaded for those pieces
+	DHTNodes     int
+}
+
+// TransferCounters are byte counters over a period: the current session or
+// all time.
+type TransferCounters struct {
+	Downloaded   int64 `json:"downloaded"`
+	Uploaded     int64 `json:"uploaded"`
+	HashFailures int64 `json:"hashFailures"`
+	WastedBytes  int64 `json:"wastedBytes"`
+}
+
+// Add returns the element-wise sum of c and o.
+func (c TransferCounters) Add(o TransferCounters) TransferCounters {
+	return TransferCounters{
+		Downloaded:   c.Downloaded + o.Downloaded,
+		Uploaded:     c.Uploaded + o.Uploaded,
+		HashFailures: c.HashFailures + o.HashFailures,
+		WastedBytes:  c.WastedBytes + o.WastedBytes,
+	}
+}
+
+// Ratio returns uploaded divided by downloaded, or 0 before any download.
+func (c TransferCounters) Ratio() float64 {
+	if c.Downloaded <= 0 {
+		return 0
+	}
+	return float64(c.Uploaded) / float64(c.Downloaded)
+}
+
+// GlobalStats summarises transfers of the whole service.
+type GlobalStats struct {
+	Session       TransferCounters      `json:"session"`
+	AllTime       TransferCounters      `json:"allTime"`
+	Ratio         float64               `json:"ratio"`    // all-time uploaded / downloaded
+	Torrents      map[TorrentStatus]int `json:"torrents"` // torrents per status, trash excluded
+	TotalTorrents int                   `json:"totalTorrents"`
+	DHTNodes      int                   `json:"dhtNodes"`
+	StartedAt     time.Time             `json:"startedAt"`
+	UptimeSeconds int64                 `json:"uptimeSeconds"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+// StorageRoot is a data directory new torrents can be placed on.
+type StorageRoot struct {
+	Path string `json:"path"`
+	// QuotaBytes caps the total size of torrents placed on the root; 0 means
+	// unlimited.
+	QuotaBytes int64 `json:"quotaBytes"`
+	// MinFreeBytes is the free space that must be left on the root's disk.
+	MinFreeBytes int64 `json:"minFreeBytes"`
+}
+
+// PlacementPolicy selects the storage root for a new torrent.
+type PlacementPolicy string
+
+const (
+	// PlacementMostFree picks the root with the most available space.
+	PlacementMostFree PlacementPolicy = "most-free"
+	// PlacementRoundRobin cycles through roots that still have room.
+	PlacementRoundRobin PlacementPolicy = "round-robin"
+	// PlacementCategory maps the torrent category to a root and falls back
+	// to the most free root for unmapped categories.
+	PlacementCategory PlacementPolicy = "category"
+)
+
+// Valid reports whether p is a known placement policy.
+func (p PlacementPolicy) Valid() bool {
+	switch p {
+	case PlacementMostFree, PlacementRoundRobin, PlacementCategory:
+		return true
+	}
+	return false
+}
@@ -2,4 +2,7 @@ package domain
 
 type TorrentID string
 
+// InfoHash is a hex-encoded info hash: the SHA-1 hash for BitTorrent v1, or
+// the full SHA-256 hash for BitTorrent v2. A v2-only torrent is identified
+// by its v2 hash truncated to 20 bytes, as on the wire.
 type InfoHash string
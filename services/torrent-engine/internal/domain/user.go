@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidUser        = errors.New("invalid user")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrLastAdmin          = errors.New("the last admin cannot be removed or demoted")
+)
+
+// MinPasswordLength is the shortest accepted password.
+const MinPasswordLength = 8
+
+// Role grants access to the API. Each role includes the rights of the roles
+// below it: viewers browse and stream, users also add, start, stop and
+// delete torrents, admins also change settings and manage users.
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleUser   Role = "user"
+	RoleViewer Role = "viewer"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleAdmin:
+		return 3
+	case RoleUser:
+		return 2
+	case RoleViewer:
+		return 1
+	}
+	return 0
+}
+
+func (r Role) Valid() bool {
+	return r.rank() > 0
+}
+
+// Allows reports whether r includes the rights of required.
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && r.rank() >= required.rank()
+}
+
+// Lower returns the less privileged of r and other.
+func (r Role) Lower(other Role) Role {
+	if other.rank() < r.rank() {
+		return other
+	}
+	return r
+}
+
+// User is an account allowed to log in to the API.
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         Role      `json:"role"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// APIKey authenticates scripts and media players on behalf of a user. Its
+// Role caps the rights of the key; the key never has more rights than its
+// user. Only the hash of the key is stored.
+type APIKey struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	Name   string `json:"name,omitempty"`
+	// Prefix is the start of the key, shown to tell keys apart.
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Role       Role       `json:"role"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// AuthSession is a login session. ID is the hash of the session token held
+// in the client's cookie.
+type AuthSession struct {
+	ID        string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	User User `json:"user"`
+	// Role is the effective role: the user's role, capped by the API key
+	// used, if any.
+	Role     Role   `json:"role"`
+	APIKeyID string `json:"apiKeyId,omitempty"`
+}
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// NormalizeUsername lowercases and trims name and checks it is 1-64
+// letters, digits, dots, dashes or underscores.
+func NormalizeUsername(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !usernamePattern.MatchString(name) {
+		return "", ErrInvalidUser
+	}
+	return name, nil
+}
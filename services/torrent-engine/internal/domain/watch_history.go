@@ -3,6 +3,9 @@ package domain
 import "time"
 
 type WatchPosition struct {
+	// ProfileID is the profile the position belongs to; empty means
+	// DefaultProfileID.
+	ProfileID   string    `json:"profileId,omitempty"`
 	TorrentID   TorrentID `json:"torrentId"`
 	FileIndex   int       `json:"fileIndex"`
 	Position    float64   `json:"position"`
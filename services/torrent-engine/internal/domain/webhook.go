@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// Webhook posts events to an external URL. Deliveries are signed with
+// Secret (HMAC-SHA256) when it is set.
+type Webhook struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+	// Events selects the event types delivered; empty delivers all.
+	Events    []EventType `json:"events,omitempty"`
+	Secret    string      `json:"-"`
+	Enabled   bool        `json:"enabled"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// Wants reports whether the webhook delivers events of type t.
+func (w Webhook) Wants(t EventType) bool {
+	return w.Enabled && (len(w.Events) == 0 || slices.Contains(w.Events, t))
+}
+
+// WebhookDelivery is the outcome of delivering one event to a webhook,
+// after all attempts.
+type WebhookDelivery struct {
+	ID         string    `json:"id"`
+	WebhookID  string    `json:"webhookId"`
+	Event      Event     `json:"event"`
+	Success    bool      `json:"success"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Duration   int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrInvalidWebSeed = errors.New("invalid web seed url")
+
+// WebSeed is a BEP 19 web seed of a torrent and its transfer statistics.
+type WebSeed struct {
+	URL             string     `json:"url"`
+	BytesDownloaded int64      `json:"bytesDownloaded"`
+	Requests        int64      `json:"requests"`
+	Errors          int64      `json:"errors"`
+	LastError       string     `json:"lastError,omitempty"`
+	LastErrorAt     *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// NormalizeWebSeedURL validates an HTTP(S) or FTP web seed URL and returns
+// it trimmed. Per BEP 19 a URL ending in "/" is a directory the torrent name
+// and file paths are appended to.
+func NormalizeWebSeedURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", ErrInvalidWebSeed
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp":
+		return raw, nil
+	}
+	return "", ErrInvalidWebSeed
+}
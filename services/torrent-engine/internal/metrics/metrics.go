@@ -186,6 +186,18 @@ var (
 		Help:      "Duration of piece re-verification phase after restart.",
 		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300},
 	})
+
+	TorrentEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "engine",
+		Name:      "torrent_events_total",
+		Help:      "Total torrent events by type.",
+	}, []string{"type"})
+
+	EventsDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "engine",
+		Name:      "events_dropped_total",
+		Help:      "Total events dropped because a subscriber fell behind, by subscriber.",
+	}, []string{"subscriber"})
 )
 
 func Register(reg prometheus.Registerer) {
@@ -219,5 +231,7 @@ func Register(reg prometheus.Registerer) {
 		HLSTTFFSeconds,
 		HLSPrebufferDuration,
 		VerifyDuration,
+		TorrentEventsTotal,
+		EventsDroppedTotal,
 	)
 }
@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"torrentstream/internal/domain"
+)
+
+// eventLogRetention is how long event log entries are kept.
+const eventLogRetention = 90 * 24 * time.Hour
+
+// EventLogRepository stores the event timeline. Entries expire after 90
+// days and outlive the torrents they describe.
+type EventLogRepository struct {
+	events *mongo.Collection
+}
+
+func NewEventLogRepository(client *mongo.Client, dbName string) *EventLogRepository {
+	return &EventLogRepository{events: client.Database(dbName).Collection("torrent_events")}
+}
+
+func (r *EventLogRepository) EnsureIndexes(ctx context.Context) error {
+	if r == nil || r.events == nil {
+		return nil
+	}
+	_, err := r.events.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "torrentId", Value: 1}, {Key: "at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(eventLogRetention / time.Second)),
+		},
+	})
+	return err
+}
+
+func (r *EventLogRepository) AddEvent(ctx context.Context, event domain.Event) error {
+	_, err := r.events.InsertOne(ctx, toEventDoc(event))
+	return err
+}
+
+func (r *EventLogRepository) ListEvents(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := r.events.Find(ctx, eventLogQuery(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []eventDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	events := make([]domain.Event, 0, len(docs))
+	for _, doc := range docs {
+		events = append(events, fromEventDoc(doc))
+	}
+	return events, nil
+}
+
+func eventLogQuery(filter domain.EventFilter) bson.M {
+	query := bson.M{}
+	if filter.TorrentID != "" {
+		query["torrentId"] = string(filter.TorrentID)
+	}
+	if len(filter.Types) > 0 {
+		types := make([]string, 0, len(filter.Types))
+		for _, t := range filter.Types {
+			types = append(types, string(t))
+		}
+		query["type"] = bson.M{"$in": types}
+	}
+	at := bson.M{}
+	if !filter.Since.IsZero() {
+		at["$gte"] = filter.Since.UTC()
+	}
+	if !filter.Until.IsZero() {
+		at["$lt"] = filter.Until.UTC()
+	}
+	if len(at) > 0 {
+		query["at"] = at
+	}
+	return query
+}
@@ -2,4 +2,8 @@ package mongo
 
 import "torrentstream/internal/domain/ports"
 
-var _ ports.TorrentRepository = (*Repository)(nil)
+var (
+	_ ports.TorrentRepository   = (*Repository)(nil)
+	_ ports.StallRepository     = (*Repository)(nil)
+	_ ports.ExtractedRepository = (*Repository)(nil)
+)
@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"torrentstream/internal/domain"
+)
+
+// postProcessRunRetention is how long command runs are kept.
+const postProcessRunRetention = 30 * 24 * time.Hour
+
+type postProcessCommandDoc struct {
+	ID             string   `bson:"_id"`
+	Name           string   `bson:"name,omitempty"`
+	Trigger        string   `bson:"trigger"`
+	Program        string   `bson:"program"`
+	Args           []string `bson:"args,omitempty"`
+	TimeoutSeconds int      `bson:"timeoutSeconds,omitempty"`
+	Enabled        bool     `bson:"enabled"`
+	CreatedAt      int64    `bson:"createdAt"`
+	UpdatedAt      int64    `bson:"updatedAt"`
+}
+
+type postProcessRunDoc struct {
+	ID          string    `bson:"_id"`
+	TorrentID   string    `bson:"torrentId"`
+	CommandID   string    `bson:"commandId"`
+	CommandName string    `bson:"commandName,omitempty"`
+	Trigger     string    `bson:"trigger"`
+	Program     string    `bson:"program"`
+	Args        []string  `bson:"args,omitempty"`
+	Success     bool      `bson:"success"`
+	ExitCode    int       `bson:"exitCode"`
+	Output      string    `bson:"output,omitempty"`
+	Truncated   bool      `bson:"truncated,omitempty"`
+	Error       string    `bson:"error,omitempty"`
+	StartedAt   time.Time `bson:"startedAt"`
+	DurationMs  int64     `bson:"durationMs"`
+}
+
+// PostProcessRepository stores post-processing commands and their runs.
+// Runs expire after 30 days.
+type PostProcessRepository struct {
+	commands *mongo.Collection
+	runs     *mongo.Collection
+}
+
+func NewPostProcessRepository(client *mongo.Client, dbName string) *PostProcessRepository {
+	db := client.Database(dbName)
+	return &PostProcessRepository{
+		commands: db.Collection("post_process_commands"),
+		runs:     db.Collection("post_process_runs"),
+	}
+}
+
+func (r *PostProcessRepository) EnsureIndexes(ctx context.Context) error {
+	if r == nil || r.runs == nil {
+		return nil
+	}
+	_, err := r.runs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "torrentId", Value: 1}, {Key: "startedAt", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "startedAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(postProcessRunRetention / time.Second)),
+		},
+	})
+	return err
+}
+
+func (r *PostProcessRepository) ListPostProcessCommands(ctx context.Context) ([]domain.PostProcessCommand, error) {
+	cursor, err := r.commands.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []postProcessCommandDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	cmds := make([]domain.PostProcessCommand, 0, len(docs))
+	for _, doc := range docs {
+		cmds = append(cmds, fromPostProcessCommandDoc(doc))
+	}
+	return cmds, nil
+}
+
+func (r *PostProcessRepository) GetPostProcessCommand(ctx context.Context, id string) (domain.PostProcessCommand, error) {
+	var doc postProcessCommandDoc
+	err := r.commands.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.PostProcessCommand{}, domain.ErrNotFound
+		}
+		return domain.PostProcessCommand{}, err
+	}
+	return fromPostProcessCommandDoc(doc), nil
+}
+
+func (r *PostProcessRepository) SavePostProcessCommand(ctx context.Context, cmd domain.PostProcessCommand) error {
+	_, err := r.commands.ReplaceOne(
+		ctx,
+		bson.M{"_id": cmd.ID},
+		toPostProcessCommandDoc(cmd),
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *PostProcessRepository) DeletePostProcessCommand(ctx context.Context, id string) error {
+	res, err := r.commands.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostProcessRepository) AddPostProcessRun(ctx context.Context, run domain.PostProcessRun) error {
+	_, err := r.runs.InsertOne(ctx, toPostProcessRunDoc(run))
+	return err
+}
+
+func (r *PostProcessRepository) ListPostProcessRuns(ctx context.Context, id domain.TorrentID, limit int) ([]domain.PostProcessRun, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "startedAt", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := r.runs.Find(ctx, bson.M{"torrentId": string(id)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []postProcessRunDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	runs := make([]domain.PostProcessRun, 0, len(docs))
+	for _, doc := range docs {
+		runs = append(runs, fromPostProcessRunDoc(doc))
+	}
+	return runs, nil
+}
+
+func toPostProcessCommandDoc(cmd domain.PostProcessCommand) postProcessCommandDoc {
+	return postProcessCommandDoc{
+		ID:             cmd.ID,
+		Name:           cmd.Name,
+		Trigger:        string(cmd.Trigger),
+		Program:        cmd.Program,
+		Args:           cmd.Args,
+		TimeoutSeconds: cmd.TimeoutSeconds,
+		Enabled:        cmd.Enabled,
+		CreatedAt:      cmd.CreatedAt.Unix(),
+		UpdatedAt:      cmd.UpdatedAt.Unix(),
+	}
+}
+
+func fromPostProcessCommandDoc(doc postProcessCommandDoc) domain.PostProcessCommand {
+	return domain.PostProcessCommand{
+		ID:             doc.ID,
+		Name:           doc.Name,
+		Trigger:        domain.EventType(doc.Trigger),
+		Program:        doc.Program,
+		Args:           doc.Args,
+		TimeoutSeconds: doc.TimeoutSeconds,
+		Enabled:        doc.Enabled,
+		CreatedAt:      timeFromUnix(doc.CreatedAt),
+		UpdatedAt:      timeFromUnix(doc.UpdatedAt),
+	}
+}
+
+func toPostProcessRunDoc(run domain.PostProcessRun) postProcessRunDoc {
+	return postProcessRunDoc{
+		ID:          run.ID,
+		TorrentID:   string(run.TorrentID),
+		CommandID:   run.CommandID,
+		CommandName: run.CommandName,
+		Trigger:     string(run.Trigger),
+		Program:     run.Program,
+		Args:        run.Args,
+		Success:     run.Success,
+		ExitCode:    run.ExitCode,
+		Output:      run.Output,
+		Truncated:   run.Truncated,
+		Error:       run.Error,
+		StartedAt:   run.StartedAt.UTC(),
+		DurationMs:  run.Duration,
+	}
+}
+
+func fromPostProcessRunDoc(doc postProcessRunDoc) domain.PostProcessRun {
+	return domain.PostProcessRun{
+		ID:          doc.ID,
+		TorrentID:   domain.TorrentID(doc.TorrentID),
+		CommandID:   doc.CommandID,
+		CommandName: doc.CommandName,
+		Trigger:     domain.EventType(doc.Trigger),
+		Program:     doc.Program,
+		Args:        doc.Args,
+		Success:     doc.Success,
+		ExitCode:    doc.ExitCode,
+		Output:      doc.Output,
+		Truncated:   doc.Truncated,
+		Error:       doc.Error,
+		StartedAt:   doc.StartedAt.UTC(),
+		Duration:    doc.DurationMs,
+	}
+}
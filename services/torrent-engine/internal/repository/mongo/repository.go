@@ -23,37 +23,64 @@ type fileDoc struct {
 	Path           string `bson:"path"`
 	Length         int64  `bson:"length"`
 	BytesCompleted int64  `bson:"bytesCompleted,omitempty"`
+	PiecesRoot     string `bson:"piecesRoot,omitempty"`
 }
 
 type torrentDoc struct {
-	ID         string    `bson:"_id"`
-	Name       string    `bson:"name"`
-	Status     string    `bson:"status"`
-	InfoHash   string    `bson:"infoHash"`
-	Magnet     string    `bson:"magnet"`
-	Torrent    string    `bson:"torrent"`
-	Files      []fileDoc `bson:"files"`
-	TotalBytes int64     `bson:"totalBytes"`
-	DoneBytes  int64     `bson:"doneBytes"`
-	Progress   float64   `bson:"progress"` // Cached progress for efficient sorting (0.0-1.0).
-	CreatedAt  int64     `bson:"createdAt"`
-	UpdatedAt  int64     `bson:"updatedAt"`
-	Tags       []string  `bson:"tags,omitempty"`
+	ID          string    `bson:"_id"`
+	Name        string    `bson:"name"`
+	Status      string    `bson:"status"`
+	InfoHash    string    `bson:"infoHash"`
+	InfoHashV2  string    `bson:"infoHashV2,omitempty"`
+	Private     bool      `bson:"private,omitempty"`
+	Magnet      string    `bson:"magnet"`
+	Torrent     string    `bson:"torrent"`
+	DataDir     string    `bson:"dataDir,omitempty"`
+	WebSeeds    []string  `bson:"webSeeds,omitempty"`
+	Trackers    []string  `bson:"trackers,omitempty"`
+	Peers       []string  `bson:"peers,omitempty"`
+	Selected    []int     `bson:"selectedFiles,omitempty"`
+	Files       []fileDoc `bson:"files"`
+	TotalBytes  int64     `bson:"totalBytes"`
+	DoneBytes   int64     `bson:"doneBytes"`
+	Progress    float64   `bson:"progress"` // Cached progress for efficient sorting (0.0-1.0).
+	CreatedAt   int64     `bson:"createdAt"`
+	UpdatedAt   int64     `bson:"updatedAt"`
+	Tags        []string  `bson:"tags,omitempty"`
+	DeletedAt   int64     `bson:"deletedAt,omitempty"`
+	DeleteFiles bool      `bson:"deleteFiles,omitempty"`
+	// Written on create and by SetCategory.
+	Category string `bson:"category,omitempty"`
+	// Written only by SetStalled and ClearStalled.
+	StalledSince  int64  `bson:"stalledSince,omitempty"`
+	StalledReason string `bson:"stalledReason,omitempty"`
+	// Written only by SetExtracted.
+	Extracted []fileDoc `bson:"extracted,omitempty"`
+	Unpacked  bool      `bson:"unpacked,omitempty"`
 }
 
 type torrentUpdateDoc struct {
-	Name       string    `bson:"name"`
-	Status     string    `bson:"status"`
-	InfoHash   string    `bson:"infoHash"`
-	Magnet     string    `bson:"magnet"`
-	Torrent    string    `bson:"torrent"`
-	Files      []fileDoc `bson:"files"`
-	TotalBytes int64     `bson:"totalBytes"`
-	DoneBytes  int64     `bson:"doneBytes"`
-	Progress   float64   `bson:"progress"`
-	CreatedAt  int64     `bson:"createdAt"`
-	UpdatedAt  int64     `bson:"updatedAt"`
-	Tags       []string  `bson:"tags,omitempty"`
+	Name        string    `bson:"name"`
+	Status      string    `bson:"status"`
+	InfoHash    string    `bson:"infoHash"`
+	InfoHashV2  string    `bson:"infoHashV2,omitempty"`
+	Private     bool      `bson:"private,omitempty"`
+	Magnet      string    `bson:"magnet"`
+	Torrent     string    `bson:"torrent"`
+	DataDir     string    `bson:"dataDir,omitempty"`
+	WebSeeds    []string  `bson:"webSeeds"`
+	Trackers    []string  `bson:"trackers"`
+	Peers       []string  `bson:"peers"`
+	Selected    []int     `bson:"selectedFiles"`
+	Files       []fileDoc `bson:"files"`
+	TotalBytes  int64     `bson:"totalBytes"`
+	DoneBytes   int64     `bson:"doneBytes"`
+	Progress    float64   `bson:"progress"`
+	CreatedAt   int64     `bson:"createdAt"`
+	UpdatedAt   int64     `bson:"updatedAt"`
+	Tags        []string  `bson:"tags,omitempty"`
+	DeletedAt   int64     `bson:"deletedAt,omitempty"`
+	DeleteFiles bool      `bson:"deleteFiles,omitempty"`
 }
 
 func NewRepository(client *mongo.Client, dbName, collectionName string) *Repository {
@@ -79,6 +106,10 @@ func (r *Repository) EnsureIndexes(ctx context.Context) error {
 		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
 		{Keys: bson.D{{Key: "updatedAt", Value: -1}}},
 		{Keys: bson.D{{Key: "progress", Value: -1}}},
+		{Keys: bson.D{{Key: "deletedAt", Value: 1}}},
+		{Keys: bson.D{{Key: "infoHash", Value: 1}}},
+		{Keys: bson.D{{Key: "infoHashV2", Value: 1}}},
+		{Keys: bson.D{{Key: "category", Value: 1}}},
 	}
 	_, err := r.collection.Indexes().CreateMany(ctx, models)
 	return err
@@ -98,7 +129,12 @@ func (r *Repository) Create(ctx context.Context, t domain.TorrentRecord) error {
 func (r *Repository) Update(ctx context.Context, t domain.TorrentRecord) error {
 	doc := toUpdateDoc(t)
 	filter := bson.M{"_id": string(t.ID)}
-	res, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": doc})
+	update := bson.M{"$set": doc}
+	if t.DeletedAt == nil {
+		// Restoring from the trash clears the soft-delete markers.
+		update["$unset"] = bson.M{"deletedAt": "", "deleteFiles": ""}
+	}
+	res, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
@@ -125,6 +161,15 @@ func (r *Repository) UpdateProgress(ctx context.Context, id domain.TorrentID, up
 	if update.Name != "" {
 		setFields["name"] = update.Name
 	}
+	if update.InfoHash != "" {
+		setFields["infoHash"] = string(update.InfoHash)
+	}
+	if update.InfoHashV2 != "" {
+		setFields["infoHashV2"] = string(update.InfoHashV2)
+	}
+	if update.Private {
+		setFields["private"] = true
+	}
 
 	// Compute progress for efficient DB sorting.
 	if update.TotalBytes > 0 {
@@ -143,6 +188,7 @@ func (r *Repository) UpdateProgress(ctx context.Context, id domain.TorrentID, up
 				Path:           f.Path,
 				Length:         f.Length,
 				BytesCompleted: f.BytesCompleted,
+				PiecesRoot:     f.PiecesRoot,
 			})
 		}
 		setFields["files"] = files
@@ -178,9 +224,62 @@ func (r *Repository) UpdateTags(ctx context.Context, id domain.TorrentID, tags [
 	return nil
 }
 
+// SetCategory moves the torrent to category; an empty category removes it.
+func (r *Repository) SetCategory(ctx context.Context, id domain.TorrentID, category string) error {
+	if category == "" {
+		return r.updateFields(ctx, id, bson.M{"$unset": bson.M{"category": ""}})
+	}
+	return r.updateFields(ctx, id, bson.M{"$set": bson.M{"category": category}})
+}
+
+func (r *Repository) SetStalled(ctx context.Context, id domain.TorrentID, since time.Time, reason domain.StallReason) error {
+	return r.updateFields(ctx, id, bson.M{"$set": bson.M{
+		"stalledSince":  since.UTC().Unix(),
+		"stalledReason": string(reason),
+	}})
+}
+
+func (r *Repository) ClearStalled(ctx context.Context, id domain.TorrentID) error {
+	return r.updateFields(ctx, id, bson.M{"$unset": bson.M{"stalledSince": "", "stalledReason": ""}})
+}
+
+// SetExtracted records the files unpacked from the torrent's archives.
+func (r *Repository) SetExtracted(ctx context.Context, id domain.TorrentID, files []domain.FileRef, unpacked bool) error {
+	docs := make([]fileDoc, 0, len(files))
+	for _, f := range files {
+		docs = append(docs, fileDoc{
+			Index:          f.Index,
+			Path:           f.Path,
+			Length:         f.Length,
+			BytesCompleted: f.BytesCompleted,
+		})
+	}
+	return r.updateFields(ctx, id, bson.M{"$set": bson.M{"extracted": docs, "unpacked": unpacked}})
+}
+
+func (r *Repository) updateFields(ctx context.Context, id domain.TorrentID, update bson.M) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": string(id)}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
 func (r *Repository) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return r.findOne(ctx, id, false)
+}
+
+func (r *Repository) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return r.findOne(ctx, id, true)
+}
+
+func (r *Repository) findOne(ctx context.Context, id domain.TorrentID, trashed bool) (domain.TorrentRecord, error) {
+	query := bson.M{"_id": string(id), "deletedAt": bson.M{"$exists": trashed}}
 	var doc torrentDoc
-	if err := r.collection.FindOne(ctx, bson.M{"_id": string(id)}).Decode(&doc); err != nil {
+	if err := r.collection.FindOne(ctx, query).Decode(&doc); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return domain.TorrentRecord{}, domain.ErrNotFound
 		}
@@ -191,9 +290,20 @@ func (r *Repository) Get(ctx context.Context, id domain.TorrentID) (domain.Torre
 
 func (r *Repository) List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error) {
 	query := bson.M{}
+	if filter.Trashed {
+		query["deletedAt"] = bson.M{"$exists": true}
+	} else {
+		query["deletedAt"] = bson.M{"$exists": false}
+	}
 	if filter.Status != nil {
 		query["status"] = string(*filter.Status)
 	}
+	if hash := strings.TrimSpace(string(filter.InfoHash)); hash != "" {
+		query["$or"] = bson.A{
+			bson.M{"infoHash": hash},
+			bson.M{"infoHashV2": hash},
+		}
+	}
 
 	search := strings.TrimSpace(filter.Search)
 	if search != "" {
@@ -207,6 +317,13 @@ func (r *Repository) List(ctx context.Context, filter domain.TorrentFilter) ([]d
 	if len(tags) > 0 {
 		query["tags"] = bson.M{"$all": tags}
 	}
+	if filter.Category != nil {
+		if *filter.Category == "" {
+			query["category"] = bson.M{"$exists": false}
+		} else {
+			query["category"] = *filter.Category
+		}
+	}
 
 	sortBy := strings.TrimSpace(filter.SortBy)
 	if sortBy == "" {
@@ -290,6 +407,17 @@ func toDoc(t domain.TorrentRecord) torrentDoc {
 			Path:           f.Path,
 			Length:         f.Length,
 			BytesCompleted: f.BytesCompleted,
+			PiecesRoot:     f.PiecesRoot,
+		})
+	}
+
+	var extracted []fileDoc
+	for _, f := range t.Extracted {
+		extracted = append(extracted, fileDoc{
+			Index:          f.Index,
+			Path:           f.Path,
+			Length:         f.Length,
+			BytesCompleted: f.BytesCompleted,
 		})
 	}
 
@@ -299,19 +427,33 @@ func toDoc(t domain.TorrentRecord) torrentDoc {
 	}
 
 	return torrentDoc{
-		ID:         string(t.ID),
-		Name:       t.Name,
-		Status:     string(t.Status),
-		InfoHash:   string(t.InfoHash),
-		Magnet:     t.Source.Magnet,
-		Torrent:    t.Source.Torrent,
-		Files:      files,
-		TotalBytes: t.TotalBytes,
-		DoneBytes:  t.DoneBytes,
-		Progress:   progress,
-		CreatedAt:  t.CreatedAt.Unix(),
-		UpdatedAt:  t.UpdatedAt.Unix(),
-		Tags:       normalizeTags(t.Tags),
+		ID:            string(t.ID),
+		Name:          t.Name,
+		Status:        string(t.Status),
+		InfoHash:      string(t.InfoHash),
+		InfoHashV2:    string(t.InfoHashV2),
+		Private:       t.Private,
+		Magnet:        t.Source.Magnet,
+		Torrent:       t.Source.Torrent,
+		DataDir:       t.Source.DataDir,
+		WebSeeds:      t.Source.WebSeeds,
+		Trackers:      t.Source.Trackers,
+		Peers:         t.Source.Peers,
+		Selected:      t.Source.SelectedFiles,
+		Files:         files,
+		TotalBytes:    t.TotalBytes,
+		DoneBytes:     t.DoneBytes,
+		Progress:      progress,
+		CreatedAt:     t.CreatedAt.Unix(),
+		UpdatedAt:     t.UpdatedAt.Unix(),
+		Tags:          normalizeTags(t.Tags),
+		DeletedAt:     unixOrZero(t.DeletedAt),
+		DeleteFiles:   t.DeleteFiles,
+		Category:      t.Category,
+		StalledSince:  unixOrZero(t.StalledSince),
+		StalledReason: string(t.StalledReason),
+		Extracted:     extracted,
+		Unpacked:      t.Unpacked,
 	}
 }
 
@@ -323,6 +465,7 @@ func toUpdateDoc(t domain.TorrentRecord) torrentUpdateDoc {
 			Path:           f.Path,
 			Length:         f.Length,
 			BytesCompleted: f.BytesCompleted,
+			PiecesRoot:     f.PiecesRoot,
 		})
 	}
 
@@ -333,18 +476,27 @@ func toUpdateDoc(t domain.TorrentRecord) torrentUpdateDoc {
 	}
 
 	return torrentUpdateDoc{
-		Name:       t.Name,
-		Status:     string(t.Status),
-		InfoHash:   string(t.InfoHash),
-		Magnet:     t.Source.Magnet,
-		Torrent:    t.Source.Torrent,
-		Files:      files,
-		TotalBytes: t.TotalBytes,
-		DoneBytes:  t.DoneBytes,
-		Progress:   progress,
-		CreatedAt:  t.CreatedAt.Unix(),
-		UpdatedAt:  t.UpdatedAt.Unix(),
-		Tags:       normalizeTags(t.Tags),
+		Name:        t.Name,
+		Status:      string(t.Status),
+		InfoHash:    string(t.InfoHash),
+		InfoHashV2:  string(t.InfoHashV2),
+		Private:     t.Private,
+		Magnet:      t.Source.Magnet,
+		Torrent:     t.Source.Torrent,
+		DataDir:     t.Source.DataDir,
+		WebSeeds:    t.Source.WebSeeds,
+		Trackers:    t.Source.Trackers,
+		Peers:       t.Source.Peers,
+		Selected:    t.Source.SelectedFiles,
+		Files:       files,
+		TotalBytes:  t.TotalBytes,
+		DoneBytes:   t.DoneBytes,
+		Progress:    progress,
+		CreatedAt:   t.CreatedAt.Unix(),
+		UpdatedAt:   t.UpdatedAt.Unix(),
+		Tags:        normalizeTags(t.Tags),
+		DeletedAt:   unixOrZero(t.DeletedAt),
+		DeleteFiles: t.DeleteFiles,
 	}
 }
 
@@ -356,21 +508,64 @@ func fromDoc(doc torrentDoc) domain.TorrentRecord {
 			Path:           f.Path,
 			Length:         f.Length,
 			BytesCompleted: f.BytesCompleted,
+			PiecesRoot:     f.PiecesRoot,
+		})
+	}
+
+	var extracted []domain.FileRef
+	for _, f := range doc.Extracted {
+		extracted = append(extracted, domain.FileRef{
+			Index:          f.Index,
+			Path:           f.Path,
+			Length:         f.Length,
+			BytesCompleted: f.BytesCompleted,
+			Progress:       1,
 		})
 	}
 
+	var deletedAt *time.Time
+	if doc.DeletedAt > 0 {
+		at := timeFromUnix(doc.DeletedAt)
+		deletedAt = &at
+	}
+
+	var stalledSince *time.Time
+	if doc.StalledSince > 0 {
+		at := timeFromUnix(doc.StalledSince)
+		stalledSince = &at
+	}
+
+	source := domain.TorrentSource{
+		Magnet:        doc.Magnet,
+		Torrent:       doc.Torrent,
+		DataDir:       doc.DataDir,
+		WebSeeds:      doc.WebSeeds,
+		Trackers:      doc.Trackers,
+		Peers:         doc.Peers,
+		SelectedFiles: doc.Selected,
+	}
+
 	return domain.TorrentRecord{
-		ID:         domain.TorrentID(doc.ID),
-		Name:       doc.Name,
-		Status:     domain.TorrentStatus(doc.Status),
-		InfoHash:   domain.InfoHash(doc.InfoHash),
-		Source:     domain.TorrentSource{Magnet: doc.Magnet, Torrent: doc.Torrent},
-		Files:      files,
-		TotalBytes: doc.TotalBytes,
-		DoneBytes:  doc.DoneBytes,
-		CreatedAt:  timeFromUnix(doc.CreatedAt),
-		UpdatedAt:  timeFromUnix(doc.UpdatedAt),
-		Tags:       normalizeTags(doc.Tags),
+		ID:            domain.TorrentID(doc.ID),
+		Name:          doc.Name,
+		Status:        domain.TorrentStatus(doc.Status),
+		InfoHash:      domain.InfoHash(doc.InfoHash),
+		InfoHashV2:    domain.InfoHash(doc.InfoHashV2),
+		Private:       doc.Private,
+		Source:        source,
+		Files:         files,
+		TotalBytes:    doc.TotalBytes,
+		DoneBytes:     doc.DoneBytes,
+		CreatedAt:     timeFromUnix(doc.CreatedAt),
+		UpdatedAt:     timeFromUnix(doc.UpdatedAt),
+		Tags:          normalizeTags(doc.Tags),
+		DeletedAt:     deletedAt,
+		DeleteFiles:   doc.DeleteFiles,
+		Category:      doc.Category,
+		StalledSince:  stalledSince,
+		StalledReason: domain.StallReason(doc.StalledReason),
+		Extracted:     extracted,
+		Unpacked:      doc.Unpacked,
 	}
 }
 
@@ -386,6 +581,13 @@ func timeFromUnix(value int64) time.Time {
 	return time.Unix(value, 0).UTC()
 }
 
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
 func normalizeTags(tags []string) []string {
 	if len(tags) == 0 {
 		return nil
@@ -167,6 +167,30 @@ func TestIntegrationGetNotFound(t *testing.T) {
 	}
 }
 
+func TestIntegrationGetSkipsTrash(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	deletedAt := time.Now().UTC().Truncate(time.Millisecond)
+	if err := repo.Create(ctx, domain.TorrentRecord{ID: "live", Name: "Live"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Create(ctx, domain.TorrentRecord{ID: "trashed", Name: "Trashed", DeletedAt: &deletedAt}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := repo.Get(ctx, "trashed"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Get trashed: expected ErrNotFound, got %v", err)
+	}
+	if got, err := repo.GetTrashed(ctx, "trashed"); err != nil || !got.InTrash() {
+		t.Errorf("GetTrashed: got %+v, %v", got, err)
+	}
+	if _, err := repo.GetTrashed(ctx, "live"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetTrashed live: expected ErrNotFound, got %v", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Update
 // ---------------------------------------------------------------------------
@@ -215,6 +239,45 @@ func TestIntegrationUpdateNotFound(t *testing.T) {
 	}
 }
 
+func TestIntegrationStallFlag(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	rec := makeTorrent("stall1", domain.TorrentPending)
+	if err := repo.Create(ctx, rec); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	since := time.Now().UTC().Truncate(time.Second)
+	if err := repo.SetStalled(ctx, "stall1", since, domain.StallNoMetadata); err != nil {
+		t.Fatalf("SetStalled: %v", err)
+	}
+	rec.Name = "Renamed"
+	if err := repo.Update(ctx, rec); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := repo.Get(ctx, "stall1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.StalledSince == nil || !got.StalledSince.Equal(since) || got.StalledReason != domain.StallNoMetadata {
+		t.Fatalf("stall flag lost: %v %q", got.StalledSince, got.StalledReason)
+	}
+
+	if err := repo.ClearStalled(ctx, "stall1"); err != nil {
+		t.Fatalf("ClearStalled: %v", err)
+	}
+	got, _ = repo.Get(ctx, "stall1")
+	if got.StalledSince != nil || got.StalledReason != "" {
+		t.Fatalf("stall flag not cleared: %v %q", got.StalledSince, got.StalledReason)
+	}
+
+	if err := repo.SetStalled(ctx, "ghost", since, domain.StallNoPeers); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // UpdateProgress — atomic $max behavior
 // ---------------------------------------------------------------------------
@@ -417,6 +480,30 @@ func TestIntegrationListFilterStatus(t *testing.T) {
 	}
 }
 
+func TestIntegrationListFilterInfoHash(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	hybrid := makeTorrent("hybrid", domain.TorrentActive)
+	hybrid.InfoHashV2 = "hash_v2"
+	for _, rec := range []domain.TorrentRecord{hybrid, makeTorrent("other", domain.TorrentActive)} {
+		if err := repo.Create(ctx, rec); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	for _, hash := range []domain.InfoHash{"hash_hybrid", "hash_v2"} {
+		results, err := repo.List(ctx, domain.TorrentFilter{InfoHash: hash})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != "hybrid" {
+			t.Fatalf("List(%q) = %+v", hash, results)
+		}
+	}
+}
+
 func TestIntegrationListSearch(t *testing.T) {
 	repo, cleanup := setupTestRepo(t)
 	defer cleanup()
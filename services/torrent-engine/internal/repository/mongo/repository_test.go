@@ -18,13 +18,15 @@ import (
 func TestToDocFromDocRoundtrip(t *testing.T) {
 	now := time.Date(2026, 2, 19, 10, 0, 0, 0, time.UTC)
 	record := domain.TorrentRecord{
-		ID:       "abc123",
-		Name:     "Big Buck Bunny",
-		Status:   domain.TorrentActive,
-		InfoHash: "d2354e",
-		Source:   domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:d2354e"},
+		ID:         "abc123",
+		Name:       "Big Buck Bunny",
+		Status:     domain.TorrentActive,
+		InfoHash:   "d2354e",
+		InfoHashV2: "9f86d081",
+		Private:    true,
+		Source:     domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:d2354e"},
 		Files: []domain.FileRef{
-			{Index: 0, Path: "video.mkv", Length: 1024, BytesCompleted: 512},
+			{Index: 0, Path: "video.mkv", Length: 1024, BytesCompleted: 512, PiecesRoot: "e3b0c442"},
 			{Index: 1, Path: "subs.srt", Length: 4096, BytesCompleted: 4096},
 		},
 		TotalBytes: 5120,
@@ -49,6 +51,12 @@ func TestToDocFromDocRoundtrip(t *testing.T) {
 	if got.InfoHash != record.InfoHash {
 		t.Errorf("InfoHash: got %q, want %q", got.InfoHash, record.InfoHash)
 	}
+	if got.InfoHashV2 != record.InfoHashV2 {
+		t.Errorf("InfoHashV2: got %q, want %q", got.InfoHashV2, record.InfoHashV2)
+	}
+	if !got.Private {
+		t.Errorf("Private: got false, want true")
+	}
 	if got.Source.Magnet != record.Source.Magnet {
 		t.Errorf("Magnet: got %q, want %q", got.Source.Magnet, record.Source.Magnet)
 	}
@@ -83,7 +91,14 @@ func TestToDocWithTorrentFileSource(t *testing.T) {
 		ID:     "t1",
 		Name:   "Test",
 		Status: domain.TorrentPending,
-		Source: domain.TorrentSource{Torrent: "/path/to/file.torrent"},
+		Source: domain.TorrentSource{
+			Torrent:       "/path/to/file.torrent",
+			DataDir:       "/mnt/disk2",
+			WebSeeds:      []string{"http://mirror.local/data/"},
+			Trackers:      []string{"udp://tracker.local:6969/announce"},
+			Peers:         []string{"10.0.0.2:6881"},
+			SelectedFiles: []int{0, 2},
+		},
 	}
 
 	doc := toDoc(record)
@@ -98,6 +113,15 @@ func TestToDocWithTorrentFileSource(t *testing.T) {
 	if got.Source.Torrent != record.Source.Torrent {
 		t.Errorf("Source.Torrent roundtrip: got %q, want %q", got.Source.Torrent, record.Source.Torrent)
 	}
+	if got.Source.DataDir != record.Source.DataDir {
+		t.Errorf("Source.DataDir roundtrip: got %q, want %q", got.Source.DataDir, record.Source.DataDir)
+	}
+	if len(got.Source.WebSeeds) != 1 || got.Source.WebSeeds[0] != "http://mirror.local/data/" {
+		t.Errorf("Source.WebSeeds roundtrip: got %v", got.Source.WebSeeds)
+	}
+	if len(got.Source.Trackers) != 1 || len(got.Source.Peers) != 1 || len(got.Source.SelectedFiles) != 2 {
+		t.Errorf("magnet hints roundtrip: got %+v", got.Source)
+	}
 }
 
 func TestToDocEmptyFiles(t *testing.T) {
@@ -222,6 +246,114 @@ func TestToUpdateDocAllFieldsPresent(t *testing.T) {
 	}
 }
 
+func TestToDocFromDocTrashRoundtrip(t *testing.T) {
+	deletedAt := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+	rec := domain.TorrentRecord{
+		ID: "t1", Name: "Movie", Status: domain.TorrentStopped,
+		DeletedAt: &deletedAt, DeleteFiles: true,
+	}
+
+	got := fromDoc(toDoc(rec))
+	if got.DeletedAt == nil || !got.DeletedAt.Equal(deletedAt) {
+		t.Fatalf("DeletedAt: got %v, want %v", got.DeletedAt, deletedAt)
+	}
+	if !got.DeleteFiles {
+		t.Fatalf("DeleteFiles not preserved")
+	}
+
+	rec.DeletedAt = nil
+	rec.DeleteFiles = false
+	raw, err := bson.Marshal(toUpdateDoc(rec))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := doc["deletedAt"]; ok {
+		t.Fatalf("deletedAt must be omitted for records outside the trash")
+	}
+	if got := fromDoc(toDoc(rec)); got.InTrash() {
+		t.Fatalf("record must not be in trash")
+	}
+}
+
+func TestToDocFromDocStallRoundtrip(t *testing.T) {
+	since := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	rec := domain.TorrentRecord{ID: "t1", StalledSince: &since, StalledReason: domain.StallNoPeers}
+
+	got := fromDoc(toDoc(rec))
+	if got.StalledSince == nil || !got.StalledSince.Equal(since) || got.StalledReason != domain.StallNoPeers {
+		t.Fatalf("stall: got %v %q", got.StalledSince, got.StalledReason)
+	}
+
+	// Full updates must not overwrite the detector's flag.
+	raw, err := bson.Marshal(toUpdateDoc(rec))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := doc["stalledSince"]; ok {
+		t.Fatalf("stalledSince must not be part of the update doc")
+	}
+}
+
+func TestToDocFromDocCategoryRoundtrip(t *testing.T) {
+	rec := domain.TorrentRecord{ID: "t1", Category: "tv-sonarr"}
+
+	if got := fromDoc(toDoc(rec)); got.Category != "tv-sonarr" {
+		t.Fatalf("category: got %q", got.Category)
+	}
+
+	// Categories change through SetCategory only.
+	raw, err := bson.Marshal(toUpdateDoc(rec))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := doc["category"]; ok {
+		t.Fatalf("category must not be part of the update doc")
+	}
+}
+
+func TestToDocFromDocExtractedRoundtrip(t *testing.T) {
+	rec := domain.TorrentRecord{
+		ID:        "t1",
+		Files:     []domain.FileRef{{Index: 0, Path: "Movie/movie.rar", Length: 50}},
+		Extracted: []domain.FileRef{{Index: 1, Path: "Movie/movie.mkv", Length: 100, BytesCompleted: 100}},
+		Unpacked:  true,
+	}
+
+	got := fromDoc(toDoc(rec))
+	if !got.Unpacked {
+		t.Fatalf("Unpacked not preserved")
+	}
+	if len(got.Extracted) != 1 || got.Extracted[0].Index != 1 || got.Extracted[0].Path != "Movie/movie.mkv" ||
+		got.Extracted[0].BytesCompleted != 100 || got.Extracted[0].Progress != 1 {
+		t.Fatalf("extracted = %+v", got.Extracted)
+	}
+
+	// Full updates must not drop the unpacked files.
+	raw, err := bson.Marshal(toUpdateDoc(rec))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := doc["extracted"]; ok {
+		t.Fatalf("extracted must not be part of the update doc")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // normalizeTags
 // ---------------------------------------------------------------------------
@@ -443,3 +575,204 @@ func TestEnsureIndexesNilCollection(t *testing.T) {
 		t.Errorf("expected nil error for nil collection, got %v", err)
 	}
 }
+
+func TestStatsSampleDocRoundtrip(t *testing.T) {
+	sample := domain.StatsSample{
+		Time:          time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC),
+		DownloadSpeed: 2048,
+		UploadSpeed:   512,
+		Peers:         7,
+		Progress:      0.25,
+		Downloaded:    1 << 30,
+		Uploaded:      1 << 20,
+	}
+	doc := toStatsSampleDoc("t1", sample)
+	if doc.TorrentID != "t1" {
+		t.Fatalf("torrentId = %q", doc.TorrentID)
+	}
+	if got := fromStatsSampleDoc(doc); !reflect.DeepEqual(got, sample) {
+		t.Fatalf("roundtrip = %+v, want %+v", got, sample)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// webhooks
+// ---------------------------------------------------------------------------
+
+func TestWebhookDocRoundtrip(t *testing.T) {
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	hook := domain.Webhook{
+		ID:        "w1",
+		Name:      "chat",
+		URL:       "https://example.com/hook",
+		Events:    []domain.EventType{domain.EventCompleted, domain.EventError},
+		Secret:    "s3cret",
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now.Add(time.Minute),
+	}
+	if got := fromWebhookDoc(toWebhookDoc(hook)); !reflect.DeepEqual(got, hook) {
+		t.Fatalf("webhook roundtrip:\n got %+v\nwant %+v", got, hook)
+	}
+
+	index := 2
+	delivery := domain.WebhookDelivery{
+		ID:        "d1",
+		WebhookID: "w1",
+		Event: domain.Event{
+			Type:      domain.EventFileCompleted,
+			TorrentID: "t1",
+			Time:      now,
+			FileIndex: &index,
+			Path:      "video.mkv",
+		},
+		Success:    false,
+		Attempts:   3,
+		StatusCode: 502,
+		Error:      "bad gateway",
+		Duration:   1500,
+		CreatedAt:  now,
+	}
+	raw, err := bson.Marshal(toWebhookDeliveryDoc(delivery))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc webhookDeliveryDoc
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := fromWebhookDeliveryDoc(doc); !reflect.DeepEqual(got, delivery) {
+		t.Fatalf("delivery roundtrip:\n got %+v\nwant %+v", got, delivery)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// event log
+// ---------------------------------------------------------------------------
+
+func TestEventDocRoundtrip(t *testing.T) {
+	event := domain.Event{
+		Type:      domain.EventTagsChanged,
+		TorrentID: "t1",
+		Time:      time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC),
+		Reason:    domain.ReasonUser,
+		Source:    "magnet",
+		Tags:      []string{"movie", "4k"},
+	}
+	raw, err := bson.Marshal(toEventDoc(event))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc eventDoc
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := fromEventDoc(doc); !reflect.DeepEqual(got, event) {
+		t.Fatalf("event roundtrip:\n got %+v\nwant %+v", got, event)
+	}
+}
+
+func TestEventLogQuery(t *testing.T) {
+	since := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	got := eventLogQuery(domain.EventFilter{
+		TorrentID: "t1",
+		Types:     []domain.EventType{domain.EventTorrentStopped, domain.EventError},
+		Since:     since,
+	})
+	want := bson.M{
+		"torrentId": "t1",
+		"type":      bson.M{"$in": []string{"torrent_stopped", "error"}},
+		"at":        bson.M{"$gte": since},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("query = %v, want %v", got, want)
+	}
+	if q := eventLogQuery(domain.EventFilter{}); len(q) != 0 {
+		t.Fatalf("empty filter query = %v", q)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// post-processing
+// ---------------------------------------------------------------------------
+
+func TestPostProcessDocRoundtrip(t *testing.T) {
+	now := time.Date(2026, 5, 2, 9, 0, 0, 0, time.UTC)
+	cmd := domain.PostProcessCommand{
+		ID:             "c1",
+		Name:           "scan",
+		Trigger:        domain.EventCompleted,
+		Program:        "/usr/bin/scan",
+		Args:           []string{"{{.SavePath}}"},
+		TimeoutSeconds: 60,
+		Enabled:        true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if got := fromPostProcessCommandDoc(toPostProcessCommandDoc(cmd)); !reflect.DeepEqual(got, cmd) {
+		t.Fatalf("command roundtrip:\n got %+v\nwant %+v", got, cmd)
+	}
+
+	run := domain.PostProcessRun{
+		ID:        "r1",
+		TorrentID: "t1",
+		CommandID: "c1",
+		Trigger:   domain.EventCompleted,
+		Program:   "/usr/bin/scan",
+		Args:      []string{"/data"},
+		ExitCode:  1,
+		Output:    "failed\n",
+		Truncated: true,
+		Error:     "exit status 1",
+		StartedAt: now,
+		Duration:  250,
+	}
+	raw, err := bson.Marshal(toPostProcessRunDoc(run))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc postProcessRunDoc
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := fromPostProcessRunDoc(doc); !reflect.DeepEqual(got, run) {
+		t.Fatalf("run roundtrip:\n got %+v\nwant %+v", got, run)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// users
+// ---------------------------------------------------------------------------
+
+func TestUserDocRoundtrip(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	user := domain.User{
+		ID:           "u1",
+		Username:     "alice",
+		PasswordHash: "$2a$10$hash",
+		Role:         domain.RoleViewer,
+		CreatedAt:    now,
+		UpdatedAt:    now.Add(time.Minute),
+	}
+	if got := fromUserDoc(toUserDoc(user)); !reflect.DeepEqual(got, user) {
+		t.Fatalf("user roundtrip:\n got %+v\nwant %+v", got, user)
+	}
+
+	used := now.Add(time.Hour)
+	for _, key := range []domain.APIKey{
+		{ID: "k1", UserID: "u1", Name: "kodi", Prefix: "tx_abcdef", Hash: "h1", Role: domain.RoleViewer, CreatedAt: now, LastUsedAt: &used},
+		{ID: "k2", UserID: "u1", Prefix: "tx_123456", Hash: "h2", Role: domain.RoleUser, CreatedAt: now},
+	} {
+		raw, err := bson.Marshal(toAPIKeyDoc(key))
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var doc apiKeyDoc
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if got := fromAPIKeyDoc(doc); !reflect.DeepEqual(got, key) {
+			t.Fatalf("api key roundtrip:\n got %+v\nwant %+v", got, key)
+		}
+	}
+}
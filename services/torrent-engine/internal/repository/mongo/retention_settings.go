@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"torrentstream/internal/domain"
+)
+
+const retentionSettingsID = "retention"
+
+type retentionSettingsDoc struct {
+	ID            string   `bson:"_id"`
+	WatchedDays   int      `bson:"watchedDays"`
+	UntouchedDays int      `bson:"untouchedDays"`
+	MaxTotalBytes int64    `bson:"maxTotalBytes"`
+	KeepTags      []string `bson:"keepTags"`
+	KeepTagged    bool     `bson:"keepTagged"`
+	KeepFiles     bool     `bson:"keepFiles"`
+	DryRun        bool     `bson:"dryRun"`
+	UpdatedAt     int64    `bson:"updatedAt"`
+}
+
+type RetentionSettingsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRetentionSettingsRepository(client *mongo.Client, dbName string) *RetentionSettingsRepository {
+	return &RetentionSettingsRepository{collection: client.Database(dbName).Collection("settings")}
+}
+
+func (r *RetentionSettingsRepository) GetRetentionPolicy(ctx context.Context) (domain.RetentionPolicy, bool, error) {
+	var doc retentionSettingsDoc
+	err := r.collection.FindOne(ctx, bson.M{"_id": retentionSettingsID}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.RetentionPolicy{}, false, nil
+		}
+		return domain.RetentionPolicy{}, false, err
+	}
+	return domain.RetentionPolicy{
+		WatchedDays:   doc.WatchedDays,
+		UntouchedDays: doc.UntouchedDays,
+		MaxTotalBytes: doc.MaxTotalBytes,
+		KeepTags:      doc.KeepTags,
+		KeepTagged:    doc.KeepTagged,
+		KeepFiles:     doc.KeepFiles,
+		DryRun:        doc.DryRun,
+	}, true, nil
+}
+
+func (r *RetentionSettingsRepository) SetRetentionPolicy(ctx context.Context, policy domain.RetentionPolicy) error {
+	keepTags := policy.KeepTags
+	if keepTags == nil {
+		keepTags = []string{}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"watchedDays":   policy.WatchedDays,
+			"untouchedDays": policy.UntouchedDays,
+			"maxTotalBytes": policy.MaxTotalBytes,
+			"keepTags":      keepTags,
+			"keepTagged":    policy.KeepTagged,
+			"keepFiles":     policy.KeepFiles,
+			"dryRun":        policy.DryRun,
+			"updatedAt":     time.Now().Unix(),
+		},
+	}
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": retentionSettingsID},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
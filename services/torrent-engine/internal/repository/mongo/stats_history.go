@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"torrentstream/internal/domain"
+)
+
+type statsSampleDoc struct {
+	TorrentID     string    `bson:"torrentId"`
+	At            time.Time `bson:"at"`
+	DownloadSpeed int64     `bson:"downloadSpeed"`
+	UploadSpeed   int64     `bson:"uploadSpeed"`
+	Peers         int       `bson:"peers"`
+	Progress      float64   `bson:"progress"`
+	Downloaded    int64     `bson:"downloaded"`
+	Uploaded      int64     `bson:"uploaded"`
+}
+
+type transferTotalsDoc struct {
+	ID         string `bson:"_id"`
+	Downloaded int64  `bson:"downloaded"`
+	Uploaded   int64  `bson:"uploaded"`
+	UpdatedAt  int64  `bson:"updatedAt"`
+}
+
+// StatsRepository stores down-sampled per-torrent statistics and all-time
+// transfer totals. Samples expire after the configured retention.
+type StatsRepository struct {
+	samples   *mongo.Collection
+	totals    *mongo.Collection
+	retention time.Duration
+}
+
+// NewStatsRepository creates the repository. A positive retention expires
+// samples through a TTL index; totals are kept until the torrent is deleted.
+func NewStatsRepository(client *mongo.Client, dbName string, retention time.Duration) *StatsRepository {
+	db := client.Database(dbName)
+	return &StatsRepository{
+		samples:   db.Collection("torrent_stats"),
+		totals:    db.Collection("torrent_totals"),
+		retention: retention,
+	}
+}
+
+func (r *StatsRepository) EnsureIndexes(ctx context.Context) error {
+	if r == nil || r.samples == nil {
+		return nil
+	}
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "torrentId", Value: 1}, {Key: "at", Value: 1}}},
+	}
+	if r.retention > 0 {
+		models = append(models, mongo.IndexModel{
+			Keys:    bson.D{{Key: "at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(r.retention / time.Second)),
+		})
+	}
+	_, err := r.samples.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func (r *StatsRepository) AppendStatsSample(ctx context.Context, id domain.TorrentID, sample domain.StatsSample) error {
+	_, err := r.samples.InsertOne(ctx, toStatsSampleDoc(id, sample))
+	return err
+}
+
+func (r *StatsRepository) ListStatsSamples(ctx context.Context, id domain.TorrentID, from, to time.Time) ([]domain.StatsSample, error) {
+	filter := bson.M{
+		"torrentId": string(id),
+		"at":        bson.M{"$gte": from.UTC(), "$lte": to.UTC()},
+	}
+	cursor, err := r.samples.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []statsSampleDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	samples := make([]domain.StatsSample, 0, len(docs))
+	for _, doc := range docs {
+		samples = append(samples, fromStatsSampleDoc(doc))
+	}
+	return samples, nil
+}
+
+func (r *StatsRepository) GetTransferTotals(ctx context.Context, id domain.TorrentID) (domain.TransferTotals, error) {
+	var doc transferTotalsDoc
+	err := r.totals.FindOne(ctx, bson.M{"_id": string(id)}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.TransferTotals{}, domain.ErrNotFound
+		}
+		return domain.TransferTotals{}, err
+	}
+	return domain.TransferTotals{
+		TorrentID:  domain.TorrentID(doc.ID),
+		Downloaded: doc.Downloaded,
+		Uploaded:   doc.Uploaded,
+		UpdatedAt:  timeFromUnix(doc.UpdatedAt),
+	}, nil
+}
+
+func (r *StatsRepository) SaveTransferTotals(ctx context.Context, totals domain.TransferTotals) error {
+	update := bson.M{
+		"$set": bson.M{
+			"downloaded": totals.Downloaded,
+			"uploaded":   totals.Uploaded,
+			"updatedAt":  totals.UpdatedAt.Unix(),
+		},
+	}
+	_, err := r.totals.UpdateOne(
+		ctx,
+		bson.M{"_id": string(totals.TorrentID)},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *StatsRepository) DeleteStats(ctx context.Context, id domain.TorrentID) error {
+	if _, err := r.samples.DeleteMany(ctx, bson.M{"torrentId": string(id)}); err != nil {
+		return err
+	}
+	_, err := r.totals.DeleteOne(ctx, bson.M{"_id": string(id)})
+	return err
+}
+
+func toStatsSampleDoc(id domain.TorrentID, s domain.StatsSample) statsSampleDoc {
+	return statsSampleDoc{
+		TorrentID:     string(id),
+		At:            s.Time.UTC(),
+		DownloadSpeed: s.DownloadSpeed,
+		UploadSpeed:   s.UploadSpeed,
+		Peers:         s.Peers,
+		Progress:      s.Progress,
+		Downloaded:    s.Downloaded,
+		Uploaded:      s.Uploaded,
+	}
+}
+
+func fromStatsSampleDoc(doc statsSampleDoc) domain.StatsSample {
+	return domain.StatsSample{
+		Time:          doc.At.UTC(),
+		DownloadSpeed: doc.DownloadSpeed,
+		UploadSpeed:   doc.UploadSpeed,
+		Peers:         doc.Peers,
+		Progress:      doc.Progress,
+		Downloaded:    doc.Downloaded,
+		Uploaded:      doc.Uploaded,
+	}
+}
+
+const transferCountersID = "global"
+
+type transferCountersDoc struct {
+	ID           string `bson:"_id"`
+	Downloaded   int64  `bson:"downloaded"`
+	Uploaded     int64  `bson:"uploaded"`
+	HashFailures int64  `bson:"hashFailures"`
+	WastedBytes  int64  `bson:"wastedBytes"`
+	UpdatedAt    int64  `bson:"updatedAt"`
+}
+
+// TransferCountersRepository stores the all-time global transfer counters
+// in a single document.
+type TransferCountersRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTransferCountersRepository(client *mongo.Client, dbName string) *TransferCountersRepository {
+	return &TransferCountersRepository{collection: client.Database(dbName).Collection("stats")}
+}
+
+func (r *TransferCountersRepository) GetTransferCounters(ctx context.Context) (domain.TransferCounters, bool, error) {
+	var doc transferCountersDoc
+	err := r.collection.FindOne(ctx, bson.M{"_id": transferCountersID}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.TransferCounters{}, false, nil
+		}
+		return domain.TransferCounters{}, false, err
+	}
+	return domain.TransferCounters{
+		Downloaded:   doc.Downloaded,
+		Uploaded:     doc.Uploaded,
+		HashFailures: doc.HashFailures,
+		WastedBytes:  doc.WastedBytes,
+	}, true, nil
+}
+
+func (r *TransferCountersRepository) SaveTransferCounters(ctx context.Context, counters domain.TransferCounters) error {
+	update := bson.M{
+		"$set": bson.M{
+			"downloaded":   counters.Downloaded,
+			"uploaded":     counters.Uploaded,
+			"hashFailures": counters.HashFailures,
+			"wastedBytes":  counters.WastedBytes,
+			"updatedAt":    time.Now().Unix(),
+		},
+	}
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": transferCountersID},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,296 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"torrentstream/internal/domain"
+)
+
+type userDoc struct {
+	ID           string `bson:"_id"`
+	Username     string `bson:"username"`
+	PasswordHash string `bson:"passwordHash"`
+	Role         string `bson:"role"`
+	CreatedAt    int64  `bson:"createdAt"`
+	UpdatedAt    int64  `bson:"updatedAt"`
+}
+
+type authSessionDoc struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"userId"`
+	CreatedAt time.Time `bson:"createdAt"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+type apiKeyDoc struct {
+	ID         string     `bson:"_id"`
+	UserID     string     `bson:"userId"`
+	Name       string     `bson:"name,omitempty"`
+	Prefix     string     `bson:"prefix"`
+	Hash       string     `bson:"hash"`
+	Role       string     `bson:"role"`
+	CreatedAt  time.Time  `bson:"createdAt"`
+	LastUsedAt *time.Time `bson:"lastUsedAt,omitempty"`
+}
+
+// UserRepository stores users, their login sessions and API keys. Sessions
+// are removed by a TTL index once they expire.
+type UserRepository struct {
+	users    *mongo.Collection
+	sessions *mongo.Collection
+	apiKeys  *mongo.Collection
+}
+
+func NewUserRepository(client *mongo.Client, dbName string) *UserRepository {
+	db := client.Database(dbName)
+	return &UserRepository{
+		users:    db.Collection("users"),
+		sessions: db.Collection("auth_sessions"),
+		apiKeys:  db.Collection("api_keys"),
+	}
+}
+
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	if r == nil || r.users == nil {
+		return nil
+	}
+	if _, err := r.users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	if _, err := r.sessions.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}); err != nil {
+		return err
+	}
+	_, err := r.apiKeys.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	return err
+}
+
+func (r *UserRepository) CountUsers(ctx context.Context) (int64, error) {
+	return r.users.CountDocuments(ctx, bson.M{})
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context) ([]domain.User, error) {
+	cursor, err := r.users.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "username", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []userDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	users := make([]domain.User, 0, len(docs))
+	for _, doc := range docs {
+		users = append(users, fromUserDoc(doc))
+	}
+	return users, nil
+}
+
+func (r *UserRepository) GetUser(ctx context.Context, id string) (domain.User, error) {
+	return r.findUser(ctx, bson.M{"_id": id})
+}
+
+func (r *UserRepository) GetUserByName(ctx context.Context, username string) (domain.User, error) {
+	return r.findUser(ctx, bson.M{"username": username})
+}
+
+func (r *UserRepository) findUser(ctx context.Context, filter bson.M) (domain.User, error) {
+	var doc userDoc
+	if err := r.users.FindOne(ctx, filter).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.User{}, domain.ErrNotFound
+		}
+		return domain.User{}, err
+	}
+	return fromUserDoc(doc), nil
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user domain.User) error {
+	_, err := r.users.ReplaceOne(
+		ctx,
+		bson.M{"_id": user.ID},
+		toUserDoc(user),
+		options.Replace().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, id string) error {
+	res, err := r.users.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	if _, err := r.sessions.DeleteMany(ctx, bson.M{"userId": id}); err != nil {
+		return err
+	}
+	_, err = r.apiKeys.DeleteMany(ctx, bson.M{"userId": id})
+	return err
+}
+
+func (r *UserRepository) SaveSession(ctx context.Context, session domain.AuthSession) error {
+	_, err := r.sessions.InsertOne(ctx, authSessionDoc{
+		ID:        session.ID,
+		UserID:    session.UserID,
+		CreatedAt: session.CreatedAt.UTC(),
+		ExpiresAt: session.ExpiresAt.UTC(),
+	})
+	return err
+}
+
+// GetSession also reports expired sessions the TTL monitor has not removed
+// yet; callers check ExpiresAt.
+func (r *UserRepository) GetSession(ctx context.Context, id string) (domain.AuthSession, error) {
+	var doc authSessionDoc
+	if err := r.sessions.FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.AuthSession{}, domain.ErrNotFound
+		}
+		return domain.AuthSession{}, err
+	}
+	return domain.AuthSession{
+		ID:        doc.ID,
+		UserID:    doc.UserID,
+		CreatedAt: doc.CreatedAt.UTC(),
+		ExpiresAt: doc.ExpiresAt.UTC(),
+	}, nil
+}
+
+func (r *UserRepository) DeleteSession(ctx context.Context, id string) error {
+	_, err := r.sessions.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *UserRepository) DeleteUserSessions(ctx context.Context, userID string) error {
+	_, err := r.sessions.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
+
+func (r *UserRepository) SaveAPIKey(ctx context.Context, key domain.APIKey) error {
+	_, err := r.apiKeys.InsertOne(ctx, toAPIKeyDoc(key))
+	return err
+}
+
+func (r *UserRepository) GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	var doc apiKeyDoc
+	if err := r.apiKeys.FindOne(ctx, bson.M{"hash": hash}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.APIKey{}, domain.ErrNotFound
+		}
+		return domain.APIKey{}, err
+	}
+	return fromAPIKeyDoc(doc), nil
+}
+
+func (r *UserRepository) ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	cursor, err := r.apiKeys.Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []apiKeyDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	keys := make([]domain.APIKey, 0, len(docs))
+	for _, doc := range docs {
+		keys = append(keys, fromAPIKeyDoc(doc))
+	}
+	return keys, nil
+}
+
+func (r *UserRepository) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	res, err := r.apiKeys.DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *UserRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	_, err := r.apiKeys.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at.UTC()}})
+	return err
+}
+
+func toUserDoc(user domain.User) userDoc {
+	return userDoc{
+		ID:           user.ID,
+		Username:     user.Username,
+		PasswordHash: user.PasswordHash,
+		Role:         string(user.Role),
+		CreatedAt:    user.CreatedAt.Unix(),
+		UpdatedAt:    user.UpdatedAt.Unix(),
+	}
+}
+
+func fromUserDoc(doc userDoc) domain.User {
+	return domain.User{
+		ID:           doc.ID,
+		Username:     doc.Username,
+		PasswordHash: doc.PasswordHash,
+		Role:         domain.Role(doc.Role),
+		CreatedAt:    timeFromUnix(doc.CreatedAt),
+		UpdatedAt:    timeFromUnix(doc.UpdatedAt),
+	}
+}
+
+func toAPIKeyDoc(key domain.APIKey) apiKeyDoc {
+	doc := apiKeyDoc{
+		ID:        key.ID,
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Hash:      key.Hash,
+		Role:      string(key.Role),
+		CreatedAt: key.CreatedAt.UTC(),
+	}
+	if key.LastUsedAt != nil {
+		at := key.LastUsedAt.UTC()
+		doc.LastUsedAt = &at
+	}
+	return doc
+}
+
+func fromAPIKeyDoc(doc apiKeyDoc) domain.APIKey {
+	key := domain.APIKey{
+		ID:        doc.ID,
+		UserID:    doc.UserID,
+		Name:      doc.Name,
+		Prefix:    doc.Prefix,
+		Hash:      doc.Hash,
+		Role:      domain.Role(doc.Role),
+		CreatedAt: doc.CreatedAt.UTC(),
+	}
+	if doc.LastUsedAt != nil {
+		at := doc.LastUsedAt.UTC()
+		key.LastUsedAt = &at
+	}
+	return key
+}
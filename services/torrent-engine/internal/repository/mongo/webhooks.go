@@ -0,0 +1,258 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"torrentstream/internal/domain"
+)
+
+// webhookDeliveryRetention is how long delivery log entries are kept.
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+type webhookDoc struct {
+	ID        string   `bson:"_id"`
+	Name      string   `bson:"name,omitempty"`
+	URL       string   `bson:"url"`
+	Events    []string `bson:"events,omitempty"`
+	Secret    string   `bson:"secret,omitempty"`
+	Enabled   bool     `bson:"enabled"`
+	CreatedAt int64    `bson:"createdAt"`
+	UpdatedAt int64    `bson:"updatedAt"`
+}
+
+type eventDoc struct {
+	Type      string    `bson:"type"`
+	TorrentID string    `bson:"torrentId,omitempty"`
+	At        time.Time `bson:"at"`
+	From      string    `bson:"from,omitempty"`
+	To        string    `bson:"to,omitempty"`
+	FileIndex *int      `bson:"fileIndex,omitempty"`
+	Path      string    `bson:"path,omitempty"`
+	Error     string    `bson:"error,omitempty"`
+	FreeBytes int64     `bson:"freeBytes,omitempty"`
+	Reason    string    `bson:"reason,omitempty"`
+	Source    string    `bson:"source,omitempty"`
+	Tags      []string  `bson:"tags,omitempty"`
+}
+
+type webhookDeliveryDoc struct {
+	ID         string    `bson:"_id"`
+	WebhookID  string    `bson:"webhookId"`
+	Event      eventDoc  `bson:"event"`
+	Success    bool      `bson:"success"`
+	Attempts   int       `bson:"attempts"`
+	StatusCode int       `bson:"statusCode,omitempty"`
+	Error      string    `bson:"error,omitempty"`
+	DurationMs int64     `bson:"durationMs"`
+	CreatedAt  time.Time `bson:"createdAt"`
+}
+
+// WebhookRepository stores webhook configurations and their delivery log.
+// Deliveries expire after 30 days.
+type WebhookRepository struct {
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewWebhookRepository(client *mongo.Client, dbName string) *WebhookRepository {
+	db := client.Database(dbName)
+	return &WebhookRepository{
+		webhooks:   db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+func (r *WebhookRepository) EnsureIndexes(ctx context.Context) error {
+	if r == nil || r.deliveries == nil {
+		return nil
+	}
+	_, err := r.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(webhookDeliveryRetention / time.Second)),
+		},
+	})
+	return err
+}
+
+func (r *WebhookRepository) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	cursor, err := r.webhooks.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []webhookDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	hooks := make([]domain.Webhook, 0, len(docs))
+	for _, doc := range docs {
+		hooks = append(hooks, fromWebhookDoc(doc))
+	}
+	return hooks, nil
+}
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id string) (domain.Webhook, error) {
+	var doc webhookDoc
+	err := r.webhooks.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Webhook{}, domain.ErrNotFound
+		}
+		return domain.Webhook{}, err
+	}
+	return fromWebhookDoc(doc), nil
+}
+
+func (r *WebhookRepository) SaveWebhook(ctx context.Context, hook domain.Webhook) error {
+	_, err := r.webhooks.ReplaceOne(
+		ctx,
+		bson.M{"_id": hook.ID},
+		toWebhookDoc(hook),
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	res, err := r.webhooks.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	_, err = r.deliveries.DeleteMany(ctx, bson.M{"webhookId": id})
+	return err
+}
+
+func (r *WebhookRepository) AddWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	_, err := r.deliveries.InsertOne(ctx, toWebhookDeliveryDoc(delivery))
+	return err
+}
+
+func (r *WebhookRepository) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := r.deliveries.Find(ctx, bson.M{"webhookId": webhookID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []webhookDeliveryDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	deliveries := make([]domain.WebhookDelivery, 0, len(docs))
+	for _, doc := range docs {
+		deliveries = append(deliveries, fromWebhookDeliveryDoc(doc))
+	}
+	return deliveries, nil
+}
+
+func toWebhookDoc(hook domain.Webhook) webhookDoc {
+	events := make([]string, 0, len(hook.Events))
+	for _, t := range hook.Events {
+		events = append(events, string(t))
+	}
+	return webhookDoc{
+		ID:        hook.ID,
+		Name:      hook.Name,
+		URL:       hook.URL,
+		Events:    events,
+		Secret:    hook.Secret,
+		Enabled:   hook.Enabled,
+		CreatedAt: hook.CreatedAt.Unix(),
+		UpdatedAt: hook.UpdatedAt.Unix(),
+	}
+}
+
+func fromWebhookDoc(doc webhookDoc) domain.Webhook {
+	var events []domain.EventType
+	for _, t := range doc.Events {
+		events = append(events, domain.EventType(t))
+	}
+	return domain.Webhook{
+		ID:        doc.ID,
+		Name:      doc.Name,
+		URL:       doc.URL,
+		Events:    events,
+		Secret:    doc.Secret,
+		Enabled:   doc.Enabled,
+		CreatedAt: timeFromUnix(doc.CreatedAt),
+		UpdatedAt: timeFromUnix(doc.UpdatedAt),
+	}
+}
+
+func toEventDoc(ev domain.Event) eventDoc {
+	return eventDoc{
+		Type:      string(ev.Type),
+		TorrentID: string(ev.TorrentID),
+		At:        ev.Time.UTC(),
+		From:      string(ev.From),
+		To:        string(ev.To),
+		FileIndex: ev.FileIndex,
+		Path:      ev.Path,
+		Error:     ev.Error,
+		FreeBytes: ev.FreeBytes,
+		Reason:    ev.Reason,
+		Source:    ev.Source,
+		Tags:      ev.Tags,
+	}
+}
+
+func fromEventDoc(doc eventDoc) domain.Event {
+	return domain.Event{
+		Type:      domain.EventType(doc.Type),
+		TorrentID: domain.TorrentID(doc.TorrentID),
+		Time:      doc.At.UTC(),
+		From:      domain.SessionMode(doc.From),
+		To:        domain.SessionMode(doc.To),
+		FileIndex: doc.FileIndex,
+		Path:      doc.Path,
+		Error:     doc.Error,
+		FreeBytes: doc.FreeBytes,
+		Reason:    doc.Reason,
+		Source:    doc.Source,
+		Tags:      doc.Tags,
+	}
+}
+
+func toWebhookDeliveryDoc(d domain.WebhookDelivery) webhookDeliveryDoc {
+	return webhookDeliveryDoc{
+		ID:         d.ID,
+		WebhookID:  d.WebhookID,
+		Event:      toEventDoc(d.Event),
+		Success:    d.Success,
+		Attempts:   d.Attempts,
+		StatusCode: d.StatusCode,
+		Error:      d.Error,
+		DurationMs: d.Duration,
+		CreatedAt:  d.CreatedAt.UTC(),
+	}
+}
+
+func fromWebhookDeliveryDoc(doc webhookDeliveryDoc) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:         doc.ID,
+		WebhookID:  doc.WebhookID,
+		Event:      fromEventDoc(doc.Event),
+		Success:    doc.Success,
+		Attempts:   doc.Attempts,
+		StatusCode: doc.StatusCode,
+		Error:      doc.Error,
+		Duration:   doc.DurationMs,
+		CreatedAt:  doc.CreatedAt.UTC(),
+	}
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/metrics"
+)
+
+// DefaultBuffer is the number of events queued per subscriber before new
+// events are dropped for it.
+const DefaultBuffer = 256
+
+// Handler processes one event. Handlers of a subscriber run one at a time,
+// in publish order.
+type Handler func(ctx context.Context, event domain.Event)
+
+type subscriber struct {
+	name string
+	ch   chan domain.Event
+}
+
+// Bus fans torrent events out to subscribers. Publish never blocks: a
+// subscriber that falls behind loses events instead of stalling the engine.
+type Bus struct {
+	logger *slog.Logger
+	buffer int
+	now    func() time.Time
+
+	mu   sync.RWMutex
+	subs []*subscriber
+}
+
+func NewBus(logger *slog.Logger) *Bus {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Bus{logger: logger, buffer: DefaultBuffer, now: time.Now}
+}
+
+// Publish queues event for every subscriber. A zero Time is set to now.
+func (b *Bus) Publish(event domain.Event) {
+	if event.Time.IsZero() {
+		event.Time = b.now().UTC()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			metrics.EventsDroppedTotal.WithLabelValues(sub.name).Inc()
+			b.logger.Warn("events: subscriber behind, event dropped",
+				slog.String("subscriber", sub.name),
+				slog.String("type", string(event.Type)),
+				slog.String("id", string(event.TorrentID)))
+		}
+	}
+}
+
+// Subscribe runs handler for every event published after the call until ctx
+// is done.
+func (b *Bus) Subscribe(ctx context.Context, name string, handler Handler) {
+	sub := &subscriber{name: name, ch: make(chan domain.Event, b.buffer)}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	go func() {
+		defer b.unsubscribe(sub)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-sub.ch:
+				b.dispatch(ctx, sub, handler, event)
+			}
+		}
+	}()
+}
+
+// dispatch runs handler, isolating the bus from a panicking subscriber.
+func (b *Bus) dispatch(ctx context.Context, sub *subscriber, handler Handler, event domain.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("events: subscriber panicked",
+				slog.String("subscriber", sub.name),
+				slog.Any("panic", r))
+		}
+	}()
+	handler(ctx, event)
+}
+
+func (b *Bus) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, s := range b.subs {
+		if s == sub {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			return
+		}
+	}
+}
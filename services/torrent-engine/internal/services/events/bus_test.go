@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+func newTestBus(buffer int) *Bus {
+	b := NewBus(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.buffer = buffer
+	return b
+}
+
+func TestBusDeliversInOrder(t *testing.T) {
+	b := newTestBus(DefaultBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got := make(chan domain.Event, 4)
+	b.Subscribe(ctx, "a", func(ctx context.Context, ev domain.Event) { got <- ev })
+	b.Subscribe(ctx, "b", func(ctx context.Context, ev domain.Event) { panic("boom") })
+
+	b.Publish(domain.Event{Type: domain.EventTorrentAdded, TorrentID: "t1"})
+	b.Publish(domain.Event{Type: domain.EventCompleted, TorrentID: "t1"})
+
+	for _, want := range []domain.EventType{domain.EventTorrentAdded, domain.EventCompleted} {
+		select {
+		case ev := <-got:
+			if ev.Type != want || ev.Time.IsZero() {
+				t.Fatalf("event = %+v, want type %s with time", ev, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %s not delivered", want)
+		}
+	}
+}
+
+func TestBusDropsForSlowSubscriber(t *testing.T) {
+	b := newTestBus(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	block := make(chan struct{})
+	got := make(chan domain.Event, 4)
+	b.Subscribe(ctx, "slow", func(ctx context.Context, ev domain.Event) {
+		<-block
+		got <- ev
+	})
+
+	// The first event is taken by the handler, the second fills the buffer
+	// and the rest are dropped without blocking.
+	b.Publish(domain.Event{Type: domain.EventModeChanged, TorrentID: "1"})
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		b.Publish(domain.Event{Type: domain.EventModeChanged, TorrentID: "2"})
+	}
+	close(block)
+
+	n := 0
+	timeout := time.After(200 * time.Millisecond)
+	for n < 3 {
+		select {
+		case <-got:
+			n++
+		case <-timeout:
+			if n != 2 {
+				t.Fatalf("delivered %d events, want 2", n)
+			}
+			return
+		}
+	}
+	t.Fatalf("delivered %d events, want 2", n)
+}
+
+func TestBusUnsubscribesOnCancel(t *testing.T) {
+	b := newTestBus(DefaultBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Subscribe(ctx, "a", func(ctx context.Context, ev domain.Event) {})
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.RLock()
+		n := len(b.subs)
+		b.mu.RUnlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("subscriber not removed after cancel")
+}
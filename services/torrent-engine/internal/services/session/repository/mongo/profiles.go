@@ -0,0 +1,130 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"torrentstream/internal/domain"
+)
+
+type profileDoc struct {
+	ID                       string `bson:"_id"`
+	Name                     string `bson:"name"`
+	OwnerID                  string `bson:"ownerId,omitempty"`
+	CurrentTorrentID         string `bson:"currentTorrentId,omitempty"`
+	PrioritizeActiveFileOnly *bool  `bson:"prioritizeActiveFileOnly,omitempty"`
+	CreatedAt                int64  `bson:"createdAt"`
+	UpdatedAt                int64  `bson:"updatedAt"`
+}
+
+type ProfileRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProfileRepository(client *mongo.Client, dbName string) *ProfileRepository {
+	return &ProfileRepository{collection: client.Database(dbName).Collection("profiles")}
+}
+
+func (r *ProfileRepository) EnsureIndexes(ctx context.Context) error {
+	if r == nil || r.collection == nil {
+		return nil
+	}
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "createdAt", Value: 1}},
+	})
+	return err
+}
+
+func (r *ProfileRepository) ListProfiles(ctx context.Context) ([]domain.Profile, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []profileDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	profiles := make([]domain.Profile, 0, len(docs))
+	for _, doc := range docs {
+		profiles = append(profiles, profileDocToProfile(doc))
+	}
+	return profiles, nil
+}
+
+func (r *ProfileRepository) GetProfile(ctx context.Context, id string) (domain.Profile, error) {
+	return r.findProfile(ctx, bson.M{"_id": id}, nil)
+}
+
+// GetProfileByOwner returns the oldest profile of a user.
+func (r *ProfileRepository) GetProfileByOwner(ctx context.Context, ownerID string) (domain.Profile, error) {
+	return r.findProfile(ctx, bson.M{"ownerId": ownerID}, options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+}
+
+func (r *ProfileRepository) findProfile(ctx context.Context, filter bson.M, opts *options.FindOneOptions) (domain.Profile, error) {
+	var doc profileDoc
+	var err error
+	if opts != nil {
+		err = r.collection.FindOne(ctx, filter, opts).Decode(&doc)
+	} else {
+		err = r.collection.FindOne(ctx, filter).Decode(&doc)
+	}
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Profile{}, domain.ErrNotFound
+		}
+		return domain.Profile{}, err
+	}
+	return profileDocToProfile(doc), nil
+}
+
+func (r *ProfileRepository) SaveProfile(ctx context.Context, profile domain.Profile) error {
+	_, err := r.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": profile.ID},
+		profileToDoc(profile),
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *ProfileRepository) DeleteProfile(ctx context.Context, id string) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func profileToDoc(p domain.Profile) profileDoc {
+	return profileDoc{
+		ID:                       p.ID,
+		Name:                     p.Name,
+		OwnerID:                  p.OwnerID,
+		CurrentTorrentID:         string(p.CurrentTorrentID),
+		PrioritizeActiveFileOnly: p.PrioritizeActiveFileOnly,
+		CreatedAt:                p.CreatedAt.Unix(),
+		UpdatedAt:                p.UpdatedAt.Unix(),
+	}
+}
+
+func profileDocToProfile(doc profileDoc) domain.Profile {
+	return domain.Profile{
+		ID:                       doc.ID,
+		Name:                     doc.Name,
+		OwnerID:                  doc.OwnerID,
+		CurrentTorrentID:         domain.TorrentID(doc.CurrentTorrentID),
+		PrioritizeActiveFileOnly: doc.PrioritizeActiveFileOnly,
+		CreatedAt:                time.Unix(doc.CreatedAt, 0).UTC(),
+		UpdatedAt:                time.Unix(doc.UpdatedAt, 0).UTC(),
+	}
+}
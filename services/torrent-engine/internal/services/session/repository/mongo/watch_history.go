@@ -15,6 +15,7 @@ import (
 
 type watchPositionDoc struct {
 	ID          string  `bson:"_id"`
+	ProfileID   string  `bson:"profileId,omitempty"`
 	TorrentID   string  `bson:"torrentId"`
 	FileIndex   int     `bson:"fileIndex"`
 	Position    float64 `bson:"position"`
@@ -33,13 +34,58 @@ func NewWatchHistoryRepository(client *mongo.Client, dbName string) *WatchHistor
 	return &WatchHistoryRepository{collection: client.Database(dbName).Collection("watch_history")}
 }
 
+// EnsureIndexes creates the index behind the per-profile history lists.
+func (r *WatchHistoryRepository) EnsureIndexes(ctx context.Context) error {
+	if r == nil || r.collection == nil {
+		return nil
+	}
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "profileId", Value: 1}, {Key: "updatedAt", Value: -1}}},
+		{Keys: bson.D{{Key: "torrentId", Value: 1}}},
+	})
+	return err
+}
+
+// MigrateToDefaultProfile assigns positions saved before profiles existed
+// to the default profile. Their ids already have the default profile's
+// format.
+func (r *WatchHistoryRepository) MigrateToDefaultProfile(ctx context.Context) (int64, error) {
+	res, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"profileId": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"profileId": domain.DefaultProfileID}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
 func watchDocID(torrentID domain.TorrentID, fileIndex int) string {
 	return fmt.Sprintf("%s:%d", string(torrentID), fileIndex)
 }
 
+// profileWatchDocID keys a position by profile. The default profile keeps
+// the id format from before profiles so existing documents stay valid.
+func profileWatchDocID(profileID string, torrentID domain.TorrentID, fileIndex int) string {
+	profileID = profileOrDefault(profileID)
+	if profileID == domain.DefaultProfileID {
+		return watchDocID(torrentID, fileIndex)
+	}
+	return profileID + "/" + watchDocID(torrentID, fileIndex)
+}
+
+func profileOrDefault(profileID string) string {
+	if profileID == "" {
+		return domain.DefaultProfileID
+	}
+	return profileID
+}
+
 func (r *WatchHistoryRepository) Upsert(ctx context.Context, wp domain.WatchPosition) error {
 	update := bson.M{
 		"$set": bson.M{
+			"profileId":   profileOrDefault(wp.ProfileID),
 			"torrentId":   string(wp.TorrentID),
 			"fileIndex":   wp.FileIndex,
 			"position":    wp.Position,
@@ -52,16 +98,16 @@ func (r *WatchHistoryRepository) Upsert(ctx context.Context, wp domain.WatchPosi
 	}
 	_, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{"_id": watchDocID(wp.TorrentID, wp.FileIndex)},
+		bson.M{"_id": profileWatchDocID(wp.ProfileID, wp.TorrentID, wp.FileIndex)},
 		update,
 		options.Update().SetUpsert(true),
 	)
 	return err
 }
 
-func (r *WatchHistoryRepository) Get(ctx context.Context, torrentID domain.TorrentID, fileIndex int) (domain.WatchPosition, error) {
+func (r *WatchHistoryRepository) Get(ctx context.Context, profileID string, torrentID domain.TorrentID, fileIndex int) (domain.WatchPosition, error) {
 	var doc watchPositionDoc
-	err := r.collection.FindOne(ctx, bson.M{"_id": watchDocID(torrentID, fileIndex)}).Decode(&doc)
+	err := r.collection.FindOne(ctx, bson.M{"_id": profileWatchDocID(profileID, torrentID, fileIndex)}).Decode(&doc)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return domain.WatchPosition{}, domain.ErrNotFound
@@ -71,7 +117,7 @@ func (r *WatchHistoryRepository) Get(ctx context.Context, torrentID domain.Torre
 	return watchDocToPosition(doc), nil
 }
 
-func (r *WatchHistoryRepository) ListRecent(ctx context.Context, limit int) ([]domain.WatchPosition, error) {
+func (r *WatchHistoryRepository) ListRecent(ctx context.Context, profileID string, limit int) ([]domain.WatchPosition, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -80,7 +126,7 @@ func (r *WatchHistoryRepository) ListRecent(ctx context.Context, limit int) ([]d
 		SetSort(bson.D{{Key: "updatedAt", Value: -1}}).
 		SetLimit(int64(limit))
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.collection.Find(ctx, bson.M{"profileId": profileOrDefault(profileID)}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -98,14 +144,15 @@ func (r *WatchHistoryRepository) ListRecent(ctx context.Context, limit int) ([]d
 	return positions, nil
 }
 
-func (r *WatchHistoryRepository) ListIncomplete(ctx context.Context, limit int) ([]domain.WatchPosition, error) {
+func (r *WatchHistoryRepository) ListIncomplete(ctx context.Context, profileID string, limit int) ([]domain.WatchPosition, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 
 	filter := bson.M{
-		"position": bson.M{"$gte": 10},
-		"duration": bson.M{"$gt": 0},
+		"profileId": profileOrDefault(profileID),
+		"position":  bson.M{"$gte": 10},
+		"duration":  bson.M{"$gt": 0},
 		"$expr": bson.M{
 			"$lt": bson.A{"$position", bson.M{"$subtract": bson.A{"$duration", 15}}},
 		},
@@ -133,6 +180,34 @@ func (r *WatchHistoryRepository) ListIncomplete(ctx context.Context, limit int)
 	return positions, nil
 }
 
+// ListByTorrent returns the positions of all profiles in a torrent.
+func (r *WatchHistoryRepository) ListByTorrent(ctx context.Context, torrentID domain.TorrentID) ([]domain.WatchPosition, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "fileIndex", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"torrentId": string(torrentID)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []watchPositionDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	positions := make([]domain.WatchPosition, 0, len(docs))
+	for _, doc := range docs {
+		positions = append(positions, watchDocToPosition(doc))
+	}
+	return positions, nil
+}
+
+// DeleteByProfile removes the watch history of a profile.
+func (r *WatchHistoryRepository) DeleteByProfile(ctx context.Context, profileID string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"profileId": profileID})
+	return err
+}
+
 func watchDocToPosition(doc watchPositionDoc) domain.WatchPosition {
 	var progress float64
 	if doc.Duration > 0 {
@@ -145,6 +220,7 @@ func watchDocToPosition(doc watchPositionDoc) domain.WatchPosition {
 		}
 	}
 	return domain.WatchPosition{
+		ProfileID:   profileOrDefault(doc.ProfileID),
 		TorrentID:   domain.TorrentID(doc.TorrentID),
 		FileIndex:   doc.FileIndex,
 		Position:    doc.Position,
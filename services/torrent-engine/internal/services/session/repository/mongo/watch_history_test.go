@@ -131,3 +131,52 @@ func TestNewWatchHistoryRepository_NotNil(t *testing.T) {
 	// So just verify the type exists and constructor is reachable
 	var _ *WatchHistoryRepository
 }
+
+func TestProfileWatchDocID(t *testing.T) {
+	tests := []struct {
+		profileID string
+		want      string
+	}{
+		{"", "abc:1"},
+		{"default", "abc:1"},
+		{"kids", "kids/abc:1"},
+	}
+	for _, tc := range tests {
+		if got := profileWatchDocID(tc.profileID, "abc", 1); got != tc.want {
+			t.Errorf("profileWatchDocID(%q) = %q, want %q", tc.profileID, got, tc.want)
+		}
+	}
+}
+
+func TestWatchDocToPosition_LegacyDocBelongsToDefaultProfile(t *testing.T) {
+	pos := watchDocToPosition(watchPositionDoc{ID: "abc:0", TorrentID: "abc"})
+	if pos.ProfileID != "default" {
+		t.Errorf("ProfileID: expected default, got %q", pos.ProfileID)
+	}
+	pos = watchDocToPosition(watchPositionDoc{ID: "kids/abc:0", ProfileID: "kids", TorrentID: "abc"})
+	if pos.ProfileID != "kids" {
+		t.Errorf("ProfileID: expected kids, got %q", pos.ProfileID)
+	}
+}
+
+func TestProfileDocRoundtrip(t *testing.T) {
+	enabled := false
+	profile := domain.Profile{
+		ID:                       "p1",
+		Name:                     "Kids",
+		OwnerID:                  "u1",
+		CurrentTorrentID:         "t1",
+		PrioritizeActiveFileOnly: &enabled,
+		CreatedAt:                time.Unix(1700000000, 0).UTC(),
+		UpdatedAt:                time.Unix(1700000060, 0).UTC(),
+	}
+	got := profileDocToProfile(profileToDoc(profile))
+	if got.ID != profile.ID || got.Name != profile.Name || got.OwnerID != profile.OwnerID ||
+		got.CurrentTorrentID != profile.CurrentTorrentID || !got.CreatedAt.Equal(profile.CreatedAt) ||
+		!got.UpdatedAt.Equal(profile.UpdatedAt) {
+		t.Fatalf("profile roundtrip: got %+v, want %+v", got, profile)
+	}
+	if got.PrioritizeActiveFileOnly == nil || *got.PrioritizeActiveFileOnly {
+		t.Fatalf("PrioritizeActiveFileOnly: got %v", got.PrioritizeActiveFileOnly)
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
 
 	"torrentstream/internal/domain"
 	"torrentstream/internal/domain/ports"
@@ -32,6 +37,12 @@ type Config struct {
 	DataDir     string
 	MaxSessions int           // 0 = unlimited
 	IdleTimeout time.Duration // auto-stop sessions idle longer than this; 0 = disabled
+	// BanBadPeersAfter bans a peer once it sent data for this many pieces
+	// that failed the hash check; 0 leaves banning to the client.
+	BanBadPeersAfter int
+	// Events receives session events (metadata, mode changes, completion,
+	// finished files); nil disables them.
+	Events ports.EventPublisher
 }
 
 type Engine struct {
@@ -53,6 +64,33 @@ type Engine struct {
 	maxSessions     int
 	idleTimeout     time.Duration
 	reaperCancel    context.CancelFunc
+
+	dataDir   string                              // client default storage root
+	storageMu sync.Mutex                          // guards storages
+	storages  map[string]storage.ClientImplCloser // file storage per extra root
+
+	webSeedMu    sync.Mutex                                      // guards webSeeds and webTransport
+	webSeeds     map[domain.TorrentID]map[string]*webSeedTracker // per-torrent web seeds by URL
+	webTransport http.RoundTripper                               // shared by all web seed trackers
+
+	selectMu sync.Mutex                 // guards selected
+	selected map[domain.TorrentID][]int // BEP 53 select-only file indices
+
+	hashMu sync.Mutex                      // guards hashes
+	hashes map[domain.TorrentID]infoHashes // v1 and v2 info hashes, once metadata is known
+
+	announceDHT bool // run dhtAnnouncer; false for clients configured by the caller
+
+	wasteMu             sync.Mutex // guards retired hash failures
+	retiredHashFailures int64      // failed pieces of dropped torrents
+	retiredWastedBytes  int64      // bytes downloaded for those pieces
+
+	integrityMu sync.Mutex                             // guards integrity
+	integrity   map[domain.TorrentID]*integrityTracker // hash failures per torrent
+	bans        *peerBans                              // client blocklist; nil when banning is off
+	banAfter    int                                    // bad pieces before a peer is banned
+
+	events ports.EventPublisher // nil when events are off
 }
 
 func New(cfg Config) (*Engine, error) {
@@ -60,13 +98,26 @@ func New(cfg Config) (*Engine, error) {
 	if cfg.DataDir != "" {
 		clientConfig.DataDir = cfg.DataDir
 	}
+	// Client logs carry announce URLs; route them through the redacting
+	// default logger. DHT announces and PEX skip private torrents (BEP 27).
+	clientConfig.Slogger = slog.Default()
+	clientConfig.PeriodicallyAnnounceTorrentsToDht = false
+	setPrivacyCallbacks(clientConfig)
+	// Runs with the client locked; bad data is accounted asynchronously.
+	var e *Engine
+	clientConfig.Callbacks.PeerConnClosed = func(pc *torrent.PeerConn) { go e.peerConnClosed(pc) }
+	var bans *peerBans
+	if cfg.BanBadPeersAfter > 0 {
+		bans = newPeerBans()
+		clientConfig.IPBlocklist = bans
+	}
 
 	client, err := torrent.NewClient(clientConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	e := &Engine{
+	e = &Engine{
 		client:          client,
 		sessions:        make(map[domain.TorrentID]*torrent.Torrent),
 		modes:           make(map[domain.TorrentID]domain.SessionMode),
@@ -80,6 +131,17 @@ func New(cfg Config) (*Engine, error) {
 		verifyPeakBytes: make(map[domain.TorrentID]int64),
 		maxSessions:     cfg.MaxSessions,
 		idleTimeout:     cfg.IdleTimeout,
+		dataDir:         cleanDir(clientConfig.DataDir),
+		storages:        make(map[string]storage.ClientImplCloser),
+		webSeeds:        make(map[domain.TorrentID]map[string]*webSeedTracker),
+		webTransport:    newWebSeedTransport(),
+		selected:        make(map[domain.TorrentID][]int),
+		hashes:          make(map[domain.TorrentID]infoHashes),
+		announceDHT:     true,
+		integrity:       make(map[domain.TorrentID]*integrityTracker),
+		bans:            bans,
+		banAfter:        cfg.BanBadPeersAfter,
+		events:          cfg.Events,
 	}
 
 	if e.idleTimeout > 0 {
@@ -104,6 +166,10 @@ func NewWithClient(client *torrent.Client) *Engine {
 		rateLimits:      make(map[domain.TorrentID]int64),
 		verifyStartedAt: make(map[domain.TorrentID]time.Time),
 		verifyPeakBytes: make(map[domain.TorrentID]int64),
+		storages:        make(map[string]storage.ClientImplCloser),
+		webSeeds:        make(map[domain.TorrentID]map[string]*webSeedTracker),
+		selected:        make(map[domain.TorrentID][]int),
+		hashes:          make(map[domain.TorrentID]infoHashes),
 	}
 }
 
@@ -131,6 +197,11 @@ func (e *Engine) transition(id domain.TorrentID, to domain.SessionMode) error {
 	} else if current == domain.ModeFocused {
 		e.focusedID = ""
 	}
+
+	e.publish(domain.Event{Type: domain.EventModeChanged, TorrentID: id, From: current, To: to})
+	if to == domain.ModeCompleted {
+		e.publish(domain.Event{Type: domain.EventCompleted, TorrentID: id})
+	}
 	return nil
 }
 
@@ -159,7 +230,7 @@ func (e *Engine) resumeTorrent(t *torrent.Torrent) {
 	t.AllowDataUpload()
 	t.AllowDataDownload()
 	if torrentInfoReady(t) {
-		t.DownloadAll()
+		e.downloadAll(t)
 	}
 }
 
@@ -214,13 +285,7 @@ func (e *Engine) Open(ctx context.Context, src domain.TorrentSource) (ports.Sess
 	}
 	ch := make(chan addResult, 1)
 	go func() {
-		var t *torrent.Torrent
-		var err error
-		if src.Magnet != "" {
-			t, err = e.client.AddMagnet(src.Magnet)
-		} else {
-			t, err = e.client.AddTorrentFromFile(src.Torrent)
-		}
+		t, err := e.addTorrent(src)
 		ch <- addResult{t, err}
 	}()
 
@@ -236,6 +301,7 @@ func (e *Engine) Open(ctx context.Context, src domain.TorrentSource) (ports.Sess
 		// Spawn a cleanup goroutine to drop the orphaned torrent.
 		go func() {
 			if res := <-ch; res.t != nil {
+				e.forgetWebSeeds(domain.TorrentID(res.t.InfoHash().HexString()))
 				res.t.Drop()
 			}
 		}()
@@ -243,6 +309,7 @@ func (e *Engine) Open(ctx context.Context, src domain.TorrentSource) (ports.Sess
 	case <-ctx.Done():
 		go func() {
 			if res := <-ch; res.t != nil {
+				e.forgetWebSeeds(domain.TorrentID(res.t.InfoHash().HexString()))
 				res.t.Drop()
 			}
 		}()
@@ -271,6 +338,7 @@ func (e *Engine) Open(ctx context.Context, src domain.TorrentSource) (ports.Sess
 		et, eid, err := e.evictIdleSessionLocked()
 		if err != nil {
 			e.mu.Unlock()
+			e.forgetWebSeeds(id)
 			t.Drop()
 			return nil, ErrSessionLimitReached
 		}
@@ -282,12 +350,17 @@ func (e *Engine) Open(ctx context.Context, src domain.TorrentSource) (ports.Sess
 	e.modes[id] = domain.ModeIdle
 	e.lastAccess[id] = time.Now().UTC()
 	e.mu.Unlock()
+	e.setSelection(id, src.SelectedFiles)
 
 	// Drop evicted torrent synchronously outside the lock to avoid
 	// a race between Drop and the new session registration.
 	if evictedTorrent != nil {
 		e.forgetFocusedPieces(evictedID)
 		e.forgetSpeed(evictedID)
+		e.forgetWebSeeds(evictedID)
+		e.forgetSelection(evictedID)
+		e.forgetInfoHashes(evictedID)
+		e.retireHashFailures(evictedTorrent)
 		evictedTorrent.Drop()
 	}
 
@@ -297,8 +370,9 @@ func (e *Engine) Open(ctx context.Context, src domain.TorrentSource) (ports.Sess
 
 	select {
 	case <-t.GotInfo():
+		e.publish(domain.Event{Type: domain.EventMetadataReady, TorrentID: id})
 		e.mu.Lock()
-		e.modes[id] = domain.ModeDownloading
+		_ = e.transition(id, domain.ModeDownloading)
 		e.mu.Unlock()
 		files := mapFiles(t)
 		return &Session{engine: e, torrent: t, id: id, files: files, ready: true}, nil
@@ -308,6 +382,100 @@ func (e *Engine) Open(ctx context.Context, src domain.TorrentSource) (ports.Sess
 	}
 }
 
+// TorrentFiles lists the files of the .torrent file at path, so its size is
+// known before a storage root is chosen.
+func (e *Engine) TorrentFiles(path string) ([]domain.FileRef, error) {
+	mi, err := metainfo.LoadFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return nil, err
+	}
+	var files []domain.FileRef
+	for i, f := range info.UpvertedFiles() {
+		files = append(files, domain.FileRef{Index: i, Path: f.DisplayPath(&info), Length: f.Length})
+	}
+	return files, nil
+}
+
+// addTorrent adds src to the client. Torrents placed on a storage root other
+// than the client data directory get a file storage for that root; the
+// storage is ignored by the client when the torrent is already known.
+func (e *Engine) addTorrent(src domain.TorrentSource) (*torrent.Torrent, error) {
+	var spec *torrent.TorrentSpec
+	if src.Magnet != "" {
+		var err error
+		spec, err = torrent.TorrentSpecFromMagnetUri(src.Magnet)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		mi, err := metainfo.LoadFromFile(src.Torrent)
+		if err != nil {
+			return nil, err
+		}
+		spec, err = torrent.TorrentSpecFromMetaInfoErr(mi)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if st := e.storageFor(src.DataDir); st != nil {
+		spec.Storage = st
+	}
+	spec.Trackers = mergeTrackers(spec.Trackers, src.Trackers)
+	for _, addr := range src.Peers {
+		if !slices.Contains(spec.PeerAddrs, addr) {
+			spec.PeerAddrs = append(spec.PeerAddrs, addr)
+		}
+	}
+	// Web seeds are attached separately so each one gets a tracking transport.
+	webSeeds := append(spec.Webseeds, src.WebSeeds...)
+	spec.Webseeds = nil
+	t, isNew, err := e.client.AddTorrentSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if isNew {
+		go e.watchIntegrity(t)
+		go e.watchFiles(t)
+		go dropPrePrivateConns(t)
+	}
+	if isNew && e.announceDHT {
+		go e.dhtAnnouncer(t)
+	}
+	e.addWebSeeds(t, webSeeds)
+	return t, nil
+}
+
+// storageFor returns the file storage for dir, or nil when dir is the client
+// default data directory.
+func (e *Engine) storageFor(dir string) storage.ClientImplCloser {
+	dir = cleanDir(dir)
+	if dir == "" || dir == e.dataDir {
+		return nil
+	}
+	e.storageMu.Lock()
+	defer e.storageMu.Unlock()
+	st, ok := e.storages[dir]
+	if !ok {
+		st = storage.NewFile(dir)
+		e.storages[dir] = st
+	}
+	return st
+}
+
+func cleanDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return filepath.Clean(dir)
+}
+
 // waitForInfo blocks until torrent metadata is available (with timeout), then transitions
 // the session to the appropriate mode based on current engine state.
 // If metadata is not received within metadataWaitTimeout, the torrent is removed to prevent goroutine leaks.
@@ -319,6 +487,7 @@ func (e *Engine) waitForInfo(t *torrent.Torrent, id domain.TorrentID) {
 		// Timeout: metadata not available after long wait (likely zero-peer torrent)
 		e.mu.Lock()
 		if _, ok := e.sessions[id]; ok {
+			e.publish(domain.Event{Type: domain.EventError, TorrentID: id, Error: "metadata not received"})
 			t.Drop() // Release torrent resources
 			delete(e.sessions, id)
 			delete(e.modes, id)
@@ -332,6 +501,9 @@ func (e *Engine) waitForInfo(t *torrent.Torrent, id domain.TorrentID) {
 		e.mu.Unlock()
 		e.forgetSpeed(id)
 		e.forgetFocusedPieces(id)
+		e.forgetWebSeeds(id)
+		e.forgetSelection(id)
+		e.forgetInfoHashes(id)
 		return
 	}
 
@@ -342,6 +514,7 @@ func (e *Engine) waitForInfo(t *torrent.Torrent, id domain.TorrentID) {
 	if !ok {
 		return // session was removed
 	}
+	e.publish(domain.Event{Type: domain.EventMetadataReady, TorrentID: id})
 	if mode == domain.ModeStopped {
 		return
 	}
@@ -356,7 +529,7 @@ func (e *Engine) waitForInfo(t *torrent.Torrent, id domain.TorrentID) {
 
 	if err := e.transition(id, domain.ModeDownloading); err == nil {
 		t.AllowDataDownload()
-		t.DownloadAll()
+		e.downloadAll(t)
 	}
 }
 
@@ -368,6 +541,16 @@ func (e *Engine) Close() error {
 		return nil
 	}
 	errList := e.client.Close()
+
+	e.storageMu.Lock()
+	for dir, st := range e.storages {
+		if err := st.Close(); err != nil {
+			errList = append(errList, err)
+		}
+		delete(e.storages, dir)
+	}
+	e.storageMu.Unlock()
+
 	if len(errList) > 0 {
 		return errList[0]
 	}
@@ -418,7 +601,9 @@ func (e *Engine) reapIdleSessions() {
 			slog.String("torrentId", string(id)),
 			slog.Duration("idleTimeout", e.idleTimeout),
 		)
-		_ = e.StopSession(context.Background(), id)
+		if err := e.StopSession(context.Background(), id); err == nil {
+			e.publish(domain.Event{Type: domain.EventTorrentStopped, TorrentID: id, Reason: domain.ReasonIdle})
+		}
 	}
 }
 
@@ -441,16 +626,22 @@ func (e *Engine) GetSessionState(ctx context.Context, id domain.TorrentID) (doma
 	default:
 		stats := t.Stats()
 		return domain.SessionState{
-			ID:        id,
-			Status:    domain.TorrentPending,
-			Mode:      mode,
-			Peers:     stats.ActivePeers,
-			UpdatedAt: time.Now().UTC(),
+			ID:         id,
+			Status:     domain.TorrentPending,
+			Mode:       mode,
+			Peers:      stats.ActivePeers,
+			Downloaded: stats.BytesReadUsefulData.Int64(),
+			Uploaded:   stats.BytesWrittenData.Int64(),
+			UpdatedAt:  time.Now().UTC(),
 		}, nil
 	}
 
 	length := t.Length()
 	rawCompleted := t.BytesCompleted()
+	if selLength, selCompleted, ok := e.selectedProgress(id, t); ok {
+		// Only the selected files are downloaded; complete once they are.
+		length, rawCompleted = selLength, selCompleted
+	}
 	completed := rawCompleted
 
 	// Maintain high-water mark: after restart anacrolix re-verifies pieces
@@ -547,9 +738,14 @@ func (e *Engine) GetSessionState(ctx context.Context, id domain.TorrentID) (doma
 	}
 	e.mu.Unlock()
 
+	infoHash, infoHashV2 := e.infoHashes(id, t)
+
 	return domain.SessionState{
 		ID:                   id,
 		Status:               status,
+		InfoHash:             infoHash,
+		InfoHashV2:           infoHashV2,
+		Private:              isPrivate(t),
 		Mode:                 mode,
 		TransferPhase:        transferPhase,
 		Progress:             progress,
@@ -557,6 +753,8 @@ func (e *Engine) GetSessionState(ctx context.Context, id domain.TorrentID) (doma
 		Peers:                stats.ActivePeers,
 		DownloadSpeed:        downloadSpeed,
 		UploadSpeed:          uploadSpeed,
+		Downloaded:           stats.BytesReadUsefulData.Int64(),
+		Uploaded:             stats.BytesWrittenData.Int64(),
 		Files:                mapFiles(t, stableBitfield),
 		NumPieces:            numPieces,
 		PieceBitfield:        bitfield,
@@ -683,7 +881,9 @@ func (e *Engine) RemoveSession(ctx context.Context, id domain.TorrentID) error {
 	if t == nil {
 		return ErrSessionNotFound
 	}
-	return e.dropTorrent(id, t)
+	err := e.dropTorrent(id, t)
+	e.forgetIntegrity(id)
+	return err
 }
 
 func (e *Engine) SetPiecePriority(ctx context.Context, id domain.TorrentID, file domain.FileRef, r domain.Range, prio domain.Priority) error {
@@ -879,7 +1079,11 @@ func (e *Engine) dropTorrent(id domain.TorrentID, t *torrent.Torrent) error {
 	e.mu.Unlock()
 	e.forgetFocusedPieces(id)
 	e.forgetSpeed(id)
+	e.forgetWebSeeds(id)
+	e.forgetSelection(id)
+	e.forgetInfoHashes(id)
 	if t != nil {
+		e.retireHashFailures(t)
 		t.Drop()
 	}
 	// Return memory to the OS promptly after dropping a torrent session.
@@ -980,6 +1184,7 @@ func mapFiles(t *torrent.Torrent, stableBitfield ...[]byte) (mapped []domain.Fil
 			PieceEnd:       end,
 			Progress:       progress,
 			Priority:       mapPriorityString(f.Priority()),
+			PiecesRoot:     piecesRoot(f),
 		})
 	}
 	return mapped
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/storage"
 
 	"torrentstream/internal/domain"
 	"torrentstream/internal/domain/ports"
@@ -494,6 +495,7 @@ func TestSessionStartNilEngine(t *testing.T) {
 
 func TestEngineImplementsPortsEngine(t *testing.T) {
 	var _ ports.Engine = (*Engine)(nil)
+	var _ ports.WebSeedEngine = (*Engine)(nil)
 }
 
 func TestSessionImplementsPortsSession(t *testing.T) {
@@ -600,3 +602,33 @@ func TestTouchLastAccessMissing(t *testing.T) {
 		t.Fatal("touchLastAccess should not create entry for missing session")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// storageFor — per-root file storage
+// ---------------------------------------------------------------------------
+
+func TestStorageForDefaultAndExtraRoots(t *testing.T) {
+	e := newTestEngine()
+	e.dataDir = cleanDir(t.TempDir())
+	e.storages = make(map[string]storage.ClientImplCloser)
+
+	if st := e.storageFor(""); st != nil {
+		t.Fatalf("empty dir should use the client default storage")
+	}
+	if st := e.storageFor(e.dataDir + "/"); st != nil {
+		t.Fatalf("data dir should use the client default storage")
+	}
+
+	extra := t.TempDir()
+	st := e.storageFor(extra)
+	if st == nil {
+		t.Fatalf("expected storage for extra root")
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	if again := e.storageFor(extra); again != st {
+		t.Fatalf("storage for the same root must be reused")
+	}
+	if len(e.storages) != 1 {
+		t.Fatalf("storages = %d, want 1", len(e.storages))
+	}
+}
@@ -0,0 +1,73 @@
+package anacrolix
+
+import (
+	"context"
+
+	"github.com/anacrolix/torrent"
+
+	"torrentstream/internal/domain"
+)
+
+// publish sends event to the configured publisher, if any. Publishers do
+// not block, so events may be published with e.mu held.
+func (e *Engine) publish(event domain.Event) {
+	if e.events != nil {
+		e.events.Publish(event)
+	}
+}
+
+// watchFiles publishes a file_completed event for every file of t that
+// finishes downloading, and completes the session as soon as the last
+// wanted file is done instead of on the next state poll. Files found
+// complete when the metadata arrives, or while nothing was downloaded yet
+// (hash check of existing data), are not reported.
+func (e *Engine) watchFiles(t *torrent.Torrent) {
+	select {
+	case <-t.GotInfo():
+	case <-t.Closed():
+		return
+	}
+	id := domain.TorrentID(t.InfoHash().HexString())
+	sub := t.SubscribePieceStateChanges()
+	defer sub.Close()
+
+	files := t.Files()
+	done := make([]bool, len(files))
+	for i, f := range files {
+		done[i] = f.BytesCompleted() >= f.Length()
+	}
+	for {
+		select {
+		case <-t.Closed():
+			return
+		case change, ok := <-sub.Values:
+			if !ok {
+				return
+			}
+			if !change.Complete {
+				continue
+			}
+			finished := false
+			for i, f := range files {
+				if done[i] || change.Index < f.BeginPieceIndex() || change.Index >= f.EndPieceIndex() {
+					continue
+				}
+				if f.BytesCompleted() < f.Length() {
+					continue
+				}
+				done[i] = true
+				stats := t.Stats()
+				if stats.BytesReadUsefulData.Int64() == 0 {
+					continue
+				}
+				index := i
+				e.publish(domain.Event{Type: domain.EventFileCompleted, TorrentID: id, FileIndex: &index, Path: f.Path()})
+				finished = true
+			}
+			if finished && e.getTorrent(id) == t {
+				// Derives the status and applies the completed transition.
+				_, _ = e.GetSessionState(context.Background(), id)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package anacrolix
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (p *recordingPublisher) Publish(event domain.Event) {
+	p.mu.Lock()
+	p.events = append(p.events, event)
+	p.mu.Unlock()
+}
+
+func (p *recordingPublisher) types() []domain.EventType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]domain.EventType, 0, len(p.events))
+	for _, ev := range p.events {
+		out = append(out, ev.Type)
+	}
+	return out
+}
+
+func (p *recordingPublisher) find(typ domain.EventType) (domain.Event, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ev := range p.events {
+		if ev.Type == typ {
+			return ev, true
+		}
+	}
+	return domain.Event{}, false
+}
+
+func TestTransitionPublishesEvents(t *testing.T) {
+	e := newTestEngine()
+	pub := &recordingPublisher{}
+	e.events = pub
+	id := domain.TorrentID("test")
+	e.modes[id] = domain.ModeDownloading
+	e.sessions[id] = nil
+
+	_ = e.transition(id, domain.ModeDownloading) // no-op, no event
+	if err := e.transition(id, domain.ModeCompleted); err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+	_ = e.transition(id, domain.ModeDownloading) // invalid, no event
+
+	got := pub.types()
+	if len(got) != 2 || got[0] != domain.EventModeChanged || got[1] != domain.EventCompleted {
+		t.Fatalf("events = %v", got)
+	}
+	if ev := pub.events[0]; ev.From != domain.ModeDownloading || ev.To != domain.ModeCompleted || ev.TorrentID != id {
+		t.Fatalf("mode event = %+v", ev)
+	}
+}
+
+func TestDownloadPublishesFileCompleted(t *testing.T) {
+	content := make([]byte, 64<<10)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	torrentPath := writeTestTorrent(t, "events.bin", content)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "events.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	e, _ := newWebSeedTestEngine(t)
+	pub := &recordingPublisher{}
+	e.events = pub
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, err := e.Open(ctx, domain.TorrentSource{Torrent: torrentPath, WebSeeds: []string{srv.URL + "/"}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, ok := pub.find(domain.EventMetadataReady); !ok {
+		t.Fatalf("metadata_ready not published: %v", pub.types())
+	}
+
+	for {
+		if _, ok := pub.find(domain.EventCompleted); ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("completion not published: %v", pub.types())
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	ev, ok := pub.find(domain.EventFileCompleted)
+	if !ok || ev.FileIndex == nil || *ev.FileIndex != 0 || ev.TorrentID != session.ID() {
+		t.Fatalf("file event = %+v (found %v)", ev, ok)
+	}
+}
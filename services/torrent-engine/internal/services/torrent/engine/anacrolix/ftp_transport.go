@@ -0,0 +1,252 @@
+package anacrolix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ftpTransport serves GET requests for ftp:// web seeds using passive-mode
+// FTP, so the anacrolix web seed client can fetch from FTP mirrors the same
+// way it fetches over HTTP. Byte ranges are served with REST and answered as
+// 206 Partial Content.
+type ftpTransport struct {
+	dialer net.Dialer
+}
+
+func (ft *ftpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("ftp: unsupported method %s", req.Method)
+	}
+	offset, length, ranged, err := parseByteRange(req.Header.Get("Range"))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	host := req.URL.Host
+	if req.URL.Port() == "" {
+		host = net.JoinHostPort(req.URL.Hostname(), "21")
+	}
+	conn, err := ft.dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	c := &ftpConn{text: textproto.NewConn(conn), conn: conn}
+	stop := context.AfterFunc(ctx, func() { c.closeAll() })
+
+	size, data, err := c.open(ctx, &ft.dialer, req, offset)
+	if err != nil {
+		stop()
+		c.closeAll()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	c.setData(data)
+
+	resp := &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		ContentLength: -1,
+		Request:       req,
+	}
+	var body io.Reader = data
+	switch {
+	case ranged:
+		if length < 0 && size >= 0 {
+			length = size - offset
+		}
+		resp.Status = "206 Partial Content"
+		resp.StatusCode = http.StatusPartialContent
+		if length >= 0 {
+			body = io.LimitReader(data, length)
+			resp.ContentLength = length
+		}
+	case size >= 0:
+		resp.ContentLength = size
+	}
+	resp.Body = &ftpBody{Reader: body, conn: c, stop: stop}
+	return resp, nil
+}
+
+// ftpConn is one control connection plus its data connection.
+type ftpConn struct {
+	text *textproto.Conn
+	conn net.Conn
+
+	mu   sync.Mutex // guards data; closeAll may run on context cancellation
+	data net.Conn
+}
+
+func (c *ftpConn) setData(data net.Conn) {
+	c.mu.Lock()
+	c.data = data
+	c.mu.Unlock()
+}
+
+// open logs in and starts the transfer of the requested file at offset.
+// size is -1 when the server does not report it.
+func (c *ftpConn) open(ctx context.Context, dialer *net.Dialer, req *http.Request, offset int64) (int64, net.Conn, error) {
+	if _, _, err := c.text.ReadResponse(220); err != nil {
+		return 0, nil, err
+	}
+
+	user, pass := "anonymous", "anonymous@"
+	if req.URL.User != nil {
+		user = req.URL.User.Username()
+		if p, ok := req.URL.User.Password(); ok {
+			pass = p
+		}
+	}
+	code, _, err := c.cmd(0, "USER %s", user)
+	if err != nil {
+		return 0, nil, err
+	}
+	if code == 331 {
+		if _, _, err := c.cmd(230, "PASS %s", pass); err != nil {
+			return 0, nil, err
+		}
+	} else if code != 230 {
+		return 0, nil, fmt.Errorf("ftp: login failed with code %d", code)
+	}
+	if _, _, err := c.cmd(200, "TYPE I"); err != nil {
+		return 0, nil, err
+	}
+
+	path := req.URL.Path
+	size := int64(-1)
+	if _, msg, err := c.cmd(213, "SIZE %s", path); err == nil {
+		if n, err := strconv.ParseInt(strings.TrimSpace(msg), 10, 64); err == nil {
+			size = n
+		}
+	}
+
+	_, msg, err := c.cmd(227, "PASV")
+	if err != nil {
+		return 0, nil, err
+	}
+	addr, err := parsePASV(msg, req.URL.Hostname())
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if offset > 0 {
+		if _, _, err := c.cmd(350, "REST %d", offset); err != nil {
+			data.Close()
+			return 0, nil, err
+		}
+	}
+	code, msg, err = c.cmd(0, "RETR %s", path)
+	if err != nil {
+		data.Close()
+		return 0, nil, err
+	}
+	if code != 125 && code != 150 {
+		data.Close()
+		return 0, nil, &textproto.Error{Code: code, Msg: msg}
+	}
+	return size, data, nil
+}
+
+// cmd sends a command and reads its reply. A zero expectCode accepts any
+// reply code.
+func (c *ftpConn) cmd(expectCode int, format string, args ...any) (int, string, error) {
+	if _, err := c.text.Cmd(format, args...); err != nil {
+		return 0, "", err
+	}
+	return c.text.ReadResponse(expectCode)
+}
+
+func (c *ftpConn) closeData() {
+	c.mu.Lock()
+	if c.data != nil {
+		c.data.Close()
+	}
+	c.mu.Unlock()
+}
+
+func (c *ftpConn) closeAll() {
+	c.closeData()
+	c.conn.Close()
+}
+
+// ftpBody closes the data connection, then the control connection, when
+// the web seed client is done with the response.
+type ftpBody struct {
+	io.Reader
+	conn *ftpConn
+	stop func() bool
+}
+
+func (b *ftpBody) Close() error {
+	b.stop()
+	b.conn.closeData()
+	// Best effort: the server replies 226 or, for an aborted transfer, 426.
+	_ = b.conn.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, _, _ = b.conn.text.ReadResponse(0)
+	_, _ = b.conn.text.Cmd("QUIT")
+	return b.conn.conn.Close()
+}
+
+// parsePASV extracts the data address from a 227 reply. The host from the
+// reply is replaced by the control host, as servers behind NAT often report
+// a private address.
+func parsePASV(msg, host string) (string, error) {
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("ftp: malformed PASV reply %q", msg)
+	}
+	fields := strings.Split(msg[start+1:end], ",")
+	if len(fields) != 6 {
+		return "", fmt.Errorf("ftp: malformed PASV reply %q", msg)
+	}
+	hi, err1 := strconv.Atoi(strings.TrimSpace(fields[4]))
+	lo, err2 := strconv.Atoi(strings.TrimSpace(fields[5]))
+	if err1 != nil || err2 != nil {
+		return "", fmt.Errorf("ftp: malformed PASV reply %q", msg)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(hi<<8|lo)), nil
+}
+
+// parseByteRange parses a single "bytes=start-end" range header. length is
+// -1 for an open-ended range.
+func parseByteRange(header string) (offset, length int64, ranged bool, err error) {
+	if header == "" {
+		return 0, -1, false, nil
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, false, errors.New("ftp: unsupported range " + header)
+	}
+	startRaw, endRaw, _ := strings.Cut(spec, "-")
+	offset, err = strconv.ParseInt(startRaw, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, false, errors.New("ftp: unsupported range " + header)
+	}
+	if endRaw == "" {
+		return offset, -1, true, nil
+	}
+	end, err := strconv.ParseInt(endRaw, 10, 64)
+	if err != nil || end < offset {
+		return 0, 0, false, errors.New("ftp: unsupported range " + header)
+	}
+	return offset, end - offset + 1, true, nil
+}
@@ -0,0 +1,69 @@
+package anacrolix
+
+import (
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+	infohash_v2 "github.com/anacrolix/torrent/types/infohash-v2"
+
+	"torrentstream/internal/domain"
+)
+
+// infoHashes holds the v1 and v2 info hashes of a torrent with metadata.
+type infoHashes struct {
+	v1, v2 domain.InfoHash
+}
+
+// infoHashes returns the v1 and v2 info hashes of t once metadata is
+// available. They are computed once per session, since hashing the info
+// dictionary of a large v2 torrent is costly and state is polled often.
+func (e *Engine) infoHashes(id domain.TorrentID, t *torrent.Torrent) (v1, v2 domain.InfoHash) {
+	e.hashMu.Lock()
+	defer e.hashMu.Unlock()
+	if h, ok := e.hashes[id]; ok {
+		return h.v1, h.v2
+	}
+	if !torrentInfoReady(t) {
+		return "", ""
+	}
+	v1, v2 = torrentInfoHashes(t)
+	e.hashes[id] = infoHashes{v1: v1, v2: v2}
+	return v1, v2
+}
+
+// forgetInfoHashes drops the cached hashes of a torrent removed from the
+// client.
+func (e *Engine) forgetInfoHashes(id domain.TorrentID) {
+	e.hashMu.Lock()
+	delete(e.hashes, id)
+	e.hashMu.Unlock()
+}
+
+// torrentInfoHashes returns the v1 and v2 info hashes of t once metadata is
+// available. v1 is empty for v2-only torrents and v2 for v1-only torrents.
+// anacrolix keys hybrid and v2 torrents by a short hash and does not expose
+// the full v2 hash, so both are computed from the info dictionary.
+func torrentInfoHashes(t *torrent.Torrent) (v1, v2 domain.InfoHash) {
+	if !torrentInfoReady(t) {
+		return "", ""
+	}
+	info := t.Info()
+	if !info.HasV2() {
+		return domain.InfoHash(t.InfoHash().HexString()), ""
+	}
+	infoBytes := t.Metainfo().InfoBytes
+	if info.HasV1() {
+		v1 = domain.InfoHash(metainfo.HashBytes(infoBytes).HexString())
+	}
+	hash := infohash_v2.HashBytes(infoBytes)
+	return v1, domain.InfoHash(hash.HexString())
+}
+
+// piecesRoot returns the hex v2 merkle root of f, or "" for v1 files and
+// empty files.
+func piecesRoot(f *torrent.File) string {
+	root := f.FileInfo().PiecesRoot
+	if !root.Ok {
+		return ""
+	}
+	return root.Value.HexString()
+}
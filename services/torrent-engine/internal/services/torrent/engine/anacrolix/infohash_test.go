@@ -0,0 +1,120 @@
+package anacrolix
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/merkle"
+	"github.com/anacrolix/torrent/metainfo"
+
+	"torrentstream/internal/domain"
+)
+
+// writeHybridTorrent builds a single-file hybrid (v1 + v2) torrent and
+// returns its path, the info dictionary bytes and the file's pieces root.
+func writeHybridTorrent(t *testing.T, name string, content []byte) (string, []byte, string) {
+	t.Helper()
+	srcPath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	info := metainfo.Info{PieceLength: 16 << 10}
+	if err := info.BuildFromFilePath(srcPath); err != nil {
+		t.Fatalf("build info: %v", err)
+	}
+	h := merkle.NewHash()
+	h.Write(content)
+	root := h.Sum(nil)
+	info.MetaVersion = 2
+	info.FileTree = metainfo.FileTree{Dir: map[string]metainfo.FileTree{
+		name: {File: metainfo.FileTreeFile{Length: int64(len(content)), PiecesRoot: string(root)}},
+	}}
+	infoBytes, err := bencode.Marshal(&info)
+	if err != nil {
+		t.Fatalf("marshal info: %v", err)
+	}
+	torrentPath := filepath.Join(t.TempDir(), name+".torrent")
+	f, err := os.Create(torrentPath)
+	if err != nil {
+		t.Fatalf("create torrent: %v", err)
+	}
+	defer f.Close()
+	if err := (&metainfo.MetaInfo{InfoBytes: infoBytes}).Write(f); err != nil {
+		t.Fatalf("write torrent: %v", err)
+	}
+	return torrentPath, infoBytes, hex.EncodeToString(root)
+}
+
+func TestSessionStateReportsHybridHashes(t *testing.T) {
+	torrentPath, infoBytes, root := writeHybridTorrent(t, "hybrid.bin", []byte("hybrid torrent payload"))
+	e, _ := newWebSeedTestEngine(t)
+	ctx := context.Background()
+
+	session, err := e.Open(ctx, domain.TorrentSource{Torrent: torrentPath})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	state, err := e.GetSessionState(ctx, session.ID())
+	if err != nil {
+		t.Fatalf("GetSessionState: %v", err)
+	}
+
+	v1 := sha1.Sum(infoBytes)
+	v2 := sha256.Sum256(infoBytes)
+	if state.InfoHash != domain.InfoHash(hex.EncodeToString(v1[:])) {
+		t.Fatalf("infoHash = %q", state.InfoHash)
+	}
+	if state.InfoHashV2 != domain.InfoHash(hex.EncodeToString(v2[:])) {
+		t.Fatalf("infoHashV2 = %q", state.InfoHashV2)
+	}
+	if session.ID() != domain.TorrentID(state.InfoHash) {
+		t.Fatalf("hybrid session id %q, want v1 hash", session.ID())
+	}
+
+	// The hashes are computed once and kept until the session is dropped.
+	e.hashMu.Lock()
+	cached := e.hashes[session.ID()]
+	e.hashMu.Unlock()
+	if cached.v2 != state.InfoHashV2 {
+		t.Fatalf("cached v2 = %q, want %q", cached.v2, state.InfoHashV2)
+	}
+	if err := e.RemoveSession(ctx, session.ID()); err != nil {
+		t.Fatalf("RemoveSession: %v", err)
+	}
+	e.hashMu.Lock()
+	_, kept := e.hashes[session.ID()]
+	e.hashMu.Unlock()
+	if kept {
+		t.Fatalf("hashes kept after the session was dropped")
+	}
+	if len(state.Files) != 1 || state.Files[0].PiecesRoot != root {
+		t.Fatalf("files = %+v, want pieces root %s", state.Files, root)
+	}
+}
+
+func TestSessionStateV1TorrentHasNoV2Hash(t *testing.T) {
+	torrentPath := writeTestTorrent(t, "v1.bin", []byte("v1 torrent payload"))
+	e, _ := newWebSeedTestEngine(t)
+	ctx := context.Background()
+
+	session, err := e.Open(ctx, domain.TorrentSource{Torrent: torrentPath})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	state, err := e.GetSessionState(ctx, session.ID())
+	if err != nil {
+		t.Fatalf("GetSessionState: %v", err)
+	}
+	if state.InfoHash != domain.InfoHash(session.ID()) || state.InfoHashV2 != "" {
+		t.Fatalf("hashes = %q, %q", state.InfoHash, state.InfoHashV2)
+	}
+	if len(state.Files) != 1 || state.Files[0].PiecesRoot != "" {
+		t.Fatalf("files = %+v", state.Files)
+	}
+}
@@ -0,0 +1,362 @@
+package anacrolix
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/iplist"
+
+	"torrentstream/internal/domain"
+)
+
+// Bounds of the hash failure history kept per torrent.
+const (
+	maxFailedPieces = 1000
+	maxBadPeers     = 200
+)
+
+// integrityTracker keeps the hash failures of one torrent. It outlives the
+// session so failures survive eviction; it is dropped with the torrent.
+type integrityTracker struct {
+	mu           sync.Mutex
+	hashFailures int64
+	wastedBytes  int64
+	pieces       map[int]*domain.PieceFailure
+	peers        map[string]*domain.BadPeer
+	peerBad      map[*torrent.PeerConn]int64 // bad pieces already counted per connection
+}
+
+func newIntegrityTracker() *integrityTracker {
+	return &integrityTracker{
+		pieces:  make(map[int]*domain.PieceFailure),
+		peers:   make(map[string]*domain.BadPeer),
+		peerBad: make(map[*torrent.PeerConn]int64),
+	}
+}
+
+func (tr *integrityTracker) recordFailure(index int, pieceLength int64, now time.Time) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.hashFailures++
+	tr.wastedBytes += pieceLength
+	f, ok := tr.pieces[index]
+	if !ok {
+		if len(tr.pieces) >= maxFailedPieces {
+			return
+		}
+		f = &domain.PieceFailure{Index: index}
+		tr.pieces[index] = f
+	}
+	f.Failures++
+	f.LastFailedAt = now
+}
+
+// notePeer accounts the bad pieces a connection contributed since it was
+// last seen. It reports whether the peer just reached banAfter.
+func (tr *integrityTracker) notePeer(pc *torrent.PeerConn, ip, client string, bad int64, now time.Time, closed bool, banAfter int) bool {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	delta := bad - tr.peerBad[pc]
+	if closed {
+		delete(tr.peerBad, pc)
+	} else {
+		tr.peerBad[pc] = bad
+	}
+	if delta <= 0 || ip == "" {
+		return false
+	}
+	p, ok := tr.peers[ip]
+	if !ok {
+		if len(tr.peers) >= maxBadPeers {
+			return false
+		}
+		p = &domain.BadPeer{IP: ip}
+		tr.peers[ip] = p
+	}
+	if client != "" {
+		p.Client = client
+	}
+	p.BadPieces += int(delta)
+	p.LastSeenAt = now
+	if banAfter > 0 && !p.Banned && p.BadPieces >= banAfter {
+		p.Banned = true
+		return true
+	}
+	return false
+}
+
+// fill copies the recorded failures into report, pieces by index and peers
+// by bad pieces.
+func (tr *integrityTracker) fill(report *domain.IntegrityReport) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	report.HashFailures = tr.hashFailures
+	report.WastedBytes = tr.wastedBytes
+	for _, f := range tr.pieces {
+		report.FailedPieces = append(report.FailedPieces, *f)
+	}
+	for _, p := range tr.peers {
+		report.BadPeers = append(report.BadPeers, *p)
+	}
+	slices.SortFunc(report.FailedPieces, func(a, b domain.PieceFailure) int { return a.Index - b.Index })
+	slices.SortFunc(report.BadPeers, func(a, b domain.BadPeer) int {
+		if a.BadPieces != b.BadPieces {
+			return b.BadPieces - a.BadPieces
+		}
+		if a.IP < b.IP {
+			return -1
+		}
+		if a.IP > b.IP {
+			return 1
+		}
+		return 0
+	})
+}
+
+// peerBans is an IP blocklist of peers banned for bad data. It is installed
+// as the client blocklist, which rejects new connections from listed IPs.
+type peerBans struct {
+	mu  sync.RWMutex
+	ips map[netip.Addr]struct{}
+}
+
+func newPeerBans() *peerBans {
+	return &peerBans{ips: make(map[netip.Addr]struct{})}
+}
+
+func (b *peerBans) add(ip string) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	b.ips[addr.Unmap()] = struct{}{}
+	b.mu.Unlock()
+}
+
+func (b *peerBans) Lookup(ip net.IP) (iplist.Range, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return iplist.Range{}, false
+	}
+	b.mu.RLock()
+	_, banned := b.ips[addr.Unmap()]
+	b.mu.RUnlock()
+	if !banned {
+		return iplist.Range{}, false
+	}
+	return iplist.Range{First: ip, Last: ip, Description: "bad data"}, true
+}
+
+func (b *peerBans) NumRanges() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.ips)
+}
+
+// Integrity reports the hash failures of an open session and the verified
+// state of each file.
+func (e *Engine) Integrity(ctx context.Context, id domain.TorrentID) (domain.IntegrityReport, error) {
+	t := e.getTorrent(id)
+	if t == nil {
+		return domain.IntegrityReport{}, ErrSessionNotFound
+	}
+	report := domain.IntegrityReport{
+		TorrentID:    id,
+		SessionOpen:  true,
+		FailedPieces: []domain.PieceFailure{},
+		BadPeers:     []domain.BadPeer{},
+		Files:        []domain.FileIntegrity{},
+		GeneratedAt:  time.Now().UTC(),
+	}
+	if tr := e.lookupIntegrity(id); tr != nil {
+		tr.fill(&report)
+	}
+	banned := e.client.BadPeerIPs()
+	for i := range report.BadPeers {
+		if slices.Contains(banned, report.BadPeers[i].IP) {
+			report.BadPeers[i].Banned = true
+		}
+	}
+	if !torrentInfoReady(t) {
+		return report, nil
+	}
+
+	info := t.Info()
+	report.PieceLength = info.PieceLength
+	report.NumPieces = t.NumPieces()
+	states := make([]torrent.PieceState, 0, report.NumPieces)
+	for _, run := range t.PieceStateRuns() {
+		for range run.Length {
+			states = append(states, run.PieceState)
+		}
+	}
+	complete := func(i int) bool { return i >= 0 && i < len(states) && states[i].Complete }
+
+	failed := make(map[int]struct{}, len(report.FailedPieces))
+	for i := range report.FailedPieces {
+		report.FailedPieces[i].Recovered = complete(report.FailedPieces[i].Index)
+		failed[report.FailedPieces[i].Index] = struct{}{}
+	}
+	for i, f := range t.Files() {
+		report.Files = append(report.Files, fileIntegrity(i, f.Path(), f.Length(), f.BeginPieceIndex(), f.EndPieceIndex(), states, failed))
+	}
+	return report, nil
+}
+
+// fileIntegrity summarizes the pieces [begin, end) of a file.
+func fileIntegrity(index int, path string, length int64, begin, end int, states []torrent.PieceState, failed map[int]struct{}) domain.FileIntegrity {
+	fi := domain.FileIntegrity{Index: index, Path: path, Length: length, Pieces: end - begin}
+	for p := begin; p < end && p < len(states); p++ {
+		state := states[p]
+		switch {
+		case state.Complete:
+			fi.CompletePieces++
+		case state.Partial || state.Checking:
+			fi.PartialPieces++
+		}
+		if _, ok := failed[p]; ok {
+			fi.FailedPieces = append(fi.FailedPieces, p)
+			if !state.Complete {
+				fi.CorruptedPieces++
+			}
+		}
+	}
+	switch {
+	case fi.CorruptedPieces > 0:
+		fi.Status = domain.FileCorrupted
+	case fi.CompletePieces == fi.Pieces:
+		fi.Status = domain.FileIntact
+	case fi.CompletePieces == 0 && fi.PartialPieces == 0:
+		fi.Status = domain.FileMissing
+	default:
+		fi.Status = domain.FilePartial
+	}
+	return fi
+}
+
+// watchIntegrity records the hash failures of t until it is closed. The
+// client has no hash failure callback: a piece that leaves the hash check
+// incomplete while the torrent's bad piece counter grew has failed, and the
+// connections whose own counters grew sent the bad data.
+func (e *Engine) watchIntegrity(t *torrent.Torrent) {
+	select {
+	case <-t.GotInfo():
+	case <-t.Closed():
+		return
+	}
+	id := domain.TorrentID(t.InfoHash().HexString())
+	tr := e.integrityFor(id, t)
+	if tr == nil {
+		return
+	}
+	pieceLength := t.Info().PieceLength
+
+	sub := t.SubscribePieceStateChanges()
+	defer sub.Close()
+	checking := make(map[int]struct{})
+	var counted int64 // failures of t already recorded
+	for {
+		select {
+		case <-t.Closed():
+			return
+		case change, ok := <-sub.Values:
+			if !ok {
+				return
+			}
+			if change.Checking || change.Marking {
+				checking[change.Index] = struct{}{}
+				continue
+			}
+			if _, ok := checking[change.Index]; !ok {
+				continue
+			}
+			delete(checking, change.Index)
+			stats := t.Stats()
+			if change.Complete || stats.PiecesDirtiedBad.Int64() <= counted {
+				continue
+			}
+			counted++
+			now := time.Now().UTC()
+			tr.recordFailure(change.Index, pieceLength, now)
+			for _, pc := range t.PeerConns() {
+				e.notePeer(tr, pc, now, false)
+			}
+		}
+	}
+}
+
+// peerConnClosed accounts bad data of a closed connection; peers the client
+// bans for a failed piece are usually gone before the failure is seen.
+func (e *Engine) peerConnClosed(pc *torrent.PeerConn) {
+	t := pc.Torrent()
+	if t == nil {
+		return
+	}
+	if tr := e.lookupIntegrity(domain.TorrentID(t.InfoHash().HexString())); tr != nil {
+		e.notePeer(tr, pc, time.Now().UTC(), true)
+	}
+}
+
+func (e *Engine) notePeer(tr *integrityTracker, pc *torrent.PeerConn, now time.Time, closed bool) {
+	ip := peerIP(pc)
+	client, _ := pc.PeerClientName.Load().(string)
+	stats := pc.Stats()
+	bad := stats.PiecesDirtiedBad.Int64()
+	if !tr.notePeer(pc, ip, client, bad, now, closed, e.banAfter) || e.bans == nil {
+		return
+	}
+	e.bans.add(ip)
+	if !closed {
+		_ = pc.Close()
+	}
+}
+
+// integrityFor returns the tracker of id, created if t is still its
+// session. Removal forgets the tracker after dropping the session, so a late
+// watcher cannot bring it back.
+func (e *Engine) integrityFor(id domain.TorrentID, t *torrent.Torrent) *integrityTracker {
+	e.integrityMu.Lock()
+	defer e.integrityMu.Unlock()
+	if e.getTorrent(id) != t {
+		return nil
+	}
+	if e.integrity == nil {
+		e.integrity = make(map[domain.TorrentID]*integrityTracker)
+	}
+	tr, ok := e.integrity[id]
+	if !ok {
+		tr = newIntegrityTracker()
+		e.integrity[id] = tr
+	}
+	return tr
+}
+
+func (e *Engine) lookupIntegrity(id domain.TorrentID) *integrityTracker {
+	e.integrityMu.Lock()
+	defer e.integrityMu.Unlock()
+	return e.integrity[id]
+}
+
+func (e *Engine) forgetIntegrity(id domain.TorrentID) {
+	e.integrityMu.Lock()
+	delete(e.integrity, id)
+	e.integrityMu.Unlock()
+}
+
+// peerIP returns the remote IP of pc without the port.
+func peerIP(pc *torrent.PeerConn) string {
+	if pc.RemoteAddr == nil {
+		return ""
+	}
+	addr := pc.RemoteAddr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
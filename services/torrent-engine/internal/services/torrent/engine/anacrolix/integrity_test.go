@@ -0,0 +1,129 @@
+package anacrolix
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent"
+
+	"torrentstream/internal/domain"
+)
+
+func TestIntegrityTrackerBansAfterThreshold(t *testing.T) {
+	tr := newIntegrityTracker()
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	pc := &torrent.PeerConn{}
+
+	if tr.notePeer(pc, "10.0.0.1", "Bad/1.0", 1, now, false, 2) {
+		t.Fatalf("banned after one bad piece")
+	}
+	// The same counter value is not counted twice.
+	if tr.notePeer(pc, "10.0.0.1", "", 1, now, false, 2) {
+		t.Fatalf("banned without new bad pieces")
+	}
+	if !tr.notePeer(pc, "10.0.0.1", "", 2, now, true, 2) {
+		t.Fatalf("expected ban at the second bad piece")
+	}
+	tr.notePeer(&torrent.PeerConn{}, "10.0.0.2", "", 3, now, false, 0)
+	tr.recordFailure(7, 16<<10, now)
+	tr.recordFailure(3, 16<<10, now)
+	tr.recordFailure(7, 16<<10, now)
+
+	var report domain.IntegrityReport
+	tr.fill(&report)
+	if report.HashFailures != 3 || report.WastedBytes != 3*16<<10 {
+		t.Fatalf("failures = %d, wasted = %d", report.HashFailures, report.WastedBytes)
+	}
+	if len(report.FailedPieces) != 2 || report.FailedPieces[0].Index != 3 || report.FailedPieces[1].Failures != 2 {
+		t.Fatalf("failed pieces = %+v", report.FailedPieces)
+	}
+	if len(report.BadPeers) != 2 || report.BadPeers[0].IP != "10.0.0.2" || report.BadPeers[1].Client != "Bad/1.0" || !report.BadPeers[1].Banned {
+		t.Fatalf("bad peers = %+v", report.BadPeers)
+	}
+	if len(tr.peerBad) != 1 {
+		t.Fatalf("closed connection not forgotten: %d tracked", len(tr.peerBad))
+	}
+}
+
+func TestPeerBansLookup(t *testing.T) {
+	bans := newPeerBans()
+	bans.add("192.0.2.7")
+	bans.add("not an ip")
+
+	if _, ok := bans.Lookup(net.ParseIP("192.0.2.7")); !ok {
+		t.Fatalf("v4-in-v6 address not blocked")
+	}
+	if _, ok := bans.Lookup(net.ParseIP("192.0.2.7").To4()); !ok {
+		t.Fatalf("v4 address not blocked")
+	}
+	if _, ok := bans.Lookup(net.ParseIP("192.0.2.8")); ok {
+		t.Fatalf("unbanned address blocked")
+	}
+	if bans.NumRanges() != 1 {
+		t.Fatalf("ranges = %d", bans.NumRanges())
+	}
+}
+
+func TestFileIntegrityStatus(t *testing.T) {
+	states := make([]torrent.PieceState, 6)
+	for _, i := range []int{0, 1, 2, 4} {
+		states[i].Complete = true
+	}
+	states[5].Partial = true
+	failed := map[int]struct{}{1: {}, 3: {}}
+
+	intact := fileIntegrity(0, "a.mkv", 100, 0, 2, states, failed)
+	if intact.Status != domain.FileIntact || intact.CompletePieces != 2 || len(intact.FailedPieces) != 1 || intact.CorruptedPieces != 0 {
+		t.Fatalf("recovered file = %+v", intact)
+	}
+	corrupted := fileIntegrity(1, "b.mkv", 100, 2, 4, states, failed)
+	if corrupted.Status != domain.FileCorrupted || corrupted.CorruptedPieces != 1 {
+		t.Fatalf("corrupted file = %+v", corrupted)
+	}
+	partial := fileIntegrity(2, "c.mkv", 100, 4, 6, states, failed)
+	if partial.Status != domain.FilePartial || partial.PartialPieces != 1 {
+		t.Fatalf("partial file = %+v", partial)
+	}
+	missing := fileIntegrity(3, "d.mkv", 100, 3, 3, nil, nil)
+	if missing.Status != domain.FileIntact || missing.Pieces != 0 {
+		t.Fatalf("empty file = %+v", missing)
+	}
+}
+
+func TestIntegrityReportsOpenSession(t *testing.T) {
+	e, _ := newWebSeedTestEngine(t)
+	ctx := context.Background()
+
+	if _, err := e.Integrity(ctx, "missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	torrentPath := writeTestTorrent(t, "integrity.bin", make([]byte, 48<<10))
+	session, err := e.Open(ctx, domain.TorrentSource{Torrent: torrentPath})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	id := session.ID()
+	e.integrityFor(id, e.getTorrent(id)).recordFailure(1, 16<<10, time.Now())
+
+	report, err := e.Integrity(ctx, id)
+	if err != nil {
+		t.Fatalf("integrity: %v", err)
+	}
+	if !report.SessionOpen || report.NumPieces != 3 || report.PieceLength != 16<<10 || report.HashFailures != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Files) != 1 || report.Files[0].Status != domain.FileCorrupted || report.Files[0].Pieces != 3 {
+		t.Fatalf("files = %+v", report.Files)
+	}
+
+	if err := e.RemoveSession(ctx, id); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if e.lookupIntegrity(id) != nil {
+		t.Fatalf("integrity history kept after removal")
+	}
+}
@@ -0,0 +1,110 @@
+package anacrolix
+
+import (
+	"slices"
+	"time"
+
+	"github.com/anacrolix/torrent"
+	pp "github.com/anacrolix/torrent/peer_protocol"
+)
+
+// dhtAnnounceInterval is how long one DHT announce runs before it is
+// restarted, matching the anacrolix client.
+const dhtAnnounceInterval = 5 * time.Minute
+
+// isPrivate reports whether t carries the BEP 27 private flag. It is false
+// until metadata is available.
+func isPrivate(t *torrent.Torrent) bool {
+	info := t.Info()
+	return info != nil && info.Private != nil && *info.Private
+}
+
+// dhtAnnouncer replaces the client's periodic DHT announces so private
+// torrents are never announced: BEP 27 limits them to their trackers.
+// Magnets are announced until metadata shows whether they are private,
+// since the DHT may be the only way to fetch it.
+func (e *Engine) dhtAnnouncer(t *torrent.Torrent) {
+	for {
+		if isPrivate(t) {
+			return
+		}
+		var stops []func()
+		for _, s := range e.client.DhtServers() {
+			if _, stop, err := t.AnnounceToDht(s); err == nil {
+				stops = append(stops, stop)
+			}
+		}
+		gotInfo := t.GotInfo()
+		if t.Info() != nil {
+			gotInfo = nil
+		}
+		closed := false
+		select {
+		case <-t.Closed():
+			closed = true
+		case <-gotInfo:
+		case <-time.After(dhtAnnounceInterval):
+		}
+		for _, stop := range stops {
+			stop()
+		}
+		if closed {
+			return
+		}
+	}
+}
+
+// setPrivacyCallbacks keeps PEX off for private torrents (BEP 27).
+func setPrivacyCallbacks(cfg *torrent.ClientConfig) {
+	cfg.Callbacks.PeerConnAdded = append(cfg.Callbacks.PeerConnAdded, disablePrivatePEX)
+	cfg.Callbacks.ReadExtendedHandshake = ignorePrivatePEX
+}
+
+// ignorePrivatePEX drops ut_pex from the extended handshake a peer of a
+// private torrent sends, so the client never starts sending it PEX. It runs
+// before the client reads the handshake.
+func ignorePrivatePEX(pc *torrent.PeerConn, msg *pp.ExtendedHandshakeMessage) {
+	if isPrivate(pc.Torrent()) {
+		delete(msg.M, pp.ExtensionNamePex)
+	}
+}
+
+// dropPrePrivateConns waits for the metadata of a magnet and, when it shows
+// the torrent is private, drops the connections made before. Those offered
+// PEX in their handshake and may have it running, which the client cannot
+// turn off on a live connection; the trackers supply the peers again.
+func dropPrePrivateConns(t *torrent.Torrent) {
+	if t.Info() != nil {
+		return
+	}
+	select {
+	case <-t.Closed():
+		return
+	case <-t.GotInfo():
+	}
+	if !isPrivate(t) {
+		return
+	}
+	for _, pc := range t.PeerConns() {
+		_ = pc.Close()
+	}
+}
+
+// disablePrivatePEX removes the ut_pex extension from connections of
+// private torrents, so peers are neither sent to nor accepted from PEX.
+// The protocol map is shared by all connections and is copied first.
+func disablePrivatePEX(pc *torrent.PeerConn) {
+	if !isPrivate(pc.Torrent()) || pc.LocalLtepProtocolMap == nil {
+		return
+	}
+	local := *pc.LocalLtepProtocolMap
+	i := slices.Index(local.Index, pp.ExtensionNamePex)
+	if i < 0 {
+		return
+	}
+	local.Index = slices.Delete(slices.Clone(local.Index), i, i+1)
+	if i < local.NumBuiltin {
+		local.NumBuiltin--
+	}
+	pc.LocalLtepProtocolMap = &local
+}
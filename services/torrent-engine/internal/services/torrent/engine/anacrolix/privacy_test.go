@@ -0,0 +1,180 @@
+package anacrolix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+
+	"torrentstream/internal/domain"
+)
+
+// writePrivateTorrent writes a private torrent of content and returns its
+// metainfo and the directory holding the data.
+func writePrivateTorrent(t *testing.T, content []byte) (metainfo.MetaInfo, string) {
+	t.Helper()
+	dataDir := t.TempDir()
+	srcPath := filepath.Join(dataDir, "private.bin")
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	private := true
+	info := metainfo.Info{PieceLength: 16 << 10, Private: &private}
+	if err := info.BuildFromFilePath(srcPath); err != nil {
+		t.Fatalf("build info: %v", err)
+	}
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatalf("marshal info: %v", err)
+	}
+	return metainfo.MetaInfo{InfoBytes: infoBytes, Announce: "http://t.example/announce?passkey=secret"}, dataDir
+}
+
+func TestPrivateTorrentState(t *testing.T) {
+	mi, _ := writePrivateTorrent(t, make([]byte, 20<<10))
+	torrentPath := filepath.Join(t.TempDir(), "private.torrent")
+	f, err := os.Create(torrentPath)
+	if err != nil {
+		t.Fatalf("create torrent: %v", err)
+	}
+	if err := mi.Write(f); err != nil {
+		t.Fatalf("write torrent: %v", err)
+	}
+	f.Close()
+
+	e, _ := newWebSeedTestEngine(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	session, err := e.Open(ctx, domain.TorrentSource{Torrent: torrentPath})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	tor := e.getTorrent(session.ID())
+	if !isPrivate(tor) {
+		t.Fatalf("isPrivate = false for a private torrent")
+	}
+	state, err := e.GetSessionState(ctx, session.ID())
+	if err != nil {
+		t.Fatalf("GetSessionState: %v", err)
+	}
+	if !state.Private {
+		t.Fatalf("state.Private = false")
+	}
+
+	// The announcer returns at once instead of announcing to the DHT.
+	done := make(chan struct{})
+	go func() {
+		e.dhtAnnouncer(tor)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("dhtAnnouncer kept running for a private torrent")
+	}
+}
+
+func TestPrivateMagnetDropsConnsMadeBeforeMetadata(t *testing.T) {
+	mi, seedDir := writePrivateTorrent(t, make([]byte, 64<<10))
+
+	seedCfg := torrent.NewDefaultClientConfig()
+	seedCfg.DataDir = seedDir
+	seedCfg.ListenPort = 0
+	seedCfg.NoDHT = true
+	seedCfg.DisableTrackers = true
+	seedCfg.NoDefaultPortForwarding = true
+	seedCfg.Seed = true
+	seeder, err := torrent.NewClient(seedCfg)
+	if err != nil {
+		t.Fatalf("new seeder: %v", err)
+	}
+	defer seeder.Close()
+	seedTorrent, err := seeder.AddTorrent(&mi)
+	if err != nil {
+		t.Fatalf("add to seeder: %v", err)
+	}
+	seedTorrent.VerifyData()
+
+	// Connections added while the metadata is unknown negotiate PEX.
+	var (
+		mu    sync.Mutex
+		early = make(map[*torrent.PeerConn]bool)
+	)
+	cfg := torrent.NewDefaultClientConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.ListenPort = 0
+	cfg.NoDHT = true
+	cfg.DisableTrackers = true
+	cfg.NoDefaultPortForwarding = true
+	setPrivacyCallbacks(cfg)
+	cfg.Callbacks.PeerConnAdded = append(cfg.Callbacks.PeerConnAdded, func(pc *torrent.PeerConn) {
+		if pc.Torrent().Info() == nil {
+			mu.Lock()
+			early[pc] = true
+			mu.Unlock()
+		}
+	})
+	client, err := torrent.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	e := NewWithClient(client)
+	defer e.Close()
+
+	hash := mi.HashInfoBytes()
+	id := domain.TorrentID(hash.HexString())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opened := make(chan error, 1)
+	go func() {
+		_, err := e.Open(ctx, domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:" + hash.HexString()})
+		opened <- err
+	}()
+
+	var tor *torrent.Torrent
+	for tor == nil {
+		if ctx.Err() != nil {
+			t.Fatalf("magnet session not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+		tor = e.getTorrent(id)
+	}
+	tor.AddClientPeer(seeder)
+	if err := <-opened; err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !isPrivate(tor) {
+		t.Fatalf("isPrivate = false after metadata")
+	}
+	mu.Lock()
+	if len(early) == 0 {
+		mu.Unlock()
+		t.Fatalf("no connection was made before metadata")
+	}
+	mu.Unlock()
+
+	for {
+		kept := 0
+		mu.Lock()
+		for _, pc := range tor.PeerConns() {
+			if early[pc] {
+				kept++
+			}
+		}
+		mu.Unlock()
+		if kept == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("%d connections made before metadata kept PEX", kept)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
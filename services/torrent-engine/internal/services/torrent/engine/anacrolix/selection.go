@@ -0,0 +1,90 @@
+package anacrolix
+
+import (
+	"slices"
+
+	"github.com/anacrolix/torrent"
+
+	"torrentstream/internal/domain"
+)
+
+// setSelection limits the download of a session to the given file indices
+// (BEP 53 select-only). An empty list downloads every file.
+func (e *Engine) setSelection(id domain.TorrentID, files []int) {
+	e.selectMu.Lock()
+	defer e.selectMu.Unlock()
+	if len(files) == 0 {
+		delete(e.selected, id)
+		return
+	}
+	e.selected[id] = slices.Clone(files)
+}
+
+func (e *Engine) selection(id domain.TorrentID) []int {
+	e.selectMu.Lock()
+	defer e.selectMu.Unlock()
+	return e.selected[id]
+}
+
+// forgetSelection drops the file selection of a torrent removed from the
+// client.
+func (e *Engine) forgetSelection(id domain.TorrentID) {
+	e.selectMu.Lock()
+	delete(e.selected, id)
+	e.selectMu.Unlock()
+}
+
+// downloadAll queues every file of t for download, or only the selected
+// ones when the torrent was added with a select-only list.
+func (e *Engine) downloadAll(t *torrent.Torrent) {
+	selected := e.selection(domain.TorrentID(t.InfoHash().HexString()))
+	if len(selected) == 0 {
+		t.DownloadAll()
+		return
+	}
+	for i, f := range t.Files() {
+		if slices.Contains(selected, i) {
+			f.SetPriority(torrent.PiecePriorityNormal)
+		} else {
+			f.SetPriority(torrent.PiecePriorityNone)
+		}
+	}
+}
+
+// selectedProgress returns the length and completed bytes of the selected
+// files of t, so a partial download completes once they are done. ok is
+// false when every file is selected.
+func (e *Engine) selectedProgress(id domain.TorrentID, t *torrent.Torrent) (length, completed int64, ok bool) {
+	selected := e.selection(id)
+	if len(selected) == 0 {
+		return 0, 0, false
+	}
+	files := t.Files()
+	for _, i := range selected {
+		if i < 0 || i >= len(files) {
+			continue
+		}
+		length += files[i].Length()
+		completed += files[i].BytesCompleted()
+	}
+	return length, completed, true
+}
+
+// mergeTrackers appends the announce URLs not yet listed in tiers as an
+// extra tier.
+func mergeTrackers(tiers [][]string, urls []string) [][]string {
+	var extra []string
+	for _, u := range urls {
+		known := slices.Contains(extra, u)
+		for _, tier := range tiers {
+			known = known || slices.Contains(tier, u)
+		}
+		if !known && u != "" {
+			extra = append(extra, u)
+		}
+	}
+	if len(extra) == 0 {
+		return tiers
+	}
+	return append(tiers, extra)
+}
@@ -0,0 +1,106 @@
+package anacrolix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+
+	"torrentstream/internal/domain"
+)
+
+// writeMultiFileTorrent builds a torrent of a directory holding the given
+// files and returns the path of the .torrent file.
+func writeMultiFileTorrent(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "pack")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), content, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	info := metainfo.Info{PieceLength: 16 << 10}
+	if err := info.BuildFromFilePath(root); err != nil {
+		t.Fatalf("build info: %v", err)
+	}
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatalf("marshal info: %v", err)
+	}
+	mi := metainfo.MetaInfo{InfoBytes: infoBytes}
+	torrentPath := filepath.Join(t.TempDir(), "pack.torrent")
+	f, err := os.Create(torrentPath)
+	if err != nil {
+		t.Fatalf("create torrent: %v", err)
+	}
+	defer f.Close()
+	if err := mi.Write(f); err != nil {
+		t.Fatalf("write torrent: %v", err)
+	}
+	return torrentPath
+}
+
+func TestSelectOnlyDownloadsSelectedFiles(t *testing.T) {
+	torrentPath := writeMultiFileTorrent(t, map[string][]byte{
+		"a.bin": make([]byte, 32<<10),
+		"b.bin": make([]byte, 32<<10),
+		"c.bin": make([]byte, 32<<10),
+	})
+	e, _ := newWebSeedTestEngine(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := e.Open(ctx, domain.TorrentSource{Torrent: torrentPath, SelectedFiles: []int{1}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	for i, f := range e.getTorrent(session.ID()).Files() {
+		want := torrent.PiecePriorityNone
+		if i == 1 {
+			want = torrent.PiecePriorityNormal
+		}
+		if got := f.Priority(); got != want {
+			t.Errorf("file %d priority = %v, want %v", i, got, want)
+		}
+	}
+
+	state, err := e.GetSessionState(ctx, session.ID())
+	if err != nil {
+		t.Fatalf("GetSessionState: %v", err)
+	}
+	if state.Progress >= 1 {
+		t.Fatalf("progress = %v before any data was downloaded", state.Progress)
+	}
+
+	if err := e.RemoveSession(ctx, session.ID()); err != nil {
+		t.Fatalf("RemoveSession: %v", err)
+	}
+	if got := e.selection(session.ID()); got != nil {
+		t.Fatalf("selection not forgotten: %v", got)
+	}
+}
+
+func TestMergeTrackers(t *testing.T) {
+	tiers := [][]string{{"udp://a:1"}, {"udp://b:2"}}
+	got := mergeTrackers(tiers, []string{"udp://b:2", "http://c/announce", "", "http://c/announce"})
+	want := [][]string{{"udp://a:1"}, {"udp://b:2"}, {"http://c/announce"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("mergeTrackers = %v, want %v", got, want)
+	}
+	if got := mergeTrackers(tiers, []string{"udp://a:1"}); len(got) != 2 {
+		t.Fatalf("known trackers must not add a tier: %v", got)
+	}
+}
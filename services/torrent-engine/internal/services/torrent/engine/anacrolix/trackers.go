@@ -0,0 +1,22 @@
+package anacrolix
+
+import (
+	"context"
+
+	"torrentstream/internal/domain"
+)
+
+// AddTrackers adds urls as an extra announce tier of an open session.
+// Private torrents are left unchanged: BEP 27 limits them to the trackers
+// of their metainfo.
+func (e *Engine) AddTrackers(ctx context.Context, id domain.TorrentID, urls []string) error {
+	t := e.getTorrent(id)
+	if t == nil {
+		return ErrSessionNotFound
+	}
+	if len(urls) == 0 || isPrivate(t) {
+		return nil
+	}
+	t.AddTrackers([][]string{urls})
+	return nil
+}
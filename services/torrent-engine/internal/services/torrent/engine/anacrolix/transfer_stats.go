@@ -0,0 +1,77 @@
+package anacrolix
+
+import (
+	"github.com/anacrolix/dht/v2"
+	"github.com/anacrolix/torrent"
+
+	"torrentstream/internal/domain"
+)
+
+// TransferStats returns client-wide transfer counters since the engine
+// started. Hash failures of dropped torrents are kept, so the counters
+// never go backwards.
+func (e *Engine) TransferStats() domain.EngineStats {
+	stats := e.client.Stats()
+	out := domain.EngineStats{
+		Downloaded: stats.BytesReadUsefulData.Int64(),
+		Uploaded:   stats.BytesWrittenData.Int64(),
+		DHTNodes:   dhtNodes(e.client),
+	}
+
+	e.mu.Lock()
+	torrents := make([]*torrent.Torrent, 0, len(e.sessions))
+	for _, t := range e.sessions {
+		torrents = append(torrents, t)
+	}
+	e.mu.Unlock()
+
+	e.wasteMu.Lock()
+	out.HashFailures = e.retiredHashFailures
+	out.WastedBytes = e.retiredWastedBytes
+	e.wasteMu.Unlock()
+	for _, t := range torrents {
+		pieces, bytes := hashFailures(t)
+		out.HashFailures += pieces
+		out.WastedBytes += bytes
+	}
+	return out
+}
+
+// retireHashFailures keeps the hash failures of a torrent about to be
+// dropped, whose own counters go away with it.
+func (e *Engine) retireHashFailures(t *torrent.Torrent) {
+	if t == nil {
+		return
+	}
+	pieces, bytes := hashFailures(t)
+	if pieces == 0 {
+		return
+	}
+	e.wasteMu.Lock()
+	e.retiredHashFailures += pieces
+	e.retiredWastedBytes += bytes
+	e.wasteMu.Unlock()
+}
+
+// hashFailures returns the number of pieces of t that failed the hash check
+// and the bytes downloaded for them.
+func hashFailures(t *torrent.Torrent) (pieces, bytes int64) {
+	info := t.Info()
+	if info == nil {
+		return 0, 0
+	}
+	stats := t.Stats()
+	pieces = stats.PiecesDirtiedBad.Int64()
+	return pieces, pieces * info.PieceLength
+}
+
+// dhtNodes returns the routing table size summed over the DHT servers.
+func dhtNodes(client *torrent.Client) int {
+	nodes := 0
+	for _, s := range client.DhtServers() {
+		if stats, ok := s.Stats().(dht.ServerStats); ok {
+			nodes += stats.Nodes
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,19 @@
+package anacrolix
+
+import "testing"
+
+func TestTransferStatsIncludesRetiredHashFailures(t *testing.T) {
+	e, _ := newWebSeedTestEngine(t)
+
+	stats := e.TransferStats()
+	if stats.Downloaded != 0 || stats.Uploaded != 0 || stats.HashFailures != 0 || stats.DHTNodes != 0 {
+		t.Fatalf("fresh engine stats = %+v", stats)
+	}
+
+	e.retiredHashFailures = 2
+	e.retiredWastedBytes = 2 << 20
+	stats = e.TransferStats()
+	if stats.HashFailures != 2 || stats.WastedBytes != 2<<20 {
+		t.Fatalf("stats = %+v, want retired hash failures", stats)
+	}
+}
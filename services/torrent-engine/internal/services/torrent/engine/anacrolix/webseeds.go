@@ -0,0 +1,217 @@
+package anacrolix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/webseed"
+
+	"torrentstream/internal/domain"
+)
+
+// errWebSeedRemoved is returned for requests to a web seed that was removed
+// while the torrent is loaded; anacrolix has no API to drop a web seed, so
+// the seed is starved instead.
+var errWebSeedRemoved = errors.New("web seed removed")
+
+// webSeedTracker is the HTTP transport of one web seed of one torrent. It
+// counts requests, downloaded bytes and failures for the stats endpoint.
+type webSeedTracker struct {
+	url  string
+	next http.RoundTripper
+
+	mu          sync.Mutex
+	removed     bool
+	bytes       int64
+	requests    int64
+	errors      int64
+	lastError   string
+	lastErrorAt time.Time
+}
+
+func (w *webSeedTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	w.mu.Lock()
+	removed := w.removed
+	if !removed {
+		w.requests++
+	}
+	w.mu.Unlock()
+	if removed {
+		return nil, errWebSeedRemoved
+	}
+
+	resp, err := w.next.RoundTrip(req)
+	if err != nil {
+		w.fail(err)
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		w.fail(fmt.Errorf("HTTP %s", resp.Status))
+	}
+	resp.Body = &webSeedBody{ReadCloser: resp.Body, tracker: w, ctx: req.Context()}
+	return resp, nil
+}
+
+// fail records a request error. Cancellations are not failures: anacrolix
+// cancels web seed requests whenever peers deliver the pieces first.
+func (w *webSeedTracker) fail(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	w.mu.Lock()
+	w.errors++
+	w.lastError = err.Error()
+	w.lastErrorAt = time.Now().UTC()
+	w.mu.Unlock()
+}
+
+func (w *webSeedTracker) addBytes(n int) {
+	w.mu.Lock()
+	w.bytes += int64(n)
+	w.mu.Unlock()
+}
+
+func (w *webSeedTracker) setRemoved(removed bool) {
+	w.mu.Lock()
+	w.removed = removed
+	w.mu.Unlock()
+}
+
+func (w *webSeedTracker) snapshot() (domain.WebSeed, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ws := domain.WebSeed{
+		URL:             w.url,
+		BytesDownloaded: w.bytes,
+		Requests:        w.requests,
+		Errors:          w.errors,
+		LastError:       w.lastError,
+	}
+	if !w.lastErrorAt.IsZero() {
+		at := w.lastErrorAt
+		ws.LastErrorAt = &at
+	}
+	return ws, !w.removed
+}
+
+// webSeedBody counts response bytes and records read failures.
+type webSeedBody struct {
+	io.ReadCloser
+	tracker *webSeedTracker
+	ctx     context.Context
+}
+
+func (b *webSeedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.tracker.addBytes(n)
+	}
+	if err != nil && !errors.Is(err, io.EOF) && b.ctx.Err() == nil {
+		b.tracker.fail(err)
+	}
+	return n, err
+}
+
+// newWebSeedTransport returns the transport shared by all web seeds: plain
+// HTTP(S) plus ftp:// through ftpTransport.
+func newWebSeedTransport() http.RoundTripper {
+	tr := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		// Matches the anacrolix default for web seed hosts.
+		MaxConnsPerHost: 10,
+	}
+	tr.RegisterProtocol("ftp", &ftpTransport{})
+	return tr
+}
+
+// addWebSeeds attaches urls to t, each through its own tracker. URLs that
+// are already attached are re-enabled if they were removed.
+func (e *Engine) addWebSeeds(t *torrent.Torrent, urls []string) {
+	if t == nil || len(urls) == 0 {
+		return
+	}
+	id := domain.TorrentID(t.InfoHash().HexString())
+
+	e.webSeedMu.Lock()
+	defer e.webSeedMu.Unlock()
+	if e.webTransport == nil {
+		e.webTransport = newWebSeedTransport()
+	}
+	seeds := e.webSeeds[id]
+	if seeds == nil {
+		seeds = make(map[string]*webSeedTracker)
+		e.webSeeds[id] = seeds
+	}
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		if tracker, ok := seeds[u]; ok {
+			tracker.setRemoved(false)
+			continue
+		}
+		tracker := &webSeedTracker{url: u, next: e.webTransport}
+		seeds[u] = tracker
+		t.AddWebSeeds([]string{u}, func(c *webseed.Client) {
+			c.HttpClient = &http.Client{Transport: tracker}
+		})
+	}
+}
+
+// forgetWebSeeds drops the trackers of a torrent removed from the client.
+func (e *Engine) forgetWebSeeds(id domain.TorrentID) {
+	e.webSeedMu.Lock()
+	delete(e.webSeeds, id)
+	e.webSeedMu.Unlock()
+}
+
+// AddWebSeeds adds BEP 19 web seeds to an open session.
+func (e *Engine) AddWebSeeds(ctx context.Context, id domain.TorrentID, urls []string) error {
+	t := e.getTorrent(id)
+	if t == nil {
+		return ErrSessionNotFound
+	}
+	e.addWebSeeds(t, urls)
+	return nil
+}
+
+// RemoveWebSeed stops requesting data from url. The seed stays attached to
+// the anacrolix torrent until the session is reloaded, but every request to
+// it fails immediately.
+func (e *Engine) RemoveWebSeed(ctx context.Context, id domain.TorrentID, url string) error {
+	if e.getTorrent(id) == nil {
+		return ErrSessionNotFound
+	}
+	e.webSeedMu.Lock()
+	defer e.webSeedMu.Unlock()
+	tracker, ok := e.webSeeds[id][url]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	tracker.setRemoved(true)
+	return nil
+}
+
+// WebSeeds lists the active web seeds of a session, sorted by URL.
+func (e *Engine) WebSeeds(ctx context.Context, id domain.TorrentID) ([]domain.WebSeed, error) {
+	if e.getTorrent(id) == nil {
+		return nil, ErrSessionNotFound
+	}
+	e.webSeedMu.Lock()
+	seeds := make([]domain.WebSeed, 0, len(e.webSeeds[id]))
+	for _, tracker := range e.webSeeds[id] {
+		if ws, active := tracker.snapshot(); active {
+			seeds = append(seeds, ws)
+		}
+	}
+	e.webSeedMu.Unlock()
+	sort.Slice(seeds, func(i, j int) bool { return seeds[i].URL < seeds[j].URL })
+	return seeds, nil
+}
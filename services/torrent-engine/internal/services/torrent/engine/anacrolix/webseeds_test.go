@@ -0,0 +1,314 @@
+package anacrolix
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+
+	"torrentstream/internal/domain"
+)
+
+// writeTestTorrent builds a single-file torrent for content and returns the
+// path of the .torrent file.
+func writeTestTorrent(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	srcDir := t.TempDir()
+	srcPath := filepath.Join(srcDir, name)
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	info := metainfo.Info{PieceLength: 16 << 10}
+	if err := info.BuildFromFilePath(srcPath); err != nil {
+		t.Fatalf("build info: %v", err)
+	}
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatalf("marshal info: %v", err)
+	}
+	mi := metainfo.MetaInfo{InfoBytes: infoBytes}
+	torrentPath := filepath.Join(t.TempDir(), name+".torrent")
+	f, err := os.Create(torrentPath)
+	if err != nil {
+		t.Fatalf("create torrent: %v", err)
+	}
+	defer f.Close()
+	if err := mi.Write(f); err != nil {
+		t.Fatalf("write torrent: %v", err)
+	}
+	return torrentPath
+}
+
+// newWebSeedTestEngine returns an engine whose client has no peer sources,
+// so web seeds are the only way to get data.
+func newWebSeedTestEngine(t *testing.T) (*Engine, string) {
+	t.Helper()
+	dataDir := t.TempDir()
+	cfg := torrent.NewDefaultClientConfig()
+	cfg.DataDir = dataDir
+	cfg.ListenPort = 0
+	cfg.NoDHT = true
+	cfg.DisablePEX = true
+	cfg.DisableTrackers = true
+	cfg.NoDefaultPortForwarding = true
+	client, err := torrent.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	e := NewWithClient(client)
+	t.Cleanup(func() { _ = e.Close() })
+	return e, dataDir
+}
+
+func TestWebSeedDownloadsFromLocalHTTPServer(t *testing.T) {
+	content := make([]byte, 100<<10)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	torrentPath := writeTestTorrent(t, "dataset.bin", content)
+
+	mirror := http.NewServeMux()
+	mirror.HandleFunc("/mirror/dataset.bin", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "dataset.bin", time.Time{}, bytes.NewReader(content))
+	})
+	srv := httptest.NewServer(mirror)
+	defer srv.Close()
+	seedURL := srv.URL + "/mirror/"
+
+	e, dataDir := newWebSeedTestEngine(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, err := e.Open(ctx, domain.TorrentSource{Torrent: torrentPath, WebSeeds: []string{seedURL}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	tor := e.getTorrent(session.ID())
+	for tor.BytesCompleted() < int64(len(content)) {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("download timed out at %d/%d bytes", tor.BytesCompleted(), len(content))
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, "dataset.bin"))
+	if err != nil {
+		t.Fatalf("read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("downloaded content differs")
+	}
+
+	seeds, err := e.WebSeeds(ctx, session.ID())
+	if err != nil {
+		t.Fatalf("WebSeeds: %v", err)
+	}
+	if len(seeds) != 1 || seeds[0].URL != seedURL {
+		t.Fatalf("seeds = %+v", seeds)
+	}
+	if seeds[0].BytesDownloaded < int64(len(content)) || seeds[0].Requests == 0 {
+		t.Fatalf("unexpected stats: %+v", seeds[0])
+	}
+
+	if err := e.RemoveWebSeed(ctx, session.ID(), seedURL); err != nil {
+		t.Fatalf("RemoveWebSeed: %v", err)
+	}
+	if seeds, _ := e.WebSeeds(ctx, session.ID()); len(seeds) != 0 {
+		t.Fatalf("removed seed still listed: %+v", seeds)
+	}
+	if err := e.RemoveWebSeed(ctx, session.ID(), "http://unknown/"); err != domain.ErrNotFound {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestWebSeedTrackerRecordsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	tracker := &webSeedTracker{url: srv.URL + "/", next: http.DefaultTransport}
+	client := &http.Client{Transport: tracker}
+	resp, err := client.Get(srv.URL + "/missing.bin")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	ws, active := tracker.snapshot()
+	if !active || ws.Requests != 1 || ws.Errors != 1 || ws.LastErrorAt == nil {
+		t.Fatalf("unexpected stats: %+v", ws)
+	}
+	if !strings.Contains(ws.LastError, "404") {
+		t.Fatalf("lastError = %q", ws.LastError)
+	}
+
+	tracker.setRemoved(true)
+	if _, err := client.Get(srv.URL + "/missing.bin"); err == nil {
+		t.Fatalf("expected removed web seed to fail")
+	}
+	if ws, _ := tracker.snapshot(); ws.Requests != 1 {
+		t.Fatalf("removed web seed must not count requests: %+v", ws)
+	}
+}
+
+func TestWebSeedsUnknownSession(t *testing.T) {
+	e := newTestEngine()
+	ctx := context.Background()
+	if err := e.AddWebSeeds(ctx, "missing", []string{"http://mirror/"}); err != ErrSessionNotFound {
+		t.Fatalf("AddWebSeeds err = %v", err)
+	}
+	if _, err := e.WebSeeds(ctx, "missing"); err != ErrSessionNotFound {
+		t.Fatalf("WebSeeds err = %v", err)
+	}
+}
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header         string
+		offset, length int64
+		ranged, bad    bool
+	}{
+		{"", 0, -1, false, false},
+		{"bytes=0-99", 0, 100, true, false},
+		{"bytes=100-", 100, -1, true, false},
+		{"bytes=5-1", 0, 0, false, true},
+		{"bytes=0-1,5-6", 0, 0, false, true},
+		{"items=0-1", 0, 0, false, true},
+	}
+	for _, tc := range tests {
+		offset, length, ranged, err := parseByteRange(tc.header)
+		if tc.bad {
+			if err == nil {
+				t.Fatalf("%q: expected error", tc.header)
+			}
+			continue
+		}
+		if err != nil || offset != tc.offset || length != tc.length || ranged != tc.ranged {
+			t.Fatalf("%q: got %d,%d,%v,%v", tc.header, offset, length, ranged, err)
+		}
+	}
+}
+
+// serveFakeFTP runs a minimal passive-mode FTP server for one file.
+func serveFakeFTP(t *testing.T, path string, content []byte) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go fakeFTPSession(conn, path, content)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func fakeFTPSession(conn net.Conn, path string, content []byte) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...any) { fmt.Fprintf(conn, format+"\r\n", args...) }
+	reply("220 ready")
+	var data net.Listener
+	var offset int64
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch cmd {
+		case "USER":
+			reply("331 password please")
+		case "PASS":
+			reply("230 logged in")
+		case "TYPE":
+			reply("200 binary")
+		case "SIZE":
+			reply("213 %d", len(content))
+		case "PASV":
+			data, _ = net.Listen("tcp", "127.0.0.1:0")
+			port := data.Addr().(*net.TCPAddr).Port
+			reply("227 Entering Passive Mode (127,0,0,1,%d,%d)", port>>8, port&0xff)
+		case "REST":
+			offset, _ = strconv.ParseInt(arg, 10, 64)
+			reply("350 restarting")
+		case "RETR":
+			if arg != path || data == nil {
+				reply("550 not found")
+				continue
+			}
+			reply("150 opening")
+			dc, err := data.Accept()
+			data.Close()
+			if err != nil {
+				return
+			}
+			_, _ = dc.Write(content[offset:])
+			dc.Close()
+			reply("226 done")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestFTPTransportServesRanges(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	addr := serveFakeFTP(t, "/pub/data.bin", content)
+
+	client := &http.Client{Transport: newWebSeedTransport()}
+	req, _ := http.NewRequest(http.MethodGet, "ftp://"+addr+"/pub/data.bin", nil)
+	req.Header.Set("Range", "bytes=5-9")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "56789" {
+		t.Fatalf("status %d body %q", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get("ftp://" + addr + "/pub/data.bin")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength != int64(len(content)) || !bytes.Equal(body, content) {
+		t.Fatalf("status %d length %d body %q", resp.StatusCode, resp.ContentLength, body)
+	}
+
+	if _, err := client.Get("ftp://" + addr + "/pub/missing.bin"); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
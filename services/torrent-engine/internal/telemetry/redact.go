@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+
+	"torrentstream/internal/domain"
+)
+
+// NewRedactingHandler wraps next so tracker passkeys and other URL
+// credentials never reach the log output. Messages, string attributes and
+// errors are redacted.
+func NewRedactingHandler(next slog.Handler) slog.Handler {
+	return redactingHandler{next: next}
+}
+
+type redactingHandler struct {
+	next slog.Handler
+}
+
+func (h redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, domain.RedactText(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h redactingHandler) WithGroup(name string) slog.Handler {
+	return redactingHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, domain.RedactText(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, domain.RedactText(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,32 @@
+package telemetry
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactingHandler(slog.NewTextHandler(&buf, nil)))
+
+	logger.With(slog.String("tracker", "http://t.example/announce?passkey=one")).
+		WithGroup("req").
+		Info("announce http://t.example/0123456789abcdef0123456789abcdef/announce failed",
+			slog.Any("error", errors.New("get http://t.example/a?authkey=two: timeout")),
+			slog.Group("src", slog.String("magnet", "magnet:?xt=urn:btih:abc&tr=http%3A%2F%2Ft%2Fa%3Fpk%3Dthree")),
+			slog.Int("attempt", 2),
+		)
+
+	out := buf.String()
+	for _, secret := range []string{"passkey=one", "0123456789abcdef", "authkey=two", "pk%3Dthree"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("%q leaked: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "req.attempt=2") {
+		t.Fatalf("attributes lost: %s", out)
+	}
+}
@@ -0,0 +1,415 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// ArchivedFile is a file stored uncompressed ("-m0") in a RAR set of the
+// torrent. It streams straight out of the volumes while they download. Its
+// index follows the torrent files and matches the index the file gets once
+// the set is unpacked.
+type ArchivedFile struct {
+	domain.FileRef
+	// Archive is the path of the first volume of the set.
+	Archive string `json:"archive"`
+
+	segments []archiveSegment
+}
+
+// archiveSegment is the part of an archived file stored in one volume.
+type archiveSegment struct {
+	volume domain.FileRef
+	offset int64
+	length int64
+}
+
+// volumeOpener returns a reader over a torrent file.
+type volumeOpener func(file domain.FileRef) (io.ReadSeekCloser, error)
+
+var oldRARVolumePattern = regexp.MustCompile(`(?i)\.([r-z])(\d{2})$`)
+
+// listArchivedFiles maps the stored files of every RAR set among files onto
+// the volumes holding their bytes. Sets that cannot be mapped are skipped;
+// read errors are returned.
+func listArchivedFiles(files []domain.FileRef, open volumeOpener) ([]ArchivedFile, error) {
+	next := 0
+	for _, f := range files {
+		if f.Index >= next {
+			next = f.Index + 1
+		}
+	}
+	var out []ArchivedFile
+	for _, archive := range findArchives(files) {
+		if archive.format != archiveRAR {
+			continue
+		}
+		volumes := rarSetVolumes(archive.file, files)
+		set, err := layoutRARSet(volumes, open, true)
+		if errors.Is(err, errRARLayout) {
+			// The volumes do not share one layout; read every header.
+			set, err = layoutRARSet(volumes, open, false)
+		}
+		if errors.Is(err, errBadRAR) || errors.Is(err, errRARLayout) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		dir := path.Dir(archive.file.Path)
+		for _, f := range set {
+			name := strings.TrimPrefix(path.Clean("/"+f.Path), "/")
+			if name == "" {
+				continue
+			}
+			f.Index = next
+			f.Path = path.Join(dir, name)
+			f.Archive = archive.file.Path
+			f.BytesCompleted = archivedBytesCompleted(f.segments)
+			if f.Length > 0 {
+				f.Progress = float64(f.BytesCompleted) / float64(f.Length)
+			}
+			out = append(out, f)
+			next++
+		}
+	}
+	return out, nil
+}
+
+// rarSetVolumes returns the volumes of the set starting at first in order:
+// "name.part1.rar", "name.part2.rar", ... or "name.rar", "name.r00", ...
+func rarSetVolumes(first domain.FileRef, files []domain.FileRef) []domain.FileRef {
+	dir, base := path.Split(first.Path)
+	type volume struct {
+		file domain.FileRef
+		key  int
+	}
+	var volumes []volume
+	if m := rarPartPattern.FindStringSubmatchIndex(base); m != nil {
+		prefix := strings.ToLower(base[:m[0]])
+		for _, f := range files {
+			d, b := path.Split(f.Path)
+			vm := rarPartPattern.FindStringSubmatch(b)
+			if d != dir || vm == nil || strings.ToLower(b[:len(b)-len(vm[0])]) != prefix {
+				continue
+			}
+			n, _ := strconv.Atoi(vm[1])
+			volumes = append(volumes, volume{file: f, key: n})
+		}
+	} else {
+		prefix := strings.ToLower(strings.TrimSuffix(base, path.Ext(base)))
+		volumes = append(volumes, volume{file: first, key: -1})
+		for _, f := range files {
+			d, b := path.Split(f.Path)
+			vm := oldRARVolumePattern.FindStringSubmatch(b)
+			if d != dir || vm == nil || strings.ToLower(b[:len(b)-len(vm[0])]) != prefix {
+				continue
+			}
+			n, _ := strconv.Atoi(vm[2])
+			volumes = append(volumes, volume{file: f, key: int(strings.ToLower(vm[1])[0]-'r')*100 + n})
+		}
+	}
+	sort.SliceStable(volumes, func(i, j int) bool { return volumes[i].key < volumes[j].key })
+	out := make([]domain.FileRef, 0, len(volumes))
+	for _, v := range volumes {
+		out = append(out, v.file)
+	}
+	return out
+}
+
+// errRARLayout marks sets whose volumes do not fit together.
+var errRARLayout = errors.New("inconsistent rar volumes")
+
+// layoutRARSet reads the volume headers and collects the segments of every
+// stored file. With predict set, volumes of the same size as the first
+// continuation volume are assumed to share its layout, so only the first,
+// second and last headers are read; the sizes are checked afterwards.
+func layoutRARSet(volumes []domain.FileRef, open volumeOpener, predict bool) ([]ArchivedFile, error) {
+	var (
+		files    []ArchivedFile
+		cur      *ArchivedFile
+		skipping bool // the continued file is not streamable
+	)
+	for i := 0; i < len(volumes); i++ {
+		entries, err := readVolumeEntries(volumes[i], open)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.splitBefore {
+				if cur == nil && !skipping {
+					return nil, fmt.Errorf("%w: %s continues a missing volume", errRARLayout, e.name)
+				}
+			} else {
+				cur, skipping = nil, false
+				if e.dir || e.encrypted || !e.stored {
+					skipping = true
+				} else {
+					files = append(files, ArchivedFile{FileRef: domain.FileRef{Path: e.name, Length: e.size}})
+					cur = &files[len(files)-1]
+				}
+			}
+			if cur != nil {
+				cur.segments = append(cur.segments, archiveSegment{volume: volumes[i], offset: e.dataOffset, length: e.packed})
+			}
+			if !e.splitAfter {
+				cur, skipping = nil, false
+			}
+		}
+
+		// A volume holding only the middle of a file is the template for the
+		// following volumes of the same size.
+		if predict && cur != nil && len(entries) == 1 && entries[0].splitBefore {
+			tmpl := entries[0]
+			for i+1 < len(volumes)-1 && volumes[i+1].Length == volumes[i].Length {
+				i++
+				cur.segments = append(cur.segments, archiveSegment{volume: volumes[i], offset: tmpl.dataOffset, length: tmpl.packed})
+			}
+		}
+	}
+	if cur != nil {
+		return nil, fmt.Errorf("%w: %s continues past the last volume", errRARLayout, cur.Path)
+	}
+	for _, f := range files {
+		var total int64
+		for _, seg := range f.segments {
+			if seg.offset < 0 || seg.length < 0 || seg.offset+seg.length > seg.volume.Length {
+				return nil, fmt.Errorf("%w: %s exceeds its volume", errRARLayout, f.Path)
+			}
+			total += seg.length
+		}
+		if total != f.Length {
+			return nil, fmt.Errorf("%w: %s stores %d of %d bytes", errRARLayout, f.Path, total, f.Length)
+		}
+	}
+	return files, nil
+}
+
+func readVolumeEntries(volume domain.FileRef, open volumeOpener) ([]rarEntry, error) {
+	r, err := open(volume)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readRARVolume(r, volume.Length)
+}
+
+// archivedBytesCompleted counts the segments in completed volumes.
+func archivedBytesCompleted(segments []archiveSegment) int64 {
+	var done int64
+	for _, seg := range segments {
+		if seg.volume.Length > 0 && seg.volume.BytesCompleted >= seg.volume.Length {
+			done += seg.length
+		}
+	}
+	return done
+}
+
+// diskVolumeOpener opens torrent files below root.
+func diskVolumeOpener(root string) volumeOpener {
+	return func(file domain.FileRef) (io.ReadSeekCloser, error) {
+		return os.Open(filepath.Join(root, filepath.FromSlash(file.Path)))
+	}
+}
+
+// sessionVolumeOpener opens torrent files through the engine, waiting for
+// their pieces until ctx ends.
+func sessionVolumeOpener(ctx context.Context, session ports.Session) volumeOpener {
+	return func(file domain.FileRef) (io.ReadSeekCloser, error) {
+		r, err := session.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			return nil, errors.New("stream reader not available")
+		}
+		r.SetContext(ctx)
+		return r, nil
+	}
+}
+
+// archiveSession presents an archived file as a file of the torrent:
+// priorities and readers for it are mapped onto its volumes.
+type archiveSession struct {
+	ports.Session
+	file ArchivedFile
+}
+
+func (s *archiveSession) SelectFile(index int) (domain.FileRef, error) {
+	if index == s.file.Index {
+		return s.file.FileRef, nil
+	}
+	return s.Session.SelectFile(index)
+}
+
+func (s *archiveSession) SetPiecePriority(file domain.FileRef, r domain.Range, prio domain.Priority) {
+	if file.Index != s.file.Index {
+		s.Session.SetPiecePriority(file, r, prio)
+		return
+	}
+	end := r.Off + r.Length
+	var start int64
+	for _, seg := range s.file.segments {
+		lo, hi := max(r.Off, start), min(end, start+seg.length)
+		if lo < hi {
+			s.Session.SetPiecePriority(seg.volume, domain.Range{Off: seg.offset + lo - start, Length: hi - lo}, prio)
+		}
+		start += seg.length
+	}
+}
+
+func (s *archiveSession) NewReader(file domain.FileRef) (ports.StreamReader, error) {
+	if file.Index != s.file.Index {
+		return s.Session.NewReader(file)
+	}
+	return &archiveReader{session: s.Session, file: s.file}, nil
+}
+
+// archiveReader reads an archived file through readers over its volumes,
+// opening them as reads cross segment boundaries.
+type archiveReader struct {
+	session ports.Session
+	file    ArchivedFile
+
+	mu         sync.Mutex
+	ctx        context.Context
+	readahead  int64
+	responsive bool
+	pos        int64
+	seg        int
+	cur        ports.StreamReader
+}
+
+func (r *archiveReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pos >= r.file.Length {
+		return 0, io.EOF
+	}
+	seg, segStart := r.segmentAt(r.pos)
+	if r.cur == nil || r.seg != seg {
+		if err := r.open(seg); err != nil {
+			return 0, err
+		}
+	}
+	s := r.file.segments[seg]
+	if _, err := r.cur.Seek(s.offset+r.pos-segStart, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if left := segStart + s.length - r.pos; int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err := r.cur.Read(p)
+	r.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (r *archiveReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.file.Length
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+func (r *archiveReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
+
+func (r *archiveReader) SetContext(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctx = ctx
+	if r.cur != nil {
+		r.cur.SetContext(ctx)
+	}
+}
+
+func (r *archiveReader) SetReadahead(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readahead = n
+	if r.cur != nil {
+		r.cur.SetReadahead(n)
+	}
+}
+
+func (r *archiveReader) SetResponsive() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responsive = true
+	if r.cur != nil {
+		r.cur.SetResponsive()
+	}
+}
+
+// segmentAt returns the segment holding pos and the file offset it starts at.
+func (r *archiveReader) segmentAt(pos int64) (int, int64) {
+	var start int64
+	for i, seg := range r.file.segments {
+		if pos < start+seg.length {
+			return i, start
+		}
+		start += seg.length
+	}
+	return len(r.file.segments) - 1, start - r.file.segments[len(r.file.segments)-1].length
+}
+
+func (r *archiveReader) open(seg int) error {
+	if r.cur != nil {
+		_ = r.cur.Close()
+		r.cur = nil
+	}
+	reader, err := r.session.NewReader(r.file.segments[seg].volume)
+	if err != nil {
+		return err
+	}
+	if reader == nil {
+		return errors.New("stream reader not available")
+	}
+	if r.ctx != nil {
+		reader.SetContext(r.ctx)
+	}
+	if r.readahead > 0 {
+		reader.SetReadahead(r.readahead)
+	}
+	if r.responsive {
+		reader.SetResponsive()
+	}
+	r.cur, r.seg = reader, seg
+	return nil
+}
@@ -0,0 +1,518 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+type testRAREntry struct {
+	name string
+	data []byte
+	// method is the RAR 4 compression method; zero stores the data.
+	method byte
+}
+
+// buildTestRAR lays entries out in RAR volumes holding at most chunk bytes
+// of file data each.
+func buildTestRAR(version, chunk int, entries ...testRAREntry) [][]byte {
+	var (
+		volumes [][]byte
+		cur     []byte
+		used    int
+	)
+	start := func() {
+		used = 0
+		if version == 5 {
+			cur = append([]byte(nil), rar5Signature...)
+			archiveFlags := []byte{0x01}
+			if n := len(volumes); n > 0 {
+				archiveFlags = append([]byte{0x03}, rar5Vint(uint64(n))...)
+			}
+			cur = append(cur, rar5Block(1, 0, 0, archiveFlags)...)
+			return
+		}
+		cur = append([]byte(nil), rar4Signature...)
+		flags := uint16(0x0001 | 0x0010)
+		if len(volumes) == 0 {
+			flags |= 0x0100
+		}
+		cur = append(cur, rar4Block(rar4BlockMain, flags, make([]byte, 6))...)
+	}
+	finish := func(last bool) {
+		if version == 5 {
+			endFlags := byte(1)
+			if last {
+				endFlags = 0
+			}
+			cur = append(cur, rar5Block(rar5BlockEnd, 0, 0, []byte{endFlags})...)
+		} else {
+			var flags uint16 = 1
+			if last {
+				flags = 0
+			}
+			cur = append(cur, rar4Block(rar4BlockEnd, flags, nil)...)
+		}
+		volumes = append(volumes, cur)
+	}
+
+	start()
+	for _, e := range entries {
+		for off := 0; off < len(e.data); {
+			if used == chunk {
+				finish(false)
+				start()
+			}
+			n := min(len(e.data)-off, chunk-used)
+			part := e.data[off : off+n]
+			before, after := off > 0, off+n < len(e.data)
+			if version == 5 {
+				cur = append(cur, rar5FileBlock(e, part, before, after)...)
+			} else {
+				cur = append(cur, rar4FileBlock(e, part, before, after)...)
+			}
+			cur = append(cur, part...)
+			used += n
+			off += n
+		}
+	}
+	finish(true)
+	return volumes
+}
+
+func rar4Block(typ byte, flags uint16, body []byte) []byte {
+	h := make([]byte, 7, 7+len(body))
+	h[2] = typ
+	binary.LittleEndian.PutUint16(h[3:], flags)
+	binary.LittleEndian.PutUint16(h[5:], uint16(7+len(body)))
+	h = append(h, body...)
+	binary.LittleEndian.PutUint16(h, uint16(crc32.ChecksumIEEE(h[2:])))
+	return h
+}
+
+func rar4FileBlock(e testRAREntry, part []byte, before, after bool) []byte {
+	flags := uint16(rar4FlagLongBlock)
+	if before {
+		flags |= rar4FlagSplitBefore
+	}
+	if after {
+		flags |= rar4FlagSplitAfter
+	}
+	crc := crc32.ChecksumIEEE(e.data)
+	if after {
+		crc = crc32.ChecksumIEEE(part)
+	}
+	method := e.method
+	if method == 0 {
+		method = rar4MethodStore
+	}
+	body := binary.LittleEndian.AppendUint32(nil, uint32(len(part)))
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(e.data)))
+	body = append(body, 3) // host OS: unix
+	body = binary.LittleEndian.AppendUint32(body, crc)
+	body = binary.LittleEndian.AppendUint32(body, 0) // time
+	body = append(body, 20, method)
+	body = binary.LittleEndian.AppendUint16(body, uint16(len(e.name)))
+	body = binary.LittleEndian.AppendUint32(body, 0o100644)
+	body = append(body, e.name...)
+	return rar4Block(rar4BlockFile, flags, body)
+}
+
+func rar5Vint(v uint64) []byte {
+	var out []byte
+	for v >= 0x80 {
+		out = append(out, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func rar5Block(typ, flags, dataSize uint64, fields []byte) []byte {
+	body := append(rar5Vint(typ), rar5Vint(flags)...)
+	if flags&rar5FlagData != 0 {
+		body = append(body, rar5Vint(dataSize)...)
+	}
+	body = append(body, fields...)
+	sized := append(rar5Vint(uint64(len(body))), body...)
+	return append(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(sized)), sized...)
+}
+
+func rar5FileBlock(e testRAREntry, part []byte, before, after bool) []byte {
+	flags := uint64(rar5FlagData)
+	if before {
+		flags |= rar5FlagSplitBefore
+	}
+	if after {
+		flags |= rar5FlagSplitAfter
+	}
+	fileFlags := uint64(rar5FileMtime)
+	if !after {
+		fileFlags |= rar5FileCRC
+	}
+	fields := rar5Vint(fileFlags)
+	fields = append(fields, rar5Vint(uint64(len(e.data)))...)
+	fields = append(fields, rar5Vint(0o644)...)
+	fields = binary.LittleEndian.AppendUint32(fields, 0) // mtime
+	if !after {
+		fields = binary.LittleEndian.AppendUint32(fields, crc32.ChecksumIEEE(e.data))
+	}
+	fields = append(fields, 0, 1) // stored, host OS unix
+	fields = append(fields, rar5Vint(uint64(len(e.name)))...)
+	fields = append(fields, e.name...)
+	return rar5Block(rar5BlockFile, flags, uint64(len(part)), fields)
+}
+
+// testRARFiles returns torrent files for the volumes, named dir/base.partN.rar
+// and indexed from first.
+func testRARFiles(volumes [][]byte, dir, base string, first int) []domain.FileRef {
+	files := make([]domain.FileRef, len(volumes))
+	for i, v := range volumes {
+		files[i] = domain.FileRef{
+			Index:          first + i,
+			Path:           path.Join(dir, fmt.Sprintf("%s.part%d.rar", base, i+1)),
+			Length:         int64(len(v)),
+			BytesCompleted: int64(len(v)),
+		}
+	}
+	return files
+}
+
+func testPayload(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*7 + i/251)
+	}
+	return data
+}
+
+// memVolumes opens volumes held in memory and counts the opens.
+type memVolumes struct {
+	data   map[int][]byte
+	opened int
+}
+
+func newMemVolumes(files []domain.FileRef, volumes [][]byte) *memVolumes {
+	m := &memVolumes{data: make(map[int][]byte)}
+	for i, f := range files {
+		m.data[f.Index] = volumes[i]
+	}
+	return m
+}
+
+func (m *memVolumes) open(file domain.FileRef) (io.ReadSeekCloser, error) {
+	m.opened++
+	return memStreamReader{Reader: bytes.NewReader(m.data[file.Index])}, nil
+}
+
+type memStreamReader struct {
+	*bytes.Reader
+}
+
+func (memStreamReader) Close() error                { return nil }
+func (memStreamReader) SetContext(context.Context) {}
+func (memStreamReader) SetReadahead(int64)         {}
+func (memStreamReader) SetResponsive()             {}
+
+// archiveTestSession serves the torrent's volumes from memory.
+type archiveTestSession struct {
+	fakeStreamSession
+	volumes *memVolumes
+}
+
+func (s *archiveTestSession) NewReader(file domain.FileRef) (ports.StreamReader, error) {
+	s.lastFile = file
+	r, err := s.volumes.open(file)
+	if err != nil {
+		return nil, err
+	}
+	return r.(memStreamReader), nil
+}
+
+func readArchived(t *testing.T, f ArchivedFile, volumes *memVolumes) []byte {
+	t.Helper()
+	var out []byte
+	for _, seg := range f.segments {
+		out = append(out, volumes.data[seg.volume.Index][seg.offset:seg.offset+seg.length]...)
+	}
+	return out
+}
+
+func TestListArchivedFilesMapsStoredEntries(t *testing.T) {
+	movie := testPayload(1000)
+	for _, version := range []int{4, 5} {
+		t.Run(fmt.Sprintf("rar%d", version), func(t *testing.T) {
+			volumes := buildTestRAR(version, 300,
+				testRAREntry{name: "Movie/movie.mkv", data: movie},
+				testRAREntry{name: "sample.txt", data: []byte("0123456789")},
+			)
+			files := append([]domain.FileRef{{Index: 0, Path: "Set/readme.nfo", Length: 5}}, testRARFiles(volumes, "Set", "set", 1)...)
+			mem := newMemVolumes(files[1:], volumes)
+
+			got, err := listArchivedFiles(files, mem.open)
+			if err != nil {
+				t.Fatalf("listArchivedFiles: %v", err)
+			}
+			if len(got) != 2 {
+				t.Fatalf("archived = %+v", got)
+			}
+			if got[0].Index != 5 || got[0].Path != "Set/Movie/movie.mkv" || got[0].Archive != "Set/set.part1.rar" || got[0].Length != 1000 {
+				t.Fatalf("movie = %+v", got[0].FileRef)
+			}
+			if got[1].Index != 6 || got[1].Path != "Set/sample.txt" || got[1].Length != 10 {
+				t.Fatalf("sample = %+v", got[1].FileRef)
+			}
+			if got[0].Progress != 1 {
+				t.Fatalf("movie progress = %v", got[0].Progress)
+			}
+			if !bytes.Equal(readArchived(t, got[0], mem), movie) || string(readArchived(t, got[1], mem)) != "0123456789" {
+				t.Fatal("segments do not cover the stored data")
+			}
+			// The third volume has the layout of the second and is not read.
+			if mem.opened != 3 {
+				t.Fatalf("opened %d volumes, want 3", mem.opened)
+			}
+		})
+	}
+}
+
+func TestListArchivedFilesSkipsCompressedEntries(t *testing.T) {
+	volumes := buildTestRAR(4, 1000,
+		testRAREntry{name: "packed.mkv", data: testPayload(100), method: 0x33},
+		testRAREntry{name: "stored.mkv", data: testPayload(50)},
+	)
+	files := testRARFiles(volumes, "", "set", 0)
+
+	got, err := listArchivedFiles(files, newMemVolumes(files, volumes).open)
+	if err != nil {
+		t.Fatalf("listArchivedFiles: %v", err)
+	}
+	if len(got) != 1 || got[0].Path != "stored.mkv" || got[0].Index != 1 {
+		t.Fatalf("archived = %+v", got)
+	}
+}
+
+func TestListArchivedFilesIgnoresBrokenSets(t *testing.T) {
+	files := []domain.FileRef{{Index: 0, Path: "bad.rar", Length: 20}}
+	mem := &memVolumes{data: map[int][]byte{0: []byte("not a rar archive!!!")}}
+
+	got, err := listArchivedFiles(files, mem.open)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("archived = %+v, %v", got, err)
+	}
+}
+
+func TestReadRARVolumeRejectsCraftedSizes(t *testing.T) {
+	rar5File := func(nameLen uint64) []byte {
+		fields := append(rar5Vint(0), rar5Vint(4)...) // flags, size
+		fields = append(fields, rar5Vint(0o644)...)
+		fields = append(fields, 0, 1)
+		fields = append(fields, rar5Vint(nameLen)...)
+		fields = append(fields, "name"...)
+		return rar5Block(rar5BlockFile, rar5FlagData, 0, fields)
+	}
+	// A service block whose data size, -17 as an int64, points back at its
+	// own header: CRC and size take 5 bytes, the header 12.
+	backwards := rar5Block(3, rar5FlagData, 1<<64-17, nil)
+	huge := rar5Block(3, rar5FlagData, 1<<40, nil)
+	extra := append(rar5Vint(rar5BlockFile), rar5Vint(rar5FlagExtra)...)
+	extra = append(extra, rar5Vint(1<<63)...)
+	extra = append(rar5Vint(uint64(len(extra))), extra...)
+	extra = append(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(extra)), extra...)
+
+	large := rar4FileBlock(testRAREntry{name: "a.mkv", data: []byte("data")}, []byte("data"), false, false)
+	binary.LittleEndian.PutUint16(large[3:], binary.LittleEndian.Uint16(large[3:])|rar4FlagLarge)
+	large = append(large[:32], append([]byte{0, 0, 0, 0, 0, 0, 0, 0xff, 0, 0, 0, 0}, large[32:]...)...)
+	binary.LittleEndian.PutUint16(large[5:], uint16(len(large)))
+
+	for name, volume := range map[string][]byte{
+		"rar5 name length": append(append([]byte(nil), rar5Signature...), rar5File(1<<63)...),
+		"rar5 extra size":  append(append([]byte(nil), rar5Signature...), extra...),
+		"rar5 backwards":   append(append([]byte(nil), rar5Signature...), backwards...),
+		"rar5 past end":    append(append([]byte(nil), rar5Signature...), huge...),
+		"rar4 large size":  append(append([]byte(nil), rar4Signature...), large...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := readRARVolume(bytes.NewReader(volume), int64(len(volume)))
+			if !errors.Is(err, errBadRAR) {
+				t.Fatalf("err = %v, want errBadRAR", err)
+			}
+		})
+	}
+}
+
+func TestRARSetVolumesOrder(t *testing.T) {
+	files := []domain.FileRef{
+		{Index: 0, Path: "Old/old.s00"},
+		{Index: 1, Path: "Old/old.r01"},
+		{Index: 2, Path: "Old/old.rar"},
+		{Index: 3, Path: "Old/old.r00"},
+		{Index: 4, Path: "Other/old.r02"},
+		{Index: 5, Path: "New/new.part10.rar"},
+		{Index: 6, Path: "New/new.part2.rar"},
+		{Index: 7, Path: "New/new.part1.rar"},
+	}
+	paths := func(volumes []domain.FileRef) []string {
+		var out []string
+		for _, v := range volumes {
+			out = append(out, v.Path)
+		}
+		return out
+	}
+
+	got := fmt.Sprint(paths(rarSetVolumes(files[2], files)))
+	if want := "[Old/old.rar Old/old.r00 Old/old.r01 Old/old.s00]"; got != want {
+		t.Fatalf("old volumes = %s, want %s", got, want)
+	}
+	got = fmt.Sprint(paths(rarSetVolumes(files[7], files)))
+	if want := "[New/new.part1.rar New/new.part2.rar New/new.part10.rar]"; got != want {
+		t.Fatalf("new volumes = %s, want %s", got, want)
+	}
+}
+
+func newArchiveTestSession(t *testing.T, data []byte) (*archiveTestSession, ArchivedFile) {
+	t.Helper()
+	volumes := buildTestRAR(5, 300, testRAREntry{name: "movie.mkv", data: data})
+	files := testRARFiles(volumes, "", "movie", 0)
+	mem := newMemVolumes(files, volumes)
+	archived, err := listArchivedFiles(files, mem.open)
+	if err != nil || len(archived) != 1 {
+		t.Fatalf("archived = %+v, %v", archived, err)
+	}
+	return &archiveTestSession{fakeStreamSession: fakeStreamSession{files: files}, volumes: mem}, archived[0]
+}
+
+func TestArchiveReaderReadsAcrossVolumes(t *testing.T) {
+	data := testPayload(1000)
+	base, file := newArchiveTestSession(t, data)
+	session := &archiveSession{Session: base, file: file}
+
+	r, err := session.NewReader(file.FileRef)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAll = %d bytes, %v", len(got), err)
+	}
+
+	if _, err := r.Seek(250, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	buf := make([]byte, 100)
+	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, data[250:350]) {
+		t.Fatalf("read across volumes = %v", err)
+	}
+	if pos, _ := r.Seek(-10, io.SeekEnd); pos != 990 {
+		t.Fatalf("SeekEnd = %d", pos)
+	}
+	rest, _ := io.ReadAll(r)
+	if !bytes.Equal(rest, data[990:]) {
+		t.Fatalf("tail = %v", rest)
+	}
+}
+
+func TestArchiveSessionMapsPriorities(t *testing.T) {
+	base, file := newArchiveTestSession(t, testPayload(1000))
+	session := &archiveSession{Session: base, file: file}
+
+	session.SetPiecePriority(file.FileRef, domain.Range{Off: 250, Length: 400}, domain.PriorityHigh)
+
+	if len(base.callFiles) != 3 {
+		t.Fatalf("priority calls = %+v", base.callFiles)
+	}
+	wantLengths := []int64{50, 300, 50}
+	for i, seg := range file.segments[:3] {
+		if base.callFiles[i].Index != seg.volume.Index || base.ranges[i].Length != wantLengths[i] || base.prios[i] != domain.PriorityHigh {
+			t.Fatalf("call %d = %+v %+v", i, base.callFiles[i], base.ranges[i])
+		}
+	}
+	if base.ranges[0].Off != file.segments[0].offset+250 || base.ranges[1].Off != file.segments[1].offset {
+		t.Fatalf("ranges = %+v", base.ranges)
+	}
+}
+
+func TestStreamTorrentStreamsArchivedFile(t *testing.T) {
+	data := testPayload(1000)
+	session, _ := newArchiveTestSession(t, data)
+	uc := &StreamTorrent{Engine: &fakeStreamEngine{session: session}}
+
+	archived, err := uc.ArchivedFiles(context.Background(), "t1")
+	if err != nil || len(archived) != 1 || archived[0].Index != 4 {
+		t.Fatalf("ArchivedFiles = %+v, %v", archived, err)
+	}
+
+	result, err := uc.Execute(context.Background(), "t1", 4)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	defer result.Reader.Close()
+	if result.File.Path != "movie.mkv" || result.File.Length != 1000 {
+		t.Fatalf("file = %+v", result.File)
+	}
+	got, err := io.ReadAll(result.Reader)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAll = %d bytes, %v", len(got), err)
+	}
+	for _, f := range session.callFiles {
+		if f.Index == 4 {
+			t.Fatalf("priority set on the virtual file: %+v", f)
+		}
+	}
+}
+
+func TestUnpackKeepsArchivedIndices(t *testing.T) {
+	for _, version := range []int{4, 5} {
+		t.Run(fmt.Sprintf("rar%d", version), func(t *testing.T) {
+			dir := t.TempDir()
+			movie := testPayload(1000)
+			volumes := buildTestRAR(version, 300,
+				testRAREntry{name: "movie.mkv", data: movie},
+				testRAREntry{name: "sample.txt", data: []byte("sample")},
+			)
+			files := testRARFiles(volumes, "Set", "set", 0)
+			for i, f := range files {
+				dst := filepath.Join(dir, filepath.FromSlash(f.Path))
+				if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+					t.Fatalf("mkdir: %v", err)
+				}
+				if err := os.WriteFile(dst, volumes[i], 0o644); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			archived, err := listArchivedFiles(files, diskVolumeOpener(dir))
+			if err != nil || len(archived) != 2 {
+				t.Fatalf("archived = %+v, %v", archived, err)
+			}
+			repo := &fakeUnpackRepo{fakeRepoWithGet: fakeRepoWithGet{getRecord: domain.TorrentRecord{ID: "t1", Files: files}}}
+			uc := &Unpack{Repo: repo, Extracted: repo, DataDir: dir}
+
+			extracted, err := uc.Execute(context.Background(), "t1")
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if len(extracted) != 2 {
+				t.Fatalf("extracted = %+v", extracted)
+			}
+			for i, f := range extracted {
+				if f.Path != archived[i].Path || f.Index != archived[i].Index {
+					t.Fatalf("extracted %+v, archived %+v", f, archived[i].FileRef)
+				}
+			}
+			if got, _ := os.ReadFile(filepath.Join(dir, "Set", "movie.mkv")); !bytes.Equal(got, movie) {
+				t.Fatal("extracted movie differs")
+			}
+		})
+	}
+}
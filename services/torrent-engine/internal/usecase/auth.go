@@ -0,0 +1,452 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"torrentstream/internal/domain"
+)
+
+const (
+	defaultSessionTTL = 30 * 24 * time.Hour
+	// apiKeyPrefix marks API keys so they are recognisable in configs.
+	apiKeyPrefix = "tx_"
+	// apiKeyTouchInterval limits how often the last use of a key is stored.
+	apiKeyTouchInterval = time.Minute
+)
+
+// AuthStore persists users, login sessions and API keys.
+type AuthStore interface {
+	CountUsers(ctx context.Context) (int64, error)
+	ListUsers(ctx context.Context) ([]domain.User, error)
+	// GetUser and GetUserByName return domain.ErrNotFound for an unknown
+	// user.
+	GetUser(ctx context.Context, id string) (domain.User, error)
+	GetUserByName(ctx context.Context, username string) (domain.User, error)
+	// SaveUser returns domain.ErrAlreadyExists when the username is taken.
+	SaveUser(ctx context.Context, user domain.User) error
+	// DeleteUser removes the user with its sessions and API keys; it
+	// returns domain.ErrNotFound for an unknown id.
+	DeleteUser(ctx context.Context, id string) error
+
+	SaveSession(ctx context.Context, session domain.AuthSession) error
+	// GetSession returns domain.ErrNotFound for an unknown or expired
+	// session.
+	GetSession(ctx context.Context, id string) (domain.AuthSession, error)
+	DeleteSession(ctx context.Context, id string) error
+	DeleteUserSessions(ctx context.Context, userID string) error
+
+	SaveAPIKey(ctx context.Context, key domain.APIKey) error
+	// GetAPIKeyByHash returns domain.ErrNotFound for an unknown key.
+	GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error)
+	// DeleteAPIKey returns domain.ErrNotFound when userID has no key id.
+	DeleteAPIKey(ctx context.Context, userID, id string) error
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
+
+// UserInput creates a user.
+type UserInput struct {
+	Username string
+	Password string
+	Role     domain.Role
+}
+
+// UserUpdate changes the fields that are set.
+type UserUpdate struct {
+	Password *string
+	Role     *domain.Role
+}
+
+// LoginResult is a new login session. Token is only available here.
+type LoginResult struct {
+	Token     string
+	User      domain.User
+	ExpiresAt time.Time
+}
+
+// Auth manages users, login sessions and API keys and authenticates
+// requests with them.
+type Auth struct {
+	Store AuthStore
+	// SessionTTL is how long a login lasts (default 30 days).
+	SessionTTL time.Duration
+	// HashCost is the bcrypt cost of password hashes (default
+	// bcrypt.DefaultCost).
+	HashCost int
+	Logger   *slog.Logger
+	Now      func() time.Time
+
+	dummyOnce sync.Once
+	dummy     []byte
+}
+
+// Bootstrap creates the first admin when there are no users yet. An empty
+// password is replaced by a generated one, which is returned.
+func (uc *Auth) Bootstrap(ctx context.Context, username, password string) (string, bool, error) {
+	count, err := uc.Store.CountUsers(ctx)
+	if err != nil {
+		return "", false, wrapRepo(err)
+	}
+	if count > 0 {
+		return "", false, nil
+	}
+	if password == "" {
+		password = randomToken(12)
+	}
+	if _, err := uc.CreateUser(ctx, UserInput{Username: username, Password: password, Role: domain.RoleAdmin}); err != nil {
+		return "", false, err
+	}
+	return password, true, nil
+}
+
+// Login checks the credentials and opens a session.
+func (uc *Auth) Login(ctx context.Context, username, password string) (LoginResult, error) {
+	name, err := domain.NormalizeUsername(username)
+	if err != nil {
+		return LoginResult{}, domain.ErrInvalidCredentials
+	}
+	user, err := uc.Store.GetUserByName(ctx, name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return LoginResult{}, wrapRepo(err)
+	}
+	if err != nil {
+		// Compare anyway so unknown users take as long as wrong passwords.
+		_ = bcrypt.CompareHashAndPassword(uc.dummyHash(), []byte(password))
+		return LoginResult{}, domain.ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return LoginResult{}, domain.ErrInvalidCredentials
+	}
+
+	token := randomToken(32)
+	ttl := uc.SessionTTL
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	now := uc.now()
+	session := domain.AuthSession{
+		ID:        hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := uc.Store.SaveSession(ctx, session); err != nil {
+		return LoginResult{}, wrapRepo(err)
+	}
+	return LoginResult{Token: token, User: user, ExpiresAt: session.ExpiresAt}, nil
+}
+
+// Logout ends the session of token. Unknown tokens are ignored.
+func (uc *Auth) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	if err := uc.Store.DeleteSession(ctx, hashToken(token)); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+// Authenticate resolves a session token. It returns domain.ErrUnauthorized
+// for unknown or expired sessions.
+func (uc *Auth) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	if token == "" {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	session, err := uc.Store.GetSession(ctx, hashToken(token))
+	if err != nil {
+		return domain.Principal{}, unauthorized(err)
+	}
+	if !uc.now().Before(session.ExpiresAt) {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	user, err := uc.Store.GetUser(ctx, session.UserID)
+	if err != nil {
+		return domain.Principal{}, unauthorized(err)
+	}
+	return domain.Principal{User: user, Role: user.Role}, nil
+}
+
+// AuthenticateKey resolves an API key. It returns domain.ErrUnauthorized
+// for unknown keys.
+func (uc *Auth) AuthenticateKey(ctx context.Context, key string) (domain.Principal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return domain.Principal{}, domain.ErrUnauthorized
+	}
+	apiKey, err := uc.Store.GetAPIKeyByHash(ctx, hashToken(key))
+	if err != nil {
+		return domain.Principal{}, unauthorized(err)
+	}
+	user, err := uc.Store.GetUser(ctx, apiKey.UserID)
+	if err != nil {
+		return domain.Principal{}, unauthorized(err)
+	}
+
+	now := uc.now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := uc.Store.TouchAPIKey(ctx, apiKey.ID, now); err != nil {
+			uc.logger().Debug("auth: touch api key failed", slog.String("error", err.Error()))
+		}
+	}
+	return domain.Principal{User: user, Role: user.Role.Lower(apiKey.Role), APIKeyID: apiKey.ID}, nil
+}
+
+func (uc *Auth) ListUsers(ctx context.Context) ([]domain.User, error) {
+	users, err := uc.Store.ListUsers(ctx)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	return users, nil
+}
+
+func (uc *Auth) GetUser(ctx context.Context, id string) (domain.User, error) {
+	user, err := uc.Store.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.User{}, err
+		}
+		return domain.User{}, wrapRepo(err)
+	}
+	return user, nil
+}
+
+// CreateUser validates and stores a new user. Usernames are unique.
+func (uc *Auth) CreateUser(ctx context.Context, input UserInput) (domain.User, error) {
+	name, err := domain.NormalizeUsername(input.Username)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("%w: username must be 1-64 letters, digits, '.', '-' or '_'", domain.ErrInvalidUser)
+	}
+	if !input.Role.Valid() {
+		return domain.User{}, fmt.Errorf("%w: unknown role %q", domain.ErrInvalidUser, input.Role)
+	}
+	hash, err := uc.hashPassword(input.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user := domain.User{
+		ID:           newID(),
+		Username:     name,
+		PasswordHash: hash,
+		Role:         input.Role,
+		CreatedAt:    uc.now(),
+	}
+	user.UpdatedAt = user.CreatedAt
+	if err := uc.Store.SaveUser(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return domain.User{}, err
+		}
+		return domain.User{}, wrapRepo(err)
+	}
+	return user, nil
+}
+
+// UpdateUser changes the password or role of a user. A new password ends
+// the user's sessions. The last admin keeps its role.
+func (uc *Auth) UpdateUser(ctx context.Context, id string, update UserUpdate) (domain.User, error) {
+	user, err := uc.GetUser(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if update.Role != nil {
+		if !update.Role.Valid() {
+			return domain.User{}, fmt.Errorf("%w: unknown role %q", domain.ErrInvalidUser, *update.Role)
+		}
+		if user.Role == domain.RoleAdmin && *update.Role != domain.RoleAdmin {
+			if err := uc.ensureOtherAdmin(ctx, user.ID); err != nil {
+				return domain.User{}, err
+			}
+		}
+		user.Role = *update.Role
+	}
+	if update.Password != nil {
+		hash, err := uc.hashPassword(*update.Password)
+		if err != nil {
+			return domain.User{}, err
+		}
+		user.PasswordHash = hash
+	}
+	user.UpdatedAt = uc.now()
+	if err := uc.Store.SaveUser(ctx, user); err != nil {
+		return domain.User{}, wrapRepo(err)
+	}
+	if update.Password != nil {
+		if err := uc.Store.DeleteUserSessions(ctx, user.ID); err != nil {
+			return domain.User{}, wrapRepo(err)
+		}
+	}
+	return user, nil
+}
+
+// ChangePassword sets a new password after checking the current one.
+func (uc *Auth) ChangePassword(ctx context.Context, id, current, password string) error {
+	user, err := uc.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(current)) != nil {
+		return domain.ErrInvalidCredentials
+	}
+	_, err = uc.UpdateUser(ctx, id, UserUpdate{Password: &password})
+	return err
+}
+
+// DeleteUser removes a user with its sessions and API keys. The last admin
+// cannot be deleted.
+func (uc *Auth) DeleteUser(ctx context.Context, id string) error {
+	user, err := uc.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == domain.RoleAdmin {
+		if err := uc.ensureOtherAdmin(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	if err := uc.Store.DeleteUser(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+func (uc *Auth) ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	keys, err := uc.Store.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	return keys, nil
+}
+
+// CreateAPIKey issues a key for the user. The key is returned once; only
+// its hash is stored. An empty role gives the key the user's role; a role
+// above the user's is rejected.
+func (uc *Auth) CreateAPIKey(ctx context.Context, userID, name string, role domain.Role) (domain.APIKey, string, error) {
+	user, err := uc.GetUser(ctx, userID)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	if role == "" {
+		role = user.Role
+	}
+	if !role.Valid() {
+		return domain.APIKey{}, "", fmt.Errorf("%w: unknown role %q", domain.ErrInvalidUser, role)
+	}
+	if !user.Role.Allows(role) {
+		return domain.APIKey{}, "", fmt.Errorf("%w: role %q exceeds the user's role", domain.ErrInvalidUser, role)
+	}
+
+	secret := apiKeyPrefix + randomToken(24)
+	key := domain.APIKey{
+		ID:        newID(),
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    secret[:len(apiKeyPrefix)+6],
+		Hash:      hashToken(secret),
+		Role:      role,
+		CreatedAt: uc.now(),
+	}
+	if err := uc.Store.SaveAPIKey(ctx, key); err != nil {
+		return domain.APIKey{}, "", wrapRepo(err)
+	}
+	return key, secret, nil
+}
+
+func (uc *Auth) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	if err := uc.Store.DeleteAPIKey(ctx, userID, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+func (uc *Auth) ensureOtherAdmin(ctx context.Context, id string) error {
+	users, err := uc.Store.ListUsers(ctx)
+	if err != nil {
+		return wrapRepo(err)
+	}
+	for _, u := range users {
+		if u.ID != id && u.Role == domain.RoleAdmin {
+			return nil
+		}
+	}
+	return domain.ErrLastAdmin
+}
+
+func (uc *Auth) hashPassword(password string) (string, error) {
+	if len(password) < domain.MinPasswordLength {
+		return "", fmt.Errorf("%w: password must have at least %d characters", domain.ErrInvalidUser, domain.MinPasswordLength)
+	}
+	// bcrypt only uses the first 72 bytes.
+	if len(password) > 72 {
+		return "", fmt.Errorf("%w: password must have at most 72 bytes", domain.ErrInvalidUser)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), uc.hashCost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (uc *Auth) dummyHash() []byte {
+	uc.dummyOnce.Do(func() {
+		uc.dummy, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), uc.hashCost())
+	})
+	return uc.dummy
+}
+
+func (uc *Auth) hashCost() int {
+	if uc.HashCost > 0 {
+		return uc.HashCost
+	}
+	return bcrypt.DefaultCost
+}
+
+func (uc *Auth) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func (uc *Auth) logger() *slog.Logger {
+	if uc.Logger != nil {
+		return uc.Logger
+	}
+	return slog.Default()
+}
+
+// unauthorized maps a missing session, key or user to ErrUnauthorized.
+func unauthorized(err error) error {
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrUnauthorized
+	}
+	return wrapRepo(err)
+}
+
+func randomToken(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// hashToken hashes session tokens and API keys for storage. They are
+// random, so a plain SHA-256 is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
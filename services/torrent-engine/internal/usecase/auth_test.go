@@ -0,0 +1,427 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeAuthStore struct {
+	mu       sync.Mutex
+	users    map[string]domain.User
+	sessions map[string]domain.AuthSession
+	keys     map[string]domain.APIKey
+	touched  int
+}
+
+func newFakeAuthStore() *fakeAuthStore {
+	return &fakeAuthStore{
+		users:    make(map[string]domain.User),
+		sessions: make(map[string]domain.AuthSession),
+		keys:     make(map[string]domain.APIKey),
+	}
+}
+
+func (f *fakeAuthStore) CountUsers(ctx context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.users)), nil
+}
+
+func (f *fakeAuthStore) ListUsers(ctx context.Context) ([]domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]domain.User, 0, len(f.users))
+	for _, u := range f.users {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Username < out[j].Username })
+	return out, nil
+}
+
+func (f *fakeAuthStore) GetUser(ctx context.Context, id string) (domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrNotFound
+	}
+	return u, nil
+}
+
+func (f *fakeAuthStore) GetUserByName(ctx context.Context, username string) (domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range f.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return domain.User{}, domain.ErrNotFound
+}
+
+func (f *fakeAuthStore) SaveUser(ctx context.Context, user domain.User) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range f.users {
+		if u.Username == user.Username && u.ID != user.ID {
+			return domain.ErrAlreadyExists
+		}
+	}
+	f.users[user.ID] = user
+	return nil
+}
+
+func (f *fakeAuthStore) DeleteUser(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.users[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.users, id)
+	for k, s := range f.sessions {
+		if s.UserID == id {
+			delete(f.sessions, k)
+		}
+	}
+	for k, key := range f.keys {
+		if key.UserID == id {
+			delete(f.keys, k)
+		}
+	}
+	return nil
+}
+
+func (f *fakeAuthStore) SaveSession(ctx context.Context, session domain.AuthSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[session.ID] = session
+	return nil
+}
+
+func (f *fakeAuthStore) GetSession(ctx context.Context, id string) (domain.AuthSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.sessions[id]
+	if !ok {
+		return domain.AuthSession{}, domain.ErrNotFound
+	}
+	return s, nil
+}
+
+func (f *fakeAuthStore) DeleteSession(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions, id)
+	return nil
+}
+
+func (f *fakeAuthStore) DeleteUserSessions(ctx context.Context, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for k, s := range f.sessions {
+		if s.UserID == userID {
+			delete(f.sessions, k)
+		}
+	}
+	return nil
+}
+
+func (f *fakeAuthStore) SaveAPIKey(ctx context.Context, key domain.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[key.ID] = key
+	return nil
+}
+
+func (f *fakeAuthStore) GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range f.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return domain.APIKey{}, domain.ErrNotFound
+}
+
+func (f *fakeAuthStore) ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.APIKey
+	for _, k := range f.keys {
+		if k.UserID == userID {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAuthStore) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[id]
+	if !ok || k.UserID != userID {
+		return domain.ErrNotFound
+	}
+	delete(f.keys, id)
+	return nil
+}
+
+func (f *fakeAuthStore) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := f.keys[id]
+	k.LastUsedAt = &at
+	f.keys[id] = k
+	f.touched++
+	return nil
+}
+
+func newTestAuth(store *fakeAuthStore, now *time.Time) *Auth {
+	return &Auth{
+		Store:    store,
+		HashCost: bcrypt.MinCost,
+		Now:      func() time.Time { return *now },
+	}
+}
+
+func TestAuthBootstrapCreatesFirstAdminOnce(t *testing.T) {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	store := newFakeAuthStore()
+	uc := newTestAuth(store, &now)
+	ctx := context.Background()
+
+	password, created, err := uc.Bootstrap(ctx, "Admin", "")
+	if err != nil || !created {
+		t.Fatalf("Bootstrap: created=%v err=%v", created, err)
+	}
+	if len(password) < domain.MinPasswordLength {
+		t.Fatalf("generated password %q too short", password)
+	}
+	if _, err := uc.Login(ctx, "admin", password); err != nil {
+		t.Fatalf("Login with generated password: %v", err)
+	}
+
+	if _, created, err := uc.Bootstrap(ctx, "other", "password123"); err != nil || created {
+		t.Fatalf("second Bootstrap: created=%v err=%v", created, err)
+	}
+	if len(store.users) != 1 {
+		t.Fatalf("users = %d, want 1", len(store.users))
+	}
+}
+
+func TestAuthLoginAndAuthenticate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	store := newFakeAuthStore()
+	uc := newTestAuth(store, &now)
+	uc.SessionTTL = time.Hour
+	ctx := context.Background()
+
+	user, err := uc.CreateUser(ctx, UserInput{Username: "alice", Password: "correct horse", Role: domain.RoleViewer})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.PasswordHash == "" || user.PasswordHash == "correct horse" {
+		t.Fatalf("password not hashed: %q", user.PasswordHash)
+	}
+
+	for _, tc := range []struct{ name, user, pass string }{
+		{"wrong password", "alice", "wrong password"},
+		{"unknown user", "bob", "correct horse"},
+		{"invalid name", "", "correct horse"},
+	} {
+		if _, err := uc.Login(ctx, tc.user, tc.pass); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v, want ErrInvalidCredentials", tc.name, err)
+		}
+	}
+
+	result, err := uc.Login(ctx, " Alice ", "correct horse")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !result.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("ExpiresAt = %v", result.ExpiresAt)
+	}
+	if _, ok := store.sessions[result.Token]; ok {
+		t.Fatal("session stored under the plain token")
+	}
+
+	principal, err := uc.Authenticate(ctx, result.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if principal.User.ID != user.ID || principal.Role != domain.RoleViewer {
+		t.Fatalf("principal = %+v", principal)
+	}
+	if _, err := uc.Authenticate(ctx, "bogus"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("unknown token err = %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := uc.Authenticate(ctx, result.Token); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("expired session err = %v", err)
+	}
+
+	if err := uc.Logout(ctx, result.Token); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if len(store.sessions) != 0 {
+		t.Fatalf("sessions after logout = %d", len(store.sessions))
+	}
+}
+
+func TestAuthAPIKeyRoleIsCapped(t *testing.T) {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	store := newFakeAuthStore()
+	uc := newTestAuth(store, &now)
+	ctx := context.Background()
+
+	user, err := uc.CreateUser(ctx, UserInput{Username: "carol", Password: "password123", Role: domain.RoleUser})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if _, _, err := uc.CreateAPIKey(ctx, user.ID, "too much", domain.RoleAdmin); !errors.Is(err, domain.ErrInvalidUser) {
+		t.Fatalf("admin key for user: err = %v, want ErrInvalidUser", err)
+	}
+
+	key, secret, err := uc.CreateAPIKey(ctx, user.ID, "kodi", domain.RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(secret, key.Prefix) || key.Hash == secret {
+		t.Fatalf("key = %+v, secret = %q", key, secret)
+	}
+
+	principal, err := uc.AuthenticateKey(ctx, secret)
+	if err != nil {
+		t.Fatalf("AuthenticateKey: %v", err)
+	}
+	if principal.Role != domain.RoleViewer || principal.APIKeyID != key.ID {
+		t.Fatalf("principal = %+v", principal)
+	}
+
+	// Uses within a minute are not stored again.
+	if _, err := uc.AuthenticateKey(ctx, secret); err != nil {
+		t.Fatalf("AuthenticateKey: %v", err)
+	}
+	if store.touched != 1 {
+		t.Fatalf("touched = %d, want 1", store.touched)
+	}
+
+	// Demoting the user lowers the rights of its keys.
+	full, fullSecret, err := uc.CreateAPIKey(ctx, user.ID, "", "")
+	if err != nil {
+		t.Fatalf("CreateAPIKey default role: %v", err)
+	}
+	if full.Role != domain.RoleUser {
+		t.Fatalf("default key role = %q", full.Role)
+	}
+	viewer := domain.RoleViewer
+	if _, err := uc.UpdateUser(ctx, user.ID, UserUpdate{Role: &viewer}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	principal, err = uc.AuthenticateKey(ctx, fullSecret)
+	if err != nil || principal.Role != domain.RoleViewer {
+		t.Fatalf("after demotion principal = %+v, err = %v", principal, err)
+	}
+
+	if err := uc.DeleteAPIKey(ctx, "someone-else", key.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("delete foreign key err = %v", err)
+	}
+	if err := uc.DeleteAPIKey(ctx, user.ID, key.ID); err != nil {
+		t.Fatalf("DeleteAPIKey: %v", err)
+	}
+	if _, err := uc.AuthenticateKey(ctx, secret); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("deleted key err = %v", err)
+	}
+	if _, err := uc.AuthenticateKey(ctx, "not-a-key"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("malformed key err = %v", err)
+	}
+}
+
+func TestAuthProtectsLastAdmin(t *testing.T) {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	store := newFakeAuthStore()
+	uc := newTestAuth(store, &now)
+	ctx := context.Background()
+
+	admin, err := uc.CreateUser(ctx, UserInput{Username: "root", Password: "password123", Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	user := domain.RoleUser
+	if _, err := uc.UpdateUser(ctx, admin.ID, UserUpdate{Role: &user}); !errors.Is(err, domain.ErrLastAdmin) {
+		t.Fatalf("demote last admin err = %v", err)
+	}
+	if err := uc.DeleteUser(ctx, admin.ID); !errors.Is(err, domain.ErrLastAdmin) {
+		t.Fatalf("delete last admin err = %v", err)
+	}
+
+	if _, err := uc.CreateUser(ctx, UserInput{Username: "second", Password: "password123", Role: domain.RoleAdmin}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := uc.DeleteUser(ctx, admin.ID); err != nil {
+		t.Fatalf("DeleteUser with another admin: %v", err)
+	}
+}
+
+func TestAuthCreateUserValidation(t *testing.T) {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	uc := newTestAuth(newFakeAuthStore(), &now)
+	ctx := context.Background()
+
+	if _, err := uc.CreateUser(ctx, UserInput{Username: "dave", Password: "password123", Role: domain.RoleUser}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := uc.CreateUser(ctx, UserInput{Username: "DAVE", Password: "password123", Role: domain.RoleUser}); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Fatalf("duplicate err = %v", err)
+	}
+	for _, input := range []UserInput{
+		{Username: "bad name", Password: "password123", Role: domain.RoleUser},
+		{Username: "erin", Password: "short", Role: domain.RoleUser},
+		{Username: "erin", Password: "password123", Role: "owner"},
+	} {
+		if _, err := uc.CreateUser(ctx, input); !errors.Is(err, domain.ErrInvalidUser) {
+			t.Fatalf("CreateUser(%+v) err = %v, want ErrInvalidUser", input, err)
+		}
+	}
+}
+
+func TestAuthChangePasswordEndsSessions(t *testing.T) {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	store := newFakeAuthStore()
+	uc := newTestAuth(store, &now)
+	ctx := context.Background()
+
+	user, err := uc.CreateUser(ctx, UserInput{Username: "frank", Password: "password123", Role: domain.RoleUser})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	result, err := uc.Login(ctx, "frank", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if err := uc.ChangePassword(ctx, user.ID, "wrong", "new password"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("wrong current password err = %v", err)
+	}
+	if err := uc.ChangePassword(ctx, user.ID, "password123", "new password"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if _, err := uc.Authenticate(ctx, result.Token); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("old session err = %v", err)
+	}
+	if _, err := uc.Login(ctx, "frank", "new password"); err != nil {
+		t.Fatalf("Login with new password: %v", err)
+	}
+}
@@ -99,9 +99,20 @@ func (f *fakeControlRepo) UpdateProgress(ctx context.Context, id domain.TorrentI
 }
 
 func (f *fakeControlRepo) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return f.lookup(false)
+}
+
+func (f *fakeControlRepo) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return f.lookup(true)
+}
+
+func (f *fakeControlRepo) lookup(trashed bool) (domain.TorrentRecord, error) {
 	if f.getErr != nil {
 		return domain.TorrentRecord{}, f.getErr
 	}
+	if f.get.InTrash() != trashed {
+		return domain.TorrentRecord{}, domain.ErrNotFound
+	}
 	return f.get, nil
 }
 
@@ -217,6 +228,33 @@ func TestStopTorrent(t *testing.T) {
 	}
 }
 
+func TestStartStopTorrentPublishReason(t *testing.T) {
+	events := &fakeEventPublisher{}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{ID: "t1", Status: domain.TorrentStopped}}
+
+	if _, err := (StartTorrent{Engine: &fakeControlEngine{}, Repo: repo, Events: events}).Execute(context.Background(), "t1"); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := (StopTorrent{Engine: &fakeControlEngine{}, Repo: repo, Events: events, Reason: domain.ReasonStalled}).Execute(context.Background(), "t1"); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	// Failed stops publish nothing.
+	failing := StopTorrent{Engine: &fakeControlEngine{stopErr: errors.New("engine broke")}, Repo: repo, Events: events}
+	if _, err := failing.Execute(context.Background(), "t1"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(events.events) != 2 {
+		t.Fatalf("events = %+v", events.events)
+	}
+	if ev := events.events[0]; ev.Type != domain.EventTorrentStarted || ev.TorrentID != "t1" || ev.Reason != domain.ReasonUser {
+		t.Fatalf("start event = %+v", ev)
+	}
+	if ev := events.events[1]; ev.Type != domain.EventTorrentStopped || ev.Reason != domain.ReasonStalled {
+		t.Fatalf("stop event = %+v", ev)
+	}
+}
+
 func TestStopTorrentNotFound(t *testing.T) {
 	uc := StopTorrent{Engine: &fakeControlEngine{}, Repo: &fakeControlRepo{getErr: domain.ErrNotFound}}
 	_, err := uc.Execute(context.Background(), "t1")
@@ -379,6 +417,33 @@ func TestDeleteTorrentKeepsNonEmptyParentDirectories(t *testing.T) {
 	}
 }
 
+func TestDeleteTorrentRemovesExtractedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"movie.rar", "movie.mkv"} {
+		if err := os.MkdirAll(filepath.Join(dir, "Movie"), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Movie", name), []byte("data"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	repo := &fakeControlRepo{
+		get: domain.TorrentRecord{
+			ID:        "t1",
+			Files:     []domain.FileRef{{Index: 0, Path: "Movie/movie.rar", Length: 4}},
+			Extracted: []domain.FileRef{{Index: 1, Path: "Movie/movie.mkv", Length: 4}},
+		},
+	}
+	uc := DeleteTorrent{Engine: &fakeControlEngine{}, Repo: repo, DataDir: dir}
+
+	if err := uc.Execute(context.Background(), "t1", true); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Movie")); !os.IsNotExist(err) {
+		t.Fatalf("torrent folder should be removed with the extracted files")
+	}
+}
+
 func TestDeleteTorrentKeepFiles(t *testing.T) {
 	dir := t.TempDir()
 	rel := "video.mp4"
@@ -16,23 +16,57 @@ type CreateTorrent struct {
 	Engine ports.Engine
 	Repo   ports.TorrentRepository
 	Now    func() time.Time
+	// Space, when set, rejects torrents whose files do not fit on disk.
+	Space *DiskSpace
+	// Placement, when set, chooses the storage root of new torrents.
+	Placement *StoragePlacement
+	// Metainfo, when set, sizes .torrent files so Placement can pick a root
+	// with room for them. Magnets are placed before their size is known.
+	Metainfo ports.MetainfoEngine
+	// Events, when set, receives torrent_added for stored torrents.
+	Events ports.EventPublisher
 }
 
 type CreateTorrentInput struct {
 	Source domain.TorrentSource
 	Name   string
+	// Category selects the storage root under the category placement policy.
+	Category string
+	// Paused adds the torrent stopped: its session is never started, and
+	// a magnet stays stopped when its metadata arrives.
+	Paused bool
 }
 
 func (uc CreateTorrent) Execute(ctx context.Context, input CreateTorrentInput) (domain.TorrentRecord, error) {
 	if err := validateSource(input.Source); err != nil {
 		return domain.TorrentRecord{}, err
 	}
+	if input.Source.Magnet != "" {
+		params, err := parseMagnetParams(input.Source.Magnet)
+		if err != nil {
+			return domain.TorrentRecord{}, err
+		}
+		input = applyMagnetParams(input, params)
+	}
+	webSeeds, err := normalizeWebSeeds(input.Source.WebSeeds)
+	if err != nil {
+		return domain.TorrentRecord{}, err
+	}
+	input.Source.WebSeeds = webSeeds
 
 	now := time.Now
 	if uc.Now != nil {
 		now = uc.Now
 	}
 
+	if uc.Placement != nil && input.Source.DataDir == "" {
+		root, err := uc.Placement.Choose(ctx, input.Category, uc.sourceSize(input.Source))
+		if err != nil {
+			return domain.TorrentRecord{}, err
+		}
+		input.Source.DataDir = root.Path
+	}
+
 	session, err := uc.Engine.Open(ctx, input.Source)
 	if err != nil {
 		return domain.TorrentRecord{}, wrapEngine(err)
@@ -40,19 +74,64 @@ func (uc CreateTorrent) Execute(ctx context.Context, input CreateTorrentInput) (
 
 	// If the torrent already exists in the repository, return the existing
 	// record instead of failing with a duplicate key error.
-	existing, getErr := uc.Repo.Get(ctx, session.ID())
+	existing, getErr := getWithTrash(ctx, uc.Repo, session.ID())
 	if getErr == nil {
+		if existing.InTrash() {
+			return uc.restoreFromTrash(ctx, session, existing, input.Paused, now())
+		}
+		return existing, nil
+	}
+
+	files := markUnselected(session.Files(), input.Source.SelectedFiles)
+
+	infoHash, infoHashV2 := parseInfoHash(input.Source.Magnet)
+	private := false
+	if len(files) > 0 {
+		// Metadata is known: take whichever hash the magnet did not carry
+		// from the engine, so a .torrent file is deduplicated as well.
+		if state, err := uc.Engine.GetSessionState(ctx, session.ID()); err == nil {
+			if infoHash == "" {
+				infoHash = state.InfoHash
+			}
+			if infoHashV2 == "" {
+				infoHashV2 = state.InfoHashV2
+			}
+			private = state.Private
+		}
+	}
+
+	// A hybrid torrent added by its v1 hash and again by its v2 hash opens
+	// two sessions with different IDs; keep the record that already exists.
+	if existing, ok := uc.findByInfoHash(ctx, session.ID(), infoHash, infoHashV2); ok {
+		_ = uc.Engine.RemoveSession(ctx, session.ID())
 		return existing, nil
 	}
 
-	files := session.Files()
 	status := domain.TorrentActive
 
-	if len(files) == 0 {
+	switch {
+	case input.Paused:
+		// Disk space is checked when the torrent is started.
+		if err := uc.Engine.StopSession(ctx, session.ID()); err != nil {
+			_ = uc.Engine.RemoveSession(ctx, session.ID())
+			return domain.TorrentRecord{}, wrapEngine(err)
+		}
+		status = domain.TorrentStopped
+	case len(files) == 0:
 		// Metadata not yet available — torrent is pending
 		status = domain.TorrentPending
-	} else {
-		if err := session.Start(); err != nil {
+	default:
+		release := func() {}
+		if uc.Space != nil {
+			release, err = uc.Space.ReserveIn(ctx, input.Source.DataDir, session.ID(), files)
+			if err != nil {
+				_ = uc.Engine.RemoveSession(ctx, session.ID())
+				return domain.TorrentRecord{}, err
+			}
+		}
+		err := session.Start()
+		release()
+		if err != nil {
 			return domain.TorrentRecord{}, wrapEngine(err)
 		}
 	}
@@ -62,7 +141,6 @@ func (uc CreateTorrent) Execute(ctx context.Context, input CreateTorrentInput) (
 		name = deriveName(files)
 	}
 
-	infoHash := parseInfoHash(input.Source.Magnet)
 	if infoHash == "" {
 		infoHash = domain.InfoHash(session.ID())
 	}
@@ -72,10 +150,13 @@ func (uc CreateTorrent) Execute(ctx context.Context, input CreateTorrentInput) (
 		Name:       name,
 		Status:     status,
 		InfoHash:   infoHash,
+		InfoHashV2: infoHashV2,
+		Private:    private,
 		Source:     input.Source,
 		Files:      files,
 		TotalBytes: sumFileLengths(files),
 		DoneBytes:  0,
+		Category:   input.Category,
 		CreatedAt:  now(),
 		UpdatedAt:  now(),
 	}
@@ -94,6 +175,73 @@ func (uc CreateTorrent) Execute(ctx context.Context, input CreateTorrentInput) (
 		return domain.TorrentRecord{}, wrapRepo(err)
 	}
 
+	uc.publishAdded(record)
+	return record, nil
+}
+
+// sourceSize is the size of the selected files of a .torrent source, or 0
+// when it is not known.
+func (uc CreateTorrent) sourceSize(src domain.TorrentSource) int64 {
+	if uc.Metainfo == nil || src.Torrent == "" {
+		return 0
+	}
+	files, err := uc.Metainfo.TorrentFiles(src.Torrent)
+	if err != nil {
+		return 0
+	}
+	return selectedBytes(selectedFiles(files, src.SelectedFiles))
+}
+
+func (uc CreateTorrent) publishAdded(record domain.TorrentRecord) {
+	if uc.Events == nil {
+		return
+	}
+	source := "torrent"
+	if record.Source.Magnet != "" {
+		source = "magnet"
+	}
+	uc.Events.Publish(domain.Event{Type: domain.EventTorrentAdded, TorrentID: record.ID, Source: source})
+}
+
+// findByInfoHash looks up a live record stored under another ID whose v1
+// or v2 info hash matches one of hashes.
+func (uc CreateTorrent) findByInfoHash(ctx context.Context, id domain.TorrentID, hashes ...domain.InfoHash) (domain.TorrentRecord, bool) {
+	for _, hash := range hashes {
+		hash = domain.InfoHash(strings.ToLower(string(hash)))
+		if hash == "" {
+			continue
+		}
+		records, err := uc.Repo.List(ctx, domain.TorrentFilter{InfoHash: hash, Limit: 1})
+		if err != nil || len(records) == 0 || records[0].ID == id {
+			continue
+		}
+		return records[0], true
+	}
+	return domain.TorrentRecord{}, false
+}
+
+// restoreFromTrash takes a trashed torrent out of the trash when the same
+// torrent is added again.
+func (uc CreateTorrent) restoreFromTrash(ctx context.Context, session ports.Session, record domain.TorrentRecord, paused bool, now time.Time) (domain.TorrentRecord, error) {
+	record.DeletedAt = nil
+	record.DeleteFiles = false
+	record.Status = domain.TorrentPending
+	if paused {
+		if err := uc.Engine.StopSession(ctx, session.ID()); err != nil {
+			return domain.TorrentRecord{}, wrapEngine(err)
+		}
+		record.Status = domain.TorrentStopped
+	} else if len(session.Files()) > 0 {
+		if err := session.Start(); err != nil {
+			return domain.TorrentRecord{}, wrapEngine(err)
+		}
+		record.Status = domain.TorrentActive
+	}
+	record.UpdatedAt = now
+	if err := uc.Repo.Update(ctx, record); err != nil {
+		return domain.TorrentRecord{}, wrapRepo(err)
+	}
+	uc.publishAdded(record)
 	return record, nil
 }
 
@@ -131,27 +279,49 @@ func splitPathParts(path string) []string {
 	})
 }
 
-func parseInfoHash(magnet string) domain.InfoHash {
+// sha256Multihash is the multihash prefix of a SHA-256 digest: function
+// code 0x12, digest length 0x20.
+const sha256Multihash = "1220"
+
+// parseInfoHash extracts the v1 (xt=urn:btih:) and v2 (xt=urn:btmh:) info
+// hashes from a magnet link; hybrid magnets carry both. The v2 hash is
+// returned as the hex SHA-256 digest without the multihash prefix.
+func parseInfoHash(magnet string) (v1, v2 domain.InfoHash) {
 	magnet = strings.TrimSpace(magnet)
 	if magnet == "" {
-		return ""
+		return "", ""
 	}
+	v1 = domain.InfoHash(magnetParam(magnet, "xt=urn:btih:"))
 
-	lower := strings.ToLower(magnet)
-	idx := strings.Index(lower, "xt=urn:btih:")
-	if idx == -1 {
-		return ""
+	multihash := strings.ToLower(magnetParam(magnet, "xt=urn:btmh:"))
+	digest, ok := strings.CutPrefix(multihash, sha256Multihash)
+	if ok && len(digest) == 64 && isHex(digest) {
+		v2 = domain.InfoHash(digest)
 	}
+	return v1, v2
+}
 
-	start := idx + len("xt=urn:btih:")
-	rest := magnet[start:]
-	if rest == "" {
+// magnetParam returns the value following prefix in magnet, matched case
+// insensitively, up to the next parameter.
+func magnetParam(magnet, prefix string) string {
+	idx := strings.Index(strings.ToLower(magnet), prefix)
+	if idx == -1 {
 		return ""
 	}
 
+	rest := magnet[idx+len(prefix):]
 	end := strings.Index(rest, "&")
 	if end == -1 {
-		return domain.InfoHash(rest)
+		return rest
+	}
+	return rest[:end]
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') && (r < 'A' || r > 'F') {
+			return false
+		}
 	}
-	return domain.InfoHash(rest[:end])
+	return true
 }
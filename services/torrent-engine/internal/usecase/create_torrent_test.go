@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,6 +21,8 @@ type fakeEngine struct {
 	setPrioCalled   int
 	setPrioTorrent  domain.TorrentID
 	returnedSession ports.Session
+	state           domain.SessionState
+	removed         []domain.TorrentID
 }
 
 func (f *fakeEngine) Open(ctx context.Context, src domain.TorrentSource) (ports.Session, error) {
@@ -35,7 +38,7 @@ func (f *fakeEngine) Close() error { return nil }
 
 func (f *fakeEngine) GetSessionState(ctx context.Context, id domain.TorrentID) (domain.SessionState, error) {
 	f.stateCalled++
-	return domain.SessionState{}, nil
+	return f.state, nil
 }
 
 func (f *fakeEngine) GetSession(ctx context.Context, id domain.TorrentID) (ports.Session, error) {
@@ -54,7 +57,10 @@ func (f *fakeEngine) StopSession(ctx context.Context, id domain.TorrentID) error
 
 func (f *fakeEngine) StartSession(ctx context.Context, id domain.TorrentID) error { return nil }
 
-func (f *fakeEngine) RemoveSession(ctx context.Context, id domain.TorrentID) error { return nil }
+func (f *fakeEngine) RemoveSession(ctx context.Context, id domain.TorrentID) error {
+	f.removed = append(f.removed, id)
+	return nil
+}
 
 func (f *fakeEngine) SetPiecePriority(ctx context.Context, id domain.TorrentID, file domain.FileRef, r domain.Range, prio domain.Priority) error {
 	f.setPrioCalled++
@@ -80,6 +86,7 @@ type fakeSession struct {
 	files    []domain.FileRef
 	startErr error
 	stopErr  error
+	startCnt int
 	stopCnt  int
 }
 
@@ -96,7 +103,10 @@ func (s *fakeSession) SelectFile(index int) (domain.FileRef, error) {
 
 func (s *fakeSession) SetPiecePriority(file domain.FileRef, r domain.Range, prio domain.Priority) {}
 
-func (s *fakeSession) Start() error { return s.startErr }
+func (s *fakeSession) Start() error {
+	s.startCnt++
+	return s.startErr
+}
 
 func (s *fakeSession) Stop() error {
 	s.stopCnt++
@@ -129,6 +139,10 @@ func (r *fakeRepo) Get(ctx context.Context, id domain.TorrentID) (domain.Torrent
 	return domain.TorrentRecord{}, errors.New("not implemented")
 }
 
+func (r *fakeRepo) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return domain.TorrentRecord{}, errors.New("not implemented")
+}
+
 func (r *fakeRepo) List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error) {
 	return nil, errors.New("not implemented")
 }
@@ -252,6 +266,37 @@ func TestCreateTorrentSuccessMagnet(t *testing.T) {
 	}
 }
 
+type fakeEventPublisher struct {
+	events []domain.Event
+}
+
+func (f *fakeEventPublisher) Publish(event domain.Event) {
+	f.events = append(f.events, event)
+}
+
+func TestCreateTorrentPublishesAdded(t *testing.T) {
+	session := &fakeSession{id: "t1", files: []domain.FileRef{{Index: 0, Path: "f.mp4", Length: 1}}}
+	events := &fakeEventPublisher{}
+	uc := CreateTorrent{Engine: &fakeEngine{returnedSession: session}, Repo: &fakeRepo{}, Events: events}
+
+	if _, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Torrent: "f.torrent"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events.events) != 1 || events.events[0].Type != domain.EventTorrentAdded || events.events[0].TorrentID != "t1" || events.events[0].Source != "torrent" {
+		t.Fatalf("events = %+v", events.events)
+	}
+
+	// Adding a torrent that is already stored publishes nothing.
+	existing := domain.TorrentRecord{ID: "t1", Status: domain.TorrentActive}
+	uc.Repo = &fakeRepoWithGet{getRecord: existing}
+	if _, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Torrent: "f.torrent"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events.events) != 1 {
+		t.Fatalf("events = %+v", events.events)
+	}
+}
+
 func TestCreateTorrentInfoHashFallback(t *testing.T) {
 	files := []domain.FileRef{{Index: 0, Path: "file.mp4", Length: 10}}
 	session := &fakeSession{id: "hash123", files: files}
@@ -289,6 +334,29 @@ func TestCreateTorrentPendingWhenNoFiles(t *testing.T) {
 	}
 }
 
+func TestCreateTorrentPausedNeverStarts(t *testing.T) {
+	for _, files := range [][]domain.FileRef{nil, {{Index: 0, Path: "Sintel/Sintel.mp4", Length: 10}}} {
+		session := &fakeSession{id: "t1", files: files}
+		engine := &fakeEngine{returnedSession: session}
+		repo := &fakeRepo{}
+		uc := CreateTorrent{Engine: engine, Repo: repo}
+
+		got, err := uc.Execute(context.Background(), CreateTorrentInput{
+			Source: domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"},
+			Paused: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Status != domain.TorrentStopped || repo.createRecord.Status != domain.TorrentStopped {
+			t.Fatalf("files %d: status = %q, stored %q, want stopped", len(files), got.Status, repo.createRecord.Status)
+		}
+		if session.startCnt != 0 || engine.stopCalled != 1 {
+			t.Fatalf("files %d: started %d times, engine stopped %d times", len(files), session.startCnt, engine.stopCalled)
+		}
+	}
+}
+
 func TestCreateTorrentCustomName(t *testing.T) {
 	files := []domain.FileRef{{Index: 0, Path: "Sintel/Sintel.mp4", Length: 10}}
 	session := &fakeSession{id: "t1", files: files}
@@ -309,6 +377,23 @@ func TestCreateTorrentCustomName(t *testing.T) {
 	}
 }
 
+func TestCreateTorrentRecordsCategory(t *testing.T) {
+	session := &fakeSession{id: "t1", files: []domain.FileRef{{Index: 0, Path: "a.mkv", Length: 10}}}
+	repo := &fakeRepo{}
+	uc := CreateTorrent{Engine: &fakeEngine{returnedSession: session}, Repo: repo}
+
+	got, err := uc.Execute(context.Background(), CreateTorrentInput{
+		Source:   domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"},
+		Category: "tv-sonarr",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Category != "tv-sonarr" || repo.createRecord.Category != "tv-sonarr" {
+		t.Fatalf("Category = %q, stored %q", got.Category, repo.createRecord.Category)
+	}
+}
+
 func TestCreateTorrentWhitespaceSource(t *testing.T) {
 	uc := CreateTorrent{Engine: &fakeEngine{}, Repo: &fakeRepo{}, Now: func() time.Time { return time.Unix(0, 0).UTC() }}
 
@@ -325,9 +410,20 @@ type fakeRepoWithGet struct {
 }
 
 func (r *fakeRepoWithGet) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return r.lookup(false)
+}
+
+func (r *fakeRepoWithGet) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return r.lookup(true)
+}
+
+func (r *fakeRepoWithGet) lookup(trashed bool) (domain.TorrentRecord, error) {
 	if r.getErr != nil {
 		return domain.TorrentRecord{}, r.getErr
 	}
+	if r.getRecord.InTrash() != trashed {
+		return domain.TorrentRecord{}, domain.ErrNotFound
+	}
 	return r.getRecord, nil
 }
 
@@ -450,24 +546,136 @@ func TestDeriveName(t *testing.T) {
 }
 
 func TestParseInfoHash(t *testing.T) {
+	const v2 = "b8c07eb2ee73c6d3fcda1fba0e8e6e0f59d1d3c9c1b8a5d6a0e1f2a3b4c5d6e7"
 	tests := []struct {
 		name   string
 		magnet string
 		want   domain.InfoHash
+		wantV2 domain.InfoHash
 	}{
-		{"empty", "", ""},
-		{"no_xt", "magnet:?dn=Sintel", ""},
-		{"valid", "magnet:?xt=urn:btih:08ada5a7a6183aae1e09d831df6748d566095a10&dn=Sintel", "08ada5a7a6183aae1e09d831df6748d566095a10"},
-		{"no_dn", "magnet:?xt=urn:btih:abc123", "abc123"},
-		{"case_insensitive", "magnet:?XT=URN:BTIH:ABC123&dn=test", "ABC123"},
-		{"whitespace", "  magnet:?xt=urn:btih:abc  ", "abc"},
+		{"empty", "", "", ""},
+		{"no_xt", "magnet:?dn=Sintel", "", ""},
+		{"valid", "magnet:?xt=urn:btih:08ada5a7a6183aae1e09d831df6748d566095a10&dn=Sintel", "08ada5a7a6183aae1e09d831df6748d566095a10", ""},
+		{"no_dn", "magnet:?xt=urn:btih:abc123", "abc123", ""},
+		{"case_insensitive", "magnet:?XT=URN:BTIH:ABC123&dn=test", "ABC123", ""},
+		{"whitespace", "  magnet:?xt=urn:btih:abc  ", "abc", ""},
+		{"v2_only", "magnet:?xt=urn:btmh:1220" + v2 + "&dn=test", "", v2},
+		{"v2_upper", "magnet:?xt=urn:btmh:1220" + strings.ToUpper(v2), "", v2},
+		{"hybrid", "magnet:?xt=urn:btih:abc&xt=urn:btmh:1220" + v2, "abc", v2},
+		{"v2_not_sha256", "magnet:?xt=urn:btmh:1114" + v2[:40], "", ""},
+		{"v2_truncated", "magnet:?xt=urn:btmh:1220abcd", "", ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parseInfoHash(tt.magnet)
-			if got != tt.want {
-				t.Fatalf("got %q, want %q", got, tt.want)
+			got, gotV2 := parseInfoHash(tt.magnet)
+			if got != tt.want || gotV2 != tt.wantV2 {
+				t.Fatalf("got %q, %q; want %q, %q", got, gotV2, tt.want, tt.wantV2)
 			}
 		})
 	}
 }
+
+// fakeRepoByHash finds records by info hash, as the Mongo filter does.
+type fakeRepoByHash struct {
+	fakeRepo
+	records []domain.TorrentRecord
+}
+
+func (r *fakeRepoByHash) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	for _, rec := range r.records {
+		if rec.ID == id && !rec.InTrash() {
+			return rec, nil
+		}
+	}
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
+
+func (r *fakeRepoByHash) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	for _, rec := range r.records {
+		if rec.ID == id && rec.InTrash() {
+			return rec, nil
+		}
+	}
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
+
+func (r *fakeRepoByHash) List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error) {
+	var out []domain.TorrentRecord
+	for _, rec := range r.records {
+		if rec.InfoHash == filter.InfoHash || rec.InfoHashV2 == filter.InfoHash {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func TestCreateTorrentStoresBothInfoHashes(t *testing.T) {
+	const v2 = "b8c07eb2ee73c6d3fcda1fba0e8e6e0f59d1d3c9c1b8a5d6a0e1f2a3b4c5d6e7"
+	session := &fakeSession{id: "b8c07eb2ee73c6d3fcda1fba0e8e6e0f59d1d3c9"}
+	repo := &fakeRepoByHash{}
+	uc := CreateTorrent{Engine: &fakeEngine{returnedSession: session}, Repo: repo}
+
+	record, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{
+		Magnet: "magnet:?xt=urn:btmh:1220" + v2,
+	}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if record.InfoHashV2 != v2 || record.InfoHash != domain.InfoHash(session.id) {
+		t.Fatalf("hashes = %q, %q", record.InfoHash, record.InfoHashV2)
+	}
+
+	// A .torrent file gets its hashes from the engine once metadata is known.
+	session = &fakeSession{id: "1111111111111111111111111111111111111111", files: []domain.FileRef{{Index: 0, Path: "f.bin", Length: 1}}}
+	engine := &fakeEngine{returnedSession: session, state: domain.SessionState{InfoHash: "1111111111111111111111111111111111111111", InfoHashV2: "22", Private: true}}
+	uc = CreateTorrent{Engine: engine, Repo: repo}
+	record, err = uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Torrent: "/tmp/f.torrent"}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if record.InfoHashV2 != "22" || !record.Private {
+		t.Fatalf("infoHashV2 = %q, private = %v", record.InfoHashV2, record.Private)
+	}
+}
+
+func TestCreateTorrentDedupesHybridByEitherHash(t *testing.T) {
+	const v1 = "08ada5a7a6183aae1e09d831df6748d566095a10"
+	const v2 = "b8c07eb2ee73c6d3fcda1fba0e8e6e0f59d1d3c9c1b8a5d6a0e1f2a3b4c5d6e7"
+	existing := domain.TorrentRecord{ID: v1, Name: "Hybrid", InfoHash: v1, InfoHashV2: v2, Status: domain.TorrentActive}
+
+	// Added again by its v2 hash only: a new session keyed by the truncated
+	// v2 hash is opened, then dropped in favour of the existing record.
+	session := &fakeSession{id: domain.TorrentID(v2[:40])}
+	engine := &fakeEngine{returnedSession: session}
+	repo := &fakeRepoByHash{records: []domain.TorrentRecord{existing}}
+	uc := CreateTorrent{Engine: engine, Repo: repo}
+
+	got, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{
+		Magnet: "magnet:?xt=urn:btmh:1220" + strings.ToUpper(v2),
+	}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got.ID != existing.ID || repo.createCalled != 0 {
+		t.Fatalf("got %+v, createCalled %d", got, repo.createCalled)
+	}
+	if len(engine.removed) != 1 || engine.removed[0] != session.id {
+		t.Fatalf("duplicate session not removed: %v", engine.removed)
+	}
+
+	// A v2-only record is found by the v2 hash of a hybrid .torrent file.
+	v2Record := domain.TorrentRecord{ID: domain.TorrentID(v2[:40]), InfoHash: domain.InfoHash(v2[:40]), InfoHashV2: v2}
+	repo = &fakeRepoByHash{records: []domain.TorrentRecord{v2Record}}
+	engine = &fakeEngine{
+		returnedSession: &fakeSession{id: v1, files: []domain.FileRef{{Index: 0, Path: "f.bin", Length: 1}}},
+		state:           domain.SessionState{InfoHash: v1, InfoHashV2: v2},
+	}
+	uc = CreateTorrent{Engine: engine, Repo: repo}
+	got, err = uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Torrent: "/tmp/hybrid.torrent"}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got.ID != v2Record.ID || repo.createCalled != 0 {
+		t.Fatalf("got %+v, createCalled %d", got, repo.createCalled)
+	}
+}
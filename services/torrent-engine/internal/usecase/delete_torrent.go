@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"torrentstream/internal/domain"
 	"torrentstream/internal/domain/ports"
@@ -16,10 +17,27 @@ type DeleteTorrent struct {
 	Engine  ports.Engine
 	Repo    ports.TorrentRepository
 	DataDir string
+	// Placement, when set, resolves the storage root of the deleted files.
+	Placement *StoragePlacement
+	// Trash moves deleted torrents to the trash instead of removing them.
+	// Deleting a torrent that is already in the trash removes it for good.
+	Trash bool
+	Now   func() time.Time
+	// Stats drops the statistics history of permanently deleted torrents.
+	Stats TorrentStatsForgetter
+	// Events, when set, receives torrent_trashed and torrent_deleted with
+	// Reason (default user).
+	Events ports.EventPublisher
+	Reason string
+}
+
+// TorrentStatsForgetter drops the statistics history of a torrent.
+type TorrentStatsForgetter interface {
+	Forget(ctx context.Context, id domain.TorrentID) error
 }
 
 func (uc DeleteTorrent) Execute(ctx context.Context, id domain.TorrentID, deleteFiles bool) error {
-	record, err := uc.Repo.Get(ctx, id)
+	record, err := getWithTrash(ctx, uc.Repo, id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			return err
@@ -27,6 +45,13 @@ func (uc DeleteTorrent) Execute(ctx context.Context, id domain.TorrentID, delete
 		return wrapRepo(err)
 	}
 
+	if uc.Trash && !record.InTrash() {
+		return uc.moveToTrash(ctx, record, deleteFiles)
+	}
+	if record.InTrash() {
+		deleteFiles = deleteFiles || record.DeleteFiles
+	}
+
 	if uc.Engine != nil {
 		if err := uc.Engine.RemoveSession(ctx, id); err != nil && !errors.Is(err, domain.ErrNotFound) {
 			return wrapEngine(err)
@@ -42,8 +67,16 @@ func (uc DeleteTorrent) Execute(ctx context.Context, id domain.TorrentID, delete
 		return wrapRepo(err)
 	}
 
+	// Statistics are secondary data; a failure leaves orphaned samples that
+	// expire on their own.
+	if uc.Stats != nil {
+		_ = uc.Stats.Forget(ctx, id)
+	}
+
+	uc.publish(domain.EventTorrentDeleted, id)
+
 	if deleteFiles {
-		if err := removeTorrentFiles(uc.DataDir, record.Files); err != nil {
+		if err := removeTorrentFiles(recordRoot(uc.Placement, uc.DataDir, record), record.AllFiles()); err != nil {
 			return err
 		}
 	}
@@ -51,6 +84,45 @@ func (uc DeleteTorrent) Execute(ctx context.Context, id domain.TorrentID, delete
 	return nil
 }
 
+func (uc DeleteTorrent) publish(typ domain.EventType, id domain.TorrentID) {
+	if uc.Events == nil {
+		return
+	}
+	reason := uc.Reason
+	if reason == "" {
+		reason = domain.ReasonUser
+	}
+	uc.Events.Publish(domain.Event{Type: typ, TorrentID: id, Reason: reason})
+}
+
+// moveToTrash closes the session and marks the record as deleted. Data stays
+// on disk until the record is purged.
+func (uc DeleteTorrent) moveToTrash(ctx context.Context, record domain.TorrentRecord, deleteFiles bool) error {
+	now := time.Now
+	if uc.Now != nil {
+		now = uc.Now
+	}
+
+	if uc.Engine != nil {
+		if err := uc.Engine.RemoveSession(ctx, record.ID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return wrapEngine(err)
+		}
+	}
+
+	deletedAt := now().UTC()
+	record.DeletedAt = &deletedAt
+	record.DeleteFiles = deleteFiles
+	record.UpdatedAt = deletedAt
+	if err := uc.Repo.Update(ctx, record); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		return wrapRepo(err)
+	}
+	uc.publish(domain.EventTorrentTrashed, record.ID)
+	return nil
+}
+
 func removeTorrentFiles(baseDir string, files []domain.FileRef) error {
 	if strings.TrimSpace(baseDir) == "" {
 		return errors.New("data dir not configured")
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"torrentstream/internal/domain"
@@ -13,6 +14,10 @@ import (
 // directory and stops all active non-focused downloads when free space drops
 // below MinFreeBytes. Stopped sessions are resumed once free space exceeds
 // ResumeBytes (hysteresis prevents rapid stop/resume cycles).
+//
+// With several storage roots (Placement) every root is checked on its own
+// and only downloads stored on a root under pressure are stopped. A root's
+// MinFreeBytes overrides the global threshold.
 type DiskPressure struct {
 	Engine       ports.Engine
 	Logger       *slog.Logger
@@ -20,11 +25,64 @@ type DiskPressure struct {
 	MinFreeBytes int64 // threshold below which downloads are paused
 	ResumeBytes  int64 // threshold above which downloads may resume
 	Interval     time.Duration
+	Placement    *StoragePlacement
+	// Status, when set, receives the per-root result of every check.
+	Status *DiskPressureStatus
+	// Events, when set, receives disk_pressure when downloads are stopped,
+	// and torrent_stopped and torrent_started for every torrent.
+	Events ports.EventPublisher
 
 	// diskFreeFunc overrides the platform disk space check (used in tests).
 	diskFreeFunc func(string) (int64, error)
 }
 
+// DiskPressureRoot is the latest disk pressure state of one storage root.
+type DiskPressureRoot struct {
+	Path            string    `json:"path"`
+	FreeBytes       int64     `json:"freeBytes"`
+	MinFreeBytes    int64     `json:"minFreeBytes"`
+	ResumeBytes     int64     `json:"resumeBytes"`
+	Paused          bool      `json:"paused"`
+	StoppedTorrents int       `json:"stoppedTorrents"`
+	CheckedAt       time.Time `json:"checkedAt"`
+}
+
+// DiskPressureStatus publishes DiskPressure results to readers such as the
+// storage settings endpoint.
+type DiskPressureStatus struct {
+	mu    sync.RWMutex
+	roots []DiskPressureRoot
+}
+
+// Roots returns the latest per-root state in configuration order.
+func (s *DiskPressureStatus) Roots() []DiskPressureRoot {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]DiskPressureRoot(nil), s.roots...)
+}
+
+func (s *DiskPressureStatus) set(roots []DiskPressureRoot) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.roots = roots
+	s.mu.Unlock()
+}
+
+// pressureRoot tracks the hysteresis state of one storage root.
+type pressureRoot struct {
+	path    string
+	minFree int64
+	resume  int64
+	free    int64
+	paused  bool
+	stopped map[domain.TorrentID]struct{}
+}
+
 // Run starts the periodic disk pressure check loop. It blocks until ctx is
 // cancelled.
 func (dp DiskPressure) Run(ctx context.Context) {
@@ -36,8 +94,7 @@ func (dp DiskPressure) Run(ctx context.Context) {
 		dp.ResumeBytes = dp.MinFreeBytes * 2
 	}
 
-	paused := false
-	stoppedByPressure := make(map[domain.TorrentID]struct{})
+	roots := dp.pressureRoots()
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -47,41 +104,110 @@ func (dp DiskPressure) Run(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			freeFn := dp.diskFreeFunc
-			if freeFn == nil {
-				freeFn = diskFreeBytes
-			}
-			free, err := freeFn(dp.DataDir)
-			if err != nil {
-				dp.Logger.Warn("disk_pressure: failed to check disk space",
-					slog.String("path", dp.DataDir),
-					slog.String("error", err.Error()),
-				)
-				continue
+			for _, root := range roots {
+				dp.check(ctx, root, len(roots) > 1)
 			}
+			dp.publish(roots)
+		}
+	}
+}
 
-			if !paused && free < dp.MinFreeBytes {
-				dp.Logger.Warn("disk_pressure: low disk space, stopping active downloads",
-					slog.Int64("freeBytes", free),
-					slog.Int64("thresholdBytes", dp.MinFreeBytes),
-				)
-				dp.stopActiveDownloads(ctx, stoppedByPressure)
-				paused = true
-			} else if paused && free >= dp.ResumeBytes {
-				dp.Logger.Info("disk_pressure: disk space recovered, resuming downloads",
-					slog.Int64("freeBytes", free),
-					slog.Int64("resumeBytes", dp.ResumeBytes),
-				)
-				dp.resumeStoppedDownloads(ctx, stoppedByPressure)
-				paused = false
-			}
+func (dp DiskPressure) pressureRoots() []*pressureRoot {
+	if dp.Placement == nil || len(dp.Placement.Roots) == 0 {
+		return []*pressureRoot{{
+			path:    dp.DataDir,
+			minFree: dp.MinFreeBytes,
+			resume:  dp.ResumeBytes,
+			stopped: make(map[domain.TorrentID]struct{}),
+		}}
+	}
+	roots := make([]*pressureRoot, 0, len(dp.Placement.Roots))
+	for _, r := range dp.Placement.Roots {
+		root := &pressureRoot{
+			path:    r.Path,
+			minFree: dp.MinFreeBytes,
+			resume:  dp.ResumeBytes,
+			stopped: make(map[domain.TorrentID]struct{}),
+		}
+		if r.MinFreeBytes > 0 {
+			root.minFree = r.MinFreeBytes
+			root.resume = r.MinFreeBytes * 2
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// check stops or resumes downloads on one root. When perRoot is set only the
+// sessions stored on that root are affected.
+func (dp DiskPressure) check(ctx context.Context, root *pressureRoot, perRoot bool) {
+	if root.minFree <= 0 {
+		return
+	}
+	freeFn := dp.diskFreeFunc
+	if freeFn == nil {
+		freeFn = diskFreeBytes
+	}
+	free, err := freeFn(root.path)
+	if err != nil {
+		dp.Logger.Warn("disk_pressure: failed to check disk space",
+			slog.String("path", root.path),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	root.free = free
+
+	filter := ""
+	if perRoot {
+		filter = root.path
+	}
+	if !root.paused && free < root.minFree {
+		dp.Logger.Warn("disk_pressure: low disk space, stopping active downloads",
+			slog.String("path", root.path),
+			slog.Int64("freeBytes", free),
+			slog.Int64("thresholdBytes", root.minFree),
+		)
+		dp.stopActiveDownloads(ctx, filter, root.stopped)
+		root.paused = true
+		if dp.Events != nil {
+			dp.Events.Publish(domain.Event{Type: domain.EventDiskPressure, Path: root.path, FreeBytes: free})
 		}
+	} else if root.paused && free >= root.resume {
+		dp.Logger.Info("disk_pressure: disk space recovered, resuming downloads",
+			slog.String("path", root.path),
+			slog.Int64("freeBytes", free),
+			slog.Int64("resumeBytes", root.resume),
+		)
+		dp.resumeStoppedDownloads(ctx, root.stopped)
+		root.paused = false
+	}
+}
+
+func (dp DiskPressure) publish(roots []*pressureRoot) {
+	if dp.Status == nil {
+		return
+	}
+	now := time.Now().UTC()
+	out := make([]DiskPressureRoot, 0, len(roots))
+	for _, root := range roots {
+		out = append(out, DiskPressureRoot{
+			Path:            root.path,
+			FreeBytes:       root.free,
+			MinFreeBytes:    root.minFree,
+			ResumeBytes:     root.resume,
+			Paused:          root.paused,
+			StoppedTorrents: len(root.stopped),
+			CheckedAt:       now,
+		})
 	}
+	dp.Status.set(out)
 }
 
-// stopActiveDownloads stops all active sessions except the focused one and
-// records their IDs so they can be resumed later.
-func (dp DiskPressure) stopActiveDownloads(ctx context.Context, stopped map[domain.TorrentID]struct{}) {
+// stopActiveDownloads stops all active sessions stored on root (any root when
+// empty) except the focused one and records their IDs so they can be resumed
+// later.
+func (dp DiskPressure) stopActiveDownloads(ctx context.Context, root string, stopped map[domain.TorrentID]struct{}) {
 	ids, err := dp.Engine.ListActiveSessions(ctx)
 	if err != nil {
 		dp.Logger.Warn("disk_pressure: list active sessions failed",
@@ -99,6 +225,9 @@ func (dp DiskPressure) stopActiveDownloads(ctx context.Context, stopped map[doma
 		if mode == domain.ModeFocused {
 			continue
 		}
+		if root != "" && dp.Placement.RootOf(ctx, id) != root {
+			continue
+		}
 		if err := dp.Engine.StopSession(ctx, id); err != nil {
 			dp.Logger.Warn("disk_pressure: stop session failed",
 				slog.String("id", string(id)),
@@ -109,6 +238,7 @@ func (dp DiskPressure) stopActiveDownloads(ctx context.Context, stopped map[doma
 			dp.Logger.Info("disk_pressure: stopped session",
 				slog.String("id", string(id)),
 			)
+			dp.publishTorrent(domain.EventTorrentStopped, id)
 		}
 	}
 }
@@ -126,7 +256,14 @@ func (dp DiskPressure) resumeStoppedDownloads(ctx context.Context, stopped map[d
 			dp.Logger.Info("disk_pressure: resumed session",
 				slog.String("id", string(id)),
 			)
+			dp.publishTorrent(domain.EventTorrentStarted, id)
 		}
 		delete(stopped, id)
 	}
 }
+
+func (dp DiskPressure) publishTorrent(typ domain.EventType, id domain.TorrentID) {
+	if dp.Events != nil {
+		dp.Events.Publish(domain.Event{Type: typ, TorrentID: id, Reason: domain.ReasonDiskPressure})
+	}
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -84,7 +85,7 @@ func TestStopActiveDownloadsEmpty(t *testing.T) {
 	dp := DiskPressure{Engine: engine, Logger: discardLogger()}
 	stopped := make(map[domain.TorrentID]struct{})
 
-	dp.stopActiveDownloads(context.Background(), stopped)
+	dp.stopActiveDownloads(context.Background(), "", stopped)
 
 	if len(stopped) != 0 {
 		t.Fatalf("expected no stopped sessions, got %d", len(stopped))
@@ -96,7 +97,7 @@ func TestStopActiveDownloadsListError(t *testing.T) {
 	dp := DiskPressure{Engine: engine, Logger: discardLogger()}
 	stopped := make(map[domain.TorrentID]struct{})
 
-	dp.stopActiveDownloads(context.Background(), stopped)
+	dp.stopActiveDownloads(context.Background(), "", stopped)
 
 	if len(stopped) != 0 {
 		t.Fatalf("expected no stopped sessions on error, got %d", len(stopped))
@@ -115,7 +116,7 @@ func TestStopActiveDownloadsSkipsFocused(t *testing.T) {
 	dp := DiskPressure{Engine: engine, Logger: discardLogger()}
 	stopped := make(map[domain.TorrentID]struct{})
 
-	dp.stopActiveDownloads(context.Background(), stopped)
+	dp.stopActiveDownloads(context.Background(), "", stopped)
 
 	if len(engine.stopCalls) != 2 {
 		t.Fatalf("expected 2 stop calls, got %d: %v", len(engine.stopCalls), engine.stopCalls)
@@ -139,7 +140,7 @@ func TestStopActiveDownloadsStopError(t *testing.T) {
 	dp := DiskPressure{Engine: engine, Logger: discardLogger()}
 	stopped := make(map[domain.TorrentID]struct{})
 
-	dp.stopActiveDownloads(context.Background(), stopped)
+	dp.stopActiveDownloads(context.Background(), "", stopped)
 
 	if len(engine.stopCalls) != 1 {
 		t.Fatalf("expected stop to be attempted")
@@ -157,7 +158,7 @@ func TestStopActiveDownloadsModeError(t *testing.T) {
 	dp := DiskPressure{Engine: engine, Logger: discardLogger()}
 	stopped := make(map[domain.TorrentID]struct{})
 
-	dp.stopActiveDownloads(context.Background(), stopped)
+	dp.stopActiveDownloads(context.Background(), "", stopped)
 
 	// When GetSessionMode fails, the session is skipped (continue)
 	if len(engine.stopCalls) != 0 {
@@ -388,3 +389,52 @@ func TestRunNoStopWhenAboveThreshold(t *testing.T) {
 		t.Fatalf("expected no stop calls when above threshold")
 	}
 }
+
+func TestCheckPublishesDiskPressure(t *testing.T) {
+	events := &fakeEventPublisher{}
+	dp := DiskPressure{
+		Engine:       &fakeDiskEngine{},
+		Logger:       discardLogger(),
+		Events:       events,
+		diskFreeFunc: func(path string) (int64, error) { return 500, nil },
+	}
+	root := &pressureRoot{path: "/data", minFree: 1000, resume: 2000, stopped: map[domain.TorrentID]struct{}{}}
+
+	dp.check(context.Background(), root, false)
+	dp.check(context.Background(), root, false) // still paused, no new event
+
+	if len(events.events) != 1 {
+		t.Fatalf("events = %+v", events.events)
+	}
+	if ev := events.events[0]; ev.Type != domain.EventDiskPressure || ev.Path != "/data" || ev.FreeBytes != 500 {
+		t.Fatalf("event = %+v", ev)
+	}
+}
+
+func TestCheckPublishesStoppedAndResumedTorrents(t *testing.T) {
+	events := &fakeEventPublisher{}
+	free := int64(500)
+	dp := DiskPressure{
+		Engine: &fakeDiskEngine{
+			activeSessions: []domain.TorrentID{"t1", "t2"},
+			modes:          map[domain.TorrentID]domain.SessionMode{"t1": domain.ModeDownloading, "t2": domain.ModeFocused},
+		},
+		Logger:       discardLogger(),
+		Events:       events,
+		diskFreeFunc: func(path string) (int64, error) { return free, nil },
+	}
+	root := &pressureRoot{path: "/data", minFree: 1000, resume: 2000, stopped: map[domain.TorrentID]struct{}{}}
+
+	dp.check(context.Background(), root, false)
+	free = 3000
+	dp.check(context.Background(), root, false)
+
+	var got []string
+	for _, ev := range events.events {
+		got = append(got, string(ev.Type)+":"+string(ev.TorrentID)+":"+ev.Reason)
+	}
+	want := []string{"torrent_stopped:t1:disk_pressure", "disk_pressure::", "torrent_started:t1:disk_pressure"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+// DiskSpaceUsage is a snapshot of free space on the data directory and the
+// bytes already promised to active downloads.
+type DiskSpaceUsage struct {
+	FreeBytes      int64 `json:"freeBytes"`
+	ReservedBytes  int64 `json:"reservedBytes"`
+	AvailableBytes int64 `json:"availableBytes"`
+}
+
+// DiskSpace is a pre-flight guard that refuses to start a download when the
+// remaining bytes of its selected files do not fit into free space minus the
+// bytes still to be written by other active downloads. Unlike DiskPressure it
+// acts before space runs out.
+//
+// A torrent admitted by Reserve holds its reservation until the returned
+// release function is called; by then the session is active and its remaining
+// bytes are counted through the engine instead.
+type DiskSpace struct {
+	Engine  ports.Engine
+	DataDir string
+	// MinFreeBytes returns the free space that must be left untouched
+	// (the disk-pressure threshold). Nil means 0.
+	MinFreeBytes func() int64
+	// Placement, when set, spreads torrents over several storage roots: each
+	// root is checked on its own, against its min-free threshold and quota.
+	Placement *StoragePlacement
+
+	// diskFreeFunc overrides the platform disk space check (used in tests).
+	diskFreeFunc func(string) (int64, error)
+
+	mu      sync.Mutex
+	pending map[domain.TorrentID]pendingReservation
+}
+
+type pendingReservation struct {
+	dir   string
+	bytes int64
+}
+
+// Usage reports free, reserved and available bytes on the data directory.
+func (g *DiskSpace) Usage(ctx context.Context) (DiskSpaceUsage, error) {
+	return g.UsageIn(ctx, "")
+}
+
+// UsageIn reports free, reserved and available bytes on the storage root dir
+// (the data directory when empty).
+func (g *DiskSpace) UsageIn(ctx context.Context, dir string) (DiskSpaceUsage, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.usageLocked(ctx, g.rootDir(dir), "")
+}
+
+// Reserve checks whether the remaining bytes of files fit on disk and, if so,
+// reserves them for id. The returned release function must be called once
+// the session has been started (or failed to start).
+func (g *DiskSpace) Reserve(ctx context.Context, id domain.TorrentID, files []domain.FileRef) (func(), error) {
+	return g.ReserveIn(ctx, "", id, files)
+}
+
+// ReserveIn is Reserve for a torrent stored on the storage root dir.
+func (g *DiskSpace) ReserveIn(ctx context.Context, dir string, id domain.TorrentID, files []domain.FileRef) (func(), error) {
+	need := remainingBytes(files)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	dir = g.rootDir(dir)
+	if left, ok, err := g.Placement.QuotaLeft(ctx, dir, id); err == nil && ok {
+		if size := selectedBytes(files); size > left {
+			return nil, fmt.Errorf("%w: need %d bytes, quota of %s has %d left",
+				ErrInsufficientSpace, size, dir, max(left, 0))
+		}
+	}
+
+	usage, err := g.usageLocked(ctx, dir, id)
+	if err != nil {
+		// Never block downloads because the platform cannot report free space.
+		return func() {}, nil
+	}
+	if need > usage.AvailableBytes {
+		return nil, fmt.Errorf("%w: need %d bytes, %d available (%d free, %d reserved)",
+			ErrInsufficientSpace, need, max(usage.AvailableBytes, 0), usage.FreeBytes, usage.ReservedBytes)
+	}
+
+	if g.pending == nil {
+		g.pending = make(map[domain.TorrentID]pendingReservation)
+	}
+	g.pending[id] = pendingReservation{dir: dir, bytes: need}
+	return func() {
+		g.mu.Lock()
+		delete(g.pending, id)
+		g.mu.Unlock()
+	}, nil
+}
+
+// rootDir maps dir to the storage root it belongs to.
+func (g *DiskSpace) rootDir(dir string) string {
+	if g.Placement != nil && len(g.Placement.Roots) > 0 {
+		return g.Placement.Root(dir).Path
+	}
+	if dir == "" {
+		return g.DataDir
+	}
+	return dir
+}
+
+// multiRoot reports whether downloads are spread over several roots, in
+// which case only torrents on the same root compete for its space.
+func (g *DiskSpace) multiRoot() bool {
+	return g.Placement != nil && len(g.Placement.Roots) > 1
+}
+
+func (g *DiskSpace) usageLocked(ctx context.Context, dir string, exclude domain.TorrentID) (DiskSpaceUsage, error) {
+	freeFn := g.diskFreeFunc
+	if freeFn == nil {
+		freeFn = diskFreeBytes
+	}
+	free, err := freeFn(dir)
+	if err != nil {
+		return DiskSpaceUsage{}, err
+	}
+
+	var reserved int64
+	counted := make(map[domain.TorrentID]struct{})
+	if g.Engine != nil {
+		ids, err := g.Engine.ListActiveSessions(ctx)
+		if err == nil {
+			for _, id := range ids {
+				if id == exclude {
+					continue
+				}
+				if g.multiRoot() && g.Placement.RootOf(ctx, id) != dir {
+					continue
+				}
+				state, err := g.Engine.GetSessionState(ctx, id)
+				if err != nil {
+					continue
+				}
+				counted[id] = struct{}{}
+				reserved += remainingBytes(state.Files)
+			}
+		}
+	}
+	for id, p := range g.pending {
+		if id == exclude || p.dir != dir {
+			continue
+		}
+		if _, ok := counted[id]; ok {
+			continue
+		}
+		reserved += p.bytes
+	}
+
+	var minFree int64
+	if g.MinFreeBytes != nil {
+		minFree = g.MinFreeBytes()
+	}
+	if g.Placement != nil {
+		minFree = max(minFree, g.Placement.Root(dir).MinFreeBytes)
+	}
+
+	return DiskSpaceUsage{
+		FreeBytes:      free,
+		ReservedBytes:  reserved,
+		AvailableBytes: free - reserved - minFree,
+	}, nil
+}
+
+// MetadataSpaceCheck stops a magnet download whose metadata, once it
+// arrives, shows that its selected files do not fit. Until then its size is
+// unknown, so DiskSpace could not check it when the torrent was added.
+type MetadataSpaceCheck struct {
+	Engine ports.Engine
+	Repo   ports.TorrentRepository
+	Space  *DiskSpace
+	Logger *slog.Logger
+	// Events, when set, receives torrent_stopped (reason disk_pressure).
+	Events ports.EventPublisher
+}
+
+func (uc MetadataSpaceCheck) HandleEvent(ctx context.Context, event domain.Event) {
+	if event.Type != domain.EventMetadataReady {
+		return
+	}
+	record, err := uc.Repo.Get(ctx, event.TorrentID)
+	if err != nil {
+		return
+	}
+	// The engine may still report the torrent as pending; only a stopped or
+	// finished one has nothing left to check.
+	state, err := uc.Engine.GetSessionState(ctx, event.TorrentID)
+	if err != nil || state.Status == domain.TorrentStopped || state.Status == domain.TorrentCompleted {
+		return
+	}
+
+	files := selectedFiles(state.Files, record.Source.SelectedFiles)
+	release, err := uc.Space.ReserveIn(ctx, record.Source.DataDir, record.ID, files)
+	if err == nil {
+		release()
+		return
+	}
+	if !errors.Is(err, ErrInsufficientSpace) {
+		return
+	}
+	if err := uc.Engine.StopSession(ctx, record.ID); err != nil {
+		uc.Logger.Warn("disk_space: stop session failed",
+			slog.String("id", string(record.ID)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	uc.Logger.Warn("disk_space: stopped download that does not fit",
+		slog.String("id", string(record.ID)),
+		slog.String("reason", err.Error()),
+	)
+	if uc.Events != nil {
+		uc.Events.Publish(domain.Event{Type: domain.EventTorrentStopped, TorrentID: record.ID, Reason: domain.ReasonDiskPressure})
+	}
+}
+
+// selectedFiles returns a copy of files with those outside a select-only
+// list marked unselected, so space is only reserved for what is downloaded.
+func selectedFiles(files []domain.FileRef, selected []int) []domain.FileRef {
+	return markUnselected(slices.Clone(files), selected)
+}
+
+// selectedBytes sums the full length of files selected for download.
+func selectedBytes(files []domain.FileRef) int64 {
+	var total int64
+	for _, f := range files {
+		if f.Priority != "none" {
+			total += f.Length
+		}
+	}
+	return total
+}
+
+// remainingBytes sums the bytes still to be downloaded for files that are
+// selected for download (any priority other than "none").
+func remainingBytes(files []domain.FileRef) int64 {
+	var total int64
+	for _, f := range files {
+		if f.Priority == "none" {
+			continue
+		}
+		if left := f.Length - f.BytesCompleted; left > 0 {
+			total += left
+		}
+	}
+	return total
+}
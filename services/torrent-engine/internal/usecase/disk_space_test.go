@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeSpaceEngine struct {
+	fakeDiskEngine
+	states map[domain.TorrentID]domain.SessionState
+}
+
+func (f *fakeSpaceEngine) GetSessionState(ctx context.Context, id domain.TorrentID) (domain.SessionState, error) {
+	state, ok := f.states[id]
+	if !ok {
+		return domain.SessionState{}, domain.ErrNotFound
+	}
+	return state, nil
+}
+
+func fixedFree(free int64) func(string) (int64, error) {
+	return func(string) (int64, error) { return free, nil }
+}
+
+func TestRemainingBytesSkipsUnselectedFiles(t *testing.T) {
+	files := []domain.FileRef{
+		{Index: 0, Length: 100, BytesCompleted: 40},
+		{Index: 1, Length: 50, BytesCompleted: 0, Priority: "none"},
+		{Index: 2, Length: 30, BytesCompleted: 30, Priority: "normal"},
+		{Index: 3, Length: 20, BytesCompleted: 0, Priority: "high"},
+	}
+	if got := remainingBytes(files); got != 80 {
+		t.Fatalf("remainingBytes = %d, want 80", got)
+	}
+}
+
+func TestDiskSpaceUsageCountsActiveDownloads(t *testing.T) {
+	engine := &fakeSpaceEngine{
+		fakeDiskEngine: fakeDiskEngine{activeSessions: []domain.TorrentID{"a", "b", "gone"}},
+		states: map[domain.TorrentID]domain.SessionState{
+			"a": {Files: []domain.FileRef{{Length: 600, BytesCompleted: 100}}},
+			"b": {Files: []domain.FileRef{{Length: 300, BytesCompleted: 300}}},
+		},
+	}
+	g := &DiskSpace{
+		Engine:       engine,
+		MinFreeBytes: func() int64 { return 100 },
+		diskFreeFunc: fixedFree(1000),
+	}
+
+	usage, err := g.Usage(context.Background())
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if usage.FreeBytes != 1000 || usage.ReservedBytes != 500 || usage.AvailableBytes != 400 {
+		t.Fatalf("usage = %+v", usage)
+	}
+}
+
+func TestDiskSpaceReserveRejectsWhenOtherDownloadsReserveSpace(t *testing.T) {
+	engine := &fakeSpaceEngine{
+		fakeDiskEngine: fakeDiskEngine{activeSessions: []domain.TorrentID{"a"}},
+		states: map[domain.TorrentID]domain.SessionState{
+			"a": {Files: []domain.FileRef{{Length: 60, BytesCompleted: 0}}},
+		},
+	}
+	g := &DiskSpace{Engine: engine, diskFreeFunc: fixedFree(100)}
+
+	release, err := g.Reserve(context.Background(), "b", []domain.FileRef{{Length: 30}})
+	if err != nil {
+		t.Fatalf("Reserve within budget: %v", err)
+	}
+	release()
+
+	_, err = g.Reserve(context.Background(), "b", []domain.FileRef{{Length: 60}})
+	if !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace", err)
+	}
+}
+
+func TestDiskSpaceReserveExcludesSelf(t *testing.T) {
+	engine := &fakeSpaceEngine{
+		fakeDiskEngine: fakeDiskEngine{activeSessions: []domain.TorrentID{"a"}},
+		states: map[domain.TorrentID]domain.SessionState{
+			"a": {Files: []domain.FileRef{{Length: 80}}},
+		},
+	}
+	g := &DiskSpace{Engine: engine, diskFreeFunc: fixedFree(100)}
+
+	release, err := g.Reserve(context.Background(), "a", []domain.FileRef{{Length: 80}})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	release()
+}
+
+func TestDiskSpacePendingReservationsUntilReleased(t *testing.T) {
+	g := &DiskSpace{Engine: &fakeSpaceEngine{}, diskFreeFunc: fixedFree(100)}
+
+	release, err := g.Reserve(context.Background(), "a", []domain.FileRef{{Length: 70}})
+	if err != nil {
+		t.Fatalf("Reserve a: %v", err)
+	}
+	if _, err := g.Reserve(context.Background(), "b", []domain.FileRef{{Length: 70}}); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace while a is pending", err)
+	}
+
+	usage, _ := g.Usage(context.Background())
+	if usage.ReservedBytes != 70 {
+		t.Fatalf("reserved = %d, want 70", usage.ReservedBytes)
+	}
+
+	release()
+	if _, err := g.Reserve(context.Background(), "b", []domain.FileRef{{Length: 70}}); err != nil {
+		t.Fatalf("Reserve b after release: %v", err)
+	}
+}
+
+func TestDiskSpaceReserveAllowsWhenFreeSpaceUnknown(t *testing.T) {
+	g := &DiskSpace{diskFreeFunc: func(string) (int64, error) { return 0, errors.New("unsupported") }}
+
+	release, err := g.Reserve(context.Background(), "a", []domain.FileRef{{Length: 1 << 40}})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	release()
+}
+
+func TestCreateTorrentRejectsWhenDiskFull(t *testing.T) {
+	session := &fakeSession{id: "t1", files: []domain.FileRef{{Index: 0, Path: "a/movie.mkv", Length: 200}}}
+	engine := &fakeEngine{returnedSession: session}
+	repo := &fakeRepo{}
+	uc := CreateTorrent{
+		Engine: engine,
+		Repo:   repo,
+		Now:    func() time.Time { return time.Unix(0, 0).UTC() },
+		Space:  &DiskSpace{Engine: engine, diskFreeFunc: fixedFree(100)},
+	}
+
+	_, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"}})
+	if !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace", err)
+	}
+	if repo.createCalled != 0 {
+		t.Fatalf("record must not be created")
+	}
+}
+
+func TestStartTorrentRejectsWhenDiskFull(t *testing.T) {
+	engine := &fakeControlEngine{}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:     "t1",
+		Status: domain.TorrentStopped,
+		Files:  []domain.FileRef{{Index: 0, Length: 200, BytesCompleted: 50}},
+	}}
+	uc := StartTorrent{
+		Engine: engine,
+		Repo:   repo,
+		Space:  &DiskSpace{Engine: engine, diskFreeFunc: fixedFree(100)},
+	}
+
+	_, err := uc.Execute(context.Background(), "t1")
+	if !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace", err)
+	}
+	if engine.startCalled != 0 {
+		t.Fatalf("session must not be started")
+	}
+
+	repo.get.Files[0].BytesCompleted = 150
+	if _, err := uc.Execute(context.Background(), "t1"); err != nil {
+		t.Fatalf("Execute with remaining 50 bytes: %v", err)
+	}
+	if engine.startCalled != 1 {
+		t.Fatalf("session not started")
+	}
+}
+
+func TestStartTorrentReservesOnlySelectedFiles(t *testing.T) {
+	engine := &fakeControlEngine{}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:     "t1",
+		Status: domain.TorrentStopped,
+		Source: domain.TorrentSource{SelectedFiles: []int{0}},
+		Files: []domain.FileRef{
+			{Index: 0, Length: 50},
+			{Index: 1, Length: 200},
+		},
+	}}
+	uc := StartTorrent{
+		Engine: engine,
+		Repo:   repo,
+		Space:  &DiskSpace{Engine: engine, diskFreeFunc: fixedFree(100)},
+	}
+
+	if _, err := uc.Execute(context.Background(), "t1"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if engine.startCalled != 1 {
+		t.Fatalf("session not started")
+	}
+}
+
+func TestMetadataSpaceCheckStopsDownloadThatDoesNotFit(t *testing.T) {
+	engine := &fakeSpaceEngine{states: map[domain.TorrentID]domain.SessionState{
+		"t1": {Status: domain.TorrentPending, Files: []domain.FileRef{
+			{Index: 0, Length: 200},
+			{Index: 1, Length: 50},
+		}},
+	}}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{ID: "t1", Status: domain.TorrentPending}}
+	events := &fakeEventPublisher{}
+	uc := MetadataSpaceCheck{
+		Engine: engine,
+		Repo:   repo,
+		Space:  &DiskSpace{Engine: engine, diskFreeFunc: fixedFree(100)},
+		Logger: discardLogger(),
+		Events: events,
+	}
+
+	uc.HandleEvent(context.Background(), domain.Event{Type: domain.EventMetadataReady, TorrentID: "t1"})
+	if len(engine.stopCalls) != 1 || engine.stopCalls[0] != "t1" {
+		t.Fatalf("stop calls = %v, want [t1]", engine.stopCalls)
+	}
+	if len(events.events) != 1 || events.events[0].Type != domain.EventTorrentStopped || events.events[0].Reason != domain.ReasonDiskPressure {
+		t.Fatalf("events = %+v", events.events)
+	}
+
+	// Only the selected file has to fit.
+	engine.stopCalls = nil
+	repo.get.Source.SelectedFiles = []int{1}
+	uc.HandleEvent(context.Background(), domain.Event{Type: domain.EventMetadataReady, TorrentID: "t1"})
+	if len(engine.stopCalls) != 0 {
+		t.Fatalf("download that fits was stopped: %v", engine.stopCalls)
+	}
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"torrentstream/internal/domain"
+)
+
+const (
+	defaultEventLogLimit = 100
+	maxEventLogLimit     = 1000
+)
+
+// EventLogStore persists the event timeline.
+type EventLogStore interface {
+	AddEvent(ctx context.Context, event domain.Event) error
+	// ListEvents returns the newest matching events first.
+	ListEvents(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error)
+}
+
+// EventLog records every published event so the history of a torrent (who
+// stopped it, when it failed) can be read back after the fact.
+type EventLog struct {
+	Store  EventLogStore
+	Logger *slog.Logger
+}
+
+// HandleEvent stores event. Failures are logged; the timeline is best effort.
+func (uc *EventLog) HandleEvent(ctx context.Context, event domain.Event) {
+	if err := uc.Store.AddEvent(ctx, event); err != nil {
+		uc.logger().Warn("event log: store failed",
+			slog.String("type", string(event.Type)),
+			slog.String("torrentId", string(event.TorrentID)),
+			slog.String("error", err.Error()))
+	}
+}
+
+// List returns the newest events matching filter, 100 by default and at
+// most 1000.
+func (uc *EventLog) List(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultEventLogLimit
+	}
+	if filter.Limit > maxEventLogLimit {
+		filter.Limit = maxEventLogLimit
+	}
+	events, err := uc.Store.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	return events, nil
+}
+
+func (uc *EventLog) logger() *slog.Logger {
+	if uc.Logger != nil {
+		return uc.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeEventLogStore struct {
+	events  []domain.Event
+	filter  domain.EventFilter
+	addErr  error
+	listErr error
+}
+
+func (s *fakeEventLogStore) AddEvent(_ context.Context, event domain.Event) error {
+	if s.addErr != nil {
+		return s.addErr
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *fakeEventLogStore) ListEvents(_ context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	s.filter = filter
+	return s.events, s.listErr
+}
+
+func TestEventLogStoresEvents(t *testing.T) {
+	store := &fakeEventLogStore{}
+	uc := &EventLog{Store: store, Logger: discardLogger()}
+
+	uc.HandleEvent(context.Background(), domain.Event{Type: domain.EventTorrentStopped, TorrentID: "t1", Reason: domain.ReasonIdle})
+	store.addErr = errors.New("db down")
+	uc.HandleEvent(context.Background(), domain.Event{Type: domain.EventError, TorrentID: "t1"})
+
+	if len(store.events) != 1 || store.events[0].Reason != domain.ReasonIdle {
+		t.Fatalf("stored = %+v", store.events)
+	}
+}
+
+func TestEventLogListLimits(t *testing.T) {
+	store := &fakeEventLogStore{}
+	uc := &EventLog{Store: store}
+
+	if _, err := uc.List(context.Background(), domain.EventFilter{TorrentID: "t1"}); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if store.filter.Limit != 100 || store.filter.TorrentID != "t1" {
+		t.Fatalf("filter = %+v", store.filter)
+	}
+	if _, err := uc.List(context.Background(), domain.EventFilter{Limit: 5000}); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if store.filter.Limit != 1000 {
+		t.Fatalf("limit = %d", store.filter.Limit)
+	}
+
+	store.listErr = errors.New("db down")
+	if _, err := uc.List(context.Background(), domain.EventFilter{}); !errors.Is(err, ErrRepository) {
+		t.Fatalf("expected repository error, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// Integrity reports piece hash failures of a torrent and the verified state
+// of its files.
+type Integrity struct {
+	Engine ports.IntegrityEngine
+	Repo   ports.TorrentRepository
+	Now    func() time.Time
+}
+
+// Report returns the integrity report of the open session. A torrent without
+// a session gets a report built from its stored file progress.
+func (uc Integrity) Report(ctx context.Context, id domain.TorrentID) (domain.IntegrityReport, error) {
+	record, err := uc.Repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.IntegrityReport{}, err
+		}
+		return domain.IntegrityReport{}, wrapRepo(err)
+	}
+
+	report, err := uc.Engine.Integrity(ctx, id)
+	if err == nil {
+		return report, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return domain.IntegrityReport{}, wrapEngine(err)
+	}
+
+	report = domain.IntegrityReport{
+		TorrentID:    id,
+		FailedPieces: []domain.PieceFailure{},
+		BadPeers:     []domain.BadPeer{},
+		Files:        make([]domain.FileIntegrity, 0, len(record.Files)),
+		GeneratedAt:  uc.now(),
+	}
+	for _, f := range record.Files {
+		report.Files = append(report.Files, storedFileIntegrity(f))
+	}
+	return report, nil
+}
+
+// storedFileIntegrity summarizes a file from its stored byte and piece
+// counts; stored data was hash-checked when it was written.
+func storedFileIntegrity(f domain.FileRef) domain.FileIntegrity {
+	fi := domain.FileIntegrity{
+		Index:  f.Index,
+		Path:   f.Path,
+		Length: f.Length,
+		Pieces: f.PieceEnd - f.PieceStart,
+	}
+	switch {
+	case f.BytesCompleted >= f.Length:
+		fi.Status = domain.FileIntact
+		fi.CompletePieces = fi.Pieces
+	case f.BytesCompleted <= 0:
+		fi.Status = domain.FileMissing
+	default:
+		fi.Status = domain.FilePartial
+		fi.CompletePieces = int(f.Progress * float64(fi.Pieces))
+	}
+	return fi
+}
+
+func (uc Integrity) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now().UTC()
+	}
+	return time.Now().UTC()
+}
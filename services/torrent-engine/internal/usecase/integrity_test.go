@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeIntegrityEngine struct {
+	report domain.IntegrityReport
+	err    error
+}
+
+func (f *fakeIntegrityEngine) Integrity(ctx context.Context, id domain.TorrentID) (domain.IntegrityReport, error) {
+	return f.report, f.err
+}
+
+func TestIntegrityReportFromOpenSession(t *testing.T) {
+	engine := &fakeIntegrityEngine{report: domain.IntegrityReport{TorrentID: "t1", SessionOpen: true, HashFailures: 4}}
+	uc := Integrity{Engine: engine, Repo: &fakeControlRepo{get: domain.TorrentRecord{ID: "t1"}}}
+
+	report, err := uc.Report(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if !report.SessionOpen || report.HashFailures != 4 {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestIntegrityReportFallsBackToRecord(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	record := domain.TorrentRecord{ID: "t1", Files: []domain.FileRef{
+		{Index: 0, Path: "a.mkv", Length: 100, BytesCompleted: 100, PieceStart: 0, PieceEnd: 4, Progress: 1},
+		{Index: 1, Path: "b.mkv", Length: 100, BytesCompleted: 50, PieceStart: 4, PieceEnd: 8, Progress: 0.5},
+		{Index: 2, Path: "c.mkv", Length: 100, PieceStart: 8, PieceEnd: 12},
+	}}
+	uc := Integrity{
+		Engine: &fakeIntegrityEngine{err: domain.ErrNotFound},
+		Repo:   &fakeControlRepo{get: record},
+		Now:    func() time.Time { return now },
+	}
+
+	report, err := uc.Report(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if report.SessionOpen || !report.GeneratedAt.Equal(now) || len(report.Files) != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+	want := []domain.FileIntegrityStatus{domain.FileIntact, domain.FilePartial, domain.FileMissing}
+	for i, f := range report.Files {
+		if f.Status != want[i] {
+			t.Fatalf("file %d status = %q, want %q", i, f.Status, want[i])
+		}
+	}
+	if report.Files[1].CompletePieces != 2 || report.Files[0].CompletePieces != 4 {
+		t.Fatalf("files = %+v", report.Files)
+	}
+}
+
+func TestIntegrityReportErrors(t *testing.T) {
+	deletedAt := time.Now()
+	trashed := Integrity{
+		Engine: &fakeIntegrityEngine{},
+		Repo:   &fakeControlRepo{get: domain.TorrentRecord{ID: "t1", DeletedAt: &deletedAt}},
+	}
+	if _, err := trashed.Report(context.Background(), "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("trashed: expected ErrNotFound, got %v", err)
+	}
+
+	failing := Integrity{
+		Engine: &fakeIntegrityEngine{err: errors.New("client closed")},
+		Repo:   &fakeControlRepo{get: domain.TorrentRecord{ID: "t1"}},
+	}
+	if _, err := failing.Report(context.Background(), "t1"); !errors.Is(err, ErrEngine) {
+		t.Fatalf("expected ErrEngine, got %v", err)
+	}
+
+	missing := Integrity{Engine: &fakeIntegrityEngine{}, Repo: &fakeControlRepo{getErr: domain.ErrNotFound}}
+	if _, err := missing.Report(context.Background(), "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
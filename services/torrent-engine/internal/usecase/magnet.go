@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"torrentstream/internal/domain"
+)
+
+// maxSelectOnlyFiles bounds the file indices a select-only range may expand
+// to, so "so=0-999999999" cannot allocate without limit.
+const maxSelectOnlyFiles = 100000
+
+// magnetParams are the magnet link parameters applied when a torrent is
+// created, beyond the info hashes.
+type magnetParams struct {
+	DisplayName string   // dn
+	Trackers    []string // tr, tr.1, tr.2, ...
+	WebSeeds    []string // ws
+	Peers       []string // x.pe
+	SelectOnly  []int    // so (BEP 53)
+}
+
+// parseMagnetParams reads the dn, tr, ws, x.pe and so parameters of a
+// magnet link. Malformed web seeds and peer addresses are skipped; a
+// malformed select-only list is an error, since downloading every file
+// instead of the requested ones is not a safe fallback.
+func parseMagnetParams(magnet string) (magnetParams, error) {
+	_, query, ok := strings.Cut(strings.TrimSpace(magnet), "?")
+	if !ok {
+		return magnetParams{}, nil
+	}
+	// ParseQuery keeps every well-formed pair when others fail to decode.
+	values, _ := url.ParseQuery(query)
+
+	var params magnetParams
+	params.DisplayName = strings.TrimSpace(values.Get("dn"))
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "tr" || strings.HasPrefix(key, "tr.") {
+			params.Trackers = appendUnique(params.Trackers, values[key]...)
+		}
+	}
+
+	for _, raw := range values["ws"] {
+		if ws, err := domain.NormalizeWebSeedURL(raw); err == nil {
+			params.WebSeeds = appendUnique(params.WebSeeds, ws)
+		}
+	}
+
+	for _, raw := range values["x.pe"] {
+		addr := strings.TrimSpace(raw)
+		if host, port, err := net.SplitHostPort(addr); err == nil && host != "" && port != "" {
+			params.Peers = appendUnique(params.Peers, addr)
+		}
+	}
+
+	if raw := values.Get("so"); raw != "" {
+		selected, err := parseSelectOnly(raw)
+		if err != nil {
+			return magnetParams{}, err
+		}
+		params.SelectOnly = selected
+	}
+	return params, nil
+}
+
+// parseSelectOnly parses a BEP 53 file index list such as "0,2,4-6" into
+// sorted, distinct indices.
+func parseSelectOnly(raw string) ([]int, error) {
+	seen := make(map[int]struct{})
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last := part, part
+		if lo, hi, isRange := strings.Cut(part, "-"); isRange {
+			first, last = lo, hi
+		}
+		start, err1 := strconv.Atoi(strings.TrimSpace(first))
+		end, err2 := strconv.Atoi(strings.TrimSpace(last))
+		if err1 != nil || err2 != nil || start < 0 || end < start {
+			return nil, fmt.Errorf("%w: select-only %q", ErrInvalidSource, raw)
+		}
+		if end-start >= maxSelectOnlyFiles || len(seen)+end-start >= maxSelectOnlyFiles {
+			return nil, fmt.Errorf("%w: select-only %q selects too many files", ErrInvalidSource, raw)
+		}
+		for i := start; i <= end; i++ {
+			seen[i] = struct{}{}
+		}
+	}
+	if len(seen) == 0 {
+		return nil, nil
+	}
+	selected := make([]int, 0, len(seen))
+	for i := range seen {
+		selected = append(selected, i)
+	}
+	sort.Ints(selected)
+	return selected, nil
+}
+
+// applyMagnetParams fills the create input from the magnet parameters.
+// Values set explicitly on the input take precedence.
+func applyMagnetParams(input CreateTorrentInput, params magnetParams) CreateTorrentInput {
+	if input.Name == "" {
+		input.Name = params.DisplayName
+	}
+	input.Source.Trackers = appendUnique(input.Source.Trackers, params.Trackers...)
+	input.Source.WebSeeds = appendUnique(input.Source.WebSeeds, params.WebSeeds...)
+	input.Source.Peers = appendUnique(input.Source.Peers, params.Peers...)
+	if len(input.Source.SelectedFiles) == 0 {
+		input.Source.SelectedFiles = params.SelectOnly
+	}
+	return input
+}
+
+// markUnselected sets the priority of files outside a select-only list to
+// "none", so disk space is only reserved and counted for selected files.
+func markUnselected(files []domain.FileRef, selected []int) []domain.FileRef {
+	if len(selected) == 0 {
+		return files
+	}
+	for i := range files {
+		if !slices.Contains(selected, files[i].Index) {
+			files[i].Priority = "none"
+		}
+	}
+	return files
+}
+
+// appendUnique appends the non-blank values not already in list.
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+func TestParseMagnetParams(t *testing.T) {
+	magnet := "magnet:?xt=urn:btih:abc&dn=Big+Show+S01" +
+		"&tr=udp%3A%2F%2Fone%3A6969&tr.1=http%3A%2F%2Ftwo%2Fannounce&tr=udp%3A%2F%2Fone%3A6969" +
+		"&ws=http%3A%2F%2Fmirror%2Fdata%2F&ws=not+a+url" +
+		"&x.pe=10.0.0.2%3A6881&x.pe=bad-peer&x.pe=%5B%3A%3A1%5D%3A51413" +
+		"&so=0%2C2%2C4-6"
+
+	params, err := parseMagnetParams(magnet)
+	if err != nil {
+		t.Fatalf("parseMagnetParams: %v", err)
+	}
+	if params.DisplayName != "Big Show S01" {
+		t.Errorf("DisplayName = %q", params.DisplayName)
+	}
+	if want := []string{"udp://one:6969", "http://two/announce"}; !reflect.DeepEqual(params.Trackers, want) {
+		t.Errorf("Trackers = %v, want %v", params.Trackers, want)
+	}
+	if len(params.WebSeeds) != 1 || params.WebSeeds[0] != "http://mirror/data/" {
+		t.Errorf("WebSeeds = %v", params.WebSeeds)
+	}
+	if want := []string{"10.0.0.2:6881", "[::1]:51413"}; !reflect.DeepEqual(params.Peers, want) {
+		t.Errorf("Peers = %v, want %v", params.Peers, want)
+	}
+	if want := []int{0, 2, 4, 5, 6}; !reflect.DeepEqual(params.SelectOnly, want) {
+		t.Errorf("SelectOnly = %v, want %v", params.SelectOnly, want)
+	}
+}
+
+func TestParseSelectOnly(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []int
+		bad  bool
+	}{
+		{raw: "3", want: []int{3}},
+		{raw: "5,1-2,2", want: []int{1, 2, 5}},
+		{raw: " 0 , 7-7 ", want: []int{0, 7}},
+		{raw: ",", want: nil},
+		{raw: "a", bad: true},
+		{raw: "-1", bad: true},
+		{raw: "4-2", bad: true},
+		{raw: "0-999999999", bad: true},
+	}
+	for _, tc := range tests {
+		got, err := parseSelectOnly(tc.raw)
+		if tc.bad {
+			if !errors.Is(err, ErrInvalidSource) {
+				t.Errorf("%q: err = %v, want ErrInvalidSource", tc.raw, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %v, %v; want %v", tc.raw, got, err, tc.want)
+		}
+	}
+}
+
+func TestCreateTorrentAppliesMagnetParams(t *testing.T) {
+	session := &fakeSession{id: "t1", files: []domain.FileRef{
+		{Index: 0, Path: "show/e1.mkv", Length: 10},
+		{Index: 1, Path: "show/e2.mkv", Length: 10},
+	}}
+	engine := &fakeEngine{returnedSession: session}
+	repo := &fakeRepo{}
+	uc := CreateTorrent{Engine: engine, Repo: repo}
+
+	record, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{
+		Magnet:   "magnet:?xt=urn:btih:abc&dn=Show&tr=udp%3A%2F%2Ft%3A1&ws=http%3A%2F%2Fm%2F&x.pe=1.2.3.4%3A5&so=1",
+		Trackers: []string{"http://extra/announce"},
+	}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if record.Name != "Show" {
+		t.Errorf("Name = %q, want dn", record.Name)
+	}
+	src := engine.openSource
+	if want := []string{"http://extra/announce", "udp://t:1"}; !reflect.DeepEqual(src.Trackers, want) {
+		t.Errorf("engine trackers = %v, want %v", src.Trackers, want)
+	}
+	if len(src.WebSeeds) != 1 || len(src.Peers) != 1 || !reflect.DeepEqual(src.SelectedFiles, []int{1}) {
+		t.Errorf("engine source = %+v", src)
+	}
+	if !reflect.DeepEqual(record.Source, src) {
+		t.Errorf("record source %+v differs from engine source %+v", record.Source, src)
+	}
+	if record.Files[0].Priority != "none" || record.Files[1].Priority == "none" {
+		t.Errorf("files = %+v, want only index 1 selected", record.Files)
+	}
+}
+
+func TestCreateTorrentRejectsInvalidSelectOnly(t *testing.T) {
+	engine := &fakeEngine{returnedSession: &fakeSession{id: "t1"}}
+	uc := CreateTorrent{Engine: engine, Repo: &fakeRepo{}}
+
+	_, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{
+		Magnet: "magnet:?xt=urn:btih:abc&so=x",
+	}})
+	if !errors.Is(err, ErrInvalidSource) {
+		t.Fatalf("err = %v, want ErrInvalidSource", err)
+	}
+	if engine.openCalled != 0 {
+		t.Fatalf("engine must not open a torrent with an invalid so parameter")
+	}
+}
@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+const (
+	defaultPostProcessTimeout    = 10 * time.Minute
+	defaultPostProcessConcurrent = 2
+	// postProcessOutputLimit caps the output stored per run.
+	postProcessOutputLimit = 64 << 10
+	defaultRunLimit        = 50
+)
+
+// PostProcessStore persists post-processing commands and their runs.
+type PostProcessStore interface {
+	ListPostProcessCommands(ctx context.Context) ([]domain.PostProcessCommand, error)
+	// GetPostProcessCommand returns domain.ErrNotFound for an unknown id.
+	GetPostProcessCommand(ctx context.Context, id string) (domain.PostProcessCommand, error)
+	SavePostProcessCommand(ctx context.Context, cmd domain.PostProcessCommand) error
+	// DeletePostProcessCommand returns domain.ErrNotFound for an unknown id.
+	DeletePostProcessCommand(ctx context.Context, id string) error
+	AddPostProcessRun(ctx context.Context, run domain.PostProcessRun) error
+	// ListPostProcessRuns returns the newest runs of a torrent first.
+	ListPostProcessRuns(ctx context.Context, id domain.TorrentID, limit int) ([]domain.PostProcessRun, error)
+}
+
+// PostProcessVars are the values command arguments are rendered with, e.g.
+// "{{.SavePath}}/{{.Name}}" or "{{join .Tags \",\"}}".
+type PostProcessVars struct {
+	ID       string
+	Name     string
+	InfoHash string
+	// SavePath is the storage root the torrent is downloaded to.
+	SavePath string
+	// Files are the absolute paths of the torrent's files.
+	Files []string
+	Tags  []string
+	Event string
+}
+
+var postProcessFuncs = template.FuncMap{"join": strings.Join}
+
+// PostProcess runs the configured commands for torrents on their trigger
+// events. At most MaxConcurrent commands run at a time; the others wait.
+type PostProcess struct {
+	Store PostProcessStore
+	Repo  ports.TorrentRepository
+	// Placement resolves the save path; without it files are resolved
+	// against DataDir.
+	Placement *StoragePlacement
+	DataDir   string
+	Logger    *slog.Logger
+	Now       func() time.Time
+	// Timeout applies to commands without their own (default 10m).
+	Timeout       time.Duration
+	MaxConcurrent int
+
+	once sync.Once
+	sem  chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (uc *PostProcess) List(ctx context.Context) ([]domain.PostProcessCommand, error) {
+	cmds, err := uc.Store.ListPostProcessCommands(ctx)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	return cmds, nil
+}
+
+func (uc *PostProcess) Get(ctx context.Context, id string) (domain.PostProcessCommand, error) {
+	cmd, err := uc.Store.GetPostProcessCommand(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.PostProcessCommand{}, err
+		}
+		return domain.PostProcessCommand{}, wrapRepo(err)
+	}
+	return cmd, nil
+}
+
+// Create validates and stores a new command.
+func (uc *PostProcess) Create(ctx context.Context, cmd domain.PostProcessCommand) (domain.PostProcessCommand, error) {
+	if err := normalizePostProcessCommand(&cmd); err != nil {
+		return domain.PostProcessCommand{}, err
+	}
+	cmd.ID = newID()
+	cmd.CreatedAt = uc.now()
+	cmd.UpdatedAt = cmd.CreatedAt
+	if err := uc.Store.SavePostProcessCommand(ctx, cmd); err != nil {
+		return domain.PostProcessCommand{}, wrapRepo(err)
+	}
+	return cmd, nil
+}
+
+// Update replaces an existing command.
+func (uc *PostProcess) Update(ctx context.Context, cmd domain.PostProcessCommand) (domain.PostProcessCommand, error) {
+	existing, err := uc.Get(ctx, cmd.ID)
+	if err != nil {
+		return domain.PostProcessCommand{}, err
+	}
+	if err := normalizePostProcessCommand(&cmd); err != nil {
+		return domain.PostProcessCommand{}, err
+	}
+	cmd.CreatedAt = existing.CreatedAt
+	cmd.UpdatedAt = uc.now()
+	if err := uc.Store.SavePostProcessCommand(ctx, cmd); err != nil {
+		return domain.PostProcessCommand{}, wrapRepo(err)
+	}
+	return cmd, nil
+}
+
+func (uc *PostProcess) Delete(ctx context.Context, id string) error {
+	if err := uc.Store.DeletePostProcessCommand(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+// Runs returns the newest runs for a torrent.
+func (uc *PostProcess) Runs(ctx context.Context, id domain.TorrentID, limit int) ([]domain.PostProcessRun, error) {
+	if limit <= 0 {
+		limit = defaultRunLimit
+	}
+	runs, err := uc.Store.ListPostProcessRuns(ctx, id, limit)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	return runs, nil
+}
+
+// HandleEvent starts the enabled commands triggered by event in the
+// background.
+func (uc *PostProcess) HandleEvent(ctx context.Context, event domain.Event) {
+	if event.TorrentID == "" || !domain.ValidPostProcessTrigger(event.Type) {
+		return
+	}
+	cmds, err := uc.Store.ListPostProcessCommands(ctx)
+	if err != nil {
+		uc.logger().Warn("post-process: list commands failed", slog.String("error", err.Error()))
+		return
+	}
+	var record *domain.TorrentRecord
+	for _, cmd := range cmds {
+		if !cmd.Enabled || cmd.Trigger != event.Type {
+			continue
+		}
+		if record == nil {
+			r, err := getWithTrash(ctx, uc.Repo, event.TorrentID)
+			if err != nil {
+				uc.logger().Warn("post-process: torrent lookup failed",
+					slog.String("torrentId", string(event.TorrentID)),
+					slog.String("error", err.Error()))
+				return
+			}
+			record = &r
+		}
+		vars := uc.vars(*record, event.Type)
+		uc.wg.Add(1)
+		go func(cmd domain.PostProcessCommand) {
+			defer uc.wg.Done()
+			uc.run(ctx, cmd, record.ID, vars)
+		}(cmd)
+	}
+}
+
+// Wait blocks until running commands are done.
+func (uc *PostProcess) Wait() {
+	uc.wg.Wait()
+}
+
+func (uc *PostProcess) run(ctx context.Context, cmd domain.PostProcessCommand, id domain.TorrentID, vars PostProcessVars) {
+	uc.once.Do(func() {
+		n := uc.MaxConcurrent
+		if n <= 0 {
+			n = defaultPostProcessConcurrent
+		}
+		uc.sem = make(chan struct{}, n)
+	})
+	select {
+	case uc.sem <- struct{}{}:
+		defer func() { <-uc.sem }()
+	case <-ctx.Done():
+		return
+	}
+
+	run := domain.PostProcessRun{
+		ID:          newID(),
+		TorrentID:   id,
+		CommandID:   cmd.ID,
+		CommandName: cmd.Name,
+		Trigger:     cmd.Trigger,
+		Program:     cmd.Program,
+		ExitCode:    -1,
+		StartedAt:   uc.now(),
+	}
+	start := time.Now()
+	args, err := renderPostProcessArgs(cmd.Args, vars)
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		run.Args = args
+		uc.exec(ctx, cmd, vars.SavePath, &run)
+	}
+	run.Duration = time.Since(start).Milliseconds()
+
+	log := uc.logger().With(
+		slog.String("torrentId", string(id)),
+		slog.String("command", cmd.ID),
+		slog.Int("exitCode", run.ExitCode),
+		slog.Int64("durationMs", run.Duration))
+	if run.Success {
+		log.Info("post-process: command finished")
+	} else {
+		log.Warn("post-process: command failed", slog.String("error", run.Error))
+	}
+
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := uc.Store.AddPostProcessRun(logCtx, run); err != nil {
+		uc.logger().Warn("post-process: record run failed", slog.String("error", err.Error()))
+	}
+}
+
+func (uc *PostProcess) exec(ctx context.Context, cmd domain.PostProcessCommand, dir string, run *domain.PostProcessRun) {
+	timeout := uc.Timeout
+	if cmd.TimeoutSeconds > 0 {
+		timeout = time.Duration(cmd.TimeoutSeconds) * time.Second
+	}
+	if timeout <= 0 {
+		timeout = defaultPostProcessTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c := exec.CommandContext(runCtx, cmd.Program, run.Args...)
+	c.Dir = dir
+	c.WaitDelay = 5 * time.Second
+	out := &limitedBuffer{limit: postProcessOutputLimit}
+	c.Stdout = out
+	c.Stderr = out
+	err := c.Run()
+
+	run.Output = out.buf.String()
+	run.Truncated = out.truncated
+	if c.ProcessState != nil {
+		run.ExitCode = c.ProcessState.ExitCode()
+	}
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		run.Error = fmt.Sprintf("timed out after %s", timeout)
+	case err != nil:
+		run.Error = err.Error()
+	default:
+		run.Success = true
+	}
+}
+
+func (uc *PostProcess) vars(record domain.TorrentRecord, trigger domain.EventType) PostProcessVars {
+	root := recordRoot(uc.Placement, uc.DataDir, record)
+	files := make([]string, 0, len(record.Files))
+	for _, f := range record.Files {
+		files = append(files, filepath.Join(root, filepath.FromSlash(f.Path)))
+	}
+	return PostProcessVars{
+		ID:       string(record.ID),
+		Name:     record.Name,
+		InfoHash: string(record.InfoHash),
+		SavePath: root,
+		Files:    files,
+		Tags:     append([]string(nil), record.Tags...),
+		Event:    string(trigger),
+	}
+}
+
+// renderPostProcessArgs renders every argument template with vars.
+func renderPostProcessArgs(args []string, vars PostProcessVars) ([]string, error) {
+	out := make([]string, 0, len(args))
+	for i, arg := range args {
+		tmpl, err := template.New("arg").Funcs(postProcessFuncs).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("arg %d: %w", i, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, vars); err != nil {
+			return nil, fmt.Errorf("arg %d: %w", i, err)
+		}
+		out = append(out, buf.String())
+	}
+	return out, nil
+}
+
+// normalizePostProcessCommand validates the trigger, program and argument
+// templates.
+func normalizePostProcessCommand(cmd *domain.PostProcessCommand) error {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	cmd.Program = strings.TrimSpace(cmd.Program)
+	if !domain.ValidPostProcessTrigger(cmd.Trigger) {
+		return fmt.Errorf("%w: unsupported trigger %q", domain.ErrInvalidPostProcess, cmd.Trigger)
+	}
+	if cmd.Program == "" {
+		return fmt.Errorf("%w: program is required", domain.ErrInvalidPostProcess)
+	}
+	if cmd.TimeoutSeconds < 0 {
+		return fmt.Errorf("%w: timeoutSeconds must be >= 0", domain.ErrInvalidPostProcess)
+	}
+	// Rendering with sample values catches unknown fields and bad syntax.
+	sample := PostProcessVars{Files: []string{"file"}, Tags: []string{"tag"}}
+	if _, err := renderPostProcessArgs(cmd.Args, sample); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidPostProcess, err)
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+func (uc *PostProcess) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func (uc *PostProcess) logger() *slog.Logger {
+	if uc.Logger != nil {
+		return uc.Logger
+	}
+	return slog.Default()
+}
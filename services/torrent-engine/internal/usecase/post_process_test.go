@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakePostProcessStore struct {
+	mu   sync.Mutex
+	cmds map[string]domain.PostProcessCommand
+	runs []domain.PostProcessRun
+}
+
+func newFakePostProcessStore() *fakePostProcessStore {
+	return &fakePostProcessStore{cmds: make(map[string]domain.PostProcessCommand)}
+}
+
+func (f *fakePostProcessStore) ListPostProcessCommands(ctx context.Context) ([]domain.PostProcessCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]domain.PostProcessCommand, 0, len(f.cmds))
+	for _, c := range f.cmds {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (f *fakePostProcessStore) GetPostProcessCommand(ctx context.Context, id string) (domain.PostProcessCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.cmds[id]
+	if !ok {
+		return domain.PostProcessCommand{}, domain.ErrNotFound
+	}
+	return c, nil
+}
+
+func (f *fakePostProcessStore) SavePostProcessCommand(ctx context.Context, cmd domain.PostProcessCommand) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cmds[cmd.ID] = cmd
+	return nil
+}
+
+func (f *fakePostProcessStore) DeletePostProcessCommand(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.cmds[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.cmds, id)
+	return nil
+}
+
+func (f *fakePostProcessStore) AddPostProcessRun(ctx context.Context, run domain.PostProcessRun) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func (f *fakePostProcessStore) ListPostProcessRuns(ctx context.Context, id domain.TorrentID, limit int) ([]domain.PostProcessRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.PostProcessRun
+	for i := len(f.runs) - 1; i >= 0 && len(out) < limit; i-- {
+		if f.runs[i].TorrentID == id {
+			out = append(out, f.runs[i])
+		}
+	}
+	return out, nil
+}
+
+func requireShell(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+}
+
+func TestRenderPostProcessArgs(t *testing.T) {
+	vars := PostProcessVars{
+		ID:       "t1",
+		Name:     "Movie",
+		InfoHash: "abc",
+		SavePath: "/data",
+		Files:    []string{"/data/Movie/a.mkv", "/data/Movie/b.srt"},
+		Tags:     []string{"movie", "hd"},
+		Event:    "completed",
+	}
+	got, err := renderPostProcessArgs([]string{
+		"--name={{.Name}}",
+		"{{.SavePath}}/{{.Name}}",
+		"{{join .Tags \",\"}}",
+		"{{index .Files 0}}",
+		"{{.ID}}:{{.InfoHash}}:{{.Event}}",
+	}, vars)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := []string{"--name=Movie", "/data/Movie", "movie,hd", "/data/Movie/a.mkv", "t1:abc:completed"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("args = %q, want %q", got, want)
+	}
+}
+
+func TestPostProcessCreateValidates(t *testing.T) {
+	uc := &PostProcess{Store: newFakePostProcessStore()}
+	ctx := context.Background()
+
+	for _, cmd := range []domain.PostProcessCommand{
+		{Trigger: domain.EventCompleted},
+		{Trigger: domain.EventFileCompleted, Program: "true"},
+		{Trigger: domain.EventCompleted, Program: "true", Args: []string{"{{.Missing}}"}},
+		{Trigger: domain.EventCompleted, Program: "true", Args: []string{"{{.Name"}},
+		{Trigger: domain.EventCompleted, Program: "true", TimeoutSeconds: -1},
+	} {
+		if _, err := uc.Create(ctx, cmd); !errors.Is(err, domain.ErrInvalidPostProcess) {
+			t.Fatalf("Create(%+v) error = %v, want ErrInvalidPostProcess", cmd, err)
+		}
+	}
+	created, err := uc.Create(ctx, domain.PostProcessCommand{Trigger: domain.EventCompleted, Program: " true ", Enabled: true})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == "" || created.Program != "true" {
+		t.Fatalf("created = %+v", created)
+	}
+}
+
+func TestPostProcessRunsCommandOnTrigger(t *testing.T) {
+	requireShell(t)
+	dir := t.TempDir()
+	store := newFakePostProcessStore()
+	repo := &fakeRepoWithGet{getRecord: domain.TorrentRecord{
+		ID:    "t1",
+		Name:  "Movie",
+		Files: []domain.FileRef{{Index: 0, Path: "Movie/a.mkv"}},
+		Tags:  []string{"movie"},
+	}}
+	uc := &PostProcess{Store: store, Repo: repo, DataDir: dir}
+	ctx := context.Background()
+	if _, err := uc.Create(ctx, domain.PostProcessCommand{
+		Name:    "echo",
+		Trigger: domain.EventCompleted,
+		Program: "sh",
+		Args:    []string{"-c", `echo "$1 $2"; pwd; echo oops >&2; exit 3`, "sh", "{{.Name}}", "{{index .Files 0}}"},
+		Enabled: true,
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := uc.Create(ctx, domain.PostProcessCommand{Trigger: domain.EventTorrentAdded, Program: "true", Enabled: true}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	uc.HandleEvent(ctx, domain.Event{Type: domain.EventCompleted, TorrentID: "t1"})
+	uc.Wait()
+
+	runs, err := uc.Runs(ctx, "t1", 0)
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("runs = %+v, want one", runs)
+	}
+	run := runs[0]
+	wantFile := filepath.Join(dir, "Movie", "a.mkv")
+	if run.Success || run.ExitCode != 3 || run.CommandName != "echo" {
+		t.Fatalf("run = %+v", run)
+	}
+	for _, want := range []string{"Movie " + wantFile, dir, "oops"} {
+		if !strings.Contains(run.Output, want) {
+			t.Fatalf("output %q does not contain %q", run.Output, want)
+		}
+	}
+}
+
+func TestPostProcessTimeoutAndConcurrency(t *testing.T) {
+	requireShell(t)
+	store := newFakePostProcessStore()
+	repo := &fakeRepoWithGet{getRecord: domain.TorrentRecord{ID: "t1", Name: "Movie"}}
+	uc := &PostProcess{Store: store, Repo: repo, DataDir: t.TempDir(), MaxConcurrent: 1}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := uc.Create(ctx, domain.PostProcessCommand{
+			Trigger:        domain.EventTorrentAdded,
+			Program:        "sleep",
+			Args:           []string{"5"},
+			TimeoutSeconds: 1,
+			Enabled:        true,
+		}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	start := time.Now()
+	uc.HandleEvent(ctx, domain.Event{Type: domain.EventTorrentAdded, TorrentID: "t1"})
+	uc.Wait()
+	elapsed := time.Since(start)
+
+	runs, _ := uc.Runs(ctx, "t1", 10)
+	if len(runs) != 2 {
+		t.Fatalf("runs = %+v", runs)
+	}
+	for _, run := range runs {
+		if run.Success || !strings.Contains(run.Error, "timed out") {
+			t.Fatalf("run = %+v, want timeout", run)
+		}
+	}
+	// With one slot the two one-second runs cannot overlap.
+	if elapsed < 2*time.Second || elapsed > 4*time.Second {
+		t.Fatalf("elapsed = %s, want about 2s", elapsed)
+	}
+}
+
+func TestLimitedBufferTruncates(t *testing.T) {
+	b := &limitedBuffer{limit: 4}
+	_, _ = b.Write([]byte("ab"))
+	_, _ = b.Write([]byte("cdef"))
+	if b.buf.String() != "abcd" || !b.truncated {
+		t.Fatalf("buffer = %q truncated=%v", b.buf.String(), b.truncated)
+	}
+}
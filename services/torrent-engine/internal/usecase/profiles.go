@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+const maxProfileNameLength = 64
+
+// ProfileStore persists profiles.
+type ProfileStore interface {
+	ListProfiles(ctx context.Context) ([]domain.Profile, error)
+	// GetProfile and GetProfileByOwner return domain.ErrNotFound when
+	// there is no such profile.
+	GetProfile(ctx context.Context, id string) (domain.Profile, error)
+	GetProfileByOwner(ctx context.Context, ownerID string) (domain.Profile, error)
+	SaveProfile(ctx context.Context, profile domain.Profile) error
+	// DeleteProfile returns domain.ErrNotFound for an unknown id.
+	DeleteProfile(ctx context.Context, id string) error
+}
+
+// ProfileHistory removes the watch history of deleted profiles.
+type ProfileHistory interface {
+	DeleteByProfile(ctx context.Context, profileID string) error
+}
+
+// PlayerStateUpdate changes the player state of a profile; nil fields are
+// kept.
+type PlayerStateUpdate struct {
+	CurrentTorrentID         *domain.TorrentID
+	PrioritizeActiveFileOnly *bool
+}
+
+// Profiles manages the profiles that keep watch history and player state
+// apart. The default profile always exists; authenticated users get a
+// profile of their own on first use.
+type Profiles struct {
+	Store   ProfileStore
+	History ProfileHistory
+	Now     func() time.Time
+}
+
+// EnsureDefault creates the default profile with the player state stored
+// before profiles existed.
+func (uc *Profiles) EnsureDefault(ctx context.Context, current domain.TorrentID, prioritizeActiveFileOnly bool) error {
+	if _, err := uc.Store.GetProfile(ctx, domain.DefaultProfileID); err == nil {
+		return nil
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return wrapRepo(err)
+	}
+	now := uc.now()
+	profile := domain.Profile{
+		ID:                       domain.DefaultProfileID,
+		Name:                     "Default",
+		CurrentTorrentID:         current,
+		PrioritizeActiveFileOnly: &prioritizeActiveFileOnly,
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+	if err := uc.Store.SaveProfile(ctx, profile); err != nil {
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+// List returns the profiles the caller may use.
+func (uc *Profiles) List(ctx context.Context, caller *domain.Principal) ([]domain.Profile, error) {
+	profiles, err := uc.Store.ListProfiles(ctx)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	out := make([]domain.Profile, 0, len(profiles))
+	for _, p := range profiles {
+		if p.UsableBy(caller) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// Get returns a profile the caller may use; others are reported as
+// domain.ErrNotFound.
+func (uc *Profiles) Get(ctx context.Context, id string, caller *domain.Principal) (domain.Profile, error) {
+	profile, err := uc.Store.GetProfile(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Profile{}, err
+		}
+		return domain.Profile{}, wrapRepo(err)
+	}
+	if !profile.UsableBy(caller) {
+		return domain.Profile{}, domain.ErrNotFound
+	}
+	return profile, nil
+}
+
+// Create adds a profile. Profiles created by an authenticated user belong
+// to that user.
+func (uc *Profiles) Create(ctx context.Context, name string, caller *domain.Principal) (domain.Profile, error) {
+	name, err := normalizeProfileName(name)
+	if err != nil {
+		return domain.Profile{}, err
+	}
+	now := uc.now()
+	profile := domain.Profile{ID: newID(), Name: name, CreatedAt: now, UpdatedAt: now}
+	if caller != nil {
+		profile.OwnerID = caller.User.ID
+	}
+	if err := uc.Store.SaveProfile(ctx, profile); err != nil {
+		return domain.Profile{}, wrapRepo(err)
+	}
+	return profile, nil
+}
+
+func (uc *Profiles) Rename(ctx context.Context, id, name string, caller *domain.Principal) (domain.Profile, error) {
+	profile, err := uc.Get(ctx, id, caller)
+	if err != nil {
+		return domain.Profile{}, err
+	}
+	if profile.Name, err = normalizeProfileName(name); err != nil {
+		return domain.Profile{}, err
+	}
+	return uc.save(ctx, profile)
+}
+
+// Delete removes a profile and its watch history. The default profile
+// cannot be deleted.
+func (uc *Profiles) Delete(ctx context.Context, id string, caller *domain.Principal) error {
+	if id == domain.DefaultProfileID {
+		return fmt.Errorf("%w: the default profile cannot be deleted", domain.ErrInvalidProfile)
+	}
+	if _, err := uc.Get(ctx, id, caller); err != nil {
+		return err
+	}
+	if err := uc.Store.DeleteProfile(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		return wrapRepo(err)
+	}
+	if uc.History != nil {
+		if err := uc.History.DeleteByProfile(ctx, id); err != nil {
+			return wrapRepo(err)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the profile a request acts for: the requested one when
+// the caller may use it, else the caller's own profile, else the default
+// profile.
+func (uc *Profiles) Resolve(ctx context.Context, requested string, caller *domain.Principal) (domain.Profile, error) {
+	if requested != "" {
+		profile, err := uc.Get(ctx, requested, caller)
+		if err == nil {
+			return profile, nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return domain.Profile{}, err
+		}
+	}
+	if caller != nil {
+		return uc.ownProfile(ctx, caller)
+	}
+	profile, err := uc.Store.GetProfile(ctx, domain.DefaultProfileID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Profile{ID: domain.DefaultProfileID, Name: "Default"}, nil
+	}
+	if err != nil {
+		return domain.Profile{}, wrapRepo(err)
+	}
+	return profile, nil
+}
+
+// SetPlayerState records the player state of a profile.
+func (uc *Profiles) SetPlayerState(ctx context.Context, id string, update PlayerStateUpdate) (domain.Profile, error) {
+	profile, err := uc.Store.GetProfile(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Profile{}, err
+		}
+		return domain.Profile{}, wrapRepo(err)
+	}
+	if update.CurrentTorrentID != nil {
+		profile.CurrentTorrentID = *update.CurrentTorrentID
+	}
+	if update.PrioritizeActiveFileOnly != nil {
+		enabled := *update.PrioritizeActiveFileOnly
+		profile.PrioritizeActiveFileOnly = &enabled
+	}
+	return uc.save(ctx, profile)
+}
+
+// ownProfile returns the caller's oldest profile, creating one named after
+// the user when there is none.
+func (uc *Profiles) ownProfile(ctx context.Context, caller *domain.Principal) (domain.Profile, error) {
+	profile, err := uc.Store.GetProfileByOwner(ctx, caller.User.ID)
+	if err == nil {
+		return profile, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return domain.Profile{}, wrapRepo(err)
+	}
+	return uc.Create(ctx, caller.User.Username, caller)
+}
+
+func (uc *Profiles) save(ctx context.Context, profile domain.Profile) (domain.Profile, error) {
+	profile.UpdatedAt = uc.now()
+	if err := uc.Store.SaveProfile(ctx, profile); err != nil {
+		return domain.Profile{}, wrapRepo(err)
+	}
+	return profile, nil
+}
+
+func (uc *Profiles) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func normalizeProfileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxProfileNameLength {
+		return "", fmt.Errorf("%w: name must have 1-%d characters", domain.ErrInvalidProfile, maxProfileNameLength)
+	}
+	return name, nil
+}
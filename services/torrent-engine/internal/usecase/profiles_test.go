@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeProfileStore struct {
+	mu       sync.Mutex
+	profiles map[string]domain.Profile
+}
+
+func newFakeProfileStore() *fakeProfileStore {
+	return &fakeProfileStore{profiles: make(map[string]domain.Profile)}
+}
+
+func (f *fakeProfileStore) ListProfiles(ctx context.Context) ([]domain.Profile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]domain.Profile, 0, len(f.profiles))
+	for _, p := range f.profiles {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (f *fakeProfileStore) GetProfile(ctx context.Context, id string) (domain.Profile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.profiles[id]
+	if !ok {
+		return domain.Profile{}, domain.ErrNotFound
+	}
+	return p, nil
+}
+
+func (f *fakeProfileStore) GetProfileByOwner(ctx context.Context, ownerID string) (domain.Profile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.profiles {
+		if p.OwnerID == ownerID {
+			return p, nil
+		}
+	}
+	return domain.Profile{}, domain.ErrNotFound
+}
+
+func (f *fakeProfileStore) SaveProfile(ctx context.Context, profile domain.Profile) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.profiles[profile.ID] = profile
+	return nil
+}
+
+func (f *fakeProfileStore) DeleteProfile(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.profiles[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.profiles, id)
+	return nil
+}
+
+type fakeProfileHistory struct {
+	deleted []string
+}
+
+func (f *fakeProfileHistory) DeleteByProfile(ctx context.Context, profileID string) error {
+	f.deleted = append(f.deleted, profileID)
+	return nil
+}
+
+func newTestProfiles(store ProfileStore, history ProfileHistory) *Profiles {
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	return &Profiles{Store: store, History: history, Now: func() time.Time { return now }}
+}
+
+func testPrincipal(id string, role domain.Role) *domain.Principal {
+	return &domain.Principal{User: domain.User{ID: id, Username: id, Role: role}, Role: role}
+}
+
+func TestProfilesEnsureDefaultKeepsLegacyPlayerState(t *testing.T) {
+	store := newFakeProfileStore()
+	uc := newTestProfiles(store, nil)
+	ctx := context.Background()
+
+	if err := uc.EnsureDefault(ctx, "t1", false); err != nil {
+		t.Fatalf("EnsureDefault: %v", err)
+	}
+	p := store.profiles[domain.DefaultProfileID]
+	if p.CurrentTorrentID != "t1" || p.PrioritizeActiveFileOnly == nil || *p.PrioritizeActiveFileOnly {
+		t.Fatalf("default profile = %+v", p)
+	}
+
+	// A second start must not overwrite the state the profile has since.
+	if err := uc.EnsureDefault(ctx, "t2", true); err != nil {
+		t.Fatalf("second EnsureDefault: %v", err)
+	}
+	if got := store.profiles[domain.DefaultProfileID].CurrentTorrentID; got != "t1" {
+		t.Fatalf("current torrent = %q, want t1", got)
+	}
+}
+
+func TestProfilesResolve(t *testing.T) {
+	store := newFakeProfileStore()
+	uc := newTestProfiles(store, nil)
+	ctx := context.Background()
+	if err := uc.EnsureDefault(ctx, "", true); err != nil {
+		t.Fatalf("EnsureDefault: %v", err)
+	}
+	kids, err := uc.Create(ctx, "Kids", nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	p, err := uc.Resolve(ctx, "", nil)
+	if err != nil || p.ID != domain.DefaultProfileID {
+		t.Fatalf("anonymous Resolve = %+v, %v", p, err)
+	}
+	p, err = uc.Resolve(ctx, kids.ID, nil)
+	if err != nil || p.ID != kids.ID {
+		t.Fatalf("Resolve(kids) = %+v, %v", p, err)
+	}
+	p, err = uc.Resolve(ctx, "missing", nil)
+	if err != nil || p.ID != domain.DefaultProfileID {
+		t.Fatalf("Resolve(missing) = %+v, %v", p, err)
+	}
+
+	// An authenticated user gets a profile of their own on first use.
+	alice := testPrincipal("alice", domain.RoleUser)
+	own, err := uc.Resolve(ctx, "", alice)
+	if err != nil || own.OwnerID != "alice" || own.Name != "alice" {
+		t.Fatalf("Resolve(alice) = %+v, %v", own, err)
+	}
+	again, err := uc.Resolve(ctx, "", alice)
+	if err != nil || again.ID != own.ID {
+		t.Fatalf("second Resolve(alice) = %+v, %v", again, err)
+	}
+
+	// Another user's profile is not usable and falls back to the caller's.
+	bob := testPrincipal("bob", domain.RoleUser)
+	p, err = uc.Resolve(ctx, own.ID, bob)
+	if err != nil || p.OwnerID != "bob" {
+		t.Fatalf("Resolve(alice's profile) as bob = %+v, %v", p, err)
+	}
+	p, err = uc.Resolve(ctx, own.ID, testPrincipal("root", domain.RoleAdmin))
+	if err != nil || p.ID != own.ID {
+		t.Fatalf("Resolve(alice's profile) as admin = %+v, %v", p, err)
+	}
+}
+
+func TestProfilesListHidesOtherUsersProfiles(t *testing.T) {
+	store := newFakeProfileStore()
+	uc := newTestProfiles(store, nil)
+	ctx := context.Background()
+	alice := testPrincipal("alice", domain.RoleUser)
+	if _, err := uc.Create(ctx, "Shared", nil); err != nil {
+		t.Fatalf("Create shared: %v", err)
+	}
+	if _, err := uc.Create(ctx, "Alice", alice); err != nil {
+		t.Fatalf("Create alice: %v", err)
+	}
+
+	list, err := uc.List(ctx, testPrincipal("bob", domain.RoleUser))
+	if err != nil || len(list) != 1 || list[0].Name != "Shared" {
+		t.Fatalf("List as bob = %+v, %v", list, err)
+	}
+	list, err = uc.List(ctx, alice)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List as alice = %+v, %v", list, err)
+	}
+}
+
+func TestProfilesDelete(t *testing.T) {
+	store := newFakeProfileStore()
+	history := &fakeProfileHistory{}
+	uc := newTestProfiles(store, history)
+	ctx := context.Background()
+	if err := uc.EnsureDefault(ctx, "", true); err != nil {
+		t.Fatalf("EnsureDefault: %v", err)
+	}
+
+	if err := uc.Delete(ctx, domain.DefaultProfileID, nil); !errors.Is(err, domain.ErrInvalidProfile) {
+		t.Fatalf("Delete(default) err = %v, want ErrInvalidProfile", err)
+	}
+	p, err := uc.Create(ctx, "Guest", nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := uc.Delete(ctx, p.ID, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if len(history.deleted) != 1 || history.deleted[0] != p.ID {
+		t.Fatalf("deleted history = %v", history.deleted)
+	}
+	if err := uc.Delete(ctx, p.ID, nil); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("second Delete err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProfilesNameValidation(t *testing.T) {
+	uc := newTestProfiles(newFakeProfileStore(), nil)
+	for _, name := range []string{"", "   ", string(make([]byte, maxProfileNameLength+1))} {
+		if _, err := uc.Create(context.Background(), name, nil); !errors.Is(err, domain.ErrInvalidProfile) {
+			t.Errorf("Create(%q) err = %v, want ErrInvalidProfile", name, err)
+		}
+	}
+}
+
+func TestProfilesSetPlayerState(t *testing.T) {
+	store := newFakeProfileStore()
+	uc := newTestProfiles(store, nil)
+	ctx := context.Background()
+	if err := uc.EnsureDefault(ctx, "t1", true); err != nil {
+		t.Fatalf("EnsureDefault: %v", err)
+	}
+
+	off := false
+	p, err := uc.SetPlayerState(ctx, domain.DefaultProfileID, PlayerStateUpdate{PrioritizeActiveFileOnly: &off})
+	if err != nil {
+		t.Fatalf("SetPlayerState: %v", err)
+	}
+	if p.CurrentTorrentID != "t1" || *p.PrioritizeActiveFileOnly {
+		t.Fatalf("profile = %+v", p)
+	}
+	var none domain.TorrentID
+	p, err = uc.SetPlayerState(ctx, domain.DefaultProfileID, PlayerStateUpdate{CurrentTorrentID: &none})
+	if err != nil || p.CurrentTorrentID != "" || *p.PrioritizeActiveFileOnly {
+		t.Fatalf("profile = %+v, %v", p, err)
+	}
+}
@@ -0,0 +1,367 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// errBadRAR marks volumes that are not RAR archives this package can map.
+var errBadRAR = errors.New("unsupported rar volume")
+
+var (
+	rar4Signature = []byte("Rar!\x1a\x07\x00")
+	rar5Signature = []byte("Rar!\x1a\x07\x01\x00")
+)
+
+const (
+	rar4BlockMain = 0x73
+	rar4BlockFile = 0x74
+	rar4BlockEnd  = 0x7b
+
+	rar4FlagSplitBefore = 0x0001
+	rar4FlagSplitAfter  = 0x0002
+	rar4FlagEncrypted   = 0x0004
+	rar4FlagDirMask     = 0x00e0
+	rar4FlagLarge       = 0x0100
+	rar4FlagUnicode     = 0x0200
+	rar4FlagLongBlock   = 0x8000
+	rar4MainEncrypted   = 0x0080
+	rar4MethodStore     = 0x30
+
+	rar5BlockFile    = 2
+	rar5BlockEncrypt = 4
+	rar5BlockEnd     = 5
+
+	rar5FlagExtra       = 0x0001
+	rar5FlagData        = 0x0002
+	rar5FlagSplitBefore = 0x0008
+	rar5FlagSplitAfter  = 0x0010
+	rar5FileDir         = 0x0001
+	rar5FileMtime       = 0x0002
+	rar5FileCRC         = 0x0004
+	rar5ExtraEncryption = 0x01
+
+	// rarMaxHeader bounds a single block header.
+	rarMaxHeader = 2 << 20
+)
+
+// rarEntry is a file block in one RAR volume. dataOffset and packed locate
+// the bytes of the file stored in that volume.
+type rarEntry struct {
+	name        string
+	size        int64
+	dataOffset  int64
+	packed      int64
+	stored      bool
+	dir         bool
+	encrypted   bool
+	splitBefore bool
+	splitAfter  bool
+}
+
+// readRARVolume lists the file blocks of a RAR 4 or RAR 5 volume of the
+// given size. Walking stops at a file continued in the next volume, so only
+// the headers of a volume are read.
+func readRARVolume(r io.ReadSeeker, size int64) ([]rarEntry, error) {
+	sig, err := readRARAt(r, 0, len(rar5Signature))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(sig, rar5Signature):
+		return readRAR5Volume(r, size)
+	case bytes.HasPrefix(sig, rar4Signature):
+		return readRAR4Volume(r, size)
+	}
+	return nil, fmt.Errorf("%w: missing signature", errBadRAR)
+}
+
+func readRAR4Volume(r io.ReadSeeker, size int64) ([]rarEntry, error) {
+	var entries []rarEntry
+	off := int64(len(rar4Signature))
+	for off+7 <= size {
+		base, err := readRARAt(r, off, 7)
+		if err != nil {
+			return nil, err
+		}
+		typ := base[2]
+		flags := binary.LittleEndian.Uint16(base[3:])
+		headSize := int64(binary.LittleEndian.Uint16(base[5:]))
+		if headSize < 7 {
+			return nil, fmt.Errorf("%w: bad header size", errBadRAR)
+		}
+		var dataSize int64
+		if flags&rar4FlagLongBlock != 0 || typ == rar4BlockFile {
+			if headSize < 11 {
+				return nil, fmt.Errorf("%w: bad header size", errBadRAR)
+			}
+			add, err := readRARAt(r, off+7, 4)
+			if err != nil {
+				return nil, err
+			}
+			dataSize = int64(binary.LittleEndian.Uint32(add))
+		}
+
+		switch typ {
+		case rar4BlockMain:
+			if flags&rar4MainEncrypted != 0 {
+				return nil, fmt.Errorf("%w: encrypted headers", errBadRAR)
+			}
+		case rar4BlockEnd:
+			return entries, nil
+		case rar4BlockFile:
+			head, err := readRARAt(r, off, int(headSize))
+			if err != nil {
+				return nil, err
+			}
+			entry, err := parseRAR4File(head, flags)
+			if err != nil {
+				return nil, err
+			}
+			entry.dataOffset = off + headSize
+			entries = append(entries, entry)
+			if entry.splitAfter {
+				return entries, nil
+			}
+			dataSize = entry.packed
+		}
+		if off, err = nextRARBlock(off, off+headSize, dataSize, size); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// nextRARBlock returns the offset of the block after one whose data of
+// dataSize bytes starts at dataStart. Crafted sizes that would move
+// backwards, stand still or run past the volume are rejected, so walking a
+// volume always ends.
+func nextRARBlock(off, dataStart, dataSize, size int64) (int64, error) {
+	if dataSize < 0 || dataSize > size {
+		return 0, fmt.Errorf("%w: bad data size", errBadRAR)
+	}
+	next := dataStart + dataSize
+	if next <= off || next > size {
+		return 0, fmt.Errorf("%w: bad data size", errBadRAR)
+	}
+	return next, nil
+}
+
+func parseRAR4File(head []byte, flags uint16) (rarEntry, error) {
+	// Fixed fields: pack size, unpacked size, host OS, CRC, time, version,
+	// method, name size and attributes.
+	if len(head) < 32 {
+		return rarEntry{}, fmt.Errorf("%w: short file header", errBadRAR)
+	}
+	packed := int64(binary.LittleEndian.Uint32(head[7:]))
+	size := int64(binary.LittleEndian.Uint32(head[11:]))
+	method := head[25]
+	nameSize := int(binary.LittleEndian.Uint16(head[26:]))
+	nameOff := 32
+	if flags&rar4FlagLarge != 0 {
+		if len(head) < 40 {
+			return rarEntry{}, fmt.Errorf("%w: short file header", errBadRAR)
+		}
+		packed |= int64(binary.LittleEndian.Uint32(head[32:])) << 32
+		size |= int64(binary.LittleEndian.Uint32(head[36:])) << 32
+		nameOff = 40
+		if packed < 0 || size < 0 {
+			return rarEntry{}, fmt.Errorf("%w: bad file size", errBadRAR)
+		}
+	}
+	if len(head) < nameOff+nameSize {
+		return rarEntry{}, fmt.Errorf("%w: short file name", errBadRAR)
+	}
+	name := head[nameOff : nameOff+nameSize]
+	if flags&rar4FlagUnicode != 0 {
+		// The ASCII name comes first, then the encoded unicode one.
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+	}
+	return rarEntry{
+		name:        strings.ReplaceAll(string(name), "\\", "/"),
+		size:        size,
+		packed:      packed,
+		stored:      method == rar4MethodStore,
+		dir:         flags&rar4FlagDirMask == rar4FlagDirMask,
+		encrypted:   flags&rar4FlagEncrypted != 0,
+		splitBefore: flags&rar4FlagSplitBefore != 0,
+		splitAfter:  flags&rar4FlagSplitAfter != 0,
+	}, nil
+}
+
+func readRAR5Volume(r io.ReadSeeker, size int64) ([]rarEntry, error) {
+	var entries []rarEntry
+	off := int64(len(rar5Signature))
+	for off+7 <= size {
+		// CRC32, then the header size as a vint of up to 3 bytes.
+		prefix, err := readRARAt(r, off, 7)
+		if err != nil {
+			return nil, err
+		}
+		b := rarVintReader{buf: prefix[4:]}
+		headSize := int64(b.next())
+		if b.err || headSize <= 0 || headSize > rarMaxHeader {
+			return nil, fmt.Errorf("%w: bad header size", errBadRAR)
+		}
+		headStart := off + 4 + int64(3-len(b.buf))
+		head, err := readRARAt(r, headStart, int(headSize))
+		if err != nil {
+			return nil, err
+		}
+		h := rarVintReader{buf: head}
+		typ := h.next()
+		flags := h.next()
+		var extraSize uint64
+		var dataSize int64
+		if flags&rar5FlagExtra != 0 {
+			extraSize = h.next()
+		}
+		if flags&rar5FlagData != 0 {
+			dataSize = h.nextInt64()
+		}
+		// The extra area ends the header, so it must fit in what is left.
+		if h.err || extraSize > uint64(len(h.buf)) {
+			return nil, fmt.Errorf("%w: corrupt header", errBadRAR)
+		}
+
+		switch typ {
+		case rar5BlockEncrypt:
+			return nil, fmt.Errorf("%w: encrypted headers", errBadRAR)
+		case rar5BlockEnd:
+			return entries, nil
+		case rar5BlockFile:
+			split := len(h.buf) - int(extraSize)
+			entry, err := parseRAR5File(rarVintReader{buf: h.buf[:split]}, rarVintReader{buf: h.buf[split:]})
+			if err != nil {
+				return nil, err
+			}
+			entry.dataOffset = headStart + headSize
+			entry.packed = dataSize
+			entry.splitBefore = flags&rar5FlagSplitBefore != 0
+			entry.splitAfter = flags&rar5FlagSplitAfter != 0
+			entries = append(entries, entry)
+			if entry.splitAfter {
+				return entries, nil
+			}
+		}
+		if off, err = nextRARBlock(off, headStart+headSize, dataSize, size); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func parseRAR5File(body, extra rarVintReader) (rarEntry, error) {
+	fileFlags := body.next()
+	size := body.nextInt64()
+	body.next() // attributes
+	if fileFlags&rar5FileMtime != 0 {
+		body.skip(4)
+	}
+	if fileFlags&rar5FileCRC != 0 {
+		body.skip(4)
+	}
+	compression := body.next()
+	body.next() // host OS
+	nameLen := body.nextLen()
+	if body.err {
+		return rarEntry{}, fmt.Errorf("%w: corrupt file header", errBadRAR)
+	}
+	entry := rarEntry{
+		name:   string(body.buf[:nameLen]),
+		size:   size,
+		stored: (compression>>7)&0x7 == 0,
+		dir:    fileFlags&rar5FileDir != 0,
+	}
+	for len(extra.buf) > 0 {
+		recSize := extra.nextLen()
+		if extra.err {
+			break
+		}
+		rec := rarVintReader{buf: extra.buf[:recSize]}
+		extra.buf = extra.buf[recSize:]
+		if rec.next() == rar5ExtraEncryption {
+			entry.encrypted = true
+		}
+	}
+	return entry, nil
+}
+
+// rarVintReader decodes RAR 5 variable length integers. Reading past the
+// end sets err.
+type rarVintReader struct {
+	buf []byte
+	err bool
+}
+
+func (b *rarVintReader) next() uint64 {
+	var v uint64
+	for i, c := range b.buf {
+		if i >= 10 {
+			break
+		}
+		v |= uint64(c&0x7f) << (7 * i)
+		if c&0x80 == 0 {
+			b.buf = b.buf[i+1:]
+			return v
+		}
+	}
+	b.fail()
+	return 0
+}
+
+// nextInt64 reads a vint that must fit an int64, such as a file or data
+// size.
+func (b *rarVintReader) nextInt64() int64 {
+	v := b.next()
+	if v > math.MaxInt64 {
+		b.fail()
+		return 0
+	}
+	return int64(v)
+}
+
+// nextLen reads a vint that counts bytes of the rest of the buffer. Larger
+// values, which could turn negative as an int, set err.
+func (b *rarVintReader) nextLen() int {
+	v := b.next()
+	if v > math.MaxInt32 || v > uint64(len(b.buf)) {
+		b.fail()
+		return 0
+	}
+	return int(v)
+}
+
+func (b *rarVintReader) fail() {
+	b.buf = nil
+	b.err = true
+}
+
+func (b *rarVintReader) skip(n int) {
+	if len(b.buf) < n {
+		b.fail()
+		return
+	}
+	b.buf = b.buf[n:]
+}
+
+func readRARAt(r io.ReadSeeker, off int64, n int) ([]byte, error) {
+	if _, err := r.Seek(off, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: truncated", errBadRAR)
+		}
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,375 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// retentionWatchedTail is how close to the end a watch position must be for
+// the file to count as fully watched. Matches the "incomplete" heuristic of
+// the watch history repository.
+const retentionWatchedTail = 15.0
+
+type RetentionWatchHistory interface {
+	ListByTorrent(ctx context.Context, torrentID domain.TorrentID) ([]domain.WatchPosition, error)
+}
+
+// TorrentDeleter is satisfied by DeleteTorrent.
+type TorrentDeleter interface {
+	Execute(ctx context.Context, id domain.TorrentID, deleteFiles bool) error
+}
+
+type RetentionReason string
+
+const (
+	RetentionWatched   RetentionReason = "watched"
+	RetentionUntouched RetentionReason = "untouched"
+	RetentionQuota     RetentionReason = "quota"
+)
+
+type RetentionCandidate struct {
+	ID           domain.TorrentID `json:"id"`
+	Name         string           `json:"name"`
+	Reason       RetentionReason  `json:"reason"`
+	Bytes        int64            `json:"bytes"`
+	LastActivity time.Time        `json:"lastActivity"`
+	Deleted      bool             `json:"deleted"`
+	Error        string           `json:"error,omitempty"`
+}
+
+type RetentionReport struct {
+	DryRun           bool                 `json:"dryRun"`
+	GeneratedAt      time.Time            `json:"generatedAt"`
+	TotalBytes       int64                `json:"totalBytes"`
+	ReclaimableBytes int64                `json:"reclaimableBytes"`
+	Candidates       []RetentionCandidate `json:"candidates"`
+}
+
+// Retention selects completed torrents for removal according to the current
+// retention policy and deletes them through DeleteTorrent. Torrents that are
+// not completed, carry a protected tag, or are focused for playback are never
+// selected.
+type Retention struct {
+	Repo         ports.TorrentRepository
+	WatchHistory RetentionWatchHistory
+	Engine       ports.Engine
+	Delete       TorrentDeleter
+	Policy       func() domain.RetentionPolicy
+	Logger       *slog.Logger
+	Interval     time.Duration
+	Now          func() time.Time
+}
+
+// Run applies the policy periodically until ctx is cancelled. When the policy
+// is in dry-run mode the candidates are only logged.
+func (uc Retention) Run(ctx context.Context) {
+	interval := uc.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			policy := uc.policy()
+			if !policy.Active() {
+				continue
+			}
+			report, err := uc.run(ctx, policy, policy.DryRun)
+			if err != nil {
+				uc.Logger.Warn("retention: run failed", slog.String("error", err.Error()))
+				continue
+			}
+			if len(report.Candidates) == 0 {
+				continue
+			}
+			uc.Logger.Info("retention: run finished",
+				slog.Bool("dryRun", report.DryRun),
+				slog.Int("candidates", len(report.Candidates)),
+				slog.Int64("reclaimableBytes", report.ReclaimableBytes),
+			)
+		}
+	}
+}
+
+// Preview reports what the current policy would delete without deleting.
+func (uc Retention) Preview(ctx context.Context) (RetentionReport, error) {
+	return uc.run(ctx, uc.policy(), true)
+}
+
+// Apply deletes every candidate selected by the current policy, regardless of
+// the policy's dry-run flag.
+func (uc Retention) Apply(ctx context.Context) (RetentionReport, error) {
+	return uc.run(ctx, uc.policy(), false)
+}
+
+func (uc Retention) run(ctx context.Context, policy domain.RetentionPolicy, dryRun bool) (RetentionReport, error) {
+	if uc.Repo == nil {
+		return RetentionReport{}, errors.New("repository not configured")
+	}
+	if !dryRun && uc.Delete == nil {
+		return RetentionReport{}, errors.New("delete use case not configured")
+	}
+
+	report, err := uc.plan(ctx, policy)
+	if err != nil {
+		return RetentionReport{}, err
+	}
+	report.DryRun = dryRun
+	if dryRun {
+		return report, nil
+	}
+
+	for i := range report.Candidates {
+		c := &report.Candidates[i]
+		if err := uc.Delete.Execute(ctx, c.ID, !policy.KeepFiles); err != nil {
+			c.Error = err.Error()
+			uc.Logger.Warn("retention: delete failed",
+				slog.String("id", string(c.ID)),
+				slog.String("reason", string(c.Reason)),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		c.Deleted = true
+		uc.Logger.Info("retention: deleted torrent",
+			slog.String("id", string(c.ID)),
+			slog.String("name", c.Name),
+			slog.String("reason", string(c.Reason)),
+			slog.Int64("bytes", c.Bytes),
+		)
+	}
+	return report, nil
+}
+
+type retentionEntry struct {
+	record       domain.TorrentRecord
+	bytes        int64
+	lastActivity time.Time
+	watchedAt    time.Time
+	watched      bool
+}
+
+func (uc Retention) plan(ctx context.Context, policy domain.RetentionPolicy) (RetentionReport, error) {
+	now := uc.now()
+	report := RetentionReport{GeneratedAt: now, Candidates: []RetentionCandidate{}}
+
+	records, err := uc.Repo.List(ctx, domain.TorrentFilter{})
+	if err != nil {
+		return RetentionReport{}, wrapRepo(err)
+	}
+
+	keep := make(map[string]struct{}, len(policy.KeepTags))
+	for _, tag := range policy.KeepTags {
+		if t := strings.ToLower(strings.TrimSpace(tag)); t != "" {
+			keep[t] = struct{}{}
+		}
+	}
+
+	var eligible []retentionEntry
+	for _, record := range records {
+		bytes := recordDownloadedBytes(record)
+		report.TotalBytes += bytes
+
+		if record.Status != domain.TorrentCompleted {
+			continue
+		}
+		if retentionProtected(record.Tags, keep, policy.KeepTagged) {
+			continue
+		}
+		if uc.isFocused(ctx, record.ID) {
+			continue
+		}
+
+		var positions []domain.WatchPosition
+		if uc.WatchHistory != nil {
+			positions, err = uc.WatchHistory.ListByTorrent(ctx, record.ID)
+			if err != nil {
+				// Without watch history we cannot tell whether the torrent is
+				// still in use, so leave it alone.
+				uc.Logger.Warn("retention: watch history lookup failed",
+					slog.String("id", string(record.ID)),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+		}
+
+		entry := retentionEntry{
+			record:       record,
+			bytes:        bytes,
+			lastActivity: record.UpdatedAt,
+		}
+		for _, wp := range positions {
+			if wp.UpdatedAt.After(entry.lastActivity) {
+				entry.lastActivity = wp.UpdatedAt
+			}
+		}
+		entry.watchedAt, entry.watched = fullyWatchedAt(record.AllFiles(), positions)
+		eligible = append(eligible, entry)
+	}
+
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return eligible[i].lastActivity.Before(eligible[j].lastActivity)
+	})
+
+	remaining := report.TotalBytes
+	selected := make(map[domain.TorrentID]struct{})
+	add := func(entry retentionEntry, reason RetentionReason) {
+		selected[entry.record.ID] = struct{}{}
+		remaining -= entry.bytes
+		report.ReclaimableBytes += entry.bytes
+		report.Candidates = append(report.Candidates, RetentionCandidate{
+			ID:           entry.record.ID,
+			Name:         entry.record.Name,
+			Reason:       reason,
+			Bytes:        entry.bytes,
+			LastActivity: entry.lastActivity,
+		})
+	}
+
+	for _, entry := range eligible {
+		switch {
+		case policy.WatchedDays > 0 && entry.watched && now.Sub(entry.watchedAt) >= days(policy.WatchedDays):
+			add(entry, RetentionWatched)
+		case policy.UntouchedDays > 0 && now.Sub(entry.lastActivity) >= days(policy.UntouchedDays):
+			add(entry, RetentionUntouched)
+		}
+	}
+
+	if policy.MaxTotalBytes > 0 {
+		for _, entry := range eligible {
+			if remaining <= policy.MaxTotalBytes {
+				break
+			}
+			if _, ok := selected[entry.record.ID]; ok {
+				continue
+			}
+			add(entry, RetentionQuota)
+		}
+	}
+
+	return report, nil
+}
+
+func (uc Retention) policy() domain.RetentionPolicy {
+	if uc.Policy == nil {
+		return domain.RetentionPolicy{}
+	}
+	return uc.Policy()
+}
+
+func (uc Retention) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now().UTC()
+}
+
+func (uc Retention) isFocused(ctx context.Context, id domain.TorrentID) bool {
+	if uc.Engine == nil {
+		return false
+	}
+	mode, err := uc.Engine.GetSessionMode(ctx, id)
+	return err == nil && mode == domain.ModeFocused
+}
+
+func retentionProtected(tags []string, keep map[string]struct{}, keepTagged bool) bool {
+	for _, tag := range tags {
+		t := strings.ToLower(strings.TrimSpace(tag))
+		if t == "" {
+			continue
+		}
+		if keepTagged {
+			return true
+		}
+		if _, ok := keep[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fullyWatchedAt reports whether every video file of the torrent has been
+// watched to its end, and when the last of them was watched. Positions are
+// kept per profile: a file counts as watched only when every profile that
+// started it has finished it, so one profile finishing a file does not
+// delete it from under another still watching.
+func fullyWatchedAt(files []domain.FileRef, positions []domain.WatchPosition) (time.Time, bool) {
+	byIndex := make(map[int]map[string]domain.WatchPosition, len(positions))
+	for _, wp := range positions {
+		profile := wp.ProfileID
+		if profile == "" {
+			profile = domain.DefaultProfileID
+		}
+		byProfile := byIndex[wp.FileIndex]
+		if byProfile == nil {
+			byProfile = make(map[string]domain.WatchPosition)
+			byIndex[wp.FileIndex] = byProfile
+		}
+		if prev, ok := byProfile[profile]; !ok || wp.UpdatedAt.After(prev.UpdatedAt) {
+			byProfile[profile] = wp
+		}
+	}
+
+	var latest time.Time
+	videos := 0
+	for _, file := range files {
+		if _, ok := domain.VideoExtensions[strings.ToLower(filepath.Ext(file.Path))]; !ok {
+			continue
+		}
+		videos++
+		byProfile := byIndex[file.Index]
+		if len(byProfile) == 0 {
+			return time.Time{}, false
+		}
+		for _, wp := range byProfile {
+			if wp.Duration <= 0 || wp.Position < wp.Duration-retentionWatchedTail {
+				return time.Time{}, false
+			}
+			if wp.UpdatedAt.After(latest) {
+				latest = wp.UpdatedAt
+			}
+		}
+	}
+	if videos == 0 {
+		return time.Time{}, false
+	}
+	return latest, true
+}
+
+// recordDownloadedBytes returns the bytes a torrent occupies on disk based on
+// per-file progress, including files unpacked from its archives, falling back
+// to the record total for pending torrents.
+func recordDownloadedBytes(record domain.TorrentRecord) int64 {
+	if len(record.Files) == 0 {
+		if record.DoneBytes > 0 {
+			return record.DoneBytes
+		}
+		return 0
+	}
+	var total int64
+	for _, file := range record.AllFiles() {
+		if file.BytesCompleted > 0 {
+			total += file.BytesCompleted
+		}
+	}
+	return total
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
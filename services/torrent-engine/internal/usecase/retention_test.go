@@ -0,0 +1,351 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeRetentionRepo struct {
+	fakeControlRepo
+	list    []domain.TorrentRecord
+	listErr error
+}
+
+func (f *fakeRetentionRepo) List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error) {
+	return f.list, f.listErr
+}
+
+type fakeRetentionHistory struct {
+	positions map[domain.TorrentID][]domain.WatchPosition
+	err       error
+}
+
+func (f *fakeRetentionHistory) ListByTorrent(ctx context.Context, id domain.TorrentID) ([]domain.WatchPosition, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.positions[id], nil
+}
+
+type fakeDeleter struct {
+	ids         []domain.TorrentID
+	deleteFiles []bool
+	err         error
+}
+
+func (f *fakeDeleter) Execute(ctx context.Context, id domain.TorrentID, deleteFiles bool) error {
+	f.ids = append(f.ids, id)
+	f.deleteFiles = append(f.deleteFiles, deleteFiles)
+	return f.err
+}
+
+var retentionNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func completedRecord(id string, size int64, updatedAgo time.Duration, tags ...string) domain.TorrentRecord {
+	return domain.TorrentRecord{
+		ID:     domain.TorrentID(id),
+		Name:   id,
+		Status: domain.TorrentCompleted,
+		Files: []domain.FileRef{
+			{Index: 0, Path: id + "/movie.mkv", Length: size, BytesCompleted: size},
+		},
+		TotalBytes: size,
+		DoneBytes:  size,
+		UpdatedAt:  retentionNow.Add(-updatedAgo),
+		Tags:       tags,
+	}
+}
+
+func newRetention(repo *fakeRetentionRepo, history *fakeRetentionHistory, deleter *fakeDeleter, policy domain.RetentionPolicy) Retention {
+	return Retention{
+		Repo:         repo,
+		WatchHistory: history,
+		Delete:       deleter,
+		Policy:       func() domain.RetentionPolicy { return policy },
+		Logger:       discardLogger(),
+		Now:          func() time.Time { return retentionNow },
+	}
+}
+
+func candidateIDs(report RetentionReport) []domain.TorrentID {
+	ids := make([]domain.TorrentID, 0, len(report.Candidates))
+	for _, c := range report.Candidates {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestRetentionPreviewWatched(t *testing.T) {
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("watched-old", 100, 60*24*time.Hour),
+		completedRecord("watched-recent", 100, 60*24*time.Hour),
+		completedRecord("half-watched", 100, 60*24*time.Hour),
+	}}
+	history := &fakeRetentionHistory{positions: map[domain.TorrentID][]domain.WatchPosition{
+		"watched-old":    {{FileIndex: 0, Position: 5990, Duration: 6000, UpdatedAt: retentionNow.Add(-10 * 24 * time.Hour)}},
+		"watched-recent": {{FileIndex: 0, Position: 6000, Duration: 6000, UpdatedAt: retentionNow.Add(-24 * time.Hour)}},
+		"half-watched":   {{FileIndex: 0, Position: 3000, Duration: 6000, UpdatedAt: retentionNow.Add(-30 * 24 * time.Hour)}},
+	}}
+	deleter := &fakeDeleter{}
+	uc := newRetention(repo, history, deleter, domain.RetentionPolicy{WatchedDays: 7})
+
+	report, err := uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if !report.DryRun {
+		t.Fatalf("preview must be a dry run")
+	}
+	ids := candidateIDs(report)
+	if len(ids) != 1 || ids[0] != "watched-old" {
+		t.Fatalf("candidates = %v, want [watched-old]", ids)
+	}
+	if report.Candidates[0].Reason != RetentionWatched {
+		t.Fatalf("reason = %s", report.Candidates[0].Reason)
+	}
+	if len(deleter.ids) != 0 {
+		t.Fatalf("preview must not delete, got %v", deleter.ids)
+	}
+}
+
+func TestRetentionKeepsTorrentAnotherProfileIsWatching(t *testing.T) {
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("shared", 100, 60*24*time.Hour),
+	}}
+	history := &fakeRetentionHistory{positions: map[domain.TorrentID][]domain.WatchPosition{
+		"shared": {
+			{ProfileID: "kids", FileIndex: 0, Position: 3000, Duration: 6000, UpdatedAt: retentionNow.Add(-20 * 24 * time.Hour)},
+			{ProfileID: "default", FileIndex: 0, Position: 6000, Duration: 6000, UpdatedAt: retentionNow.Add(-10 * 24 * time.Hour)},
+		},
+	}}
+	uc := newRetention(repo, history, &fakeDeleter{}, domain.RetentionPolicy{WatchedDays: 7})
+
+	report, err := uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if ids := candidateIDs(report); len(ids) != 0 {
+		t.Fatalf("candidates = %v, want none while a profile is halfway through", ids)
+	}
+
+	// Once both profiles finish, the torrent counts as watched at the later
+	// finish.
+	history.positions["shared"][0].Position = 6000
+	history.positions["shared"][0].UpdatedAt = retentionNow.Add(-3 * 24 * time.Hour)
+	if report, err = uc.Preview(context.Background()); err != nil || len(report.Candidates) != 0 {
+		t.Fatalf("candidates = %+v err = %v, want none within a week of the later finish", report.Candidates, err)
+	}
+	history.positions["shared"][0].UpdatedAt = retentionNow.Add(-8 * 24 * time.Hour)
+	report, err = uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if ids := candidateIDs(report); len(ids) != 1 || report.Candidates[0].Reason != RetentionWatched {
+		t.Fatalf("candidates = %+v, want shared as watched", report.Candidates)
+	}
+}
+
+func TestRetentionCountsUnpackedFiles(t *testing.T) {
+	archive := completedRecord("archive", 100, 60*24*time.Hour)
+	archive.Files = []domain.FileRef{{Index: 0, Path: "Movie/movie.rar", BytesCompleted: 100}}
+	archive.Extracted = []domain.FileRef{{Index: 1, Path: "Movie/movie.mkv", Length: 300, BytesCompleted: 300}}
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{archive}}
+	history := &fakeRetentionHistory{positions: map[domain.TorrentID][]domain.WatchPosition{
+		"archive": {{FileIndex: 1, Position: 6000, Duration: 6000, UpdatedAt: retentionNow.Add(-10 * 24 * time.Hour)}},
+	}}
+	uc := newRetention(repo, history, &fakeDeleter{}, domain.RetentionPolicy{WatchedDays: 7})
+
+	report, err := uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if len(report.Candidates) != 1 || report.Candidates[0].Reason != RetentionWatched {
+		t.Fatalf("candidates = %+v, want archive as watched", report.Candidates)
+	}
+	if report.Candidates[0].Bytes != 400 {
+		t.Fatalf("bytes = %d, want 400 with the unpacked file", report.Candidates[0].Bytes)
+	}
+}
+
+func TestRetentionUntouchedUsesWatchActivity(t *testing.T) {
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("idle", 100, 40*24*time.Hour),
+		completedRecord("played", 100, 40*24*time.Hour),
+		completedRecord("fresh", 100, 2*24*time.Hour),
+	}}
+	history := &fakeRetentionHistory{positions: map[domain.TorrentID][]domain.WatchPosition{
+		"played": {{FileIndex: 0, Position: 10, Duration: 6000, UpdatedAt: retentionNow.Add(-time.Hour)}},
+	}}
+	uc := newRetention(repo, history, &fakeDeleter{}, domain.RetentionPolicy{UntouchedDays: 30})
+
+	report, err := uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	ids := candidateIDs(report)
+	if len(ids) != 1 || ids[0] != "idle" {
+		t.Fatalf("candidates = %v, want [idle]", ids)
+	}
+}
+
+func TestRetentionQuotaOldestFirst(t *testing.T) {
+	active := completedRecord("active", 500, 100*24*time.Hour)
+	active.Status = domain.TorrentActive
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("newest", 300, 1*time.Hour),
+		completedRecord("oldest", 300, 30*24*time.Hour),
+		completedRecord("middle", 300, 10*24*time.Hour),
+		active,
+	}}
+	uc := newRetention(repo, &fakeRetentionHistory{}, &fakeDeleter{}, domain.RetentionPolicy{MaxTotalBytes: 1000})
+
+	report, err := uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if report.TotalBytes != 1400 {
+		t.Fatalf("totalBytes = %d, want 1400", report.TotalBytes)
+	}
+	ids := candidateIDs(report)
+	if len(ids) != 2 || ids[0] != "oldest" || ids[1] != "middle" {
+		t.Fatalf("candidates = %v, want [oldest middle]", ids)
+	}
+	if report.ReclaimableBytes != 600 {
+		t.Fatalf("reclaimableBytes = %d, want 600", report.ReclaimableBytes)
+	}
+}
+
+func TestRetentionSkipsProtectedTorrents(t *testing.T) {
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("pinned", 100, 90*24*time.Hour, "Pinned"),
+		completedRecord("tagged", 100, 90*24*time.Hour, "kids"),
+		completedRecord("focused", 100, 90*24*time.Hour),
+	}}
+	uc := newRetention(repo, &fakeRetentionHistory{}, &fakeDeleter{}, domain.RetentionPolicy{
+		UntouchedDays: 30,
+		KeepTags:      []string{"pinned"},
+	})
+	uc.Engine = &fakeDiskEngine{modes: map[domain.TorrentID]domain.SessionMode{"focused": domain.ModeFocused}}
+
+	report, err := uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	ids := candidateIDs(report)
+	if len(ids) != 1 || ids[0] != "tagged" {
+		t.Fatalf("candidates = %v, want [tagged]", ids)
+	}
+
+	uc.Policy = func() domain.RetentionPolicy {
+		return domain.RetentionPolicy{UntouchedDays: 30, KeepTagged: true}
+	}
+	report, err = uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if len(report.Candidates) != 0 {
+		t.Fatalf("candidates = %v, want none", candidateIDs(report))
+	}
+}
+
+func TestRetentionSkipsTorrentWhenHistoryFails(t *testing.T) {
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("idle", 100, 90*24*time.Hour),
+	}}
+	history := &fakeRetentionHistory{err: errors.New("mongo down")}
+	uc := newRetention(repo, history, &fakeDeleter{}, domain.RetentionPolicy{UntouchedDays: 30})
+
+	report, err := uc.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if len(report.Candidates) != 0 {
+		t.Fatalf("candidates = %v, want none", candidateIDs(report))
+	}
+}
+
+func TestRetentionApplyDeletesCandidates(t *testing.T) {
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("a", 100, 90*24*time.Hour),
+		completedRecord("b", 100, 80*24*time.Hour),
+	}}
+	deleter := &fakeDeleter{}
+	uc := newRetention(repo, &fakeRetentionHistory{}, deleter, domain.RetentionPolicy{UntouchedDays: 30, DryRun: true})
+
+	report, err := uc.Apply(context.Background())
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if report.DryRun {
+		t.Fatalf("apply must not be a dry run")
+	}
+	if len(deleter.ids) != 2 || deleter.ids[0] != "a" || deleter.ids[1] != "b" {
+		t.Fatalf("deleted = %v, want [a b]", deleter.ids)
+	}
+	for i, deleteFiles := range deleter.deleteFiles {
+		if !deleteFiles {
+			t.Fatalf("call %d: deleteFiles = false, want true", i)
+		}
+	}
+	for _, c := range report.Candidates {
+		if !c.Deleted {
+			t.Fatalf("candidate %s not marked deleted", c.ID)
+		}
+	}
+}
+
+func TestRetentionApplyKeepFilesAndErrors(t *testing.T) {
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		completedRecord("a", 100, 90*24*time.Hour),
+	}}
+	deleter := &fakeDeleter{err: errors.New("boom")}
+	uc := newRetention(repo, &fakeRetentionHistory{}, deleter, domain.RetentionPolicy{UntouchedDays: 30, KeepFiles: true})
+
+	report, err := uc.Apply(context.Background())
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(deleter.deleteFiles) != 1 || deleter.deleteFiles[0] {
+		t.Fatalf("deleteFiles = %v, want [false]", deleter.deleteFiles)
+	}
+	if report.Candidates[0].Deleted || report.Candidates[0].Error == "" {
+		t.Fatalf("candidate = %+v, want error recorded", report.Candidates[0])
+	}
+}
+
+func TestRetentionRepoError(t *testing.T) {
+	repo := &fakeRetentionRepo{listErr: errors.New("boom")}
+	uc := newRetention(repo, &fakeRetentionHistory{}, &fakeDeleter{}, domain.RetentionPolicy{UntouchedDays: 1})
+
+	_, err := uc.Preview(context.Background())
+	if !errors.Is(err, ErrRepository) {
+		t.Fatalf("err = %v, want ErrRepository", err)
+	}
+}
+
+func TestFullyWatchedAtRequiresEveryVideo(t *testing.T) {
+	files := []domain.FileRef{
+		{Index: 0, Path: "Show/S01E01.mkv"},
+		{Index: 1, Path: "Show/S01E02.mkv"},
+		{Index: 2, Path: "Show/readme.nfo"},
+	}
+	watched := domain.WatchPosition{FileIndex: 0, Position: 1500, Duration: 1500, UpdatedAt: retentionNow}
+	if _, ok := fullyWatchedAt(files, []domain.WatchPosition{watched}); ok {
+		t.Fatalf("expected not fully watched with one of two episodes")
+	}
+	second := domain.WatchPosition{FileIndex: 1, Position: 1490, Duration: 1500, UpdatedAt: retentionNow.Add(time.Hour)}
+	at, ok := fullyWatchedAt(files, []domain.WatchPosition{watched, second})
+	if !ok {
+		t.Fatalf("expected fully watched")
+	}
+	if !at.Equal(second.UpdatedAt) {
+		t.Fatalf("watchedAt = %v, want %v", at, second.UpdatedAt)
+	}
+	if _, ok := fullyWatchedAt([]domain.FileRef{{Index: 0, Path: "notes.txt"}}, nil); ok {
+		t.Fatalf("expected torrents without video not to count as watched")
+	}
+}
@@ -0,0 +1,310 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// TorrentStopper stops a torrent and records it as stopped.
+type TorrentStopper interface {
+	Execute(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error)
+}
+
+// StallNotifier publishes stall events, e.g. to WebSocket clients.
+type StallNotifier interface {
+	NotifyStall(event domain.StallEvent)
+}
+
+// StallDetector flags torrents that made no progress for After: magnets
+// whose metadata never arrived, torrents without peers and torrents with
+// peers that send nothing. A flagged torrent gets StalledSince and
+// StalledReason on its record until it downloads again.
+type StallDetector struct {
+	Engine   ports.Engine
+	Trackers ports.TrackerEngine // used by StallActionRetry
+	Repo     ports.TorrentRepository
+	Stalls   ports.StallRepository
+	Stop     TorrentStopper // used by StallActionStop
+	Notifier StallNotifier
+	After    time.Duration
+	Action   domain.StallAction
+	// RetryTrackers are added to a stalled public torrent once before it is
+	// flagged.
+	RetryTrackers []string
+	Logger        *slog.Logger
+	Interval      time.Duration
+	Now           func() time.Time
+
+	mu      sync.Mutex
+	watches map[domain.TorrentID]*stallWatch
+}
+
+// stallWatch tracks activity of one session since it was first checked.
+type stallWatch struct {
+	seenAt       time.Time
+	doneBytes    int64
+	progressAt   time.Time
+	progressed   bool      // bytes were downloaded since seenAt or the flag
+	noPeersSince time.Time // zero while peers are connected
+	pending      bool      // waiting for metadata at the last check
+	retried      bool
+}
+
+// Run checks sessions periodically until ctx is cancelled.
+func (uc *StallDetector) Run(ctx context.Context) {
+	interval := uc.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := uc.Check(ctx); err != nil {
+				uc.logger().Warn("stall: check failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// Check evaluates every open session once.
+func (uc *StallDetector) Check(ctx context.Context) error {
+	if uc.After <= 0 {
+		return nil
+	}
+	ids, err := uc.Engine.ListSessions(ctx)
+	if err != nil {
+		return wrapEngine(err)
+	}
+	records, err := uc.Repo.GetMany(ctx, ids)
+	if err != nil {
+		return wrapRepo(err)
+	}
+	recordMap := make(map[domain.TorrentID]domain.TorrentRecord, len(records))
+	for _, r := range records {
+		recordMap[r.ID] = r
+	}
+	uc.prune(ids)
+
+	now := uc.now()
+	for _, id := range ids {
+		record, ok := recordMap[id]
+		if !ok {
+			continue
+		}
+		state, err := uc.Engine.GetSessionState(ctx, id)
+		if err != nil {
+			if !errors.Is(err, domain.ErrNotFound) {
+				uc.logger().Warn("stall: get session state failed",
+					slog.String("id", string(id)),
+					slog.String("error", err.Error()))
+			}
+			continue
+		}
+		uc.check(ctx, record, state, now)
+	}
+	return nil
+}
+
+func (uc *StallDetector) check(ctx context.Context, record domain.TorrentRecord, state domain.SessionState, now time.Time) {
+	switch state.Mode {
+	case domain.ModeStopped, domain.ModePaused, domain.ModeCompleted:
+		// Not expected to download; a stopped torrent keeps its flag.
+		uc.forget(record.ID)
+		if state.Mode == domain.ModeCompleted && record.StalledSince != nil {
+			uc.clear(ctx, record)
+		}
+		return
+	}
+
+	uc.mu.Lock()
+	w := uc.watchLocked(record.ID, state, now)
+	reason, since := w.stall(state, now, uc.After)
+	recovered := w.progressed
+	retry := reason != "" && record.StalledSince == nil && uc.shouldRetry(record, w)
+	if retry {
+		// Give the torrent another period with the extra trackers.
+		w.retried = true
+		w.seenAt, w.progressAt = now, now
+		if !w.noPeersSince.IsZero() {
+			w.noPeersSince = now
+		}
+	} else if reason != "" && record.StalledSince == nil {
+		// Recovery counts from the moment the torrent is flagged.
+		w.progressed = false
+	}
+	uc.mu.Unlock()
+
+	switch {
+	case retry:
+		if err := uc.Trackers.AddTrackers(ctx, record.ID, uc.RetryTrackers); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			uc.logger().Warn("stall: add trackers failed",
+				slog.String("id", string(record.ID)),
+				slog.String("error", err.Error()))
+			return
+		}
+		uc.logger().Info("stall: retrying with extra trackers",
+			slog.String("id", string(record.ID)),
+			slog.String("reason", string(reason)))
+	case record.StalledSince == nil && reason != "":
+		uc.flag(ctx, record, since, reason)
+	case record.StalledSince != nil && recoveredFrom(record.StalledReason, state, recovered):
+		uc.clear(ctx, record)
+	}
+}
+
+// stall returns the reason the session counts as stalled and since when, or
+// an empty reason.
+func (w *stallWatch) stall(state domain.SessionState, now time.Time, after time.Duration) (domain.StallReason, time.Time) {
+	if state.Status == domain.TorrentPending {
+		if now.Sub(w.seenAt) >= after {
+			return domain.StallNoMetadata, w.seenAt
+		}
+		return "", time.Time{}
+	}
+	if !w.noPeersSince.IsZero() && now.Sub(w.noPeersSince) >= after {
+		return domain.StallNoPeers, w.noPeersSince
+	}
+	if now.Sub(w.progressAt) >= after {
+		return domain.StallNoProgress, w.progressAt
+	}
+	return "", time.Time{}
+}
+
+// recoveredFrom reports whether a torrent flagged for reason works again:
+// metadata arrived or data is downloaded.
+func recoveredFrom(reason domain.StallReason, state domain.SessionState, progressed bool) bool {
+	if reason == domain.StallNoMetadata && state.Status != domain.TorrentPending {
+		return true
+	}
+	return progressed
+}
+
+func (uc *StallDetector) shouldRetry(record domain.TorrentRecord, w *stallWatch) bool {
+	return uc.Action == domain.StallActionRetry && !w.retried && !record.Private &&
+		uc.Trackers != nil && len(uc.RetryTrackers) > 0
+}
+
+func (uc *StallDetector) flag(ctx context.Context, record domain.TorrentRecord, since time.Time, reason domain.StallReason) {
+	if err := uc.Stalls.SetStalled(ctx, record.ID, since, reason); err != nil {
+		uc.logger().Warn("stall: flag failed",
+			slog.String("id", string(record.ID)),
+			slog.String("error", err.Error()))
+		return
+	}
+	uc.logger().Info("stall: torrent stalled",
+		slog.String("id", string(record.ID)),
+		slog.String("reason", string(reason)),
+		slog.Time("since", since))
+
+	action := domain.StallActionNone
+	if uc.Action == domain.StallActionStop && uc.Stop != nil {
+		if _, err := uc.Stop.Execute(ctx, record.ID); err != nil {
+			uc.logger().Warn("stall: stop failed",
+				slog.String("id", string(record.ID)),
+				slog.String("error", err.Error()))
+		} else {
+			action = domain.StallActionStop
+			uc.forget(record.ID)
+		}
+	}
+	uc.notify(domain.StallEvent{
+		TorrentID:    record.ID,
+		Name:         record.Name,
+		Stalled:      true,
+		Reason:       reason,
+		StalledSince: &since,
+		Action:       action,
+	})
+}
+
+func (uc *StallDetector) clear(ctx context.Context, record domain.TorrentRecord) {
+	if err := uc.Stalls.ClearStalled(ctx, record.ID); err != nil {
+		uc.logger().Warn("stall: clear failed",
+			slog.String("id", string(record.ID)),
+			slog.String("error", err.Error()))
+		return
+	}
+	uc.logger().Info("stall: torrent recovered", slog.String("id", string(record.ID)))
+	uc.notify(domain.StallEvent{TorrentID: record.ID, Name: record.Name, Stalled: false})
+}
+
+func (uc *StallDetector) notify(event domain.StallEvent) {
+	if uc.Notifier != nil {
+		uc.Notifier.NotifyStall(event)
+	}
+}
+
+// watchLocked returns the watch of id, updated with state. Caller must hold
+// uc.mu.
+func (uc *StallDetector) watchLocked(id domain.TorrentID, state domain.SessionState, now time.Time) *stallWatch {
+	if uc.watches == nil {
+		uc.watches = make(map[domain.TorrentID]*stallWatch)
+	}
+	done := sumBytesCompleted(state.Files)
+	pending := state.Status == domain.TorrentPending
+	w, ok := uc.watches[id]
+	if !ok || (w.pending && !pending) {
+		// Downloading starts over once metadata arrives.
+		w = &stallWatch{seenAt: now, doneBytes: done, progressAt: now, retried: ok && w.retried}
+		uc.watches[id] = w
+	}
+	w.pending = pending
+	if done > w.doneBytes {
+		w.doneBytes = done
+		w.progressAt = now
+		w.progressed = true
+	}
+	if state.Peers > 0 {
+		w.noPeersSince = time.Time{}
+	} else if w.noPeersSince.IsZero() {
+		w.noPeersSince = now
+	}
+	return w
+}
+
+func (uc *StallDetector) forget(id domain.TorrentID) {
+	uc.mu.Lock()
+	delete(uc.watches, id)
+	uc.mu.Unlock()
+}
+
+// prune drops watches of sessions that were closed.
+func (uc *StallDetector) prune(ids []domain.TorrentID) {
+	open := make(map[domain.TorrentID]struct{}, len(ids))
+	for _, id := range ids {
+		open[id] = struct{}{}
+	}
+	uc.mu.Lock()
+	for id := range uc.watches {
+		if _, ok := open[id]; !ok {
+			delete(uc.watches, id)
+		}
+	}
+	uc.mu.Unlock()
+}
+
+func (uc *StallDetector) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now()
+}
+
+func (uc *StallDetector) logger() *slog.Logger {
+	if uc.Logger != nil {
+		return uc.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeStallEngine struct {
+	fakeSpaceEngine
+	trackerCalls []domain.TorrentID
+	trackers     []string
+}
+
+func (f *fakeStallEngine) ListSessions(ctx context.Context) ([]domain.TorrentID, error) {
+	ids := make([]domain.TorrentID, 0, len(f.states))
+	for id := range f.states {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (f *fakeStallEngine) AddTrackers(ctx context.Context, id domain.TorrentID, urls []string) error {
+	f.trackerCalls = append(f.trackerCalls, id)
+	f.trackers = urls
+	return nil
+}
+
+// fakeStallRepo keeps records in memory and applies stall flags to them.
+type fakeStallRepo struct {
+	fakeControlRepo
+	records map[domain.TorrentID]domain.TorrentRecord
+	cleared []domain.TorrentID
+}
+
+func (f *fakeStallRepo) GetMany(ctx context.Context, ids []domain.TorrentID) ([]domain.TorrentRecord, error) {
+	out := make([]domain.TorrentRecord, 0, len(ids))
+	for _, id := range ids {
+		if r, ok := f.records[id]; ok {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStallRepo) SetStalled(ctx context.Context, id domain.TorrentID, since time.Time, reason domain.StallReason) error {
+	r := f.records[id]
+	r.StalledSince = &since
+	r.StalledReason = reason
+	f.records[id] = r
+	return nil
+}
+
+func (f *fakeStallRepo) ClearStalled(ctx context.Context, id domain.TorrentID) error {
+	r := f.records[id]
+	r.StalledSince = nil
+	r.StalledReason = ""
+	f.records[id] = r
+	f.cleared = append(f.cleared, id)
+	return nil
+}
+
+type fakeStallNotifier struct {
+	events []domain.StallEvent
+}
+
+func (f *fakeStallNotifier) NotifyStall(event domain.StallEvent) {
+	f.events = append(f.events, event)
+}
+
+type fakeStopper struct {
+	stopped []domain.TorrentID
+}
+
+func (f *fakeStopper) Execute(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	f.stopped = append(f.stopped, id)
+	return domain.TorrentRecord{ID: id, Status: domain.TorrentStopped}, nil
+}
+
+type stallFixture struct {
+	engine   *fakeStallEngine
+	repo     *fakeStallRepo
+	notifier *fakeStallNotifier
+	stopper  *fakeStopper
+	uc       *StallDetector
+	now      time.Time
+}
+
+func newStallFixture(records ...domain.TorrentRecord) *stallFixture {
+	f := &stallFixture{
+		engine:   &fakeStallEngine{fakeSpaceEngine: fakeSpaceEngine{states: map[domain.TorrentID]domain.SessionState{}}},
+		repo:     &fakeStallRepo{records: map[domain.TorrentID]domain.TorrentRecord{}},
+		notifier: &fakeStallNotifier{},
+		stopper:  &fakeStopper{},
+		now:      time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for _, r := range records {
+		f.repo.records[r.ID] = r
+	}
+	f.uc = &StallDetector{
+		Engine:   f.engine,
+		Trackers: f.engine,
+		Repo:     f.repo,
+		Stalls:   f.repo,
+		Stop:     f.stopper,
+		Notifier: f.notifier,
+		After:    30 * time.Minute,
+		Logger:   discardLogger(),
+		Now:      func() time.Time { return f.now },
+	}
+	return f
+}
+
+func (f *stallFixture) checkAt(t *testing.T, offset time.Duration) {
+	t.Helper()
+	saved := f.now
+	f.now = saved.Add(offset)
+	if err := f.uc.Check(context.Background()); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	f.now = saved
+}
+
+func TestStallDetectorFlagsMissingMetadata(t *testing.T) {
+	f := newStallFixture(domain.TorrentRecord{ID: "m1", Name: "magnet", Status: domain.TorrentPending})
+	f.engine.states["m1"] = domain.SessionState{ID: "m1", Status: domain.TorrentPending, Mode: domain.ModeDownloading}
+
+	f.checkAt(t, 0)
+	f.checkAt(t, 29*time.Minute)
+	if f.repo.records["m1"].StalledSince != nil {
+		t.Fatalf("flagged before the timeout")
+	}
+
+	f.checkAt(t, 30*time.Minute)
+	rec := f.repo.records["m1"]
+	if rec.StalledSince == nil || !rec.StalledSince.Equal(f.now) || rec.StalledReason != domain.StallNoMetadata {
+		t.Fatalf("record = %+v", rec)
+	}
+	if len(f.notifier.events) != 1 || !f.notifier.events[0].Stalled || f.notifier.events[0].Reason != domain.StallNoMetadata {
+		t.Fatalf("events = %+v", f.notifier.events)
+	}
+
+	// Metadata arrives: the flag is cleared.
+	f.engine.states["m1"] = domain.SessionState{ID: "m1", Status: domain.TorrentActive, Mode: domain.ModeDownloading, Peers: 3}
+	f.checkAt(t, 31*time.Minute)
+	if f.repo.records["m1"].StalledSince != nil {
+		t.Fatalf("flag not cleared after metadata arrived")
+	}
+	if n := len(f.notifier.events); n != 2 || f.notifier.events[1].Stalled {
+		t.Fatalf("events = %+v", f.notifier.events)
+	}
+}
+
+func TestStallDetectorFlagsNoPeersAndRecovers(t *testing.T) {
+	f := newStallFixture(domain.TorrentRecord{ID: "t1", Status: domain.TorrentActive})
+	files := []domain.FileRef{{Index: 0, Length: 1000, BytesCompleted: 100}}
+	f.engine.states["t1"] = domain.SessionState{ID: "t1", Status: domain.TorrentActive, Mode: domain.ModeDownloading, Files: files}
+
+	f.checkAt(t, 0)
+	f.checkAt(t, 30*time.Minute)
+	if rec := f.repo.records["t1"]; rec.StalledReason != domain.StallNoPeers {
+		t.Fatalf("record = %+v", rec)
+	}
+
+	// Peers without data do not clear the flag.
+	f.engine.states["t1"] = domain.SessionState{ID: "t1", Status: domain.TorrentActive, Mode: domain.ModeDownloading, Peers: 2, Files: files}
+	f.checkAt(t, 31*time.Minute)
+	if f.repo.records["t1"].StalledSince == nil {
+		t.Fatalf("flag cleared without progress")
+	}
+
+	files = []domain.FileRef{{Index: 0, Length: 1000, BytesCompleted: 300}}
+	f.engine.states["t1"] = domain.SessionState{ID: "t1", Status: domain.TorrentActive, Mode: domain.ModeDownloading, Peers: 2, Files: files}
+	f.checkAt(t, 32*time.Minute)
+	if f.repo.records["t1"].StalledSince != nil || len(f.repo.cleared) != 1 {
+		t.Fatalf("flag not cleared after progress: %+v", f.repo.records["t1"])
+	}
+}
+
+func TestStallDetectorStopAction(t *testing.T) {
+	f := newStallFixture(domain.TorrentRecord{ID: "t1", Status: domain.TorrentActive})
+	f.uc.Action = domain.StallActionStop
+	f.engine.states["t1"] = domain.SessionState{ID: "t1", Status: domain.TorrentActive, Mode: domain.ModeDownloading, Peers: 4}
+
+	f.checkAt(t, 0)
+	f.checkAt(t, 30*time.Minute)
+	if len(f.stopper.stopped) != 1 || f.stopper.stopped[0] != "t1" {
+		t.Fatalf("stopped = %v", f.stopper.stopped)
+	}
+	if rec := f.repo.records["t1"]; rec.StalledReason != domain.StallNoProgress {
+		t.Fatalf("record = %+v", rec)
+	}
+	if len(f.notifier.events) != 1 || f.notifier.events[0].Action != domain.StallActionStop {
+		t.Fatalf("events = %+v", f.notifier.events)
+	}
+}
+
+func TestStallDetectorRetriesWithTrackersOnce(t *testing.T) {
+	f := newStallFixture(
+		domain.TorrentRecord{ID: "pub", Status: domain.TorrentActive},
+		domain.TorrentRecord{ID: "priv", Status: domain.TorrentActive, Private: true},
+	)
+	f.uc.Action = domain.StallActionRetry
+	f.uc.RetryTrackers = []string{"udp://tracker.example:1337/announce"}
+	f.engine.states["pub"] = domain.SessionState{ID: "pub", Status: domain.TorrentActive, Mode: domain.ModeDownloading}
+	f.engine.states["priv"] = domain.SessionState{ID: "priv", Status: domain.TorrentActive, Mode: domain.ModeDownloading}
+
+	f.checkAt(t, 0)
+	f.checkAt(t, 30*time.Minute)
+	if len(f.engine.trackerCalls) != 1 || f.engine.trackerCalls[0] != "pub" {
+		t.Fatalf("tracker calls = %v", f.engine.trackerCalls)
+	}
+	if f.repo.records["pub"].StalledSince != nil {
+		t.Fatalf("public torrent flagged before the retry period")
+	}
+	if f.repo.records["priv"].StalledSince == nil {
+		t.Fatalf("private torrent should be flagged without retry")
+	}
+
+	f.checkAt(t, 60*time.Minute)
+	if len(f.engine.trackerCalls) != 1 {
+		t.Fatalf("trackers added twice: %v", f.engine.trackerCalls)
+	}
+	if rec := f.repo.records["pub"]; rec.StalledSince == nil || !rec.StalledSince.Equal(f.now.Add(30*time.Minute)) {
+		t.Fatalf("record = %+v", rec)
+	}
+}
+
+func TestStallDetectorSkipsInactiveModes(t *testing.T) {
+	f := newStallFixture(
+		domain.TorrentRecord{ID: "paused", Status: domain.TorrentActive},
+		domain.TorrentRecord{ID: "stopped", Status: domain.TorrentStopped},
+	)
+	f.engine.states["paused"] = domain.SessionState{ID: "paused", Status: domain.TorrentActive, Mode: domain.ModePaused}
+	f.engine.states["stopped"] = domain.SessionState{ID: "stopped", Status: domain.TorrentStopped, Mode: domain.ModeStopped}
+
+	f.checkAt(t, 0)
+	f.checkAt(t, 2*time.Hour)
+	for id, rec := range f.repo.records {
+		if rec.StalledSince != nil {
+			t.Fatalf("%s flagged", id)
+		}
+	}
+	if len(f.notifier.events) != 0 {
+		t.Fatalf("events = %+v", f.notifier.events)
+	}
+}
+
+func TestStallDetectorDisabled(t *testing.T) {
+	f := newStallFixture(domain.TorrentRecord{ID: "t1", Status: domain.TorrentPending})
+	f.uc.After = 0
+	f.engine.states["t1"] = domain.SessionState{ID: "t1", Status: domain.TorrentPending, Mode: domain.ModeDownloading}
+
+	f.checkAt(t, 0)
+	f.checkAt(t, 24*time.Hour)
+	if f.repo.records["t1"].StalledSince != nil {
+		t.Fatalf("disabled detector flagged a torrent")
+	}
+}
@@ -13,6 +13,10 @@ type StartTorrent struct {
 	Engine ports.Engine
 	Repo   ports.TorrentRepository
 	Now    func() time.Time
+	// Space, when set, rejects starts whose remaining bytes do not fit on disk.
+	Space *DiskSpace
+	// Events, when set, receives torrent_started.
+	Events ports.EventPublisher
 }
 
 func (uc StartTorrent) Execute(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
@@ -29,6 +33,15 @@ func (uc StartTorrent) Execute(ctx context.Context, id domain.TorrentID) (domain
 		return domain.TorrentRecord{}, wrapRepo(err)
 	}
 
+	release := func() {}
+	if uc.Space != nil && record.Status != domain.TorrentCompleted {
+		release, err = uc.Space.ReserveIn(ctx, record.Source.DataDir, id, selectedFiles(record.Files, record.Source.SelectedFiles))
+		if err != nil {
+			return domain.TorrentRecord{}, err
+		}
+	}
+	defer release()
+
 	if err := uc.Engine.StartSession(ctx, id); err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			session, openErr := openSessionFromRecord(ctx, uc.Engine, record)
@@ -53,5 +66,8 @@ func (uc StartTorrent) Execute(ctx context.Context, id domain.TorrentID) (domain
 		return domain.TorrentRecord{}, wrapRepo(err)
 	}
 
+	if uc.Events != nil {
+		uc.Events.Publish(domain.Event{Type: domain.EventTorrentStarted, TorrentID: id, Reason: domain.ReasonUser})
+	}
 	return record, nil
 }
@@ -0,0 +1,375 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+var ErrInvalidStatsRange = errors.New("invalid stats range")
+
+const (
+	// defaultStatsCapacity keeps one hour of 5-second samples per torrent.
+	defaultStatsCapacity = 720
+	// defaultStatsResolution is the step of persisted samples.
+	defaultStatsResolution = time.Minute
+	// defaultStatsWindow is queried when no start time is given.
+	defaultStatsWindow = time.Hour
+	// maxStatsPoints bounds the samples a single query may return.
+	maxStatsPoints = 5000
+)
+
+// StatsStore persists down-sampled statistics and all-time transfer totals.
+type StatsStore interface {
+	AppendStatsSample(ctx context.Context, id domain.TorrentID, sample domain.StatsSample) error
+	ListStatsSamples(ctx context.Context, id domain.TorrentID, from, to time.Time) ([]domain.StatsSample, error)
+	// GetTransferTotals returns domain.ErrNotFound for an unknown torrent.
+	GetTransferTotals(ctx context.Context, id domain.TorrentID) (domain.TransferTotals, error)
+	SaveTransferTotals(ctx context.Context, totals domain.TransferTotals) error
+	DeleteStats(ctx context.Context, id domain.TorrentID) error
+}
+
+// StatsHistory keeps a per-torrent time series of speeds, peers, progress
+// and transfer totals. Recent samples live in a ring buffer; with a Store,
+// samples are down-sampled to Resolution and persisted together with the
+// all-time totals, so graphs and totals survive restarts.
+type StatsHistory struct {
+	// Store persists samples and totals. Nil keeps history in memory only.
+	Store StatsStore
+	// Capacity is the number of raw samples kept per torrent (default 720).
+	Capacity int
+	// Resolution is the step of persisted samples (default one minute).
+	Resolution time.Duration
+	Logger     *slog.Logger
+	Now        func() time.Time
+
+	mu       sync.Mutex
+	torrents map[domain.TorrentID]*torrentStats
+}
+
+type torrentStats struct {
+	ring []domain.StatsSample // circular, oldest at next once full
+	next int
+
+	totals       domain.TransferTotals
+	seeded       bool  // totals loaded from the store
+	lastDown     int64 // session counters at the previous sample
+	lastUp       int64
+	bucket       statsBucket
+	bucketFilled bool
+}
+
+// statsBucket accumulates raw samples of one Resolution step.
+type statsBucket struct {
+	start          time.Time
+	n              int64
+	down, up, peer int64
+	last           domain.StatsSample
+}
+
+type pendingStats struct {
+	id     domain.TorrentID
+	sample domain.StatsSample
+	totals domain.TransferTotals
+}
+
+// Record adds one sample per session state. It is called on every engine
+// metrics tick.
+func (h *StatsHistory) Record(ctx context.Context, states []domain.SessionState) {
+	if len(states) == 0 {
+		return
+	}
+	now := h.now()
+	h.seedTotals(ctx, states)
+
+	var flush []pendingStats
+	h.mu.Lock()
+	for _, state := range states {
+		ts := h.torrentLocked(state.ID)
+		ts.totals.Downloaded += counterDelta(state.Downloaded, ts.lastDown)
+		ts.totals.Uploaded += counterDelta(state.Uploaded, ts.lastUp)
+		ts.lastDown, ts.lastUp = state.Downloaded, state.Uploaded
+		ts.totals.UpdatedAt = now
+
+		sample := domain.StatsSample{
+			Time:          now,
+			DownloadSpeed: state.DownloadSpeed,
+			UploadSpeed:   state.UploadSpeed,
+			Peers:         state.Peers,
+			Progress:      state.Progress,
+			Downloaded:    ts.totals.Downloaded,
+			Uploaded:      ts.totals.Uploaded,
+		}
+		ts.push(sample, h.capacity())
+
+		start := now.Truncate(h.resolution())
+		if ts.bucketFilled && !ts.bucket.start.Equal(start) {
+			flush = append(flush, pendingStats{id: state.ID, sample: ts.bucket.sample(), totals: ts.totals})
+			ts.bucketFilled = false
+		}
+		if !ts.bucketFilled {
+			ts.bucket = statsBucket{start: start}
+			ts.bucketFilled = true
+		}
+		ts.bucket.add(sample)
+	}
+	h.mu.Unlock()
+
+	if h.Store == nil {
+		return
+	}
+	for _, p := range flush {
+		if err := h.Store.AppendStatsSample(ctx, p.id, p.sample); err != nil {
+			h.logger().Warn("stats sample persist failed", slog.String("id", string(p.id)), slog.String("error", err.Error()))
+			continue
+		}
+		if err := h.Store.SaveTransferTotals(ctx, p.totals); err != nil {
+			h.logger().Warn("stats totals persist failed", slog.String("id", string(p.id)), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Query returns the samples of id between from and to. A zero to means now
+// and a zero from one hour before to. With a positive step, samples are
+// merged into buckets of that length.
+func (h *StatsHistory) Query(ctx context.Context, id domain.TorrentID, from, to time.Time, step time.Duration) ([]domain.StatsSample, error) {
+	if to.IsZero() {
+		to = h.now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultStatsWindow)
+	}
+	if from.After(to) || step < 0 {
+		return nil, ErrInvalidStatsRange
+	}
+	if step > 0 && to.Sub(from)/step > maxStatsPoints {
+		return nil, ErrInvalidStatsRange
+	}
+
+	h.mu.Lock()
+	var recent []domain.StatsSample
+	if ts, ok := h.torrents[id]; ok {
+		recent = ts.samples(from, to)
+	}
+	h.mu.Unlock()
+
+	var samples []domain.StatsSample
+	if h.Store != nil {
+		storedTo := to
+		if len(recent) > 0 {
+			storedTo = recent[0].Time
+		}
+		if from.Before(storedTo) {
+			stored, err := h.Store.ListStatsSamples(ctx, id, from, storedTo)
+			if err != nil {
+				return nil, wrapRepo(err)
+			}
+			for _, s := range stored {
+				if s.Time.Before(storedTo) {
+					samples = append(samples, s)
+				}
+			}
+		}
+	}
+	samples = append(samples, recent...)
+
+	if step > 0 {
+		samples = downsampleStats(samples, from, step)
+	}
+	if len(samples) > maxStatsPoints {
+		samples = samples[len(samples)-maxStatsPoints:]
+	}
+	if samples == nil {
+		samples = []domain.StatsSample{}
+	}
+	return samples, nil
+}
+
+// Totals returns the all-time transfer totals of id.
+func (h *StatsHistory) Totals(ctx context.Context, id domain.TorrentID) (domain.TransferTotals, error) {
+	h.mu.Lock()
+	ts, ok := h.torrents[id]
+	if ok && (ts.seeded || h.Store == nil) {
+		totals := ts.totals
+		h.mu.Unlock()
+		return totals, nil
+	}
+	h.mu.Unlock()
+
+	if h.Store == nil {
+		return domain.TransferTotals{TorrentID: id}, nil
+	}
+	totals, err := h.Store.GetTransferTotals(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.TransferTotals{TorrentID: id}, nil
+	}
+	if err != nil {
+		return domain.TransferTotals{}, wrapRepo(err)
+	}
+	return totals, nil
+}
+
+// Forget drops the history and totals of a deleted torrent.
+func (h *StatsHistory) Forget(ctx context.Context, id domain.TorrentID) error {
+	h.mu.Lock()
+	delete(h.torrents, id)
+	h.mu.Unlock()
+	if h.Store == nil {
+		return nil
+	}
+	if err := h.Store.DeleteStats(ctx, id); err != nil {
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+// seedTotals loads the stored totals of torrents seen for the first time,
+// so counting continues where the previous run stopped.
+func (h *StatsHistory) seedTotals(ctx context.Context, states []domain.SessionState) {
+	if h.Store == nil {
+		return
+	}
+	var missing []domain.TorrentID
+	h.mu.Lock()
+	for _, state := range states {
+		if ts, ok := h.torrents[state.ID]; !ok || !ts.seeded {
+			missing = append(missing, state.ID)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, id := range missing {
+		totals, err := h.Store.GetTransferTotals(ctx, id)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			h.logger().Warn("stats totals load failed", slog.String("id", string(id)), slog.String("error", err.Error()))
+			continue
+		}
+		h.mu.Lock()
+		ts := h.torrentLocked(id)
+		if !ts.seeded {
+			ts.totals.Downloaded += totals.Downloaded
+			ts.totals.Uploaded += totals.Uploaded
+			ts.seeded = true
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *StatsHistory) torrentLocked(id domain.TorrentID) *torrentStats {
+	if h.torrents == nil {
+		h.torrents = make(map[domain.TorrentID]*torrentStats)
+	}
+	ts, ok := h.torrents[id]
+	if !ok {
+		ts = &torrentStats{totals: domain.TransferTotals{TorrentID: id}}
+		h.torrents[id] = ts
+	}
+	return ts
+}
+
+func (h *StatsHistory) capacity() int {
+	if h.Capacity > 0 {
+		return h.Capacity
+	}
+	return defaultStatsCapacity
+}
+
+func (h *StatsHistory) resolution() time.Duration {
+	if h.Resolution > 0 {
+		return h.Resolution
+	}
+	return defaultStatsResolution
+}
+
+func (h *StatsHistory) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+func (h *StatsHistory) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.Default()
+}
+
+// counterDelta returns the growth of a session byte counter. A counter that
+// went backwards belongs to a reopened session and counts from zero.
+func counterDelta(current, last int64) int64 {
+	if current >= last {
+		return current - last
+	}
+	return current
+}
+
+func (ts *torrentStats) push(sample domain.StatsSample, capacity int) {
+	if len(ts.ring) < capacity {
+		ts.ring = append(ts.ring, sample)
+		return
+	}
+	ts.ring[ts.next] = sample
+	ts.next = (ts.next + 1) % len(ts.ring)
+}
+
+// samples returns the ring samples between from and to, oldest first.
+func (ts *torrentStats) samples(from, to time.Time) []domain.StatsSample {
+	var out []domain.StatsSample
+	for i := range ts.ring {
+		s := ts.ring[(ts.next+i)%len(ts.ring)]
+		if s.Time.Before(from) || s.Time.After(to) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func (b *statsBucket) add(s domain.StatsSample) {
+	b.n++
+	b.down += s.DownloadSpeed
+	b.up += s.UploadSpeed
+	b.peer += int64(s.Peers)
+	b.last = s
+}
+
+// sample averages speeds and peers over the bucket and keeps the last
+// progress and totals.
+func (b *statsBucket) sample() domain.StatsSample {
+	s := b.last
+	s.Time = b.start
+	if b.n > 0 {
+		s.DownloadSpeed = b.down / b.n
+		s.UploadSpeed = b.up / b.n
+		s.Peers = int(b.peer / b.n)
+	}
+	return s
+}
+
+// downsampleStats merges samples into buckets of step starting at from.
+func downsampleStats(samples []domain.StatsSample, from time.Time, step time.Duration) []domain.StatsSample {
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	var out []domain.StatsSample
+	var bucket statsBucket
+	for _, s := range samples {
+		start := from.Add(s.Time.Sub(from) / step * step)
+		if bucket.n > 0 && !bucket.start.Equal(start) {
+			out = append(out, bucket.sample())
+			bucket = statsBucket{}
+		}
+		if bucket.n == 0 {
+			bucket.start = start
+		}
+		bucket.add(s)
+	}
+	if bucket.n > 0 {
+		out = append(out, bucket.sample())
+	}
+	return out
+}
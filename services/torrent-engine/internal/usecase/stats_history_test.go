@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeStatsStore struct {
+	samples map[domain.TorrentID][]domain.StatsSample
+	totals  map[domain.TorrentID]domain.TransferTotals
+	deleted []domain.TorrentID
+}
+
+func newFakeStatsStore() *fakeStatsStore {
+	return &fakeStatsStore{
+		samples: make(map[domain.TorrentID][]domain.StatsSample),
+		totals:  make(map[domain.TorrentID]domain.TransferTotals),
+	}
+}
+
+func (f *fakeStatsStore) AppendStatsSample(ctx context.Context, id domain.TorrentID, sample domain.StatsSample) error {
+	f.samples[id] = append(f.samples[id], sample)
+	return nil
+}
+
+func (f *fakeStatsStore) ListStatsSamples(ctx context.Context, id domain.TorrentID, from, to time.Time) ([]domain.StatsSample, error) {
+	var out []domain.StatsSample
+	for _, s := range f.samples[id] {
+		if !s.Time.Before(from) && !s.Time.After(to) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStatsStore) GetTransferTotals(ctx context.Context, id domain.TorrentID) (domain.TransferTotals, error) {
+	totals, ok := f.totals[id]
+	if !ok {
+		return domain.TransferTotals{}, domain.ErrNotFound
+	}
+	return totals, nil
+}
+
+func (f *fakeStatsStore) SaveTransferTotals(ctx context.Context, totals domain.TransferTotals) error {
+	f.totals[totals.TorrentID] = totals
+	return nil
+}
+
+func (f *fakeStatsStore) DeleteStats(ctx context.Context, id domain.TorrentID) error {
+	f.deleted = append(f.deleted, id)
+	delete(f.samples, id)
+	delete(f.totals, id)
+	return nil
+}
+
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time { return c.now }
+
+func TestStatsHistoryRingBuffer(t *testing.T) {
+	clock := &stepClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h := &StatsHistory{Capacity: 3, Now: clock.Now}
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		h.Record(ctx, []domain.SessionState{{ID: "t1", DownloadSpeed: int64(i), Peers: i}})
+		clock.now = clock.now.Add(5 * time.Second)
+	}
+
+	samples, err := h.Query(ctx, "t1", time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(samples))
+	}
+	for i, want := range []int64{3, 4, 5} {
+		if samples[i].DownloadSpeed != want {
+			t.Fatalf("sample %d speed = %d, want %d", i, samples[i].DownloadSpeed, want)
+		}
+	}
+}
+
+func TestStatsHistoryTotalsSurviveCounterReset(t *testing.T) {
+	clock := &stepClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h := &StatsHistory{Now: clock.Now}
+	ctx := context.Background()
+
+	for _, st := range []domain.SessionState{
+		{ID: "t1", Downloaded: 100, Uploaded: 10},
+		{ID: "t1", Downloaded: 300, Uploaded: 30},
+		{ID: "t1", Downloaded: 50, Uploaded: 5}, // session reopened
+	} {
+		h.Record(ctx, []domain.SessionState{st})
+		clock.now = clock.now.Add(5 * time.Second)
+	}
+
+	totals, err := h.Totals(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Totals: %v", err)
+	}
+	if totals.Downloaded != 350 || totals.Uploaded != 35 {
+		t.Fatalf("totals = %+v, want 350/35", totals)
+	}
+}
+
+func TestStatsHistoryPersistsAndSeedsTotals(t *testing.T) {
+	store := newFakeStatsStore()
+	store.totals["t1"] = domain.TransferTotals{TorrentID: "t1", Downloaded: 1000, Uploaded: 200}
+	clock := &stepClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h := &StatsHistory{Store: store, Resolution: time.Minute, Now: clock.Now}
+	ctx := context.Background()
+
+	// Two minutes of 30-second samples.
+	for i := 0; i < 5; i++ {
+		h.Record(ctx, []domain.SessionState{{ID: "t1", DownloadSpeed: int64(100 * (i + 1)), Downloaded: int64(10 * (i + 1))}})
+		clock.now = clock.now.Add(30 * time.Second)
+	}
+
+	persisted := store.samples["t1"]
+	if len(persisted) != 2 {
+		t.Fatalf("expected 2 persisted buckets, got %d", len(persisted))
+	}
+	if persisted[0].DownloadSpeed != 150 || persisted[1].DownloadSpeed != 350 {
+		t.Fatalf("bucket speeds = %d, %d, want 150, 350", persisted[0].DownloadSpeed, persisted[1].DownloadSpeed)
+	}
+	if got := store.totals["t1"].Downloaded; got != 1050 {
+		t.Fatalf("stored downloaded = %d, want 1050", got)
+	}
+
+	// A restarted process continues from the stored totals.
+	restarted := &StatsHistory{Store: store, Now: clock.Now}
+	restarted.Record(ctx, []domain.SessionState{{ID: "t1", Downloaded: 5}})
+	totals, err := restarted.Totals(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Totals: %v", err)
+	}
+	if totals.Downloaded != 1055 || totals.Uploaded != 200 {
+		t.Fatalf("totals after restart = %+v", totals)
+	}
+
+	// Older stored samples are merged ahead of the ring buffer.
+	samples, err := restarted.Query(ctx, "t1", clock.now.Add(-time.Hour), clock.now, 0)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(samples) != 3 || !samples[0].Time.Equal(persisted[0].Time) {
+		t.Fatalf("merged samples = %+v", samples)
+	}
+}
+
+func TestStatsHistoryDownsample(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &stepClock{now: start}
+	h := &StatsHistory{Now: clock.Now}
+	ctx := context.Background()
+
+	for i := 0; i < 12; i++ {
+		h.Record(ctx, []domain.SessionState{{ID: "t1", UploadSpeed: int64(i), Progress: float64(i) / 10}})
+		clock.now = clock.now.Add(5 * time.Second)
+	}
+
+	samples, err := h.Query(ctx, "t1", start, clock.now, 30*time.Second)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(samples))
+	}
+	if samples[0].UploadSpeed != 2 || samples[1].UploadSpeed != 8 {
+		t.Fatalf("bucket speeds = %d, %d, want 2, 8", samples[0].UploadSpeed, samples[1].UploadSpeed)
+	}
+	if samples[1].Progress != 1.1 || !samples[1].Time.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("second bucket = %+v", samples[1])
+	}
+}
+
+func TestStatsHistoryInvalidRange(t *testing.T) {
+	h := &StatsHistory{}
+	now := time.Now()
+	if _, err := h.Query(context.Background(), "t1", now, now.Add(-time.Minute), 0); !errors.Is(err, ErrInvalidStatsRange) {
+		t.Fatalf("expected ErrInvalidStatsRange for from > to, got %v", err)
+	}
+	if _, err := h.Query(context.Background(), "t1", now.Add(-24*time.Hour), now, time.Second); !errors.Is(err, ErrInvalidStatsRange) {
+		t.Fatalf("expected ErrInvalidStatsRange for too many points, got %v", err)
+	}
+}
+
+func TestStatsHistoryForget(t *testing.T) {
+	store := newFakeStatsStore()
+	h := &StatsHistory{Store: store}
+	ctx := context.Background()
+	h.Record(ctx, []domain.SessionState{{ID: "t1", Downloaded: 10}})
+
+	if err := h.Forget(ctx, "t1"); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "t1" {
+		t.Fatalf("deleted = %v", store.deleted)
+	}
+	samples, _ := h.Query(ctx, "t1", time.Time{}, time.Time{}, 0)
+	if len(samples) != 0 {
+		t.Fatalf("expected no samples after Forget, got %d", len(samples))
+	}
+}
@@ -13,6 +13,9 @@ type StopTorrent struct {
 	Engine ports.Engine
 	Repo   ports.TorrentRepository
 	Now    func() time.Time
+	// Events, when set, receives torrent_stopped with Reason (default user).
+	Events ports.EventPublisher
+	Reason string
 }
 
 func (uc StopTorrent) Execute(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
@@ -42,5 +45,12 @@ func (uc StopTorrent) Execute(ctx context.Context, id domain.TorrentID) (domain.
 		return domain.TorrentRecord{}, wrapRepo(err)
 	}
 
+	if uc.Events != nil {
+		reason := uc.Reason
+		if reason == "" {
+			reason = domain.ReasonUser
+		}
+		uc.Events.Publish(domain.Event{Type: domain.EventTorrentStopped, TorrentID: id, Reason: reason})
+	}
 	return record, nil
 }
@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// StorageRootUsage reports space on one storage root. AvailableBytes is the
+// room left for new data after the root's min-free threshold and quota.
+type StorageRootUsage struct {
+	Path           string `json:"path"`
+	QuotaBytes     int64  `json:"quotaBytes"`
+	MinFreeBytes   int64  `json:"minFreeBytes"`
+	FreeBytes      int64  `json:"freeBytes"`
+	UsedBytes      int64  `json:"usedBytes"`
+	AvailableBytes int64  `json:"availableBytes"`
+	Torrents       int    `json:"torrents"`
+}
+
+// StoragePlacement chooses the storage root for new torrents and accounts
+// for space per root. The first root is the engine data directory; records
+// without a DataDir belong to it.
+//
+// Quotas are enforced against the total size of the torrents placed on a
+// root (trashed ones included, their data is still on disk).
+type StoragePlacement struct {
+	Roots  []domain.StorageRoot
+	Policy domain.PlacementPolicy
+	// Categories maps a torrent category to a root path for PlacementCategory.
+	Categories map[string]string
+	Repo       ports.TorrentRepository
+
+	// diskFreeFunc overrides the platform disk space check (used in tests).
+	diskFreeFunc func(string) (int64, error)
+
+	mu   sync.Mutex
+	next int
+}
+
+// Primary returns the default storage root path.
+func (p *StoragePlacement) Primary() string {
+	if p == nil || len(p.Roots) == 0 {
+		return ""
+	}
+	return p.Roots[0].Path
+}
+
+// Paths returns all storage root paths, the primary root first.
+func (p *StoragePlacement) Paths() []string {
+	if p == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(p.Roots))
+	for _, root := range p.Roots {
+		paths = append(paths, root.Path)
+	}
+	return paths
+}
+
+// Root returns the configured root that contains dir, falling back to the
+// primary root for an empty or unknown dir.
+func (p *StoragePlacement) Root(dir string) domain.StorageRoot {
+	if p == nil || len(p.Roots) == 0 {
+		return domain.StorageRoot{Path: dir}
+	}
+	if dir != "" {
+		clean := filepath.Clean(dir)
+		for _, root := range p.Roots {
+			if filepath.Clean(root.Path) == clean {
+				return root
+			}
+		}
+	}
+	return p.Roots[0]
+}
+
+// RootOf returns the storage root path of a stored torrent.
+func (p *StoragePlacement) RootOf(ctx context.Context, id domain.TorrentID) string {
+	if p == nil || p.Repo == nil {
+		return p.Primary()
+	}
+	record, err := p.Repo.Get(ctx, id)
+	if err != nil {
+		return p.Primary()
+	}
+	return p.Root(record.Source.DataDir).Path
+}
+
+// recordRoot returns the absolute storage root holding a torrent's data.
+// Without placement the record's DataDir or dataDir is used.
+func recordRoot(placement *StoragePlacement, dataDir string, record domain.TorrentRecord) string {
+	root := dataDir
+	if placement != nil {
+		root = placement.Root(record.Source.DataDir).Path
+	} else if record.Source.DataDir != "" {
+		root = record.Source.DataDir
+	}
+	if root == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return root
+}
+
+// Usage reports per-root space in configuration order.
+func (p *StoragePlacement) Usage(ctx context.Context) ([]StorageRootUsage, error) {
+	used, counts, err := p.usedBytes(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StorageRootUsage, 0, len(p.Roots))
+	for _, root := range p.Roots {
+		usage := StorageRootUsage{
+			Path:         root.Path,
+			QuotaBytes:   root.QuotaBytes,
+			MinFreeBytes: root.MinFreeBytes,
+			UsedBytes:    used[root.Path],
+			Torrents:     counts[root.Path],
+		}
+		usage.FreeBytes, usage.AvailableBytes = p.available(root, used[root.Path])
+		out = append(out, usage)
+	}
+	return out, nil
+}
+
+// QuotaLeft returns how many bytes torrents other than exclude may still
+// place on the root containing dir. ok is false when the root has no quota.
+func (p *StoragePlacement) QuotaLeft(ctx context.Context, dir string, exclude domain.TorrentID) (int64, bool, error) {
+	root := p.Root(dir)
+	if root.QuotaBytes <= 0 {
+		return 0, false, nil
+	}
+	used, _, err := p.usedBytes(ctx, exclude)
+	if err != nil {
+		return 0, true, err
+	}
+	return root.QuotaBytes - used[root.Path], true, nil
+}
+
+// Choose picks the storage root for a new torrent of needBytes (0 when the
+// size is not known yet).
+func (p *StoragePlacement) Choose(ctx context.Context, category string, needBytes int64) (domain.StorageRoot, error) {
+	if len(p.Roots) <= 1 {
+		return p.Root(""), nil
+	}
+
+	usage, err := p.Usage(ctx)
+	if err != nil {
+		return domain.StorageRoot{}, err
+	}
+	fits := func(u StorageRootUsage) bool {
+		if needBytes > 0 {
+			return u.AvailableBytes >= needBytes
+		}
+		return u.AvailableBytes > 0
+	}
+
+	switch p.Policy {
+	case domain.PlacementCategory:
+		if path, ok := p.categoryRoot(category); ok {
+			for i, u := range usage {
+				if filepath.Clean(u.Path) != filepath.Clean(path) {
+					continue
+				}
+				if !fits(u) {
+					return domain.StorageRoot{}, fmt.Errorf("%w: storage root %s for category %q is full",
+						ErrInsufficientSpace, u.Path, category)
+				}
+				return p.Roots[i], nil
+			}
+		}
+	case domain.PlacementRoundRobin:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for n := 0; n < len(usage); n++ {
+			i := (p.next + n) % len(usage)
+			if fits(usage[i]) {
+				p.next = i + 1
+				return p.Roots[i], nil
+			}
+		}
+		return domain.StorageRoot{}, fmt.Errorf("%w: no storage root has room", ErrInsufficientSpace)
+	}
+
+	best := -1
+	for i, u := range usage {
+		if fits(u) && (best < 0 || u.AvailableBytes > usage[best].AvailableBytes) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return domain.StorageRoot{}, fmt.Errorf("%w: no storage root has room", ErrInsufficientSpace)
+	}
+	return p.Roots[best], nil
+}
+
+func (p *StoragePlacement) categoryRoot(category string) (string, bool) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return "", false
+	}
+	for name, path := range p.Categories {
+		if strings.ToLower(name) == category {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// available returns free bytes on the root's disk and the room left after
+// its min-free threshold and quota. Unknown free space counts as unlimited so
+// a platform without statfs never blocks placement.
+func (p *StoragePlacement) available(root domain.StorageRoot, used int64) (free, available int64) {
+	freeFn := p.diskFreeFunc
+	if freeFn == nil {
+		freeFn = diskFreeBytes
+	}
+	available = math.MaxInt64
+	if f, err := freeFn(root.Path); err == nil {
+		free = f
+		available = f - root.MinFreeBytes
+	}
+	if root.QuotaBytes > 0 {
+		available = min(available, root.QuotaBytes-used)
+	}
+	return free, max(available, 0)
+}
+
+// usedBytes sums torrent sizes, extracted files included, per root path,
+// skipping exclude.
+func (p *StoragePlacement) usedBytes(ctx context.Context, exclude domain.TorrentID) (map[string]int64, map[string]int, error) {
+	used := make(map[string]int64)
+	counts := make(map[string]int)
+	if p.Repo == nil {
+		return used, counts, nil
+	}
+	for _, trashed := range []bool{false, true} {
+		records, err := p.Repo.List(ctx, domain.TorrentFilter{Trashed: trashed})
+		if err != nil {
+			return nil, nil, wrapRepo(err)
+		}
+		for _, record := range records {
+			if record.ID == exclude {
+				continue
+			}
+			path := p.Root(record.Source.DataDir).Path
+			used[path] += record.TotalBytes
+			for _, f := range record.Extracted {
+				used[path] += f.Length
+			}
+			counts[path]++
+		}
+	}
+	return used, counts, nil
+}
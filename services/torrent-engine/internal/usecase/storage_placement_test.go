@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakePlacementRepo struct {
+	fakeControlRepo
+	records []domain.TorrentRecord
+}
+
+func (f *fakePlacementRepo) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	for _, r := range f.records {
+		if r.ID == id && !r.InTrash() {
+			return r, nil
+		}
+	}
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
+
+func (f *fakePlacementRepo) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	for _, r := range f.records {
+		if r.ID == id && r.InTrash() {
+			return r, nil
+		}
+	}
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
+
+func (f *fakePlacementRepo) List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error) {
+	var out []domain.TorrentRecord
+	for _, r := range f.records {
+		if r.InTrash() == filter.Trashed {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func placedRecord(id, dir string, size int64) domain.TorrentRecord {
+	return domain.TorrentRecord{
+		ID:         domain.TorrentID(id),
+		Source:     domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:" + id, DataDir: dir},
+		TotalBytes: size,
+	}
+}
+
+func freeByPath(free map[string]int64) func(string) (int64, error) {
+	return func(path string) (int64, error) {
+		f, ok := free[path]
+		if !ok {
+			return 0, errors.New("unknown path")
+		}
+		return f, nil
+	}
+}
+
+func TestStoragePlacementUsagePerRoot(t *testing.T) {
+	deletedAt := time.Now()
+	trashed := placedRecord("c", "/b", 50)
+	trashed.DeletedAt = &deletedAt
+	p := &StoragePlacement{
+		Roots: []domain.StorageRoot{
+			{Path: "/a", MinFreeBytes: 100},
+			{Path: "/b", QuotaBytes: 500},
+		},
+		Repo: &fakePlacementRepo{records: []domain.TorrentRecord{
+			placedRecord("a", "", 300),
+			placedRecord("b", "/b/", 200),
+			trashed,
+		}},
+		diskFreeFunc: freeByPath(map[string]int64{"/a": 1000, "/b": 5000}),
+	}
+
+	usage, err := p.Usage(context.Background())
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("roots = %d, want 2", len(usage))
+	}
+	if usage[0].UsedBytes != 300 || usage[0].Torrents != 1 || usage[0].AvailableBytes != 900 {
+		t.Fatalf("root a = %+v", usage[0])
+	}
+	if usage[1].UsedBytes != 250 || usage[1].Torrents != 2 || usage[1].AvailableBytes != 250 {
+		t.Fatalf("root b = %+v", usage[1])
+	}
+}
+
+func TestStoragePlacementMostFree(t *testing.T) {
+	p := &StoragePlacement{
+		Roots:        []domain.StorageRoot{{Path: "/a"}, {Path: "/b"}, {Path: "/c", MinFreeBytes: 4000}},
+		Policy:       domain.PlacementMostFree,
+		diskFreeFunc: freeByPath(map[string]int64{"/a": 1000, "/b": 3000, "/c": 5000}),
+	}
+
+	root, err := p.Choose(context.Background(), "", 0)
+	if err != nil {
+		t.Fatalf("Choose: %v", err)
+	}
+	if root.Path != "/b" {
+		t.Fatalf("root = %s, want /b", root.Path)
+	}
+
+	if _, err := p.Choose(context.Background(), "", 4000); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace", err)
+	}
+}
+
+func TestStoragePlacementRoundRobinSkipsFullRoots(t *testing.T) {
+	p := &StoragePlacement{
+		Roots:  []domain.StorageRoot{{Path: "/a"}, {Path: "/b", QuotaBytes: 100}, {Path: "/c"}},
+		Policy: domain.PlacementRoundRobin,
+		Repo: &fakePlacementRepo{records: []domain.TorrentRecord{
+			placedRecord("full", "/b", 100),
+		}},
+		diskFreeFunc: freeByPath(map[string]int64{"/a": 1000, "/b": 1000, "/c": 1000}),
+	}
+
+	var got []string
+	for range 4 {
+		root, err := p.Choose(context.Background(), "", 0)
+		if err != nil {
+			t.Fatalf("Choose: %v", err)
+		}
+		got = append(got, root.Path)
+	}
+	want := []string{"/a", "/c", "/a", "/c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("placements = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestStoragePlacementCategory(t *testing.T) {
+	p := &StoragePlacement{
+		Roots:        []domain.StorageRoot{{Path: "/a"}, {Path: "/movies", MinFreeBytes: 100}},
+		Policy:       domain.PlacementCategory,
+		Categories:   map[string]string{"Movies": "/movies"},
+		diskFreeFunc: freeByPath(map[string]int64{"/a": 5000, "/movies": 1000}),
+	}
+
+	root, err := p.Choose(context.Background(), "movies", 0)
+	if err != nil || root.Path != "/movies" {
+		t.Fatalf("mapped category: root=%s err=%v", root.Path, err)
+	}
+	root, err = p.Choose(context.Background(), "series", 0)
+	if err != nil || root.Path != "/a" {
+		t.Fatalf("unmapped category should use most free root: root=%s err=%v", root.Path, err)
+	}
+	if _, err := p.Choose(context.Background(), "movies", 2000); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace for full category root", err)
+	}
+}
+
+func TestCreateTorrentPlacesOnChosenRoot(t *testing.T) {
+	session := &fakeSession{id: "t1", files: []domain.FileRef{{Index: 0, Path: "a/movie.mkv", Length: 10}}}
+	engine := &fakeEngine{returnedSession: session}
+	repo := &fakeRepo{}
+	placement := &StoragePlacement{
+		Roots:        []domain.StorageRoot{{Path: "/a"}, {Path: "/b"}},
+		Policy:       domain.PlacementMostFree,
+		diskFreeFunc: freeByPath(map[string]int64{"/a": 100, "/b": 900}),
+	}
+	uc := CreateTorrent{
+		Engine:    engine,
+		Repo:      repo,
+		Placement: placement,
+		Space:     &DiskSpace{Engine: engine, Placement: placement, diskFreeFunc: placement.diskFreeFunc},
+	}
+
+	record, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if engine.openSource.DataDir != "/b" || record.Source.DataDir != "/b" {
+		t.Fatalf("data dir: engine=%q record=%q, want /b", engine.openSource.DataDir, record.Source.DataDir)
+	}
+}
+
+type fakeMetainfo struct {
+	files []domain.FileRef
+}
+
+func (f fakeMetainfo) TorrentFiles(path string) ([]domain.FileRef, error) {
+	return f.files, nil
+}
+
+func TestCreateTorrentPlacesTorrentFileBySize(t *testing.T) {
+	session := &fakeSession{id: "t1", files: []domain.FileRef{{Index: 0, Path: "movie.mkv", Length: 500}}}
+	engine := &fakeEngine{returnedSession: session}
+	uc := CreateTorrent{
+		Engine: engine,
+		Repo:   &fakeRepo{},
+		Placement: &StoragePlacement{
+			Roots:        []domain.StorageRoot{{Path: "/a"}, {Path: "/b"}},
+			Policy:       domain.PlacementRoundRobin,
+			diskFreeFunc: freeByPath(map[string]int64{"/a": 100, "/b": 1000}),
+		},
+		// Only the selected file has to fit.
+		Metainfo: fakeMetainfo{files: []domain.FileRef{{Index: 0, Length: 500}, {Index: 1, Length: 5000}}},
+	}
+
+	record, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Torrent: "/tmp/t.torrent", SelectedFiles: []int{0}}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if record.Source.DataDir != "/b" {
+		t.Fatalf("data dir = %q, want /b (the only root with room)", record.Source.DataDir)
+	}
+}
+
+func TestDiskSpaceReserveInChecksRootAndQuota(t *testing.T) {
+	engine := &fakeSpaceEngine{
+		fakeDiskEngine: fakeDiskEngine{activeSessions: []domain.TorrentID{"on-a"}},
+		states: map[domain.TorrentID]domain.SessionState{
+			"on-a": {Files: []domain.FileRef{{Length: 900}}},
+		},
+	}
+	placement := &StoragePlacement{
+		Roots: []domain.StorageRoot{{Path: "/a"}, {Path: "/b", QuotaBytes: 600, MinFreeBytes: 100}},
+		Repo: &fakePlacementRepo{records: []domain.TorrentRecord{
+			placedRecord("on-a", "/a", 900),
+			placedRecord("on-b", "/b", 300),
+		}},
+	}
+	g := &DiskSpace{Engine: engine, Placement: placement, diskFreeFunc: fixedFree(1000)}
+
+	// Root b does not see the download reserved on root a.
+	release, err := g.ReserveIn(context.Background(), "/b", "new", []domain.FileRef{{Length: 250}})
+	if err != nil {
+		t.Fatalf("ReserveIn b: %v", err)
+	}
+	release()
+
+	// Root a is mostly reserved by the active download.
+	if _, err := g.ReserveIn(context.Background(), "/a", "new", []domain.FileRef{{Length: 250}}); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace on root a", err)
+	}
+
+	// Quota of root b: 600 - 300 used.
+	if _, err := g.ReserveIn(context.Background(), "/b", "new", []domain.FileRef{{Length: 400}}); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace for quota", err)
+	}
+}
+
+func TestDiskPressurePerRoot(t *testing.T) {
+	engine := &fakeDiskEngine{
+		activeSessions: []domain.TorrentID{"on-a", "on-b"},
+	}
+	placement := &StoragePlacement{
+		Roots: []domain.StorageRoot{{Path: "/a"}, {Path: "/b", MinFreeBytes: 500}},
+		Repo: &fakePlacementRepo{records: []domain.TorrentRecord{
+			placedRecord("on-a", "/a", 1),
+			placedRecord("on-b", "/b", 1),
+		}},
+	}
+	status := &DiskPressureStatus{}
+	dp := DiskPressure{
+		Engine:       engine,
+		Logger:       discardLogger(),
+		MinFreeBytes: 100,
+		Interval:     time.Millisecond,
+		Placement:    placement,
+		Status:       status,
+		diskFreeFunc: freeByPath(map[string]int64{"/a": 1000, "/b": 200}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	dp.Run(ctx)
+
+	engine.mu.Lock()
+	stops := append([]domain.TorrentID(nil), engine.stopCalls...)
+	engine.mu.Unlock()
+	if len(stops) != 1 || stops[0] != "on-b" {
+		t.Fatalf("stop calls = %v, want [on-b]", stops)
+	}
+
+	roots := status.Roots()
+	if len(roots) != 2 {
+		t.Fatalf("status roots = %d, want 2", len(roots))
+	}
+	if roots[0].Paused || roots[0].MinFreeBytes != 100 {
+		t.Fatalf("root a = %+v", roots[0])
+	}
+	if !roots[1].Paused || roots[1].MinFreeBytes != 500 || roots[1].FreeBytes != 200 || roots[1].StoppedTorrents != 1 {
+		t.Fatalf("root b = %+v", roots[1])
+	}
+}
@@ -3,6 +3,8 @@ package usecase
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 
 	"torrentstream/internal/domain"
@@ -53,9 +55,16 @@ type StreamTorrent struct {
 	Repo           ports.TorrentRepository
 	ReadaheadBytes int64
 	PlayerSettings StreamPrioritySettings
+	// Placement and DataDir locate files extracted from the torrent's
+	// archives, which are streamed from disk.
+	Placement *StoragePlacement
+	DataDir   string
 
 	readersOnce sync.Once
 	readers     *readerRegistry
+
+	archivedMu sync.Mutex
+	archived   map[domain.TorrentID][]ArchivedFile
 }
 
 func (uc *StreamTorrent) getRegistry() *readerRegistry {
@@ -70,33 +79,9 @@ func (uc *StreamTorrent) Execute(ctx context.Context, id domain.TorrentID, fileI
 		return StreamResult{}, errors.New("engine not configured")
 	}
 
-	session, err := uc.Engine.GetSession(ctx, id)
+	session, err := uc.session(ctx, id)
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) && uc.Repo != nil {
-			record, repoErr := uc.Repo.Get(ctx, id)
-			if repoErr != nil {
-				if errors.Is(repoErr, domain.ErrNotFound) {
-					return StreamResult{}, repoErr
-				}
-				return StreamResult{}, wrapRepo(repoErr)
-			}
-
-			session, err = openSessionFromRecord(ctx, uc.Engine, record)
-			if err != nil {
-				if errors.Is(err, errMissingSource) {
-					return StreamResult{}, domain.ErrNotFound
-				}
-				return StreamResult{}, wrapEngine(err)
-			}
-			if err := session.Start(); err != nil {
-				_ = session.Stop() // Clean up session on Start failure
-				return StreamResult{}, wrapEngine(err)
-			}
-		} else if errors.Is(err, domain.ErrNotFound) {
-			return StreamResult{}, err
-		} else {
-			return StreamResult{}, wrapEngine(err)
-		}
+		return StreamResult{}, err
 	}
 
 	// Focus the session so it gets maximum bandwidth for streaming.
@@ -104,7 +89,14 @@ func (uc *StreamTorrent) Execute(ctx context.Context, id domain.TorrentID, fileI
 
 	file, err := session.SelectFile(fileIndex)
 	if err != nil {
-		return StreamResult{}, ErrInvalidFileIndex
+		if result, ok := uc.openExtracted(ctx, id, fileIndex); ok {
+			return result, nil
+		}
+		archived, ok := uc.openArchived(ctx, id, session, fileIndex)
+		if !ok {
+			return StreamResult{}, ErrInvalidFileIndex
+		}
+		session, file = archived, archived.file.FileRef
 	}
 
 	applyFilePriorityPolicy(session, file, uc.prioritizeActiveFileOnly())
@@ -161,40 +153,23 @@ func (uc *StreamTorrent) ExecuteRaw(ctx context.Context, id domain.TorrentID, fi
 		return StreamResult{}, errors.New("engine not configured")
 	}
 
-	session, err := uc.Engine.GetSession(ctx, id)
+	session, err := uc.session(ctx, id)
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) && uc.Repo != nil {
-			record, repoErr := uc.Repo.Get(ctx, id)
-			if repoErr != nil {
-				if errors.Is(repoErr, domain.ErrNotFound) {
-					return StreamResult{}, repoErr
-				}
-				return StreamResult{}, wrapRepo(repoErr)
-			}
-
-			session, err = openSessionFromRecord(ctx, uc.Engine, record)
-			if err != nil {
-				if errors.Is(err, errMissingSource) {
-					return StreamResult{}, domain.ErrNotFound
-				}
-				return StreamResult{}, wrapEngine(err)
-			}
-			if err := session.Start(); err != nil {
-				_ = session.Stop()
-				return StreamResult{}, wrapEngine(err)
-			}
-		} else if errors.Is(err, domain.ErrNotFound) {
-			return StreamResult{}, err
-		} else {
-			return StreamResult{}, wrapEngine(err)
-		}
+		return StreamResult{}, err
 	}
 
 	_ = uc.Engine.FocusSession(ctx, id)
 
 	file, err := session.SelectFile(fileIndex)
 	if err != nil {
-		return StreamResult{}, ErrInvalidFileIndex
+		if result, ok := uc.openExtracted(ctx, id, fileIndex); ok {
+			return result, nil
+		}
+		archived, ok := uc.openArchived(ctx, id, session, fileIndex)
+		if !ok {
+			return StreamResult{}, ErrInvalidFileIndex
+		}
+		session, file = archived, archived.file.FileRef
 	}
 
 	applyFilePriorityPolicy(session, file, uc.prioritizeActiveFileOnly())
@@ -216,6 +191,147 @@ func (uc *StreamTorrent) ExecuteRaw(ctx context.Context, id domain.TorrentID, fi
 	}, nil
 }
 
+// session returns the engine session of the torrent, starting it from the
+// stored record when it is not loaded.
+func (uc *StreamTorrent) session(ctx context.Context, id domain.TorrentID) (ports.Session, error) {
+	session, err := uc.Engine.GetSession(ctx, id)
+	if err == nil {
+		return session, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, wrapEngine(err)
+	}
+	if uc.Repo == nil {
+		return nil, err
+	}
+	record, err := uc.Repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		return nil, wrapRepo(err)
+	}
+	session, err = openSessionFromRecord(ctx, uc.Engine, record)
+	if err != nil {
+		if errors.Is(err, errMissingSource) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, wrapEngine(err)
+	}
+	if err := session.Start(); err != nil {
+		_ = session.Stop() // Clean up session on Start failure
+		return nil, wrapEngine(err)
+	}
+	return session, nil
+}
+
+// ArchivedFiles lists the files stored uncompressed in the torrent's RAR
+// sets. Reading the volume headers waits for their pieces until ctx ends.
+func (uc *StreamTorrent) ArchivedFiles(ctx context.Context, id domain.TorrentID) ([]ArchivedFile, error) {
+	if uc.Engine == nil {
+		return nil, errors.New("engine not configured")
+	}
+	session, err := uc.session(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	files, err := uc.listArchived(ctx, id, session)
+	if err != nil {
+		return nil, wrapEngine(err)
+	}
+	return files, nil
+}
+
+// listArchived returns the archived files of the torrent with their progress
+// refreshed from the session. Successful listings are cached; the layout of
+// a torrent never changes.
+func (uc *StreamTorrent) listArchived(ctx context.Context, id domain.TorrentID, session ports.Session) ([]ArchivedFile, error) {
+	uc.archivedMu.Lock()
+	files, ok := uc.archived[id]
+	uc.archivedMu.Unlock()
+	if !ok {
+		var err error
+		files, err = listArchivedFiles(session.Files(), sessionVolumeOpener(ctx, session))
+		if err != nil {
+			return nil, err
+		}
+		uc.archivedMu.Lock()
+		if uc.archived == nil {
+			uc.archived = make(map[domain.TorrentID][]ArchivedFile)
+		}
+		uc.archived[id] = files
+		uc.archivedMu.Unlock()
+	}
+
+	volumes := make(map[int]domain.FileRef)
+	for _, f := range session.Files() {
+		volumes[f.Index] = f
+	}
+	out := make([]ArchivedFile, len(files))
+	for i, f := range files {
+		segments := make([]archiveSegment, len(f.segments))
+		for j, seg := range f.segments {
+			if v, ok := volumes[seg.volume.Index]; ok {
+				seg.volume = v
+			}
+			segments[j] = seg
+		}
+		f.segments = segments
+		f.BytesCompleted = archivedBytesCompleted(segments)
+		f.Progress = 0
+		if f.Length > 0 {
+			f.Progress = float64(f.BytesCompleted) / float64(f.Length)
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+// openArchived wraps the session so the archived file with the index
+// streams like a torrent file.
+func (uc *StreamTorrent) openArchived(ctx context.Context, id domain.TorrentID, session ports.Session, fileIndex int) (*archiveSession, bool) {
+	files, err := uc.listArchived(ctx, id, session)
+	if err != nil {
+		return nil, false
+	}
+	for _, f := range files {
+		if f.Index == fileIndex {
+			return &archiveSession{Session: session, file: f}, true
+		}
+	}
+	return nil, false
+}
+
+// openExtracted opens a file extracted from the torrent's archives.
+func (uc *StreamTorrent) openExtracted(ctx context.Context, id domain.TorrentID, fileIndex int) (StreamResult, bool) {
+	if uc.Repo == nil {
+		return StreamResult{}, false
+	}
+	record, err := uc.Repo.Get(ctx, id)
+	if err != nil {
+		return StreamResult{}, false
+	}
+	file, ok := record.ExtractedFile(fileIndex)
+	if !ok {
+		return StreamResult{}, false
+	}
+	root := recordRoot(uc.Placement, uc.DataDir, record)
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(file.Path)))
+	if err != nil {
+		return StreamResult{}, false
+	}
+	return StreamResult{Reader: fileStreamReader{f}, File: file}, true
+}
+
+// fileStreamReader serves a file on disk; there are no pieces to wait for.
+type fileStreamReader struct {
+	*os.File
+}
+
+func (fileStreamReader) SetContext(context.Context) {}
+func (fileStreamReader) SetReadahead(int64)         {}
+func (fileStreamReader) SetResponsive()             {}
+
 func (uc *StreamTorrent) prioritizeActiveFileOnly() bool {
 	if uc.PlayerSettings == nil {
 		return true
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -172,6 +174,9 @@ func (r *fakeStreamRepo) UpdateProgress(context.Context, domain.TorrentID, domai
 func (r *fakeStreamRepo) Get(_ context.Context, _ domain.TorrentID) (domain.TorrentRecord, error) {
 	return r.record, r.getErr
 }
+func (r *fakeStreamRepo) GetTrashed(context.Context, domain.TorrentID) (domain.TorrentRecord, error) {
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
 func (r *fakeStreamRepo) List(context.Context, domain.TorrentFilter) ([]domain.TorrentRecord, error) {
 	return nil, nil
 }
@@ -249,6 +254,41 @@ func TestStreamTorrentRepoFallbackSuccess(t *testing.T) {
 	}
 }
 
+func TestStreamTorrentServesExtractedFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "movie.mkv"), []byte("extracted"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	session := &fakeStreamSession{
+		files:     []domain.FileRef{{Index: 0, Path: "movie.rar", Length: 100}},
+		selectErr: errors.New("invalid index"),
+	}
+	repo := &fakeStreamRepo{
+		record: domain.TorrentRecord{
+			ID:        "t1",
+			Files:     []domain.FileRef{{Index: 0, Path: "movie.rar", Length: 100}},
+			Extracted: []domain.FileRef{{Index: 1, Path: "movie.mkv", Length: 9, BytesCompleted: 9}},
+		},
+	}
+	uc := StreamTorrent{Engine: &fakeStreamEngine{session: session}, Repo: repo, DataDir: dir}
+
+	for _, execute := range []func(context.Context, domain.TorrentID, int) (StreamResult, error){uc.Execute, uc.ExecuteRaw} {
+		result, err := execute(context.Background(), "t1", 1)
+		if err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		data, err := io.ReadAll(result.Reader)
+		_ = result.Reader.Close()
+		if err != nil || string(data) != "extracted" || result.File.Path != "movie.mkv" {
+			t.Fatalf("read %q (%v), file %+v", data, err, result.File)
+		}
+	}
+
+	if _, err := uc.Execute(context.Background(), "t1", 2); !errors.Is(err, ErrInvalidFileIndex) {
+		t.Fatalf("expected ErrInvalidFileIndex, got %v", err)
+	}
+}
+
 func TestStreamTorrentRepoFallbackNotFound(t *testing.T) {
 	engine := &fakeStreamEngineWithOpen{
 		fakeStreamEngine: fakeStreamEngine{err: domain.ErrNotFound},
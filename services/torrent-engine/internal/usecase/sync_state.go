@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"strings"
 	"time"
 
 	"torrentstream/internal/domain"
@@ -15,6 +16,13 @@ type SyncState struct {
 	Repo     ports.TorrentRepository
 	Logger   *slog.Logger
 	Interval time.Duration
+	// Stats persists global transfer counters on every tick.
+	Stats TransferStatsSyncer
+}
+
+// TransferStatsSyncer persists global transfer counters.
+type TransferStatsSyncer interface {
+	Sync(ctx context.Context)
 }
 
 func (s SyncState) Run(ctx context.Context) {
@@ -37,11 +45,28 @@ func (s SyncState) Run(ctx context.Context) {
 }
 
 func (s SyncState) sync(ctx context.Context) {
+	if s.Stats != nil {
+		s.Stats.Sync(ctx)
+	}
+
 	ids, err := s.Engine.ListSessions(ctx)
 	if err != nil {
 		s.Logger.Warn("sync: list sessions failed", slog.String("error", err.Error()))
 		return
 	}
+	s.syncSessions(ctx, ids)
+}
+
+// HandleEvent stores the state of a torrent as soon as the engine reports a
+// change, so the periodic sync only has to catch up on progress.
+func (s SyncState) HandleEvent(ctx context.Context, event domain.Event) {
+	switch event.Type {
+	case domain.EventMetadataReady, domain.EventModeChanged, domain.EventCompleted, domain.EventFileCompleted:
+		s.syncSessions(ctx, []domain.TorrentID{event.TorrentID})
+	}
+}
+
+func (s SyncState) syncSessions(ctx context.Context, ids []domain.TorrentID) {
 	if len(ids) == 0 {
 		return
 	}
@@ -114,6 +139,21 @@ func (s SyncState) sync(ctx context.Context) {
 			}
 		}
 
+		// Metadata reveals the hashes a magnet did not carry: the v2 hash of
+		// a hybrid added by btih, or the v1 hash of one added by btmh.
+		if state.InfoHash != "" && !strings.EqualFold(string(state.InfoHash), string(record.InfoHash)) {
+			update.InfoHash = state.InfoHash
+			changed = true
+		}
+		if state.InfoHashV2 != "" && state.InfoHashV2 != record.InfoHashV2 {
+			update.InfoHashV2 = state.InfoHashV2
+			changed = true
+		}
+		if state.Private && !record.Private {
+			update.Private = true
+			changed = true
+		}
+
 		if record.Name == "" && len(state.Files) > 0 {
 			update.Name = deriveName(state.Files)
 			update.TotalBytes = sumFileLengths(state.Files)
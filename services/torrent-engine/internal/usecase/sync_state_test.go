@@ -82,6 +82,9 @@ func (f *fakeSyncRepo) Update(ctx context.Context, t domain.TorrentRecord) error
 func (f *fakeSyncRepo) Get(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
 	return domain.TorrentRecord{}, nil
 }
+func (f *fakeSyncRepo) GetTrashed(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	return domain.TorrentRecord{}, domain.ErrNotFound
+}
 func (f *fakeSyncRepo) List(ctx context.Context, filter domain.TorrentFilter) ([]domain.TorrentRecord, error) {
 	return nil, nil
 }
@@ -682,3 +685,77 @@ func TestSyncStateSyncEmptyEngineFiles(t *testing.T) {
 		t.Fatalf("expected no update calls, got %d", len(repo.updateProgCalls))
 	}
 }
+
+func TestSyncStateSyncFillsInfoHashes(t *testing.T) {
+	files := []domain.FileRef{{Index: 0, Path: "a.mp4", Length: 1000, PiecesRoot: "ab"}}
+	engine := &fakeSyncEngine{
+		sessions: []domain.TorrentID{"t1", "t2"},
+		states: map[domain.TorrentID]domain.SessionState{
+			"t1": {ID: "t1", Status: domain.TorrentActive, InfoHash: "aa11", InfoHashV2: "bb22", Files: files},
+			"t2": {ID: "t2", Status: domain.TorrentActive, InfoHash: "cc33", Files: files},
+		},
+	}
+	repo := &fakeSyncRepo{records: map[domain.TorrentID]domain.TorrentRecord{
+		"t1": {ID: "t1", Name: "a", Status: domain.TorrentActive, InfoHash: "AA11", TotalBytes: 1000, Files: files},
+		"t2": {ID: "t2", Name: "b", Status: domain.TorrentActive, InfoHash: "CC33", TotalBytes: 1000, Files: files},
+	}}
+	s := SyncState{Engine: engine, Repo: repo, Logger: discardLogger(), Interval: time.Second}
+	s.sync(context.Background())
+
+	if len(repo.updateProgCalls) != 1 {
+		t.Fatalf("expected 1 update call, got %d", len(repo.updateProgCalls))
+	}
+	update := repo.updateProgCalls[0]
+	if update.ID != "t1" || update.Update.InfoHashV2 != "bb22" || update.Update.InfoHash != "" {
+		t.Fatalf("unexpected update: %+v", update)
+	}
+}
+
+func TestSyncStateSyncMarksPrivate(t *testing.T) {
+	files := []domain.FileRef{{Index: 0, Path: "a.mkv", Length: 1000}}
+	engine := &fakeSyncEngine{
+		sessions: []domain.TorrentID{"t1"},
+		states: map[domain.TorrentID]domain.SessionState{
+			"t1": {ID: "t1", Status: domain.TorrentActive, Private: true, Files: files},
+		},
+	}
+	repo := &fakeSyncRepo{records: map[domain.TorrentID]domain.TorrentRecord{
+		"t1": {ID: "t1", Name: "a", Status: domain.TorrentActive, TotalBytes: 1000, Files: files},
+	}}
+	s := SyncState{Engine: engine, Repo: repo, Logger: discardLogger(), Interval: time.Second}
+	s.sync(context.Background())
+
+	if len(repo.updateProgCalls) != 1 || !repo.updateProgCalls[0].Update.Private {
+		t.Fatalf("private flag not stored: %+v", repo.updateProgCalls)
+	}
+}
+
+// --- SyncState.HandleEvent ---
+
+func TestSyncStateHandleEventSyncsOnlyThatTorrent(t *testing.T) {
+	engine := &fakeSyncEngine{
+		sessions: []domain.TorrentID{"t1", "t2"},
+		states: map[domain.TorrentID]domain.SessionState{
+			"t1": {ID: "t1", Status: domain.TorrentCompleted},
+			"t2": {ID: "t2", Status: domain.TorrentCompleted},
+		},
+	}
+	repo := &fakeSyncRepo{records: map[domain.TorrentID]domain.TorrentRecord{
+		"t1": {ID: "t1", Status: domain.TorrentActive},
+		"t2": {ID: "t2", Status: domain.TorrentActive},
+	}}
+	s := SyncState{Engine: engine, Repo: repo, Logger: discardLogger()}
+
+	s.HandleEvent(context.Background(), domain.Event{Type: domain.EventTorrentAdded, TorrentID: "t1"})
+	if len(engine.stateCalls) != 0 {
+		t.Fatalf("torrent_added should not sync, state calls = %v", engine.stateCalls)
+	}
+
+	s.HandleEvent(context.Background(), domain.Event{Type: domain.EventCompleted, TorrentID: "t1"})
+	if len(engine.stateCalls) != 1 || engine.stateCalls[0] != "t1" {
+		t.Fatalf("state calls = %v", engine.stateCalls)
+	}
+	if len(repo.updateProgCalls) != 1 || repo.updateProgCalls[0].ID != "t1" || repo.updateProgCalls[0].Update.Status != domain.TorrentCompleted {
+		t.Fatalf("updates = %+v", repo.updateProgCalls)
+	}
+}
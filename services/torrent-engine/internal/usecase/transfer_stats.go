@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// TransferStatsSource reports client-wide counters since the engine started.
+type TransferStatsSource interface {
+	TransferStats() domain.EngineStats
+}
+
+// TransferCountersStore persists the all-time transfer counters.
+type TransferCountersStore interface {
+	GetTransferCounters(ctx context.Context) (domain.TransferCounters, bool, error)
+	SaveTransferCounters(ctx context.Context, counters domain.TransferCounters) error
+}
+
+// TransferStats reports global transfer statistics. All-time counters are
+// the counters stored by earlier runs plus the current session; Sync
+// persists them and is called from the sync loop.
+type TransferStats struct {
+	Engine    TransferStatsSource
+	Repo      ports.TorrentRepository
+	Store     TransferCountersStore // nil keeps all-time equal to the session
+	StartedAt time.Time
+	Logger    *slog.Logger
+	Now       func() time.Time
+
+	mu     sync.Mutex
+	base   domain.TransferCounters // all-time counters of earlier runs
+	loaded bool
+}
+
+// Sync stores the current all-time counters. Nothing is written until the
+// counters of earlier runs have been loaded, so a failing read never
+// overwrites them.
+func (s *TransferStats) Sync(ctx context.Context) {
+	if s.Store == nil || s.Engine == nil {
+		return
+	}
+	base, err := s.loadBase(ctx)
+	if err != nil {
+		s.logger().Warn("transfer stats load failed", slog.String("error", err.Error()))
+		return
+	}
+	allTime := base.Add(sessionCounters(s.Engine.TransferStats()))
+	if err := s.Store.SaveTransferCounters(ctx, allTime); err != nil {
+		s.logger().Warn("transfer stats save failed", slog.String("error", err.Error()))
+	}
+}
+
+// Get returns the current global statistics.
+func (s *TransferStats) Get(ctx context.Context) (domain.GlobalStats, error) {
+	var engineStats domain.EngineStats
+	if s.Engine != nil {
+		engineStats = s.Engine.TransferStats()
+	}
+	session := sessionCounters(engineStats)
+
+	allTime := session
+	if s.Store != nil {
+		base, err := s.loadBase(ctx)
+		if err != nil {
+			return domain.GlobalStats{}, wrapRepo(err)
+		}
+		allTime = base.Add(session)
+	}
+
+	torrents := map[domain.TorrentStatus]int{}
+	total := 0
+	if s.Repo != nil {
+		records, err := s.Repo.List(ctx, domain.TorrentFilter{})
+		if err != nil {
+			return domain.GlobalStats{}, wrapRepo(err)
+		}
+		for _, record := range records {
+			torrents[record.Status]++
+		}
+		total = len(records)
+	}
+
+	stats := domain.GlobalStats{
+		Session:       session,
+		AllTime:       allTime,
+		Ratio:         allTime.Ratio(),
+		Torrents:      torrents,
+		TotalTorrents: total,
+		DHTNodes:      engineStats.DHTNodes,
+		StartedAt:     s.StartedAt,
+	}
+	if !s.StartedAt.IsZero() {
+		stats.UptimeSeconds = int64(s.now().Sub(s.StartedAt) / time.Second)
+	}
+	return stats, nil
+}
+
+func (s *TransferStats) loadBase(ctx context.Context) (domain.TransferCounters, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return s.base, nil
+	}
+	base, _, err := s.Store.GetTransferCounters(ctx)
+	if err != nil {
+		return domain.TransferCounters{}, err
+	}
+	s.base = base
+	s.loaded = true
+	return base, nil
+}
+
+func (s *TransferStats) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *TransferStats) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
+
+func sessionCounters(stats domain.EngineStats) domain.TransferCounters {
+	return domain.TransferCounters{
+		Downloaded:   stats.Downloaded,
+		Uploaded:     stats.Uploaded,
+		HashFailures: stats.HashFailures,
+		WastedBytes:  stats.WastedBytes,
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeTransferSource struct {
+	stats domain.EngineStats
+}
+
+func (f *fakeTransferSource) TransferStats() domain.EngineStats { return f.stats }
+
+type fakeCountersStore struct {
+	counters domain.TransferCounters
+	found    bool
+	getErr   error
+	saved    []domain.TransferCounters
+}
+
+func (f *fakeCountersStore) GetTransferCounters(ctx context.Context) (domain.TransferCounters, bool, error) {
+	return f.counters, f.found, f.getErr
+}
+
+func (f *fakeCountersStore) SaveTransferCounters(ctx context.Context, counters domain.TransferCounters) error {
+	f.saved = append(f.saved, counters)
+	return nil
+}
+
+func TestTransferStatsGet(t *testing.T) {
+	started := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	source := &fakeTransferSource{stats: domain.EngineStats{Downloaded: 1000, Uploaded: 500, HashFailures: 2, WastedBytes: 32768, DHTNodes: 120}}
+	store := &fakeCountersStore{counters: domain.TransferCounters{Downloaded: 9000, Uploaded: 13500, HashFailures: 1, WastedBytes: 16384}, found: true}
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		{ID: "a", Status: domain.TorrentActive},
+		{ID: "b", Status: domain.TorrentCompleted},
+		{ID: "c", Status: domain.TorrentCompleted},
+	}}
+	uc := &TransferStats{
+		Engine:    source,
+		Repo:      repo,
+		Store:     store,
+		StartedAt: started,
+		Now:       func() time.Time { return started.Add(90 * time.Minute) },
+	}
+
+	stats, err := uc.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stats.Session.Downloaded != 1000 || stats.Session.WastedBytes != 32768 {
+		t.Fatalf("session = %+v", stats.Session)
+	}
+	want := domain.TransferCounters{Downloaded: 10000, Uploaded: 14000, HashFailures: 3, WastedBytes: 49152}
+	if stats.AllTime != want {
+		t.Fatalf("allTime = %+v, want %+v", stats.AllTime, want)
+	}
+	if stats.Ratio != 1.4 {
+		t.Fatalf("ratio = %v, want 1.4", stats.Ratio)
+	}
+	if stats.TotalTorrents != 3 || stats.Torrents[domain.TorrentCompleted] != 2 || stats.Torrents[domain.TorrentActive] != 1 {
+		t.Fatalf("torrents = %v (total %d)", stats.Torrents, stats.TotalTorrents)
+	}
+	if stats.DHTNodes != 120 || stats.UptimeSeconds != 5400 {
+		t.Fatalf("dhtNodes = %d, uptime = %d", stats.DHTNodes, stats.UptimeSeconds)
+	}
+}
+
+func TestTransferStatsSyncPersistsAllTime(t *testing.T) {
+	source := &fakeTransferSource{stats: domain.EngineStats{Downloaded: 100, Uploaded: 10}}
+	store := &fakeCountersStore{counters: domain.TransferCounters{Downloaded: 1000, Uploaded: 200}, found: true}
+	uc := &TransferStats{Engine: source, Store: store}
+
+	uc.Sync(context.Background())
+	source.stats.Downloaded = 150
+	uc.Sync(context.Background())
+
+	if len(store.saved) != 2 {
+		t.Fatalf("expected 2 saves, got %d", len(store.saved))
+	}
+	if got := store.saved[1]; got.Downloaded != 1150 || got.Uploaded != 210 {
+		t.Fatalf("saved = %+v", got)
+	}
+}
+
+func TestTransferStatsSyncSkipsUnreadableStore(t *testing.T) {
+	source := &fakeTransferSource{stats: domain.EngineStats{Downloaded: 100}}
+	store := &fakeCountersStore{getErr: errors.New("mongo down")}
+	uc := &TransferStats{Engine: source, Store: store}
+
+	uc.Sync(context.Background())
+	if len(store.saved) != 0 {
+		t.Fatalf("counters saved without loading the stored ones: %+v", store.saved)
+	}
+	if _, err := uc.Get(context.Background()); !errors.Is(err, ErrRepository) {
+		t.Fatalf("expected ErrRepository, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// RestoreTorrent takes a torrent out of the trash and reopens its session.
+// Torrents that were downloading when deleted resume downloading; others are
+// reopened without starting.
+type RestoreTorrent struct {
+	Engine ports.Engine
+	Repo   ports.TorrentRepository
+	Now    func() time.Time
+}
+
+func (uc RestoreTorrent) Execute(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	now := time.Now
+	if uc.Now != nil {
+		now = uc.Now
+	}
+
+	record, err := uc.Repo.GetTrashed(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.TorrentRecord{}, err
+		}
+		return domain.TorrentRecord{}, wrapRepo(err)
+	}
+
+	session, err := openSessionFromRecord(ctx, uc.Engine, record)
+	if err != nil && !errors.Is(err, errMissingSource) {
+		return domain.TorrentRecord{}, wrapEngine(err)
+	}
+	if session == nil {
+		// Without a source the record can still be restored, but only as
+		// stopped metadata.
+		record.Status = domain.TorrentStopped
+	} else if record.Status == domain.TorrentActive || record.Status == domain.TorrentPending {
+		if err := session.Start(); err != nil {
+			return domain.TorrentRecord{}, wrapEngine(err)
+		}
+	}
+
+	record.DeletedAt = nil
+	record.DeleteFiles = false
+	record.UpdatedAt = now()
+	if err := uc.Repo.Update(ctx, record); err != nil {
+		return domain.TorrentRecord{}, wrapRepo(err)
+	}
+	return record, nil
+}
+
+// TrashPurge permanently deletes torrents that have been in the trash longer
+// than Retention.
+type TrashPurge struct {
+	Repo      ports.TorrentRepository
+	Delete    TorrentDeleter
+	Retention time.Duration
+	Logger    *slog.Logger
+	Interval  time.Duration
+	Now       func() time.Time
+}
+
+// Run purges expired trash periodically until ctx is cancelled.
+func (uc TrashPurge) Run(ctx context.Context) {
+	interval := uc.Interval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := uc.PurgeExpired(ctx)
+			if err != nil {
+				uc.Logger.Warn("trash: purge failed", slog.String("error", err.Error()))
+				continue
+			}
+			if purged > 0 {
+				uc.Logger.Info("trash: purged expired torrents", slog.Int("count", purged))
+			}
+		}
+	}
+}
+
+// PurgeExpired deletes every trashed torrent whose retention has elapsed and
+// returns how many were removed.
+func (uc TrashPurge) PurgeExpired(ctx context.Context) (int, error) {
+	now := time.Now
+	if uc.Now != nil {
+		now = uc.Now
+	}
+
+	records, err := uc.Repo.List(ctx, domain.TorrentFilter{Trashed: true})
+	if err != nil {
+		return 0, wrapRepo(err)
+	}
+
+	cutoff := now().Add(-uc.Retention)
+	purged := 0
+	for _, record := range records {
+		if record.DeletedAt == nil || record.DeletedAt.After(cutoff) {
+			continue
+		}
+		// DeleteTorrent removes trashed records for good, deleting data if
+		// that was requested when the torrent was trashed.
+		if err := uc.Delete.Execute(ctx, record.ID, false); err != nil {
+			uc.Logger.Warn("trash: purge torrent failed",
+				slog.String("id", string(record.ID)),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// getWithTrash looks a torrent up whether or not it is in the trash, for the
+// use cases that handle both.
+func getWithTrash(ctx context.Context, repo ports.TorrentRepository, id domain.TorrentID) (domain.TorrentRecord, error) {
+	record, err := repo.Get(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return repo.GetTrashed(ctx, id)
+	}
+	return record, err
+}
@@ -0,0 +1,258 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type countingSession struct {
+	fakeSession
+	startCnt int
+}
+
+func (s *countingSession) Start() error {
+	s.startCnt++
+	return s.startErr
+}
+
+func trashedAt(at time.Time) *time.Time { return &at }
+
+func TestDeleteTorrentMovesToTrash(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "movie.mkv")
+	if err := os.WriteFile(filePath, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	engine := &fakeControlEngine{}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:     "t1",
+		Status: domain.TorrentActive,
+		Files:  []domain.FileRef{{Index: 0, Path: "movie.mkv", Length: 4}},
+	}}
+	uc := DeleteTorrent{Engine: engine, Repo: repo, DataDir: dir, Trash: true, Now: func() time.Time { return now }}
+
+	if err := uc.Execute(context.Background(), "t1", true); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if engine.removeCalled != 1 {
+		t.Fatalf("session not removed")
+	}
+	if repo.deleteCalls != 0 {
+		t.Fatalf("record must not be deleted")
+	}
+	if repo.updateCalls != 1 || repo.updated.DeletedAt == nil || !repo.updated.DeletedAt.Equal(now) {
+		t.Fatalf("record not trashed: %+v", repo.updated)
+	}
+	if !repo.updated.DeleteFiles {
+		t.Fatalf("deleteFiles choice not recorded")
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		t.Fatalf("data must be kept while in trash: %v", err)
+	}
+}
+
+func TestDeleteTorrentPurgesTrashedRecord(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "movie.mkv")
+	if err := os.WriteFile(filePath, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:          "t1",
+		Status:      domain.TorrentStopped,
+		Files:       []domain.FileRef{{Index: 0, Path: "movie.mkv", Length: 4}},
+		DeletedAt:   trashedAt(time.Now()),
+		DeleteFiles: true,
+	}}
+	uc := DeleteTorrent{Engine: &fakeControlEngine{}, Repo: repo, DataDir: dir, Trash: true}
+
+	if err := uc.Execute(context.Background(), "t1", false); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if repo.deleteCalls != 1 {
+		t.Fatalf("record not deleted")
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Fatalf("expected file removed, stat err = %v", err)
+	}
+}
+
+func TestDeleteTorrentPublishesReason(t *testing.T) {
+	events := &fakeEventPublisher{}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{ID: "t1", Status: domain.TorrentActive}}
+	uc := DeleteTorrent{Engine: &fakeControlEngine{}, Repo: repo, Trash: true, Events: events}
+
+	if err := uc.Execute(context.Background(), "t1", false); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	repo.get = repo.updated
+	uc.Reason = domain.ReasonTrashExpired
+	if err := uc.Execute(context.Background(), "t1", false); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if len(events.events) != 2 {
+		t.Fatalf("events = %+v", events.events)
+	}
+	if ev := events.events[0]; ev.Type != domain.EventTorrentTrashed || ev.TorrentID != "t1" || ev.Reason != domain.ReasonUser {
+		t.Fatalf("trash event = %+v", ev)
+	}
+	if ev := events.events[1]; ev.Type != domain.EventTorrentDeleted || ev.Reason != domain.ReasonTrashExpired {
+		t.Fatalf("delete event = %+v", ev)
+	}
+}
+
+func TestStartTorrentRejectsTrashed(t *testing.T) {
+	engine := &fakeControlEngine{}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{ID: "t1", Status: domain.TorrentStopped, DeletedAt: trashedAt(time.Now())}}
+	uc := StartTorrent{Engine: engine, Repo: repo}
+
+	if _, err := uc.Execute(context.Background(), "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	if engine.startCalled != 0 {
+		t.Fatalf("trashed torrent must not start")
+	}
+}
+
+func TestRestoreTorrentReopensSession(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	session := &countingSession{fakeSession: fakeSession{id: "t1"}}
+	engine := &fakeEngine{returnedSession: session}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:          "t1",
+		Status:      domain.TorrentActive,
+		Source:      domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"},
+		DeletedAt:   trashedAt(now.Add(-time.Hour)),
+		DeleteFiles: true,
+	}}
+	uc := RestoreTorrent{Engine: engine, Repo: repo, Now: func() time.Time { return now }}
+
+	record, err := uc.Execute(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if engine.openCalled != 1 || session.startCnt != 1 {
+		t.Fatalf("open=%d start=%d, want 1/1", engine.openCalled, session.startCnt)
+	}
+	if record.InTrash() || repo.updated.InTrash() || repo.updated.DeleteFiles {
+		t.Fatalf("record still trashed: %+v", repo.updated)
+	}
+	if record.Status != domain.TorrentActive || !record.UpdatedAt.Equal(now) {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+}
+
+func TestRestoreTorrentStoppedIsNotStarted(t *testing.T) {
+	session := &countingSession{fakeSession: fakeSession{id: "t1"}}
+	engine := &fakeEngine{returnedSession: session}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:        "t1",
+		Status:    domain.TorrentStopped,
+		Source:    domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"},
+		DeletedAt: trashedAt(time.Now()),
+	}}
+	uc := RestoreTorrent{Engine: engine, Repo: repo}
+
+	if _, err := uc.Execute(context.Background(), "t1"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if engine.openCalled != 1 || session.startCnt != 0 {
+		t.Fatalf("open=%d start=%d, want 1/0", engine.openCalled, session.startCnt)
+	}
+}
+
+func TestRestoreTorrentNotInTrash(t *testing.T) {
+	repo := &fakeControlRepo{get: domain.TorrentRecord{ID: "t1", Status: domain.TorrentStopped}}
+	uc := RestoreTorrent{Engine: &fakeEngine{}, Repo: repo}
+
+	if _, err := uc.Execute(context.Background(), "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRestoreTorrentEngineError(t *testing.T) {
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:        "t1",
+		Status:    domain.TorrentStopped,
+		Source:    domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"},
+		DeletedAt: trashedAt(time.Now()),
+	}}
+	uc := RestoreTorrent{Engine: &fakeEngine{openErr: errors.New("boom")}, Repo: repo}
+
+	if _, err := uc.Execute(context.Background(), "t1"); !errors.Is(err, ErrEngine) {
+		t.Fatalf("err = %v, want ErrEngine", err)
+	}
+	if repo.updateCalls != 0 {
+		t.Fatalf("record must stay in trash")
+	}
+}
+
+func TestTrashPurgeExpired(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	repo := &fakeRetentionRepo{list: []domain.TorrentRecord{
+		{ID: "old", DeletedAt: trashedAt(now.Add(-8 * 24 * time.Hour))},
+		{ID: "fresh", DeletedAt: trashedAt(now.Add(-time.Hour))},
+		{ID: "failing", DeletedAt: trashedAt(now.Add(-30 * 24 * time.Hour))},
+	}}
+	deleter := &fakeDeleter{}
+	uc := TrashPurge{
+		Repo:      repo,
+		Delete:    deleteFailing{fakeDeleter: deleter, fail: "failing"},
+		Retention: 7 * 24 * time.Hour,
+		Logger:    discardLogger(),
+		Now:       func() time.Time { return now },
+	}
+
+	purged, err := uc.PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeExpired: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged = %d, want 1", purged)
+	}
+	if len(deleter.ids) != 2 || deleter.ids[0] != "old" || deleter.ids[1] != "failing" {
+		t.Fatalf("delete calls = %v", deleter.ids)
+	}
+}
+
+type deleteFailing struct {
+	*fakeDeleter
+	fail domain.TorrentID
+}
+
+func (d deleteFailing) Execute(ctx context.Context, id domain.TorrentID, deleteFiles bool) error {
+	_ = d.fakeDeleter.Execute(ctx, id, deleteFiles)
+	if id == d.fail {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func TestCreateTorrentRestoresTrashedRecord(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	existing := domain.TorrentRecord{ID: "t1", Name: "Existing", Status: domain.TorrentStopped, DeletedAt: trashedAt(now.Add(-time.Hour))}
+	session := &countingSession{fakeSession: fakeSession{id: "t1", files: []domain.FileRef{{Index: 0, Path: "f.mp4", Length: 1}}}}
+	repo := &fakeRepoWithGet{getRecord: existing}
+	uc := CreateTorrent{Engine: &fakeEngine{returnedSession: session}, Repo: repo, Now: func() time.Time { return now }}
+
+	got, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc"}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got.InTrash() || got.Status != domain.TorrentActive || session.startCnt != 1 {
+		t.Fatalf("expected restored active record, got %+v (starts %d)", got, session.startCnt)
+	}
+	if repo.createCalled != 0 {
+		t.Fatalf("expected repo.Create not called")
+	}
+}
@@ -0,0 +1,427 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/nwaples/rardecode/v2"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+type archiveFormat string
+
+const (
+	archiveRAR      archiveFormat = "rar"
+	archiveZIP      archiveFormat = "zip"
+	archiveSevenZip archiveFormat = "7z"
+)
+
+var (
+	rarPartPattern      = regexp.MustCompile(`(?i)\.part(\d+)\.rar$`)
+	sevenZipPartPattern = regexp.MustCompile(`(?i)\.7z\.(\d+)$`)
+)
+
+// errUnpackTooLarge stops an extraction that writes more than the archives
+// listed, and so more than was reserved for it.
+var errUnpackTooLarge = errors.New("unpacked data exceeds the archive listing")
+
+// archiveSpec is the first volume of an archive in a torrent.
+type archiveSpec struct {
+	file   domain.FileRef
+	format archiveFormat
+}
+
+// archiveEntry is a regular file listed in an archive.
+type archiveEntry struct {
+	name string
+	size int64
+}
+
+// Unpack extracts the RAR, ZIP and 7z archives of completed torrents next to
+// the archives and registers the extracted files on the record. Torrent files
+// are never overwritten. One torrent is unpacked at a time.
+type Unpack struct {
+	Repo      ports.TorrentRepository
+	Extracted ports.ExtractedRepository
+	// Placement resolves the save path; without it files are resolved
+	// against DataDir.
+	Placement *StoragePlacement
+	DataDir   string
+	// Space, when set, reserves the unpacked size before anything is
+	// written, so extraction respects free space and root quotas.
+	Space  *DiskSpace
+	Logger *slog.Logger
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+}
+
+// HandleEvent unpacks the torrent in the background once it completes.
+func (uc *Unpack) HandleEvent(ctx context.Context, event domain.Event) {
+	if event.Type != domain.EventCompleted || event.TorrentID == "" {
+		return
+	}
+	uc.wg.Add(1)
+	go func() {
+		defer uc.wg.Done()
+		files, err := uc.Execute(ctx, event.TorrentID)
+		log := uc.logger().With(slog.String("torrentId", string(event.TorrentID)))
+		if err != nil {
+			log.Warn("unpack: failed", slog.Int("extracted", len(files)), slog.String("error", err.Error()))
+			return
+		}
+		if len(files) > 0 {
+			log.Info("unpack: extracted archives", slog.Int("files", len(files)))
+		}
+	}()
+}
+
+// Wait blocks until running extractions are done.
+func (uc *Unpack) Wait() {
+	uc.wg.Wait()
+}
+
+// Execute extracts the torrent's archives and records the extracted files.
+// Torrents that were already unpacked return their recorded files. Archives
+// that fail are reported in the error; files extracted from the others are
+// still recorded, and the next run extracts only what is missing.
+func (uc *Unpack) Execute(ctx context.Context, id domain.TorrentID) ([]domain.FileRef, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	record, err := uc.Repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		return nil, wrapRepo(err)
+	}
+	if record.Unpacked {
+		return record.Extracted, nil
+	}
+	archives := findArchives(record.Files)
+	if len(archives) == 0 {
+		return nil, nil
+	}
+
+	root := recordRoot(uc.Placement, uc.DataDir, record)
+	files := slices.Clone(record.Extracted)
+	taken := make(map[string]struct{}, len(record.Files)+len(files))
+	next := 0
+	for _, f := range record.AllFiles() {
+		taken[f.Path] = struct{}{}
+		if f.Index >= next {
+			next = f.Index + 1
+		}
+	}
+	// Files streamed out of stored RAR sets keep their index once extracted.
+	archived := make(map[string]int)
+	if listed, err := listArchivedFiles(record.Files, diskVolumeOpener(root)); err == nil {
+		for _, f := range listed {
+			archived[f.Path] = f.Index
+			if f.Index >= next {
+				next = f.Index + 1
+			}
+		}
+	}
+
+	// List the archives first: the bytes still to be written are reserved
+	// up front and extraction stops if the archives turn out larger.
+	var errs []error
+	var need int64
+	var listed []archiveSpec
+	for _, archive := range archives {
+		entries, err := listArchive(archive, root)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", archive.file.Path, err))
+			continue
+		}
+		dir := path.Dir(archive.file.Path)
+		for _, e := range entries {
+			if _, ok := taken[path.Join(dir, e.name)]; !ok {
+				need += e.size
+			}
+		}
+		listed = append(listed, archive)
+	}
+	if uc.Space != nil && need > 0 {
+		reserve := append(selectedFiles(record.Files, record.Source.SelectedFiles), record.Extracted...)
+		release, err := uc.Space.ReserveIn(ctx, record.Source.DataDir, id, append(reserve, domain.FileRef{Length: need}))
+		if err != nil {
+			return record.Extracted, err
+		}
+		defer release()
+	}
+
+	left := need
+	for _, archive := range listed {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		dir := path.Dir(archive.file.Path)
+		err := extractArchive(ctx, archive, root, func(name string, r io.Reader) error {
+			rel := path.Join(dir, name)
+			if _, ok := taken[rel]; ok {
+				return nil
+			}
+			n, err := writeExtractedFile(filepath.Join(root, filepath.FromSlash(rel)), r, left)
+			if err != nil {
+				return err
+			}
+			left -= n
+			taken[rel] = struct{}{}
+			index, ok := archived[rel]
+			if !ok {
+				index = next
+				next++
+			}
+			files = append(files, domain.FileRef{
+				Index:          index,
+				Path:           rel,
+				Length:         n,
+				BytesCompleted: n,
+				Progress:       1,
+			})
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", archive.file.Path, err))
+			if errors.Is(err, errUnpackTooLarge) {
+				break
+			}
+		}
+	}
+
+	unpacked := len(errs) == 0
+	if len(files) > len(record.Extracted) || unpacked {
+		if err := uc.Extracted.SetExtracted(ctx, id, files, unpacked); err != nil {
+			errs = append(errs, wrapRepo(err))
+		}
+	}
+	return files, errors.Join(errs...)
+}
+
+// findArchives returns the first volume of every archive among files.
+// Later volumes ("name.part02.rar", "name.r00", "name.7z.002") are read
+// through their first volume.
+func findArchives(files []domain.FileRef) []archiveSpec {
+	var out []archiveSpec
+	for _, f := range files {
+		name := strings.ToLower(path.Base(f.Path))
+		switch {
+		case strings.HasSuffix(name, ".rar"):
+			if m := rarPartPattern.FindStringSubmatch(name); m != nil {
+				if n, _ := strconv.Atoi(m[1]); n != 1 {
+					continue
+				}
+			}
+			out = append(out, archiveSpec{file: f, format: archiveRAR})
+		case strings.HasSuffix(name, ".zip"):
+			out = append(out, archiveSpec{file: f, format: archiveZIP})
+		case strings.HasSuffix(name, ".7z"):
+			out = append(out, archiveSpec{file: f, format: archiveSevenZip})
+		default:
+			if m := sevenZipPartPattern.FindStringSubmatch(name); m != nil {
+				if n, _ := strconv.Atoi(m[1]); n == 1 {
+					out = append(out, archiveSpec{file: f, format: archiveSevenZip})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// listArchive returns the regular files of the archive with their cleaned
+// names and unpacked sizes. Entries of unknown size count as empty.
+func listArchive(archive archiveSpec, root string) ([]archiveEntry, error) {
+	src := filepath.Join(root, filepath.FromSlash(archive.file.Path))
+	var out []archiveEntry
+	add := func(name string, size int64) {
+		if clean := cleanEntryName(name); clean != "" {
+			out = append(out, archiveEntry{name: clean, size: max(size, 0)})
+		}
+	}
+	switch archive.format {
+	case archiveRAR:
+		list, err := rardecode.List(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range list {
+			if !f.IsDir && f.Mode().IsRegular() {
+				add(f.Name, f.UnPackedSize)
+			}
+		}
+		return out, nil
+	case archiveZIP:
+		rc, err := zip.OpenReader(src)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		for _, f := range rc.File {
+			if f.Mode().IsRegular() {
+				add(f.Name, int64(min(f.UncompressedSize64, math.MaxInt64)))
+			}
+		}
+		return out, nil
+	case archiveSevenZip:
+		rc, err := sevenzip.OpenReader(src)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		for _, f := range rc.File {
+			if f.Mode().IsRegular() {
+				add(f.Name, int64(min(f.UncompressedSize, math.MaxInt64)))
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported archive format %q", archive.format)
+}
+
+// extractArchive calls emit for every regular file in the archive with its
+// cleaned slash-separated name.
+func extractArchive(ctx context.Context, archive archiveSpec, root string, emit func(name string, r io.Reader) error) error {
+	src := filepath.Join(root, filepath.FromSlash(archive.file.Path))
+	switch archive.format {
+	case archiveRAR:
+		rc, err := rardecode.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		for {
+			header, err := rc.Next()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if header.IsDir || !header.Mode().IsRegular() {
+				continue
+			}
+			if err := emitEntry(header.Name, &rc.Reader, emit); err != nil {
+				return err
+			}
+		}
+	case archiveZIP:
+		rc, err := zip.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		for _, f := range rc.File {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			if err := emitOpened(f.Name, f.Open, emit); err != nil {
+				return err
+			}
+		}
+		return nil
+	case archiveSevenZip:
+		rc, err := sevenzip.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		for _, f := range rc.File {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			if err := emitOpened(f.Name, f.Open, emit); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported archive format %q", archive.format)
+}
+
+func emitOpened(name string, open func() (io.ReadCloser, error), emit func(string, io.Reader) error) error {
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return emitEntry(name, r, emit)
+}
+
+// emitEntry skips entries whose name is empty after cleaning; names can
+// never climb out of the archive directory.
+func emitEntry(name string, r io.Reader, emit func(string, io.Reader) error) error {
+	clean := cleanEntryName(name)
+	if clean == "" {
+		return nil
+	}
+	return emit(clean, r)
+}
+
+func cleanEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+}
+
+// writeExtractedFile writes r to dst through a temporary file so readers
+// never see a partial file. Writing more than limit bytes fails with
+// errUnpackTooLarge.
+func writeExtractedFile(dst string, r io.Reader, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return 0, err
+	}
+	tmp := dst + ".unpacking"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, max(limit, 0)+1))
+	if err == nil && n > limit {
+		err = errUnpackTooLarge
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	return n, nil
+}
+
+func (uc *Unpack) logger() *slog.Logger {
+	if uc.Logger != nil {
+		return uc.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeUnpackRepo struct {
+	fakeRepoWithGet
+	setCalls int
+}
+
+func (r *fakeUnpackRepo) SetExtracted(_ context.Context, _ domain.TorrentID, files []domain.FileRef, unpacked bool) error {
+	r.setCalls++
+	r.getRecord.Extracted = files
+	r.getRecord.Unpacked = unpacked
+	return nil
+}
+
+func writeTestZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestFindArchives(t *testing.T) {
+	var files []domain.FileRef
+	for i, p := range []string{
+		"Movie/movie.part01.rar", "Movie/movie.part02.rar",
+		"Old/old.rar", "Old/old.r00", "Old/old.r01",
+		"Subs/subs.zip",
+		"Pack/pack.7z.001", "Pack/pack.7z.002", "Single/single.7z",
+		"Movie/movie.nfo", "Sample/sample.mkv",
+	} {
+		files = append(files, domain.FileRef{Index: i, Path: p})
+	}
+
+	var got []string
+	for _, a := range findArchives(files) {
+		got = append(got, a.file.Path+":"+string(a.format))
+	}
+	want := []string{
+		"Movie/movie.part01.rar:rar", "Old/old.rar:rar", "Subs/subs.zip:zip",
+		"Pack/pack.7z.001:7z", "Single/single.7z:7z",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("archives = %v, want %v", got, want)
+	}
+}
+
+func TestUnpackExtractsZipNextToArchive(t *testing.T) {
+	dir := t.TempDir()
+	writeTestZip(t, filepath.Join(dir, "Movie", "movie.zip"), map[string]string{
+		"movie.mkv":             "video",
+		"extras/../../evil.txt": "contained",
+		"readme.txt":            "from archive",
+	})
+	if err := os.WriteFile(filepath.Join(dir, "Movie", "readme.txt"), []byte("from torrent"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	repo := &fakeUnpackRepo{fakeRepoWithGet: fakeRepoWithGet{getRecord: domain.TorrentRecord{
+		ID: "t1",
+		Files: []domain.FileRef{
+			{Index: 0, Path: "Movie/movie.zip", Length: 10},
+			{Index: 1, Path: "Movie/readme.txt", Length: 12},
+		},
+	}}}
+	uc := &Unpack{Repo: repo, Extracted: repo, DataDir: dir}
+
+	files, err := uc.Execute(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	byPath := make(map[string]domain.FileRef)
+	for _, f := range files {
+		byPath[f.Path] = f
+		if f.Index < 2 || f.BytesCompleted != f.Length {
+			t.Fatalf("extracted file = %+v", f)
+		}
+	}
+	if len(files) != 2 || byPath["Movie/movie.mkv"].Length != 5 || byPath["Movie/evil.txt"].Length != 9 {
+		t.Fatalf("extracted = %+v", files)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "Movie", "readme.txt")); string(data) != "from torrent" {
+		t.Fatalf("torrent file overwritten: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatalf("entry escaped the archive directory")
+	}
+
+	// A second run reuses the recorded files.
+	again, err := uc.Execute(context.Background(), "t1")
+	if err != nil || len(again) != 2 || repo.setCalls != 1 {
+		t.Fatalf("second run = %+v, %v (set calls %d)", again, err, repo.setCalls)
+	}
+}
+
+func TestUnpackKeepsGoodArchivesOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	writeTestZip(t, filepath.Join(dir, "a.zip"), map[string]string{"a.mkv": "aaa"})
+	if err := os.WriteFile(filepath.Join(dir, "b.zip"), []byte("not a zip"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	repo := &fakeUnpackRepo{fakeRepoWithGet: fakeRepoWithGet{getRecord: domain.TorrentRecord{
+		ID: "t1",
+		Files: []domain.FileRef{
+			{Index: 0, Path: "a.zip"},
+			{Index: 1, Path: "b.zip"},
+		},
+	}}}
+	uc := &Unpack{Repo: repo, Extracted: repo, DataDir: dir}
+
+	uc.HandleEvent(context.Background(), domain.Event{Type: domain.EventCompleted, TorrentID: "t1"})
+	uc.Wait()
+
+	got := repo.getRecord.Extracted
+	if len(got) != 1 || got[0].Path != "a.mkv" || got[0].Index != 2 {
+		t.Fatalf("extracted = %+v", got)
+	}
+}
+
+func TestUnpackRetriesFailedArchives(t *testing.T) {
+	dir := t.TempDir()
+	writeTestZip(t, filepath.Join(dir, "a.zip"), map[string]string{"a.mkv": "aaa"})
+	if err := os.WriteFile(filepath.Join(dir, "b.zip"), []byte("not a zip"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	repo := &fakeUnpackRepo{fakeRepoWithGet: fakeRepoWithGet{getRecord: domain.TorrentRecord{
+		ID: "t1",
+		Files: []domain.FileRef{
+			{Index: 0, Path: "a.zip"},
+			{Index: 1, Path: "b.zip"},
+		},
+	}}}
+	uc := &Unpack{Repo: repo, Extracted: repo, DataDir: dir}
+
+	if _, err := uc.Execute(context.Background(), "t1"); err == nil {
+		t.Fatalf("expected an error for b.zip")
+	}
+	if repo.getRecord.Unpacked || len(repo.getRecord.Extracted) != 1 {
+		t.Fatalf("after partial failure: %+v", repo.getRecord)
+	}
+
+	writeTestZip(t, filepath.Join(dir, "b.zip"), map[string]string{"b.mkv": "bb"})
+	files, err := uc.Execute(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if !repo.getRecord.Unpacked || len(files) != 2 || files[0].Path != "a.mkv" || files[0].Index != 2 || files[1].Path != "b.mkv" || files[1].Index != 3 {
+		t.Fatalf("after retry: %+v", files)
+	}
+}
+
+func TestUnpackReservesSpace(t *testing.T) {
+	dir := t.TempDir()
+	writeTestZip(t, filepath.Join(dir, "a.zip"), map[string]string{"a.mkv": "aaaa"})
+	repo := &fakeUnpackRepo{fakeRepoWithGet: fakeRepoWithGet{getRecord: domain.TorrentRecord{
+		ID:    "t1",
+		Files: []domain.FileRef{{Index: 0, Path: "a.zip", Length: 100, BytesCompleted: 100}},
+	}}}
+	uc := &Unpack{Repo: repo, Extracted: repo, DataDir: dir, Space: &DiskSpace{diskFreeFunc: fixedFree(3)}}
+
+	if _, err := uc.Execute(context.Background(), "t1"); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.mkv")); !os.IsNotExist(err) {
+		t.Fatalf("file extracted without space")
+	}
+}
+
+func TestWriteExtractedFileStopsPastLimit(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "a.mkv")
+	if _, err := writeExtractedFile(dst, strings.NewReader("abcd"), 3); !errors.Is(err, errUnpackTooLarge) {
+		t.Fatalf("err = %v, want errUnpackTooLarge", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("partial file left behind")
+	}
+	if _, err := os.Stat(dst + ".unpacking"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file left behind")
+	}
+}
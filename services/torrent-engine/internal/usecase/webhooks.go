@@ -0,0 +1,345 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+const (
+	defaultWebhookAttempts = 5
+	defaultWebhookBackoff  = 5 * time.Second
+	maxWebhookBackoff      = 5 * time.Minute
+	webhookTimeout         = 10 * time.Second
+	// defaultDeliveryLimit is the number of deliveries listed by default.
+	defaultDeliveryLimit = 50
+)
+
+// WebhookStore persists webhooks and their delivery log.
+type WebhookStore interface {
+	ListWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	// GetWebhook returns domain.ErrNotFound for an unknown id.
+	GetWebhook(ctx context.Context, id string) (domain.Webhook, error)
+	SaveWebhook(ctx context.Context, hook domain.Webhook) error
+	// DeleteWebhook removes the webhook and its deliveries; it returns
+	// domain.ErrNotFound for an unknown id.
+	DeleteWebhook(ctx context.Context, id string) error
+	AddWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	// ListWebhookDeliveries returns the newest deliveries first.
+	ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error)
+}
+
+// webhookPayload is the JSON body posted to a webhook.
+type webhookPayload struct {
+	DeliveryID  string       `json:"deliveryId"`
+	WebhookID   string       `json:"webhookId"`
+	Event       domain.Event `json:"event"`
+	TorrentName string       `json:"torrentName,omitempty"`
+}
+
+// Webhooks manages webhook configurations and delivers events to them.
+// A failed delivery is retried with exponential backoff; its final outcome
+// is recorded in the delivery log.
+type Webhooks struct {
+	Store WebhookStore
+	// Repo, when set, adds the torrent name to deliveries.
+	Repo   ports.TorrentRepository
+	Client *http.Client
+	Logger *slog.Logger
+	Now    func() time.Time
+	// MaxAttempts bounds the attempts per delivery (default 5).
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for every
+	// further retry (default 5s, at most 5m).
+	Backoff time.Duration
+
+	wg sync.WaitGroup
+}
+
+func (uc *Webhooks) List(ctx context.Context) ([]domain.Webhook, error) {
+	hooks, err := uc.Store.ListWebhooks(ctx)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	return hooks, nil
+}
+
+func (uc *Webhooks) Get(ctx context.Context, id string) (domain.Webhook, error) {
+	hook, err := uc.Store.GetWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Webhook{}, err
+		}
+		return domain.Webhook{}, wrapRepo(err)
+	}
+	return hook, nil
+}
+
+// Create validates and stores a new webhook.
+func (uc *Webhooks) Create(ctx context.Context, hook domain.Webhook) (domain.Webhook, error) {
+	if err := normalizeWebhook(&hook); err != nil {
+		return domain.Webhook{}, err
+	}
+	hook.ID = newID()
+	hook.CreatedAt = uc.now()
+	hook.UpdatedAt = hook.CreatedAt
+	if err := uc.Store.SaveWebhook(ctx, hook); err != nil {
+		return domain.Webhook{}, wrapRepo(err)
+	}
+	return hook, nil
+}
+
+// Update replaces the configuration of an existing webhook.
+func (uc *Webhooks) Update(ctx context.Context, hook domain.Webhook) (domain.Webhook, error) {
+	existing, err := uc.Get(ctx, hook.ID)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	if err := normalizeWebhook(&hook); err != nil {
+		return domain.Webhook{}, err
+	}
+	hook.CreatedAt = existing.CreatedAt
+	hook.UpdatedAt = uc.now()
+	if err := uc.Store.SaveWebhook(ctx, hook); err != nil {
+		return domain.Webhook{}, wrapRepo(err)
+	}
+	return hook, nil
+}
+
+func (uc *Webhooks) Delete(ctx context.Context, id string) error {
+	if err := uc.Store.DeleteWebhook(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+// Deliveries returns the newest deliveries of a webhook.
+func (uc *Webhooks) Deliveries(ctx context.Context, id string, limit int) ([]domain.WebhookDelivery, error) {
+	if _, err := uc.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	deliveries, err := uc.Store.ListWebhookDeliveries(ctx, id, limit)
+	if err != nil {
+		return nil, wrapRepo(err)
+	}
+	return deliveries, nil
+}
+
+// HandleEvent delivers event to every enabled webhook that wants it. The
+// deliveries run in the background.
+func (uc *Webhooks) HandleEvent(ctx context.Context, event domain.Event) {
+	hooks, err := uc.Store.ListWebhooks(ctx)
+	if err != nil {
+		uc.logger().Warn("webhooks: list failed", slog.String("error", err.Error()))
+		return
+	}
+	name := ""
+	for _, hook := range hooks {
+		if !hook.Wants(event.Type) {
+			continue
+		}
+		if name == "" && event.TorrentID != "" && uc.Repo != nil {
+			if record, err := getWithTrash(ctx, uc.Repo, event.TorrentID); err == nil {
+				name = record.Name
+			}
+		}
+		uc.wg.Add(1)
+		go func(hook domain.Webhook) {
+			defer uc.wg.Done()
+			uc.deliver(ctx, hook, event, name)
+		}(hook)
+	}
+}
+
+// Wait blocks until running deliveries are done.
+func (uc *Webhooks) Wait() {
+	uc.wg.Wait()
+}
+
+func (uc *Webhooks) deliver(ctx context.Context, hook domain.Webhook, event domain.Event, name string) {
+	delivery := domain.WebhookDelivery{
+		ID:        newID(),
+		WebhookID: hook.ID,
+		Event:     event,
+		CreatedAt: uc.now(),
+	}
+	body, err := json.Marshal(webhookPayload{DeliveryID: delivery.ID, WebhookID: hook.ID, Event: event, TorrentName: name})
+	if err != nil {
+		return
+	}
+
+	attempts := uc.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultWebhookAttempts
+	}
+	backoff := uc.Backoff
+	if backoff <= 0 {
+		backoff = defaultWebhookBackoff
+	}
+	start := time.Now()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		delivery.Attempts = attempt
+		status, err := uc.post(ctx, hook, delivery.ID, event.Type, body)
+		delivery.StatusCode = status
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if err == nil && status >= 200 && status < 300 {
+			delivery.Success = true
+			break
+		}
+		if err == nil && !retryableStatus(status) {
+			break
+		}
+		if attempt == attempts {
+			break
+		}
+		if !sleepCtx(ctx, backoff) {
+			delivery.Error = ctx.Err().Error()
+			break
+		}
+		backoff = min(backoff*2, maxWebhookBackoff)
+	}
+	delivery.Duration = time.Since(start).Milliseconds()
+
+	if !delivery.Success {
+		uc.logger().Warn("webhooks: delivery failed",
+			slog.String("webhook", hook.ID),
+			slog.String("event", string(event.Type)),
+			slog.Int("attempts", delivery.Attempts),
+			slog.Int("status", delivery.StatusCode),
+			slog.String("error", delivery.Error))
+	}
+	// The log is written even when ctx ended the retries.
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := uc.Store.AddWebhookDelivery(logCtx, delivery); err != nil {
+		uc.logger().Warn("webhooks: record delivery failed", slog.String("error", err.Error()))
+	}
+}
+
+func (uc *Webhooks) post(ctx context.Context, hook domain.Webhook, deliveryID string, eventType domain.EventType, body []byte) (int, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "torrentstream-webhook/1")
+	req.Header.Set("X-Webhook-Event", string(eventType))
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	timestamp := strconv.FormatInt(uc.now().Unix(), 10)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	if hook.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", SignWebhook(hook.Secret, timestamp, body))
+	}
+	client := uc.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, errors.New(domain.RedactText(err.Error()))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the X-Webhook-Signature value of a delivery:
+// "sha256=" followed by the hex HMAC-SHA256 of timestamp, ".", and body
+// keyed with secret. Signing the X-Webhook-Timestamp lets receivers reject
+// replayed deliveries.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryableStatus reports whether a delivery answered with status may
+// succeed later.
+func retryableStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+}
+
+// normalizeWebhook trims and validates the URL and event filter.
+func normalizeWebhook(hook *domain.Webhook) error {
+	hook.Name = strings.TrimSpace(hook.Name)
+	hook.URL = strings.TrimSpace(hook.URL)
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", domain.ErrInvalidWebhook)
+	}
+	events := make([]domain.EventType, 0, len(hook.Events))
+	for _, t := range hook.Events {
+		if !slices.Contains(domain.EventTypes, t) {
+			return fmt.Errorf("%w: unknown event %q", domain.ErrInvalidWebhook, t)
+		}
+		if !slices.Contains(events, t) {
+			events = append(events, t)
+		}
+	}
+	hook.Events = events
+	return nil
+}
+
+// newID returns a random 24-character hex id.
+func newID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func (uc *Webhooks) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func (uc *Webhooks) logger() *slog.Logger {
+	if uc.Logger != nil {
+		return uc.Logger
+	}
+	return slog.Default()
+}
+
+// sleepCtx waits for d and reports false when ctx ends first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
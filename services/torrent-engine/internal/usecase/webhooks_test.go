@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeWebhookStore struct {
+	mu         sync.Mutex
+	hooks      map[string]domain.Webhook
+	deliveries []domain.WebhookDelivery
+}
+
+func newFakeWebhookStore() *fakeWebhookStore {
+	return &fakeWebhookStore{hooks: make(map[string]domain.Webhook)}
+}
+
+func (f *fakeWebhookStore) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]domain.Webhook, 0, len(f.hooks))
+	for _, h := range f.hooks {
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (f *fakeWebhookStore) GetWebhook(ctx context.Context, id string) (domain.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h, ok := f.hooks[id]
+	if !ok {
+		return domain.Webhook{}, domain.ErrNotFound
+	}
+	return h, nil
+}
+
+func (f *fakeWebhookStore) SaveWebhook(ctx context.Context, hook domain.Webhook) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hooks[hook.ID] = hook
+	return nil
+}
+
+func (f *fakeWebhookStore) DeleteWebhook(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.hooks[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(f.hooks, id)
+	return nil
+}
+
+func (f *fakeWebhookStore) AddWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliveries = append(f.deliveries, delivery)
+	return nil
+}
+
+func (f *fakeWebhookStore) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.WebhookDelivery
+	for i := len(f.deliveries) - 1; i >= 0 && len(out) < limit; i-- {
+		if f.deliveries[i].WebhookID == webhookID {
+			out = append(out, f.deliveries[i])
+		}
+	}
+	return out, nil
+}
+
+func TestWebhooksCreateValidates(t *testing.T) {
+	uc := &Webhooks{Store: newFakeWebhookStore()}
+	ctx := context.Background()
+
+	for _, hook := range []domain.Webhook{
+		{URL: "ftp://example.com"},
+		{URL: "/relative"},
+		{URL: "https://example.com", Events: []domain.EventType{"bogus"}},
+	} {
+		if _, err := uc.Create(ctx, hook); !errors.Is(err, domain.ErrInvalidWebhook) {
+			t.Fatalf("Create(%+v) error = %v, want ErrInvalidWebhook", hook, err)
+		}
+	}
+
+	created, err := uc.Create(ctx, domain.Webhook{
+		URL:     " https://example.com/hook ",
+		Events:  []domain.EventType{domain.EventCompleted, domain.EventCompleted},
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == "" || created.URL != "https://example.com/hook" || len(created.Events) != 1 {
+		t.Fatalf("created = %+v", created)
+	}
+
+	created.Name = "renamed"
+	updated, err := uc.Update(ctx, created)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !updated.CreatedAt.Equal(created.CreatedAt) || updated.Name != "renamed" {
+		t.Fatalf("updated = %+v", updated)
+	}
+	if _, err := uc.Update(ctx, domain.Webhook{ID: "missing", URL: "https://example.com"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Update(missing) error = %v", err)
+	}
+	if err := uc.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := uc.Delete(ctx, created.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("second Delete error = %v", err)
+	}
+}
+
+func TestWebhooksDeliverSignedPayload(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: body}
+	}))
+	defer srv.Close()
+
+	store := newFakeWebhookStore()
+	uc := &Webhooks{
+		Store: store,
+		Repo:  &fakeRepoWithGet{getRecord: domain.TorrentRecord{ID: "t1", Name: "Big Buck Bunny"}},
+		Now:   func() time.Time { return time.Unix(1700000000, 0) },
+	}
+	ctx := context.Background()
+	hook, err := uc.Create(ctx, domain.Webhook{
+		URL:     srv.URL,
+		Events:  []domain.EventType{domain.EventCompleted},
+		Secret:  "s3cret",
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Filtered out: no request is made.
+	uc.HandleEvent(ctx, domain.Event{Type: domain.EventTorrentAdded, TorrentID: "t1"})
+	uc.HandleEvent(ctx, domain.Event{Type: domain.EventCompleted, TorrentID: "t1"})
+	uc.Wait()
+
+	req := <-got
+	if ts := req.header.Get("X-Webhook-Timestamp"); ts != "1700000000" {
+		t.Fatalf("timestamp header = %q", ts)
+	}
+	sig := req.header.Get("X-Webhook-Signature")
+	if sig != SignWebhook("s3cret", "1700000000", req.body) {
+		t.Fatalf("signature = %q", sig)
+	}
+	// The timestamp is signed, so a delivery replayed with a new one fails.
+	if sig == SignWebhook("s3cret", "1700000600", req.body) {
+		t.Fatal("signature does not cover the timestamp")
+	}
+	if req.header.Get("X-Webhook-Event") != string(domain.EventCompleted) {
+		t.Fatalf("event header = %q", req.header.Get("X-Webhook-Event"))
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.WebhookID != hook.ID || payload.Event.TorrentID != "t1" || payload.TorrentName != "Big Buck Bunny" {
+		t.Fatalf("payload = %+v", payload)
+	}
+	if len(got) != 0 {
+		t.Fatalf("filtered event was delivered")
+	}
+
+	deliveries, err := uc.Deliveries(ctx, hook.ID, 0)
+	if err != nil {
+		t.Fatalf("Deliveries: %v", err)
+	}
+	if len(deliveries) != 1 || !deliveries[0].Success || deliveries[0].Attempts != 1 || deliveries[0].StatusCode != http.StatusOK {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+}
+
+func TestWebhooksRetryWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store := newFakeWebhookStore()
+	uc := &Webhooks{Store: store, Backoff: time.Millisecond}
+	ctx := context.Background()
+	hook, err := uc.Create(ctx, domain.Webhook{URL: srv.URL, Enabled: true})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	uc.HandleEvent(ctx, domain.Event{Type: domain.EventError, TorrentID: "t1"})
+	uc.Wait()
+
+	deliveries, _ := uc.Deliveries(ctx, hook.ID, 10)
+	if len(deliveries) != 1 || !deliveries[0].Success || deliveries[0].Attempts != 3 {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+}
+
+func TestWebhooksClientErrorIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	store := newFakeWebhookStore()
+	uc := &Webhooks{Store: store, Backoff: time.Millisecond}
+	ctx := context.Background()
+	hook, _ := uc.Create(ctx, domain.Webhook{URL: srv.URL, Enabled: true})
+	disabled, _ := uc.Create(ctx, domain.Webhook{URL: srv.URL})
+	uc.HandleEvent(ctx, domain.Event{Type: domain.EventDiskPressure, Path: "/data", FreeBytes: 1})
+	uc.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+	deliveries, _ := uc.Deliveries(ctx, hook.ID, 10)
+	if len(deliveries) != 1 || deliveries[0].Success || deliveries[0].StatusCode != http.StatusGone {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+	if deliveries, _ := uc.Deliveries(ctx, disabled.ID, 10); len(deliveries) != 0 {
+		t.Fatalf("disabled webhook delivered: %+v", deliveries)
+	}
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"torrentstream/internal/domain"
+	"torrentstream/internal/domain/ports"
+)
+
+// WebSeeds manages the BEP 19 web seeds of a torrent. User-added seeds are
+// stored on the record so they are re-attached when the session is
+// reopened; seeds from the magnet or metainfo come back with the source.
+type WebSeeds struct {
+	Engine ports.WebSeedEngine
+	Repo   ports.TorrentRepository
+	Now    func() time.Time
+}
+
+// List returns the torrent's web seeds. Statistics are only available while
+// the session is open; stored seeds of a closed session are listed with
+// zero counters.
+func (uc WebSeeds) List(ctx context.Context, id domain.TorrentID) ([]domain.WebSeed, error) {
+	record, err := uc.getRecord(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds, err := uc.Engine.WebSeeds(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, wrapEngine(err)
+	}
+	listed := make(map[string]struct{}, len(seeds))
+	for _, ws := range seeds {
+		listed[ws.URL] = struct{}{}
+	}
+	for _, url := range record.Source.WebSeeds {
+		if _, ok := listed[url]; !ok {
+			seeds = append(seeds, domain.WebSeed{URL: url})
+		}
+	}
+	if seeds == nil {
+		seeds = []domain.WebSeed{}
+	}
+	return seeds, nil
+}
+
+// Add validates urls, stores the new ones on the record and attaches them to
+// the open session.
+func (uc WebSeeds) Add(ctx context.Context, id domain.TorrentID, urls []string) ([]domain.WebSeed, error) {
+	normalized, err := normalizeWebSeeds(urls)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) == 0 {
+		return nil, domain.ErrInvalidWebSeed
+	}
+
+	record, err := uc.getRecord(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := normalizeWebSeeds(append(record.Source.WebSeeds, normalized...))
+	if err != nil {
+		return nil, err
+	}
+	if len(merged) != len(record.Source.WebSeeds) {
+		record.Source.WebSeeds = merged
+		record.UpdatedAt = uc.now()
+		if err := uc.Repo.Update(ctx, record); err != nil {
+			return nil, wrapRepo(err)
+		}
+	}
+
+	if err := uc.Engine.AddWebSeeds(ctx, id, normalized); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, wrapEngine(err)
+	}
+	return uc.List(ctx, id)
+}
+
+// Remove detaches url from the torrent. Seeds that come from the magnet or
+// metainfo can be removed from the running session but return when the
+// torrent is loaded again.
+func (uc WebSeeds) Remove(ctx context.Context, id domain.TorrentID, url string) error {
+	record, err := uc.getRecord(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	stored := false
+	kept := make([]string, 0, len(record.Source.WebSeeds))
+	for _, u := range record.Source.WebSeeds {
+		if u == url {
+			stored = true
+			continue
+		}
+		kept = append(kept, u)
+	}
+
+	engineErr := uc.Engine.RemoveWebSeed(ctx, id, url)
+	if engineErr != nil && !errors.Is(engineErr, domain.ErrNotFound) {
+		return wrapEngine(engineErr)
+	}
+	if !stored {
+		if engineErr != nil {
+			return domain.ErrNotFound
+		}
+		return nil
+	}
+
+	if len(kept) == 0 {
+		kept = nil
+	}
+	record.Source.WebSeeds = kept
+	record.UpdatedAt = uc.now()
+	if err := uc.Repo.Update(ctx, record); err != nil {
+		return wrapRepo(err)
+	}
+	return nil
+}
+
+func (uc WebSeeds) getRecord(ctx context.Context, id domain.TorrentID) (domain.TorrentRecord, error) {
+	record, err := uc.Repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.TorrentRecord{}, err
+		}
+		return domain.TorrentRecord{}, wrapRepo(err)
+	}
+	return record, nil
+}
+
+func (uc WebSeeds) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now()
+}
+
+// normalizeWebSeeds validates urls and drops blanks and duplicates, keeping
+// the original order.
+func normalizeWebSeeds(urls []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]struct{}, len(urls))
+	for _, raw := range urls {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		url, err := domain.NormalizeWebSeedURL(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[url]; ok {
+			continue
+		}
+		seen[url] = struct{}{}
+		out = append(out, url)
+	}
+	return out, nil
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"torrentstream/internal/domain"
+)
+
+type fakeWebSeedEngine struct {
+	seeds     []domain.WebSeed
+	closed    bool
+	added     []string
+	removed   []string
+	removeErr error
+}
+
+func (f *fakeWebSeedEngine) AddWebSeeds(ctx context.Context, id domain.TorrentID, urls []string) error {
+	if f.closed {
+		return domain.ErrNotFound
+	}
+	f.added = append(f.added, urls...)
+	for _, u := range urls {
+		f.seeds = append(f.seeds, domain.WebSeed{URL: u})
+	}
+	return nil
+}
+
+func (f *fakeWebSeedEngine) RemoveWebSeed(ctx context.Context, id domain.TorrentID, url string) error {
+	if f.closed {
+		return domain.ErrNotFound
+	}
+	f.removed = append(f.removed, url)
+	return f.removeErr
+}
+
+func (f *fakeWebSeedEngine) WebSeeds(ctx context.Context, id domain.TorrentID) ([]domain.WebSeed, error) {
+	if f.closed {
+		return nil, domain.ErrNotFound
+	}
+	return append([]domain.WebSeed(nil), f.seeds...), nil
+}
+
+func TestWebSeedsListMergesStoredSeeds(t *testing.T) {
+	engine := &fakeWebSeedEngine{seeds: []domain.WebSeed{{URL: "http://meta/", BytesDownloaded: 42}}}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:     "t1",
+		Source: domain.TorrentSource{WebSeeds: []string{"http://meta/", "http://user/"}},
+	}}
+	uc := WebSeeds{Engine: engine, Repo: repo}
+
+	seeds, err := uc.List(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(seeds) != 2 || seeds[0].BytesDownloaded != 42 || seeds[1].URL != "http://user/" {
+		t.Fatalf("seeds = %+v", seeds)
+	}
+
+	engine.closed = true
+	seeds, err = uc.List(context.Background(), "t1")
+	if err != nil || len(seeds) != 2 {
+		t.Fatalf("closed session: seeds = %+v, err = %v", seeds, err)
+	}
+}
+
+func TestWebSeedsAddStoresAndAttaches(t *testing.T) {
+	engine := &fakeWebSeedEngine{}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:     "t1",
+		Source: domain.TorrentSource{Magnet: "magnet:?xt=urn:btih:abc", WebSeeds: []string{"http://a/"}},
+	}}
+	uc := WebSeeds{Engine: engine, Repo: repo}
+
+	if _, err := uc.Add(context.Background(), "t1", []string{" http://b/ ", "http://a/", "http://b/"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if repo.updateCalls != 1 {
+		t.Fatalf("updateCalls = %d, want 1", repo.updateCalls)
+	}
+	if got := repo.updated.Source.WebSeeds; len(got) != 2 || got[0] != "http://a/" || got[1] != "http://b/" {
+		t.Fatalf("stored seeds = %v", got)
+	}
+	if len(engine.added) != 2 {
+		t.Fatalf("engine added = %v", engine.added)
+	}
+}
+
+func TestWebSeedsAddRejectsInvalidURL(t *testing.T) {
+	repo := &fakeControlRepo{get: domain.TorrentRecord{ID: "t1"}}
+	uc := WebSeeds{Engine: &fakeWebSeedEngine{}, Repo: repo}
+
+	for _, urls := range [][]string{{"magnet:?xt=urn:btih:abc"}, {""}} {
+		if _, err := uc.Add(context.Background(), "t1", urls); !errors.Is(err, domain.ErrInvalidWebSeed) {
+			t.Fatalf("Add(%v) err = %v, want ErrInvalidWebSeed", urls, err)
+		}
+	}
+	if repo.updateCalls != 0 {
+		t.Fatalf("record must not be updated")
+	}
+}
+
+func TestWebSeedsAddWhileSessionClosed(t *testing.T) {
+	repo := &fakeControlRepo{get: domain.TorrentRecord{ID: "t1", Status: domain.TorrentStopped}}
+	uc := WebSeeds{Engine: &fakeWebSeedEngine{closed: true}, Repo: repo}
+
+	if _, err := uc.Add(context.Background(), "t1", []string{"ftp://mirror/pub/"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if len(repo.updated.Source.WebSeeds) != 1 {
+		t.Fatalf("seed not stored: %+v", repo.updated.Source)
+	}
+}
+
+func TestWebSeedsRemove(t *testing.T) {
+	engine := &fakeWebSeedEngine{removeErr: domain.ErrNotFound}
+	repo := &fakeControlRepo{get: domain.TorrentRecord{
+		ID:     "t1",
+		Source: domain.TorrentSource{WebSeeds: []string{"http://a/", "http://b/"}},
+	}}
+	uc := WebSeeds{Engine: engine, Repo: repo}
+
+	if err := uc.Remove(context.Background(), "t1", "http://a/"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if got := repo.updated.Source.WebSeeds; len(got) != 1 || got[0] != "http://b/" {
+		t.Fatalf("stored seeds = %v", got)
+	}
+
+	if err := uc.Remove(context.Background(), "t1", "http://unknown/"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreateTorrentStoresWebSeeds(t *testing.T) {
+	session := &fakeSession{id: "t1", files: []domain.FileRef{{Index: 0, Path: "f.bin", Length: 1}}}
+	engine := &fakeEngine{returnedSession: session}
+	repo := &fakeRepo{}
+	uc := CreateTorrent{Engine: engine, Repo: repo}
+
+	record, err := uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{
+		Magnet:   "magnet:?xt=urn:btih:abc",
+		WebSeeds: []string{"http://mirror/data/", "http://mirror/data/"},
+	}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(record.Source.WebSeeds) != 1 || len(engine.openSource.WebSeeds) != 1 {
+		t.Fatalf("web seeds not passed through: record %v, engine %v", record.Source.WebSeeds, engine.openSource.WebSeeds)
+	}
+
+	_, err = uc.Execute(context.Background(), CreateTorrentInput{Source: domain.TorrentSource{
+		Magnet:   "magnet:?xt=urn:btih:abc",
+		WebSeeds: []string{"not a url"},
+	}})
+	if !errors.Is(err, domain.ErrInvalidWebSeed) {
+		t.Fatalf("err = %v, want ErrInvalidWebSeed", err)
+	}
+}
@@ -58,6 +58,7 @@ func main() {
 		slog.String("flareSolverrURL", strings.TrimSpace(cfg.FlareSolverrURL)),
 		slog.Bool("hasRedis", strings.TrimSpace(cfg.RedisURL) != ""),
 		slog.Bool("hasTMDBKey", strings.TrimSpace(cfg.TMDBAPIKey) != ""),
+		slog.Bool("torznabEnabled", cfg.TorznabAPIKey != ""),
 		slog.Duration("cacheTTL", cfg.CacheTTL),
 	)
 
@@ -118,6 +119,12 @@ func main() {
 	if tmdbClient != nil && tmdbClient.Enabled() {
 		serverOpts = append(serverOpts, apihttp.WithTMDB(tmdbClient))
 	}
+	if cfg.TorznabAPIKey != "" {
+		serverOpts = append(serverOpts,
+			apihttp.WithTorznab(cfg.TorznabAPIKey),
+			apihttp.WithTorznabDefaultQuery(cfg.TorznabDefaultQuery),
+		)
+	}
 
 	handler := apihttp.NewServer(searchService, serverOpts...).Handler()
 	server := &http.Server{
@@ -63,7 +63,7 @@ func loggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
 			slog.String("clientIP", clientIP(r)),
 		}
 		if rawQuery := strings.TrimSpace(r.URL.RawQuery); rawQuery != "" {
-			attrs = append(attrs, slog.String("query", truncate(rawQuery, 180)))
+			attrs = append(attrs, slog.String("query", truncate(redactQuery(rawQuery), 180)))
 		}
 		if userAgent := strings.TrimSpace(r.UserAgent()); userAgent != "" {
 			attrs = append(attrs, slog.String("userAgent", truncate(userAgent, 120)))
@@ -112,6 +112,8 @@ func normalizeRoute(path string) string {
 		return path
 	case path == "/search" || path == "/search/stream" || path == "/search/suggest" || path == "/search/image":
 		return path
+	case path == torznabPath:
+		return path
 	case strings.HasPrefix(path, "/search/providers"):
 		return "/search/providers"
 	case strings.HasPrefix(path, "/search/settings"):
@@ -48,6 +48,9 @@ type Server struct {
 	settings ProviderSettingsService
 	tmdb     TMDBSuggestService
 	logger   *slog.Logger
+
+	torznabAPIKey       string
+	torznabDefaultQuery string
 }
 
 type providerAutodetectResult struct {
@@ -110,6 +113,7 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/search/suggest", s.handleSearchSuggest)
 	mux.HandleFunc("/search/image", s.handleImageProxy)
 	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc(torznabPath, s.handleTorznab)
 	traced := otelhttp.NewHandler(loggingMiddleware(s.logger, mux), "torrent-search",
 		otelhttp.WithFilter(func(r *http.Request) bool {
 			p := r.URL.Path
@@ -0,0 +1,550 @@
+package apihttp
+
+import (
+	"crypto/subtle"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"torrentstream/searchservice/internal/domain"
+	"torrentstream/searchservice/internal/providers/common"
+	"torrentstream/searchservice/internal/search"
+)
+
+const (
+	torznabPath         = "/api"
+	torznabNamespace    = "http://torznab.com/schemas/2015/feed"
+	torznabDefaultLimit = 100
+	torznabMaxLimit     = 200
+	// torznabDefaultQuery is searched for requests without q, which
+	// Sonarr, Radarr and Prowlarr send for RSS sync and indexer tests.
+	torznabDefaultQuery = "1080p"
+)
+
+// Torznab error codes as defined by the newznab API.
+const (
+	torznabErrCredentials  = 100
+	torznabErrMissingParam = 200
+	torznabErrInvalidParam = 201
+	torznabErrNoSuchFunc   = 202
+	torznabErrNotAvailable = 203
+	torznabErrUnknown      = 900
+)
+
+// Standard newznab category ids advertised in caps.
+const (
+	torznabCategoryMovies   = 2000
+	torznabCategoryMoviesSD = 2030
+	torznabCategoryMoviesHD = 2040
+	torznabCategoryMovies4K = 2045
+	torznabCategoryTV       = 5000
+	torznabCategoryTVSD     = 5030
+	torznabCategoryTVHD     = 5040
+	torznabCategoryTV4K     = 5045
+	torznabCategoryTVAnime  = 5070
+)
+
+// WithTorznab exposes search results as a Torznab indexer on /api, guarded
+// by apiKey. The endpoint stays disabled when apiKey is empty.
+func WithTorznab(apiKey string) ServerOption {
+	return func(s *Server) {
+		s.torznabAPIKey = strings.TrimSpace(apiKey)
+		if s.torznabDefaultQuery == "" {
+			s.torznabDefaultQuery = torznabDefaultQuery
+		}
+	}
+}
+
+// WithTorznabDefaultQuery sets the query searched, newest first, for
+// Torznab requests without q. An empty query keeps the default.
+func WithTorznabDefaultQuery(query string) ServerOption {
+	return func(s *Server) {
+		if query = strings.TrimSpace(query); query != "" {
+			s.torznabDefaultQuery = query
+		}
+	}
+}
+
+type torznabError struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+type torznabCaps struct {
+	XMLName    xml.Name              `xml:"caps"`
+	Server     torznabCapsServer     `xml:"server"`
+	Limits     torznabCapsLimits     `xml:"limits"`
+	Searching  torznabCapsSearching  `xml:"searching"`
+	Categories torznabCapsCategories `xml:"categories"`
+}
+
+type torznabCapsServer struct {
+	Title string `xml:"title,attr"`
+}
+
+type torznabCapsLimits struct {
+	Default int `xml:"default,attr"`
+	Max     int `xml:"max,attr"`
+}
+
+type torznabCapsSearching struct {
+	Search      torznabCapsFunction `xml:"search"`
+	TVSearch    torznabCapsFunction `xml:"tv-search"`
+	MovieSearch torznabCapsFunction `xml:"movie-search"`
+}
+
+type torznabCapsFunction struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type torznabCapsCategories struct {
+	Categories []torznabCapsCategory `xml:"category"`
+}
+
+type torznabCapsCategory struct {
+	ID     int                   `xml:"id,attr"`
+	Name   string                `xml:"name,attr"`
+	Subcat []torznabCapsCategory `xml:"subcat,omitempty"`
+}
+
+type torznabRSS struct {
+	XMLName      xml.Name       `xml:"rss"`
+	Version      string         `xml:"version,attr"`
+	TorznabXMLNS string         `xml:"xmlns:torznab,attr"`
+	Channel      torznabChannel `xml:"channel"`
+}
+
+type torznabChannel struct {
+	Title       string        `xml:"title"`
+	Description string        `xml:"description"`
+	Items       []torznabItem `xml:"item"`
+}
+
+type torznabItem struct {
+	Title       string           `xml:"title"`
+	GUID        torznabGUID      `xml:"guid"`
+	Link        string           `xml:"link"`
+	Comments    string           `xml:"comments,omitempty"`
+	PubDate     string           `xml:"pubDate"`
+	Size        int64            `xml:"size"`
+	Description string           `xml:"description,omitempty"`
+	Categories  []int            `xml:"category"`
+	Enclosure   torznabEnclosure `xml:"enclosure"`
+	Attrs       []torznabAttr    `xml:"torznab:attr"`
+}
+
+type torznabGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type torznabEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type torznabAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func (s *Server) handleTorznab(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != torznabPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.torznabAPIKey == "" {
+		writeTorznabError(w, http.StatusNotImplemented, torznabErrNotAvailable, "torznab endpoint is not configured")
+		return
+	}
+	if !s.torznabAuthorized(r) {
+		writeTorznabError(w, http.StatusUnauthorized, torznabErrCredentials, "Incorrect user credentials")
+		return
+	}
+
+	function := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("t")))
+	switch function {
+	case "caps":
+		writeTorznabXML(w, http.StatusOK, buildTorznabCaps())
+	case "search", "tvsearch", "movie":
+		s.handleTorznabSearch(w, r, function)
+	case "":
+		writeTorznabError(w, http.StatusBadRequest, torznabErrMissingParam, "Missing parameter (t)")
+	default:
+		writeTorznabError(w, http.StatusBadRequest, torznabErrNoSuchFunc, "No such function ("+truncate(function, 40)+")")
+	}
+}
+
+func (s *Server) torznabAuthorized(r *http.Request) bool {
+	key := strings.TrimSpace(r.URL.Query().Get("apikey"))
+	if key == "" {
+		key = strings.TrimSpace(r.Header.Get("X-Api-Key"))
+	}
+	return key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.torznabAPIKey)) == 1
+}
+
+func (s *Server) handleTorznabSearch(w http.ResponseWriter, r *http.Request, function string) {
+	if s.search == nil {
+		writeTorznabError(w, http.StatusInternalServerError, torznabErrUnknown, "search service is not configured")
+		return
+	}
+	params := r.URL.Query()
+	limit, err := parsePositiveInt(r, "limit", torznabDefaultLimit)
+	if err != nil {
+		writeTorznabError(w, http.StatusBadRequest, torznabErrInvalidParam, "Incorrect parameter (limit)")
+		return
+	}
+	if limit > torznabMaxLimit {
+		limit = torznabMaxLimit
+	}
+	offset, err := parseNonNegativeInt(r, "offset", 0)
+	if err != nil {
+		writeTorznabError(w, http.StatusBadRequest, torznabErrInvalidParam, "Incorrect parameter (offset)")
+		return
+	}
+	categories, err := parseTorznabCategories(params.Get("cat"))
+	if err != nil {
+		writeTorznabError(w, http.StatusBadRequest, torznabErrInvalidParam, "Incorrect parameter (cat)")
+		return
+	}
+
+	query := torznabQuery(function, params)
+	if len(query) > maxQueryLength {
+		writeTorznabError(w, http.StatusBadRequest, torznabErrInvalidParam, "Incorrect parameter (q)")
+		return
+	}
+	feed := torznabRSS{
+		Version:      "2.0",
+		TorznabXMLNS: torznabNamespace,
+		Channel: torznabChannel{
+			Title:       "TorrX",
+			Description: "TorrX aggregated search",
+			Items:       []torznabItem{},
+		},
+	}
+	// Providers have no "latest releases" listing, so requests without a
+	// query (RSS sync, indexer tests) get the newest results of the
+	// default query; an empty feed makes the clients reject the indexer.
+	sortBy := domain.SearchSortByRelevance
+	if query == "" {
+		query = s.torznabDefaultQuery
+		sortBy = domain.SearchSortByPublished
+	}
+
+	profile := domain.DefaultSearchRankingProfile()
+	switch function {
+	case "tvsearch":
+		profile.PreferMovies = false
+	case "movie":
+		profile.PreferSeries = false
+	}
+	response, err := s.search.Search(r.Context(), domain.SearchRequest{
+		Query:   query,
+		Limit:   limit,
+		Offset:  offset,
+		SortBy:  sortBy,
+		Profile: profile,
+	}, nil)
+	if err != nil {
+		s.logger.Warn("torznab search failed",
+			slog.String("function", function),
+			slog.String("query", truncate(query, 80)),
+			slog.String("error", err.Error()),
+		)
+		switch {
+		case errors.Is(err, search.ErrInvalidQuery), errors.Is(err, search.ErrInvalidOffset):
+			writeTorznabError(w, http.StatusBadRequest, torznabErrInvalidParam, err.Error())
+		case errors.Is(err, search.ErrNoProviders):
+			writeTorznabError(w, http.StatusServiceUnavailable, torznabErrNotAvailable, err.Error())
+		default:
+			writeTorznabError(w, http.StatusInternalServerError, torznabErrUnknown, "search failed")
+		}
+		return
+	}
+
+	for _, result := range response.Items {
+		item, ok := buildTorznabItem(result, function)
+		if !ok || !matchesTorznabCategories(item.Categories, categories) {
+			continue
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	s.logger.Info("torznab search completed",
+		slog.String("function", function),
+		slog.String("query", truncate(query, 80)),
+		slog.Int("items", len(feed.Channel.Items)),
+		slog.Int64("elapsedMs", response.ElapsedMS),
+	)
+	writeTorznabXML(w, http.StatusOK, feed)
+}
+
+// torznabQuery folds the structured tvsearch/movie parameters into a plain
+// text query, which is all the aggregated providers understand.
+func torznabQuery(function string, params url.Values) string {
+	query := strings.TrimSpace(params.Get("q"))
+	if query == "" {
+		return ""
+	}
+	switch function {
+	case "tvsearch":
+		season := strings.TrimSpace(params.Get("season"))
+		episode := strings.TrimSpace(params.Get("ep"))
+		seasonNum, seasonErr := strconv.Atoi(season)
+		episodeNum, episodeErr := strconv.Atoi(episode)
+		switch {
+		case seasonErr == nil && episodeErr == nil:
+			query += fmt.Sprintf(" S%02dE%02d", seasonNum, episodeNum)
+		case seasonErr == nil && episode == "":
+			query += fmt.Sprintf(" S%02d", seasonNum)
+		default:
+			// Daily shows send the air date split over season and ep.
+			for _, part := range []string{season, episode} {
+				if part != "" {
+					query += " " + part
+				}
+			}
+		}
+	case "movie":
+		if year := strings.TrimSpace(params.Get("year")); year != "" && !strings.Contains(query, year) {
+			query += " " + year
+		}
+	}
+	return query
+}
+
+func parseTorznabCategories(raw string) ([]int, error) {
+	parts := parseCSV(raw)
+	out := make([]int, 0, len(parts))
+	for _, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value <= 0 {
+			return nil, errors.New("invalid category")
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
+
+func matchesTorznabCategories(itemCategories, wanted []int) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, category := range itemCategories {
+		for _, value := range wanted {
+			if category == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// torznabCategories maps a result onto a parent category and, when the
+// resolution is known, a subcategory. tvsearch and movie requests force the
+// parent category because title detection falls back to "movie".
+func torznabCategories(result domain.SearchResult, function string) []int {
+	contentType := strings.ToLower(strings.TrimSpace(result.Enrichment.ContentType))
+	if contentType == "anime" && function != "movie" {
+		return []int{torznabCategoryTV, torznabCategoryTVAnime}
+	}
+	series := result.Enrichment.IsSeries || contentType == "series"
+	switch function {
+	case "tvsearch":
+		series = true
+	case "movie":
+		series = false
+	}
+
+	quality := strings.ToLower(result.Enrichment.Quality)
+	tier := 0
+	switch {
+	case strings.Contains(quality, "2160p"):
+		tier = 3
+	case strings.Contains(quality, "1440p"), strings.Contains(quality, "1080p"), strings.Contains(quality, "720p"):
+		tier = 2
+	case strings.Contains(quality, "480p"):
+		tier = 1
+	}
+
+	parent, subcats := torznabCategoryMovies, []int{torznabCategoryMoviesSD, torznabCategoryMoviesHD, torznabCategoryMovies4K}
+	if series {
+		parent, subcats = torznabCategoryTV, []int{torznabCategoryTVSD, torznabCategoryTVHD, torznabCategoryTV4K}
+	}
+	if tier == 0 {
+		return []int{parent}
+	}
+	return []int{parent, subcats[tier-1]}
+}
+
+func buildTorznabItem(result domain.SearchResult, function string) (torznabItem, bool) {
+	title := strings.TrimSpace(result.Name)
+	infoHash := common.NormalizeInfoHash(result.InfoHash)
+	magnet := strings.TrimSpace(result.Magnet)
+	if magnet == "" {
+		magnet = common.BuildMagnet(infoHash, title, nil)
+	}
+	if title == "" || magnet == "" {
+		return torznabItem{}, false
+	}
+	guid := infoHash
+	if guid == "" {
+		guid = magnet
+	}
+	publishedAt := time.Now().UTC()
+	if result.PublishedAt != nil && !result.PublishedAt.IsZero() {
+		publishedAt = result.PublishedAt.UTC()
+	}
+	categories := torznabCategories(result, function)
+
+	item := torznabItem{
+		Title:      title,
+		GUID:       torznabGUID{Value: guid},
+		Link:       magnet,
+		Comments:   strings.TrimSpace(result.PageURL),
+		PubDate:    publishedAt.Format(time.RFC1123Z),
+		Size:       result.SizeBytes,
+		Categories: categories,
+		Enclosure: torznabEnclosure{
+			URL:    magnet,
+			Length: result.SizeBytes,
+			Type:   "application/x-bittorrent",
+		},
+	}
+	attr := func(name, value string) {
+		item.Attrs = append(item.Attrs, torznabAttr{Name: name, Value: value})
+	}
+	for _, category := range categories {
+		attr("category", strconv.Itoa(category))
+	}
+	attr("size", strconv.FormatInt(result.SizeBytes, 10))
+	attr("seeders", strconv.Itoa(result.Seeders))
+	attr("leechers", strconv.Itoa(result.Leechers))
+	attr("peers", strconv.Itoa(result.Seeders+result.Leechers))
+	if infoHash != "" {
+		attr("infohash", infoHash)
+	}
+	attr("magneturl", magnet)
+	attr("downloadvolumefactor", "1")
+	attr("uploadvolumefactor", "1")
+
+	enrichment := result.Enrichment
+	if enrichment.Year > 0 {
+		attr("year", strconv.Itoa(enrichment.Year))
+	}
+	if enrichment.Season > 0 {
+		attr("season", strconv.Itoa(enrichment.Season))
+	}
+	if enrichment.Episode > 0 {
+		attr("episode", strconv.Itoa(enrichment.Episode))
+	}
+	if enrichment.TMDBId > 0 {
+		attr("tmdbid", strconv.Itoa(enrichment.TMDBId))
+	}
+	if enrichment.Dubbing.Type != domain.DubbingUnknown {
+		attr("tag", string(enrichment.Dubbing.Type))
+	}
+	for _, group := range torznabDubbingGroups(enrichment.Dubbing) {
+		attr("tag", group)
+	}
+	item.Description = torznabDescription(enrichment)
+	return item, true
+}
+
+func torznabDubbingGroups(info domain.DubbingInfo) []string {
+	groups := make([]string, 0, len(info.Groups)+1)
+	seen := make(map[string]struct{}, len(info.Groups)+1)
+	for _, group := range append([]string{info.Group}, info.Groups...) {
+		group = strings.TrimSpace(group)
+		key := strings.ToLower(group)
+		if group == "" {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// torznabDescription surfaces dubbing and audio details, which the *arr
+// clients otherwise cannot see for Russian releases.
+func torznabDescription(enrichment domain.SearchEnrichment) string {
+	parts := make([]string, 0, 3)
+	if enrichment.Dubbing.Type != domain.DubbingUnknown {
+		dubbing := "Dubbing: " + string(enrichment.Dubbing.Type)
+		if groups := torznabDubbingGroups(enrichment.Dubbing); len(groups) > 0 {
+			dubbing += " (" + strings.Join(groups, ", ") + ")"
+		}
+		parts = append(parts, dubbing)
+	}
+	if len(enrichment.Audio) > 0 {
+		parts = append(parts, "Audio: "+strings.Join(enrichment.Audio, ", "))
+	}
+	if len(enrichment.Subtitles) > 0 {
+		parts = append(parts, "Subtitles: "+strings.Join(enrichment.Subtitles, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func buildTorznabCaps() torznabCaps {
+	return torznabCaps{
+		Server: torznabCapsServer{Title: "TorrX"},
+		Limits: torznabCapsLimits{Default: torznabDefaultLimit, Max: torznabMaxLimit},
+		Searching: torznabCapsSearching{
+			Search:      torznabCapsFunction{Available: "yes", SupportedParams: "q"},
+			TVSearch:    torznabCapsFunction{Available: "yes", SupportedParams: "q,season,ep"},
+			MovieSearch: torznabCapsFunction{Available: "yes", SupportedParams: "q,year"},
+		},
+		Categories: torznabCapsCategories{Categories: []torznabCapsCategory{
+			{ID: torznabCategoryMovies, Name: "Movies", Subcat: []torznabCapsCategory{
+				{ID: torznabCategoryMoviesSD, Name: "Movies/SD"},
+				{ID: torznabCategoryMoviesHD, Name: "Movies/HD"},
+				{ID: torznabCategoryMovies4K, Name: "Movies/UHD"},
+			}},
+			{ID: torznabCategoryTV, Name: "TV", Subcat: []torznabCapsCategory{
+				{ID: torznabCategoryTVSD, Name: "TV/SD"},
+				{ID: torznabCategoryTVHD, Name: "TV/HD"},
+				{ID: torznabCategoryTV4K, Name: "TV/UHD"},
+				{ID: torznabCategoryTVAnime, Name: "TV/Anime"},
+			}},
+		}},
+	}
+}
+
+func writeTorznabXML(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(payload)
+}
+
+func writeTorznabError(w http.ResponseWriter, status, code int, description string) {
+	writeTorznabXML(w, status, torznabError{Code: code, Description: description})
+}
+
+// redactQuery hides the Torznab API key before a query string is logged.
+func redactQuery(rawQuery string) string {
+	if !strings.Contains(strings.ToLower(rawQuery), "apikey") {
+		return rawQuery
+	}
+	// ParseQuery keeps every well-formed pair even when it reports an error;
+	// a malformed apikey pair is dropped from the output entirely.
+	values, _ := url.ParseQuery(rawQuery)
+	values.Set("apikey", "REDACTED")
+	return values.Encode()
+}
@@ -0,0 +1,268 @@
+package apihttp
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"torrentstream/searchservice/internal/domain"
+)
+
+type torznabSearchService struct {
+	fakeSearchService
+	items []domain.SearchResult
+}
+
+func (f *torznabSearchService) Search(ctx context.Context, request domain.SearchRequest, providers []string) (domain.SearchResponse, error) {
+	_ = ctx
+	f.callCount++
+	f.lastProviders = append([]string(nil), providers...)
+	f.lastRequest = request
+	return domain.SearchResponse{
+		Query:      request.Query,
+		Items:      f.items,
+		TotalItems: len(f.items),
+		Limit:      request.Limit,
+		Offset:     request.Offset,
+	}, nil
+}
+
+func newTorznabSearchService() *torznabSearchService {
+	publishedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &torznabSearchService{items: []domain.SearchResult{
+		{
+			Name:        "The Show S01E02 1080p WEB-DL",
+			InfoHash:    "ABCDEF0123456789ABCDEF0123456789ABCDEF01",
+			PageURL:     "https://rutracker.org/forum/viewtopic.php?t=1",
+			SizeBytes:   1 << 30,
+			Seeders:     40,
+			Leechers:    5,
+			PublishedAt: &publishedAt,
+			Enrichment: domain.SearchEnrichment{
+				Quality:     "1080p WEB-DL",
+				IsSeries:    true,
+				Season:      1,
+				Episode:     2,
+				ContentType: "series",
+				Audio:       []string{"ru", "en"},
+				Dubbing: domain.DubbingInfo{
+					Type:   domain.DubbingMultiVoice,
+					Group:  "LostFilm",
+					Groups: []string{"LostFilm", "NewStudio"},
+				},
+			},
+		},
+		{
+			Name:      "The Movie 2024 2160p",
+			Magnet:    "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=The+Movie",
+			SizeBytes: 4 << 30,
+			Seeders:   3,
+			Enrichment: domain.SearchEnrichment{
+				Quality:     "2160p",
+				Year:        2024,
+				ContentType: "movie",
+			},
+		},
+		{Name: "No links at all"},
+	}}
+}
+
+func doTorznabRequest(server *Server, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+type torznabTestFeed struct {
+	Items []struct {
+		Title      string `xml:"title"`
+		GUID       string `xml:"guid"`
+		Link       string `xml:"link"`
+		PubDate    string `xml:"pubDate"`
+		Categories []int  `xml:"category"`
+		Enclosure  struct {
+			URL    string `xml:"url,attr"`
+			Length int64  `xml:"length,attr"`
+		} `xml:"enclosure"`
+		Description string `xml:"description"`
+		Attrs       []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"http://torznab.com/schemas/2015/feed attr"`
+	} `xml:"channel>item"`
+}
+
+func decodeTorznabFeed(t *testing.T, rec *httptest.ResponseRecorder) torznabTestFeed {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var feed torznabTestFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("decode feed: %v\n%s", err, rec.Body.String())
+	}
+	return feed
+}
+
+func TestTorznabRequiresAPIKey(t *testing.T) {
+	server := NewServer(newTorznabSearchService())
+	rec := doTorznabRequest(server, "/api?t=caps&apikey=secret")
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 when not configured, got %d", rec.Code)
+	}
+
+	server = NewServer(newTorznabSearchService(), WithTorznab("secret"))
+	for _, target := range []string{"/api?t=caps", "/api?t=caps&apikey=wrong"} {
+		rec = doTorznabRequest(server, target)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401, got %d", target, rec.Code)
+		}
+		var payload torznabError
+		if err := xml.Unmarshal(rec.Body.Bytes(), &payload); err != nil || payload.Code != torznabErrCredentials {
+			t.Fatalf("%s: unexpected error payload %q (%v)", target, rec.Body.String(), err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api?t=caps", nil)
+	req.Header.Set("X-Api-Key", "secret")
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected header key to be accepted, got %d", rec.Code)
+	}
+}
+
+func TestTorznabCaps(t *testing.T) {
+	server := NewServer(newTorznabSearchService(), WithTorznab("secret"))
+	rec := doTorznabRequest(server, "/api?t=caps&apikey=secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	var caps torznabCaps
+	if err := xml.Unmarshal(rec.Body.Bytes(), &caps); err != nil {
+		t.Fatalf("decode caps: %v", err)
+	}
+	if caps.Searching.TVSearch.SupportedParams != "q,season,ep" || caps.Searching.MovieSearch.Available != "yes" {
+		t.Fatalf("unexpected searching caps: %+v", caps.Searching)
+	}
+	if len(caps.Categories.Categories) != 2 || caps.Categories.Categories[1].ID != torznabCategoryTV {
+		t.Fatalf("unexpected categories: %+v", caps.Categories)
+	}
+}
+
+func TestTorznabTVSearch(t *testing.T) {
+	service := newTorznabSearchService()
+	server := NewServer(service, WithTorznab("secret"))
+	rec := doTorznabRequest(server, "/api?t=tvsearch&apikey=secret&q=The+Show&season=1&ep=2&cat=5040&limit=20&offset=5")
+
+	if service.lastRequest.Query != "The Show S01E02" {
+		t.Fatalf("unexpected query %q", service.lastRequest.Query)
+	}
+	if service.lastRequest.Limit != 20 || service.lastRequest.Offset != 5 || service.lastRequest.Profile.PreferMovies {
+		t.Fatalf("unexpected request %+v", service.lastRequest)
+	}
+	feed := decodeTorznabFeed(t, rec)
+	if len(feed.Items) != 1 {
+		t.Fatalf("expected only the HD episode to match cat=5040, got %d items", len(feed.Items))
+	}
+	item := feed.Items[0]
+	if item.GUID != "abcdef0123456789abcdef0123456789abcdef01" || !strings.HasPrefix(item.Link, "magnet:?xt=urn:btih:abcdef") {
+		t.Fatalf("unexpected guid/link %q %q", item.GUID, item.Link)
+	}
+	if item.Enclosure.URL != item.Link || item.Enclosure.Length != 1<<30 {
+		t.Fatalf("unexpected enclosure %+v", item.Enclosure)
+	}
+	if item.PubDate != "Sun, 01 Mar 2026 12:00:00 +0000" {
+		t.Fatalf("unexpected pubDate %q", item.PubDate)
+	}
+	if len(item.Categories) != 2 || item.Categories[0] != torznabCategoryTV || item.Categories[1] != torznabCategoryTVHD {
+		t.Fatalf("unexpected categories %v", item.Categories)
+	}
+	if item.Description != "Dubbing: многоголос (LostFilm, NewStudio); Audio: ru, en" {
+		t.Fatalf("unexpected description %q", item.Description)
+	}
+
+	attrs := map[string][]string{}
+	for _, attr := range item.Attrs {
+		attrs[attr.Name] = append(attrs[attr.Name], attr.Value)
+	}
+	for name, want := range map[string]string{"seeders": "40", "peers": "45", "size": "1073741824", "season": "1", "episode": "2"} {
+		if got := attrs[name]; len(got) != 1 || got[0] != want {
+			t.Fatalf("attr %s = %v, want %s", name, got, want)
+		}
+	}
+	if got := strings.Join(attrs["tag"], ","); got != "многоголос,LostFilm,NewStudio" {
+		t.Fatalf("unexpected tags %q", got)
+	}
+}
+
+func TestTorznabMovieSearch(t *testing.T) {
+	service := newTorznabSearchService()
+	server := NewServer(service, WithTorznab("secret"))
+	rec := doTorznabRequest(server, "/api?t=movie&apikey=secret&q=The+Movie&year=2024")
+
+	if service.lastRequest.Query != "The Movie 2024" || service.lastRequest.Profile.PreferSeries {
+		t.Fatalf("unexpected request %+v", service.lastRequest)
+	}
+	feed := decodeTorznabFeed(t, rec)
+	if len(feed.Items) != 2 {
+		t.Fatalf("expected results without links to be skipped, got %d items", len(feed.Items))
+	}
+	if got := feed.Items[1].Categories; len(got) != 2 || got[0] != torznabCategoryMovies || got[1] != torznabCategoryMovies4K {
+		t.Fatalf("unexpected categories %v", got)
+	}
+}
+
+func TestTorznabSearchWithoutQueryReturnsRecentResults(t *testing.T) {
+	service := newTorznabSearchService()
+	server := NewServer(service, WithTorznab("secret"))
+	feed := decodeTorznabFeed(t, doTorznabRequest(server, "/api?t=search&apikey=secret&cat=2000"))
+	if service.lastRequest.Query != torznabDefaultQuery || service.lastRequest.SortBy != domain.SearchSortByPublished {
+		t.Fatalf("unexpected request %+v", service.lastRequest)
+	}
+	if len(feed.Items) != 1 || feed.Items[0].Title != "The Movie 2024 2160p" {
+		t.Fatalf("expected the movie matching cat=2000, got %+v", feed.Items)
+	}
+
+	server = NewServer(service, WithTorznabDefaultQuery(" WEB-DL "), WithTorznab("secret"))
+	feed = decodeTorznabFeed(t, doTorznabRequest(server, "/api?t=tvsearch&apikey=secret&season=1"))
+	if service.lastRequest.Query != "WEB-DL" || len(feed.Items) != 2 {
+		t.Fatalf("unexpected request %+v with %d items", service.lastRequest, len(feed.Items))
+	}
+}
+
+func TestTorznabInvalidRequests(t *testing.T) {
+	server := NewServer(newTorznabSearchService(), WithTorznab("secret"))
+	for target, code := range map[string]int{
+		"/api?apikey=secret":                     torznabErrMissingParam,
+		"/api?t=music&apikey=secret":             torznabErrNoSuchFunc,
+		"/api?t=search&apikey=secret&cat=movies": torznabErrInvalidParam,
+		"/api?t=search&apikey=secret&limit=-1":   torznabErrInvalidParam,
+	} {
+		rec := doTorznabRequest(server, target)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, rec.Code)
+		}
+		var payload torznabError
+		if err := xml.Unmarshal(rec.Body.Bytes(), &payload); err != nil || payload.Code != code {
+			t.Fatalf("%s: expected code %d, got %q", target, code, rec.Body.String())
+		}
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	if got := redactQuery("t=search&apikey=secret&q=x"); strings.Contains(got, "secret") || !strings.Contains(got, "apikey=REDACTED") {
+		t.Fatalf("apikey not redacted: %q", got)
+	}
+	if got := redactQuery("q=a+b&limit=5"); got != "q=a+b&limit=5" {
+		t.Fatalf("query without apikey changed: %q", got)
+	}
+}
@@ -25,27 +25,32 @@ type Config struct {
 	CacheTTL          time.Duration
 	CacheDisabled     bool
 	TMDBCacheTTL      time.Duration
+	TorznabAPIKey     string
+	// TorznabDefaultQuery is searched for Torznab requests without q.
+	TorznabDefaultQuery string
 }
 
 func LoadConfig() Config {
 	return Config{
-		HTTPAddr:          getEnv("HTTP_ADDR", ":8090"),
-		RequestTimeout:    time.Duration(getEnvInt("SEARCH_TIMEOUT_SECONDS", 15)) * time.Second,
-		LogLevel:          strings.ToLower(getEnv("LOG_LEVEL", "info")),
-		LogFormat:         strings.ToLower(getEnv("LOG_FORMAT", "text")),
-		UserAgent:         getEnv("SEARCH_USER_AGENT", "torrent-stream-search/1.0"),
-		PirateBayEndpoint: getEnv("SEARCH_PROVIDER_PIRATEBAY_ENDPOINT", getEnv("SEARCH_PROVIDER_BITTORRENT_ENDPOINT", "https://apibay.org/q.php")),
-		X1337Endpoint:     getEnv("SEARCH_PROVIDER_1337X_ENDPOINT", "https://x1337x.ws,https://1337x.to,https://1377x.to"),
-		RutrackerEndpoint: getEnv("SEARCH_PROVIDER_RUTRACKER_ENDPOINT", "https://rutracker.org/forum/tracker.php"),
-		RutrackerCookies:  buildRutrackerCookies(),
-		RutrackerProxyURL: getEnv("SEARCH_PROVIDER_RUTRACKER_PROXY", ""),
-		FlareSolverrURL:   normalizeFlareSolverrURL(getEnv("FLARESOLVERR_URL", "http://flaresolverr:8191/")),
-		RedisURL:          getEnv("REDIS_URL", ""),
-		TMDBAPIKey:        strings.TrimSpace(os.Getenv("TMDB_API_KEY")),
-		TMDBBaseURL:       getEnv("TMDB_BASE_URL", "https://api.themoviedb.org/3"),
-		CacheTTL:          time.Duration(getEnvInt("SEARCH_CACHE_TTL_HOURS", 6)) * time.Hour,
-		CacheDisabled:     getEnvBool("SEARCH_CACHE_DISABLED", false),
-		TMDBCacheTTL:      time.Duration(getEnvInt("TMDB_CACHE_TTL_DAYS", 7)) * 24 * time.Hour,
+		HTTPAddr:            getEnv("HTTP_ADDR", ":8090"),
+		RequestTimeout:      time.Duration(getEnvInt("SEARCH_TIMEOUT_SECONDS", 15)) * time.Second,
+		LogLevel:            strings.ToLower(getEnv("LOG_LEVEL", "info")),
+		LogFormat:           strings.ToLower(getEnv("LOG_FORMAT", "text")),
+		UserAgent:           getEnv("SEARCH_USER_AGENT", "torrent-stream-search/1.0"),
+		PirateBayEndpoint:   getEnv("SEARCH_PROVIDER_PIRATEBAY_ENDPOINT", getEnv("SEARCH_PROVIDER_BITTORRENT_ENDPOINT", "https://apibay.org/q.php")),
+		X1337Endpoint:       getEnv("SEARCH_PROVIDER_1337X_ENDPOINT", "https://x1337x.ws,https://1337x.to,https://1377x.to"),
+		RutrackerEndpoint:   getEnv("SEARCH_PROVIDER_RUTRACKER_ENDPOINT", "https://rutracker.org/forum/tracker.php"),
+		RutrackerCookies:    buildRutrackerCookies(),
+		RutrackerProxyURL:   getEnv("SEARCH_PROVIDER_RUTRACKER_PROXY", ""),
+		FlareSolverrURL:     normalizeFlareSolverrURL(getEnv("FLARESOLVERR_URL", "http://flaresolverr:8191/")),
+		RedisURL:            getEnv("REDIS_URL", ""),
+		TMDBAPIKey:          strings.TrimSpace(os.Getenv("TMDB_API_KEY")),
+		TMDBBaseURL:         getEnv("TMDB_BASE_URL", "https://api.themoviedb.org/3"),
+		CacheTTL:            time.Duration(getEnvInt("SEARCH_CACHE_TTL_HOURS", 6)) * time.Hour,
+		CacheDisabled:       getEnvBool("SEARCH_CACHE_DISABLED", false),
+		TMDBCacheTTL:        time.Duration(getEnvInt("TMDB_CACHE_TTL_DAYS", 7)) * 24 * time.Hour,
+		TorznabAPIKey:       strings.TrimSpace(os.Getenv("SEARCH_TORZNAB_API_KEY")),
+		TorznabDefaultQuery: getEnv("SEARCH_TORZNAB_DEFAULT_QUERY", ""),
 	}
 }
 
@@ -5,13 +5,27 @@ import (
 	"strings"
 )
 
+// sha256Multihash is the multihash prefix of the SHA-256 digest in a
+// BitTorrent v2 (urn:btmh:) info hash.
+const sha256Multihash = "1220"
+
+// NormalizeInfoHash lowercases an info hash and strips its URN prefix.
+// BitTorrent v2 hashes are reduced to the 64-character hex digest.
 func NormalizeInfoHash(raw string) string {
-	value := strings.TrimSpace(raw)
-	value = strings.TrimPrefix(strings.ToLower(value), "urn:btih:")
-	if value == "" {
-		return ""
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if multihash, ok := strings.CutPrefix(value, "urn:btmh:"); ok {
+		if digest, ok := strings.CutPrefix(multihash, sha256Multihash); ok && len(digest) == 64 {
+			return digest
+		}
+		return multihash
 	}
-	return value
+	return strings.TrimPrefix(value, "urn:btih:")
+}
+
+// IsV2InfoHash reports whether a normalized info hash is a BitTorrent v2
+// (SHA-256) hash.
+func IsV2InfoHash(hash string) bool {
+	return len(hash) == 64
 }
 
 func BuildMagnet(infoHash, name string, trackers []string) string {
@@ -20,7 +34,11 @@ func BuildMagnet(infoHash, name string, trackers []string) string {
 		return ""
 	}
 	var builder strings.Builder
-	builder.WriteString("magnet:?xt=urn:btih:")
+	if IsV2InfoHash(hash) {
+		builder.WriteString("magnet:?xt=urn:btmh:" + sha256Multihash)
+	} else {
+		builder.WriteString("magnet:?xt=urn:btih:")
+	}
 	builder.WriteString(hash)
 	if strings.TrimSpace(name) != "" {
 		builder.WriteString("&dn=")
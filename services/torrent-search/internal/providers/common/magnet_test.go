@@ -24,6 +24,8 @@ func TestNormalizeInfoHash(t *testing.T) {
 		{"whitespace only", "   ", ""},
 		{"whitespace around hash", "  abcdef1234567890  ", "abcdef1234567890"},
 		{"urn:btih: only", "urn:btih:", ""},
+		{"v2 multihash", "urn:btmh:1220" + strings.Repeat("AB", 32), strings.Repeat("ab", 32)},
+		{"v2 other hash function", "urn:btmh:1114abcd", "1114abcd"},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -49,6 +51,14 @@ func TestBuildMagnetBasic(t *testing.T) {
 	}
 }
 
+func TestBuildMagnetV2(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	magnet := BuildMagnet(hash, "Test", nil)
+	if !strings.HasPrefix(magnet, "magnet:?xt=urn:btmh:1220"+hash) {
+		t.Fatalf("unexpected magnet: %s", magnet)
+	}
+}
+
 func TestBuildMagnetWithTrackers(t *testing.T) {
 	trackers := []string{"udp://tracker1:1337", "udp://tracker2:6969"}
 	magnet := BuildMagnet("abcdef1234567890", "Test", trackers)
@@ -595,7 +595,17 @@ func extractInfoHashFromMagnet(rawMagnet string) string {
 	if err != nil {
 		return ""
 	}
-	return parsed.Query().Get("xt")
+	// Prefer the v1 hash of hybrid magnets; see search.extractInfoHashFromMagnet.
+	xts := parsed.Query()["xt"]
+	for _, xt := range xts {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(xt)), "urn:btih:") {
+			return xt
+		}
+	}
+	if len(xts) > 0 {
+		return xts[0]
+	}
+	return ""
 }
 
 func parseInt(raw string) int {
@@ -2,12 +2,14 @@ package torznab
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 )
 
 // ExtractInfoHashFromTorrent computes the BitTorrent infohash (SHA1 of the bencoded "info" dict).
-// It returns a lowercase hex string.
+// It returns a lowercase hex string. Hybrid torrents yield their v1 hash; v2-only torrents,
+// which have a "meta version" but no "pieces", yield the SHA-256 v2 hash.
 func ExtractInfoHashFromTorrent(payload []byte) (string, error) {
 	start, end, ok, err := findTopLevelInfoValue(payload)
 	if err != nil {
@@ -16,10 +18,37 @@ func ExtractInfoHashFromTorrent(payload []byte) (string, error) {
 	if !ok {
 		return "", errors.New("missing info dictionary")
 	}
-	sum := sha1.Sum(payload[start:end])
+	info := payload[start:end]
+	if keys, err := dictKeys(info); err == nil && keys["meta version"] && !keys["pieces"] {
+		sum := sha256.Sum256(info)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	sum := sha1.Sum(info)
 	return hex.EncodeToString(sum[:]), nil
 }
 
+// dictKeys returns the keys of a bencoded dictionary.
+func dictKeys(dict []byte) (map[string]bool, error) {
+	if len(dict) == 0 || dict[0] != 'd' {
+		return nil, errors.New("invalid bencode: expected dict")
+	}
+	keys := make(map[string]bool)
+	i := 1
+	for i < len(dict) && dict[i] != 'e' {
+		key, next, err := parseBencodeString(dict, i)
+		if err != nil {
+			return nil, err
+		}
+		end, err := skipBencodeValue(dict, next)
+		if err != nil {
+			return nil, err
+		}
+		keys[string(key)] = true
+		i = end
+	}
+	return keys, nil
+}
+
 func findTopLevelInfoValue(payload []byte) (start int, end int, ok bool, err error) {
 	if len(payload) == 0 || payload[0] != 'd' {
 		return 0, 0, false, errors.New("invalid torrent: expected top-level dict")
@@ -2,6 +2,7 @@ package torznab
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"testing"
 )
@@ -23,3 +24,32 @@ func TestExtractInfoHashFromTorrent(t *testing.T) {
 		t.Fatalf("expected %q, got %q", want, got)
 	}
 }
+
+func TestExtractInfoHashFromV2Torrent(t *testing.T) {
+	v2Info := []byte("d9:file treed4:testd0:d6:lengthi1e11:pieces root32:" +
+		"0123456789abcdef0123456789abcdefeee12:meta versioni2e4:name4:test12:piece lengthi16384ee")
+	payload := append([]byte("d4:info"), v2Info...)
+	payload = append(payload, 'e')
+
+	got, err := ExtractInfoHashFromTorrent(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sum := sha256.Sum256(v2Info)
+	if want := hex.EncodeToString(sum[:]); got != want {
+		t.Fatalf("v2-only: expected %q, got %q", want, got)
+	}
+
+	// A hybrid torrent keeps its v1 hash.
+	hybridInfo := []byte("d12:meta versioni2e4:name4:test12:piece lengthi16384e6:pieces20:01234567890123456789e")
+	payload = append([]byte("d4:info"), hybridInfo...)
+	payload = append(payload, 'e')
+	got, err = ExtractInfoHashFromTorrent(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v1 := sha1.Sum(hybridInfo)
+	if want := hex.EncodeToString(v1[:]); got != want {
+		t.Fatalf("hybrid: expected %q, got %q", want, got)
+	}
+}
@@ -180,12 +180,23 @@ var cyrillicToLatin = map[rune]string{
 	'\u0448': "sh", '\u0449': "sch", '\u044b': "y", '\u044d': "e", '\u044e': "yu", '\u044f': "ya", '\u044c': "", '\u044a': "",
 }
 
+// normalizeInfoHash lowercases an info hash and strips its URN prefix.
+// BitTorrent v2 hashes (urn:btmh:) are reduced to the hex SHA-256 digest.
 func normalizeInfoHash(raw string) string {
 	value := strings.TrimSpace(strings.ToLower(raw))
+	if multihash, ok := strings.CutPrefix(value, "urn:btmh:"); ok {
+		if digest, ok := strings.CutPrefix(multihash, "1220"); ok && len(digest) == 64 {
+			return digest
+		}
+		return multihash
+	}
 	value = strings.TrimPrefix(value, "urn:btih:")
 	return value
 }
 
+// extractInfoHashFromMagnet returns the info hash of a magnet link. Hybrid
+// magnets carry a v1 and a v2 hash; the v1 hash wins so a hybrid result
+// dedupes with v1-only listings of the same torrent.
 func extractInfoHashFromMagnet(rawMagnet string) string {
 	value := strings.TrimSpace(rawMagnet)
 	if value == "" {
@@ -195,13 +206,21 @@ func extractInfoHashFromMagnet(rawMagnet string) string {
 	if err != nil {
 		return ""
 	}
+	fallback := ""
 	for _, xt := range parsed.Query()["xt"] {
 		hash := normalizeInfoHash(xt)
-		if hash != "" {
-			return hash
+		if hash == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(xt)), "urn:btmh:") {
+			if fallback == "" {
+				fallback = hash
+			}
+			continue
 		}
+		return hash
 	}
-	return ""
+	return fallback
 }
 
 func buildTitleDedupeKey(item domain.SearchResult) string {
@@ -766,6 +766,7 @@ func TestNormalizeInfoHash(t *testing.T) {
 		{"urn:btih:ABCDEF1234", "abcdef1234"},
 		{"", ""},
 		{" ABCDEF1234 ", "abcdef1234"},
+		{"urn:btmh:1220" + strings.Repeat("AB", 32), strings.Repeat("ab", 32)},
 	}
 	for _, tc := range cases {
 		got := normalizeInfoHash(tc.input)
@@ -785,6 +786,8 @@ func TestExtractInfoHashFromMagnet(t *testing.T) {
 		{"", ""},
 		{"not a magnet", ""},
 		{"magnet:?dn=Test", ""},
+		{"magnet:?xt=urn:btmh:1220" + strings.Repeat("cd", 32), strings.Repeat("cd", 32)},
+		{"magnet:?xt=urn:btmh:1220" + strings.Repeat("cd", 32) + "&xt=urn:btih:ABC123", "abc123"},
 	}
 	for _, tc := range cases {
 		got := extractInfoHashFromMagnet(tc.input)